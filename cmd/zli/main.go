@@ -4,13 +4,26 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
 
 	cli "zotregistry.dev/zot/pkg/cli/client"
+	"zotregistry.dev/zot/pkg/extensions/tracing"
 )
 
 func main() {
-	if err := cli.NewCliRootCmd().Execute(); err != nil {
-		os.Exit(1)
+	shutdownTracing, err := tracing.EnableCLITracing()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to enable tracing: %v\n", err)
+	}
+
+	err = cli.NewCliRootCmd().Execute()
+
+	// the spans are sent before exiting
+	_ = shutdownTracing(context.Background())
+
+	if err != nil {
+		os.Exit(cli.ExitCode(err))
 	}
 }
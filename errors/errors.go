@@ -123,6 +123,7 @@ var (
 	ErrTimeout                        = errors.New("operation timeout")
 	ErrNotImplemented                 = errors.New("not implemented")
 	ErrDedupeRebuild                  = errors.New("couldn't rebuild dedupe index")
+	ErrTooManyLinks                   = errors.New("too many hard links to the blob")
 	ErrMissingAuthHeader              = errors.New("required authorization header is missing")
 	ErrUserAPIKeyNotFound             = errors.New("user info for given API key hash not found")
 	ErrUserSessionNotFound            = errors.New("user session for given ID not found")
@@ -168,4 +169,32 @@ var (
 	ErrAPINotSupported                = errors.New("registry at the given address doesn't implement the correct API")
 	ErrURLNotFound                    = errors.New("url not found")
 	ErrInvalidSearchQuery             = errors.New("invalid search query")
+	ErrImageLabelsMismatch            = errors.New("image labels don't match the given selectors")
+	ErrInvalidBearerChallenge         = errors.New("bearer challenge doesn't have a valid realm")
+	ErrNoBearerToken                  = errors.New("token server didn't return a token")
+	ErrRegistrySearchFailed           = errors.New("search failed on some of the registries")
+	ErrPartialResults                 = errors.New("some of the images couldn't be fetched")
+	ErrSearchInterrupted              = errors.New("the search was interrupted")
+	ErrSBOMNotFound                   = errors.New("no sbom found for the image")
+	ErrOCILayoutNotFound              = errors.New("no oci layout found in the directory")
+	ErrPlatformNotFound               = errors.New("the image has no manifest for the platform")
+	ErrNotADirectory                  = errors.New("not a directory")
+	ErrNoPEMCertificate               = errors.New("no PEM encoded certificate found")
+	ErrBadHTPasswdEntry               = errors.New("not a 'user:hash' htpasswd entry")
+	ErrWebhookFailed                  = errors.New("webhook didn't accept the event")
+	ErrActivityQueueFull              = errors.New("repository activity queue is full")
+	ErrQuotaExceeded                  = errors.New("storage quota exceeded")
+	ErrImageNotTrusted                = errors.New("image is not signed by a trusted key")
+	ErrImmutableTag                   = errors.New("tag is immutable")
+	ErrReadOnly                       = errors.New("registry is read-only")
+	ErrAddressNotAllowed              = errors.New("client address is not allowed")
+	ErrInvalidBundle                  = errors.New("invalid image bundle")
+	ErrCompressionNotSupported        = errors.New("compression format not supported")
+	ErrInvalidKeyring                 = errors.New("invalid encryption keyring")
+	ErrEncryptionKeyNotFound          = errors.New("encryption key not found in the keyring")
+	ErrNotReady                       = errors.New("registry is not ready")
+	ErrImageVerificationFailed        = errors.New("image content doesn't match its descriptors")
+	ErrImagesNotUpToDate              = errors.New("some of the images differ from the ones they're checked against")
+	ErrPaginationLoop                 = errors.New("pagination links don't end")
+	ErrPromotionRefused               = errors.New("image doesn't pass the checks of the promotion policy")
 )
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/log"
+	mTypes "zotregistry.dev/zot/pkg/meta/types"
+)
+
+// the events recorded while the activity log of MetaDB can't keep up are dropped once the queue is full.
+const activityQueueSize = 1000
+
+type activityEntry struct {
+	repo      string
+	event     mTypes.RepoEvent
+	maxEvents int
+	maxAge    time.Duration
+}
+
+// activityRecorder adds the events to the activity logs of the repos in the background, so the requests,
+// e.g. the pulls, don't wait for the updates of MetaDB.
+type activityRecorder struct {
+	metaDB  mTypes.MetaDB
+	queue   chan activityEntry
+	stopped bool
+	lock    sync.RWMutex
+	wg      sync.WaitGroup
+	log     log.Logger
+}
+
+func newActivityRecorder(metaDB mTypes.MetaDB, log log.Logger) *activityRecorder {
+	recorder := &activityRecorder{
+		metaDB: metaDB,
+		queue:  make(chan activityEntry, activityQueueSize),
+		log:    log,
+	}
+
+	recorder.wg.Add(1)
+
+	go func() {
+		defer recorder.wg.Done()
+
+		recorder.run()
+	}()
+
+	return recorder
+}
+
+// Record queues the event, it's dropped if the queue is full or the recorder is stopped.
+func (recorder *activityRecorder) Record(repo string, event mTypes.RepoEvent, maxEvents int,
+	maxAge time.Duration,
+) {
+	recorder.lock.RLock()
+	defer recorder.lock.RUnlock()
+
+	if recorder.stopped {
+		return
+	}
+
+	select {
+	case recorder.queue <- activityEntry{repo: repo, event: event, maxEvents: maxEvents, maxAge: maxAge}:
+	default:
+		recorder.log.Error().Err(zerr.ErrActivityQueueFull).Str("repository", repo).Str("reference", event.Reference).
+			Str("type", event.Type).Msg("failed to record repository activity, dropping the event")
+	}
+}
+
+// Stop records the events already queued and waits for them.
+func (recorder *activityRecorder) Stop() {
+	recorder.lock.Lock()
+
+	if recorder.stopped {
+		recorder.lock.Unlock()
+
+		return
+	}
+
+	recorder.stopped = true
+
+	close(recorder.queue)
+
+	recorder.lock.Unlock()
+
+	recorder.wg.Wait()
+}
+
+func (recorder *activityRecorder) run() {
+	for entry := range recorder.queue {
+		err := recorder.metaDB.AddRepoEvent(entry.repo, entry.event, entry.maxEvents, entry.maxAge)
+		if err != nil {
+			// the request already succeeded, a missing entry in the activity log doesn't fail it
+			recorder.log.Error().Err(err).Str("repository", entry.repo).Str("reference", entry.event.Reference).
+				Str("type", entry.event.Type).Msg("failed to record repository activity")
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"bufio"
 	"context"
 	"crypto/sha256"
 	"crypto/x509"
@@ -18,6 +17,7 @@ import (
 
 	"github.com/chartmuseum/auth"
 	guuid "github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt"
 	"github.com/google/go-github/v52/github"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -46,8 +46,9 @@ const (
 	relyingPartyCookieMaxAge         = 120
 )
 
+var bearerTokenMatch = regexp.MustCompile("(?i)bearer (.*)") //nolint: gochecknoglobals
+
 type AuthnMiddleware struct {
-	credMap    map[string]string
 	ldapClient *LDAPClient
 	log        log.Logger
 }
@@ -110,15 +111,15 @@ func (amw *AuthnMiddleware) basicAuthn(ctlr *Controller, userAc *reqCtx.UserAcce
 		return false, nil
 	}
 
-	passphraseHash, ok := amw.credMap[identity]
+	passphraseHash, ok := ctlr.HTPasswd.Get(identity)
 	if ok {
 		// first, HTTPPassword authN (which is local)
 		if err := bcrypt.CompareHashAndPassword([]byte(passphraseHash), []byte(passphrase)); err == nil {
 			// Process request
 			var groups []string
 
-			if ctlr.Config.HTTP.AccessControl != nil {
-				ac := NewAccessController(ctlr.Config)
+			if ctlr.accessControlConfig() != nil {
+				ac := ctlr.accessController()
 				groups = ac.getUserGroups(identity)
 			}
 
@@ -153,8 +154,8 @@ func (amw *AuthnMiddleware) basicAuthn(ctlr *Controller, userAc *reqCtx.UserAcce
 			// Process request
 			var groups []string
 
-			if ctlr.Config.HTTP.AccessControl != nil {
-				ac := NewAccessController(ctlr.Config)
+			if ctlr.accessControlConfig() != nil {
+				ac := ctlr.accessController()
 				groups = ac.getUserGroups(identity)
 			}
 
@@ -255,8 +256,6 @@ func (amw *AuthnMiddleware) tryAuthnHandlers(ctlr *Controller) mux.MiddlewareFun
 		return noPasswdAuth(ctlr)
 	}
 
-	amw.credMap = make(map[string]string)
-
 	delay := ctlr.Config.HTTP.Auth.FailDelay
 
 	// ldap and htpasswd based authN
@@ -309,22 +308,10 @@ func (amw *AuthnMiddleware) tryAuthnHandlers(ctlr *Controller) mux.MiddlewareFun
 	}
 
 	if ctlr.Config.IsHtpasswdAuthEnabled() {
-		credsFile, err := os.Open(ctlr.Config.HTTP.Auth.HTPasswd.Path)
-		if err != nil {
+		if err := ctlr.HTPasswd.Reload(ctlr.Config.HTTP.Auth.HTPasswd.Path); err != nil {
 			amw.log.Panic().Err(err).Str("credsFile", ctlr.Config.HTTP.Auth.HTPasswd.Path).
 				Msg("failed to open creds-file")
 		}
-		defer credsFile.Close()
-
-		scanner := bufio.NewScanner(credsFile)
-
-		for scanner.Scan() {
-			line := scanner.Text()
-			if strings.Contains(line, ":") {
-				tokens := strings.Split(scanner.Text(), ":")
-				amw.credMap[tokens[0]] = tokens[1]
-			}
-		}
 	}
 
 	// openid based authN
@@ -352,7 +339,8 @@ func (amw *AuthnMiddleware) tryAuthnHandlers(ctlr *Controller) mux.MiddlewareFun
 			}
 
 			isMgmtRequested := request.RequestURI == constants.FullMgmt
-			allowAnonymous := ctlr.Config.HTTP.AccessControl.AnonymousPolicyExists()
+			allowAnonymous := ctlr.accessControlConfig().AnonymousPolicyExists()
+			isPublicPullRequested := isPublicPull(ctlr, request)
 
 			// build user access control info
 			userAc := reqCtx.NewUserAccessControl()
@@ -397,13 +385,15 @@ func (amw *AuthnMiddleware) tryAuthnHandlers(ctlr *Controller) mux.MiddlewareFun
 				}
 
 				// the session header can be present also for anonymous calls
-				if allowAnonymous || isMgmtRequested {
+				if allowAnonymous || isMgmtRequested || isPublicPullRequested {
+					restrictToPublicRepos(ctlr, userAc, request)
 					next.ServeHTTP(response, request)
 
 					return
 				}
-			} else if allowAnonymous || isMgmtRequested {
+			} else if allowAnonymous || isMgmtRequested || isPublicPullRequested {
 				// try anonymous auth only if basic auth/session was not given
+				restrictToPublicRepos(ctlr, userAc, request)
 				next.ServeHTTP(response, request)
 
 				return
@@ -434,7 +424,7 @@ func bearerAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
 
 				return
 			}
-			acCtrlr := NewAccessController(ctlr.Config)
+			acCtrlr := ctlr.accessController()
 			vars := mux.Vars(request)
 			name := vars["name"]
 
@@ -449,11 +439,21 @@ func bearerAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
 				return
 			}
 
+			// the public repositories are pulled without a token, the catalog and /v2/ still require one
+			if isAuthorizationHeaderEmpty(request) && name != "" && isPublicPull(ctlr, request) {
+				next.ServeHTTP(response, request)
+
+				return
+			}
+
 			action := auth.PullAction
 			if m := request.Method; m != http.MethodGet && m != http.MethodHead {
 				action = auth.PushAction
 			}
 
+			// decoded once for the base route, the zot routes and the user of the token
+			token, tokenErr := decodeBearerToken(authorizer, header)
+
 			var permissions *auth.Permission
 
 			// Empty scope should be allowed according to the distribution auth spec
@@ -471,16 +471,11 @@ func bearerAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
 					}
 				} else {
 					// subsequent requests with token on /v2/
-					bearerTokenMatch := regexp.MustCompile("(?i)bearer (.*)")
-
-					signedString := bearerTokenMatch.ReplaceAllString(header, "$1")
-
 					// If the token is valid, our job is done
 					// Since this is the /v2 base path and we didn't pass a scope to the auth header in the previous step
 					// there is no access check to enforce
-					_, err := authorizer.TokenDecoder.DecodeToken(signedString)
-					if err != nil {
-						ctlr.Log.Error().Err(err).Msg("failed to parse Authorization header")
+					if tokenErr != nil {
+						ctlr.Log.Error().Err(tokenErr).Msg("failed to parse Authorization header")
 						response.Header().Set("Content-Type", "application/json")
 						zcommon.WriteJSON(response, http.StatusUnauthorized, apiErr.NewError(apiErr.UNSUPPORTED))
 
@@ -491,6 +486,13 @@ func bearerAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
 						Allowed: true,
 					}
 				}
+			} else if name == "" && strings.HasPrefix(request.URL.Path, constants.RoutePrefix+constants.BasePrefix) &&
+				tokenSubject(token) != "" {
+				// the zot routes without a repository, e.g. the admin ones, accept any valid token having a subject,
+				// whatever its scope: they authorize the user of the token themselves, like the other users
+				permissions = &auth.Permission{
+					Allowed: true,
+				}
 			} else {
 				var err error
 
@@ -514,12 +516,62 @@ func bearerAuthHandler(ctlr *Controller) mux.MiddlewareFunc {
 				return
 			}
 
+			// the token has to allow pulling from the repository a blob is mounted from,
+			// otherwise the mount is ignored and a regular upload is started
+			if query := request.URL.Query(); query.Has("mount") && query.Get("from") != "" {
+				fromPermissions, err := authorizer.Authorize(header, auth.PullAction, query.Get("from"))
+				if err != nil || !fromPermissions.Allowed {
+					query.Del("from")
+					request.URL.RawQuery = query.Encode()
+				}
+			}
+
+			// the user of the token, e.g. issued by the built-in token server, gets its access control back
+			if identity := tokenSubject(token); identity != "" {
+				userAc := reqCtx.NewUserAccessControl()
+				userAc.SetUsername(identity)
+				userAc.AddGroups(acCtrlr.getUserGroups(identity))
+
+				if ctlr.accessControlConfig() != nil {
+					acCtrlr.updateUserAccessControl(userAc)
+				}
+
+				userAc.SaveOnRequest(request)
+			}
+
 			amCtx := acCtrlr.getAuthnMiddlewareContext(BEARER, request)
 			next.ServeHTTP(response, request.WithContext(amCtx)) //nolint:contextcheck
 		})
 	}
 }
 
+// decodeBearerToken returns the token of the Authorization header, nil if it's not valid.
+func decodeBearerToken(authorizer *auth.Authorizer, header string) (*jwt.Token, error) {
+	token, err := authorizer.TokenDecoder.DecodeToken(bearerTokenMatch.ReplaceAllString(header, "$1"))
+	if err != nil {
+		// e.g. the expired tokens are returned along with the error
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// tokenSubject returns the subject of the token, "" if there's no token.
+func tokenSubject(token *jwt.Token) string {
+	if token == nil {
+		return ""
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+
+	subject, _ := claims["sub"].(string)
+
+	return subject
+}
+
 func noPasswdAuth(ctlr *Controller) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
@@ -727,6 +779,41 @@ func isAuthorizationHeaderEmpty(request *http.Request) bool {
 	return false
 }
 
+// isPublicPull returns true if the request pulls from a public repository, or reaches the /v2/ or catalog routes
+// which anonymous users need once some repositories are public.
+func isPublicPull(ctlr *Controller, request *http.Request) bool {
+	if len(ctlr.publicRepositories()) == 0 ||
+		(request.Method != http.MethodGet && request.Method != http.MethodHead) {
+		return false
+	}
+
+	if name, ok := mux.Vars(request)["name"]; ok {
+		return ctlr.accessController().isPublic(name)
+	}
+
+	return request.URL.Path == constants.RoutePrefix+"/" ||
+		request.URL.Path == constants.RoutePrefix+constants.ExtCatalogPrefix
+}
+
+// restrictToPublicRepos lets anonymous users read only the public repositories, e.g. in the catalog,
+// when there's no access control config to set their permissions.
+func restrictToPublicRepos(ctlr *Controller, userAc *reqCtx.UserAccessControl, request *http.Request) {
+	publicRepositories := ctlr.publicRepositories()
+
+	if len(publicRepositories) == 0 || ctlr.accessControlConfig() != nil {
+		return
+	}
+
+	publicRepos := make(map[string]bool)
+	for _, pattern := range publicRepositories {
+		publicRepos[pattern] = true
+	}
+
+	userAc.SetGlobPatterns(constants.ReadPermission, publicRepos)
+	userAc.SetIsAdmin(false)
+	userAc.SaveOnRequest(request)
+}
+
 func hasSessionHeader(request *http.Request) bool {
 	clientHeader := request.Header.Get(constants.SessionClientHeaderName)
 
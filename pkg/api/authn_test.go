@@ -5,9 +5,14 @@ package api_test
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -928,6 +933,172 @@ func TestAPIKeysGeneratorErrors(t *testing.T) {
 	})
 }
 
+func TestTokenServer(t *testing.T) {
+	Convey("Tokens are issued by the built-in token server", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		certPath, keyPath := makeTokenKeyPair(t.TempDir())
+
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString("writer", "writer") + "\n" +
+			test.GetCredString("reader", "reader") + "\n" + test.GetCredString("admin", "admin"))
+		defer os.Remove(htpasswdPath)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+			Bearer: &config.BearerConfig{
+				Realm:   baseURL + constants.TokenPath,
+				Service: "zot",
+				Cert:    certPath,
+				TokenServer: &config.TokenServerConfig{
+					Key:        keyPath,
+					Expiration: 10 * time.Minute,
+				},
+			},
+		}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				"**": config.PolicyGroup{
+					Policies: []config.Policy{
+						{
+							Users:   []string{"writer"},
+							Actions: []string{constants.ReadPermission, constants.CreatePermission},
+						},
+					},
+					DefaultPolicy: []string{constants.ReadPermission},
+				},
+			},
+			AdminPolicy: config.Policy{
+				Users:   []string{"admin"},
+				Actions: []string{constants.ReadPermission, constants.CreatePermission, constants.DeletePermission},
+			},
+		}
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		getToken := func(username, password, scope string) string {
+			var tokenResponse api.TokenResponse
+
+			resp, err := resty.R().SetBasicAuth(username, password).
+				SetQueryParams(map[string]string{"service": "zot", "scope": scope}).
+				Get(baseURL + constants.TokenPath)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(json.Unmarshal(resp.Body(), &tokenResponse), ShouldBeNil)
+			So(tokenResponse.Token, ShouldNotBeEmpty)
+			So(tokenResponse.AccessToken, ShouldEqual, tokenResponse.Token)
+			So(tokenResponse.ExpiresIn, ShouldEqual, 600)
+
+			return tokenResponse.Token
+		}
+
+		resp, err := resty.R().Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+		authHeader := authutils.ParseBearerAuthHeader(resp.Header().Get("WWW-Authenticate"))
+		So(authHeader.Realm, ShouldEqual, baseURL+constants.TokenPath)
+		So(authHeader.Service, ShouldEqual, "zot")
+
+		resp, err = resty.R().SetAuthToken(getToken("reader", "reader", "")).Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		Convey("the token grants the actions allowed by the access control", func() {
+			token := getToken("writer", "writer", "repository:app:pull,push")
+
+			resp, err := resty.R().SetAuthToken(token).Post(baseURL + "/v2/app/blobs/uploads/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+			// the token is scoped to the repository
+			resp, err = resty.R().SetAuthToken(token).Post(baseURL + "/v2/other/blobs/uploads/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+			token = getToken("reader", "reader", "repository:app:pull,push")
+
+			resp, err = resty.R().SetAuthToken(token).Post(baseURL + "/v2/app/blobs/uploads/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+			resp, err = resty.R().SetAuthToken(token).Get(baseURL + "/v2/app/tags/list")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		})
+
+		Convey("the admin routes are only allowed to the admins", func() {
+			resp, err := resty.R().SetAuthToken(getToken("writer", "writer", "repository:app:pull,push")).
+				Get(baseURL + constants.RoutePrefix + constants.AdminReposPath)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+			resp, err = resty.R().SetAuthToken(getToken("admin", "admin", "")).
+				Get(baseURL + constants.RoutePrefix + constants.AdminReposPath)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			resp, err = resty.R().SetAuthToken("invalid").
+				Get(baseURL + constants.RoutePrefix + constants.AdminReposPath)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldNotEqual, http.StatusOK)
+		})
+
+		Convey("the users have to be authenticated", func() {
+			resp, err := resty.R().SetBasicAuth("writer", "wrong").
+				SetQueryParam("scope", "repository:app:pull").Get(baseURL + constants.TokenPath)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+			resp, err = resty.R().SetQueryParam("scope", "repository:app:pull").Get(baseURL + constants.TokenPath)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+		})
+
+		Convey("the tokens are only issued for the registry service", func() {
+			resp, err := resty.R().SetBasicAuth("writer", "writer").
+				SetQueryParam("service", "other").Get(baseURL + constants.TokenPath)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
+
+func makeTokenKeyPair(dir string) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048) //nolint: gomnd
+	So(err, ShouldBeNil)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	So(err, ShouldBeNil)
+
+	certPath := path.Join(dir, "token.crt")
+	keyPath := path.Join(dir, "token.key")
+
+	err = os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600)
+	So(err, ShouldBeNil)
+
+	err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{
+		Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}), 0o600)
+	So(err, ShouldBeNil)
+
+	return certPath, keyPath
+}
+
 func TestCookiestoreCleanup(t *testing.T) {
 	log := log.Logger{}
 	metrics := monitoring.NewMetricsServer(true, log)
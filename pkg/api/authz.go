@@ -24,19 +24,31 @@ const (
 type AccessController struct {
 	Config *config.AccessControlConfig
 	Log    log.Logger
+	// glob patterns of the repositories anonymous users can pull from
+	publicRepositories []string
+	// repositories policies along with the ones of the namespaces
+	repositories config.Repositories
 }
 
 func NewAccessController(conf *config.Config) *AccessController {
+	var publicRepositories []string
+	if conf.HTTP.Auth != nil {
+		publicRepositories = conf.HTTP.Auth.PublicRepositories
+	}
+
 	if conf.HTTP.AccessControl == nil {
 		return &AccessController{
-			Config: &config.AccessControlConfig{},
-			Log:    log.NewLogger(conf.Log.Level, conf.Log.Output),
+			Config:             &config.AccessControlConfig{},
+			Log:                log.NewLogger(conf.Log.Level, conf.Log.Output),
+			publicRepositories: publicRepositories,
 		}
 	}
 
 	return &AccessController{
-		Config: conf.HTTP.AccessControl,
-		Log:    log.NewLogger(conf.Log.Level, conf.Log.Output),
+		Config:             conf.HTTP.AccessControl,
+		Log:                log.NewLogger(conf.Log.Level, conf.Log.Output),
+		publicRepositories: publicRepositories,
+		repositories:       conf.HTTP.AccessControl.AllRepositories(),
 	}
 }
 
@@ -45,7 +57,7 @@ func NewAccessController(conf *config.Config) *AccessController {
 func (ac *AccessController) getGlobPatterns(username string, groups []string, action string) map[string]bool {
 	globPatterns := make(map[string]bool)
 
-	for pattern, policyGroup := range ac.Config.Repositories {
+	for pattern, policyGroup := range ac.repositories {
 		if username == "" {
 			// check anonymous policy
 			if common.Contains(policyGroup.AnonymousPolicy, action) {
@@ -80,6 +92,13 @@ func (ac *AccessController) getGlobPatterns(username string, groups []string, ac
 		}
 	}
 
+	// anonymous users can also read the public repositories
+	if username == "" && action == constants.ReadPermission {
+		for _, pattern := range ac.publicRepositories {
+			globPatterns[pattern] = true
+		}
+	}
+
 	return globPatterns
 }
 
@@ -89,7 +108,7 @@ func (ac *AccessController) can(userAc *reqCtx.UserAccessControl, action, reposi
 
 	var longestMatchedPattern string
 
-	for pattern := range ac.Config.Repositories {
+	for pattern := range ac.repositories {
 		matched, err := glob.Match(pattern, repository)
 		if err == nil {
 			if matched && len(pattern) > len(longestMatchedPattern) {
@@ -102,11 +121,16 @@ func (ac *AccessController) can(userAc *reqCtx.UserAccessControl, action, reposi
 	username := userAc.GetUsername()
 
 	// check matched repo based policy
-	pg, ok := ac.Config.Repositories[longestMatchedPattern]
+	pg, ok := ac.repositories[longestMatchedPattern]
 	if ok {
 		can = ac.isPermitted(userGroups, username, action, pg)
 	}
 
+	// check public repositories
+	if !can && username == "" && action == constants.ReadPermission {
+		can = ac.isPublic(repository)
+	}
+
 	// check admins based policy
 	if !can {
 		if ac.isAdmin(username, userGroups) && common.Contains(ac.Config.AdminPolicy.Actions, action) {
@@ -117,6 +141,11 @@ func (ac *AccessController) can(userAc *reqCtx.UserAccessControl, action, reposi
 	return can
 }
 
+// isPublic returns true if anonymous users can pull from the repository.
+func (ac *AccessController) isPublic(repository string) bool {
+	return common.MatchesAnyGlob(ac.publicRepositories, repository)
+}
+
 // isAdmin .
 func (ac *AccessController) isAdmin(username string, userGroups []string) bool {
 	if common.Contains(ac.Config.AdminPolicy.Users, username) || ac.isAnyGroupInAdminPolicy(userGroups) {
@@ -126,6 +155,30 @@ func (ac *AccessController) isAdmin(username string, userGroups []string) bool {
 	return false
 }
 
+// isNamespaceAdmin returns true if the user can manage the namespace, the registry admins can manage all of them.
+func (ac *AccessController) isNamespaceAdmin(username string, userGroups []string, namespace string) bool {
+	if ac.isAdmin(username, userGroups) {
+		return true
+	}
+
+	namespaceConfig, ok := ac.Config.Namespaces[namespace]
+	if !ok || username == "" {
+		return false
+	}
+
+	if common.Contains(namespaceConfig.AdminUsers, username) {
+		return true
+	}
+
+	for _, group := range userGroups {
+		if common.Contains(namespaceConfig.AdminGroups, group) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (ac *AccessController) isAnyGroupInAdminPolicy(userGroups []string) bool {
 	for _, group := range userGroups {
 		if common.Contains(ac.Config.AdminPolicy.Groups, group) {
@@ -252,7 +305,7 @@ func BaseAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 				return
 			}
 
-			aCtlr := NewAccessController(ctlr.Config)
+			aCtlr := ctlr.accessController()
 
 			// get access control context made in authn.go
 			userAc, err := reqCtx.UserAcFromContext(request.Context())
@@ -291,7 +344,7 @@ func DistSpecAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 			resource := vars["name"]
 			reference, ok := vars["reference"]
 
-			acCtrlr := NewAccessController(ctlr.Config)
+			acCtrlr := ctlr.accessController()
 
 			// get userAc built in authn and previous authz middlewares
 			userAc, err := reqCtx.UserAcFromContext(request.Context())
@@ -337,13 +390,14 @@ func DistSpecAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 func MetricsAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
-			if ctlr.Config.HTTP.AccessControl == nil {
+			accessControl := ctlr.accessControlConfig()
+			if accessControl == nil {
 				// allow access to authenticated user as anonymous policy does not exist
 				next.ServeHTTP(response, request)
 
 				return
 			}
-			if len(ctlr.Config.HTTP.AccessControl.Metrics.Users) == 0 {
+			if len(accessControl.Metrics.Users) == 0 {
 				log := ctlr.Log
 				log.Warn().Msg("auth is enabled but no metrics users in accessControl: /metrics is unaccesible")
 				common.AuthzFail(response, request, "", ctlr.Config.HTTP.Realm, ctlr.Config.HTTP.Auth.FailDelay)
@@ -360,7 +414,7 @@ func MetricsAuthzHandler(ctlr *Controller) mux.MiddlewareFunc {
 			}
 
 			username := userAc.GetUsername()
-			if !common.Contains(ctlr.Config.HTTP.AccessControl.Metrics.Users, username) {
+			if !common.Contains(accessControl.Metrics.Users, username) {
 				common.AuthzFail(response, request, username, ctlr.Config.HTTP.Realm, ctlr.Config.HTTP.Auth.FailDelay)
 
 				return
@@ -2,15 +2,23 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
 	"os"
+	"slices"
+	"sort"
 	"time"
 
 	distspec "github.com/opencontainers/distribution-spec/specs-go"
 
+	"zotregistry.dev/zot/pkg/api/constants"
 	extconf "zotregistry.dev/zot/pkg/extensions/config"
 	storageConstants "zotregistry.dev/zot/pkg/storage/constants"
 )
 
+// the namespace of the staging repositories if the promotion config doesn't set one.
+const defaultStagingPrefix = "staging"
+
 var (
 	Commit     string //nolint: gochecknoglobals
 	ReleaseTag string //nolint: gochecknoglobals
@@ -31,8 +39,55 @@ type StorageConfig struct {
 	GCDelay       time.Duration // applied for blobs
 	GCInterval    time.Duration
 	Retention     ImageRetention
-	StorageDriver map[string]interface{} `mapstructure:",omitempty"`
-	CacheDriver   map[string]interface{} `mapstructure:",omitempty"`
+	// the blob uploads which don't receive data for longer are removed, 0 keeps them
+	UploadSessionTimeout time.Duration          `mapstructure:",omitempty"`
+	StorageDriver        map[string]interface{} `mapstructure:",omitempty"`
+	CacheDriver          map[string]interface{} `mapstructure:",omitempty"`
+	// only for subpaths, glob patterns of the repositories stored in the subpath besides the ones under its route,
+	// e.g. "infra/**"
+	Repositories []string `mapstructure:",omitempty"`
+	// moves the layers of the images not pulled for a while to a cheaper storage, only for local storage
+	Tiering *TieringConfig `mapstructure:",omitempty"`
+	// pushes a copy of the images with their gzip layers recompressed, for the clients pulling layers partially
+	Recompression *RecompressionConfig `mapstructure:",omitempty"`
+	// encrypts the blobs at rest, only for local storage
+	Encryption *EncryptionConfig `mapstructure:",omitempty"`
+}
+
+// TieringConfig moves the layers of the images not pulled, or pushed, for ColdAfter to the cold tier,
+// a local directory usually on cheaper disks. They're moved back to the storage when they're read.
+type TieringConfig struct {
+	RootDirectory string
+	ColdAfter     time.Duration
+	// how often the images are checked, 24h by default
+	Interval time.Duration
+}
+
+// RecompressionConfig pushes a copy of each image pushed with gzip layers, with these layers recompressed
+// to Format, under the same tag with TagSuffix appended.
+type RecompressionConfig struct {
+	// "zstd:chunked", the default, or "zstd"
+	Format string
+	// "-zstd" by default
+	TagSuffix string
+	// the zstd compression level, 3 by default
+	Level int
+}
+
+// EncryptionConfig encrypts the blobs of the repositories with AES-GCM when they're written, with the current key
+// of the keyring. The blobs encrypted with the other keys of the keyring, or in plaintext, are still read.
+type EncryptionConfig struct {
+	// JSON file with the base64 encoded keys by id and the id of the current key
+	Keyring string
+	// the keys of the keyring are data keys encrypted with AWS KMS, they're decrypted when zot starts
+	KMS *KMSConfig `mapstructure:",omitempty"`
+	// glob patterns of the repositories encrypted, all of them by default
+	Repositories []string `mapstructure:",omitempty"`
+}
+
+type KMSConfig struct {
+	Region   string
+	Endpoint string `mapstructure:",omitempty"`
 }
 
 type ImageRetention struct {
@@ -46,6 +101,8 @@ type RetentionPolicy struct {
 	DeleteReferrers bool
 	DeleteUntagged  *bool
 	KeepTags        []KeepTagsPolicy
+	// KeepSigned keeps the signed tags, even the ones no KeepTags policy retains
+	KeepSigned bool
 }
 
 type KeepTagsPolicy struct {
@@ -73,12 +130,22 @@ type AuthConfig struct {
 	Bearer    *BearerConfig
 	OpenID    *OpenIDConfig
 	APIKey    bool
+	// glob patterns of the repositories anonymous users can pull from, pushing still requires authentication
+	PublicRepositories []string
 }
 
 type BearerConfig struct {
 	Realm   string
 	Service string
 	Cert    string
+	// built-in token server, issuing the tokens to the users authenticated with htpasswd, LDAP or API keys
+	TokenServer *TokenServerConfig
+}
+
+type TokenServerConfig struct {
+	// private key signing the tokens, its public key being in the bearer cert
+	Key        string
+	Expiration time.Duration
 }
 
 type OpenIDConfig struct {
@@ -92,6 +159,8 @@ type OpenIDProviderConfig struct {
 	KeyPath      string
 	Issuer       string
 	Scopes       []string
+	// the claim the groups of the user are read from, "groups" by default
+	GroupsClaim string
 }
 
 type MethodRatelimitConfig struct {
@@ -102,6 +171,10 @@ type MethodRatelimitConfig struct {
 type RatelimitConfig struct {
 	Rate    *int                    // requests per second
 	Methods []MethodRatelimitConfig `mapstructure:",omitempty"`
+	// requests per second of each authenticated user, on top of the limits of their IP address
+	UserRate *int `mapstructure:",omitempty"`
+	// blob uploads served at the same time for each IP address
+	MaxConcurrentUploads *int `mapstructure:",omitempty"`
 }
 
 //nolint:maligned
@@ -115,6 +188,57 @@ type HTTPConfig struct {
 	AccessControl *AccessControlConfig `mapstructure:"accessControl,omitempty"`
 	Realm         string
 	Ratelimit     *RatelimitConfig `mapstructure:",omitempty"`
+	IPAccess      *IPAccessConfig  `mapstructure:",omitempty"`
+	// how long the requests in progress are waited for when the server stops, 0 means until they're done
+	ShutdownTimeout time.Duration `mapstructure:",omitempty"`
+	// unix socket the server also listens on, the only address it listens on if no port is given
+	Socket string `mapstructure:",omitempty"`
+}
+
+// IPAccessConfig restricts the client addresses allowed to reach the server, checked before authentication.
+type IPAccessConfig struct {
+	IPRules `mapstructure:",squash"`
+	// rules of the pulls, the pushes and the admin routes, checked on top of the global ones
+	Pull  *IPRules
+	Push  *IPRules
+	Admin *IPRules
+	// CIDRs of the reverse proxies trusted to give the client address in the X-Forwarded-For header
+	TrustedProxies []string
+}
+
+type IPRules struct {
+	// CIDRs or addresses allowed, all of them if empty
+	Allow []string
+	// CIDRs or addresses denied, even if they're allowed
+	Deny []string
+}
+
+// ParseCIDRs parses CIDRs, and plain addresses as CIDRs matching only them.
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+
+	for _, value := range values {
+		if ip := net.ParseIP(value); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, err
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
 }
 
 type SchedulerConfig struct {
@@ -165,12 +289,98 @@ func (ldapConf *LDAPConfig) SetBindPassword(bindPassword string) *LDAPConfig {
 type LogConfig struct {
 	Level  string
 	Output string
-	Audit  string
+	// "json" (default) or "console"
+	Format string `mapstructure:",omitempty"`
+	// levels of the modules overriding the level, e.g. {"sync": "debug", "http": "warn"}
+	Modules map[string]string `mapstructure:",omitempty"`
+	// sampling of the access logs
+	Sampling *LogSamplingConfig `mapstructure:",omitempty"`
+	Audit    string
+	// rotation of the audit log file
+	AuditRotation *LogRotationConfig `mapstructure:",omitempty"`
+	// sends the audit records to syslog instead of the audit log file
+	AuditSyslog *SyslogConfig `mapstructure:",omitempty"`
+}
+
+type LogSamplingConfig struct {
+	// only 1 in Pulls successful pull requests is written to the access log, the others are all written
+	Pulls uint32
+}
+
+// LogRotationConfig renames the log file once it's too big, 0 means no limit.
+type LogRotationConfig struct {
+	MaxBytes int64
+	// number of rotated files kept
+	MaxBackups int
+	// rotated files older than this are removed
+	MaxAge time.Duration
+}
+
+type SyslogConfig struct {
+	// "udp" or "tcp" to send the records to a remote server, the local syslog daemon is used if empty
+	Network string
+	Address string
+	// "zot" by default
+	Tag string
 }
 
 type GlobalStorageConfig struct {
 	StorageConfig `mapstructure:",squash"`
 	SubPaths      map[string]StorageConfig
+	Quota         *QuotaConfig          `mapstructure:",omitempty"`
+	ImmutableTags []ImmutableTagsPolicy `mapstructure:",omitempty"`
+	// rejects the requests changing the registry, e.g. while its storage is backed up, admins can switch it at runtime
+	ReadOnly bool `mapstructure:",omitempty"`
+	// keeps the tags, digests, sizes and annotations of the images in metaDB even if no feature needs it,
+	// the catalog is listed from it instead of walking the storage
+	MetadataIndex bool `mapstructure:",omitempty"`
+	// lets the admins promote the images of the staging repositories to the production ones
+	Promotion *PromotionConfig `mapstructure:",omitempty"`
+}
+
+// PromotionConfig lets the admins promote the images pushed to a staging repository, "<StagingPrefix>/<repo>",
+// to the production repository "<repo>", if they pass the checks of the first policy matching it.
+// The images of the repositories no policy matches are promoted without checks.
+type PromotionConfig struct {
+	// "staging" by default
+	StagingPrefix string
+	Policies      []PromotionPolicy
+}
+
+type PromotionPolicy struct {
+	// glob patterns of the production repositories, e.g. "team-a/**"
+	Repositories []string
+	// the image must have a cosign or notation signature
+	RequireSignature bool
+	// the image must have a signature verified with a trusted key, which needs the trust extension
+	RequireTrustedSignature bool
+	// the highest severity of the CVEs the image can have, e.g. "HIGH" refuses the images with CRITICAL CVEs
+	// and "NONE" the images with any CVE, which needs the CVE scanning
+	MaxSeverity string
+}
+
+// ImmutableTagsPolicy prevents the pushes moving the tags matching its patterns to another manifest and the deletes
+// removing them, new tags are still allowed.
+type ImmutableTagsPolicy struct {
+	Repositories []string
+	// glob patterns of the tags, e.g. "v*"
+	Patterns []string
+}
+
+// QuotaConfig limits the bytes of the blobs stored in the repositories, whatever their storage, 0 means no limit.
+type QuotaConfig struct {
+	// bytes stored in all the repositories together
+	MaxBytes int64
+	Policies []QuotaPolicy
+}
+
+type QuotaPolicy struct {
+	// glob patterns of the repositories, e.g. "team-a/**" for a namespace
+	Repositories []string
+	// bytes stored in each of the repositories
+	MaxRepositoryBytes int64
+	// bytes stored in all the repositories together
+	MaxBytes int64
 }
 
 type AccessControlConfig struct {
@@ -178,6 +388,48 @@ type AccessControlConfig struct {
 	AdminPolicy  Policy
 	Groups       Groups
 	Metrics      Metrics
+	Namespaces   Namespaces
+}
+
+// NamespaceConfig delegates the administration of the repositories of a namespace, the ones under "<namespace>/",
+// to its admins. They can set the access control policies, the retention and the quota of the namespace
+// with the admin API, without being registry admins.
+type NamespaceConfig struct {
+	AdminUsers  []string
+	AdminGroups []string
+	// access control policies of the namespace repositories, taking precedence over the repositories policy
+	// with the same pattern
+	Policies *PolicyGroup
+	// retention policy of the namespace repositories, taking precedence over the storage retention policies
+	Retention *RetentionPolicy
+	// quota of the namespace repositories, applying along with the storage quotas
+	Quota *QuotaPolicy
+}
+
+// NamespacePattern returns the glob pattern of the repositories of a namespace.
+func NamespacePattern(namespace string) string {
+	return namespace + "/**"
+}
+
+// AllRepositories returns the repositories policies along with the ones of the namespaces.
+func (config *AccessControlConfig) AllRepositories() Repositories {
+	if len(config.Namespaces) == 0 {
+		return config.Repositories
+	}
+
+	repositories := make(Repositories, len(config.Repositories)+len(config.Namespaces))
+
+	for pattern, policyGroup := range config.Repositories {
+		repositories[pattern] = policyGroup
+	}
+
+	for namespace, namespaceConfig := range config.Namespaces {
+		if namespaceConfig.Policies != nil {
+			repositories[NamespacePattern(namespace)] = *namespaceConfig.Policies
+		}
+	}
+
+	return repositories
 }
 
 func (config *AccessControlConfig) AnonymousPolicyExists() bool {
@@ -185,7 +437,7 @@ func (config *AccessControlConfig) AnonymousPolicyExists() bool {
 		return false
 	}
 
-	for _, repository := range config.Repositories {
+	for _, repository := range config.AllRepositories() {
 		if len(repository.AnonymousPolicy) > 0 {
 			return true
 		}
@@ -197,6 +449,7 @@ func (config *AccessControlConfig) AnonymousPolicyExists() bool {
 type (
 	Repositories map[string]PolicyGroup
 	Groups       map[string]Group
+	Namespaces   map[string]NamespaceConfig
 )
 
 type Group struct {
@@ -215,6 +468,47 @@ type Policy struct {
 	Groups  []string
 }
 
+// UnknownAction returns the first action the access control doesn't know, a misspelled action would otherwise
+// silently grant nothing.
+func (policy Policy) UnknownAction() (string, bool) {
+	return unknownAction(policy.Actions)
+}
+
+// UnknownAction returns the first action of the policies the access control doesn't know, along with the policy
+// using it, e.g. "policies[0]" or "defaultPolicy".
+func (policyGroup PolicyGroup) UnknownAction() (string, string, bool) {
+	for i, policy := range policyGroup.Policies {
+		if action, found := policy.UnknownAction(); found {
+			return fmt.Sprintf("policies[%d]", i), action, true
+		}
+	}
+
+	if action, found := unknownAction(policyGroup.DefaultPolicy); found {
+		return "defaultPolicy", action, true
+	}
+
+	if action, found := unknownAction(policyGroup.AnonymousPolicy); found {
+		return "anonymousPolicy", action, true
+	}
+
+	return "", "", false
+}
+
+func unknownAction(actions []string) (string, bool) {
+	knownActions := []string{
+		constants.ReadPermission, constants.CreatePermission, constants.UpdatePermission,
+		constants.DeletePermission, constants.DetectManifestCollisionPermission,
+	}
+
+	for _, action := range actions {
+		if !slices.Contains(knownActions, action) {
+			return action, true
+		}
+	}
+
+	return "", false
+}
+
 type Metrics struct {
 	Users []string
 }
@@ -230,6 +524,18 @@ type Config struct {
 	Log             *LogConfig
 	Extensions      *extconf.ExtensionConfig
 	Scheduler       *SchedulerConfig `json:"scheduler" mapstructure:",omitempty"`
+	Compat          *CompatConfig    `json:"compat" mapstructure:",omitempty"`
+}
+
+// CompatConfig toggles the quirks of the Docker registry v2 API the older docker and containerd clients need,
+// zot only speaks OCI otherwise.
+type CompatConfig struct {
+	// accepts and serves the Docker schema2 manifests and manifest lists
+	DockerManifests bool
+	// accepts the manifests referencing foreign layers, which are pulled from elsewhere and not stored in zot
+	ForeignLayers bool
+	// serves the linux/amd64 manifest of the indexes to the clients not accepting them
+	AcceptFallback bool
 }
 
 func New() *Config {
@@ -302,6 +608,14 @@ func (c *Config) Sanitize() *Config {
 		sanitizedConfig.HTTP.Auth.LDAP.bindPassword = "******"
 	}
 
+	if sanitizedConfig.Extensions != nil && sanitizedConfig.Extensions.Events != nil {
+		for id, webhook := range sanitizedConfig.Extensions.Events.Webhooks {
+			if webhook.Secret != "" {
+				sanitizedConfig.Extensions.Events.Webhooks[id].Secret = "******"
+			}
+		}
+	}
+
 	return sanitizedConfig
 }
 
@@ -346,6 +660,10 @@ func (c *Config) IsBearerAuthEnabled() bool {
 	return false
 }
 
+func (c *Config) IsTokenServerEnabled() bool {
+	return c.IsBearerAuthEnabled() && c.HTTP.Auth.Bearer.TokenServer != nil
+}
+
 func (c *Config) IsOpenIDAuthEnabled() bool {
 	if c.HTTP.Auth != nil &&
 		c.HTTP.Auth.OpenID != nil {
@@ -367,6 +685,10 @@ func (c *Config) IsAPIKeyEnabled() bool {
 	return false
 }
 
+func (c *Config) PublicRepositoriesExist() bool {
+	return c.HTTP.Auth != nil && len(c.HTTP.Auth.PublicRepositories) > 0
+}
+
 func (c *Config) IsBasicAuthnEnabled() bool {
 	if c.IsHtpasswdAuthEnabled() || c.IsLdapAuthEnabled() ||
 		c.IsOpenIDAuthEnabled() || c.IsAPIKeyEnabled() {
@@ -397,6 +719,14 @@ func (c *Config) IsMetricsEnabled() bool {
 	return c.Extensions != nil && c.Extensions.Metrics != nil && *c.Extensions.Metrics.Enable
 }
 
+func (c *Config) IsTracingEnabled() bool {
+	return c.Extensions != nil && c.Extensions.Tracing != nil && *c.Extensions.Tracing.Enable
+}
+
+func (c *Config) IsEventsEnabled() bool {
+	return c.Extensions != nil && c.Extensions.Events != nil && *c.Extensions.Events.Enable
+}
+
 func (c *Config) IsSearchEnabled() bool {
 	return c.Extensions != nil && c.Extensions.Search != nil && *c.Extensions.Search.Enable
 }
@@ -405,6 +735,10 @@ func (c *Config) IsCveScanningEnabled() bool {
 	return c.IsSearchEnabled() && c.Extensions.Search.CVE != nil
 }
 
+func (c *Config) IsActivityEnabled() bool {
+	return c.IsSearchEnabled() && c.Extensions.Search.Activity != nil
+}
+
 func (c *Config) IsUIEnabled() bool {
 	return c.Extensions != nil && c.Extensions.UI != nil && *c.Extensions.UI.Enable
 }
@@ -422,6 +756,95 @@ func (c *Config) IsImageTrustEnabled() bool {
 }
 
 // check if tags retention is enabled.
+// IsTieringEnabled tells if the blobs of the storage or one of its subpaths are moved to a cold tier,
+// the last pulls of the images are read from metaDB.
+func (c *Config) IsTieringEnabled() bool {
+	if c.Storage.Tiering != nil {
+		return true
+	}
+
+	for _, subpath := range c.Storage.SubPaths {
+		if subpath.Tiering != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsQuotaEnabled returns true if there's a storage quota or a namespace quota.
+func (c *Config) IsQuotaEnabled() bool {
+	if c.Storage.Quota != nil {
+		return true
+	}
+
+	if c.HTTP.AccessControl != nil {
+		for _, namespaceConfig := range c.HTTP.AccessControl.Namespaces {
+			if namespaceConfig.Quota != nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// QuotaWithNamespaces returns the storage quota along with the policies of the namespace quotas.
+func (c *Config) QuotaWithNamespaces() *QuotaConfig {
+	if c.HTTP.AccessControl == nil || len(c.HTTP.AccessControl.Namespaces) == 0 {
+		return c.Storage.Quota
+	}
+
+	quota := QuotaConfig{}
+	if c.Storage.Quota != nil {
+		quota.MaxBytes = c.Storage.Quota.MaxBytes
+		quota.Policies = append(quota.Policies, c.Storage.Quota.Policies...)
+	}
+
+	for namespace, namespaceConfig := range c.HTTP.AccessControl.Namespaces {
+		if namespaceConfig.Quota != nil {
+			policy := *namespaceConfig.Quota
+			policy.Repositories = []string{NamespacePattern(namespace)}
+
+			quota.Policies = append(quota.Policies, policy)
+		}
+	}
+
+	return &quota
+}
+
+// RetentionWithNamespaces returns the retention policies of a storage preceded by the ones of the namespaces.
+func (c *Config) RetentionWithNamespaces(retention ImageRetention) ImageRetention {
+	if c.HTTP.AccessControl == nil || len(c.HTTP.AccessControl.Namespaces) == 0 {
+		return retention
+	}
+
+	namespaces := make([]string, 0, len(c.HTTP.AccessControl.Namespaces))
+	for namespace := range c.HTTP.AccessControl.Namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+
+	// the nested namespaces come first, so their policies aren't hidden by the ones of their parents
+	sort.Slice(namespaces, func(i, j int) bool {
+		return len(namespaces[i]) > len(namespaces[j])
+	})
+
+	policies := []RetentionPolicy{}
+
+	for _, namespace := range namespaces {
+		if policy := c.HTTP.AccessControl.Namespaces[namespace].Retention; policy != nil {
+			namespacePolicy := *policy
+			namespacePolicy.Repositories = []string{NamespacePattern(namespace)}
+
+			policies = append(policies, namespacePolicy)
+		}
+	}
+
+	retention.Policies = append(policies, retention.Policies...)
+
+	return retention
+}
+
 func (c *Config) IsRetentionEnabled() bool {
 	var needsMetaDB bool
 
@@ -465,6 +888,23 @@ func (c *Config) IsNotationEnabled() bool {
 	return c.IsImageTrustEnabled() && c.Extensions.Trust.Notation
 }
 
+func (c *Config) IsPromotionEnabled() bool {
+	return c.Storage.Promotion != nil
+}
+
+// GetStagingPrefix returns the namespace of the staging repositories, the images are promoted from.
+func (c *Config) GetStagingPrefix() string {
+	if c.Storage.Promotion == nil || c.Storage.Promotion.StagingPrefix == "" {
+		return defaultStagingPrefix
+	}
+
+	return c.Storage.Promotion.StagingPrefix
+}
+
+func (c *Config) IsTrustPolicyEnabled() bool {
+	return c.IsImageTrustEnabled() && len(c.Extensions.Trust.Policies) > 0
+}
+
 func (c *Config) IsSyncEnabled() bool {
 	return c.Extensions != nil && c.Extensions.Sync != nil && *c.Extensions.Sync.Enable
 }
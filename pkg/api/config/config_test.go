@@ -7,6 +7,7 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 
 	"zotregistry.dev/zot/pkg/api/config"
+	extconf "zotregistry.dev/zot/pkg/extensions/config"
 )
 
 func TestConfig(t *testing.T) {
@@ -75,6 +76,14 @@ func TestConfig(t *testing.T) {
 		conf = conf.Sanitize()
 		So(conf.HTTP.Auth.LDAP.BindPassword(), ShouldEqual, "******")
 
+		conf.Extensions = &extconf.ExtensionConfig{Events: &extconf.EventsConfig{
+			Webhooks: []extconf.WebhookConfig{{URL: "http://ci", Secret: "secret"}, {URL: "http://other"}},
+		}}
+		sanitized := conf.Sanitize()
+		So(sanitized.Extensions.Events.Webhooks[0].Secret, ShouldEqual, "******")
+		So(sanitized.Extensions.Events.Webhooks[1].Secret, ShouldBeEmpty)
+		So(conf.Extensions.Events.Webhooks[0].Secret, ShouldEqual, "secret")
+
 		// negative
 		obj := make(chan int)
 		err := config.DeepCopy(conf, obj)
@@ -83,6 +92,36 @@ func TestConfig(t *testing.T) {
 		So(err, ShouldNotBeNil)
 	})
 
+	Convey("Test UnknownAction()", t, func() {
+		policyGroup := config.PolicyGroup{
+			Policies:        []config.Policy{{Actions: []string{"read", "create"}}},
+			DefaultPolicy:   []string{"read"},
+			AnonymousPolicy: []string{"read", "detectManifestCollision"},
+		}
+
+		_, _, found := policyGroup.UnknownAction()
+		So(found, ShouldBeFalse)
+
+		policyGroup.Policies = append(policyGroup.Policies, config.Policy{Actions: []string{"read", "write"}})
+
+		policy, action, found := policyGroup.UnknownAction()
+		So(found, ShouldBeTrue)
+		So(policy, ShouldEqual, "policies[1]")
+		So(action, ShouldEqual, "write")
+
+		policyGroup.Policies = nil
+		policyGroup.AnonymousPolicy = []string{"push"}
+
+		policy, action, found = policyGroup.UnknownAction()
+		So(found, ShouldBeTrue)
+		So(policy, ShouldEqual, "anonymousPolicy")
+		So(action, ShouldEqual, "push")
+
+		action, found = config.Policy{Actions: []string{"delete", "pull"}}.UnknownAction()
+		So(found, ShouldBeTrue)
+		So(action, ShouldEqual, "pull")
+	})
+
 	Convey("Test IsRetentionEnabled()", t, func() {
 		conf := config.New()
 		So(conf.IsRetentionEnabled(), ShouldBeFalse)
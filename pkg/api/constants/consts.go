@@ -11,14 +11,27 @@ const (
 	DistContentDigestKey         = "Docker-Content-Digest"
 	SubjectDigestKey             = "OCI-Subject"
 	BlobUploadUUID               = "Blob-Upload-UUID"
+	RequestIDHeader              = "X-Request-ID"
 	DefaultMediaType             = "application/json"
 	BinaryMediaType              = "application/octet-stream"
 	DefaultMetricsExtensionRoute = "/metrics"
+	LivenessPath                 = "/livez"
+	ReadinessPath                = "/readyz"
 	AppNamespacePath             = "/zot"
 	CallbackBasePath             = AppNamespacePath + "/auth/callback"
 	LoginPath                    = AppNamespacePath + "/auth/login"
 	LogoutPath                   = AppNamespacePath + "/auth/logout"
 	APIKeyPath                   = AppNamespacePath + "/auth/apikey"
+	TokenPath                    = AppNamespacePath + "/auth/token"
+	GCPath                       = BasePrefix + "/gc"
+	QuotaPath                    = BasePrefix + "/quota"
+	ReadOnlyPath                 = BasePrefix + "/readonly"
+	AdminPath                    = BasePrefix + "/admin"
+	AdminReposPath               = AdminPath + "/repos"
+	AdminUploadsPath             = AdminPath + "/uploads"
+	AdminTasksPath               = AdminPath + "/tasks"
+	AdminNamespacesPath          = AdminPath + "/namespaces"
+	AdminPromotePath             = AdminPath + "/promote"
 	SessionClientHeaderName      = "X-ZOT-API-CLIENT"
 	SessionClientHeaderValue     = "zot-ui"
 	APIKeysPrefix                = "zak_"
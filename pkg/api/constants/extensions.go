@@ -33,4 +33,9 @@ const (
 	UserPrefs     = "/userprefs"
 	ExtUserPrefs  = ExtPrefix + UserPrefs
 	FullUserPrefs = RoutePrefix + ExtUserPrefs
+
+	// scrub extension.
+	Scrub     = "/scrub"
+	ExtScrub  = ExtPrefix + Scrub
+	FullScrub = RoutePrefix + ExtScrub
 )
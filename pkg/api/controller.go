@@ -11,23 +11,30 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
 	"github.com/zitadel/oidc/pkg/client/rp"
 
 	"zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api/config"
 	ext "zotregistry.dev/zot/pkg/extensions"
 	extconf "zotregistry.dev/zot/pkg/extensions/config"
+	"zotregistry.dev/zot/pkg/extensions/events"
 	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	"zotregistry.dev/zot/pkg/extensions/tracing"
 	"zotregistry.dev/zot/pkg/log"
 	"zotregistry.dev/zot/pkg/meta"
 	mTypes "zotregistry.dev/zot/pkg/meta/types"
 	"zotregistry.dev/zot/pkg/scheduler"
 	"zotregistry.dev/zot/pkg/storage"
 	"zotregistry.dev/zot/pkg/storage/gc"
+	"zotregistry.dev/zot/pkg/storage/tiering"
+	storageTypes "zotregistry.dev/zot/pkg/storage/types"
 )
 
 const (
@@ -45,11 +52,36 @@ type Controller struct {
 	Server          *http.Server
 	Metrics         monitoring.MetricServer
 	CveScanner      ext.CveScanner
+	EventNotifier   events.Notifier
 	SyncOnDemand    SyncOnDemand
 	RelyingParties  map[string]rp.RelyingParty
 	CookieStore     *CookieStore
 	LDAPClient      *LDAPClient
-	taskScheduler   *scheduler.Scheduler
+	HTPasswd        *HTPasswd
+	// the results of the periodic scrub, kept while the server runs
+	ScrubReport *storage.ScrubReport
+	// adds the events to the activity logs of the repos kept in MetaDB, off the request path
+	activityRecorder *activityRecorder
+	// checked before accepting the blob uploads
+	quotas        *storage.Quotas
+	taskScheduler *scheduler.Scheduler
+	// the garbage collectors of the stores with GC enabled, also run on demand by admins
+	garbageCollectors []gc.GarbageCollect
+	// the scheduler, the garbage collectors and SyncOnDemand are replaced when the background tasks are restarted,
+	// e.g. by the read-only mode, while the requests use them
+	backgroundTasksLock sync.RWMutex
+	// sends the spans left when the server stops
+	shutdownTracing func(context.Context) error
+	// the requests changing the registry are rejected and the background tasks stopped while it's set
+	readOnly     atomic.Bool
+	readOnlyLock sync.Mutex
+	// serializes the changes of the namespaces settings by their admins
+	namespacesLock sync.Mutex
+	// the access control config, the quotas and the public repositories are replaced by the namespaces admins
+	// and the config reloads while the requests read them
+	accessControlLock sync.RWMutex
+	// serializes the promotions, so the checks of an image and the moves of its tag aren't interleaved
+	promotionLock sync.Mutex
 	// runtime params
 	chosenPort int // kernel-chosen port
 }
@@ -57,18 +89,48 @@ type Controller struct {
 func NewController(config *config.Config) *Controller {
 	var controller Controller
 
-	logger := log.NewLogger(config.Log.Level, config.Log.Output)
+	logger := log.NewLoggerWithFormat(config.Log.Level, config.Log.Output, config.Log.Format)
+	if err := log.SetModuleLevels(config.Log.Modules); err != nil {
+		logger.Error().Err(err).Interface("modules", config.Log.Modules).Msg("invalid module log levels, ignoring them")
+	}
+
 	controller.Config = config
 	controller.Log = logger
+	controller.ScrubReport = storage.NewScrubReport()
+	controller.HTPasswd = NewHTPasswd()
+	controller.readOnly.Store(config.Storage.ReadOnly)
 
-	if config.Log.Audit != "" {
-		audit := log.NewAuditLogger(config.Log.Level, config.Log.Audit)
-		controller.Audit = audit
+	if config.Log.Audit != "" || config.Log.AuditSyslog != nil {
+		controller.Audit = newAuditLogger(config.Log)
 	}
 
 	return &controller
 }
 
+// newAuditLogger sends the audit records to syslog or to the audit file, rotated if it's configured.
+func newAuditLogger(logConfig *config.LogConfig) *log.Logger {
+	switch {
+	case logConfig.AuditSyslog != nil:
+		writer, err := log.NewSyslogWriter(logConfig.AuditSyslog.Network, logConfig.AuditSyslog.Address,
+			logConfig.AuditSyslog.Tag)
+		if err != nil {
+			panic(err)
+		}
+
+		return log.NewAuditLoggerWithWriter(logConfig.Level, writer)
+	case logConfig.AuditRotation != nil:
+		writer, err := log.NewRotatingFile(logConfig.Audit, logConfig.AuditRotation.MaxBytes,
+			logConfig.AuditRotation.MaxBackups, logConfig.AuditRotation.MaxAge)
+		if err != nil {
+			panic(err)
+		}
+
+		return log.NewAuditLoggerWithWriter(logConfig.Level, writer)
+	default:
+		return log.NewAuditLogger(logConfig.Level, logConfig.Audit)
+	}
+}
+
 func DumpRuntimeParams(log log.Logger) {
 	var rLimit syscall.Rlimit
 
@@ -113,6 +175,10 @@ func (c *Controller) Run() error {
 		for _, mrlim := range c.Config.HTTP.Ratelimit.Methods {
 			engine.Use(MethodRateLimiter(c, mrlim.Method, mrlim.Rate))
 		}
+
+		if c.Config.HTTP.Ratelimit.MaxConcurrentUploads != nil {
+			engine.Use(UploadConcurrencyLimiter(c, *c.Config.HTTP.Ratelimit.MaxConcurrentUploads))
+		}
 	}
 
 	engine.Use(
@@ -120,10 +186,20 @@ func (c *Controller) Run() error {
 		RecoveryHandler(c.Log),
 	)
 
+	if c.Config.IsTracingEnabled() {
+		engine.Use(tracing.RouteSpanNameMiddleware)
+	}
+
 	if c.Audit != nil {
 		engine.Use(SessionAuditLogger(c.Audit))
 	}
 
+	if c.Config.HTTP.IPAccess != nil {
+		engine.Use(IPAccessHandler(c))
+	}
+
+	engine.Use(ReadOnlyHandler(c))
+
 	c.Router = engine
 	c.Router.UseEncodedPath()
 
@@ -140,14 +216,43 @@ func (c *Controller) Run() error {
 		IdleTimeout:       idleTimeout,
 		ReadHeaderTimeout: readHeaderTimeout,
 	}
+
+	if c.Config.IsTracingEnabled() {
+		server.Handler = tracing.Handler(c.Router)
+	}
+
 	c.Server = server
 
+	var socketListener net.Listener
+
+	if c.Config.HTTP.Socket != "" {
+		listener, err := c.listenUnixSocket()
+		if err != nil {
+			return err
+		}
+
+		// the server is only reached through the socket
+		if c.Config.HTTP.Port == "" {
+			return server.Serve(listener)
+		}
+
+		socketListener = listener
+	}
+
 	// Create the listener
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
+		if socketListener != nil {
+			_ = socketListener.Close()
+		}
+
 		return err
 	}
 
+	if socketListener != nil {
+		go c.serveUnixSocket(socketListener)
+	}
+
 	if c.Config.HTTP.Port == "0" || c.Config.HTTP.Port == "" {
 		chosenAddr, ok := listener.Addr().(*net.TCPAddr)
 		if !ok {
@@ -233,6 +338,10 @@ func (c *Controller) Init() error {
 
 	c.Metrics = monitoring.NewMetricsServer(enabled, c.Log)
 
+	c.shutdownTracing = tracing.EnableTracing(c.Config, c.Log)
+
+	c.EventNotifier = events.NewNotifier(c.Config, c.Log)
+
 	if err := c.InitImageStore(); err != nil { //nolint:contextcheck
 		return err
 	}
@@ -241,6 +350,10 @@ func (c *Controller) Init() error {
 		return err
 	}
 
+	if c.MetaDB != nil && c.Config.IsActivityEnabled() {
+		c.activityRecorder = newActivityRecorder(c.MetaDB, c.Log)
+	}
+
 	c.InitCVEInfo()
 
 	return nil
@@ -262,6 +375,7 @@ func (c *Controller) InitImageStore() error {
 	}
 
 	c.StoreController = storeController
+	c.quotas = storage.NewQuotas(c.Config.QuotaWithNamespaces(), storeController)
 
 	return nil
 }
@@ -283,7 +397,8 @@ func (c *Controller) initCookieStore() error {
 func (c *Controller) InitMetaDB() error {
 	// init metaDB if search is enabled or we need to store user profiles, api keys or signatures
 	if c.Config.IsSearchEnabled() || c.Config.IsBasicAuthnEnabled() || c.Config.IsImageTrustEnabled() ||
-		c.Config.IsRetentionEnabled() {
+		c.Config.IsRetentionEnabled() || c.Config.IsTieringEnabled() || c.Config.Storage.MetadataIndex ||
+		c.Config.IsPromotionEnabled() {
 		driver, err := meta.New(c.Config.Storage.StorageConfig, c.Log) //nolint:contextcheck
 		if err != nil {
 			return err
@@ -311,11 +426,12 @@ func (c *Controller) InitMetaDB() error {
 }
 
 func (c *Controller) LoadNewConfig(newConfig *config.Config) {
-	// reload access control config
-	c.Config.HTTP.AccessControl = newConfig.HTTP.AccessControl
+	// reload access control config, the namespaces settings changed by their admins are replaced too
+	c.setAccessControlConfig(newConfig.HTTP.AccessControl)
 
 	if c.Config.HTTP.Auth != nil {
 		c.Config.HTTP.Auth.LDAP = newConfig.HTTP.Auth.LDAP
+		c.setPublicRepositories(newConfig.HTTP.Auth.PublicRepositories)
 
 		if c.LDAPClient != nil {
 			c.LDAPClient.lock.Lock()
@@ -323,6 +439,44 @@ func (c *Controller) LoadNewConfig(newConfig *config.Config) {
 			c.LDAPClient.BindPassword = newConfig.HTTP.Auth.LDAP.BindPassword()
 			c.LDAPClient.lock.Unlock()
 		}
+
+		// reload the htpasswd users, only if htpasswd authn was set up when the server started
+		if c.Config.IsHtpasswdAuthEnabled() && newConfig.HTTP.Auth.HTPasswd.Path != "" {
+			c.Config.HTTP.Auth.HTPasswd = newConfig.HTTP.Auth.HTPasswd
+
+			if err := c.HTPasswd.Reload(newConfig.HTTP.Auth.HTPasswd.Path); err != nil {
+				c.Log.Error().Err(err).Str("credsFile", newConfig.HTTP.Auth.HTPasswd.Path).
+					Msg("failed to reload htpasswd file, keeping the previous credentials")
+			}
+		}
+	}
+
+	// switch the read-only mode only if the config changed, so it's not undone when it was switched by an admin
+	if newConfig.Storage.ReadOnly != c.Config.Storage.ReadOnly {
+		c.Config.Storage.ReadOnly = newConfig.Storage.ReadOnly
+		c.SetReadOnly(newConfig.Storage.ReadOnly)
+	}
+
+	// reload log level
+	if newConfig.Log != nil && newConfig.Log.Level != c.Config.Log.Level {
+		level, err := zerolog.ParseLevel(newConfig.Log.Level)
+		if err != nil {
+			c.Log.Error().Err(err).Str("level", newConfig.Log.Level).Msg("invalid log level, keeping the previous one")
+		} else {
+			c.Config.Log.Level = newConfig.Log.Level
+
+			log.SetLevel(level)
+		}
+	}
+
+	// reload the log levels of the modules
+	if newConfig.Log != nil {
+		if err := log.SetModuleLevels(newConfig.Log.Modules); err != nil {
+			c.Log.Error().Err(err).Interface("modules", newConfig.Log.Modules).
+				Msg("invalid module log levels, keeping the previous ones")
+		} else {
+			c.Config.Log.Modules = newConfig.Log.Modules
+		}
 	}
 
 	// reload periodical gc config
@@ -379,39 +533,317 @@ func (c *Controller) LoadNewConfig(newConfig *config.Config) {
 		Msg("loaded new configuration settings")
 }
 
+// Shutdown stops accepting requests and waits for the ones in progress, e.g. the uploads,
+// up to the shutdown timeout before stopping the background tasks.
 func (c *Controller) Shutdown() {
-	c.StopBackgroundTasks()
-
 	if c.Server != nil {
 		ctx := context.Background()
-		_ = c.Server.Shutdown(ctx)
+
+		if c.Config.HTTP.ShutdownTimeout > 0 {
+			var cancel context.CancelFunc
+
+			ctx, cancel = context.WithTimeout(ctx, c.Config.HTTP.ShutdownTimeout)
+			defer cancel()
+		}
+
+		if err := c.Server.Shutdown(ctx); err != nil {
+			c.Log.Warn().Err(err).Dur("timeout", c.Config.HTTP.ShutdownTimeout).
+				Msg("requests still in progress after the shutdown timeout, closing their connections")
+
+			_ = c.Server.Close()
+		}
+	}
+
+	c.StopBackgroundTasks()
+
+	if c.EventNotifier != nil {
+		c.EventNotifier.Stop()
+	}
+
+	if c.activityRecorder != nil {
+		c.activityRecorder.Stop()
+	}
+
+	if c.shutdownTracing != nil {
+		_ = c.shutdownTracing(context.Background())
 	}
 }
 
 // Will stop scheduler and wait for all tasks to finish their work.
+// runTiering moves the layers of the images not used anymore to the cold tier of the image store periodically.
+func (c *Controller) runTiering(imgStore storageTypes.ImageStore, tieringConfig *config.TieringConfig) {
+	interval := tieringConfig.Interval
+	if interval == 0 {
+		interval = tiering.DefaultInterval
+	}
+
+	tiering.NewTiering(imgStore, c.MetaDB, tiering.Options{
+		ColdDir:   tieringConfig.RootDirectory,
+		ColdAfter: tieringConfig.ColdAfter,
+		Metrics:   c.Metrics,
+	}, c.Log).MoveColdBlobsPeriodically(interval, c.taskScheduler)
+}
+
 func (c *Controller) StopBackgroundTasks() {
+	c.backgroundTasksLock.Lock()
+	defer c.backgroundTasksLock.Unlock()
+
+	c.stopBackgroundTasks()
+}
+
+func (c *Controller) stopBackgroundTasks() {
 	if c.taskScheduler != nil {
 		c.taskScheduler.Shutdown()
 	}
 }
 
+// getTaskScheduler returns the scheduler of the background tasks, nil if they were never started.
+func (c *Controller) getTaskScheduler() *scheduler.Scheduler {
+	c.backgroundTasksLock.RLock()
+	defer c.backgroundTasksLock.RUnlock()
+
+	return c.taskScheduler
+}
+
+// getGarbageCollectors returns the scheduler of the background tasks along with the garbage collectors
+// of the stores with GC enabled.
+func (c *Controller) getGarbageCollectors() (*scheduler.Scheduler, []gc.GarbageCollect) {
+	c.backgroundTasksLock.RLock()
+	defer c.backgroundTasksLock.RUnlock()
+
+	return c.taskScheduler, c.garbageCollectors
+}
+
+// getSyncOnDemand returns the syncing of the images missing from the storage, nil if it isn't enabled.
+func (c *Controller) getSyncOnDemand() SyncOnDemand {
+	c.backgroundTasksLock.RLock()
+	defer c.backgroundTasksLock.RUnlock()
+
+	return c.SyncOnDemand
+}
+
+// RunGarbageCollect starts a garbage collect of every store with GC enabled, regardless of their GC interval.
+// It returns false if GC is enabled on none of them.
+func (c *Controller) RunGarbageCollect() bool {
+	taskScheduler, garbageCollectors := c.getGarbageCollectors()
+	if taskScheduler == nil {
+		return false
+	}
+
+	for _, garbageCollector := range garbageCollectors {
+		garbageCollector.CleanImageStore(taskScheduler)
+	}
+
+	return len(garbageCollectors) > 0
+}
+
+// GarbageCollectReport returns what a garbage collection of every store with GC enabled would remove now, or
+// with retentionOnly what the retention policies would remove. It returns false if GC isn't enabled.
+func (c *Controller) GarbageCollectReport(ctx context.Context, retentionOnly bool) (gc.Report, bool, error) {
+	report := gc.Report{Manifests: []gc.RemovedManifest{}, Blobs: []gc.RemovedBlob{}}
+
+	taskScheduler, garbageCollectors := c.getGarbageCollectors()
+	if taskScheduler == nil || len(garbageCollectors) == 0 {
+		return report, false, nil
+	}
+
+	for _, garbageCollector := range garbageCollectors {
+		storeReport, err := garbageCollector.DryRun(ctx, retentionOnly)
+		if err != nil {
+			return report, true, err
+		}
+
+		report.Manifests = append(report.Manifests, storeReport.Manifests...)
+		report.Blobs = append(report.Blobs, storeReport.Blobs...)
+		report.Size += storeReport.Size
+	}
+
+	return report, true, nil
+}
+
+// RunScrub starts a scrub of every store, regardless of the scrub interval. It returns false if scrub isn't enabled.
+func (c *Controller) RunScrub() bool {
+	taskScheduler := c.getTaskScheduler()
+	if taskScheduler == nil {
+		return false
+	}
+
+	return ext.RunScrub(c.Config, c.Log, c.StoreController, taskScheduler, c.ScrubReport, c.Metrics)
+}
+
+// RunSync starts a sync of the content of every registry which has some, regardless of their poll interval.
+// It returns false if sync isn't enabled or if there's no content to sync.
+func (c *Controller) RunSync() (bool, error) {
+	taskScheduler := c.getTaskScheduler()
+	if taskScheduler == nil {
+		return false, nil
+	}
+
+	return ext.RunSync(c.Config, c.MetaDB, c.StoreController, taskScheduler, c.Log)
+}
+
+// IsReadOnly tells if the requests changing the registry are rejected.
+func (c *Controller) IsReadOnly() bool {
+	return c.readOnly.Load()
+}
+
+// SetReadOnly switches the read-only mode, the background tasks, e.g. GC, are stopped while it's on
+// so the storage doesn't change at all.
+func (c *Controller) SetReadOnly(readOnly bool) {
+	c.readOnlyLock.Lock()
+	defer c.readOnlyLock.Unlock()
+
+	if c.readOnly.Load() == readOnly {
+		return
+	}
+
+	c.readOnly.Store(readOnly)
+
+	if readOnly {
+		c.Log.Info().Msg("read-only mode enabled, stopping background tasks")
+
+		c.StopBackgroundTasks()
+
+		return
+	}
+
+	c.Log.Info().Msg("read-only mode disabled, starting background tasks")
+
+	c.StartBackgroundTasks()
+}
+
+// accessController returns the access controller of the current access control config.
+func (c *Controller) accessController() *AccessController {
+	c.accessControlLock.RLock()
+	defer c.accessControlLock.RUnlock()
+
+	return NewAccessController(c.Config)
+}
+
+// publicRepositories returns the glob patterns of the repositories anonymous users can pull from.
+func (c *Controller) publicRepositories() []string {
+	c.accessControlLock.RLock()
+	defer c.accessControlLock.RUnlock()
+
+	if c.Config.HTTP.Auth == nil {
+		return nil
+	}
+
+	return c.Config.HTTP.Auth.PublicRepositories
+}
+
+func (c *Controller) setPublicRepositories(publicRepositories []string) {
+	c.accessControlLock.Lock()
+	defer c.accessControlLock.Unlock()
+
+	c.Config.HTTP.Auth.PublicRepositories = publicRepositories
+}
+
+// accessControlConfig returns the current access control config, nil if there's none. It's replaced rather than
+// changed, so it can be read once returned.
+func (c *Controller) accessControlConfig() *config.AccessControlConfig {
+	c.accessControlLock.RLock()
+	defer c.accessControlLock.RUnlock()
+
+	return c.Config.HTTP.AccessControl
+}
+
+// setAccessControlConfig replaces the access control config along with the quotas, some of them being the ones
+// of its namespaces.
+func (c *Controller) setAccessControlConfig(accessControl *config.AccessControlConfig) {
+	c.accessControlLock.Lock()
+	defer c.accessControlLock.Unlock()
+
+	c.Config.HTTP.AccessControl = accessControl
+	c.quotas = storage.NewQuotas(c.Config.QuotaWithNamespaces(), c.StoreController)
+}
+
+// getQuotas returns the quotas checked before accepting the blob uploads, nil if there are none.
+func (c *Controller) getQuotas() *storage.Quotas {
+	c.accessControlLock.RLock()
+	defer c.accessControlLock.RUnlock()
+
+	if !c.Config.IsQuotaEnabled() {
+		return nil
+	}
+
+	return c.quotas
+}
+
+func (c *Controller) retentionWithNamespaces(retention config.ImageRetention) config.ImageRetention {
+	c.accessControlLock.RLock()
+	defer c.accessControlLock.RUnlock()
+
+	return c.Config.RetentionWithNamespaces(retention)
+}
+
+// RestartBackgroundTasks restarts the background tasks so they use the current config, e.g. the retention policies.
+func (c *Controller) RestartBackgroundTasks() {
+	c.readOnlyLock.Lock()
+	defer c.readOnlyLock.Unlock()
+
+	if c.IsReadOnly() {
+		return
+	}
+
+	c.backgroundTasksLock.Lock()
+	defer c.backgroundTasksLock.Unlock()
+
+	c.stopBackgroundTasks()
+	c.startBackgroundTasks()
+}
+
 func (c *Controller) StartBackgroundTasks() {
+	c.backgroundTasksLock.Lock()
+	defer c.backgroundTasksLock.Unlock()
+
+	c.startBackgroundTasks()
+}
+
+// startBackgroundTasks starts a new scheduler, the tasks submitted to the previous one which didn't start yet,
+// e.g. the CVE scans of the pushed images, are handed over to it.
+func (c *Controller) startBackgroundTasks() {
+	if c.IsReadOnly() {
+		c.Log.Info().Msg("read-only mode, not starting background tasks")
+
+		return
+	}
+
+	previousScheduler := c.taskScheduler
+
 	c.taskScheduler = scheduler.NewScheduler(c.Config, c.Metrics, c.Log)
 	c.taskScheduler.RunScheduler()
 
+	if previousScheduler != nil {
+		previousScheduler.SubmitPendingTasks(c.taskScheduler)
+	}
+
+	c.garbageCollectors = nil
+
 	// Enable running garbage-collect periodically for DefaultStore
 	if c.Config.Storage.GC {
 		gc := gc.NewGarbageCollect(c.StoreController.DefaultStore, c.MetaDB, gc.Options{
 			Delay:          c.Config.Storage.GCDelay,
-			ImageRetention: c.Config.Storage.Retention,
+			ImageRetention: c.retentionWithNamespaces(c.Config.Storage.Retention),
+			Metrics:        c.Metrics,
 		}, c.Audit, c.Log)
 
 		gc.CleanImageStorePeriodically(c.Config.Storage.GCInterval, c.taskScheduler)
+
+		c.garbageCollectors = append(c.garbageCollectors, gc)
 	}
 
 	// Enable running dedupe blobs both ways (dedupe or restore deduped blobs)
 	c.StoreController.DefaultStore.RunDedupeBlobs(time.Duration(0), c.taskScheduler)
 
+	if c.Config.Storage.UploadSessionTimeout > 0 {
+		c.StoreController.DefaultStore.RunBlobUploadsCleanup(c.Config.Storage.UploadSessionTimeout, c.taskScheduler)
+	}
+
+	if c.Config.Storage.Tiering != nil {
+		c.runTiering(c.StoreController.DefaultStore, c.Config.Storage.Tiering)
+	}
+
 	// Enable extensions if extension config is provided for DefaultStore
 	if c.Config != nil && c.Config.Extensions != nil {
 		ext.EnableMetricsExtension(c.Config, c.Log, c.Config.Storage.RootDirectory)
@@ -429,10 +861,13 @@ func (c *Controller) StartBackgroundTasks() {
 				gc := gc.NewGarbageCollect(c.StoreController.SubStore[route], c.MetaDB,
 					gc.Options{
 						Delay:          storageConfig.GCDelay,
-						ImageRetention: storageConfig.Retention,
+						ImageRetention: c.retentionWithNamespaces(storageConfig.Retention),
+						Metrics:        c.Metrics,
 					}, c.Audit, c.Log)
 
 				gc.CleanImageStorePeriodically(storageConfig.GCInterval, c.taskScheduler)
+
+				c.garbageCollectors = append(c.garbageCollectors, gc)
 			}
 
 			// Enable extensions if extension config is provided for subImageStore
@@ -445,6 +880,14 @@ func (c *Controller) StartBackgroundTasks() {
 			if substore != nil {
 				substore.RunDedupeBlobs(time.Duration(0), c.taskScheduler)
 
+				if storageConfig.UploadSessionTimeout > 0 {
+					substore.RunBlobUploadsCleanup(storageConfig.UploadSessionTimeout, c.taskScheduler)
+				}
+
+				if storageConfig.Tiering != nil {
+					c.runTiering(substore, storageConfig.Tiering)
+				}
+
 				if c.Config.IsMetricsEnabled() && c.Config.Storage.StorageDriver == nil {
 					substore.PopulateStorageMetrics(time.Duration(0), c.taskScheduler)
 				}
@@ -453,7 +896,7 @@ func (c *Controller) StartBackgroundTasks() {
 	}
 
 	if c.Config.Extensions != nil {
-		ext.EnableScrubExtension(c.Config, c.Log, c.StoreController, c.taskScheduler)
+		ext.EnableScrubExtension(c.Config, c.Log, c.StoreController, c.taskScheduler, c.ScrubReport, c.Metrics)
 		//nolint: contextcheck
 		syncOnDemand, err := ext.EnableSyncExtension(c.Config, c.MetaDB, c.StoreController, c.taskScheduler, c.Log)
 		if err != nil {
@@ -4,8 +4,10 @@
 package api_test
 
 import (
+	"archive/tar"
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -13,6 +15,8 @@ import (
 	goerrors "errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -26,6 +30,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
 	"github.com/google/go-github/v52/github"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/securecookie"
@@ -786,6 +792,7 @@ func TestRatelimit(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(resp, ShouldNotBeNil)
 			So(resp.StatusCode(), ShouldEqual, http.StatusTooManyRequests)
+			So(resp.Header().Get("Retry-After"), ShouldEqual, "1")
 		})
 	})
 
@@ -858,6 +865,111 @@ func TestRatelimit(t *testing.T) {
 			So(resp.StatusCode(), ShouldEqual, http.StatusTooManyRequests)
 		})
 	})
+
+	Convey("Make a new controller", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString("alice", "alice") +
+			test.GetCredString("bob", "bob"))
+		defer os.Remove(htpasswdPath)
+
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+
+		userRate := 1
+		conf.HTTP.Ratelimit = &config.RatelimitConfig{
+			UserRate: &userRate,
+		}
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+		Convey("User Ratelimit", func() {
+			client := resty.New()
+			// first request should succeed
+			resp, err := client.R().SetBasicAuth("alice", "alice").Get(baseURL + "/v2/")
+			So(err, ShouldBeNil)
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			// second request back-to-back should fail
+			resp, err = client.R().SetBasicAuth("alice", "alice").Get(baseURL + "/v2/")
+			So(err, ShouldBeNil)
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusTooManyRequests)
+			So(resp.Header().Get("Retry-After"), ShouldEqual, "1")
+			// other users from the same address are not limited
+			resp, err = client.R().SetBasicAuth("bob", "bob").Get(baseURL + "/v2/")
+			So(err, ShouldBeNil)
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		})
+	})
+
+	Convey("Make a new controller", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		maxUploads := 1
+		conf.HTTP.Ratelimit = &config.RatelimitConfig{
+			MaxConcurrentUploads: &maxUploads,
+		}
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+		Convey("Concurrent uploads limit", func() {
+			resp, err := resty.R().Post(baseURL + "/v2/repo/blobs/uploads/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+			loc := test.Location(baseURL, resp)
+
+			// the first upload is still being sent
+			reader, writer := io.Pipe()
+			done := make(chan int)
+
+			go func() {
+				resp, err := resty.R().SetHeader("Content-Type", "application/octet-stream").
+					SetBody(reader).Patch(loc)
+				if err != nil {
+					done <- 0
+
+					return
+				}
+
+				done <- resp.StatusCode()
+			}()
+
+			_, err = writer.Write([]byte("chunk"))
+			So(err, ShouldBeNil)
+
+			resp, err = resty.R().Post(baseURL + "/v2/repo/blobs/uploads/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusTooManyRequests)
+			So(resp.Header().Get("Retry-After"), ShouldEqual, "1")
+
+			// the other requests are not limited
+			resp, err = resty.R().Get(baseURL + "/v2/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			writer.Close()
+			So(<-done, ShouldEqual, http.StatusAccepted)
+
+			resp, err = resty.R().Post(baseURL + "/v2/repo/blobs/uploads/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		})
+	})
 }
 
 func TestBasicAuth(t *testing.T) {
@@ -993,7 +1105,7 @@ func TestPrintTracebackOnPanic(t *testing.T) {
 }
 
 func TestInterruptedBlobUpload(t *testing.T) {
-	Convey("Successfully cleaning interrupted blob uploads", t, func() {
+	Convey("Handling interrupted blob uploads", t, func() {
 		port := test.GetFreePort()
 		baseURL := test.GetBaseURL(port)
 		conf := config.New()
@@ -1054,13 +1166,16 @@ func TestInterruptedBlobUpload(t *testing.T) {
 				time.Sleep(100 * time.Millisecond)
 			}
 
-			// wait for zot to remove blobUpload
+			// wait for zot to handle the interrupted request
 			time.Sleep(1 * time.Second)
 
+			// what was received is kept for the upload to be resumed
 			resp, err = client.R().Get(baseURL + "/v2/" + repoName + "/blobs/uploads/" + sessionID)
 			So(err, ShouldBeNil)
 			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
+			So(resp.Header().Get("Range"), ShouldStartWith, "0-")
+			So(resp.Header().Get("Range"), ShouldNotEqual, "0-0")
 		})
 
 		//nolint: dupl
@@ -4629,6 +4744,104 @@ func TestAuthorization(t *testing.T) {
 	})
 }
 
+// rolesUser is an openid user whose groups are sent in the "roles" claim.
+type rolesUser struct {
+	*mockoidc.MockUser
+	roles []string
+}
+
+func (user rolesUser) Userinfo(scope []string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{"sub": user.Subject, "email": user.Email, "roles": user.roles})
+}
+
+func TestOpenIDGroupsClaim(t *testing.T) {
+	Convey("Groups are read from the configured claim", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		mockOIDCServer, err := authutils.MockOIDCRun()
+		if err != nil {
+			panic(err)
+		}
+
+		defer func() {
+			err := mockOIDCServer.Shutdown()
+			if err != nil {
+				panic(err)
+			}
+		}()
+
+		mockOIDCConfig := mockOIDCServer.Config()
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Auth = &config.AuthConfig{
+			OpenID: &config.OpenIDConfig{
+				Providers: map[string]config.OpenIDProviderConfig{
+					"oidc": {
+						ClientID:     mockOIDCConfig.ClientID,
+						ClientSecret: mockOIDCConfig.ClientSecret,
+						Issuer:       mockOIDCConfig.Issuer,
+						Scopes:       []string{"openid", "email"},
+						GroupsClaim:  "roles",
+					},
+				},
+			},
+		}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				"team-a/**": config.PolicyGroup{
+					Policies: []config.Policy{
+						{
+							Groups:  []string{"team-a"},
+							Actions: []string{"read"},
+						},
+					},
+				},
+			},
+		}
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+
+		for _, repo := range []string{"team-a/app", "team-b/app"} {
+			err = WriteImageToFileSystem(CreateDefaultImage(), repo, "0.0.1",
+				ociutils.GetDefaultStoreController(ctlr.Config.Storage.RootDirectory, ctlr.Log))
+			So(err, ShouldBeNil)
+		}
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		client := resty.New()
+		client.SetRedirectPolicy(test.CustomRedirectPolicy(20))
+
+		mockOIDCServer.QueueUser(rolesUser{
+			MockUser: &mockoidc.MockUser{Email: "alice@example.com", Subject: "1234567890"},
+			roles:    []string{"team-a"},
+		})
+
+		resp, err := client.R().
+			SetHeader(constants.SessionClientHeaderName, constants.SessionClientHeaderValue).
+			SetQueryParam("provider", "oidc").
+			Get(baseURL + constants.LoginPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		client.SetCookies(resp.Cookies())
+		client.SetHeader(constants.SessionClientHeaderName, constants.SessionClientHeaderValue)
+
+		resp, err = client.R().Get(baseURL + "/v2/team-a/app/tags/list")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = client.R().Get(baseURL + "/v2/team-b/app/tags/list")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+	})
+}
+
 func TestGetUsername(t *testing.T) {
 	Convey("Make a new controller", t, func() {
 		port := test.GetFreePort()
@@ -5126,6 +5339,152 @@ func TestAuthorizationWithAnonymousPolicyBasicAuthAndSessionHeader(t *testing.T)
 	})
 }
 
+func TestPublicRepositories(t *testing.T) {
+	Convey("Anonymous users pull from the public repositories", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString("user", "user"))
+		defer os.Remove(htpasswdPath)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+			PublicRepositories: []string{"public/**"},
+		}
+
+		Convey("Without access control", func() {
+			testPublicRepositories(t, conf, baseURL, port)
+		})
+
+		Convey("With access control", func() {
+			conf.HTTP.AccessControl = &config.AccessControlConfig{
+				Repositories: config.Repositories{
+					"**": config.PolicyGroup{
+						Policies: []config.Policy{
+							{
+								Users:   []string{"user"},
+								Actions: []string{"read", "create", "update", "delete"},
+							},
+						},
+					},
+				},
+			}
+
+			testPublicRepositories(t, conf, baseURL, port)
+		})
+
+		Convey("Reloading the public repositories while anonymous users pull", func() {
+			ctlr := makeController(conf, t.TempDir())
+			cm := test.NewControllerManager(ctlr)
+			cm.StartAndWait(port)
+			defer cm.StopServer()
+
+			image := CreateRandomImage()
+			So(UploadImageWithBasicAuth(image, baseURL, "public/app", "1.0", "user", "user"), ShouldBeNil)
+			So(UploadImageWithBasicAuth(image, baseURL, "private/app", "1.0", "user", "user"), ShouldBeNil)
+
+			reloaded := make(chan struct{})
+
+			go func() {
+				defer close(reloaded)
+
+				for i := 0; i < 10; i++ {
+					newConfig := config.New()
+					newConfig.HTTP.Auth = &config.AuthConfig{
+						PublicRepositories: []string{"public/**"},
+					}
+
+					if i%2 == 1 {
+						newConfig.HTTP.Auth.PublicRepositories = []string{"private/**"}
+					}
+
+					ctlr.LoadNewConfig(newConfig)
+				}
+			}()
+
+			for i := 0; i < 10; i++ {
+				_, err := resty.R().Get(baseURL + "/v2/public/app/manifests/1.0")
+				So(err, ShouldBeNil)
+			}
+
+			<-reloaded
+
+			resp, err := resty.R().Get(baseURL + "/v2/private/app/manifests/1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			resp, err = resty.R().Get(baseURL + "/v2/public/app/manifests/1.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+		})
+	})
+}
+
+func testPublicRepositories(t *testing.T, conf *config.Config, baseURL, port string) {
+	t.Helper()
+
+	ctlr := makeController(conf, t.TempDir())
+	cm := test.NewControllerManager(ctlr)
+	cm.StartAndWait(port)
+	defer cm.StopServer()
+
+	image := CreateRandomImage()
+	So(UploadImageWithBasicAuth(image, baseURL, "public/app", "1.0", "user", "user"), ShouldBeNil)
+	So(UploadImageWithBasicAuth(image, baseURL, "private/app", "1.0", "user", "user"), ShouldBeNil)
+
+	resp, err := resty.R().Get(baseURL + "/v2/")
+	So(err, ShouldBeNil)
+	So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+	resp, err = resty.R().Get(baseURL + "/v2/public/app/manifests/1.0")
+	So(err, ShouldBeNil)
+	So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+	resp, err = resty.R().Head(baseURL + "/v2/public/app/blobs/" + image.Manifest.Layers[0].Digest.String())
+	So(err, ShouldBeNil)
+	So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+	resp, err = resty.R().Get(baseURL + "/v2/public/app/tags/list")
+	So(err, ShouldBeNil)
+	So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+	resp, err = resty.R().Get(baseURL + "/v2/private/app/manifests/1.0")
+	So(err, ShouldBeNil)
+	So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+	// pushing still requires authentication
+	resp, err = resty.R().Post(baseURL + "/v2/public/app/blobs/uploads/")
+	So(err, ShouldBeNil)
+	So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+	resp, err = resty.R().Delete(baseURL + "/v2/public/app/manifests/1.0")
+	So(err, ShouldBeNil)
+	So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+	// anonymous users only see the public repositories in the catalog
+	var catalog api.RepositoryList
+
+	resp, err = resty.R().Get(baseURL + "/v2/_catalog")
+	So(err, ShouldBeNil)
+	So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+	So(json.Unmarshal(resp.Body(), &catalog), ShouldBeNil)
+	So(catalog.Repositories, ShouldResemble, []string{"public/app"})
+
+	resp, err = resty.R().SetBasicAuth("user", "user").Get(baseURL + "/v2/_catalog")
+	So(err, ShouldBeNil)
+	So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+	So(json.Unmarshal(resp.Body(), &catalog), ShouldBeNil)
+	So(catalog.Repositories, ShouldHaveLength, 2)
+
+	resp, err = resty.R().SetBasicAuth("user", "bad").Get(baseURL + "/v2/public/app/manifests/1.0")
+	So(err, ShouldBeNil)
+	So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+}
+
 func TestAuthorizationWithMultiplePolicies(t *testing.T) {
 	Convey("Make a new controller", t, func() {
 		port := test.GetFreePort()
@@ -5484,28 +5843,41 @@ func TestCrossRepoMount(t *testing.T) {
 			baseURL, constants.RoutePrefix, constants.Blobs, constants.Uploads))
 
 		// Use correct request
-		// This is correct request but it will return 202 because blob is not present in cache.
+		// The blob is not in the dedupe cache, it's copied from the repository given in the from parameter.
 		params["mount"] = string(manifestDigest)
 		postResponse, err = client.R().
 			SetBasicAuth(username, password).SetQueryParams(params).
 			Post(baseURL + "/v2/zot-c-test/blobs/uploads/")
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
-		So(test.Location(baseURL, postResponse), ShouldStartWith, fmt.Sprintf("%s%s/zot-c-test/%s/%s",
-			baseURL, constants.RoutePrefix, constants.Blobs, constants.Uploads))
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
+		So(test.Location(baseURL, postResponse), ShouldEqual, fmt.Sprintf("%s%s/zot-c-test/%s/%s",
+			baseURL, constants.RoutePrefix, constants.Blobs, manifestDigest))
+
+		headResponse, err = client.R().SetBasicAuth(username, password).
+			Head(fmt.Sprintf("%s/v2/zot-c-test/blobs/%s", baseURL, manifestDigest))
+		So(err, ShouldBeNil)
+		So(headResponse.StatusCode(), ShouldEqual, http.StatusOK)
 
 		// Send same request again
 		postResponse, err = client.R().
 			SetBasicAuth(username, password).SetQueryParams(params).
 			Post(baseURL + "/v2/zot-c-test/blobs/uploads/")
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
 
 		// Valid requests
 		postResponse, err = client.R().
 			SetBasicAuth(username, password).SetQueryParams(params).
 			Post(baseURL + "/v2/zot-d-test/blobs/uploads/")
 		So(err, ShouldBeNil)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		// the blob is not mounted if it's not in the from repository
+		missingParams := map[string]string{"mount": string(manifestDigest), "from": "zot-x-test"}
+		postResponse, err = client.R().
+			SetBasicAuth(username, password).SetQueryParams(missingParams).
+			Post(baseURL + "/v2/zot-e-test/blobs/uploads/")
+		So(err, ShouldBeNil)
 		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
 
 		headResponse, err = client.R().SetBasicAuth(username, password).
@@ -5516,7 +5888,7 @@ func TestCrossRepoMount(t *testing.T) {
 		postResponse, err = client.R().
 			SetBasicAuth(username, password).SetQueryParams(params).Post(baseURL + "/v2/zot-c-test/blobs/uploads/")
 		So(err, ShouldBeNil)
-		So(postResponse.StatusCode(), ShouldEqual, http.StatusAccepted)
+		So(postResponse.StatusCode(), ShouldEqual, http.StatusCreated)
 
 		postResponse, err = client.R().
 			SetBasicAuth(username, password).SetQueryParams(params).
@@ -5664,6 +6036,82 @@ func TestCrossRepoMount(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(headResponse.StatusCode(), ShouldEqual, http.StatusNotFound)
 	})
+
+	Convey("Mount with access control", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString(username, password))
+
+		defer os.Remove(htpasswdPath)
+
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				"public/**": config.PolicyGroup{
+					Policies: []config.Policy{{Users: []string{username}, Actions: []string{"read", "create"}}},
+				},
+				"private/**": config.PolicyGroup{
+					Policies: []config.Policy{{Users: []string{"admin"}, Actions: []string{"read"}}},
+				},
+			},
+		}
+
+		dir := t.TempDir()
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = dir
+		ctlr.Config.Storage.GC = false
+
+		image := CreateImageWith().RandomLayers(1, 10).DefaultConfig().Build()
+		storeController := ociutils.GetDefaultStoreController(dir, ctlr.Log)
+
+		So(WriteImageToFileSystem(image, "public/src", "0.0.1", storeController), ShouldBeNil)
+		So(WriteImageToFileSystem(image, "private/src", "0.0.1", storeController), ShouldBeNil)
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		digest := godigest.FromBytes(image.Layers[0]).String()
+		client := resty.New()
+
+		// the user can't read the from repository, a regular upload is started
+		resp, err := client.R().SetBasicAuth(username, password).
+			SetQueryParams(map[string]string{"mount": digest, "from": "private/src"}).
+			Post(baseURL + "/v2/public/dst/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		// the repository of the blob isn't known
+		resp, err = client.R().SetBasicAuth(username, password).
+			SetQueryParams(map[string]string{"mount": digest}).
+			Post(baseURL + "/v2/public/dst/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		resp, err = client.R().SetBasicAuth(username, password).
+			Head(fmt.Sprintf("%s/v2/public/dst/blobs/%s", baseURL, digest))
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = client.R().SetBasicAuth(username, password).
+			SetQueryParams(map[string]string{"mount": digest, "from": "public/src"}).
+			Post(baseURL + "/v2/public/dst/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		resp, err = client.R().SetBasicAuth(username, password).
+			Head(fmt.Sprintf("%s/v2/public/dst/blobs/%s", baseURL, digest))
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+	})
 }
 
 func TestParallelRequests(t *testing.T) {
@@ -7518,6 +7966,99 @@ func TestListingTags(t *testing.T) {
 	}
 }
 
+func TestListTagsDetails(t *testing.T) {
+	Convey("Make a new controller", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		repoName := "details"
+
+		image := CreateRandomImage()
+		err := UploadImage(image, baseURL, repoName, "1.0")
+		So(err, ShouldBeNil)
+
+		multiarch := CreateRandomMultiarch()
+		err = UploadMultiarchImage(multiarch, baseURL, repoName, "2.0")
+		So(err, ShouldBeNil)
+
+		Convey("The details aren't returned by default", func() {
+			resp, err := resty.R().Get(baseURL + "/v2/" + repoName + "/tags/list")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			var tags common.ImageTags
+			err = json.Unmarshal(resp.Body(), &tags)
+			So(err, ShouldBeNil)
+			So(tags.Tags, ShouldResemble, []string{"1.0", "2.0"})
+			So(tags.Details, ShouldBeEmpty)
+			So(tags.Total, ShouldEqual, 0)
+		})
+
+		Convey("The details of the tags are returned", func() {
+			resp, err := resty.R().Get(baseURL + "/v2/" + repoName + "/tags/list?details=true")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			var tags common.ImageTags
+			err = json.Unmarshal(resp.Body(), &tags)
+			So(err, ShouldBeNil)
+			So(tags.Tags, ShouldResemble, []string{"1.0", "2.0"})
+			So(tags.Total, ShouldEqual, 2)
+			So(len(tags.Details), ShouldEqual, 2)
+
+			So(tags.Details[0].Tag, ShouldEqual, "1.0")
+			So(tags.Details[0].Digest, ShouldEqual, image.ManifestDescriptor.Digest.String())
+			So(tags.Details[0].MediaType, ShouldEqual, ispec.MediaTypeImageManifest)
+			So(tags.Details[0].Size, ShouldEqual, image.ManifestDescriptor.Size)
+			So(tags.Details[0].LastModified.IsZero(), ShouldBeFalse)
+
+			So(tags.Details[1].Tag, ShouldEqual, "2.0")
+			So(tags.Details[1].Digest, ShouldEqual, multiarch.IndexDescriptor.Digest.String())
+			So(tags.Details[1].MediaType, ShouldEqual, ispec.MediaTypeImageIndex)
+			So(tags.Details[1].LastModified.IsZero(), ShouldBeFalse)
+		})
+
+		Convey("The next pages keep the details", func() {
+			resp, err := resty.R().Get(baseURL + "/v2/" + repoName + "/tags/list?n=1&details=true")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Header().Get("Link"), ShouldEqual,
+				fmt.Sprintf("</v2/%s/tags/list?n=1&last=1.0&details=true>; rel=\"next\"", repoName))
+
+			var tags common.ImageTags
+			err = json.Unmarshal(resp.Body(), &tags)
+			So(err, ShouldBeNil)
+			So(tags.Tags, ShouldResemble, []string{"1.0"})
+			So(tags.Total, ShouldEqual, 2)
+			So(len(tags.Details), ShouldEqual, 1)
+
+			resp, err = resty.R().Get(baseURL + "/v2/" + repoName + "/tags/list?n=1&last=1.0&details=true")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			err = json.Unmarshal(resp.Body(), &tags)
+			So(err, ShouldBeNil)
+			So(tags.Tags, ShouldResemble, []string{"2.0"})
+			So(len(tags.Details), ShouldEqual, 1)
+			So(tags.Details[0].Tag, ShouldEqual, "2.0")
+		})
+
+		Convey("An invalid details value is rejected", func() {
+			resp, err := resty.R().Get(baseURL + "/v2/" + repoName + "/tags/list?details=maybe")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
+
 func TestStorageCommit(t *testing.T) {
 	Convey("Make a new controller", t, func() {
 		port := test.GetFreePort()
@@ -8213,6 +8754,60 @@ func TestPullRange(t *testing.T) {
 			So(resp.Body(), ShouldResemble, content[2:4])
 		})
 
+		Convey("Get several ranges of bytes", func() {
+			resp, err = resty.R().SetHeader("Range", "bytes=0-1, 4-6,8-").
+				SetHeader("Accept", ispec.MediaTypeImageLayerZstd).Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusPartialContent)
+
+			mediaType, params, err := mime.ParseMediaType(resp.Header().Get("Content-Type"))
+			So(err, ShouldBeNil)
+			So(mediaType, ShouldEqual, "multipart/byteranges")
+
+			reader := multipart.NewReader(bytes.NewReader(resp.Body()), params["boundary"])
+			parts := []string{}
+			contentRanges := []string{}
+
+			for {
+				part, err := reader.NextPart()
+				if goerrors.Is(err, io.EOF) {
+					break
+				}
+
+				So(err, ShouldBeNil)
+				So(part.Header.Get("Content-Type"), ShouldEqual, ispec.MediaTypeImageLayerZstd)
+
+				partContent, err := io.ReadAll(part)
+				So(err, ShouldBeNil)
+
+				parts = append(parts, string(partContent))
+				contentRanges = append(contentRanges, part.Header.Get("Content-Range"))
+			}
+
+			So(parts, ShouldResemble, []string{"01", "456", "89"})
+			So(contentRanges, ShouldResemble, []string{"bytes 0-1/10", "bytes 4-6/10", "bytes 8-9/10"})
+
+			resp, err = resty.R().SetHeader("Range", "bytes=0-1,a-b").Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusRequestedRangeNotSatisfiable)
+
+			resp, err = resty.R().SetHeader("Range", "bytes=0-1,2-3").
+				Get(baseURL + "/v2/index/blobs/" + godigest.FromString("missing").String())
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		})
+
+		Convey("Content type of blobs", func() {
+			resp, err = resty.R().Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.Header().Get("Content-Type"), ShouldEqual, "application/octet-stream")
+
+			resp, err = resty.R().SetHeader("Accept",
+				ispec.MediaTypeImageLayerZstd+";q=0.9, "+ispec.MediaTypeImageLayerGzip).Get(blobLoc)
+			So(err, ShouldBeNil)
+			So(resp.Header().Get("Content-Type"), ShouldEqual, ispec.MediaTypeImageLayerZstd)
+		})
+
 		Convey("Negative cases", func() {
 			resp, err = resty.R().SetHeader("Range", "=0").Get(blobLoc)
 			So(err, ShouldBeNil)
@@ -9081,109 +9676,1639 @@ func TestPeriodicGC(t *testing.T) {
 	})
 }
 
-func TestSearchRoutes(t *testing.T) {
-	Convey("Upload image for test", t, func(c C) {
-		tempDir := t.TempDir()
+func TestGCOnDemand(t *testing.T) {
+	Convey("GC is run on demand", t, func() {
 		repoName := "testrepo" //nolint:goconst
-		inaccessibleRepo := "inaccessible"
 
-		Convey("GlobalSearch with authz enabled", func(c C) {
-			conf := config.New()
-			port := test.GetFreePort()
-			baseURL := test.GetBaseURL(port)
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
 
-			user1 := "test"
-			password1 := "test"
-			testString1 := test.GetCredString(user1, password1)
-			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
-			defer os.Remove(htpasswdPath)
-			conf.HTTP.Auth = &config.AuthConfig{
-				HTPasswd: config.AuthHTPasswd{
-					Path: htpasswdPath,
-				},
-			}
+		ctlr := api.NewController(conf)
+		dir := t.TempDir()
+		ctlr.Config.Storage.RootDirectory = dir
+		ctlr.Config.Storage.Dedupe = false
+		ctlr.Config.Storage.GC = true
+		ctlr.Config.Storage.GCInterval = 1 * time.Hour
+		ctlr.Config.Storage.GCDelay = 1 * time.Second
 
-			conf.HTTP.Port = port
+		err := WriteImageToFileSystem(CreateDefaultImage(), repoName, "0.0.1",
+			ociutils.GetDefaultStoreController(dir, ctlr.Log))
+		So(err, ShouldBeNil)
 
-			defaultVal := true
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-			searchConfig := &extconf.SearchConfig{
-				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
-			}
+		// a blob no manifest references, old enough to be removed
+		content := []byte("orphan blob")
+		blobPath := path.Join(dir, repoName, "blobs", "sha256", godigest.FromBytes(content).Encoded())
+		So(os.WriteFile(blobPath, content, 0o600), ShouldBeNil)
+		So(os.Chtimes(blobPath, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)), ShouldBeNil)
 
-			conf.Extensions = &extconf.ExtensionConfig{
-				Search: searchConfig,
-			}
+		resp, err := resty.R().Post(baseURL + constants.RoutePrefix + constants.GCPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
 
-			conf.HTTP.AccessControl = &config.AccessControlConfig{
-				Repositories: config.Repositories{
-					repoName: config.PolicyGroup{
-						Policies: []config.Policy{
-							{
-								Users:   []string{user1},
-								Actions: []string{"read", "create"},
-							},
-						},
-						DefaultPolicy: []string{},
-					},
-					inaccessibleRepo: config.PolicyGroup{
-						Policies: []config.Policy{
-							{
-								Users:   []string{user1},
-								Actions: []string{"create"},
-							},
-						},
-						DefaultPolicy: []string{},
-					},
-				},
-				AdminPolicy: config.Policy{
-					Users:   []string{},
-					Actions: []string{},
-				},
+		for i := 0; i < 100; i++ {
+			if _, err = os.Stat(blobPath); err != nil {
+				break
 			}
 
-			ctlr := makeController(conf, tempDir)
-
-			cm := test.NewControllerManager(ctlr)
-			cm.StartAndWait(port)
-			defer cm.StopServer()
+			time.Sleep(100 * time.Millisecond)
+		}
 
-			img := CreateImageWith().RandomLayers(1, 10000).DefaultConfig().Build()
+		So(os.IsNotExist(err), ShouldBeTrue)
 
-			err := UploadImageWithBasicAuth(
-				img, baseURL, repoName, "latest",
-				user1, password1)
-			So(err, ShouldBeNil)
+		resp, err = resty.R().Get(baseURL + constants.RoutePrefix + constants.GCPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusMethodNotAllowed)
+	})
 
-			// data for the inaccessible repo
-			img = CreateImageWith().RandomLayers(1, 10000).DefaultConfig().Build()
+	Convey("GC is not enabled", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.GC = false
 
-			err = UploadImageWithBasicAuth(
-				img, baseURL, inaccessibleRepo, "latest",
-				user1, password1)
-			So(err, ShouldBeNil)
+		ctlr := makeController(conf, t.TempDir())
 
-			query := `
-				{
-					GlobalSearch(query:"testrepo"){
-						Repos {
-							Name
-							NewestImage {
-								RepoName
-								Tag
-							}
-						}
-					}
-				}`
-			resp, err := resty.R().SetBasicAuth(user1, password1).Get(baseURL + constants.FullSearchPrefix +
-				"?query=" + url.QueryEscape(query))
-			So(err, ShouldBeNil)
-			So(resp, ShouldNotBeNil)
-			So(resp.StatusCode(), ShouldEqual, 200)
-			So(string(resp.Body()), ShouldContainSubstring, repoName)
-			So(string(resp.Body()), ShouldNotContainSubstring, inaccessibleRepo)
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
 
-			resp, err = resty.R().Get(baseURL + constants.FullSearchPrefix + "?query=" + url.QueryEscape(query))
+		resp, err := resty.R().Post(baseURL + constants.RoutePrefix + constants.GCPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusMethodNotAllowed)
+	})
+
+	Convey("GC on demand is only for admins", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString("admin", "admin") +
+			test.GetCredString("user", "user"))
+		defer os.Remove(htpasswdPath)
+
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			AdminPolicy: config.Policy{
+				Users:   []string{"admin"},
+				Actions: []string{},
+			},
+		}
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		resp, err := resty.R().Post(baseURL + constants.RoutePrefix + constants.GCPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+		resp, err = resty.R().SetBasicAuth("user", "user").Post(baseURL + constants.RoutePrefix + constants.GCPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Post(baseURL + constants.RoutePrefix + constants.GCPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+	})
+}
+
+func TestIPAccess(t *testing.T) {
+	Convey("Requests are filtered by client address", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.IPAccess = &config.IPAccessConfig{
+			IPRules: config.IPRules{
+				Deny: []string{"192.168.0.0/16"},
+			},
+			Push: &config.IPRules{
+				Allow: []string{"10.0.0.0/8"},
+			},
+			Admin: &config.IPRules{
+				Allow: []string{"10.0.0.1"},
+			},
+			TrustedProxies: []string{"127.0.0.1"},
+		}
+
+		enable := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Scrub: &extconf.ScrubConfig{BaseConfig: extconf.BaseConfig{Enable: &enable}, Interval: time.Hour},
+		}
+
+		ctlr := makeController(conf, t.TempDir())
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		resp, err := resty.R().Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().SetHeader("X-Forwarded-For", "192.168.1.1").Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		// pushes are only allowed from the internal range
+		resp, err = resty.R().Post(baseURL + "/v2/app/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		var apiErrList apiErr.ErrorList
+
+		So(json.Unmarshal(resp.Body(), &apiErrList), ShouldBeNil)
+		So(apiErrList.Errors, ShouldHaveLength, 1)
+		So(apiErrList.Errors[0].Code, ShouldEqual, "DENIED")
+
+		resp, err = resty.R().SetHeader("X-Forwarded-For", "10.1.2.3").Post(baseURL + "/v2/app/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		// only the addresses added by the trusted proxies are used
+		resp, err = resty.R().SetHeader("X-Forwarded-For", "10.1.2.3, 192.168.1.1").
+			Post(baseURL + "/v2/app/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetHeader("X-Forwarded-For", "10.1.2.3").
+			Get(baseURL + constants.RoutePrefix + constants.ReadOnlyPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetHeader("X-Forwarded-For", "10.0.0.1").
+			Get(baseURL + constants.RoutePrefix + constants.ReadOnlyPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		// the other routes only admins can use are admin requests too
+		resp, err = resty.R().SetHeader("X-Forwarded-For", "10.1.2.3").Get(baseURL + constants.FullScrub)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetHeader("X-Forwarded-For", "10.0.0.1").Get(baseURL + constants.FullScrub)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+	})
+}
+
+func TestAdminAPI(t *testing.T) {
+	Convey("Admins manage the repositories", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.GC = true
+		conf.Storage.GCInterval = time.Hour
+
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString("admin", "admin") +
+			test.GetCredString("user", "user"))
+		defer os.Remove(htpasswdPath)
+
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				"**": config.PolicyGroup{
+					Policies: []config.Policy{
+						{
+							Users:   []string{"user"},
+							Actions: []string{"read", "create", "update", "delete"},
+						},
+					},
+				},
+			},
+			AdminPolicy: config.Policy{
+				Users:   []string{"admin"},
+				Actions: []string{"read", "create", "update", "delete"},
+			},
+		}
+
+		dir := t.TempDir()
+		ctlr := makeController(conf, dir)
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		image := CreateRandomImage()
+		err := UploadImageWithBasicAuth(image, baseURL, "app", "1.0", "user", "user")
+		So(err, ShouldBeNil)
+		err = UploadImageWithBasicAuth(image, baseURL, "app", "latest", "user", "user")
+		So(err, ShouldBeNil)
+		err = UploadImageWithBasicAuth(CreateRandomImage(), baseURL, "other", "1.0", "user", "user")
+		So(err, ShouldBeNil)
+
+		resp, err := resty.R().SetBasicAuth("user", "user").Post(baseURL + "/v2/app/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		sessionID := path.Base(resp.Header().Get("Location"))
+
+		reposURL := baseURL + constants.RoutePrefix + constants.AdminReposPath
+
+		resp, err = resty.R().SetBasicAuth("user", "user").Get(reposURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Get(reposURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var stats []storage.RepoStats
+
+		err = json.Unmarshal(resp.Body(), &stats)
+		So(err, ShouldBeNil)
+		So(stats, ShouldHaveLength, 2)
+		So(stats[0].Name, ShouldEqual, "app")
+		So(stats[0].Tags, ShouldEqual, 2)
+		So(stats[0].Manifests, ShouldEqual, 2)
+		So(stats[0].Blobs, ShouldEqual, 3)
+		So(stats[0].Size, ShouldEqual, image.ManifestDescriptor.Size+image.Manifest.Config.Size+
+			image.Manifest.Layers[0].Size)
+		So(stats[0].Uploads, ShouldEqual, 1)
+		So(stats[1].Name, ShouldEqual, "other")
+
+		resp, err = resty.R().SetBasicAuth("admin", "admin").
+			Get(baseURL + constants.RoutePrefix + constants.AdminUploadsPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var uploads []storage.RepoUploads
+
+		err = json.Unmarshal(resp.Body(), &uploads)
+		So(err, ShouldBeNil)
+		So(uploads, ShouldHaveLength, 1)
+		So(uploads[0].Name, ShouldEqual, "app")
+		So(uploads[0].Uploads, ShouldHaveLength, 1)
+		So(uploads[0].Uploads[0].SessionID, ShouldEqual, sessionID)
+
+		resp, err = resty.R().SetBasicAuth("user", "user").Delete(reposURL + "/app")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Delete(reposURL + "/app")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Delete(reposURL + "/app")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().SetBasicAuth("user", "user").Get(baseURL + "/v2/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		resp, err = resty.R().SetBasicAuth("user", "user").Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(string(resp.Body()), ShouldNotContainSubstring, `"app"`)
+
+		_, err = os.Stat(path.Join(dir, "app"))
+		So(os.IsNotExist(err), ShouldBeTrue)
+
+		_, err = ctlr.MetaDB.GetRepoMeta(context.Background(), "app")
+		So(err, ShouldNotBeNil)
+
+		tasksURL := baseURL + constants.RoutePrefix + constants.AdminTasksPath
+
+		resp, err = resty.R().SetBasicAuth("user", "user").Post(tasksURL + "/gc")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Post(tasksURL + "/gc")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		// neither scrub nor sync are enabled
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Post(tasksURL + "/scrub")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusMethodNotAllowed)
+
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Post(tasksURL + "/sync")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusMethodNotAllowed)
+
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Post(tasksURL + "/dedupe")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		adminURL := baseURL + constants.RoutePrefix + constants.AdminPath
+
+		resp, err = resty.R().SetBasicAuth("user", "user").Get(adminURL + "/gc/dryrun")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		for _, task := range []string{"gc", "retention"} {
+			resp, err = resty.R().SetBasicAuth("admin", "admin").Get(adminURL + "/" + task + "/dryrun")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			var report gc.Report
+			err = json.Unmarshal(resp.Body(), &report)
+			So(err, ShouldBeNil)
+			So(report.Manifests, ShouldNotBeNil)
+			So(report.Blobs, ShouldNotBeNil)
+		}
+
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Get(adminURL + "/scrub/dryrun")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+	})
+}
+
+func TestNamespaces(t *testing.T) {
+	Convey("Namespace admins manage the settings of their namespace", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString("admin", "admin") +
+			test.GetCredString("lead", "lead") + test.GetCredString("dev", "dev"))
+		defer os.Remove(htpasswdPath)
+
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			Repositories: config.Repositories{
+				"**": config.PolicyGroup{
+					Policies: []config.Policy{
+						{
+							Users:   []string{"lead"},
+							Actions: []string{"read", "create"},
+						},
+					},
+				},
+			},
+			AdminPolicy: config.Policy{
+				Users:   []string{"admin"},
+				Actions: []string{"read", "create", "update", "delete"},
+			},
+			Namespaces: config.Namespaces{
+				"team-a": config.NamespaceConfig{AdminUsers: []string{"lead"}},
+				"team-b": config.NamespaceConfig{},
+			},
+		}
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		namespacesURL := baseURL + constants.RoutePrefix + constants.AdminNamespacesPath
+
+		var namespaces []api.NamespaceSettings
+
+		resp, err := resty.R().SetBasicAuth("lead", "lead").Get(namespacesURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		err = json.Unmarshal(resp.Body(), &namespaces)
+		So(err, ShouldBeNil)
+		So(namespaces, ShouldHaveLength, 1)
+		So(namespaces[0].Name, ShouldEqual, "team-a")
+
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Get(namespacesURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		err = json.Unmarshal(resp.Body(), &namespaces)
+		So(err, ShouldBeNil)
+		So(namespaces, ShouldHaveLength, 2)
+
+		resp, err = resty.R().SetBasicAuth("dev", "dev").Get(namespacesURL + "/team-a")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetBasicAuth("lead", "lead").Get(namespacesURL + "/team-b")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Get(namespacesURL + "/team-c")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		// dev has no permission until the namespace admin grants it
+		err = UploadImageWithBasicAuth(CreateRandomImage(), baseURL, "team-a/app", "1.0", "dev", "dev")
+		So(err, ShouldNotBeNil)
+
+		policies := `{"policies": [{"users": ["dev"], "actions": ["read", "create"]}]}`
+
+		resp, err = resty.R().SetBasicAuth("dev", "dev").SetBody(policies).
+			Put(namespacesURL + "/team-a/policies")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetBasicAuth("lead", "lead").SetBody(policies).
+			Put(namespacesURL + "/team-b/policies")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetBasicAuth("lead", "lead").
+			SetBody(`{"policies": [{"users": ["dev"], "actions": ["pull"]}]}`).
+			Put(namespacesURL + "/team-a/policies")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+		resp, err = resty.R().SetBasicAuth("lead", "lead").SetBody(`{"unknown": true}`).
+			Put(namespacesURL + "/team-a/policies")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+		resp, err = resty.R().SetBasicAuth("lead", "lead").SetBody(policies).
+			Put(namespacesURL + "/team-a/policies")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		err = UploadImageWithBasicAuth(CreateRandomImage(), baseURL, "team-a/app", "1.0", "dev", "dev")
+		So(err, ShouldBeNil)
+
+		// the namespace policies don't apply outside of it
+		err = UploadImageWithBasicAuth(CreateRandomImage(), baseURL, "team-b/app", "1.0", "dev", "dev")
+		So(err, ShouldNotBeNil)
+
+		resp, err = resty.R().SetBasicAuth("lead", "lead").SetBody(`{"maxBytes": -1}`).
+			Put(namespacesURL + "/team-a/quota")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+		resp, err = resty.R().SetBasicAuth("lead", "lead").SetBody(`{"maxBytes": 10}`).
+			Put(namespacesURL + "/team-a/quota")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var settings api.NamespaceSettings
+
+		err = json.Unmarshal(resp.Body(), &settings)
+		So(err, ShouldBeNil)
+		So(settings.Quota, ShouldNotBeNil)
+		So(settings.Quota.MaxBytes, ShouldEqual, 10)
+		So(settings.Quota.Repositories, ShouldResemble, []string{"team-a/**"})
+
+		image := CreateRandomImage()
+
+		resp, err = resty.R().SetBasicAuth("dev", "dev").
+			SetQueryParam("digest", image.Manifest.Layers[0].Digest.String()).
+			SetHeader("Content-Type", "application/octet-stream").
+			SetBody(image.Layers[0]).
+			Post(baseURL + "/v2/team-a/app/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusRequestEntityTooLarge)
+
+		resp, err = resty.R().SetBasicAuth("lead", "lead").Delete(namespacesURL + "/team-a/quota")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		err = UploadImageWithBasicAuth(image, baseURL, "team-a/app", "2.0", "dev", "dev")
+		So(err, ShouldBeNil)
+
+		resp, err = resty.R().SetBasicAuth("lead", "lead").
+			SetBody(`{"keepTags": [{"patterns": ["["]}]}`).
+			Put(namespacesURL + "/team-a/retention")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+		resp, err = resty.R().SetBasicAuth("lead", "lead").
+			SetBody(`{"keepTags": [{"patterns": ["v.*"], "pushedWithin": "24h"}]}`).
+			Put(namespacesURL + "/team-a/retention")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().SetBasicAuth("lead", "lead").Get(namespacesURL + "/team-a")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		settings = api.NamespaceSettings{}
+		err = json.Unmarshal(resp.Body(), &settings)
+		So(err, ShouldBeNil)
+		So(settings.Policies, ShouldNotBeNil)
+		So(settings.Quota, ShouldBeNil)
+		So(settings.Retention, ShouldNotBeNil)
+		So(settings.Retention.KeepTags[0].Patterns, ShouldResemble, []string{"v.*"})
+		So(*settings.Retention.KeepTags[0].PushedWithin, ShouldEqual, 24*time.Hour)
+
+		retention := ctlr.Config.RetentionWithNamespaces(ctlr.Config.Storage.Retention)
+		So(retention.Policies, ShouldNotBeEmpty)
+		So(retention.Policies[0].Repositories, ShouldResemble, []string{"team-a/**"})
+
+		// the settings are changed while the requests relying on them are served
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			for i := 0; i < 10; i++ {
+				_, _ = resty.R().SetBasicAuth("lead", "lead").SetBody(`{"maxBytes": 1000000000}`).
+					Put(namespacesURL + "/team-a/quota")
+			}
+		}()
+
+		for i := 0; i < 10; i++ {
+			err = UploadImageWithBasicAuth(CreateRandomImage(), baseURL, "team-a/app", fmt.Sprintf("3.%d", i),
+				"dev", "dev")
+			So(err, ShouldBeNil)
+		}
+
+		<-done
+	})
+}
+
+func TestHealthProbes(t *testing.T) {
+	Convey("Health probes are reachable without credentials", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString(username, password))
+		defer os.Remove(htpasswdPath)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+		conf.Storage.SubPaths = map[string]config.StorageConfig{
+			"/a": {RootDirectory: t.TempDir()},
+		}
+
+		ctlr := makeController(conf, t.TempDir())
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		resp, err := resty.R().Get(baseURL + constants.RoutePrefix + "/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+		resp, err = resty.R().Get(baseURL + constants.LivenessPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var status api.HealthStatus
+
+		resp, err = resty.R().Get(baseURL + constants.ReadinessPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(json.Unmarshal(resp.Body(), &status), ShouldBeNil)
+		So(status.Status, ShouldEqual, "ok")
+		So(status.Checks, ShouldContainKey, "storage")
+		So(status.Checks, ShouldContainKey, "storage:/a")
+		So(status.Checks, ShouldContainKey, "metadb")
+		So(status.Checks["htpasswd"].Status, ShouldEqual, "ok")
+
+		// the probe files don't stay in the storage
+		entries, err := os.ReadDir(ctlr.Config.Storage.RootDirectory)
+		So(err, ShouldBeNil)
+
+		for _, entry := range entries {
+			So(entry.Name(), ShouldNotStartWith, ".probe-")
+		}
+
+		ctlr.MetaDB = mocks.MetaDBMock{
+			PingFn: func(ctx context.Context) error {
+				return ErrUnexpectedError
+			},
+		}
+
+		status = api.HealthStatus{}
+
+		resp, err = resty.R().Get(baseURL + constants.ReadinessPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusServiceUnavailable)
+		So(json.Unmarshal(resp.Body(), &status), ShouldBeNil)
+		So(status.Status, ShouldEqual, "failed")
+		So(status.Checks["metadb"].Status, ShouldEqual, "failed")
+		So(status.Checks["metadb"].Error, ShouldEqual, ErrUnexpectedError.Error())
+		So(status.Checks["storage"].Status, ShouldEqual, "ok")
+	})
+
+	Convey("Readiness fails when the LDAP server can't be reached", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		ldapPort, err := strconv.Atoi(test.GetFreePort())
+		So(err, ShouldBeNil)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Auth = &config.AuthConfig{
+			LDAP: (&config.LDAPConfig{
+				Insecure:      true,
+				Address:       "127.0.0.1",
+				Port:          ldapPort,
+				BaseDN:        LDAPBaseDN,
+				UserAttribute: "uid",
+			}).SetBindDN(LDAPBindDN).SetBindPassword(LDAPBindPassword),
+		}
+
+		ctlr := makeController(conf, t.TempDir())
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		var status api.HealthStatus
+
+		resp, err := resty.R().Get(baseURL + constants.ReadinessPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusServiceUnavailable)
+		So(json.Unmarshal(resp.Body(), &status), ShouldBeNil)
+		So(status.Checks["ldap"].Status, ShouldEqual, "failed")
+		So(status.Checks["ldap"].Error, ShouldNotBeEmpty)
+		So(status.Checks["storage"].Status, ShouldEqual, "ok")
+	})
+}
+
+func TestResumeBlobUpload(t *testing.T) {
+	Convey("Blob uploads can be resumed after the connection drops", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		content := []byte(strings.Repeat("0123456789", 10))
+		digest := godigest.FromBytes(content)
+
+		resp, err := resty.R().Post(baseURL + "/v2/repo/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc := test.Location(baseURL, resp)
+
+		resp, err = resty.R().Get(loc)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
+		So(resp.Header().Get("Range"), ShouldEqual, "0-0")
+
+		locURL, err := url.Parse(loc)
+		So(err, ShouldBeNil)
+
+		// the connection drops after half of the chunk is sent
+		conn, err := net.Dial("tcp", locURL.Host)
+		So(err, ShouldBeNil)
+
+		_, err = fmt.Fprintf(conn, "PATCH %s HTTP/1.1\r\nHost: %s\r\nContent-Type: application/octet-stream\r\n"+
+			"Content-Length: %d\r\n\r\n", locURL.RequestURI(), locURL.Host, len(content))
+		So(err, ShouldBeNil)
+
+		_, err = conn.Write(content[:50])
+		So(err, ShouldBeNil)
+		So(conn.Close(), ShouldBeNil)
+
+		for i := 0; i < 50; i++ {
+			resp, err = resty.R().Get(loc)
+			So(err, ShouldBeNil)
+
+			if resp.Header().Get("Range") == "0-49" {
+				break
+			}
+
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		So(resp.StatusCode(), ShouldEqual, http.StatusNoContent)
+		So(resp.Header().Get("Range"), ShouldEqual, "0-49")
+
+		// the upload resumes where it stopped, the range covers all the chunks
+		resp, err = resty.R().SetHeader("Content-Type", "application/octet-stream").
+			SetHeader("Content-Range", "50-99").SetBody(content[50:]).Patch(loc)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		So(resp.Header().Get("Range"), ShouldEqual, "0-99")
+
+		resp, err = resty.R().SetQueryParam("digest", digest.String()).
+			SetHeader("Content-Type", "application/octet-stream").Put(loc)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		resp, err = resty.R().Get(baseURL + "/v2/repo/blobs/" + digest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Body(), ShouldResemble, content)
+	})
+}
+
+func TestStorageQuota(t *testing.T) {
+	Convey("Blob uploads are rejected over the quotas", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		image := CreateImageWith().RandomLayers(1, 1000).DefaultConfig().Build()
+		imageSize := image.ManifestDescriptor.Size + image.Manifest.Config.Size + image.Manifest.Layers[0].Size
+
+		conf.Storage.Quota = &config.QuotaConfig{
+			Policies: []config.QuotaPolicy{
+				{Repositories: []string{"**"}, MaxRepositoryBytes: imageSize + 100},
+			},
+		}
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		err := UploadImage(image, baseURL, "app", "1.0")
+		So(err, ShouldBeNil)
+
+		content := make([]byte, 200)
+		digest := godigest.FromBytes(content)
+
+		// monolithic upload
+		resp, err := resty.R().SetHeader("Content-Type", "application/octet-stream").SetBody(content).
+			SetQueryParam("digest", digest.String()).Post(baseURL + "/v2/app/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusRequestEntityTooLarge)
+
+		var apiErrList apiErr.ErrorList
+
+		err = json.Unmarshal(resp.Body(), &apiErrList)
+		So(err, ShouldBeNil)
+		So(apiErrList.Errors, ShouldHaveLength, 1)
+		So(apiErrList.Errors[0].Code, ShouldEqual, "QUOTA_EXCEEDED")
+		So(apiErrList.Errors[0].Detail["name"], ShouldEqual, "app")
+
+		// chunked upload, there's still room when it starts
+		resp, err = resty.R().Post(baseURL + "/v2/app/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+		loc := test.Location(baseURL, resp)
+
+		resp, err = resty.R().SetHeader("Content-Type", "application/octet-stream").
+			SetHeader("Content-Range", fmt.Sprintf("%d-%d", 0, 99)).SetBody(content[:100]).Patch(loc)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		resp, err = resty.R().SetHeader("Content-Type", "application/octet-stream").
+			SetHeader("Content-Range", fmt.Sprintf("%d-%d", 100, 199)).SetBody(content[100:]).Patch(loc)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusRequestEntityTooLarge)
+
+		// the other repositories have their own quota
+		err = UploadImage(CreateRandomImage(), baseURL, "other", "1.0")
+		So(err, ShouldBeNil)
+
+		resp, err = resty.R().Get(baseURL + constants.RoutePrefix + constants.QuotaPath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var usage storage.QuotaUsage
+
+		err = json.Unmarshal(resp.Body(), &usage)
+		So(err, ShouldBeNil)
+		So(usage.Repositories, ShouldHaveLength, 2)
+		So(usage.Repositories[0].Name, ShouldEqual, "app")
+		So(usage.Repositories[0].Usage, ShouldEqual, imageSize)
+		So(usage.Repositories[0].MaxBytes, ShouldEqual, imageSize+100)
+		So(usage.Usage, ShouldEqual, usage.Repositories[0].Usage+usage.Repositories[1].Usage)
+		So(usage.Policies, ShouldHaveLength, 1)
+		So(usage.Policies[0].Usage, ShouldEqual, usage.Usage)
+	})
+
+	Convey("Registry quota", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.Quota = &config.QuotaConfig{MaxBytes: 100}
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		err := UploadImage(CreateImageWith().RandomLayers(1, 1000).DefaultConfig().Build(), baseURL, "app", "1.0")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestImmutableTags(t *testing.T) {
+	Convey("Pushes moving immutable tags are rejected", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.ImmutableTags = []config.ImmutableTagsPolicy{
+			{Repositories: []string{"prod/**"}, Patterns: []string{"v*", "release-*"}},
+		}
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		image := CreateRandomImage()
+
+		err := UploadImage(image, baseURL, "prod/app", "v1.0")
+		So(err, ShouldBeNil)
+
+		// pushing the same manifest again doesn't move the tag
+		err = UploadImage(image, baseURL, "prod/app", "v1.0")
+		So(err, ShouldBeNil)
+
+		updatedImage := CreateRandomImage()
+		So(UploadImage(updatedImage, baseURL, "prod/app", "v2.0"), ShouldBeNil)
+
+		manifestBlob, err := json.Marshal(updatedImage.Manifest)
+		So(err, ShouldBeNil)
+
+		resp, err := resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/prod/app/manifests/v1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusConflict)
+
+		var apiErrList apiErr.ErrorList
+
+		err = json.Unmarshal(resp.Body(), &apiErrList)
+		So(err, ShouldBeNil)
+		So(apiErrList.Errors[0].Code, ShouldEqual, "TAG_IMMUTABLE")
+		So(apiErrList.Errors[0].Detail["digest"], ShouldEqual, image.DigestStr())
+
+		resp, err = resty.R().Get(baseURL + "/v2/prod/app/manifests/v1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, image.DigestStr())
+
+		// the tags and repositories not matching the patterns are mutable
+		resp, err = resty.R().SetHeader("Content-Type", ispec.MediaTypeImageManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/prod/app/manifests/latest")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		So(UploadImage(image, baseURL, "dev/app", "v1.0"), ShouldBeNil)
+		So(UploadImage(updatedImage, baseURL, "dev/app", "v1.0"), ShouldBeNil)
+	})
+
+	Convey("Deletes removing immutable tags are rejected", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.ImmutableTags = []config.ImmutableTagsPolicy{
+			{Repositories: []string{"prod/**"}, Patterns: []string{"v*"}},
+		}
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		image := CreateRandomImage()
+		So(UploadImage(image, baseURL, "prod/app", "v1.0"), ShouldBeNil)
+
+		// deleting the tag would let it be pushed again with another manifest
+		resp, err := resty.R().Delete(baseURL + "/v2/prod/app/manifests/v1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusConflict)
+
+		var apiErrList apiErr.ErrorList
+
+		err = json.Unmarshal(resp.Body(), &apiErrList)
+		So(err, ShouldBeNil)
+		So(apiErrList.Errors[0].Code, ShouldEqual, "TAG_IMMUTABLE")
+
+		So(UploadImage(CreateRandomImage(), baseURL, "prod/app", "v1.0"), ShouldNotBeNil)
+
+		// so would deleting the manifest the tag points to
+		resp, err = resty.R().Delete(baseURL + "/v2/prod/app/manifests/" + image.DigestStr())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusConflict)
+
+		resp, err = resty.R().Get(baseURL + "/v2/prod/app/manifests/v1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, image.DigestStr())
+
+		// the mutable tags and the manifests only they point to can still be deleted
+		otherImage := CreateRandomImage()
+		So(UploadImage(otherImage, baseURL, "prod/app", "latest"), ShouldBeNil)
+
+		resp, err = resty.R().Delete(baseURL + "/v2/prod/app/manifests/" + otherImage.DigestStr())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		So(UploadImage(otherImage, baseURL, "dev/app", "v1.0"), ShouldBeNil)
+
+		resp, err = resty.R().Delete(baseURL + "/v2/dev/app/manifests/v1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		So(UploadImage(image, baseURL, "dev/app", "v1.0"), ShouldBeNil)
+	})
+}
+
+func TestPromotion(t *testing.T) {
+	Convey("Promotion isn't allowed without its config", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		So(UploadImage(CreateRandomImage(), baseURL, "staging/app", "1.0"), ShouldBeNil)
+
+		resp, err := resty.R().SetBody(api.PromotionRequest{Repository: "app", Reference: "1.0"}).
+			Post(baseURL + constants.RoutePrefix + constants.AdminPromotePath)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusMethodNotAllowed)
+	})
+
+	Convey("Staging images are promoted if they pass the checks", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		promoteURL := baseURL + constants.RoutePrefix + constants.AdminPromotePath
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.ImmutableTags = []config.ImmutableTagsPolicy{
+			{Repositories: []string{"app"}, Patterns: []string{"v*"}},
+		}
+		conf.Storage.Promotion = &config.PromotionConfig{
+			Policies: []config.PromotionPolicy{
+				{Repositories: []string{"secure/**"}, RequireSignature: true},
+			},
+		}
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		image := CreateRandomImage()
+		So(UploadImage(image, baseURL, "staging/app", "1.0"), ShouldBeNil)
+
+		sbom := CreateRandomImageWith().Subject(image.DescriptorRef()).ArtifactType("application/spdx+json").Build()
+		So(UploadImage(sbom, baseURL, "staging/app", sbom.DigestStr()), ShouldBeNil)
+
+		// no policy matches the repository, there are no checks
+		resp, err := resty.R().SetBody(api.PromotionRequest{Repository: "app", Reference: "1.0"}).Post(promoteURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var result api.PromotionResult
+
+		So(json.Unmarshal(resp.Body(), &result), ShouldBeNil)
+		So(result.Promoted, ShouldBeTrue)
+		So(result.Source, ShouldEqual, "staging/app:1.0")
+		So(result.Tag, ShouldEqual, "1.0")
+		So(result.Digest, ShouldEqual, image.DigestStr())
+		So(result.Checks, ShouldBeEmpty)
+
+		resp, err = resty.R().Get(baseURL + "/v2/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, image.DigestStr())
+
+		resp, err = resty.R().Get(baseURL + "/v2/app/blobs/" + image.Manifest.Layers[0].Digest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Get(baseURL + "/v2/app/referrers/" + image.DigestStr())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var referrers ispec.Index
+
+		So(json.Unmarshal(resp.Body(), &referrers), ShouldBeNil)
+		So(referrers.Manifests, ShouldHaveLength, 1)
+		So(referrers.Manifests[0].Digest.String(), ShouldEqual, sbom.DigestStr())
+
+		// a digest is promoted under the given tag
+		resp, err = resty.R().SetBody(api.PromotionRequest{
+			Repository: "app", Reference: image.DigestStr(), Tag: "v1",
+		}).Post(promoteURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Get(baseURL + "/v2/app/manifests/v1")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		// the immutable tags aren't moved
+		So(UploadImage(CreateRandomImage(), baseURL, "staging/app", "v1"), ShouldBeNil)
+
+		resp, err = resty.R().SetBody(api.PromotionRequest{Repository: "app", Reference: "v1"}).Post(promoteURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusConflict)
+
+		resp, err = resty.R().Get(baseURL + "/v2/app/manifests/v1")
+		So(err, ShouldBeNil)
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, image.DigestStr())
+
+		// the unsigned images are refused by the policy
+		secureImage := CreateRandomImage()
+		So(UploadImage(secureImage, baseURL, "staging/secure/app", "1.0"), ShouldBeNil)
+
+		resp, err = resty.R().SetBody(api.PromotionRequest{Repository: "secure/app", Reference: "1.0"}).
+			Post(promoteURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusConflict)
+
+		result = api.PromotionResult{}
+
+		So(json.Unmarshal(resp.Body(), &result), ShouldBeNil)
+		So(result.Promoted, ShouldBeFalse)
+		So(result.Checks, ShouldHaveLength, 1)
+		So(result.Checks[0].Name, ShouldEqual, "signature")
+		So(result.Checks[0].Passed, ShouldBeFalse)
+
+		resp, err = resty.R().Get(baseURL + "/v2/secure/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+		// the signature is promoted along with the image
+		signature := CreateMockNotationSignature(secureImage.DescriptorRef())
+		So(UploadImage(signature, baseURL, "staging/secure/app", signature.DigestStr()), ShouldBeNil)
+
+		resp, err = resty.R().SetBody(api.PromotionRequest{Repository: "secure/app", Reference: "1.0"}).
+			Post(promoteURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Get(baseURL + "/v2/secure/app/manifests/" + signature.DigestStr())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		Convey("Invalid promotions are rejected", func() {
+			resp, err := resty.R().SetBody(api.PromotionRequest{Repository: "app", Reference: "2.0"}).
+				Post(promoteURL)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+			resp, err = resty.R().SetBody(api.PromotionRequest{Repository: "other", Reference: "1.0"}).
+				Post(promoteURL)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+			resp, err = resty.R().SetBody(api.PromotionRequest{Repository: "staging/app", Reference: "1.0"}).
+				Post(promoteURL)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+			resp, err = resty.R().SetBody(api.PromotionRequest{Repository: "app", Reference: image.DigestStr()}).
+				Post(promoteURL)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+			resp, err = resty.R().SetBody("{").Post(promoteURL)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	Convey("Pushes are rejected in read-only mode while pulls keep working", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		image := CreateRandomImage()
+		err := UploadImage(image, baseURL, "app", "1.0")
+		So(err, ShouldBeNil)
+
+		readOnlyURL := baseURL + constants.RoutePrefix + constants.ReadOnlyPath
+
+		resp, err := resty.R().SetHeader("Content-Type", "application/json").
+			SetBody(`{"readOnly": true}`).Put(readOnlyURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(ctlr.IsReadOnly(), ShouldBeTrue)
+
+		resp, err = resty.R().Get(readOnlyURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(string(resp.Body()), ShouldContainSubstring, `"readOnly":true`)
+
+		resp, err = resty.R().Post(baseURL + "/v2/app/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusServiceUnavailable)
+
+		var apiErrList apiErr.ErrorList
+
+		err = json.Unmarshal(resp.Body(), &apiErrList)
+		So(err, ShouldBeNil)
+		So(apiErrList.Errors, ShouldHaveLength, 1)
+		So(apiErrList.Errors[0].Code, ShouldEqual, "UNAVAILABLE")
+
+		resp, err = resty.R().Delete(baseURL + "/v2/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusServiceUnavailable)
+
+		resp, err = resty.R().Get(baseURL + "/v2/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Get(baseURL + "/v2/app/blobs/" + image.Manifest.Layers[0].Digest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().SetBody(`not json`).Put(readOnlyURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+		resp, err = resty.R().SetHeader("Content-Type", "application/json").
+			SetBody(`{"readOnly": false}`).Put(readOnlyURL)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(ctlr.IsReadOnly(), ShouldBeFalse)
+
+		err = UploadImage(CreateRandomImage(), baseURL, "app", "2.0")
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Toggling the read-only mode while the background tasks are used", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.GC = true
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		err := UploadImage(CreateRandomImage(), baseURL, "app", "1.0")
+		So(err, ShouldBeNil)
+
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			for i := 0; i < 10; i++ {
+				ctlr.RunGarbageCollect()
+				_, _, _ = ctlr.GarbageCollectReport(context.Background(), false)
+				_, _ = resty.R().Get(baseURL + "/v2/app/manifests/1.0")
+			}
+		}()
+
+		for i := 0; i < 10; i++ {
+			ctlr.SetReadOnly(i%2 == 0)
+		}
+
+		<-done
+
+		So(ctlr.IsReadOnly(), ShouldBeFalse)
+		So(ctlr.RunGarbageCollect(), ShouldBeTrue)
+	})
+
+	Convey("Read-only mode from the config", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.ReadOnly = true
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		So(ctlr.IsReadOnly(), ShouldBeTrue)
+
+		err := UploadImage(CreateRandomImage(), baseURL, "app", "1.0")
+		So(err, ShouldNotBeNil)
+
+		resp, err := resty.R().Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		// reloading the config switches the mode
+		newConf := *conf
+		newConf.Storage.ReadOnly = false
+		ctlr.LoadNewConfig(&newConf)
+		So(ctlr.IsReadOnly(), ShouldBeFalse)
+
+		err = UploadImage(CreateRandomImage(), baseURL, "app", "1.0")
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestSubPathRepositories(t *testing.T) {
+	Convey("Repositories are routed to the subpaths matching their names", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		rootDir := t.TempDir()
+		fastDir := t.TempDir()
+
+		conf.Storage.SubPaths = map[string]config.StorageConfig{
+			"/fast": {RootDirectory: fastDir, Repositories: []string{"infra/**"}},
+		}
+
+		ctlr := makeController(conf, rootDir)
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		for _, repo := range []string{"infra/db/postgres", "fast/app", "web"} {
+			err := UploadImage(CreateRandomImage(), baseURL, repo, "1.0")
+			So(err, ShouldBeNil)
+		}
+
+		_, err := os.Stat(path.Join(fastDir, "infra/db/postgres", "index.json"))
+		So(err, ShouldBeNil)
+
+		_, err = os.Stat(path.Join(fastDir, "fast/app", "index.json"))
+		So(err, ShouldBeNil)
+
+		_, err = os.Stat(path.Join(rootDir, "web", "index.json"))
+		So(err, ShouldBeNil)
+
+		_, err = os.Stat(path.Join(rootDir, "infra"))
+		So(os.IsNotExist(err), ShouldBeTrue)
+
+		resp, err := resty.R().Get(baseURL + "/v2/infra/db/postgres/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		resp, err = resty.R().Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var catalog struct {
+			Repos []string `json:"repositories"`
+		}
+
+		err = json.Unmarshal(resp.Body(), &catalog)
+		So(err, ShouldBeNil)
+		So(catalog.Repos, ShouldResemble, []string{"fast/app", "infra/db/postgres", "web"})
+	})
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	// sends the blob of the upload through a pipe, so the test decides when the request is done
+	startBlobUpload := func(baseURL string, content []byte) (*io.PipeWriter, chan *http.Response) {
+		resp, err := resty.R().Post(baseURL + "/v2/repo/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		loc := test.Location(baseURL, resp)
+
+		reader, writer := io.Pipe()
+
+		request, err := http.NewRequestWithContext(context.Background(), http.MethodPut,
+			loc+"?digest="+godigest.FromBytes(content).String(), reader)
+		So(err, ShouldBeNil)
+
+		request.ContentLength = int64(len(content))
+		request.Header.Set("Content-Type", "application/octet-stream")
+
+		responses := make(chan *http.Response, 1)
+
+		go func() {
+			defer close(responses)
+
+			response, err := http.DefaultClient.Do(request)
+			if err == nil {
+				responses <- response
+			}
+		}()
+
+		_, err = writer.Write(content[:5])
+		So(err, ShouldBeNil)
+
+		// let the handler start reading the blob
+		time.Sleep(100 * time.Millisecond)
+
+		return writer, responses
+	}
+
+	Convey("The uploads in progress are completed when the server stops", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+
+		content := []byte("this is a blob")
+		writer, responses := startBlobUpload(baseURL, content)
+
+		stopped := make(chan struct{})
+
+		go func() {
+			cm.StopServer()
+			close(stopped)
+		}()
+
+		time.Sleep(100 * time.Millisecond)
+
+		select {
+		case <-stopped:
+			t.Fatal("the server stopped before the upload was done")
+		default:
+		}
+
+		_, err := writer.Write(content[5:])
+		So(err, ShouldBeNil)
+		So(writer.Close(), ShouldBeNil)
+
+		response := <-responses
+		So(response, ShouldNotBeNil)
+		So(response.StatusCode, ShouldEqual, http.StatusCreated)
+		response.Body.Close()
+
+		<-stopped
+	})
+
+	Convey("The requests in progress are interrupted after the shutdown timeout", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.ShutdownTimeout = 100 * time.Millisecond
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+
+		writer, responses := startBlobUpload(baseURL, []byte("this is a blob"))
+
+		start := time.Now()
+		cm.StopServer()
+		So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+
+		writer.CloseWithError(io.ErrUnexpectedEOF)
+
+		_, ok := <-responses
+		So(ok, ShouldBeFalse)
+	})
+}
+
+func TestUnixSocket(t *testing.T) {
+	getThroughSocket := func(socket string) (*http.Response, error) {
+		client := &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+				},
+			},
+		}
+
+		return client.Get("http://localhost" + constants.RoutePrefix + "/")
+	}
+
+	waitForSocket := func(socket string) *http.Response {
+		for {
+			resp, err := getThroughSocket(socket)
+			if err == nil {
+				return resp
+			}
+
+			time.Sleep(test.SleepTime)
+		}
+	}
+
+	Convey("A server only listening on a unix socket", t, func() {
+		socket := path.Join(t.TempDir(), "zot.sock")
+		conf := config.New()
+		conf.HTTP.Port = ""
+		conf.HTTP.Socket = socket
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartServer()
+		defer cm.StopServer()
+
+		resp := waitForSocket(socket)
+		So(resp.StatusCode, ShouldEqual, http.StatusOK)
+		resp.Body.Close()
+
+		info, err := os.Stat(socket)
+		So(err, ShouldBeNil)
+		So(info.Mode().Perm(), ShouldEqual, os.FileMode(0o660))
+	})
+
+	Convey("A server listening on a port and a unix socket", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		socket := path.Join(t.TempDir(), "zot.sock")
+
+		// left by a server which didn't stop cleanly
+		listener, err := net.Listen("unix", socket)
+		So(err, ShouldBeNil)
+
+		listener.(*net.UnixListener).SetUnlinkOnClose(false) //nolint: forcetypeassert
+		listener.Close()
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Socket = socket
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+
+		resp, err := resty.R().Get(baseURL + constants.RoutePrefix + "/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		socketResp := waitForSocket(socket)
+		So(socketResp.StatusCode, ShouldEqual, http.StatusOK)
+		socketResp.Body.Close()
+
+		Convey("another server can't use the same socket", func() {
+			conf := config.New()
+			conf.HTTP.Port = ""
+			conf.HTTP.Socket = socket
+
+			ctlr := makeController(conf, t.TempDir())
+			So(ctlr.Init(), ShouldBeNil)
+			defer ctlr.Shutdown()
+
+			err := ctlr.Run()
+			So(goerrors.Is(err, errors.ErrServerIsRunning), ShouldBeTrue)
+		})
+
+		// the socket is removed when the server stops
+		cm.StopServer()
+
+		_, err = os.Stat(socket)
+		So(os.IsNotExist(err), ShouldBeTrue)
+	})
+
+	Convey("A file which isn't a socket isn't replaced", t, func() {
+		socket := path.Join(t.TempDir(), "zot.sock")
+
+		err := os.WriteFile(socket, []byte("not a socket"), 0o600)
+		So(err, ShouldBeNil)
+
+		conf := config.New()
+		conf.HTTP.Port = ""
+		conf.HTTP.Socket = socket
+
+		ctlr := makeController(conf, t.TempDir())
+		So(ctlr.Init(), ShouldBeNil)
+		defer ctlr.Shutdown()
+
+		err = ctlr.Run()
+		So(goerrors.Is(err, errors.ErrBadConfig), ShouldBeTrue)
+
+		content, err := os.ReadFile(socket)
+		So(err, ShouldBeNil)
+		So(string(content), ShouldEqual, "not a socket")
+	})
+}
+
+func TestSearchRoutes(t *testing.T) {
+	Convey("Upload image for test", t, func(c C) {
+		tempDir := t.TempDir()
+		repoName := "testrepo" //nolint:goconst
+		inaccessibleRepo := "inaccessible"
+
+		Convey("GlobalSearch with authz enabled", func(c C) {
+			conf := config.New()
+			port := test.GetFreePort()
+			baseURL := test.GetBaseURL(port)
+
+			user1 := "test"
+			password1 := "test"
+			testString1 := test.GetCredString(user1, password1)
+			htpasswdPath := test.MakeHtpasswdFileFromString(testString1)
+			defer os.Remove(htpasswdPath)
+			conf.HTTP.Auth = &config.AuthConfig{
+				HTPasswd: config.AuthHTPasswd{
+					Path: htpasswdPath,
+				},
+			}
+
+			conf.HTTP.Port = port
+
+			defaultVal := true
+
+			searchConfig := &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+			}
+
+			conf.Extensions = &extconf.ExtensionConfig{
+				Search: searchConfig,
+			}
+
+			conf.HTTP.AccessControl = &config.AccessControlConfig{
+				Repositories: config.Repositories{
+					repoName: config.PolicyGroup{
+						Policies: []config.Policy{
+							{
+								Users:   []string{user1},
+								Actions: []string{"read", "create"},
+							},
+						},
+						DefaultPolicy: []string{},
+					},
+					inaccessibleRepo: config.PolicyGroup{
+						Policies: []config.Policy{
+							{
+								Users:   []string{user1},
+								Actions: []string{"create"},
+							},
+						},
+						DefaultPolicy: []string{},
+					},
+				},
+				AdminPolicy: config.Policy{
+					Users:   []string{},
+					Actions: []string{},
+				},
+			}
+
+			ctlr := makeController(conf, tempDir)
+
+			cm := test.NewControllerManager(ctlr)
+			cm.StartAndWait(port)
+			defer cm.StopServer()
+
+			img := CreateImageWith().RandomLayers(1, 10000).DefaultConfig().Build()
+
+			err := UploadImageWithBasicAuth(
+				img, baseURL, repoName, "latest",
+				user1, password1)
+			So(err, ShouldBeNil)
+
+			// data for the inaccessible repo
+			img = CreateImageWith().RandomLayers(1, 10000).DefaultConfig().Build()
+
+			err = UploadImageWithBasicAuth(
+				img, baseURL, inaccessibleRepo, "latest",
+				user1, password1)
+			So(err, ShouldBeNil)
+
+			query := `
+				{
+					GlobalSearch(query:"testrepo"){
+						Repos {
+							Name
+							NewestImage {
+								RepoName
+								Tag
+							}
+						}
+					}
+				}`
+			resp, err := resty.R().SetBasicAuth(user1, password1).Get(baseURL + constants.FullSearchPrefix +
+				"?query=" + url.QueryEscape(query))
+			So(err, ShouldBeNil)
+			So(resp, ShouldNotBeNil)
+			So(resp.StatusCode(), ShouldEqual, 200)
+			So(string(resp.Body()), ShouldContainSubstring, repoName)
+			So(string(resp.Body()), ShouldNotContainSubstring, inaccessibleRepo)
+
+			resp, err = resty.R().Get(baseURL + constants.FullSearchPrefix + "?query=" + url.QueryEscape(query))
 			So(err, ShouldBeNil)
 			So(resp, ShouldNotBeNil)
 			So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
@@ -10679,3 +12804,235 @@ func getNumberOfSessions(rootDir string) (int, error) {
 
 	return sessionsNo, nil
 }
+
+func TestRecompression(t *testing.T) {
+	Convey("A copy of the pushed images is pushed with zstd:chunked layers", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.Recompression = &config.RecompressionConfig{}
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		tarBuf := bytes.Buffer{}
+		tarWriter := tar.NewWriter(&tarBuf)
+		So(tarWriter.WriteHeader(&tar.Header{Name: "etc/hostname", Mode: 0o644, Size: 3}), ShouldBeNil)
+		_, err := tarWriter.Write([]byte("zot"))
+		So(err, ShouldBeNil)
+		So(tarWriter.Close(), ShouldBeNil)
+
+		gzipBuf := bytes.Buffer{}
+		gzipWriter := gzip.NewWriter(&gzipBuf)
+		_, err = gzipWriter.Write(tarBuf.Bytes())
+		So(err, ShouldBeNil)
+		So(gzipWriter.Close(), ShouldBeNil)
+
+		image := CreateImageWith().LayerBlobs([][]byte{gzipBuf.Bytes()}).DefaultConfig().Build()
+		err = UploadImage(image, baseURL, "app", "1.0")
+		So(err, ShouldBeNil)
+
+		var manifest ispec.Manifest
+
+		for i := 0; i < 50; i++ {
+			resp, err := resty.R().SetHeader("Accept", ispec.MediaTypeImageManifest).
+				Get(baseURL + "/v2/app/manifests/1.0-zstd")
+			So(err, ShouldBeNil)
+
+			if resp.StatusCode() == http.StatusOK {
+				So(json.Unmarshal(resp.Body(), &manifest), ShouldBeNil)
+
+				break
+			}
+
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		So(manifest.Layers, ShouldHaveLength, 1)
+		So(manifest.Layers[0].MediaType, ShouldEqual, ispec.MediaTypeImageLayerZstd)
+		So(manifest.Config.Digest, ShouldEqual, image.Manifest.Config.Digest)
+
+		// the copies are not recompressed again
+		resp, err := resty.R().Get(baseURL + "/v2/app/tags/list")
+		So(err, ShouldBeNil)
+		So(string(resp.Body()), ShouldEqual, `{"name":"app","tags":["1.0","1.0-zstd"]}`)
+	})
+}
+
+func TestMetadataIndex(t *testing.T) {
+	Convey("The catalog is listed from metaDB with the metadata index", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.MetadataIndex = true
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		So(ctlr.MetaDB, ShouldNotBeNil)
+
+		image := CreateRandomImage()
+		So(UploadImage(image, baseURL, "zot/app", "1.0"), ShouldBeNil)
+		So(UploadImage(CreateRandomImage(), baseURL, "app", "1.0"), ShouldBeNil)
+
+		resp, err := resty.R().Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(string(resp.Body()), ShouldEqual, `{"repositories":["app","zot/app"]}`)
+
+		repoMeta, err := ctlr.MetaDB.GetRepoMeta(context.Background(), "zot/app")
+		So(err, ShouldBeNil)
+		So(repoMeta.Tags["1.0"].Digest, ShouldEqual, image.DigestStr())
+		So(repoMeta.Size, ShouldBeGreaterThan, 0)
+
+		// deleted manifests are removed from the index
+		resp, err = resty.R().Delete(baseURL + "/v2/zot/app/manifests/" + image.DigestStr())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		repoMeta, err = ctlr.MetaDB.GetRepoMeta(context.Background(), "zot/app")
+		So(err, ShouldBeNil)
+		So(repoMeta.Tags, ShouldBeEmpty)
+	})
+}
+
+func TestDockerCompat(t *testing.T) {
+	Convey("Docker manifests are accepted with the compat config", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Compat = &config.CompatConfig{DockerManifests: true}
+
+		ctlr := makeController(conf, t.TempDir())
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		// the blobs are pushed along with the OCI manifest
+		image := CreateRandomImage()
+		So(UploadImage(image, baseURL, "app", image.DigestStr()), ShouldBeNil)
+
+		dockerManifest := image.Manifest
+		dockerManifest.MediaType = schema2.MediaTypeManifest
+		dockerManifest.Config.MediaType = schema2.MediaTypeImageConfig
+		dockerManifest.Layers = []ispec.Descriptor{image.Manifest.Layers[0]}
+		dockerManifest.Layers[0].MediaType = schema2.MediaTypeLayer
+
+		manifestBlob, err := json.Marshal(dockerManifest)
+		So(err, ShouldBeNil)
+
+		manifestDigest := godigest.FromBytes(manifestBlob)
+
+		resp, err := resty.R().SetHeader("Content-Type", schema2.MediaTypeManifest).SetBody(manifestBlob).
+			Put(baseURL + "/v2/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		resp, err = resty.R().Get(baseURL + "/v2/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Content-Type"), ShouldEqual, schema2.MediaTypeManifest)
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, manifestDigest.String())
+
+		list := ispec.Index{
+			Versioned: image.Manifest.Versioned,
+			MediaType: manifestlist.MediaTypeManifestList,
+			Manifests: []ispec.Descriptor{
+				{
+					MediaType: schema2.MediaTypeManifest,
+					Digest:    manifestDigest,
+					Size:      int64(len(manifestBlob)),
+					Platform:  &ispec.Platform{OS: "linux", Architecture: "amd64"},
+				},
+			},
+		}
+
+		listBlob, err := json.Marshal(list)
+		So(err, ShouldBeNil)
+
+		resp, err = resty.R().SetHeader("Content-Type", manifestlist.MediaTypeManifestList).SetBody(listBlob).
+			Put(baseURL + "/v2/app/manifests/multi")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+
+		// the manifests of a list can't be deleted while it's there
+		resp, err = resty.R().Delete(baseURL + "/v2/app/manifests/" + manifestDigest.String())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusMethodNotAllowed)
+
+		Convey("Foreign layers", func() {
+			foreignManifest := dockerManifest
+			foreignManifest.Layers = append([]ispec.Descriptor{{
+				MediaType: schema2.MediaTypeForeignLayer,
+				Digest:    godigest.FromString("foreign"),
+				Size:      7,
+				URLs:      []string{"https://example.com/foreign.tar.gz"},
+			}}, dockerManifest.Layers...)
+
+			foreignBlob, err := json.Marshal(foreignManifest)
+			So(err, ShouldBeNil)
+
+			resp, err := resty.R().SetHeader("Content-Type", schema2.MediaTypeManifest).SetBody(foreignBlob).
+				Put(baseURL + "/v2/app/manifests/foreign")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusBadRequest)
+
+			conf.Compat.ForeignLayers = true
+
+			resp, err = resty.R().SetHeader("Content-Type", schema2.MediaTypeManifest).SetBody(foreignBlob).
+				Put(baseURL + "/v2/app/manifests/foreign")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusCreated)
+		})
+
+		Convey("Accept header fallback", func() {
+			request := resty.R().SetHeader("Accept", schema2.MediaTypeManifest+", "+ispec.MediaTypeImageManifest)
+
+			resp, err := request.Get(baseURL + "/v2/app/manifests/multi")
+			So(err, ShouldBeNil)
+			So(resp.Header().Get("Content-Type"), ShouldEqual, manifestlist.MediaTypeManifestList)
+
+			conf.Compat.AcceptFallback = true
+
+			resp, err = request.Get(baseURL + "/v2/app/manifests/multi")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(resp.Header().Get("Content-Type"), ShouldEqual, schema2.MediaTypeManifest)
+			So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, manifestDigest.String())
+			So(resp.Body(), ShouldResemble, manifestBlob)
+
+			resp, err = request.Head(baseURL + "/v2/app/manifests/multi")
+			So(err, ShouldBeNil)
+			So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, manifestDigest.String())
+
+			// the clients accepting lists and the requests by digest get the list
+			resp, err = resty.R().SetHeader("Accept", manifestlist.MediaTypeManifestList).
+				Get(baseURL + "/v2/app/manifests/multi")
+			So(err, ShouldBeNil)
+			So(resp.Header().Get("Content-Type"), ShouldEqual, manifestlist.MediaTypeManifestList)
+
+			resp, err = request.Get(baseURL + "/v2/app/manifests/" + godigest.FromBytes(listBlob).String())
+			So(err, ShouldBeNil)
+			So(resp.Header().Get("Content-Type"), ShouldEqual, manifestlist.MediaTypeManifestList)
+		})
+
+		Convey("Docker manifests are rejected without the compat config", func() {
+			conf.Compat.DockerManifests = false
+
+			resp, err := resty.R().SetHeader("Content-Type", schema2.MediaTypeManifest).SetBody(manifestBlob).
+				Put(baseURL + "/v2/app/manifests/2.0")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusUnsupportedMediaType)
+		})
+	})
+}
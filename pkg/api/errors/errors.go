@@ -33,6 +33,9 @@ const (
 	DENIED
 	UNSUPPORTED
 	TOOMANYREQUESTS
+	QUOTA_EXCEEDED
+	TAG_IMMUTABLE
+	UNAVAILABLE
 )
 
 func (e ErrorCode) String() string {
@@ -51,6 +54,9 @@ func (e ErrorCode) String() string {
 		DENIED:                "DENIED",
 		UNSUPPORTED:           "UNSUPPORTED",
 		TOOMANYREQUESTS:       "TOOMANYREQUESTS",
+		QUOTA_EXCEEDED:        "QUOTA_EXCEEDED",
+		TAG_IMMUTABLE:         "TAG_IMMUTABLE",
+		UNAVAILABLE:           "UNAVAILABLE",
 	}
 
 	return errMap[e]
@@ -140,6 +146,24 @@ func NewError(code ErrorCode) *Error {
 			Message:     "too many requests",
 			Description: "When a user or users has sent too many requests to the server within a given amount of time.",
 		},
+
+		QUOTA_EXCEEDED: {
+			Message: "storage quota exceeded",
+			Description: "The blob upload was rejected because the repository, or the registry, " +
+				"already stores as many bytes as its quota allows.",
+		},
+
+		TAG_IMMUTABLE: {
+			Message: "tag is immutable",
+			Description: "The manifest push or delete was rejected because it would move a tag which is configured " +
+				"as immutable to another manifest, or remove it.",
+		},
+
+		UNAVAILABLE: {
+			Message: "registry is read-only",
+			Description: "The request was rejected because the registry is in read-only mode, " +
+				"only the requests which don't change it are served.",
+		},
 	}
 
 	err, ok := errMap[code]
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	zerr "zotregistry.dev/zot/errors"
+	zcommon "zotregistry.dev/zot/pkg/common"
+	storageTypes "zotregistry.dev/zot/pkg/storage/types"
+)
+
+const (
+	healthStatusOK     = "ok"
+	healthStatusFailed = "failed"
+	healthCheckTimeout = 5 * time.Second
+)
+
+type HealthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type HealthStatus struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// Livez godoc
+// @Summary Liveness probe
+// @Description Tell if the registry process is up, without checking its dependencies
+// @Router  /livez [get]
+// @Produce json
+// @Success 200 {object} api.HealthStatus
+func (rh *RouteHandler) Livez(response http.ResponseWriter, request *http.Request) {
+	zcommon.WriteJSON(response, http.StatusOK, HealthStatus{Status: healthStatusOK})
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Check the storage accepts writes, the metadata DB answers and the auth backends can be reached
+// @Router  /readyz [get]
+// @Produce json
+// @Success 200 {object} api.HealthStatus
+// @Failure 503 {object} api.HealthStatus.
+func (rh *RouteHandler) Readyz(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithTimeout(request.Context(), healthCheckTimeout)
+	defer cancel()
+
+	status := HealthStatus{Status: healthStatusOK, Checks: map[string]HealthCheck{}}
+
+	addCheck := func(name string, err error) {
+		if err == nil {
+			status.Checks[name] = HealthCheck{Status: healthStatusOK}
+
+			return
+		}
+
+		rh.c.Log.Warn().Err(err).Str("check", name).Msg("readiness check failed")
+
+		status.Status = healthStatusFailed
+		status.Checks[name] = HealthCheck{Status: healthStatusFailed, Error: err.Error()}
+	}
+
+	addCheck("storage", checkStorage(rh.c.StoreController.DefaultStore))
+
+	for route, imgStore := range rh.c.StoreController.SubStore {
+		addCheck("storage:"+route, checkStorage(imgStore))
+	}
+
+	if rh.c.MetaDB == nil {
+		addCheck("metadb", zerr.ErrNotReady)
+	} else {
+		addCheck("metadb", rh.c.MetaDB.Ping(ctx))
+	}
+
+	if rh.c.Config.IsHtpasswdAuthEnabled() {
+		_, err := os.Stat(rh.c.Config.HTTP.Auth.HTPasswd.Path)
+		addCheck("htpasswd", err)
+	}
+
+	if rh.c.Config.IsLdapAuthEnabled() {
+		ldapConfig := rh.c.Config.HTTP.Auth.LDAP
+		addCheck("ldap", checkAddress(ctx, ldapConfig.Address, ldapConfig.Port))
+	}
+
+	if rh.c.Config.IsBearerAuthEnabled() {
+		_, err := os.Stat(rh.c.Config.HTTP.Auth.Bearer.Cert)
+		addCheck("bearer", err)
+	}
+
+	if status.Status != healthStatusOK {
+		zcommon.WriteJSON(response, http.StatusServiceUnavailable, status)
+
+		return
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, status)
+}
+
+func checkStorage(imgStore storageTypes.ImageStore) error {
+	if imgStore == nil {
+		return zerr.ErrNotReady
+	}
+
+	return imgStore.CheckWritable()
+}
+
+// checkAddress tells if a TCP connection can be opened to the address.
+func checkAddress(ctx context.Context, host string, port int) error {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("%w: %w", zerr.ErrNotReady, err)
+	}
+
+	return conn.Close()
+}
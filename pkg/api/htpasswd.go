@@ -0,0 +1,59 @@
+package api
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+)
+
+// HTPasswd holds the credentials of the htpasswd file, they're read again when the config is reloaded.
+type HTPasswd struct {
+	credMap map[string]string
+	lock    sync.RWMutex
+}
+
+func NewHTPasswd() *HTPasswd {
+	return &HTPasswd{credMap: map[string]string{}}
+}
+
+// Reload replaces the credentials with the ones of the file, they're kept if it can't be read.
+func (htp *HTPasswd) Reload(path string) error {
+	credsFile, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer credsFile.Close()
+
+	credMap := map[string]string{}
+
+	scanner := bufio.NewScanner(credsFile)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, ":") {
+			tokens := strings.Split(line, ":")
+			credMap[tokens[0]] = tokens[1]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	htp.lock.Lock()
+	htp.credMap = credMap
+	htp.lock.Unlock()
+
+	return nil
+}
+
+// Get returns the password hash of the user.
+func (htp *HTPasswd) Get(username string) (string, bool) {
+	htp.lock.RLock()
+	defer htp.lock.RUnlock()
+
+	passphraseHash, ok := htp.credMap[username]
+
+	return passphraseHash, ok
+}
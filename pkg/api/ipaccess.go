@@ -0,0 +1,182 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/api/constants"
+	apiErr "zotregistry.dev/zot/pkg/api/errors"
+	zcommon "zotregistry.dev/zot/pkg/common"
+	debugConstants "zotregistry.dev/zot/pkg/debug/constants"
+)
+
+const (
+	pullRequests  = "pull"
+	pushRequests  = "push"
+	adminRequests = "admin"
+)
+
+type ipRules struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// IPAccessHandler rejects the requests from the client addresses the IP access rules don't allow.
+// The global rules are checked first, then the ones of the pulls, the pushes or the admin routes.
+func IPAccessHandler(ctlr *Controller) mux.MiddlewareFunc {
+	ipAccess := ctlr.Config.HTTP.IPAccess
+
+	trustedProxies, err := config.ParseCIDRs(ipAccess.TrustedProxies)
+	if err != nil {
+		ctlr.Log.Panic().Err(err).Msg("failed to parse the trusted proxies")
+	}
+
+	globalRules := newIPRules(ctlr, &ipAccess.IPRules)
+	classRules := map[string]*ipRules{
+		pullRequests:  newIPRules(ctlr, ipAccess.Pull),
+		pushRequests:  newIPRules(ctlr, ipAccess.Push),
+		adminRequests: newIPRules(ctlr, ipAccess.Admin),
+	}
+
+	ctlr.Log.Info().Msg("IP access rules enabled")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			clientIP := getClientIP(request, trustedProxies)
+			class := getRequestClass(request)
+
+			if globalRules.allows(clientIP) && classRules[class].allows(clientIP) {
+				next.ServeHTTP(response, request)
+
+				return
+			}
+
+			ctlr.Log.Info().Err(zerr.ErrAddressNotAllowed).Str("clientIP", clientIP.String()).
+				Str("class", class).Str("method", request.Method).Str("path", request.URL.Path).
+				Msg("rejected request from a denied address")
+
+			zcommon.WriteJSON(response, http.StatusForbidden, apiErr.NewErrorList(apiErr.NewError(apiErr.DENIED)))
+		})
+	}
+}
+
+func newIPRules(ctlr *Controller, rules *config.IPRules) *ipRules {
+	if rules == nil {
+		return nil
+	}
+
+	allow, err := config.ParseCIDRs(rules.Allow)
+	if err != nil {
+		ctlr.Log.Panic().Err(err).Msg("failed to parse the allowed addresses")
+	}
+
+	deny, err := config.ParseCIDRs(rules.Deny)
+	if err != nil {
+		ctlr.Log.Panic().Err(err).Msg("failed to parse the denied addresses")
+	}
+
+	return &ipRules{allow: allow, deny: deny}
+}
+
+// allows returns true if the address isn't denied and, when there's an allow list, is in it.
+func (rules *ipRules) allows(ip net.IP) bool {
+	if rules == nil {
+		return true
+	}
+
+	if containsIP(rules.deny, ip) {
+		return false
+	}
+
+	return len(rules.allow) == 0 || containsIP(rules.allow, ip)
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getClientIP returns the address of the client. The X-Forwarded-For header is only used when the request comes
+// from a trusted proxy, the client being the last address in it not belonging to a trusted proxy.
+func getClientIP(request *http.Request, trustedProxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+
+	clientIP := net.ParseIP(host)
+	if !containsIP(trustedProxies, clientIP) {
+		return clientIP
+	}
+
+	forwarded := strings.Split(strings.Join(request.Header.Values("X-Forwarded-For"), ","), ",")
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		forwardedIP := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if forwardedIP == nil {
+			break
+		}
+
+		clientIP = forwardedIP
+
+		if !containsIP(trustedProxies, forwardedIP) {
+			break
+		}
+	}
+
+	return clientIP
+}
+
+// getRequestClass tells whether the request uses an admin route, pushes or pulls.
+// The searches are pulls, even when they're sent with POST.
+func getRequestClass(request *http.Request) string {
+	path := request.URL.Path
+
+	switch {
+	case isAdminRoute(path):
+		return adminRequests
+	case isMutatingRequest(request) && !strings.HasPrefix(path, constants.FullSearchPrefix):
+		return pushRequests
+	default:
+		return pullRequests
+	}
+}
+
+// isAdminRoute returns true for the routes of the admin API and the other routes only admins can use,
+// the ones behind AuthzOnlyAdminsMiddleware.
+func isAdminRoute(path string) bool {
+	switch path {
+	case constants.RoutePrefix + constants.GCPath,
+		constants.RoutePrefix + constants.QuotaPath,
+		constants.RoutePrefix + constants.ReadOnlyPath:
+		return true
+	}
+
+	for _, prefix := range []string{
+		constants.RoutePrefix + constants.AdminPath + "/",
+		constants.FullScrub,
+		constants.FullNotation,
+		constants.FullCosign,
+		constants.RoutePrefix + debugConstants.ProfilingEndpoint,
+	} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
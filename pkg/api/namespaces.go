@@ -0,0 +1,325 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/mitchellh/mapstructure"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/config"
+	apiErr "zotregistry.dev/zot/pkg/api/errors"
+	zcommon "zotregistry.dev/zot/pkg/common"
+	reqCtx "zotregistry.dev/zot/pkg/requestcontext"
+)
+
+const (
+	namespacePolicies  = "policies"
+	namespaceRetention = "retention"
+	namespaceQuota     = "quota"
+)
+
+// NamespaceSettings are the settings of a namespace, the repositories under "<name>/".
+type NamespaceSettings struct {
+	Name        string                  `json:"name"`
+	AdminUsers  []string                `json:"adminUsers"`
+	AdminGroups []string                `json:"adminGroups"`
+	Policies    *config.PolicyGroup     `json:"policies,omitempty"`
+	Retention   *config.RetentionPolicy `json:"retention,omitempty"`
+	Quota       *config.QuotaPolicy     `json:"quota,omitempty"`
+}
+
+// GetNamespaces godoc
+// @Summary List the namespaces
+// @Description List the settings of the namespaces the user manages, all of them for the registry admins
+// @Router  /v2/_zot/admin/namespaces [get]
+// @Accept  json
+// @Produce json
+// @Success 200 {array} api.NamespaceSettings
+// @Failure 401 {string} string "unauthorized".
+func (rh *RouteHandler) GetNamespaces(response http.ResponseWriter, request *http.Request) {
+	userAc, err := reqCtx.UserAcFromContext(request.Context())
+	if err != nil {
+		response.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	acCtrlr := rh.c.accessController()
+	namespaces := []NamespaceSettings{}
+
+	for namespace, namespaceConfig := range acCtrlr.Config.Namespaces {
+		if acCtrlr.isNamespaceAdmin(userAc.GetUsername(), userAc.GetGroups(), namespace) {
+			namespaces = append(namespaces, getNamespaceSettings(namespace, namespaceConfig))
+		}
+	}
+
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Name < namespaces[j].Name
+	})
+
+	zcommon.WriteJSON(response, http.StatusOK, namespaces)
+}
+
+// GetNamespace godoc
+// @Summary Get a namespace
+// @Description Get the admins, access control policies, retention and quota of a namespace
+// @Router  /v2/_zot/admin/namespaces/{namespace} [get]
+// @Accept  json
+// @Produce json
+// @Param   namespace path string true "namespace name"
+// @Success 200 {object} api.NamespaceSettings
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found".
+func (rh *RouteHandler) GetNamespace(response http.ResponseWriter, request *http.Request) {
+	namespace := mux.Vars(request)["namespace"]
+
+	namespaceConfig, ok := rh.authorizeNamespaceAdmin(response, request, namespace)
+	if !ok {
+		return
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, getNamespaceSettings(namespace, namespaceConfig))
+}
+
+// UpdateNamespace godoc
+// @Summary Set a setting of a namespace
+// @Description Set the access control policies, the retention policy or the quota of a namespace,
+// @Description the durations of the retention policy being strings, e.g. "24h"
+// @Router  /v2/_zot/admin/namespaces/{namespace}/{setting} [put]
+// @Accept  json
+// @Produce json
+// @Param   namespace path string true "namespace name"
+// @Param   setting   path string true "policies, retention or quota"
+// @Success 200 {object} api.NamespaceSettings
+// @Failure 400 {string} string "bad request"
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found".
+func (rh *RouteHandler) UpdateNamespace(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	namespace := vars["namespace"]
+	setting := vars["setting"]
+
+	rh.c.namespacesLock.Lock()
+	defer rh.c.namespacesLock.Unlock()
+
+	namespaceConfig, ok := rh.authorizeNamespaceAdmin(response, request, namespace)
+	if !ok {
+		return
+	}
+
+	var err error
+
+	switch setting {
+	case namespacePolicies:
+		namespaceConfig.Policies = &config.PolicyGroup{}
+		if err = decodeNamespaceSetting(request.Body, namespaceConfig.Policies); err == nil {
+			err = validateNamespacePolicies(*namespaceConfig.Policies)
+		}
+	case namespaceRetention:
+		namespaceConfig.Retention = &config.RetentionPolicy{}
+		if err = decodeNamespaceSetting(request.Body, namespaceConfig.Retention); err == nil {
+			err = validateNamespaceRetention(*namespaceConfig.Retention)
+		}
+	case namespaceQuota:
+		namespaceConfig.Quota = &config.QuotaPolicy{}
+		if err = decodeNamespaceSetting(request.Body, namespaceConfig.Quota); err == nil {
+			err = validateNamespaceQuota(*namespaceConfig.Quota)
+		}
+	}
+
+	if err != nil {
+		rh.c.Log.Info().Err(err).Str("namespace", namespace).Str("setting", setting).
+			Msg("invalid namespace setting")
+
+		e := apiErr.NewError(apiErr.UNSUPPORTED).AddDetail(map[string]string{
+			"namespace": namespace, "setting": setting, "reason": err.Error(),
+		})
+		zcommon.WriteJSON(response, http.StatusBadRequest, apiErr.NewErrorList(e))
+
+		return
+	}
+
+	rh.setNamespace(request, namespace, setting, namespaceConfig)
+
+	zcommon.WriteJSON(response, http.StatusOK, getNamespaceSettings(namespace, namespaceConfig))
+}
+
+// DeleteNamespaceSetting godoc
+// @Summary Remove a setting of a namespace
+// @Description Remove the access control policies, the retention policy or the quota of a namespace
+// @Router  /v2/_zot/admin/namespaces/{namespace}/{setting} [delete]
+// @Accept  json
+// @Produce json
+// @Param   namespace path string true "namespace name"
+// @Param   setting   path string true "policies, retention or quota"
+// @Success 200 {object} api.NamespaceSettings
+// @Failure 403 {string} string "forbidden"
+// @Failure 404 {string} string "not found".
+func (rh *RouteHandler) DeleteNamespaceSetting(response http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	namespace := vars["namespace"]
+	setting := vars["setting"]
+
+	rh.c.namespacesLock.Lock()
+	defer rh.c.namespacesLock.Unlock()
+
+	namespaceConfig, ok := rh.authorizeNamespaceAdmin(response, request, namespace)
+	if !ok {
+		return
+	}
+
+	switch setting {
+	case namespacePolicies:
+		namespaceConfig.Policies = nil
+	case namespaceRetention:
+		namespaceConfig.Retention = nil
+	case namespaceQuota:
+		namespaceConfig.Quota = nil
+	}
+
+	rh.setNamespace(request, namespace, setting, namespaceConfig)
+
+	zcommon.WriteJSON(response, http.StatusOK, getNamespaceSettings(namespace, namespaceConfig))
+}
+
+// authorizeNamespaceAdmin returns the config of the namespace if the user manages it,
+// otherwise it writes the error response.
+func (rh *RouteHandler) authorizeNamespaceAdmin(response http.ResponseWriter, request *http.Request,
+	namespace string,
+) (config.NamespaceConfig, bool) {
+	userAc, err := reqCtx.UserAcFromContext(request.Context())
+	if err != nil {
+		response.WriteHeader(http.StatusUnauthorized)
+
+		return config.NamespaceConfig{}, false
+	}
+
+	acCtrlr := rh.c.accessController()
+
+	if !acCtrlr.isNamespaceAdmin(userAc.GetUsername(), userAc.GetGroups(), namespace) {
+		zcommon.AuthzFail(response, request, userAc.GetUsername(), rh.c.Config.HTTP.Realm,
+			rh.c.Config.HTTP.Auth.FailDelay)
+
+		return config.NamespaceConfig{}, false
+	}
+
+	namespaceConfig, ok := acCtrlr.Config.Namespaces[namespace]
+	if !ok {
+		e := apiErr.NewError(apiErr.NAME_UNKNOWN).AddDetail(map[string]string{"namespace": namespace})
+		zcommon.WriteJSON(response, http.StatusNotFound, apiErr.NewErrorList(e))
+
+		return config.NamespaceConfig{}, false
+	}
+
+	return namespaceConfig, true
+}
+
+// setNamespace replaces the config of the namespace and applies it. The access control config is copied
+// rather than changed, since it's read by the requests being served.
+func (rh *RouteHandler) setNamespace(request *http.Request, namespace, setting string,
+	namespaceConfig config.NamespaceConfig,
+) {
+	current := rh.c.accessControlConfig()
+	accessControl := *current
+
+	accessControl.Namespaces = make(config.Namespaces, len(current.Namespaces))
+	for name, value := range current.Namespaces {
+		accessControl.Namespaces[name] = value
+	}
+
+	accessControl.Namespaces[namespace] = namespaceConfig
+
+	rh.c.setAccessControlConfig(&accessControl)
+
+	username := ""
+	if userAc, err := reqCtx.UserAcFromContext(request.Context()); err == nil {
+		username = userAc.GetUsername()
+	}
+
+	rh.c.Log.Info().Str("namespace", namespace).Str("setting", setting).Str("identity", username).
+		Msg("namespace setting changed")
+
+	// the garbage collectors read the retention policies when they start
+	if setting == namespaceRetention {
+		rh.c.RestartBackgroundTasks()
+	}
+}
+
+func getNamespaceSettings(namespace string, namespaceConfig config.NamespaceConfig) NamespaceSettings {
+	settings := NamespaceSettings{
+		Name:        namespace,
+		AdminUsers:  namespaceConfig.AdminUsers,
+		AdminGroups: namespaceConfig.AdminGroups,
+		Policies:    namespaceConfig.Policies,
+		Retention:   namespaceConfig.Retention,
+		Quota:       namespaceConfig.Quota,
+	}
+
+	if settings.Retention != nil {
+		retention := *settings.Retention
+		retention.Repositories = []string{config.NamespacePattern(namespace)}
+		settings.Retention = &retention
+	}
+
+	if settings.Quota != nil {
+		quota := *settings.Quota
+		quota.Repositories = []string{config.NamespacePattern(namespace)}
+		settings.Quota = &quota
+	}
+
+	return settings
+}
+
+// decodeNamespaceSetting decodes a setting as in the config file, e.g. the durations being strings.
+func decodeNamespaceSetting(body io.Reader, setting interface{}) error {
+	var values map[string]interface{}
+
+	if err := json.NewDecoder(body).Decode(&values); err != nil {
+		return err
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:  mapstructure.StringToTimeDurationHookFunc(),
+		ErrorUnused: true,
+		Result:      setting,
+	})
+	if err != nil {
+		return err
+	}
+
+	return decoder.Decode(values)
+}
+
+func validateNamespacePolicies(policyGroup config.PolicyGroup) error {
+	if policy, action, found := policyGroup.UnknownAction(); found {
+		return fmt.Errorf("%w: unknown action %q in %s", zerr.ErrBadConfig, action, policy)
+	}
+
+	return nil
+}
+
+func validateNamespaceRetention(retention config.RetentionPolicy) error {
+	for _, keepTags := range retention.KeepTags {
+		for _, pattern := range keepTags.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("%w: invalid tag pattern %q: %w", zerr.ErrBadConfig, pattern, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateNamespaceQuota(quota config.QuotaPolicy) error {
+	if quota.MaxBytes < 0 || quota.MaxRepositoryBytes < 0 {
+		return fmt.Errorf("%w: the quota limits can't be negative", zerr.ErrBadConfig)
+	}
+
+	return nil
+}
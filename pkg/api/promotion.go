@@ -0,0 +1,480 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/config"
+	apiErr "zotregistry.dev/zot/pkg/api/errors"
+	zcommon "zotregistry.dev/zot/pkg/common"
+	ext "zotregistry.dev/zot/pkg/extensions"
+	"zotregistry.dev/zot/pkg/extensions/events"
+	cvemodel "zotregistry.dev/zot/pkg/extensions/search/cve/model"
+	"zotregistry.dev/zot/pkg/meta"
+	zreg "zotregistry.dev/zot/pkg/regexp"
+)
+
+const (
+	promotionCheckSignature        = "signature"
+	promotionCheckTrustedSignature = "trustedSignature"
+	promotionCheckCVE              = "cve"
+)
+
+var tagRegexp = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`)
+
+// PromotionRequest asks for the image "<staging prefix>/<Repository>:<Reference>" to be promoted to
+// "<Repository>:<Tag>". The tag defaults to the reference, it is needed if the reference is a digest.
+type PromotionRequest struct {
+	Repository string `json:"repository"`
+	Reference  string `json:"reference"`
+	Tag        string `json:"tag,omitempty"`
+}
+
+// PromotionCheck is the result of one of the checks of the promotion policy.
+type PromotionCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// PromotionResult tells if the image was promoted and the checks it went through.
+type PromotionResult struct {
+	Repository string           `json:"repository"`
+	Tag        string           `json:"tag"`
+	Source     string           `json:"source"`
+	Digest     string           `json:"digest"`
+	Promoted   bool             `json:"promoted"`
+	Checks     []PromotionCheck `json:"checks"`
+}
+
+// PromoteImage godoc
+// @Summary Promote an image of a staging repository
+// @Description Copy an image of "<staging prefix>/<repository>", with its referrers, to the production repository
+// @Description and tag it there, if it passes the checks of the promotion policy matching the repository.
+// @Description The tag is moved last, the image can't be pulled from the production repository before.
+// @Router  /v2/_zot/admin/promote [post]
+// @Accept  json
+// @Produce json
+// @Param   body body api.PromotionRequest true "the image to promote"
+// @Success 200 {object} api.PromotionResult
+// @Failure 400 {string} string "bad request"
+// @Failure 404 {string} string "not found"
+// @Failure 405 {string} string "method not allowed"
+// @Failure 409 {object} api.PromotionResult "the image doesn't pass the checks".
+func (rh *RouteHandler) PromoteImage(response http.ResponseWriter, request *http.Request) {
+	if !rh.c.Config.IsPromotionEnabled() {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	promotion := PromotionRequest{}
+
+	if err := json.Unmarshal(body, &promotion); err != nil {
+		zcommon.WriteJSON(response, http.StatusBadRequest,
+			apiErr.NewErrorList(apiErr.NewError(apiErr.UNSUPPORTED).AddDetail(map[string]string{
+				"error": err.Error(),
+			})))
+
+		return
+	}
+
+	if err := rh.validatePromotionRequest(&promotion); err != nil {
+		zcommon.WriteJSON(response, http.StatusBadRequest,
+			apiErr.NewErrorList(apiErr.NewError(apiErr.UNSUPPORTED).AddDetail(map[string]string{
+				"error": err.Error(),
+			})))
+
+		return
+	}
+
+	staging := rh.c.Config.GetStagingPrefix() + "/" + promotion.Repository
+
+	// the checks are done on the content which is copied, another promotion can't move the tags in between
+	rh.c.promotionLock.Lock()
+	defer rh.c.promotionLock.Unlock()
+
+	srcStore := rh.getImageStore(staging)
+
+	content, digest, mediaType, err := srcStore.GetImageManifest(staging, promotion.Reference)
+	if err != nil {
+		if errors.Is(err, zerr.ErrRepoNotFound) {
+			e := apiErr.NewError(apiErr.NAME_UNKNOWN).AddDetail(map[string]string{"name": staging})
+			zcommon.WriteJSON(response, http.StatusNotFound, apiErr.NewErrorList(e))
+		} else if errors.Is(err, zerr.ErrManifestNotFound) {
+			e := apiErr.NewError(apiErr.MANIFEST_UNKNOWN).AddDetail(map[string]string{
+				"name": staging, "reference": promotion.Reference,
+			})
+			zcommon.WriteJSON(response, http.StatusNotFound, apiErr.NewErrorList(e))
+		} else {
+			rh.c.Log.Error().Err(err).Str("repository", staging).Str("reference", promotion.Reference).
+				Msg("failed to get the manifest to promote")
+			response.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	result := PromotionResult{
+		Repository: promotion.Repository,
+		Tag:        promotion.Tag,
+		Source:     staging + ":" + promotion.Reference,
+		Digest:     digest.String(),
+		Checks:     rh.checkPromotion(request.Context(), promotion.Repository, staging, digest),
+	}
+
+	if _, err := godigest.Parse(promotion.Reference); err == nil {
+		result.Source = staging + "@" + promotion.Reference
+	}
+
+	for _, check := range result.Checks {
+		if !check.Passed {
+			rh.c.Log.Info().Str("source", result.Source).Str("check", check.Name).Str("reason", check.Reason).
+				Msg("refused to promote the image")
+			zcommon.WriteJSON(response, http.StatusConflict, result)
+
+			return
+		}
+	}
+
+	imgStore := rh.getImageStore(promotion.Repository)
+
+	_, previousDigest, _, _ := imgStore.GetImageManifest(promotion.Repository, promotion.Tag)
+	if previousDigest != "" && previousDigest != digest && rh.isTagImmutable(promotion.Repository, promotion.Tag) {
+		e := apiErr.NewError(apiErr.TAG_IMMUTABLE).AddDetail(map[string]string{
+			"name": promotion.Repository, "reference": promotion.Tag, "digest": previousDigest.String(),
+		})
+		zcommon.WriteJSON(response, http.StatusConflict, apiErr.NewErrorList(e))
+
+		return
+	}
+
+	if err := rh.copyPromotedImage(request, staging, promotion.Repository, digest, mediaType, content); err != nil {
+		rh.c.Log.Error().Err(err).Str("source", result.Source).Msg("failed to copy the promoted image")
+		rh.writePromotionError(response, promotion.Repository, err)
+
+		return
+	}
+
+	// moving the tag last makes the promotion atomic, it points to the complete image or to the previous one
+	if err := rh.putPromotedManifest(request, promotion.Repository, promotion.Tag, mediaType, content,
+		previousDigest); err != nil {
+		rh.c.Log.Error().Err(err).Str("source", result.Source).Msg("failed to tag the promoted image")
+		rh.writePromotionError(response, promotion.Repository, err)
+
+		return
+	}
+
+	rh.c.Log.Info().Str("source", result.Source).Str("repository", promotion.Repository).
+		Str("tag", promotion.Tag).Str("digest", digest.String()).Msg("promoted image")
+
+	result.Promoted = true
+
+	zcommon.WriteJSON(response, http.StatusOK, result)
+}
+
+func (rh *RouteHandler) validatePromotionRequest(promotion *PromotionRequest) error {
+	if !zreg.FullNameRegexp.MatchString(promotion.Repository) {
+		return fmt.Errorf("%w: invalid repository %q", zerr.ErrInvalidRequestParams, promotion.Repository)
+	}
+
+	if strings.HasPrefix(promotion.Repository, rh.c.Config.GetStagingPrefix()+"/") {
+		return fmt.Errorf("%w: %q is a staging repository, the production one is expected",
+			zerr.ErrInvalidRequestParams, promotion.Repository)
+	}
+
+	if _, err := godigest.Parse(promotion.Reference); err == nil {
+		if promotion.Tag == "" {
+			return fmt.Errorf("%w: the tag is needed to promote a digest", zerr.ErrInvalidRequestParams)
+		}
+	} else if !tagRegexp.MatchString(promotion.Reference) {
+		return fmt.Errorf("%w: invalid reference %q", zerr.ErrInvalidRequestParams, promotion.Reference)
+	}
+
+	if promotion.Tag == "" {
+		promotion.Tag = promotion.Reference
+	}
+
+	if !tagRegexp.MatchString(promotion.Tag) {
+		return fmt.Errorf("%w: invalid tag %q", zerr.ErrInvalidRequestParams, promotion.Tag)
+	}
+
+	return nil
+}
+
+// getPromotionPolicy returns the first policy matching the production repository.
+func (rh *RouteHandler) getPromotionPolicy(repo string) (config.PromotionPolicy, bool) {
+	for _, policy := range rh.c.Config.Storage.Promotion.Policies {
+		if zcommon.MatchesAnyGlob(policy.Repositories, repo) {
+			return policy, true
+		}
+	}
+
+	return config.PromotionPolicy{}, false
+}
+
+// checkPromotion runs the checks of the promotion policy of the repository on the staging image.
+func (rh *RouteHandler) checkPromotion(ctx context.Context, repo, staging string, digest godigest.Digest,
+) []PromotionCheck {
+	checks := []PromotionCheck{}
+
+	policy, ok := rh.getPromotionPolicy(repo)
+	if !ok {
+		return checks
+	}
+
+	if policy.RequireSignature {
+		check := PromotionCheck{Name: promotionCheckSignature, Passed: rh.isImageSigned(ctx, staging, digest)}
+		if !check.Passed {
+			check.Reason = "the image isn't signed"
+		}
+
+		checks = append(checks, check)
+	}
+
+	if policy.RequireTrustedSignature {
+		check := PromotionCheck{Name: promotionCheckTrustedSignature, Passed: true}
+
+		if err := ext.CheckImageTrusted(ctx, rh.c.MetaDB, staging, digest); err != nil {
+			check.Passed = false
+			check.Reason = err.Error()
+		}
+
+		checks = append(checks, check)
+	}
+
+	if policy.MaxSeverity != "" {
+		check := PromotionCheck{Name: promotionCheckCVE, Passed: true}
+		maxSeverity := strings.ToUpper(policy.MaxSeverity)
+
+		severity, err := ext.GetImageMaxSeverity(ctx, rh.c.CveScanner, staging, digest.String())
+		if err != nil {
+			check.Passed = false
+			check.Reason = fmt.Sprintf("failed to scan the image: %s", err)
+		} else if cvemodel.CompareSeverities(maxSeverity, severity) > 0 {
+			check.Passed = false
+			check.Reason = fmt.Sprintf("the image has %s CVEs, the highest severity allowed is %s", severity, maxSeverity)
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// isImageSigned tells if metaDB knows a cosign or notation signature of the image.
+func (rh *RouteHandler) isImageSigned(ctx context.Context, repo string, digest godigest.Digest) bool {
+	repoMeta, err := rh.c.MetaDB.GetRepoMeta(ctx, repo)
+	if err != nil {
+		return false
+	}
+
+	for _, signatures := range repoMeta.Signatures[digest.String()] {
+		if len(signatures) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// copyPromotedImage copies the image, untagged, with its referrers and its cosign tags from the staging
+// repository to the production one.
+func (rh *RouteHandler) copyPromotedImage(request *http.Request, staging, repo string, digest godigest.Digest,
+	mediaType string, content []byte,
+) error {
+	if err := rh.copyPromotedManifest(request, staging, repo, digest.String(), mediaType, content); err != nil {
+		return err
+	}
+
+	srcStore := rh.getImageStore(staging)
+
+	referrers, err := srcStore.GetReferrers(staging, digest, nil)
+	if err != nil && !errors.Is(err, zerr.ErrManifestNotFound) {
+		return err
+	}
+
+	for _, referrer := range referrers.Manifests {
+		content, _, mediaType, err := srcStore.GetImageManifest(staging, referrer.Digest.String())
+		if err != nil {
+			return err
+		}
+
+		if err := rh.copyPromotedManifest(request, staging, repo, referrer.Digest.String(), mediaType,
+			content); err != nil {
+			return err
+		}
+	}
+
+	tags, err := srcStore.GetImageTags(staging)
+	if err != nil {
+		return err
+	}
+
+	// the cosign signatures, sboms and attestations are tagged "sha256-<hex>.<suffix>"
+	cosignTagPrefix := fmt.Sprintf("%s-%s.", digest.Algorithm(), digest.Encoded())
+
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, cosignTagPrefix) {
+			continue
+		}
+
+		content, _, mediaType, err := srcStore.GetImageManifest(staging, tag)
+		if err != nil {
+			return err
+		}
+
+		if err := rh.copyPromotedManifest(request, staging, repo, tag, mediaType, content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyPromotedManifest copies the blobs and the manifests referenced by the manifest, then the manifest itself.
+func (rh *RouteHandler) copyPromotedManifest(request *http.Request, staging, repo, reference, mediaType string,
+	content []byte,
+) error {
+	srcStore := rh.getImageStore(staging)
+
+	switch mediaType {
+	case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
+		var index ispec.Index
+
+		if err := json.Unmarshal(content, &index); err != nil {
+			return err
+		}
+
+		for _, manifest := range index.Manifests {
+			content, _, mediaType, err := srcStore.GetImageManifest(staging, manifest.Digest.String())
+			if err != nil {
+				return err
+			}
+
+			if err := rh.copyPromotedManifest(request, staging, repo, manifest.Digest.String(), mediaType,
+				content); err != nil {
+				return err
+			}
+		}
+	default:
+		var manifest ispec.Manifest
+
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			return err
+		}
+
+		for _, blob := range append([]ispec.Descriptor{manifest.Config}, manifest.Layers...) {
+			// the foreign layers aren't in the registry
+			if blob.MediaType == schema2.MediaTypeForeignLayer {
+				continue
+			}
+
+			if err := rh.copyPromotedBlob(staging, repo, blob.Digest); err != nil {
+				return err
+			}
+		}
+	}
+
+	return rh.putPromotedManifest(request, repo, reference, mediaType, content, "")
+}
+
+// copyPromotedBlob copies the blob to the production repository if it isn't there already.
+func (rh *RouteHandler) copyPromotedBlob(staging, repo string, digest godigest.Digest) error {
+	srcStore := rh.getImageStore(staging)
+	imgStore := rh.getImageStore(repo)
+
+	if ok, _, err := imgStore.CheckBlob(repo, digest); err == nil && ok {
+		return nil
+	}
+
+	_, size, _, err := srcStore.StatBlob(staging, digest)
+	if err != nil {
+		return err
+	}
+
+	if quotas := rh.c.getQuotas(); quotas != nil {
+		if err := quotas.Check(repo, size); err != nil {
+			return err
+		}
+	}
+
+	blob, _, err := srcStore.GetBlob(staging, digest, ispec.MediaTypeImageLayer)
+	if err != nil {
+		return err
+	}
+
+	defer blob.Close()
+
+	_, _, err = imgStore.FullBlobUpload(repo, blob, digest)
+
+	return err
+}
+
+// putPromotedManifest pushes the manifest to the production repository, with the side effects of a push.
+func (rh *RouteHandler) putPromotedManifest(request *http.Request, repo, reference, mediaType string,
+	content []byte, previousDigest godigest.Digest,
+) error {
+	imgStore := rh.getImageStore(repo)
+
+	digest, _, err := imgStore.PutImageManifest(repo, reference, mediaType, content)
+	if err != nil {
+		return err
+	}
+
+	if rh.c.MetaDB != nil {
+		if err := meta.OnUpdateManifest(request.Context(), repo, reference, mediaType, digest, content,
+			rh.c.StoreController, rh.c.MetaDB, rh.c.Log); err != nil {
+			return err
+		}
+	}
+
+	ext.ScanPushedImage(rh.c.Config, rh.c.getTaskScheduler(), rh.c.CveScanner, repo, digest.String(), rh.c.Log)
+
+	rh.notifyEvent(request, events.Event{
+		Type: events.PushEvent, Repository: repo, Reference: reference, Digest: digest.String(), MediaType: mediaType,
+	})
+
+	if previousDigest != "" && previousDigest != digest {
+		rh.notifyEvent(request, events.Event{
+			Type: events.TagEvent, Repository: repo, Reference: reference, Digest: digest.String(), MediaType: mediaType,
+			PreviousDigest: previousDigest.String(),
+		})
+	}
+
+	return nil
+}
+
+func (rh *RouteHandler) writePromotionError(response http.ResponseWriter, repo string, err error) {
+	switch {
+	case errors.Is(err, zerr.ErrQuotaExceeded):
+		e := apiErr.NewError(apiErr.QUOTA_EXCEEDED).AddDetail(map[string]string{"name": repo, "reason": err.Error()})
+		zcommon.WriteJSON(response, http.StatusRequestEntityTooLarge, apiErr.NewErrorList(e))
+	case errors.Is(err, zerr.ErrBadManifest), errors.Is(err, zerr.ErrImageLintAnnotations):
+		details := zerr.GetDetails(err)
+		details["name"] = repo
+		e := apiErr.NewError(apiErr.MANIFEST_INVALID).AddDetail(details)
+		zcommon.WriteJSON(response, http.StatusBadRequest, apiErr.NewErrorList(e))
+	default:
+		response.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -13,7 +13,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"path"
 	"regexp"
@@ -22,6 +24,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
 	guuid "github.com/gofrs/uuid"
 	"github.com/google/go-github/v52/github"
 	"github.com/gorilla/mux"
@@ -42,13 +46,18 @@ import (
 	pprof "zotregistry.dev/zot/pkg/debug/pprof"
 	debug "zotregistry.dev/zot/pkg/debug/swagger"
 	ext "zotregistry.dev/zot/pkg/extensions"
+	"zotregistry.dev/zot/pkg/extensions/events"
 	syncConstants "zotregistry.dev/zot/pkg/extensions/sync/constants"
+	"zotregistry.dev/zot/pkg/extensions/tracing"
 	"zotregistry.dev/zot/pkg/log"
 	"zotregistry.dev/zot/pkg/meta"
 	mTypes "zotregistry.dev/zot/pkg/meta/types"
 	zreg "zotregistry.dev/zot/pkg/regexp"
 	reqCtx "zotregistry.dev/zot/pkg/requestcontext"
+	"zotregistry.dev/zot/pkg/scheduler"
+	"zotregistry.dev/zot/pkg/storage"
 	storageCommon "zotregistry.dev/zot/pkg/storage/common"
+	"zotregistry.dev/zot/pkg/storage/recompression"
 	storageTypes "zotregistry.dev/zot/pkg/storage/types"
 	"zotregistry.dev/zot/pkg/test/inject"
 )
@@ -66,7 +75,7 @@ func NewRouteHandler(c *Controller) *RouteHandler {
 
 func (rh *RouteHandler) SetupRoutes() {
 	// first get Auth middleware in order to first setup openid/ldap/htpasswd, before oidc provider routes are setup
-	authHandler := AuthHandler(rh.c)
+	authHandler := tracing.TracedMiddleware("authn", AuthHandler(rh.c))
 
 	applyCORSHeaders := getCORSHeadersHandler(rh.c.Config.HTTP.AllowOrigin)
 
@@ -81,7 +90,7 @@ func (rh *RouteHandler) SetupRoutes() {
 					rp.CodeExchangeHandler(rh.GithubCodeExchangeCallback(), relyingParty))
 			} else if config.IsOpenIDSupported(provider) {
 				rh.c.Router.HandleFunc(constants.CallbackBasePath+fmt.Sprintf("/%s", provider),
-					rp.CodeExchangeHandler(rp.UserinfoCallback(rh.OpenIDCodeExchangeCallback()), relyingParty))
+					rp.CodeExchangeHandler(rp.UserinfoCallback(rh.OpenIDCodeExchangeCallback(provider)), relyingParty))
 			}
 		}
 	}
@@ -90,7 +99,7 @@ func (rh *RouteHandler) SetupRoutes() {
 		// enable api key management urls
 		apiKeyRouter := rh.c.Router.PathPrefix(constants.APIKeyPath).Subrouter()
 		apiKeyRouter.Use(authHandler)
-		apiKeyRouter.Use(BaseAuthzHandler(rh.c))
+		apiKeyRouter.Use(tracing.TracedMiddleware("authz", BaseAuthzHandler(rh.c)))
 
 		// Always use CORSHeadersMiddleware before ACHeadersMiddleware
 		apiKeyRouter.Use(zcommon.CORSHeadersMiddleware(rh.c.Config.HTTP.AllowOrigin))
@@ -111,9 +120,19 @@ func (rh *RouteHandler) SetupRoutes() {
 			Methods(http.MethodPost, http.MethodOptions)
 	}
 
+	if rh.c.Config.IsTokenServerEnabled() {
+		// built-in token server, the users get their tokens with their credentials
+		rh.c.Router.Handle(constants.TokenPath, tokenAuthnHandler(rh.c)(TokenHandler(rh.c))).
+			Methods(http.MethodGet)
+	}
+
 	prefixedRouter := rh.c.Router.PathPrefix(constants.RoutePrefix).Subrouter()
 	prefixedRouter.Use(authHandler)
 
+	if rh.c.Config.HTTP.Ratelimit != nil && rh.c.Config.HTTP.Ratelimit.UserRate != nil {
+		prefixedRouter.Use(UserRateLimiter(rh.c, *rh.c.Config.HTTP.Ratelimit.UserRate))
+	}
+
 	prefixedDistSpecRouter := prefixedRouter.NewRoute().Subrouter()
 	// authz is being enabled if AccessControl is specified
 	// if Authn is not present AccessControl will have only default policies
@@ -124,8 +143,8 @@ func (rh *RouteHandler) SetupRoutes() {
 			rh.c.Log.Info().Msg("anonymous policy only access control is being enabled")
 		}
 
-		prefixedRouter.Use(BaseAuthzHandler(rh.c))
-		prefixedDistSpecRouter.Use(DistSpecAuthzHandler(rh.c))
+		prefixedRouter.Use(tracing.TracedMiddleware("authz", BaseAuthzHandler(rh.c)))
+		prefixedDistSpecRouter.Use(tracing.TracedMiddleware("authz", DistSpecAuthzHandler(rh.c)))
 	}
 
 	// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#endpoints
@@ -173,6 +192,10 @@ func (rh *RouteHandler) SetupRoutes() {
 				applyCORSHeaders(rh.CheckVersionSupport))).Methods(http.MethodGet, http.MethodOptions)
 	}
 
+	// health probes, reachable without credentials
+	rh.c.Router.HandleFunc(constants.LivenessPath, rh.Livez).Methods(http.MethodGet, http.MethodHead)
+	rh.c.Router.HandleFunc(constants.ReadinessPath, rh.Readyz).Methods(http.MethodGet, http.MethodHead)
+
 	// support for ORAS artifact reference types (alpha 1) - image signature use case
 	rh.c.Router.HandleFunc(fmt.Sprintf("%s/{name:%s}/manifests/{digest}/referrers",
 		constants.ArtifactSpecRoutePrefix, zreg.NameRegexp.String()), rh.GetOrasReferrers).Methods("GET")
@@ -183,6 +206,49 @@ func (rh *RouteHandler) SetupRoutes() {
 	gqlPlayground.SetupGQLPlaygroundRoutes(prefixedRouter, rh.c.StoreController, rh.c.Log)
 	// pprof
 	pprof.SetupPprofRoutes(rh.c.Config, prefixedRouter, authHandler, rh.c.Log)
+	// garbage collect on demand, only for admins if authn/authz are enabled
+	prefixedRouter.Handle(constants.GCPath,
+		zcommon.AuthzOnlyAdminsMiddleware(rh.c.Config)(http.HandlerFunc(rh.RunGarbageCollect))).
+		Methods(http.MethodPost)
+	// storage usage against the quotas, only for admins if authn/authz are enabled
+	prefixedRouter.Handle(constants.QuotaPath,
+		zcommon.AuthzOnlyAdminsMiddleware(rh.c.Config)(http.HandlerFunc(rh.GetQuotaUsage))).
+		Methods(http.MethodGet)
+	// read-only mode switch, only for admins if authn/authz are enabled
+	prefixedRouter.Handle(constants.ReadOnlyPath,
+		zcommon.AuthzOnlyAdminsMiddleware(rh.c.Config)(http.HandlerFunc(rh.GetReadOnly))).
+		Methods(http.MethodGet)
+	prefixedRouter.Handle(constants.ReadOnlyPath,
+		zcommon.AuthzOnlyAdminsMiddleware(rh.c.Config)(http.HandlerFunc(rh.SetReadOnly))).
+		Methods(http.MethodPut)
+	// repository management, only for admins if authn/authz are enabled
+	prefixedRouter.Handle(constants.AdminReposPath,
+		zcommon.AuthzOnlyAdminsMiddleware(rh.c.Config)(http.HandlerFunc(rh.GetRepoStats))).
+		Methods(http.MethodGet)
+	prefixedRouter.Handle(fmt.Sprintf("%s/{name:%s}", constants.AdminReposPath, zreg.NameRegexp.String()),
+		zcommon.AuthzOnlyAdminsMiddleware(rh.c.Config)(http.HandlerFunc(rh.DeleteRepository))).
+		Methods(http.MethodDelete)
+	prefixedRouter.Handle(constants.AdminUploadsPath,
+		zcommon.AuthzOnlyAdminsMiddleware(rh.c.Config)(http.HandlerFunc(rh.GetBlobUploads))).
+		Methods(http.MethodGet)
+	prefixedRouter.Handle(constants.AdminTasksPath+"/{task:gc|scrub|sync}",
+		zcommon.AuthzOnlyAdminsMiddleware(rh.c.Config)(http.HandlerFunc(rh.RunAdminTask))).
+		Methods(http.MethodPost)
+	prefixedRouter.Handle(constants.AdminPath+"/{task:gc|retention}/dryrun",
+		zcommon.AuthzOnlyAdminsMiddleware(rh.c.Config)(http.HandlerFunc(rh.GetGarbageCollectReport))).
+		Methods(http.MethodGet)
+	// promotion of the staging images, only for admins if authn/authz are enabled
+	prefixedRouter.Handle(constants.AdminPromotePath,
+		zcommon.AuthzOnlyAdminsMiddleware(rh.c.Config)(http.HandlerFunc(rh.PromoteImage))).
+		Methods(http.MethodPost)
+	// namespaces settings, for the registry admins and the admins of each namespace
+	prefixedRouter.HandleFunc(constants.AdminNamespacesPath, rh.GetNamespaces).Methods(http.MethodGet)
+	prefixedRouter.HandleFunc(constants.AdminNamespacesPath+"/{namespace}/{setting:policies|retention|quota}",
+		rh.UpdateNamespace).Methods(http.MethodPut)
+	prefixedRouter.HandleFunc(constants.AdminNamespacesPath+"/{namespace}/{setting:policies|retention|quota}",
+		rh.DeleteNamespaceSetting).Methods(http.MethodDelete)
+	prefixedRouter.HandleFunc(constants.AdminNamespacesPath+"/{namespace}", rh.GetNamespace).
+		Methods(http.MethodGet)
 
 	// Preconditions for enabling the actual extension routes are part of extensions themselves
 	ext.SetupMetricsRoutes(rh.c.Config, rh.c.Router, authHandler, MetricsAuthzHandler(rh.c), rh.c.Log, rh.c.Metrics)
@@ -191,6 +257,7 @@ func (rh *RouteHandler) SetupRoutes() {
 	ext.SetupImageTrustRoutes(rh.c.Config, prefixedRouter, rh.c.MetaDB, rh.c.Log)
 	ext.SetupMgmtRoutes(rh.c.Config, prefixedRouter, rh.c.Log)
 	ext.SetupUserPreferencesRoutes(rh.c.Config, prefixedRouter, rh.c.MetaDB, rh.c.Log)
+	ext.SetupScrubRoutes(rh.c.Config, prefixedRouter, rh.c.ScrubReport, rh.c.Log)
 	// last should always be UI because it will setup a http.FileServer and paths will be resolved by this FileServer.
 	ext.SetupUIRoutes(rh.c.Config, rh.c.Router, rh.c.Log)
 }
@@ -263,6 +330,7 @@ func (rh *RouteHandler) CheckVersionSupport(response http.ResponseWriter, reques
 // @Param   name  path   string   true   "repository name"
 // @Param   n     query  integer  true   "limit entries for pagination"
 // @Param   last  query  string   true   "last tag value for pagination"
+// @Param   details query boolean false  "also return the digest, media type, size and last modification of the tags"
 // @Success 200 {object}     common.ImageTags
 // @Failure 404 {string}     string                 "not found"
 // @Failure 400 {string}     string                 "bad request".
@@ -326,6 +394,18 @@ func (rh *RouteHandler) ListTags(response http.ResponseWriter, request *http.Req
 		last = lastQuery[0]
 	}
 
+	withDetails := false
+
+	if detailsQuery := request.URL.Query().Get("details"); detailsQuery != "" {
+		var err error
+
+		if withDetails, err = strconv.ParseBool(detailsQuery); err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+	}
+
 	imgStore := rh.getImageStore(name)
 
 	tags, err := imgStore.GetImageTags(name)
@@ -363,6 +443,10 @@ func (rh *RouteHandler) ListTags(response http.ResponseWriter, request *http.Req
 
 	pTags := zcommon.ImageTags{Name: name}
 
+	if withDetails {
+		pTags.Total = len(tags)
+	}
+
 	if paginate && numTags == 0 {
 		pTags.Tags = []string{}
 		zcommon.WriteJSON(response, http.StatusOK, pTags)
@@ -373,21 +457,88 @@ func (rh *RouteHandler) ListTags(response http.ResponseWriter, request *http.Req
 	stopIndex := len(tags) - 1
 	if paginate && (startIndex+numTags < len(tags)) {
 		stopIndex = startIndex + numTags - 1
+
+		// the next pages keep the details
+		detailsParam := ""
+		if withDetails {
+			detailsParam = "&details=true"
+		}
+
 		response.Header().Set(
 			"Link",
-			fmt.Sprintf("</v2/%s/tags/list?n=%d&last=%s>; rel=\"next\"",
+			fmt.Sprintf("</v2/%s/tags/list?n=%d&last=%s%s>; rel=\"next\"",
 				name,
 				numTags,
 				tags[stopIndex],
+				detailsParam,
 			),
 		)
 	}
 
 	pTags.Tags = tags[startIndex : stopIndex+1]
 
+	if withDetails {
+		details, err := getTagDetails(imgStore, name, pTags.Tags)
+		if err != nil {
+			rh.c.Log.Error().Err(err).Str("repository", name).Msg("failed to get the details of the tags")
+			response.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		pTags.Details = details
+	}
+
 	zcommon.WriteJSON(response, http.StatusOK, pTags)
 }
 
+// getTagDetails returns the manifest descriptor of each tag, with the time its manifest was last written
+// to the storage, so the clients can fetch the manifests without checking each tag first.
+func getTagDetails(imgStore storageTypes.ImageStore, repo string, tags []string) ([]zcommon.TagDetails, error) {
+	buf, err := imgStore.GetIndexContent(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var index ispec.Index
+
+	if err := json.Unmarshal(buf, &index); err != nil {
+		return nil, err
+	}
+
+	descriptors := map[string]ispec.Descriptor{}
+
+	for _, descriptor := range index.Manifests {
+		if tag, ok := descriptor.Annotations[ispec.AnnotationRefName]; ok {
+			descriptors[tag] = descriptor
+		}
+	}
+
+	details := make([]zcommon.TagDetails, 0, len(tags))
+
+	for _, tag := range tags {
+		descriptor, ok := descriptors[tag]
+		if !ok {
+			continue
+		}
+
+		_, _, modTime, err := imgStore.StatBlob(repo, descriptor.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		details = append(details, zcommon.TagDetails{
+			Tag:          tag,
+			Digest:       descriptor.Digest.String(),
+			MediaType:    descriptor.MediaType,
+			Size:         descriptor.Size,
+			LastModified: modTime,
+		})
+	}
+
+	return details, nil
+}
+
 // CheckManifest godoc
 // @Summary Check image manifest
 // @Description Check an image's manifest given a reference or a digest
@@ -444,6 +595,8 @@ func (rh *RouteHandler) CheckManifest(response http.ResponseWriter, request *htt
 		return
 	}
 
+	content, digest, mediaType = rh.acceptFallback(request, imgStore, name, reference, content, digest, mediaType)
+
 	response.Header().Set(constants.DistContentDigestKey, digest.String())
 	response.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
 	response.Header().Set("Content-Type", mediaType)
@@ -517,6 +670,15 @@ func (rh *RouteHandler) GetManifest(response http.ResponseWriter, request *http.
 		return
 	}
 
+	content, digest, mediaType = rh.acceptFallback(request, imgStore, name, reference, content, digest, mediaType)
+
+	if err := ext.CheckPullTrustPolicy(request.Context(), rh.c.Config, rh.c.MetaDB, name, reference, digest,
+		mediaType, content); err != nil {
+		rh.writeNotTrusted(response, name, reference, err)
+
+		return
+	}
+
 	if rh.c.MetaDB != nil {
 		err := meta.OnGetManifest(name, reference, mediaType, content, rh.c.StoreController, rh.c.MetaDB, rh.c.Log)
 		if err != nil {
@@ -526,6 +688,10 @@ func (rh *RouteHandler) GetManifest(response http.ResponseWriter, request *http.
 		}
 	}
 
+	rh.notifyEvent(request, events.Event{
+		Type: events.PullEvent, Repository: name, Reference: reference, Digest: digest.String(), MediaType: mediaType,
+	})
+
 	response.Header().Set(constants.DistContentDigestKey, digest.String())
 	response.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
 	response.Header().Set("Content-Type", mediaType)
@@ -542,11 +708,11 @@ func getReferrers(ctx context.Context, routeHandler *RouteHandler,
 ) (ispec.Index, error) {
 	refs, err := imgStore.GetReferrers(name, digest, artifactTypes)
 	if err != nil || len(refs.Manifests) == 0 {
-		if isSyncOnDemandEnabled(*routeHandler.c) {
+		if syncOnDemand := getSyncOnDemand(routeHandler.c); syncOnDemand != nil {
 			routeHandler.c.Log.Info().Str("repository", name).Str("reference", digest.String()).
 				Msg("referrers not found, trying to get reference by syncing on demand")
 
-			if errSync := routeHandler.c.SyncOnDemand.SyncReference(ctx, name, digest.String(),
+			if errSync := syncOnDemand.SyncReference(ctx, name, digest.String(),
 				syncConstants.OCI); errSync != nil {
 				routeHandler.c.Log.Err(errSync).Str("repository", name).Str("reference", digest.String()).
 					Msg("failed to sync OCI reference for image")
@@ -666,7 +832,7 @@ func (rh *RouteHandler) UpdateManifest(response http.ResponseWriter, request *ht
 	}
 
 	mediaType := request.Header.Get("Content-Type")
-	if !storageCommon.IsSupportedMediaType(mediaType) {
+	if !rh.isSupportedMediaType(mediaType) {
 		err := apiErr.NewError(apiErr.MANIFEST_INVALID).AddDetail(map[string]string{"mediaType": mediaType})
 		zcommon.WriteJSON(response, http.StatusUnsupportedMediaType, apiErr.NewErrorList(err))
 
@@ -683,7 +849,48 @@ func (rh *RouteHandler) UpdateManifest(response http.ResponseWriter, request *ht
 		return
 	}
 
+	if rh.hasForeignLayers(mediaType, body) {
+		e := apiErr.NewError(apiErr.MANIFEST_INVALID).AddDetail(map[string]string{
+			"reference": reference, "mediaType": schema2.MediaTypeForeignLayer,
+		})
+		zcommon.WriteJSON(response, http.StatusBadRequest, apiErr.NewErrorList(e))
+
+		return
+	}
+
+	// the tag events and the trust policies need the manifest the tag pointed to
+	var previousDigest godigest.Digest
+
+	if _, err := godigest.Parse(reference); err != nil && (rh.c.Config.IsEventsEnabled() ||
+		rh.c.Config.IsActivityEnabled() || rh.c.Config.IsTrustPolicyEnabled() ||
+		len(rh.c.Config.Storage.ImmutableTags) > 0) {
+		_, previousDigest, _, _ = imgStore.GetImageManifest(name, reference)
+	}
+
+	if previousDigest != "" && previousDigest != godigest.FromBytes(body) {
+		if rh.isTagImmutable(name, reference) {
+			rh.c.Log.Info().Str("repository", name).Str("tag", reference).Msg("rejected push to immutable tag")
+
+			e := apiErr.NewError(apiErr.TAG_IMMUTABLE).AddDetail(map[string]string{
+				"name": name, "reference": reference, "digest": previousDigest.String(),
+			})
+			zcommon.WriteJSON(response, http.StatusConflict, apiErr.NewErrorList(e))
+
+			return
+		}
+
+		if err := ext.CheckOverwriteTrustPolicy(request.Context(), rh.c.Config, rh.c.MetaDB, name, reference,
+			godigest.FromBytes(body), mediaType, body); err != nil {
+			rh.writeNotTrusted(response, name, reference, err)
+
+			return
+		}
+	}
+
+	span := tracing.StartStorageSpan(request.Context(), "PutImageManifest", name)
 	digest, subjectDigest, err := imgStore.PutImageManifest(name, reference, mediaType, body)
+	span.End()
+
 	if err != nil {
 		details := zerr.GetDetails(err)
 		if errors.Is(err, zerr.ErrRepoNotFound) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
@@ -734,6 +941,21 @@ func (rh *RouteHandler) UpdateManifest(response http.ResponseWriter, request *ht
 		}
 	}
 
+	ext.ScanPushedImage(rh.c.Config, rh.c.getTaskScheduler(), rh.c.CveScanner, name, digest.String(), rh.c.Log)
+
+	rh.recompressPushedImage(name, reference)
+
+	rh.notifyEvent(request, events.Event{
+		Type: events.PushEvent, Repository: name, Reference: reference, Digest: digest.String(), MediaType: mediaType,
+	})
+
+	if previousDigest != "" && previousDigest != digest {
+		rh.notifyEvent(request, events.Event{
+			Type: events.TagEvent, Repository: name, Reference: reference, Digest: digest.String(), MediaType: mediaType,
+			PreviousDigest: previousDigest.String(),
+		})
+	}
+
 	if subjectDigest.String() != "" {
 		response.Header().Set(constants.SubjectDigestKey, subjectDigest.String())
 	}
@@ -807,7 +1029,23 @@ func (rh *RouteHandler) DeleteManifest(response http.ResponseWriter, request *ht
 		return
 	}
 
+	// deleting an immutable tag, or the manifest it points to, would let it be pushed again with another manifest
+	if tag, ok := rh.immutableTagOf(imgStore, name, reference, manifestDigest); ok {
+		rh.c.Log.Info().Str("repository", name).Str("reference", reference).Str("tag", tag).
+			Msg("rejected delete of immutable tag")
+
+		e := apiErr.NewError(apiErr.TAG_IMMUTABLE).AddDetail(map[string]string{
+			"name": name, "reference": reference, "tag": tag, "digest": manifestDigest.String(),
+		})
+		zcommon.WriteJSON(response, http.StatusConflict, apiErr.NewErrorList(e))
+
+		return
+	}
+
+	span := tracing.StartStorageSpan(request.Context(), "DeleteImageManifest", name)
 	err = imgStore.DeleteImageManifest(name, reference, detectCollision)
+	span.End()
+
 	if err != nil { //nolint: dupl
 		details := zerr.GetDetails(err)
 		if errors.Is(err, zerr.ErrRepoNotFound) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
@@ -849,6 +1087,11 @@ func (rh *RouteHandler) DeleteManifest(response http.ResponseWriter, request *ht
 		}
 	}
 
+	rh.notifyEvent(request, events.Event{
+		Type: events.DeleteEvent, Repository: name, Reference: reference, Digest: manifestDigest.String(),
+		MediaType: mediaType,
+	})
+
 	response.WriteHeader(http.StatusAccepted)
 }
 
@@ -884,7 +1127,10 @@ func (rh *RouteHandler) CheckBlob(response http.ResponseWriter, request *http.Re
 
 	digest := godigest.Digest(digestStr)
 
+	span := tracing.StartStorageSpan(request.Context(), "CheckBlob", name)
 	ok, blen, err := imgStore.CheckBlob(name, digest)
+	span.End()
+
 	if err != nil {
 		details := zerr.GetDetails(err)
 		if errors.Is(err, zerr.ErrBadBlobDigest) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
@@ -921,6 +1167,32 @@ func (rh *RouteHandler) CheckBlob(response http.ResponseWriter, request *http.Re
 }
 
 /* parseRangeHeader validates the "Range" HTTP header and returns the range. */
+type blobRange struct {
+	from int64
+	to   int64
+}
+
+// parseRangesHeader parses a range header with one or more ranges, e.g. bytes=0-10,20-30.
+func parseRangesHeader(contentRange string) ([]blobRange, error) {
+	rangesStr, ok := strings.CutPrefix(contentRange, "bytes=")
+	if !ok {
+		return nil, zerr.ErrParsingHTTPHeader
+	}
+
+	ranges := []blobRange{}
+
+	for _, rangeStr := range strings.Split(rangesStr, ",") {
+		from, to, err := parseRangeHeader("bytes=" + strings.TrimSpace(rangeStr))
+		if err != nil {
+			return nil, err
+		}
+
+		ranges = append(ranges, blobRange{from: from, to: to})
+	}
+
+	return ranges, nil
+}
+
 func parseRangeHeader(contentRange string) (int64, int64, error) {
 	/* bytes=<start>- and bytes=<start>-<end> formats are supported */
 	pattern := `bytes=(?P<rangeFrom>\d+)-(?P<rangeTo>\d*$)`
@@ -998,13 +1270,15 @@ func (rh *RouteHandler) GetBlob(response http.ResponseWriter, request *http.Requ
 
 	digest := godigest.Digest(digestStr)
 
-	mediaType := request.Header.Get("Accept")
+	mediaType := getBlobContentType(request.Header.Get("Accept"))
 
 	/* content range is supported for resumbale pulls */
 	partial := false
 
 	var from, to int64
 
+	var ranges []blobRange
+
 	var err error
 
 	contentRange := request.Header.Get("Range")
@@ -1017,44 +1291,40 @@ func (rh *RouteHandler) GetBlob(response http.ResponseWriter, request *http.Requ
 	}
 
 	if contentRange != "" {
-		from, to, err = parseRangeHeader(contentRange)
+		ranges, err = parseRangesHeader(contentRange)
 		if err != nil {
 			response.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
 
 			return
 		}
 
+		from, to = ranges[0].from, ranges[0].to
 		partial = true
 	}
 
+	// the clients pulling zstd:chunked or estargz layers partially ask for the files they miss in a single request
+	if len(ranges) > 1 {
+		rh.getBlobRanges(response, imgStore, name, digest, mediaType, ranges)
+
+		return
+	}
+
 	var repo io.ReadCloser
 
 	var blen, bsize int64
 
+	span := tracing.StartStorageSpan(request.Context(), "GetBlob", name)
+
 	if partial {
 		repo, blen, bsize, err = imgStore.GetBlobPartial(name, digest, mediaType, from, to)
 	} else {
 		repo, blen, err = imgStore.GetBlob(name, digest, mediaType)
 	}
 
+	span.End()
+
 	if err != nil {
-		details := zerr.GetDetails(err)
-		if errors.Is(err, zerr.ErrBadBlobDigest) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
-			details["digest"] = digest.String()
-			e := apiErr.NewError(apiErr.DIGEST_INVALID).AddDetail(details)
-			zcommon.WriteJSON(response, http.StatusBadRequest, apiErr.NewErrorList(e))
-		} else if errors.Is(err, zerr.ErrRepoNotFound) {
-			details["name"] = name
-			e := apiErr.NewError(apiErr.NAME_UNKNOWN).AddDetail(details)
-			zcommon.WriteJSON(response, http.StatusNotFound, apiErr.NewErrorList(e))
-		} else if errors.Is(err, zerr.ErrBlobNotFound) {
-			details["digest"] = digest.String()
-			e := apiErr.NewError(apiErr.BLOB_UNKNOWN).AddDetail(details)
-			zcommon.WriteJSON(response, http.StatusNotFound, apiErr.NewErrorList(e))
-		} else {
-			rh.c.Log.Error().Err(err).Msg("unexpected error")
-			response.WriteHeader(http.StatusInternalServerError)
-		}
+		rh.writeGetBlobError(response, name, digest, err)
 
 		return
 	}
@@ -1076,6 +1346,89 @@ func (rh *RouteHandler) GetBlob(response http.ResponseWriter, request *http.Requ
 	WriteDataFromReader(response, status, blen, mediaType, repo, rh.c.Log)
 }
 
+func (rh *RouteHandler) writeGetBlobError(response http.ResponseWriter, name string, digest godigest.Digest,
+	err error,
+) {
+	details := zerr.GetDetails(err)
+	if errors.Is(err, zerr.ErrBadBlobDigest) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
+		details["digest"] = digest.String()
+		e := apiErr.NewError(apiErr.DIGEST_INVALID).AddDetail(details)
+		zcommon.WriteJSON(response, http.StatusBadRequest, apiErr.NewErrorList(e))
+	} else if errors.Is(err, zerr.ErrRepoNotFound) {
+		details["name"] = name
+		e := apiErr.NewError(apiErr.NAME_UNKNOWN).AddDetail(details)
+		zcommon.WriteJSON(response, http.StatusNotFound, apiErr.NewErrorList(e))
+	} else if errors.Is(err, zerr.ErrBlobNotFound) {
+		details["digest"] = digest.String()
+		e := apiErr.NewError(apiErr.BLOB_UNKNOWN).AddDetail(details)
+		zcommon.WriteJSON(response, http.StatusNotFound, apiErr.NewErrorList(e))
+	} else {
+		rh.c.Log.Error().Err(err).Msg("unexpected error")
+		response.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// getBlobRanges writes the ranges of the blob as the parts of a multipart/byteranges response.
+func (rh *RouteHandler) getBlobRanges(response http.ResponseWriter, imgStore storageTypes.ImageStore,
+	name string, digest godigest.Digest, mediaType string, ranges []blobRange,
+) {
+	multipartWriter := multipart.NewWriter(response)
+
+	for idx, blobRange := range ranges {
+		reader, blen, bsize, err := imgStore.GetBlobPartial(name, digest, mediaType, blobRange.from, blobRange.to)
+		if err != nil {
+			// the status is already sent after the first part
+			if idx == 0 {
+				rh.writeGetBlobError(response, name, digest, err)
+			} else {
+				rh.c.Log.Error().Err(err).Str("repository", name).Str("digest", digest.String()).
+					Msg("failed to read blob range")
+			}
+
+			return
+		}
+
+		if idx == 0 {
+			response.Header().Set("Content-Type", "multipart/byteranges; boundary="+multipartWriter.Boundary())
+			response.WriteHeader(http.StatusPartialContent)
+		}
+
+		part, err := multipartWriter.CreatePart(textproto.MIMEHeader{
+			"Content-Type":  {mediaType},
+			"Content-Range": {fmt.Sprintf("bytes %d-%d/%d", blobRange.from, blobRange.from+blen-1, bsize)},
+		})
+		if err == nil {
+			_, err = io.Copy(part, reader)
+		}
+
+		reader.Close()
+
+		if err != nil {
+			rh.c.Log.Error().Err(err).Msg("failed to copy data into http response")
+
+			return
+		}
+	}
+
+	if err := multipartWriter.Close(); err != nil {
+		rh.c.Log.Error().Err(err).Msg("failed to copy data into http response")
+	}
+}
+
+// getBlobContentType returns the first media type accepted by the client, blobs are sent as they were pushed.
+func getBlobContentType(accept string) string {
+	for _, mediaType := range strings.Split(accept, ",") {
+		mediaType, _, _ = strings.Cut(mediaType, ";")
+		mediaType = strings.TrimSpace(mediaType)
+
+		if mediaType != "" && mediaType != "*/*" {
+			return mediaType
+		}
+	}
+
+	return "application/octet-stream"
+}
+
 // DeleteBlob godoc
 // @Summary Delete image blob/layer
 // @Description Delete an image's blob/layer given a digest
@@ -1106,7 +1459,10 @@ func (rh *RouteHandler) DeleteBlob(response http.ResponseWriter, request *http.R
 
 	imgStore := rh.getImageStore(name)
 
+	span := tracing.StartStorageSpan(request.Context(), "DeleteBlob", name)
 	err = imgStore.DeleteBlob(name, digest)
+	span.End()
+
 	if err != nil {
 		details := zerr.GetDetails(err)
 		if errors.Is(err, zerr.ErrBadBlobDigest) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
@@ -1161,7 +1517,7 @@ func (rh *RouteHandler) CreateBlobUpload(response http.ResponseWriter, request *
 
 	imgStore := rh.getImageStore(name)
 
-	// currently zot does not support cross-repository mounting, following dist-spec and returning 202
+	// cross-repository mounting, following dist-spec a new upload is started (202) if the blob can't be mounted
 	if mountDigests, ok := request.URL.Query()["mount"]; ok {
 		if len(mountDigests) != 1 {
 			response.WriteHeader(http.StatusBadRequest)
@@ -1170,11 +1526,8 @@ func (rh *RouteHandler) CreateBlobUpload(response http.ResponseWriter, request *
 		}
 
 		mountDigest := godigest.Digest(mountDigests[0])
-		// zot does not support cross mounting directly and do a workaround creating using hard link.
-		// check blob looks for actual path (name+mountDigests[0]) first then look for cache and
-		// if found in cache, will do hard link and if fails we will start new upload.
-		_, _, err := imgStore.CheckBlob(name, mountDigest)
-		if err != nil {
+
+		if !rh.mountBlob(request, name, request.URL.Query().Get("from"), mountDigest) {
 			upload, err := imgStore.NewBlobUpload(name)
 			if err != nil {
 				details := zerr.GetDetails(err)
@@ -1247,7 +1600,14 @@ func (rh *RouteHandler) CreateBlobUpload(response http.ResponseWriter, request *
 			return
 		}
 
+		if !rh.checkQuota(response, name, contentLength) {
+			return
+		}
+
+		span := tracing.StartStorageSpan(request.Context(), "FullBlobUpload", name)
 		sessionID, size, err := imgStore.FullBlobUpload(name, request.Body, digest)
+		span.End()
+
 		if err != nil {
 			rh.c.Log.Error().Err(err).Int64("actual", size).Int64("expected", contentLength).
 				Msg("failed to full blob upload")
@@ -1270,6 +1630,11 @@ func (rh *RouteHandler) CreateBlobUpload(response http.ResponseWriter, request *
 		return
 	}
 
+	// no need to start an upload which can't be finished
+	if !rh.checkQuota(response, name, 0) {
+		return
+	}
+
 	upload, err := imgStore.NewBlobUpload(name)
 	if err != nil {
 		details := zerr.GetDetails(err)
@@ -1347,7 +1712,7 @@ func (rh *RouteHandler) GetBlobUpload(response http.ResponseWriter, request *htt
 	}
 
 	response.Header().Set("Location", getBlobUploadSessionLocation(request.URL, sessionID))
-	response.Header().Set("Range", fmt.Sprintf("0-%d", size-1))
+	response.Header().Set("Range", getUploadRange(size))
 	response.WriteHeader(http.StatusNoContent)
 }
 
@@ -1386,13 +1751,19 @@ func (rh *RouteHandler) PatchBlobUpload(response http.ResponseWriter, request *h
 		return
 	}
 
+	if !rh.checkUploadQuota(response, request, imgStore, name, sessionID) {
+		return
+	}
+
 	var clen int64
 
 	var err error
 
 	if request.Header.Get("Content-Length") == "" || request.Header.Get("Content-Range") == "" {
 		// streamed blob upload
+		span := tracing.StartStorageSpan(request.Context(), "PutBlobChunkStreamed", name)
 		clen, err = imgStore.PutBlobChunkStreamed(name, sessionID, request.Body)
+		span.End()
 	} else {
 		// chunked blob upload
 
@@ -1412,7 +1783,9 @@ func (rh *RouteHandler) PatchBlobUpload(response http.ResponseWriter, request *h
 			return
 		}
 
+		span := tracing.StartStorageSpan(request.Context(), "PutBlobChunk", name)
 		clen, err = imgStore.PutBlobChunk(name, sessionID, from, to, request.Body)
+		span.End()
 	}
 
 	if err != nil { //nolint: dupl
@@ -1421,6 +1794,20 @@ func (rh *RouteHandler) PatchBlobUpload(response http.ResponseWriter, request *h
 			details["session_id"] = sessionID
 			e := apiErr.NewError(apiErr.BLOB_UPLOAD_INVALID).AddDetail(details)
 			zcommon.WriteJSON(response, http.StatusRequestedRangeNotSatisfiable, apiErr.NewErrorList(e))
+		} else if errors.Is(err, io.ErrUnexpectedEOF) {
+			// the connection dropped, what was received is kept so the client can get the upload status
+			// and resume from there
+			rh.c.Log.Warn().Err(err).Str("repository", name).Str("session_id", sessionID).
+				Msg("incomplete blob chunk, keeping the upload to be resumed")
+
+			if size, err := imgStore.GetBlobUpload(name, sessionID); err == nil {
+				response.Header().Set("Location", getBlobUploadSessionLocation(request.URL, sessionID))
+				response.Header().Set("Range", getUploadRange(size))
+			}
+
+			details["session_id"] = sessionID
+			e := apiErr.NewError(apiErr.BLOB_UPLOAD_INVALID).AddDetail(details)
+			zcommon.WriteJSON(response, http.StatusBadRequest, apiErr.NewErrorList(e))
 		} else if errors.Is(err, zerr.ErrRepoNotFound) {
 			details["name"] = name
 			e := apiErr.NewError(apiErr.NAME_UNKNOWN).AddDetail(details)
@@ -1443,8 +1830,14 @@ func (rh *RouteHandler) PatchBlobUpload(response http.ResponseWriter, request *h
 		return
 	}
 
+	// the range covers all the chunks received so far, not only this one
+	size, err := imgStore.GetBlobUpload(name, sessionID)
+	if err != nil {
+		size = clen
+	}
+
 	response.Header().Set("Location", getBlobUploadSessionLocation(request.URL, sessionID))
-	response.Header().Set("Range", fmt.Sprintf("0-%d", clen-1))
+	response.Header().Set("Range", getUploadRange(size))
 	response.Header().Set("Content-Length", "0")
 	response.Header().Set(constants.BlobUploadUUID, sessionID)
 	response.WriteHeader(http.StatusAccepted)
@@ -1518,6 +1911,10 @@ func (rh *RouteHandler) UpdateBlobUpload(response http.ResponseWriter, request *
 		return
 	}
 
+	if !rh.checkUploadQuota(response, request, imgStore, name, sessionID) {
+		return
+	}
+
 	var from, to int64
 
 	if contentPresent {
@@ -1536,7 +1933,10 @@ func (rh *RouteHandler) UpdateBlobUpload(response http.ResponseWriter, request *
 			return
 		}
 
+		span := tracing.StartStorageSpan(request.Context(), "PutBlobChunk", name)
 		_, err = imgStore.PutBlobChunk(name, sessionID, from, to, request.Body)
+		span.End()
+
 		if err != nil { //nolint:dupl
 			details := zerr.GetDetails(err)
 			if errors.Is(err, zerr.ErrBadUploadRange) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
@@ -1568,7 +1968,11 @@ func (rh *RouteHandler) UpdateBlobUpload(response http.ResponseWriter, request *
 
 finish:
 	// blob chunks already transferred, just finish
-	if err := imgStore.FinishBlobUpload(name, sessionID, request.Body, digest); err != nil {
+	span := tracing.StartStorageSpan(request.Context(), "FinishBlobUpload", name)
+	err = imgStore.FinishBlobUpload(name, sessionID, request.Body, digest)
+	span.End()
+
+	if err != nil {
 		details := zerr.GetDetails(err)
 		if errors.Is(err, zerr.ErrBadBlobDigest) { //nolint:gocritic // errorslint conflicts with gocritic:IfElseChain
 			details["digest"] = digest.String()
@@ -1674,31 +2078,12 @@ func (rh *RouteHandler) ListRepositories(response http.ResponseWriter, request *
 		return
 	}
 
-	combineRepoList := make([]string, 0)
-
-	subStore := rh.c.StoreController.SubStore
-
-	for _, imgStore := range subStore {
-		repos, err := imgStore.GetRepositories()
-		if err != nil {
-			response.WriteHeader(http.StatusInternalServerError)
-
-			return
-		}
-
-		combineRepoList = append(combineRepoList, repos...)
-	}
-
-	singleStore := rh.c.StoreController.DefaultStore
-	if singleStore != nil {
-		repos, err := singleStore.GetRepositories()
-		if err != nil {
-			response.WriteHeader(http.StatusInternalServerError)
-
-			return
-		}
+	combineRepoList, err := rh.getRepositories()
+	if err != nil {
+		rh.c.Log.Error().Err(err).Msg("failed to list repositories")
+		response.WriteHeader(http.StatusInternalServerError)
 
-		combineRepoList = append(combineRepoList, repos...)
+		return
 	}
 
 	repos := make([]string, 0)
@@ -1725,6 +2110,42 @@ func (rh *RouteHandler) ListRepositories(response http.ResponseWriter, request *
 	zcommon.WriteJSON(response, http.StatusOK, is)
 }
 
+// getRepositories lists the repositories of all the stores, from metaDB if the metadata index is enabled.
+func (rh *RouteHandler) getRepositories() ([]string, error) {
+	if rh.c.Config.Storage.MetadataIndex && rh.c.MetaDB != nil {
+		repos, err := rh.c.MetaDB.GetAllRepoNames()
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(repos)
+
+		return repos, nil
+	}
+
+	combineRepoList := make([]string, 0)
+
+	for _, imgStore := range rh.c.StoreController.SubStore {
+		repos, err := imgStore.GetRepositories()
+		if err != nil {
+			return nil, err
+		}
+
+		combineRepoList = append(combineRepoList, repos...)
+	}
+
+	if singleStore := rh.c.StoreController.DefaultStore; singleStore != nil {
+		repos, err := singleStore.GetRepositories()
+		if err != nil {
+			return nil, err
+		}
+
+		combineRepoList = append(combineRepoList, repos...)
+	}
+
+	return combineRepoList, nil
+}
+
 // ListExtensions godoc
 // @Summary List Registry level extensions
 // @Description List all extensions present on registry
@@ -1744,21 +2165,555 @@ func (rh *RouteHandler) ListExtensions(w http.ResponseWriter, r *http.Request) {
 
 // The following routes are specific to zot and NOT part of the OCI dist-spec
 
-// Logout godoc
-// @Summary Logout by removing current session
-// @Description Logout by removing current session
-// @Router  /zot/auth/logout [post]
+// RunGarbageCollect godoc
+// @Summary Run garbage collection
+// @Description Start garbage collection of every store with GC enabled now, without waiting for gcInterval
+// @Router  /v2/_zot/gc [post]
 // @Accept  json
 // @Produce json
-// @Success 200 {string} string "ok".
-// @Failure 500 {string} string "internal server error".
-func (rh *RouteHandler) Logout(response http.ResponseWriter, request *http.Request) {
-	if request.Method == http.MethodOptions {
+// @Success 202 {string} string "accepted".
+// @Failure 405 {string} string "method not allowed".
+func (rh *RouteHandler) RunGarbageCollect(response http.ResponseWriter, request *http.Request) {
+	if !rh.c.RunGarbageCollect() {
+		rh.c.Log.Info().Msg("garbage collection requested but GC is not enabled")
+		response.WriteHeader(http.StatusMethodNotAllowed)
+
 		return
 	}
 
-	session, _ := rh.c.CookieStore.Get(request, "session")
-	session.Options.MaxAge = -1
+	rh.c.Log.Info().Msg("garbage collection requested")
+	response.WriteHeader(http.StatusAccepted)
+}
+
+// mountBlob adds the blob of the from repository to the name repository, sparing the client from uploading it again.
+// The blob is hard linked if the dedupe cache knows about it, otherwise it's copied. The user has to be allowed to read
+// the from repository, without it only the blobs the dedupe cache knows about are mounted and only if there's no
+// authorization, as the repository they come from isn't known.
+func (rh *RouteHandler) mountBlob(request *http.Request, name, from string, digest godigest.Digest) bool {
+	if err := digest.Validate(); err != nil {
+		return false
+	}
+
+	imgStore := rh.getImageStore(name)
+
+	if from == "" {
+		if rh.c.accessControlConfig() != nil || rh.c.Config.IsBearerAuthEnabled() {
+			return false
+		}
+
+		_, _, err := imgStore.CheckBlob(name, digest)
+
+		return err == nil
+	}
+
+	if !zreg.FullNameRegexp.MatchString(from) {
+		return false
+	}
+
+	userAc, err := reqCtx.UserAcFromContext(request.Context())
+	if err != nil || (userAc != nil && !userAc.Can(constants.ReadPermission, from)) {
+		return false
+	}
+
+	srcStore := rh.getImageStore(from)
+
+	ok, size, _, err := srcStore.StatBlob(from, digest)
+	if err != nil || !ok {
+		return false
+	}
+
+	if quotas := rh.c.getQuotas(); quotas != nil {
+		if err := quotas.Check(name, size); err != nil {
+			rh.c.Log.Info().Err(err).Str("repository", name).Str("from", from).Msg("not mounting blob")
+
+			return false
+		}
+	}
+
+	// hard link through the dedupe cache, the blob may also be in the repository already
+	if srcStore == imgStore {
+		if _, _, err := imgStore.CheckBlob(name, digest); err == nil {
+			return true
+		}
+	}
+
+	blob, _, err := srcStore.GetBlob(from, digest, ispec.MediaTypeImageLayer)
+	if err != nil {
+		return false
+	}
+
+	defer blob.Close()
+
+	span := tracing.StartStorageSpan(request.Context(), "FullBlobUpload", name)
+	_, _, err = imgStore.FullBlobUpload(name, blob, digest)
+	span.End()
+
+	if err != nil {
+		rh.c.Log.Error().Err(err).Str("repository", name).Str("from", from).Str("digest", digest.String()).
+			Msg("failed to copy mounted blob")
+
+		return false
+	}
+
+	return true
+}
+
+// isTagImmutable tells if one of the immutable tags policies matches the tag of the repository.
+func (rh *RouteHandler) isTagImmutable(name, tag string) bool {
+	for _, policy := range rh.c.Config.Storage.ImmutableTags {
+		if zcommon.MatchesAnyGlob(policy.Repositories, name) && zcommon.MatchesAnyGlob(policy.Patterns, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// immutableTagOf returns the immutable tag a delete of the reference would remove: the reference itself
+// or, if it's a digest, one of the tags pointing to the manifest.
+func (rh *RouteHandler) immutableTagOf(imgStore storageTypes.ImageStore, name, reference string,
+	digest godigest.Digest,
+) (string, bool) {
+	if len(rh.c.Config.Storage.ImmutableTags) == 0 {
+		return "", false
+	}
+
+	if _, err := godigest.Parse(reference); err != nil {
+		return reference, rh.isTagImmutable(name, reference)
+	}
+
+	index, err := storageCommon.GetIndex(imgStore, name, rh.c.Log)
+	if err != nil {
+		return "", false
+	}
+
+	for _, desc := range index.Manifests {
+		if tag, ok := desc.Annotations[ispec.AnnotationRefName]; ok && desc.Digest == digest &&
+			rh.isTagImmutable(name, tag) {
+			return tag, true
+		}
+	}
+
+	return "", false
+}
+
+// isSupportedMediaType tells if the manifests of the media type can be pushed, the Docker ones only with the compat
+// config.
+func (rh *RouteHandler) isSupportedMediaType(mediaType string) bool {
+	if storageCommon.IsDockerMediaType(mediaType) {
+		return rh.c.Config.Compat != nil && rh.c.Config.Compat.DockerManifests
+	}
+
+	return storageCommon.IsSupportedMediaType(mediaType)
+}
+
+// hasForeignLayers tells if the Docker manifest references foreign layers while the compat config doesn't allow it.
+func (rh *RouteHandler) hasForeignLayers(mediaType string, body []byte) bool {
+	if mediaType != schema2.MediaTypeManifest || (rh.c.Config.Compat != nil && rh.c.Config.Compat.ForeignLayers) {
+		return false
+	}
+
+	var manifest ispec.Manifest
+
+	// invalid manifests are rejected by the storage
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return false
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == schema2.MediaTypeForeignLayer {
+			return true
+		}
+	}
+
+	return false
+}
+
+// acceptFallback returns the linux/amd64 manifest of the index the tag points to if the client doesn't accept
+// indexes, as the older docker and containerd clients expect, the manifest itself is returned otherwise.
+func (rh *RouteHandler) acceptFallback(request *http.Request, imgStore storageTypes.ImageStore, name, reference string,
+	content []byte, digest godigest.Digest, mediaType string,
+) ([]byte, godigest.Digest, string) {
+	if rh.c.Config.Compat == nil || !rh.c.Config.Compat.AcceptFallback ||
+		(mediaType != ispec.MediaTypeImageIndex && mediaType != manifestlist.MediaTypeManifestList) {
+		return content, digest, mediaType
+	}
+
+	// the manifests requested by digest are always returned as they are
+	if _, err := godigest.Parse(reference); err == nil {
+		return content, digest, mediaType
+	}
+
+	accepted := getAcceptedMediaTypes(request)
+	if len(accepted) == 0 || zcommon.Contains(accepted, "*/*") || zcommon.Contains(accepted, mediaType) {
+		return content, digest, mediaType
+	}
+
+	var index ispec.Index
+
+	if err := json.Unmarshal(content, &index); err != nil {
+		return content, digest, mediaType
+	}
+
+	for _, desc := range index.Manifests {
+		if desc.Platform == nil || desc.Platform.OS != "linux" || desc.Platform.Architecture != "amd64" ||
+			!zcommon.Contains(accepted, desc.MediaType) {
+			continue
+		}
+
+		manifestContent, manifestDigest, manifestMediaType, err := imgStore.GetImageManifest(name,
+			desc.Digest.String())
+		if err != nil {
+			rh.c.Log.Error().Err(err).Str("repository", name).Str("digest", desc.Digest.String()).
+				Msg("failed to get the manifest of the index accepted by the client")
+
+			break
+		}
+
+		rh.c.Log.Debug().Str("repository", name).Str("reference", reference).Str("digest", manifestDigest.String()).
+			Msg("index not accepted by the client, returning its linux/amd64 manifest")
+
+		return manifestContent, manifestDigest, manifestMediaType
+	}
+
+	return content, digest, mediaType
+}
+
+// getAcceptedMediaTypes returns the media types of the Accept headers without their parameters.
+func getAcceptedMediaTypes(request *http.Request) []string {
+	accepted := []string{}
+
+	for _, accept := range request.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+
+			if mediaType = strings.TrimSpace(mediaType); mediaType != "" {
+				accepted = append(accepted, mediaType)
+			}
+		}
+	}
+
+	return accepted
+}
+
+// writeNotTrusted writes the 403 error for the manifests the trust policies don't allow.
+func (rh *RouteHandler) writeNotTrusted(response http.ResponseWriter, name, reference string, err error) {
+	rh.c.Log.Info().Err(err).Str("repository", name).Str("reference", reference).Msg("denied by trust policy")
+
+	e := apiErr.NewError(apiErr.DENIED).AddDetail(map[string]string{
+		"name": name, "reference": reference, "reason": err.Error(),
+	})
+	zcommon.WriteJSON(response, http.StatusForbidden, apiErr.NewErrorList(e))
+}
+
+// checkQuota writes a 413 error and returns false if storing size more bytes in the repository
+// would exceed its quotas.
+func (rh *RouteHandler) checkQuota(response http.ResponseWriter, name string, size int64) bool {
+	quotas := rh.c.getQuotas()
+	if quotas == nil {
+		return true
+	}
+
+	err := quotas.Check(name, size)
+	if err == nil {
+		return true
+	}
+
+	if !errors.Is(err, zerr.ErrQuotaExceeded) {
+		rh.c.Log.Error().Err(err).Str("repository", name).Msg("failed to check the storage quotas")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return false
+	}
+
+	rh.c.Log.Info().Err(err).Str("repository", name).Int64("size", size).Msg("rejected blob upload")
+
+	e := apiErr.NewError(apiErr.QUOTA_EXCEEDED).AddDetail(map[string]string{"name": name, "reason": err.Error()})
+	zcommon.WriteJSON(response, http.StatusRequestEntityTooLarge, apiErr.NewErrorList(e))
+
+	return false
+}
+
+// checkUploadQuota checks the quotas with the bytes already uploaded in the session
+// and the ones sent in the request, if their length is known.
+func (rh *RouteHandler) checkUploadQuota(response http.ResponseWriter, request *http.Request,
+	imgStore storageTypes.ImageStore, name, sessionID string,
+) bool {
+	if rh.c.getQuotas() == nil {
+		return true
+	}
+
+	// the upload errors are reported by the handlers
+	size, err := imgStore.GetBlobUpload(name, sessionID)
+	if err != nil {
+		return true
+	}
+
+	if request.ContentLength > 0 {
+		size += request.ContentLength
+	}
+
+	return rh.checkQuota(response, name, size)
+}
+
+// GetQuotaUsage godoc
+// @Summary Get the storage usage
+// @Description Get the bytes stored in each repository along with the storage quotas
+// @Router  /v2/_zot/quota [get]
+// @Accept  json
+// @Produce json
+// @Success 200 {object} storage.QuotaUsage
+// @Failure 500 {string} string "internal server error".
+func (rh *RouteHandler) GetQuotaUsage(response http.ResponseWriter, request *http.Request) {
+	quotas := rh.c.getQuotas()
+	if quotas == nil {
+		quotas = storage.NewQuotas(nil, rh.c.StoreController)
+	}
+
+	usage, err := quotas.Usage()
+	if err != nil {
+		rh.c.Log.Error().Err(err).Msg("failed to compute the storage usage")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, usage)
+}
+
+type ReadOnlyStatus struct {
+	ReadOnly bool `json:"readOnly"`
+}
+
+// GetReadOnly godoc
+// @Summary Get the read-only mode
+// @Description Tell if the requests changing the registry are rejected
+// @Router  /v2/_zot/readonly [get]
+// @Accept  json
+// @Produce json
+// @Success 200 {object} api.ReadOnlyStatus
+func (rh *RouteHandler) GetReadOnly(response http.ResponseWriter, request *http.Request) {
+	zcommon.WriteJSON(response, http.StatusOK, ReadOnlyStatus{ReadOnly: rh.c.IsReadOnly()})
+}
+
+// SetReadOnly godoc
+// @Summary Switch the read-only mode
+// @Description Reject the requests changing the registry and stop the background tasks, or serve them again
+// @Router  /v2/_zot/readonly [put]
+// @Accept  json
+// @Produce json
+// @Param   status body api.ReadOnlyStatus true "read-only mode"
+// @Success 200 {object} api.ReadOnlyStatus
+// @Failure 400 {string} string "bad request".
+func (rh *RouteHandler) SetReadOnly(response http.ResponseWriter, request *http.Request) {
+	var status ReadOnlyStatus
+
+	if err := json.NewDecoder(request.Body).Decode(&status); err != nil {
+		rh.c.Log.Error().Err(err).Msg("failed to decode read-only mode")
+		response.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	rh.c.SetReadOnly(status.ReadOnly)
+
+	zcommon.WriteJSON(response, http.StatusOK, ReadOnlyStatus{ReadOnly: rh.c.IsReadOnly()})
+}
+
+// GetRepoStats godoc
+// @Summary List the repositories with their figures
+// @Description List every repository with the count of its tags, manifests, blobs and uploads in progress,
+// @Description and the size of its blobs
+// @Router  /v2/_zot/admin/repos [get]
+// @Accept  json
+// @Produce json
+// @Success 200 {array}  storage.RepoStats
+// @Failure 500 {string} string "internal server error".
+func (rh *RouteHandler) GetRepoStats(response http.ResponseWriter, request *http.Request) {
+	stats, err := storage.GetRepoStats(rh.c.StoreController)
+	if err != nil {
+		rh.c.Log.Error().Err(err).Msg("failed to compute the repository stats")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, stats)
+}
+
+// DeleteRepository godoc
+// @Summary Delete a repository
+// @Description Delete a repository with all its images and its uploads in progress
+// @Router  /v2/_zot/admin/repos/{name} [delete]
+// @Accept  json
+// @Produce json
+// @Param   name path string true "repository name"
+// @Success 202 {string} string "accepted"
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "internal server error".
+func (rh *RouteHandler) DeleteRepository(response http.ResponseWriter, request *http.Request) {
+	name := mux.Vars(request)["name"]
+	imgStore := rh.getImageStore(name)
+
+	// the manifests removed are notified as if they were deleted one by one
+	var index ispec.Index
+
+	if indexContent, err := imgStore.GetIndexContent(name); err == nil {
+		_ = json.Unmarshal(indexContent, &index)
+	}
+
+	if err := imgStore.DeleteRepository(name); err != nil {
+		if errors.Is(err, zerr.ErrRepoNotFound) || errors.Is(err, zerr.ErrInvalidRepositoryName) {
+			e := apiErr.NewError(apiErr.NAME_UNKNOWN).AddDetail(map[string]string{"name": name})
+			zcommon.WriteJSON(response, http.StatusNotFound, apiErr.NewErrorList(e))
+
+			return
+		}
+
+		rh.c.Log.Error().Err(err).Str("repository", name).Msg("failed to delete repository")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if rh.c.MetaDB != nil {
+		if err := rh.c.MetaDB.DeleteRepoMeta(name); err != nil {
+			rh.c.Log.Error().Err(err).Str("repository", name).Msg("failed to delete repository metadata")
+			response.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+	}
+
+	for _, desc := range index.Manifests {
+		reference := desc.Digest.String()
+		if tag, ok := desc.Annotations[ispec.AnnotationRefName]; ok {
+			reference = tag
+		}
+
+		rh.notifyEvent(request, events.Event{
+			Type: events.DeleteEvent, Repository: name, Reference: reference, Digest: desc.Digest.String(),
+			MediaType: desc.MediaType,
+		})
+	}
+
+	rh.c.Log.Info().Str("repository", name).Msg("repository deleted")
+	response.WriteHeader(http.StatusAccepted)
+}
+
+// GetBlobUploads godoc
+// @Summary List the uploads in progress
+// @Description List the upload sessions which weren't finished yet, by repository
+// @Router  /v2/_zot/admin/uploads [get]
+// @Accept  json
+// @Produce json
+// @Success 200 {array}  storage.RepoUploads
+// @Failure 500 {string} string "internal server error".
+func (rh *RouteHandler) GetBlobUploads(response http.ResponseWriter, request *http.Request) {
+	uploads, err := storage.GetRepoUploads(rh.c.StoreController)
+	if err != nil {
+		rh.c.Log.Error().Err(err).Msg("failed to list the blob uploads")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, uploads)
+}
+
+// RunAdminTask godoc
+// @Summary Run a background task
+// @Description Start a garbage collection, a scrub or a sync of the registries with content to sync now,
+// @Description without waiting for their interval
+// @Router  /v2/_zot/admin/tasks/{task} [post]
+// @Accept  json
+// @Produce json
+// @Param   task path string true "gc, scrub or sync"
+// @Success 202 {string} string "accepted"
+// @Failure 405 {string} string "method not allowed"
+// @Failure 500 {string} string "internal server error".
+func (rh *RouteHandler) RunAdminTask(response http.ResponseWriter, request *http.Request) {
+	task := mux.Vars(request)["task"]
+
+	var (
+		started bool
+		err     error
+	)
+
+	switch task {
+	case "gc":
+		started = rh.c.RunGarbageCollect()
+	case "scrub":
+		started = rh.c.RunScrub()
+	case "sync":
+		started, err = rh.c.RunSync()
+	}
+
+	if err != nil {
+		rh.c.Log.Error().Err(err).Str("task", task).Msg("failed to start task")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	if !started {
+		rh.c.Log.Info().Str("task", task).Msg("task requested but it is not enabled")
+		response.WriteHeader(http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	rh.c.Log.Info().Str("task", task).Msg("task requested")
+	response.WriteHeader(http.StatusAccepted)
+}
+
+// GetGarbageCollectReport godoc
+// @Summary Report what garbage collection would remove
+// @Description List the manifests and blobs a garbage collection would remove now, and the bytes it would free,
+// @Description without removing anything. For retention, only what the retention policies remove is listed
+// @Router  /v2/_zot/admin/{task}/dryrun [get]
+// @Accept  json
+// @Produce json
+// @Param   task path string true "gc or retention"
+// @Success 200 {object} gc.Report
+// @Failure 405 {string} string "method not allowed"
+// @Failure 500 {string} string "internal server error".
+func (rh *RouteHandler) GetGarbageCollectReport(response http.ResponseWriter, request *http.Request) {
+	task := mux.Vars(request)["task"]
+
+	report, enabled, err := rh.c.GarbageCollectReport(request.Context(), task == "retention")
+	if !enabled {
+		rh.c.Log.Info().Str("task", task).Msg("dry-run requested but GC is not enabled")
+		response.WriteHeader(http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if err != nil {
+		rh.c.Log.Error().Err(err).Str("task", task).Msg("failed to compute the dry-run report")
+		response.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	zcommon.WriteJSON(response, http.StatusOK, report)
+}
+
+// Logout godoc
+// @Summary Logout by removing current session
+// @Description Logout by removing current session
+// @Router  /zot/auth/logout [post]
+// @Accept  json
+// @Produce json
+// @Success 200 {string} string "ok".
+// @Failure 500 {string} string "internal server error".
+func (rh *RouteHandler) Logout(response http.ResponseWriter, request *http.Request) {
+	if request.Method == http.MethodOptions {
+		return
+	}
+
+	session, _ := rh.c.CookieStore.Get(request, "session")
+	session.Options.MaxAge = -1
 
 	err := session.Save(request, response)
 	if err != nil {
@@ -1806,7 +2761,12 @@ func (rh *RouteHandler) GithubCodeExchangeCallback() rp.CodeExchangeCallback {
 }
 
 // Openid CodeExchange callback.
-func (rh *RouteHandler) OpenIDCodeExchangeCallback() rp.CodeExchangeUserinfoCallback {
+func (rh *RouteHandler) OpenIDCodeExchangeCallback(provider string) rp.CodeExchangeUserinfoCallback {
+	groupsClaim := rh.c.Config.HTTP.Auth.OpenID.Providers[provider].GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+
 	return func(w http.ResponseWriter, r *http.Request, tokens *oidc.Tokens, state string,
 		relyingParty rp.RelyingParty, info oidc.UserInfo,
 	) {
@@ -1820,13 +2780,16 @@ func (rh *RouteHandler) OpenIDCodeExchangeCallback() rp.CodeExchangeUserinfoCall
 
 		var groups []string
 
-		val, ok := info.GetClaim("groups").([]interface{})
-		if !ok {
-			rh.c.Log.Info().Msgf("failed to find any 'groups' claim for user %s", email)
-		}
-
-		for _, group := range val {
-			groups = append(groups, fmt.Sprint(group))
+		// the providers send either a list of groups or a single one
+		switch val := info.GetClaim(groupsClaim).(type) {
+		case []interface{}:
+			for _, group := range val {
+				groups = append(groups, fmt.Sprint(group))
+			}
+		case string:
+			groups = append(groups, val)
+		default:
+			rh.c.Log.Info().Msgf("failed to find any '%s' claim for user %s", groupsClaim, email)
 		}
 
 		callbackUI, err := OAuth2Callback(rh.c, w, r, state, email, groups)
@@ -1898,11 +2861,79 @@ func (rh *RouteHandler) getImageStore(name string) storageTypes.ImageStore {
 	return rh.c.StoreController.GetImageStore(name)
 }
 
+// notifyEvent records the event in the activity log of the repository and sends it to the webhooks,
+// with the user who made the request as the actor.
+func (rh *RouteHandler) notifyEvent(request *http.Request, event events.Event) {
+	userAc, err := reqCtx.UserAcFromContext(request.Context())
+	if err == nil && userAc != nil {
+		event.Actor = userAc.GetUsername()
+	}
+
+	rh.recordActivity(event)
+
+	if rh.c.EventNotifier == nil || event.Type == events.PullEvent {
+		return
+	}
+
+	rh.c.EventNotifier.Notify(event)
+}
+
+// recordActivity queues the event to be added to the activity log of the repository kept in MetaDB, if enabled.
+func (rh *RouteHandler) recordActivity(event events.Event) {
+	if rh.c.activityRecorder == nil || !rh.c.Config.IsActivityEnabled() {
+		return
+	}
+
+	activityConfig := rh.c.Config.Extensions.Search.Activity
+
+	rh.c.activityRecorder.Record(event.Repository, mTypes.RepoEvent{
+		Type:           event.Type,
+		Reference:      event.Reference,
+		Digest:         event.Digest,
+		MediaType:      event.MediaType,
+		PreviousDigest: event.PreviousDigest,
+		Actor:          event.Actor,
+		Timestamp:      time.Now().UTC(),
+	}, activityConfig.MaxEvents, activityConfig.MaxAge)
+}
+
+// recompressPushedImage submits the task pushing a copy of the image with its gzip layers recompressed,
+// if recompression is enabled for the storage of the repository.
+func (rh *RouteHandler) recompressPushedImage(name, reference string) {
+	recompressionConfig := rh.c.Config.Storage.Recompression
+
+	if route, ok := rh.c.StoreController.GetSubStoreRoute(name); ok {
+		recompressionConfig = rh.c.Config.Storage.SubPaths[route].Recompression
+	}
+
+	taskScheduler := rh.c.getTaskScheduler()
+
+	if recompressionConfig == nil || taskScheduler == nil {
+		return
+	}
+
+	recompressor := recompression.NewRecompressor(rh.c.StoreController, rh.c.MetaDB, recompression.Options{
+		Format:    recompressionConfig.Format,
+		TagSuffix: recompressionConfig.TagSuffix,
+		Level:     recompressionConfig.Level,
+	}, rh.c.Log)
+
+	if !recompressor.IsRecompressed(reference) {
+		return
+	}
+
+	taskScheduler.SubmitTask(recompressor.NewTask(name, reference), scheduler.LowPriority)
+}
+
 // will sync on demand if an image is not found, in case sync extensions is enabled.
 func getImageManifest(ctx context.Context, routeHandler *RouteHandler, imgStore storageTypes.ImageStore, name,
 	reference string,
 ) ([]byte, godigest.Digest, string, error) {
-	syncEnabled := isSyncOnDemandEnabled(*routeHandler.c)
+	span := tracing.StartStorageSpan(ctx, "GetImageManifest", name)
+	defer span.End()
+
+	syncOnDemand := getSyncOnDemand(routeHandler.c)
+	syncEnabled := syncOnDemand != nil
 
 	_, digestErr := godigest.Parse(reference)
 	if digestErr == nil {
@@ -1917,7 +2948,7 @@ func getImageManifest(ctx context.Context, routeHandler *RouteHandler, imgStore
 		routeHandler.c.Log.Info().Str("repository", name).Str("reference", reference).
 			Msg("trying to get updated image by syncing on demand")
 
-		if errSync := routeHandler.c.SyncOnDemand.SyncImage(ctx, name, reference); errSync != nil {
+		if errSync := syncOnDemand.SyncImage(ctx, name, reference); errSync != nil {
 			routeHandler.c.Log.Err(errSync).Str("repository", name).Str("reference", reference).
 				Msg("failed to sync image")
 		}
@@ -1933,11 +2964,11 @@ func getOrasReferrers(ctx context.Context, routeHandler *RouteHandler,
 ) ([]artifactspec.Descriptor, error) {
 	refs, err := imgStore.GetOrasReferrers(name, digest, artifactType)
 	if err != nil {
-		if isSyncOnDemandEnabled(*routeHandler.c) {
+		if syncOnDemand := getSyncOnDemand(routeHandler.c); syncOnDemand != nil {
 			routeHandler.c.Log.Info().Str("repository", name).Str("reference", digest.String()).
 				Msg("artifact not found, trying to get artifact by syncing on demand")
 
-			if errSync := routeHandler.c.SyncOnDemand.SyncReference(ctx, name, digest.String(),
+			if errSync := syncOnDemand.SyncReference(ctx, name, digest.String(),
 				syncConstants.Oras); errSync != nil {
 				routeHandler.c.Log.Error().Err(err).Str("name", name).Str("digest", digest.String()).
 					Msg("failed to get references")
@@ -2206,6 +3237,15 @@ func (rh *RouteHandler) RevokeAPIKey(resp http.ResponseWriter, req *http.Request
 
 // GetBlobUploadSessionLocation returns actual blob location to start/resume uploading blobs.
 // e.g. /v2/<name>/blobs/uploads/<session-id>.
+// getUploadRange returns the Range header of an upload which received size bytes.
+func getUploadRange(size int64) string {
+	if size <= 0 {
+		return "0-0"
+	}
+
+	return fmt.Sprintf("0-%d", size-1)
+}
+
 func getBlobUploadSessionLocation(url *url.URL, sessionID string) string {
 	url.RawQuery = ""
 
@@ -2232,11 +3272,15 @@ func getBlobUploadLocation(url *url.URL, name string, digest godigest.Digest) st
 	return url.String()
 }
 
-func isSyncOnDemandEnabled(ctlr Controller) bool {
-	if ctlr.Config.IsSyncEnabled() &&
-		fmt.Sprintf("%v", ctlr.SyncOnDemand) != fmt.Sprintf("%v", nil) {
-		return true
+// getSyncOnDemand returns the syncing of the images missing from the storage if it's enabled, never in read-only
+// mode, nil otherwise.
+func getSyncOnDemand(ctlr *Controller) SyncOnDemand {
+	syncOnDemand := ctlr.getSyncOnDemand()
+
+	if ctlr.Config.IsSyncEnabled() && !ctlr.IsReadOnly() &&
+		fmt.Sprintf("%v", syncOnDemand) != fmt.Sprintf("%v", nil) {
+		return syncOnDemand
 	}
 
-	return false
+	return nil
 }
@@ -5,15 +5,26 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/didip/tollbooth/v6"
+	"github.com/didip/tollbooth/v6/libstring"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
 
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/constants"
+	apiErr "zotregistry.dev/zot/pkg/api/errors"
+	zcommon "zotregistry.dev/zot/pkg/common"
 	"zotregistry.dev/zot/pkg/extensions/monitoring"
 	"zotregistry.dev/zot/pkg/log"
+	reqCtx "zotregistry.dev/zot/pkg/requestcontext"
 )
 
+const maxRequestIDLength = 128
+
 type statusWriter struct {
 	http.ResponseWriter
 	status int
@@ -43,7 +54,7 @@ func RateLimiter(ctlr *Controller, rate int) mux.MiddlewareFunc {
 	limiter := tollbooth.NewLimiter(float64(rate), nil)
 	limiter.SetMessage(http.StatusText(http.StatusTooManyRequests)).
 		SetStatusCode(http.StatusTooManyRequests).
-		SetOnLimitReached(nil)
+		SetOnLimitReached(setRetryAfter)
 
 	return func(next http.Handler) http.Handler {
 		return tollbooth.LimitHandler(limiter, next)
@@ -58,19 +69,121 @@ func MethodRateLimiter(ctlr *Controller, method string, rate int) mux.Middleware
 	limiter.SetMethods([]string{method}).
 		SetMessage(http.StatusText(http.StatusTooManyRequests)).
 		SetStatusCode(http.StatusTooManyRequests).
-		SetOnLimitReached(nil)
+		SetOnLimitReached(setRetryAfter)
 
 	return func(next http.Handler) http.Handler {
 		return tollbooth.LimitHandler(limiter, next)
 	}
 }
 
+// UserRateLimiter limits the requests of each authenticated user, wherever they come from,
+// so it has to run after the authentication. The anonymous requests are only limited by IP address.
+func UserRateLimiter(ctlr *Controller, rate int) mux.MiddlewareFunc {
+	ctlr.Log.Info().Int("rate", rate).Msg("per-user ratelimiter enabled")
+
+	limiter := tollbooth.NewLimiter(float64(rate), nil)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			userAc, err := reqCtx.UserAcFromContext(request.Context())
+			if err == nil && userAc != nil && userAc.GetUsername() != "" {
+				if httpErr := tollbooth.LimitByKeys(limiter, []string{userAc.GetUsername()}); httpErr != nil {
+					writeTooManyRequests(response, request)
+
+					return
+				}
+			}
+
+			next.ServeHTTP(response, request)
+		})
+	}
+}
+
+// UploadConcurrencyLimiter limits the number of blob uploads served at the same time for each IP address,
+// the uploads being the longest requests.
+func UploadConcurrencyLimiter(ctlr *Controller, maxUploads int) mux.MiddlewareFunc {
+	ctlr.Log.Info().Int("maxUploads", maxUploads).Msg("per-IP concurrent uploads limiter enabled")
+
+	// same lookups as the rate limiters
+	ipLookups := tollbooth.NewLimiter(1, nil).GetIPLookups()
+
+	var lock sync.Mutex
+
+	uploads := map[string]int{}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			if !isBlobUploadRequest(request) {
+				next.ServeHTTP(response, request)
+
+				return
+			}
+
+			remoteIP := libstring.RemoteIP(ipLookups, 0, request)
+
+			lock.Lock()
+
+			if uploads[remoteIP] >= maxUploads {
+				lock.Unlock()
+
+				writeTooManyRequests(response, request)
+
+				return
+			}
+
+			uploads[remoteIP]++
+
+			lock.Unlock()
+
+			defer func() {
+				lock.Lock()
+				defer lock.Unlock()
+
+				uploads[remoteIP]--
+
+				if uploads[remoteIP] == 0 {
+					delete(uploads, remoteIP)
+				}
+			}()
+
+			next.ServeHTTP(response, request)
+		})
+	}
+}
+
+// setRetryAfter tells the clients over the limit to try again in a second, as the limits are per second.
+func setRetryAfter(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Retry-After", "1")
+}
+
+func writeTooManyRequests(response http.ResponseWriter, request *http.Request) {
+	setRetryAfter(response, request)
+	http.Error(response, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
+
 // SessionLogger logs session details.
+// The logs written while serving the request carry its ID, given by the client or generated,
+// so the access log and the application logs of a request can be matched.
 func SessionLogger(ctlr *Controller) mux.MiddlewareFunc {
-	logger := ctlr.Log.With().Str("module", "http").Logger()
+	logger := ctlr.Log.Module("http").With().Str("module", "http").Logger()
+
+	// only some of the successful pulls are logged, they're most of the requests
+	pullLogger := logger
+	if sampling := ctlr.Config.Log.Sampling; sampling != nil && sampling.Pulls > 1 {
+		pullLogger = logger.Sample(&zerolog.BasicSampler{N: sampling.Pulls})
+	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			requestID := request.Header.Get(constants.RequestIDHeader)
+			if !isValidRequestID(requestID) {
+				requestID = uuid.NewString()
+			}
+
+			response.Header().Set(constants.RequestIDHeader, requestID)
+
+			defer log.SetRequestID(requestID)()
+
 			// Start timer
 			start := time.Now()
 			path := request.URL.Path
@@ -78,6 +191,11 @@ func SessionLogger(ctlr *Controller) mux.MiddlewareFunc {
 
 			stwr := statusWriter{ResponseWriter: response}
 
+			if isBlobUploadRequest(request) {
+				monitoring.IncUploadsInFlight(ctlr.Metrics)
+				defer monitoring.DecUploadsInFlight(ctlr.Metrics)
+			}
+
 			// Process request
 			next.ServeHTTP(&stwr, request)
 
@@ -89,7 +207,21 @@ func SessionLogger(ctlr *Controller) mux.MiddlewareFunc {
 			clientIP := request.RemoteAddr
 			method := request.Method
 			headers := map[string][]string{}
+			statusCode := stwr.status
+			bodySize := stwr.length
+
 			log := logger.Info()
+			if (method == http.MethodGet || method == http.MethodHead) && statusCode < http.StatusBadRequest {
+				log = pullLogger.Info()
+			}
+
+			if log == nil {
+				// not sampled
+				monitorRequest(ctlr, method, path, statusCode, latency)
+
+				return
+			}
+
 			for key, value := range request.Header {
 				if key == "Authorization" { // anonymize from logs
 					s := strings.SplitN(value[0], " ", 2) //nolint:gomnd
@@ -107,20 +239,13 @@ func SessionLogger(ctlr *Controller) mux.MiddlewareFunc {
 				}
 				headers[key] = value
 			}
-			statusCode := stwr.status
-			bodySize := stwr.length
+
+			monitorRequest(ctlr, method, path, statusCode, latency)
+
 			if raw != "" {
 				path = path + "?" + raw
 			}
 
-			if path != "/metrics" {
-				// In order to test metrics feture,the instrumentation related to node exporter
-				// should be handled by node exporter itself (ex: latency)
-				monitoring.IncHTTPConnRequests(ctlr.Metrics, method, strconv.Itoa(statusCode))
-				monitoring.ObserveHTTPRepoLatency(ctlr.Metrics, path, latency)     // summary
-				monitoring.ObserveHTTPMethodLatency(ctlr.Metrics, method, latency) // histogram
-			}
-
 			log.Str("component", "session").
 				Str("clientIP", clientIP).
 				Str("method", method).
@@ -134,6 +259,78 @@ func SessionLogger(ctlr *Controller) mux.MiddlewareFunc {
 	}
 }
 
+func monitorRequest(ctlr *Controller, method, path string, statusCode int, latency time.Duration) {
+	if path != "/metrics" {
+		// In order to test metrics feture,the instrumentation related to node exporter
+		// should be handled by node exporter itself (ex: latency)
+		monitoring.IncHTTPConnRequests(ctlr.Metrics, method, strconv.Itoa(statusCode))
+		monitoring.ObserveHTTPRepoLatency(ctlr.Metrics, path, latency)     // summary
+		monitoring.ObserveHTTPMethodLatency(ctlr.Metrics, method, latency) // histogram
+	}
+}
+
+// isValidRequestID accepts the request IDs given by the clients or the proxies if they're short and can't
+// mess up the logs.
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, char := range requestID {
+		if !(char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9' ||
+			char == '-' || char == '_' || char == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ReadOnlyHandler rejects the requests changing the registry while it's in read-only mode,
+// except the ones switching the mode and the search queries.
+func ReadOnlyHandler(ctlr *Controller) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			if !ctlr.IsReadOnly() || !isMutatingRequest(request) ||
+				request.URL.Path == constants.RoutePrefix+constants.ReadOnlyPath ||
+				strings.HasPrefix(request.URL.Path, constants.FullSearchPrefix) {
+				next.ServeHTTP(response, request)
+
+				return
+			}
+
+			ctlr.Log.Info().Err(zerr.ErrReadOnly).Str("method", request.Method).Str("path", request.URL.Path).
+				Msg("rejected request in read-only mode")
+
+			zcommon.WriteJSON(response, http.StatusServiceUnavailable,
+				apiErr.NewErrorList(apiErr.NewError(apiErr.UNAVAILABLE)))
+		})
+	}
+}
+
+func isMutatingRequest(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isBlobUploadRequest returns true for the requests sending the content of a blob upload.
+func isBlobUploadRequest(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodPost, http.MethodPatch, http.MethodPut:
+	default:
+		return false
+	}
+
+	return strings.HasPrefix(request.URL.Path, constants.RoutePrefix+"/") &&
+		strings.Contains(request.URL.Path, "/blobs/uploads")
+}
+
+// SessionAuditLogger writes an audit record for the requests changing the registry, whatever their result,
+// and for the requests denied because of the credentials or the permissions of the user.
 func SessionAuditLogger(audit *log.Logger) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
@@ -142,17 +339,24 @@ func SessionAuditLogger(audit *log.Logger) mux.MiddlewareFunc {
 
 			statusWr := statusWriter{ResponseWriter: response}
 
+			// the authn middleware fills in the user once it's authenticated
+			ctx, record := reqCtx.WithAuditRecord(request.Context())
+			request = request.WithContext(ctx)
+
 			// Process request
 			next.ServeHTTP(&statusWr, request)
 
 			clientIP := request.RemoteAddr
 			method := request.Method
-			username := ""
+			username := record.Username
+			credentials := false
 
 			for key, value := range request.Header {
 				if key == "Authorization" { // anonymize from logs
+					credentials = true
+
 					s := strings.SplitN(value[0], " ", 2) //nolint:gomnd
-					if len(s) == 2 && strings.EqualFold(s[0], "basic") {
+					if len(s) == 2 && strings.EqualFold(s[0], "basic") && username == "" {
 						b, err := base64.StdEncoding.DecodeString(s[1])
 						if err == nil {
 							pair := strings.SplitN(string(b), ":", 2) //nolint:gomnd
@@ -165,22 +369,51 @@ func SessionAuditLogger(audit *log.Logger) mux.MiddlewareFunc {
 			}
 
 			statusCode := statusWr.status
+			if statusCode == 0 {
+				statusCode = http.StatusOK
+			}
+
 			if raw != "" {
 				path = path + "?" + raw
 			}
 
-			if (method == http.MethodPost || method == http.MethodPut ||
-				method == http.MethodPatch || method == http.MethodDelete) &&
-				(statusCode == http.StatusOK || statusCode == http.StatusCreated || statusCode == http.StatusAccepted) {
-				audit.Info().
-					Str("component", "session").
-					Str("clientIP", clientIP).
-					Str("subject", username).
-					Str("action", method).
-					Str("object", path).
-					Int("status", statusCode).
-					Msg("HTTP API Audit")
+			mutating := method == http.MethodPost || method == http.MethodPut ||
+				method == http.MethodPatch || method == http.MethodDelete
+			// anonymous requests answered with 401 are only the clients asking how to authenticate
+			denied := statusCode == http.StatusForbidden || (statusCode == http.StatusUnauthorized && credentials)
+
+			if !mutating && !denied {
+				return
 			}
+
+			result := "success"
+			if statusCode >= http.StatusBadRequest {
+				result = "failure"
+			}
+
+			vars := mux.Vars(request)
+
+			digest := vars["digest"]
+			if digest == "" {
+				digest = request.URL.Query().Get("digest")
+			}
+
+			if digest == "" {
+				digest = statusWr.Header().Get(constants.DistContentDigestKey)
+			}
+
+			audit.Info().
+				Str("component", "session").
+				Str("clientIP", clientIP).
+				Str("subject", username).
+				Str("action", method).
+				Str("object", path).
+				Str("repository", vars["name"]).
+				Str("reference", vars["reference"]).
+				Str("digest", digest).
+				Str("result", result).
+				Int("status", statusCode).
+				Msg("HTTP API Audit")
 		})
 	}
 }
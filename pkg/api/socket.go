@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+
+	zerr "zotregistry.dev/zot/errors"
+)
+
+// only the user and the group of the server can connect to its socket.
+const socketPerms = 0o660
+
+// listenUnixSocket listens on the unix socket of the config, replacing the one left by a server which
+// didn't stop cleanly.
+func (c *Controller) listenUnixSocket() (net.Listener, error) {
+	socket := c.Config.HTTP.Socket
+
+	if info, err := os.Lstat(socket); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			c.Log.Error().Str("socket", socket).Msg("failed to listen on the unix socket, the file isn't a socket")
+
+			return nil, fmt.Errorf("%w: %s isn't a unix socket", zerr.ErrBadConfig, socket)
+		}
+
+		if conn, err := net.Dial("unix", socket); err == nil {
+			_ = conn.Close()
+
+			c.Log.Error().Str("socket", socket).Msg("failed to listen on the unix socket, another server uses it")
+
+			return nil, fmt.Errorf("%w: %s", zerr.ErrServerIsRunning, socket)
+		}
+
+		if err := os.Remove(socket); err != nil {
+			c.Log.Error().Err(err).Str("socket", socket).Msg("failed to remove the stale unix socket")
+
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		c.Log.Error().Err(err).Str("socket", socket).Msg("failed to listen on the unix socket")
+
+		return nil, err
+	}
+
+	if err := os.Chmod(socket, socketPerms); err != nil {
+		c.Log.Error().Err(err).Str("socket", socket).Msg("failed to change the permissions of the unix socket")
+
+		_ = listener.Close()
+
+		return nil, err
+	}
+
+	c.Log.Info().Str("socket", socket).Msg("listening on the unix socket")
+
+	return listener, nil
+}
+
+// serveUnixSocket serves the requests of the socket along with the ones of the port. The socket isn't
+// served with TLS, its permissions restrict who can reach it.
+func (c *Controller) serveUnixSocket(listener net.Listener) {
+	if err := c.Server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		c.Log.Error().Err(err).Str("socket", c.Config.HTTP.Socket).Msg("failed to serve the unix socket")
+	}
+}
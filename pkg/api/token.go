@@ -0,0 +1,181 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chartmuseum/auth"
+	"github.com/golang-jwt/jwt"
+	"github.com/gorilla/mux"
+
+	"zotregistry.dev/zot/pkg/api/constants"
+	apiErr "zotregistry.dev/zot/pkg/api/errors"
+	zcommon "zotregistry.dev/zot/pkg/common"
+	reqCtx "zotregistry.dev/zot/pkg/requestcontext"
+)
+
+const (
+	defaultTokenExpiration = 5 * time.Minute
+	deleteAction           = "delete"
+	allActions             = "*"
+)
+
+type TokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"` //nolint:tagliatelle // token format
+	ExpiresIn   int    `json:"expires_in"`   //nolint:tagliatelle // token format
+	IssuedAt    string `json:"issued_at"`    //nolint:tagliatelle // token format
+}
+
+// tokenActions maps the token actions to the access control permissions they require.
+var tokenActions = map[string]string{ //nolint: gochecknoglobals
+	auth.PullAction: constants.ReadPermission,
+	auth.PushAction: constants.CreatePermission,
+	deleteAction:    constants.DeletePermission,
+}
+
+// TokenHandler issues the tokens of the built-in token server, implementing the docker token authentication.
+// The users are authenticated by the basic authn middleware and the token grants the requested actions
+// their permissions allow.
+func TokenHandler(ctlr *Controller) http.HandlerFunc {
+	bearerConfig := ctlr.Config.HTTP.Auth.Bearer
+
+	expiration := bearerConfig.TokenServer.Expiration
+	if expiration == 0 {
+		expiration = defaultTokenExpiration
+	}
+
+	tokenGenerator, err := auth.NewTokenGenerator(&auth.TokenGeneratorOptions{
+		PrivateKeyPath: bearerConfig.TokenServer.Key,
+		Audience:       bearerConfig.Service,
+		Issuer:         bearerConfig.Realm,
+		AddKIDHeader:   true,
+	})
+	if err != nil {
+		ctlr.Log.Panic().Err(err).Str("key", bearerConfig.TokenServer.Key).Msg("failed to create token generator")
+	}
+
+	return func(response http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+
+		if service := query.Get("service"); service != "" && service != bearerConfig.Service {
+			zcommon.WriteJSON(response, http.StatusBadRequest,
+				apiErr.NewErrorList(apiErr.NewError(apiErr.UNSUPPORTED).AddDetail(map[string]string{"service": service})))
+
+			return
+		}
+
+		userAc, err := reqCtx.UserAcFromContext(request.Context())
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		access := []auth.AccessEntry{}
+
+		for _, scope := range query["scope"] {
+			for _, scope := range strings.Fields(scope) {
+				entry, ok := parseScope(scope)
+				if !ok {
+					continue
+				}
+
+				entry.Actions = grantedActions(ctlr, userAc, entry.Name, entry.Actions)
+
+				access = append(access, entry)
+			}
+		}
+
+		issuedAt := time.Now()
+
+		token, err := generateToken(tokenGenerator, userAc.GetUsername(), access, issuedAt, expiration)
+		if err != nil {
+			ctlr.Log.Error().Err(err).Msg("failed to generate token")
+			response.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		ctlr.Log.Info().Str("identity", userAc.GetUsername()).Interface("access", access).Msg("issued token")
+
+		zcommon.WriteJSON(response, http.StatusOK, TokenResponse{
+			Token:       token,
+			AccessToken: token,
+			ExpiresIn:   int(expiration.Seconds()),
+			IssuedAt:    issuedAt.UTC().Format(time.RFC3339),
+		})
+	}
+}
+
+// generateToken signs the token like the chartmuseum token generator, with the user as the subject so the
+// bearer authn middleware knows who the user is.
+func generateToken(tokenGenerator *auth.TokenGenerator, username string, access []auth.AccessEntry,
+	issuedAt time.Time, expiration time.Duration,
+) (string, error) {
+	token := jwt.New(jwt.SigningMethodRS256)
+	token.Header["kid"] = tokenGenerator.KID
+	token.Claims = &auth.Claims{
+		StandardClaims: &jwt.StandardClaims{
+			Subject:   username,
+			IssuedAt:  issuedAt.Unix(),
+			ExpiresAt: issuedAt.Add(expiration).Unix(),
+		},
+		Access:   access,
+		Audience: tokenGenerator.Audience,
+		Issuer:   tokenGenerator.Issuer,
+	}
+
+	return token.SignedString(tokenGenerator.PrivateKey)
+}
+
+// parseScope parses a "repository:<name>:<actions>" scope, the name can contain colons, e.g. a registry port.
+func parseScope(scope string) (auth.AccessEntry, bool) {
+	first := strings.Index(scope, ":")
+	last := strings.LastIndex(scope, ":")
+
+	if first == -1 || first == last || scope[:first] != bearerAuthDefaultAccessEntryType {
+		return auth.AccessEntry{}, false
+	}
+
+	return auth.AccessEntry{
+		Type:    scope[:first],
+		Name:    scope[first+1 : last],
+		Actions: strings.Split(scope[last+1:], ","),
+	}, true
+}
+
+// grantedActions returns the requested actions the user is allowed to do on the repository.
+func grantedActions(ctlr *Controller, userAc *reqCtx.UserAccessControl, repo string, requested []string) []string {
+	if len(requested) == 1 && requested[0] == allActions {
+		requested = []string{auth.PullAction, auth.PushAction, deleteAction}
+	}
+
+	accessControl := ctlr.accessControlConfig()
+	acCtrlr := ctlr.accessController()
+	granted := []string{}
+
+	for _, action := range requested {
+		permission, ok := tokenActions[action]
+		if !ok || zcommon.Contains(granted, action) {
+			continue
+		}
+
+		// without access control the authenticated users can do anything, like with basic auth
+		if (accessControl == nil && !userAc.IsAnonymous()) ||
+			acCtrlr.can(userAc, permission, repo) {
+			granted = append(granted, action)
+		}
+	}
+
+	return granted
+}
+
+// tokenAuthnHandler authenticates the users asking for a token with their credentials,
+// the bearer authn middleware only accepting tokens.
+func tokenAuthnHandler(ctlr *Controller) mux.MiddlewareFunc {
+	authnMiddleware := &AuthnMiddleware{log: ctlr.Log}
+
+	return authnMiddleware.tryAuthnHandlers(ctlr)
+}
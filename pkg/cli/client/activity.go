@@ -0,0 +1,111 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v2"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/common"
+)
+
+// repoActivityStruct is the activity log of a repository, the most recent event first.
+type repoActivityStruct struct {
+	RepoName string             `json:"repoName"`
+	Events   []common.RepoEvent `json:"events"`
+}
+
+func (activity repoActivityStruct) string(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return activity.stringPlainText()
+	case jsonFormat:
+		return activity.stringJSON()
+	case ndjsonFormat:
+		return activity.stringNDJSON()
+	case ymlFormat, yamlFormat:
+		return activity.stringYAML()
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (activity repoActivityStruct) stringPlainText() (string, error) {
+	var builder strings.Builder
+
+	writer := tabwriter.NewWriter(&builder, 0, 8, 2, ' ', 0) //nolint:gomnd
+
+	fmt.Fprintln(writer, "TIME\tTYPE\tREFERENCE\tDIGEST\tACTOR")
+
+	for _, event := range activity.Events {
+		// a tag event shows the manifest the tag was moved from
+		digest := getShortDigest(event.Digest)
+		if event.PreviousDigest != "" {
+			digest = getShortDigest(event.PreviousDigest) + " -> " + digest
+		}
+
+		reference := event.Reference
+		if reference == event.Digest {
+			reference = getShortDigest(reference)
+		}
+
+		actor := event.Actor
+		if actor == "" {
+			actor = "-"
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", getCreatedStr(event.Timestamp), event.Type, reference,
+			digest, actor)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+func (activity repoActivityStruct) stringJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.MarshalIndent(activity, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+// stringNDJSON writes one event per line.
+func (activity repoActivityStruct) stringNDJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	var builder strings.Builder
+
+	for _, event := range activity.Events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return "", err
+		}
+
+		builder.Write(body)
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
+
+func (activity repoActivityStruct) stringYAML() (string, error) {
+	body, err := yaml.Marshal(activity)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(body), nil
+}
@@ -0,0 +1,286 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/constants"
+)
+
+func NewAPIKeyCommand() *cobra.Command {
+	apiKeyCmd := &cobra.Command{
+		Use:   "apikey [command]",
+		Short: "Manage the API keys of the user",
+		Long: `Manage the API keys of the user. API keys are used instead of the password of the user,
+e.g. by CI systems, and can be revoked without changing the password. The server must have http.auth.apikey enabled.`,
+		RunE: ShowSuggestionsIfUnknownCommand,
+	}
+
+	apiKeyCmd.SetUsageTemplate(apiKeyCmd.UsageTemplate() + usageFooter)
+
+	apiKeyCmd.PersistentFlags().String(URLFlag, "",
+		"Specify zot server URL if config-name is not mentioned")
+	apiKeyCmd.PersistentFlags().String(ConfigFlag, "",
+		"Specify the registry configuration to use for connection")
+	_ = apiKeyCmd.RegisterFlagCompletionFunc(ConfigFlag, completeConfigNames)
+	apiKeyCmd.PersistentFlags().StringP(UserFlag, "u", "",
+		`User Credentials of zot server in "username:password" format`)
+	apiKeyCmd.PersistentFlags().StringP(OutputFormatFlag, "f", "", "Specify output format [text/json/yaml]")
+
+	apiKeyCmd.AddCommand(NewAPIKeyCreateCommand())
+	apiKeyCmd.AddCommand(NewAPIKeyListCommand())
+	apiKeyCmd.AddCommand(NewAPIKeyRevokeCommand())
+
+	return apiKeyCmd
+}
+
+func NewAPIKeyCreateCommand() *cobra.Command {
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create an API key",
+		Long: `Create an API key for the user. The key is only shown once, it's stored hashed on the server.
+It's used as the password of the user, e.g. 'docker login -u <user> -p <key>'.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, NewSearchService())
+			if err != nil {
+				return err
+			}
+
+			flags := cmd.Flags()
+			payload := apiKeyPayload{
+				Label:  defaultIfError(flags.GetString(LabelFlag)),
+				Scopes: defaultIfError(flags.GetStringSlice(ScopeFlag)),
+			}
+
+			expiresIn := defaultIfError(flags.GetDuration(ExpiresInFlag))
+			if expiresIn < 0 {
+				return fmt.Errorf("%w: --%s can't be negative", zerr.ErrInvalidCLIParameter, ExpiresInFlag)
+			}
+
+			if expiresIn > 0 {
+				payload.ExpirationDate = time.Now().Add(expiresIn).Format(constants.APIKeyTimeFormat)
+			}
+
+			return CreateAPIKey(cmd.Context(), searchConfig, payload)
+		},
+	}
+
+	createCmd.Flags().String(LabelFlag, "", "Label of the key, to tell the keys apart")
+	createCmd.Flags().StringSlice(ScopeFlag, nil, "Scope of the key, can be repeated")
+	createCmd.Flags().Duration(ExpiresInFlag, 0, "Time after which the key expires, e.g. 720h, 0 means never")
+
+	return createCmd
+}
+
+func NewAPIKeyListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the API keys",
+		Long:  `List the API keys of the user, with when they were last used and when they expire`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, NewSearchService())
+			if err != nil {
+				return err
+			}
+
+			return ListAPIKeys(cmd.Context(), searchConfig)
+		},
+	}
+}
+
+func NewAPIKeyRevokeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke [id]",
+		Short: "Revoke an API key",
+		Long:  `Revoke an API key of the user, given the id shown when listing the keys`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, NewSearchService())
+			if err != nil {
+				return err
+			}
+
+			return RevokeAPIKey(cmd.Context(), searchConfig, args[0])
+		},
+	}
+}
+
+type apiKeyPayload struct {
+	Label          string   `json:"label"`
+	Scopes         []string `json:"scopes"`
+	ExpirationDate string   `json:"expirationDate"`
+}
+
+type apiKeyInfo struct {
+	UUID           string    `json:"uuid"             yaml:"uuid"`
+	Label          string    `json:"label"            yaml:"label"`
+	Scopes         []string  `json:"scopes"           yaml:"scopes"`
+	CreatedAt      time.Time `json:"createdAt"        yaml:"createdAt"`
+	ExpirationDate time.Time `json:"expirationDate"   yaml:"expirationDate"`
+	IsExpired      bool      `json:"isExpired"        yaml:"isExpired"`
+	LastUsed       time.Time `json:"lastUsed"         yaml:"lastUsed"`
+	APIKey         string    `json:"apiKey,omitempty" yaml:"apiKey,omitempty"`
+}
+
+type apiKeyList struct {
+	APIKeys []apiKeyInfo `json:"apiKeys" yaml:"apiKeys"`
+}
+
+func CreateAPIKey(ctx context.Context, config SearchConfig, payload apiKeyPayload) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	endpoint, err := combineServerAndEndpointURL(config.ServURL, constants.APIKeyPath)
+	if err != nil {
+		return err
+	}
+
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	apiKey := apiKeyInfo{}
+
+	_, err = makePOSTRequest(ctx, endpoint, username, password, config, body, &apiKey)
+	if err != nil {
+		return getAPIKeyError(err)
+	}
+
+	return printAPIKeys(config, apiKey, []apiKeyInfo{apiKey})
+}
+
+func ListAPIKeys(ctx context.Context, config SearchConfig) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	endpoint, err := combineServerAndEndpointURL(config.ServURL, constants.APIKeyPath)
+	if err != nil {
+		return err
+	}
+
+	apiKeys := apiKeyList{}
+
+	_, err = makeGETRequest(ctx, endpoint, username, password, config, &apiKeys)
+	if err != nil {
+		return getAPIKeyError(err)
+	}
+
+	return printAPIKeys(config, apiKeys, apiKeys.APIKeys)
+}
+
+func RevokeAPIKey(ctx context.Context, config SearchConfig, keyID string) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	endpoint, err := combineServerAndEndpointURL(config.ServURL, constants.APIKeyPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = makeDELETERequest(ctx, endpoint+"?id="+url.QueryEscape(keyID), username, password, config)
+	if err != nil {
+		return getAPIKeyError(err)
+	}
+
+	fmt.Fprintf(config.ResultWriter, "API key %s revoked\n", keyID)
+
+	return nil
+}
+
+// getAPIKeyError explains the errors of the server which doesn't have the API keys enabled.
+func getAPIKeyError(err error) error {
+	if errors.Is(err, zerr.ErrURLNotFound) {
+		return fmt.Errorf("%w: the server doesn't have http.auth.apikey enabled", zerr.ErrAPINotSupported)
+	}
+
+	return err
+}
+
+// printAPIKeys prints the result as it is for the json and yaml formats and the keys as a table for text.
+func printAPIKeys(config SearchConfig, result interface{}, apiKeys []apiKeyInfo) error {
+	var (
+		output []byte
+		err    error
+	)
+
+	switch strings.ToLower(config.OutputFormat) {
+	case "", defaultOutputFormat:
+		output = []byte(apiKeysTable(apiKeys))
+	case jsonFormat:
+		json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+		output, err = json.MarshalIndent(result, "", "  ")
+		output = append(output, '\n')
+	case ymlFormat, yamlFormat:
+		output, err = yaml.Marshal(result)
+		output = append([]byte("---\n"), output...)
+	default:
+		return zerr.ErrInvalidOutputFormat
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(config.ResultWriter, string(output))
+
+	return nil
+}
+
+func apiKeysTable(apiKeys []apiKeyInfo) string {
+	var builder strings.Builder
+
+	table := getImageTableWriter(&builder)
+
+	header := []string{"ID", "LABEL", "SCOPES", "CREATED", "EXPIRES", "LAST USED"}
+
+	// the key itself is only known right after it's created
+	showKey := len(apiKeys) > 0 && apiKeys[0].APIKey != ""
+	if showKey {
+		header = append(header, "API KEY")
+	}
+
+	table.Append(header)
+
+	for _, apiKey := range apiKeys {
+		expires := getAPIKeyTimeStr(apiKey.ExpirationDate, "never")
+		if apiKey.IsExpired {
+			expires = "expired"
+		}
+
+		row := []string{apiKey.UUID, apiKey.Label, strings.Join(apiKey.Scopes, ","),
+			getAPIKeyTimeStr(apiKey.CreatedAt, "-"), expires, getAPIKeyTimeStr(apiKey.LastUsed, "never")}
+
+		if showKey {
+			row = append(row, apiKey.APIKey)
+		}
+
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return builder.String()
+}
+
+func getAPIKeyTimeStr(timestamp time.Time, zeroValue string) string {
+	if timestamp.IsZero() {
+		return zeroValue
+	}
+
+	return timestamp.Local().Format(time.RFC3339)
+}
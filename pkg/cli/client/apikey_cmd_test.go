@@ -0,0 +1,117 @@
+//go:build search
+// +build search
+
+package client //nolint:testpackage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/resty.v1"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	test "zotregistry.dev/zot/pkg/test/common"
+)
+
+func runAPIKeyCommand(args ...string) (string, error) {
+	cmd := NewCliRootCmd()
+	buff := bytes.NewBufferString("")
+	cmd.SetOut(buff)
+	cmd.SetErr(buff)
+	cmd.SetArgs(append([]string{"apikey"}, args...))
+
+	err := cmd.Execute()
+
+	return buff.String(), err
+}
+
+func TestAPIKeyCommand(t *testing.T) {
+	Convey("Create, list and revoke API keys", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		username, _ := test.GenerateRandomString()
+		password, _ := test.GenerateRandomString()
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString(username, password))
+		defer os.Remove(htpasswdPath)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{Path: htpasswdPath},
+			APIKey:   true,
+		}
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+
+		cm.StartAndWait(conf.HTTP.Port)
+		defer cm.StopServer()
+
+		user := fmt.Sprintf("%s:%s", username, password)
+
+		output, err := runAPIKeyCommand("create", "--url", baseURL, "-u", user, "--label", "ci",
+			"--scope", "repo1", "--expires-in", "24h", "-f", "json")
+		So(err, ShouldBeNil)
+
+		apiKey := apiKeyInfo{}
+		err = json.Unmarshal([]byte(output), &apiKey)
+		So(err, ShouldBeNil)
+		So(apiKey.APIKey, ShouldStartWith, "zak_")
+		So(apiKey.Label, ShouldEqual, "ci")
+		So(apiKey.Scopes, ShouldResemble, []string{"repo1"})
+		So(apiKey.ExpirationDate.IsZero(), ShouldBeFalse)
+
+		// the key is used as the password of the user
+		resp, err := resty.R().SetBasicAuth(username, apiKey.APIKey).Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		output, err = runAPIKeyCommand("list", "--url", baseURL, "-u", user)
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "LAST USED")
+		So(output, ShouldContainSubstring, apiKey.UUID)
+		So(output, ShouldNotContainSubstring, apiKey.APIKey)
+
+		output, err = runAPIKeyCommand("revoke", apiKey.UUID, "--url", baseURL, "-u", user)
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "revoked")
+
+		resp, err = resty.R().SetBasicAuth(username, apiKey.APIKey).Get(baseURL + "/v2/_catalog")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+		output, err = runAPIKeyCommand("list", "--url", baseURL, "-u", user, "-f", "yaml")
+		So(err, ShouldBeNil)
+		So(output, ShouldNotContainSubstring, apiKey.UUID)
+
+		_, err = runAPIKeyCommand("create", "--url", baseURL, "-u", user, "--expires-in", "-1h")
+		So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+	})
+
+	Convey("API keys are not enabled on the server", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+
+		cm.StartAndWait(conf.HTTP.Port)
+		defer cm.StopServer()
+
+		_, err := runAPIKeyCommand("list", "--url", baseURL)
+		So(err, ShouldWrap, zerr.ErrAPINotSupported)
+	})
+}
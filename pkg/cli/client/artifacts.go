@@ -0,0 +1,251 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	jsoniter "github.com/json-iterator/go"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sigstore/cosign/v2/pkg/oci/remote"
+	"gopkg.in/yaml.v2"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/common"
+)
+
+const (
+	signatureKind   = "signature"
+	sbomKind        = "sbom"
+	attestationKind = "attestation"
+	scanKind        = "scan"
+	otherKind       = "other"
+
+	inTotoMediaType = "application/vnd.in-toto+json"
+	dsseMediaType   = "application/vnd.dsse.envelope.v1+json"
+	sarifMediaType  = "application/sarif+json"
+
+	referrerSource  = "referrer"
+	cosignTagSource = "tag"
+)
+
+// artifactKinds are the kinds of the well-known artifact types, the other artifacts are of otherKind.
+var artifactKinds = map[string]string{ //nolint: gochecknoglobals
+	common.ArtifactTypeCosign:   signatureKind,
+	common.ArtifactTypeNotation: signatureKind,
+	spdxJSONMediaType:           sbomKind,
+	cosignSPDXJSONMediaType:     sbomKind,
+	cycloneDXJSONMediaType:      sbomKind,
+	inTotoMediaType:             attestationKind,
+	dsseMediaType:               attestationKind,
+	sarifMediaType:              scanKind,
+}
+
+// cosignTagKinds are the kinds of the artifacts cosign attaches with a tag instead of a referrer.
+var cosignTagKinds = map[string]string{ //nolint: gochecknoglobals
+	remote.SignatureTagSuffix:   signatureKind,
+	remote.AttestationTagSuffix: attestationKind,
+	remote.SBOMTagSuffix:        sbomKind,
+}
+
+// artifactStruct is an artifact attached to an image, as a referrer or with the cosign tag convention.
+type artifactStruct struct {
+	Kind         string `json:"kind"`
+	ArtifactType string `json:"artifactType"`
+	MediaType    string `json:"mediaType"`
+	Digest       string `json:"digest"`
+	Size         int64  `json:"size"`
+	Source       string `json:"source"`
+	Tag          string `json:"tag,omitempty"`
+}
+
+func getArtifactKind(artifactType string) string {
+	if kind, ok := artifactKinds[artifactType]; ok {
+		return kind
+	}
+
+	return otherKind
+}
+
+// fetchArtifacts returns the referrers of the manifest and the artifacts attached to it by cosign with
+// a tag. If artifactType is given only the artifacts of this type are returned.
+func fetchArtifacts(ctx context.Context, config SearchConfig, username, password, repo, digest,
+	artifactType string,
+) (artifactsResult, error) {
+	referrersURL := fmt.Sprintf("%s/v2/%s/referrers/%s", config.ServURL, repo, digest)
+
+	if artifactType != "" {
+		referrersURL += "?artifactType=" + url.QueryEscape(artifactType)
+	}
+
+	var referrers ispec.Index
+
+	if _, err := makeGETRequest(ctx, referrersURL, username, password, config, &referrers); err != nil {
+		return nil, err
+	}
+
+	artifacts := artifactsResult{}
+
+	for _, referrer := range referrers.Manifests {
+		// the registry may not support filtering the referrers
+		if artifactType != "" && referrer.ArtifactType != artifactType {
+			continue
+		}
+
+		artifacts = append(artifacts, artifactStruct{
+			Kind:         getArtifactKind(referrer.ArtifactType),
+			ArtifactType: referrer.ArtifactType,
+			MediaType:    referrer.MediaType,
+			Digest:       referrer.Digest.String(),
+			Size:         referrer.Size,
+			Source:       referrerSource,
+		})
+	}
+
+	for _, suffix := range []string{remote.SignatureTagSuffix, remote.AttestationTagSuffix, remote.SBOMTagSuffix} {
+		tag := strings.Replace(digest, ":", "-", 1) + "." + suffix
+
+		content, mediaType, manifestDigest, err := fetchRawManifest(ctx, config, username, password, repo, tag)
+		if err != nil {
+			if common.IsContextDone(ctx) {
+				return nil, err
+			}
+
+			continue
+		}
+
+		tagArtifactType := getManifestArtifactType(content)
+
+		if artifactType != "" && tagArtifactType != artifactType {
+			continue
+		}
+
+		artifacts = append(artifacts, artifactStruct{
+			Kind:         cosignTagKinds[suffix],
+			ArtifactType: tagArtifactType,
+			MediaType:    mediaType,
+			Digest:       manifestDigest,
+			Size:         int64(len(content)),
+			Source:       cosignTagSource,
+			Tag:          tag,
+		})
+	}
+
+	return artifacts, nil
+}
+
+// getManifestArtifactType returns the artifact type of the manifest, which is the media type of its config
+// if the manifest doesn't set it, like the registries do for the referrers.
+func getManifestArtifactType(content []byte) string {
+	var manifest ispec.Manifest
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return ""
+	}
+
+	if manifest.ArtifactType != "" {
+		return manifest.ArtifactType
+	}
+
+	return manifest.Config.MediaType
+}
+
+type artifactsResult []artifactStruct
+
+func (artifacts artifactsResult) string(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return artifacts.stringPlainText()
+	case jsonFormat:
+		return artifacts.stringJSON()
+	case ndjsonFormat:
+		return artifacts.stringNDJSON()
+	case ymlFormat, yamlFormat:
+		return artifacts.stringYAML()
+	case csvFormat, tsvFormat:
+		return artifacts.stringSeparatedValues(format)
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (artifacts artifactsResult) stringPlainText() (string, error) {
+	var builder strings.Builder
+
+	table := getImageTableWriter(&builder)
+
+	table.Append([]string{"KIND", "ARTIFACT TYPE", "DIGEST", sizeColumn})
+
+	for _, artifact := range artifacts {
+		size := ellipsize(strings.ReplaceAll(humanize.Bytes(uint64(artifact.Size)), " ", ""), sizeWidth, ellipsis)
+
+		table.Append([]string{artifact.Kind, artifact.ArtifactType, artifact.Digest, size})
+	}
+
+	table.Render()
+
+	return builder.String(), nil
+}
+
+func (artifacts artifactsResult) stringJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.MarshalIndent(artifacts, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+// stringNDJSON renders one artifact per line.
+func (artifacts artifactsResult) stringNDJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	var builder strings.Builder
+
+	for _, artifact := range artifacts {
+		body, err := json.Marshal(artifact)
+		if err != nil {
+			return "", err
+		}
+
+		builder.Write(body)
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
+
+func (artifacts artifactsResult) stringYAML() (string, error) {
+	body, err := yaml.Marshal(artifacts)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(body), nil
+}
+
+func (artifacts artifactsResult) stringSeparatedValues(format string) (string, error) {
+	rows := [][]string{{"kind", "artifact type", "media type", "digest", "size", "source", "tag"}}
+
+	for _, artifact := range artifacts {
+		rows = append(rows, []string{artifact.Kind, artifact.ArtifactType, artifact.MediaType, artifact.Digest,
+			strconv.FormatInt(artifact.Size, 10), artifact.Source, artifact.Tag})
+	}
+
+	var builder strings.Builder
+
+	if err := writeSeparatedValues(&builder, format, rows); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
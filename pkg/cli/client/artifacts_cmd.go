@@ -0,0 +1,48 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewArtifactsCommand(searchService SearchService) *cobra.Command {
+	artifactsCmd := &cobra.Command{
+		Use:   "artifacts [command]",
+		Short: "List the artifacts attached to images",
+		Long:  `List the signatures, SBOMs, attestations and scan results attached to images hosted on zot`,
+		RunE:  ShowSuggestionsIfUnknownCommand,
+	}
+
+	artifactsCmd.SetUsageTemplate(artifactsCmd.UsageTemplate() + usageFooter)
+
+	artifactsCmd.PersistentFlags().String(URLFlag, "",
+		"Specify zot server URL if config-name is not mentioned")
+	artifactsCmd.PersistentFlags().String(ConfigFlag, "",
+		"Specify the registry configuration to use for connection")
+	_ = artifactsCmd.RegisterFlagCompletionFunc(ConfigFlag, completeConfigNames)
+	artifactsCmd.PersistentFlags().StringP(UserFlag, "u", "",
+		`User Credentials of zot server in "username:password" format`)
+	artifactsCmd.PersistentFlags().StringP(OutputFormatFlag, "f", "",
+		"Specify output format [text/json/ndjson/yaml/csv/tsv]")
+	artifactsCmd.PersistentFlags().Bool(DebugFlag, false,
+		"Show every request made to the registry on stderr, with its status, latency and request id")
+	artifactsCmd.PersistentFlags().Bool(DebugBodyFlag, false, "With --debug, also show the headers and the textual bodies")
+	artifactsCmd.PersistentFlags().Duration(TimeoutFlag, 0,
+		"Maximum time the whole search can take, 0 means no limit")
+	artifactsCmd.PersistentFlags().Int(RetriesFlag, defaultRetries,
+		"Number of times a request is retried when the registry is busy or the connection fails")
+	artifactsCmd.PersistentFlags().Duration(RetryMaxWaitFlag, defaultRetryMaxWait,
+		"Maximum time to wait between two retries of a request")
+	artifactsCmd.PersistentFlags().String(CertFlag, "", "Client certificate file to present to the server")
+	artifactsCmd.PersistentFlags().String(KeyFlag, "", "Key file of the client certificate")
+	artifactsCmd.PersistentFlags().String(CACertFlag, "",
+		"CA certificate file used to verify the server, in addition to the system ones")
+	artifactsCmd.PersistentFlags().String(ProxyFlag, "",
+		"Proxy the server is reached through, an http, https or socks5 url [default: HTTPS_PROXY/NO_PROXY]")
+
+	artifactsCmd.AddCommand(NewArtifactsListCommand(searchService))
+
+	return artifactsCmd
+}
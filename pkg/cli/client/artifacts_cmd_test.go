@@ -0,0 +1,162 @@
+//go:build search
+// +build search
+
+package client_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/cli/client"
+	"zotregistry.dev/zot/pkg/common"
+	test "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+func TestArtifactsList(t *testing.T) {
+	space := regexp.MustCompile(`\s+`)
+
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	image := CreateRandomImage()
+	err := UploadImage(image, baseURL, "repo", "1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = UploadImage(CreateRandomImage(), baseURL, "repo", "noartifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createArtifact := func(content, artifactType string, subject *ispec.Descriptor) Image {
+		builder := CreateImageWith().Layers([]Layer{{
+			Blob:      []byte(content),
+			MediaType: artifactType,
+			Digest:    godigest.FromString(content),
+		}}).EmptyConfig()
+
+		if subject != nil {
+			return builder.ArtifactType(artifactType).Subject(subject).Build()
+		}
+
+		return builder.Build()
+	}
+
+	referrers := []Image{
+		createArtifact("sig", common.ArtifactTypeNotation, image.DescriptorRef()),
+		createArtifact("{}", "application/spdx+json", image.DescriptorRef()),
+		createArtifact("{}", "application/sarif+json", image.DescriptorRef()),
+		createArtifact("custom", "application/vnd.example.custom", image.DescriptorRef()),
+	}
+
+	for _, referrer := range referrers {
+		err = UploadImage(referrer, baseURL, "repo", referrer.DigestStr())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a signature attached with cosign sign
+	cosignTag := strings.Replace(image.DigestStr(), ":", "-", 1) + ".sig"
+	cosignSignature := createArtifact("payload", "application/vnd.dev.cosign.simplesigning.v1+json", nil)
+
+	err = UploadImage(cosignSignature, baseURL, "repo", cosignTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runArtifactsList := func(args ...string) (string, error) {
+		cmd := client.NewArtifactsCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append([]string{"list", "--url", baseURL}, args...))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	Convey("Test artifacts list", t, func() {
+		Convey("all the artifacts", func() {
+			output, err := runArtifactsList("repo:1.0")
+			So(err, ShouldBeNil)
+
+			actual := strings.TrimSpace(space.ReplaceAllString(output, " "))
+			So(actual, ShouldStartWith, "KIND ARTIFACT TYPE DIGEST SIZE")
+			So(actual, ShouldContainSubstring, "signature "+common.ArtifactTypeNotation+" "+
+				referrers[0].DigestStr())
+			So(actual, ShouldContainSubstring, "sbom application/spdx+json "+referrers[1].DigestStr())
+			So(actual, ShouldContainSubstring, "scan application/sarif+json "+referrers[2].DigestStr())
+			So(actual, ShouldContainSubstring, "other application/vnd.example.custom "+referrers[3].DigestStr())
+			So(actual, ShouldContainSubstring, "signature "+ispec.MediaTypeEmptyJSON+" "+
+				cosignSignature.DigestStr())
+		})
+
+		Convey("the artifacts of a type, by digest", func() {
+			output, err := runArtifactsList("repo@"+image.DigestStr(), "--artifact-type", "application/spdx+json",
+				"-f", "json")
+			So(err, ShouldBeNil)
+
+			var artifacts []map[string]any
+
+			err = json.Unmarshal([]byte(output), &artifacts)
+			So(err, ShouldBeNil)
+			So(len(artifacts), ShouldEqual, 1)
+			So(artifacts[0]["kind"], ShouldEqual, "sbom")
+			So(artifacts[0]["digest"], ShouldEqual, referrers[1].DigestStr())
+			So(artifacts[0]["source"], ShouldEqual, "referrer")
+			So(artifacts[0]["size"], ShouldEqual, float64(referrers[1].ManifestDescriptor.Size))
+		})
+
+		Convey("the cosign artifacts in csv", func() {
+			output, err := runArtifactsList("repo:1.0", "--artifact-type", ispec.MediaTypeEmptyJSON, "-f", "csv")
+			So(err, ShouldBeNil)
+
+			lines := strings.Split(strings.TrimSpace(output), "\n")
+			So(len(lines), ShouldEqual, 2)
+			So(lines[0], ShouldEqual, "kind,artifact type,media type,digest,size,source,tag")
+			So(lines[1], ShouldStartWith, "signature,"+ispec.MediaTypeEmptyJSON+","+ispec.MediaTypeImageManifest+
+				","+cosignSignature.DigestStr())
+			So(lines[1], ShouldEndWith, ",tag,"+cosignTag)
+		})
+
+		Convey("no artifacts", func() {
+			output, err := runArtifactsList("repo:noartifacts", "-f", "ndjson")
+			So(err, ShouldBeNil)
+			So(output, ShouldBeEmpty)
+		})
+
+		Convey("errors", func() {
+			_, err := runArtifactsList("repo:1.0", "-f", "xml")
+			So(errors.Is(err, zerr.ErrInvalidOutputFormat), ShouldBeTrue)
+
+			_, err = runArtifactsList("repo:missing")
+			So(err, ShouldNotBeNil)
+
+			_, err = runArtifactsList("repo")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
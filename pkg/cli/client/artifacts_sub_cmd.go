@@ -0,0 +1,36 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewArtifactsListCommand(searchService SearchService) *cobra.Command {
+	var artifactType string
+
+	cmd := &cobra.Command{
+		Use:   "list [repo-name:tag]|[repo-name@digest]",
+		Short: "List the artifacts attached to an image",
+		Long: `List the artifacts attached to the image, found with the referrers API or the cosign
+tag convention, with their kind, artifact type, digest and size.`,
+		Example: `  zli artifacts list alpine:3.18
+  zli artifacts list alpine@sha256:... -f json
+  zli artifacts list alpine:3.18 --artifact-type application/vnd.dev.cosign.artifact.sig.v1+json`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              OneImageWithRefArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return ListArtifacts(searchConfig, args[0], artifactType)
+		},
+	}
+
+	cmd.Flags().StringVar(&artifactType, ArtifactTypeFlag, "", "Only list the artifacts of this artifact type")
+
+	return cmd
+}
@@ -0,0 +1,68 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"io"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// bandwidthLimiter is a token bucket of bytes shared by all the requests of a command, so the total
+// rate of the transfers stays under the limit however many of them run at the same time.
+type bandwidthLimiter struct {
+	limiter *rate.Limiter
+}
+
+// newBandwidthLimiter returns nil, meaning no limit, if bytesPerSecond is 0. The bucket holds one
+// second of transfer, which is also the most a single read can take at once.
+func newBandwidthLimiter(bytesPerSecond uint64) *bandwidthLimiter {
+	if bytesPerSecond == 0 {
+		return nil
+	}
+
+	burst := math.MaxInt32
+	if bytesPerSecond < uint64(burst) {
+		burst = int(bytesPerSecond)
+	}
+
+	return &bandwidthLimiter{limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), burst)}
+}
+
+// limitReader returns the reader slowed down to the rate of the limiter, a nil limiter returns it as it is.
+func (l *bandwidthLimiter) limitReader(ctx context.Context, reader io.ReadCloser) io.ReadCloser {
+	if l == nil || reader == nil {
+		return reader
+	}
+
+	return &limitedReader{ctx: ctx, reader: reader, limiter: l.limiter}
+}
+
+type limitedReader struct {
+	ctx     context.Context //nolint:containedctx // the reads have no context of their own
+	reader  io.ReadCloser
+	limiter *rate.Limiter
+}
+
+// Read takes as many tokens as the bytes it read, waiting for the bucket to refill if needed.
+func (r *limitedReader) Read(buf []byte) (int, error) {
+	if len(buf) > r.limiter.Burst() {
+		buf = buf[:r.limiter.Burst()]
+	}
+
+	read, err := r.reader.Read(buf)
+	if read > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, read); waitErr != nil {
+			return read, waitErr
+		}
+	}
+
+	return read, err
+}
+
+func (r *limitedReader) Close() error {
+	return r.reader.Close()
+}
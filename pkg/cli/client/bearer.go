@@ -0,0 +1,206 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/registry/client/auth/challenge"
+
+	zerr "zotregistry.dev/zot/errors"
+)
+
+// tokens are valid for 60 seconds if the token server doesn't say otherwise.
+const defaultBearerTokenExpiration = 60 * time.Second
+
+var (
+	bearerTokensMap  = make(map[string]bearerToken) //nolint: gochecknoglobals
+	bearerTokensLock sync.Mutex                     //nolint: gochecknoglobals
+
+	repoRequestPathRegex = regexp.MustCompile(`^/v2/(.+)/(manifests|blobs|tags|referrers)/`)
+)
+
+type bearerToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+type bearerTokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"` //nolint: tagliatelle // defined by the oauth2 spec
+	ExpiresIn   int    `json:"expires_in"`   //nolint: tagliatelle // defined by the oauth2 spec
+}
+
+// doWithBearerAuth sends the request and, if the registry answers with a bearer challenge, gets a token
+// for the challenged scope from the token server and sends the request again with it.
+// The tokens are cached per registry and scope, so following requests use them directly.
+func doWithBearerAuth(httpClient *http.Client, req *http.Request, config SearchConfig) (*http.Response, error) {
+	username, password, _ := req.BasicAuth()
+	tokenKey := req.URL.Host + " " + getRequestScope(req)
+
+	if token, ok := getCachedBearerToken(tokenKey); ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := doWithRetries(httpClient, req, config)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	bearerChallenge, ok := getBearerChallenge(resp)
+	if !ok {
+		return resp, nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	token, err := fetchBearerToken(req.Context(), bearerChallenge, username, password, config)
+	if err != nil {
+		return nil, err
+	}
+
+	bearerTokensLock.Lock()
+	bearerTokensMap[tokenKey] = token
+	bearerTokensLock.Unlock()
+
+	if req.GetBody != nil {
+		req.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token.token)
+
+	return doWithRetries(httpClient, req, config)
+}
+
+func getCachedBearerToken(tokenKey string) (string, bool) {
+	bearerTokensLock.Lock()
+	defer bearerTokensLock.Unlock()
+
+	token, ok := bearerTokensMap[tokenKey]
+	if !ok || time.Now().After(token.expiresAt) {
+		return "", false
+	}
+
+	return token.token, true
+}
+
+func getBearerChallenge(resp *http.Response) (challenge.Challenge, bool) {
+	for _, authChallenge := range challenge.ResponseChallenges(resp) {
+		if authChallenge.Scheme == "bearer" {
+			return authChallenge, true
+		}
+	}
+
+	return challenge.Challenge{}, false
+}
+
+// getRequestScope returns the scope a token server would grant for the request,
+// it is only used to find the cached tokens, the scope asked to the token server is the challenged one.
+func getRequestScope(req *http.Request) string {
+	if req.URL.Path == "/v2/_catalog" {
+		return "registry:catalog:*"
+	}
+
+	matches := repoRequestPathRegex.FindStringSubmatch(req.URL.Path)
+	if matches == nil {
+		return ""
+	}
+
+	action := "pull"
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		action = "pull,push"
+	default:
+		action = strings.ToLower(req.Method)
+	}
+
+	return "repository:" + matches[1] + ":" + action
+}
+
+func fetchBearerToken(ctx context.Context, bearerChallenge challenge.Challenge, username, password string,
+	config SearchConfig,
+) (bearerToken, error) {
+	realmURL, err := url.Parse(bearerChallenge.Parameters["realm"])
+	if err != nil || realmURL.Host == "" {
+		return bearerToken{}, fmt.Errorf("%w: '%s'", zerr.ErrInvalidBearerChallenge, bearerChallenge.Parameters["realm"])
+	}
+
+	query := realmURL.Query()
+
+	if service := bearerChallenge.Parameters["service"]; service != "" {
+		query.Set("service", service)
+	}
+
+	if scope := bearerChallenge.Parameters["scope"]; scope != "" {
+		query.Set("scope", scope)
+	}
+
+	realmURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realmURL.String(), nil)
+	if err != nil {
+		return bearerToken{}, err
+	}
+
+	// anonymous tokens are asked without credentials
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	httpClient, err := getHTTPClient(req.Host, config)
+	if err != nil {
+		return bearerToken{}, err
+	}
+
+	resp, err := doWithRetries(httpClient, req, config)
+	if err != nil {
+		return bearerToken{}, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+
+		return bearerToken{}, fmt.Errorf("%w: token server %s answered with %d, Body: '%s'",
+			zerr.ErrUnauthorizedAccess, realmURL.Host, resp.StatusCode, string(bodyBytes))
+	}
+
+	tokenResponse := bearerTokenResponse{}
+
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return bearerToken{}, err
+	}
+
+	token := tokenResponse.Token
+	if token == "" {
+		token = tokenResponse.AccessToken
+	}
+
+	if token == "" {
+		return bearerToken{}, zerr.ErrNoBearerToken
+	}
+
+	expiresIn := time.Duration(tokenResponse.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = defaultBearerTokenExpiration
+	}
+
+	return bearerToken{token: token, expiresAt: time.Now().Add(expiresIn)}, nil
+}
@@ -0,0 +1,169 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/constants"
+	test "zotregistry.dev/zot/pkg/test/common"
+)
+
+func TestBearerAuth(t *testing.T) {
+	Convey("Bearer token auth", t, func() {
+		var (
+			tokenRequests   atomic.Int32
+			catalogRequests atomic.Int32
+			tokenResponse   atomic.Value
+			realm           atomic.Value
+		)
+
+		bearerTokensLock.Lock()
+		bearerTokensMap = make(map[string]bearerToken)
+		bearerTokensLock.Unlock()
+
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		tokenResponse.Store(`{"token": "catalog-token", "expires_in": 300}`)
+		realm.Store(baseURL + "/token")
+
+		server := StartTestHTTPServer(HTTPRoutes{
+			{
+				Route: "/token",
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					tokenRequests.Add(1)
+
+					username, password, ok := r.BasicAuth()
+					if !ok || username != "user" || password != "pass" {
+						w.WriteHeader(http.StatusUnauthorized)
+
+						return
+					}
+
+					if r.URL.Query().Get("service") != "registry" || r.URL.Query().Get("scope") != "registry:catalog:*" {
+						w.WriteHeader(http.StatusBadRequest)
+
+						return
+					}
+
+					_, _ = w.Write([]byte(tokenResponse.Load().(string)))
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+			{
+				Route: "/v2/_catalog",
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					catalogRequests.Add(1)
+
+					if r.Header.Get("Authorization") != "Bearer catalog-token" {
+						w.Header().Set("WWW-Authenticate", `Bearer realm="`+realm.Load().(string)+
+							`",service="registry",scope="registry:catalog:*"`)
+						w.WriteHeader(http.StatusUnauthorized)
+
+						return
+					}
+
+					_, _ = w.Write([]byte(`{"repositories": ["repo"]}`))
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+		}, port)
+		defer server.Close()
+
+		searchConf := getDefaultSearchConf(baseURL)
+
+		Convey("the token is fetched then cached", func() {
+			catalog, err := getCatalog(context.Background(), searchConf, "user", "pass")
+			So(err, ShouldBeNil)
+			So(catalog.Repositories, ShouldResemble, []string{"repo"})
+			So(tokenRequests.Load(), ShouldEqual, 1)
+			So(catalogRequests.Load(), ShouldEqual, 2)
+
+			_, err = getCatalog(context.Background(), searchConf, "user", "pass")
+			So(err, ShouldBeNil)
+			So(tokenRequests.Load(), ShouldEqual, 1)
+			So(catalogRequests.Load(), ShouldEqual, 3)
+		})
+
+		Convey("access_token is used if there is no token", func() {
+			tokenResponse.Store(`{"access_token": "catalog-token"}`)
+
+			_, err := getCatalog(context.Background(), searchConf, "user", "pass")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("bad credentials", func() {
+			_, err := getCatalog(context.Background(), searchConf, "user", "bad")
+			So(errors.Is(err, zerr.ErrUnauthorizedAccess), ShouldBeTrue)
+		})
+
+		Convey("no token in the response", func() {
+			tokenResponse.Store(`{}`)
+
+			_, err := getCatalog(context.Background(), searchConf, "user", "pass")
+			So(errors.Is(err, zerr.ErrNoBearerToken), ShouldBeTrue)
+		})
+
+		Convey("bad token response", func() {
+			tokenResponse.Store(`bad json`)
+
+			_, err := getCatalog(context.Background(), searchConf, "user", "pass")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("bad realm", func() {
+			realm.Store("not a url")
+
+			_, err := getCatalog(context.Background(), searchConf, "user", "pass")
+			So(errors.Is(err, zerr.ErrInvalidBearerChallenge), ShouldBeTrue)
+		})
+
+		Convey("expired tokens are not used", func() {
+			tokenResponse.Store(`{"token": "catalog-token", "expires_in": -1}`)
+
+			_, err := getCatalog(context.Background(), searchConf, "user", "pass")
+			So(err, ShouldBeNil)
+
+			bearerTokensLock.Lock()
+			for key, token := range bearerTokensMap {
+				token.expiresAt = token.expiresAt.Add(-defaultBearerTokenExpiration)
+				bearerTokensMap[key] = token
+			}
+			bearerTokensLock.Unlock()
+
+			_, err = getCatalog(context.Background(), searchConf, "user", "pass")
+			So(err, ShouldBeNil)
+			So(tokenRequests.Load(), ShouldEqual, 2)
+		})
+	})
+
+	Convey("Request scopes", t, func() {
+		scopes := map[string]string{
+			http.MethodGet + " /v2/_catalog":                  "registry:catalog:*",
+			http.MethodHead + " /v2/a/b/manifests/tag":        "repository:a/b:pull",
+			http.MethodGet + " /v2/repo/tags/list":            "repository:repo:pull",
+			http.MethodDelete + " /v2/repo/manifests/digest":  "repository:repo:delete",
+			http.MethodPost + " /v2/repo/blobs/uploads/":      "repository:repo:pull,push",
+			http.MethodPut + " /v2/repo/manifests/tag":        "repository:repo:pull,push",
+			http.MethodGet + " " + constants.FullSearchPrefix: "",
+		}
+
+		for request, scope := range scopes {
+			method, path, _ := strings.Cut(request, " ")
+
+			req, err := http.NewRequestWithContext(context.Background(), method, "http://server"+path, nil)
+			So(err, ShouldBeNil)
+			So(getRequestScope(req), ShouldEqual, scope)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+
+	godigest "github.com/opencontainers/go-digest"
+	"github.com/spf13/cobra"
+
+	"zotregistry.dev/zot/pkg/common"
+)
+
+const (
+	cacheDirName         = "zot"
+	manifestCacheDirName = "manifests"
+	defaultCacheDirPerms = 0o700
+)
+
+// manifestCache keeps on disk the summaries of the manifests fetched over the REST API, so later
+// listings don't download them again. Manifests are content addressed, so the entries are keyed by
+// digest and the digest returned by the registry for a tag is enough to know whether an entry is
+// still valid. A nil cache is valid and never stores anything.
+type manifestCache struct {
+	rootDir string
+}
+
+type manifestCacheEntry struct {
+	Manifest common.ManifestSummary `json:"manifest"`
+	Labels   map[string]string      `json:"labels"`
+	Author   string                 `json:"author"`
+}
+
+func newManifestCache(rootDir string) *manifestCache {
+	return &manifestCache{rootDir: rootDir}
+}
+
+func getDefaultCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(cacheDir, cacheDirName), nil
+}
+
+func (cache *manifestCache) entryPath(serverURL, repo string, digest godigest.Digest) string {
+	key := godigest.FromString(serverURL + "/" + repo + "@" + digest.String())
+
+	return path.Join(cache.rootDir, manifestCacheDirName, key.Encoded()+".json")
+}
+
+func (cache *manifestCache) get(serverURL, repo, reference string) (manifestCacheEntry, bool) {
+	if cache == nil {
+		return manifestCacheEntry{}, false
+	}
+
+	digest, err := godigest.Parse(reference)
+	if err != nil {
+		return manifestCacheEntry{}, false
+	}
+
+	content, err := os.ReadFile(cache.entryPath(serverURL, repo, digest))
+	if err != nil {
+		return manifestCacheEntry{}, false
+	}
+
+	entry := manifestCacheEntry{}
+
+	if err := json.Unmarshal(content, &entry); err != nil || entry.Manifest.Digest != digest.String() {
+		return manifestCacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+// put stores the entry under the digest of its manifest, the signature status is not cached
+// since it can change without the manifest changing.
+func (cache *manifestCache) put(serverURL, repo string, entry manifestCacheEntry) error {
+	if cache == nil {
+		return nil
+	}
+
+	digest, err := godigest.Parse(entry.Manifest.Digest)
+	if err != nil {
+		return err
+	}
+
+	entry.Manifest.IsSigned = false
+	entry.Manifest.SignatureInfo = nil
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return writeCacheFile(cache.entryPath(serverURL, repo, digest), content)
+}
+
+func writeCacheFile(entryPath string, content []byte) error {
+	if err := os.MkdirAll(path.Dir(entryPath), defaultCacheDirPerms); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so concurrent readers never see a partial entry
+	tmpFile, err := os.CreateTemp(path.Dir(entryPath), "entry-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), entryPath)
+}
+
+func (cache *manifestCache) purge() error {
+	return os.RemoveAll(cache.rootDir)
+}
+
+func NewCacheCommand() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache [command]",
+		Short: "Manage the local cache of the CLI",
+		Long:  `Manage the local cache of the CLI`,
+		RunE:  ShowSuggestionsIfUnknownCommand,
+	}
+
+	cacheCmd.AddCommand(NewCachePurgeCommand())
+
+	return cacheCmd
+}
+
+func NewCachePurgeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "purge",
+		Short: "Remove all the cached image manifests and completions",
+		Long:  `Remove all the cached image manifests and completions`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cacheDir, err := getDefaultCacheDir()
+			if err != nil {
+				return err
+			}
+
+			return newManifestCache(cacheDir).purge()
+		},
+	}
+}
@@ -0,0 +1,167 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/mux"
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.dev/zot/pkg/common"
+	test "zotregistry.dev/zot/pkg/test/common"
+)
+
+func TestManifestCache(t *testing.T) {
+	Convey("Manifest cache", t, func() {
+		cache := newManifestCache(t.TempDir())
+		digest := godigest.FromString("manifest").String()
+
+		entry := manifestCacheEntry{
+			Manifest: common.ManifestSummary{Digest: digest, Size: "100", IsSigned: true},
+			Labels:   map[string]string{"key": "value"},
+		}
+
+		_, found := cache.get("http://server", "repo", digest)
+		So(found, ShouldBeFalse)
+
+		err := cache.put("http://server", "repo", entry)
+		So(err, ShouldBeNil)
+
+		cachedEntry, found := cache.get("http://server", "repo", digest)
+		So(found, ShouldBeTrue)
+		So(cachedEntry.Manifest.Size, ShouldEqual, "100")
+		So(cachedEntry.Manifest.IsSigned, ShouldBeFalse)
+		So(cachedEntry.Labels, ShouldResemble, entry.Labels)
+
+		// the entries are scoped to the server and repo
+		_, found = cache.get("http://other-server", "repo", digest)
+		So(found, ShouldBeFalse)
+		_, found = cache.get("http://server", "other-repo", digest)
+		So(found, ShouldBeFalse)
+
+		// tags are never looked up
+		_, found = cache.get("http://server", "repo", "tag")
+		So(found, ShouldBeFalse)
+
+		err = cache.put("http://server", "repo", manifestCacheEntry{Manifest: common.ManifestSummary{Digest: "bad"}})
+		So(err, ShouldNotBeNil)
+
+		Convey("corrupted entries are ignored", func() {
+			err := os.WriteFile(cache.entryPath("http://server", "repo", godigest.Digest(digest)),
+				[]byte("bad json"), 0o600)
+			So(err, ShouldBeNil)
+
+			_, found := cache.get("http://server", "repo", digest)
+			So(found, ShouldBeFalse)
+		})
+
+		Convey("purge", func() {
+			err := cache.purge()
+			So(err, ShouldBeNil)
+
+			_, found := cache.get("http://server", "repo", digest)
+			So(found, ShouldBeFalse)
+		})
+	})
+
+	Convey("Nil manifest cache", t, func() {
+		var cache *manifestCache
+
+		err := cache.put("http://server", "repo", manifestCacheEntry{})
+		So(err, ShouldBeNil)
+
+		_, found := cache.get("http://server", "repo", godigest.FromString("manifest").String())
+		So(found, ShouldBeFalse)
+	})
+
+	Convey("fetchManifestStruct uses the cache", t, func() {
+		var manifestRequests atomic.Int32
+
+		manifestDigest := godigest.FromString("manifest").String()
+		manifestContent := `{"config": {"digest": "configRef", "size": 1}, "layers": [{"digest": "layerRef", "size": 10}]}`
+
+		port := test.GetFreePort()
+		server := StartTestHTTPServer(HTTPRoutes{
+			{
+				Route: "/v2/{name}/manifests/{reference}",
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					// signatures are looked up every time
+					if strings.HasSuffix(mux.Vars(r)["reference"], ".sig") {
+						w.WriteHeader(http.StatusNotFound)
+
+						return
+					}
+
+					manifestRequests.Add(1)
+
+					w.Header().Set("docker-content-digest", manifestDigest)
+					w.Header().Set("Content-Length", strconv.Itoa(len(manifestContent)))
+					_, _ = w.Write([]byte(manifestContent))
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+			{
+				Route: "/v2/{name}/blobs/{digest}",
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write([]byte(`{"architecture": "amd64", "os": "linux",
+						"config": {"Labels": {"key": "value"}}}`))
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+		}, port)
+		defer server.Close()
+
+		searchConf := getDefaultSearchConf(test.GetBaseURL(port))
+		searchConf.Cache = newManifestCache(path.Join(t.TempDir(), "cache"))
+
+		entry, err := fetchManifestStruct(context.Background(), "repo", manifestDigest, searchConf, "", "")
+		So(err, ShouldBeNil)
+		So(manifestRequests.Load(), ShouldEqual, 1)
+
+		cachedEntry, err := fetchManifestStruct(context.Background(), "repo", manifestDigest,
+			searchConf, "", "")
+		So(err, ShouldBeNil)
+		So(manifestRequests.Load(), ShouldEqual, 1)
+		So(cachedEntry.Manifest, ShouldResemble, entry.Manifest)
+		So(cachedEntry.Labels, ShouldResemble, entry.Labels)
+		So(cachedEntry.Manifest.Platform.Os, ShouldEqual, "linux")
+		So(cachedEntry.Labels, ShouldResemble, map[string]string{"key": "value"})
+
+		// tags are always resolved by the registry
+		_, err = fetchManifestStruct(context.Background(), "repo", "tag", searchConf, "", "")
+		So(err, ShouldBeNil)
+		So(manifestRequests.Load(), ShouldEqual, 2)
+	})
+
+	Convey("cache purge command", t, func() {
+		cacheHome := t.TempDir()
+		t.Setenv("XDG_CACHE_HOME", cacheHome)
+
+		cacheDir, err := getDefaultCacheDir()
+		So(err, ShouldBeNil)
+		So(cacheDir, ShouldEqual, path.Join(cacheHome, "zot"))
+
+		err = newManifestCache(cacheDir).put("http://server", "repo", manifestCacheEntry{
+			Manifest: common.ManifestSummary{Digest: godigest.FromString("manifest").String()},
+		})
+		So(err, ShouldBeNil)
+
+		cmd := NewCacheCommand()
+		cmd.SetArgs([]string{"purge"})
+		err = cmd.Execute()
+		So(err, ShouldBeNil)
+
+		_, err = os.Stat(cacheDir)
+		So(os.IsNotExist(err), ShouldBeTrue)
+	})
+}
@@ -11,5 +11,11 @@ func enableCli(rootCmd *cobra.Command) {
 	rootCmd.AddCommand(NewCVECommand(NewSearchService()))
 	rootCmd.AddCommand(NewRepoCommand(NewSearchService()))
 	rootCmd.AddCommand(NewSearchCommand(NewSearchService()))
+	rootCmd.AddCommand(NewSBOMCommand(NewSearchService()))
+	rootCmd.AddCommand(NewArtifactsCommand(NewSearchService()))
+	rootCmd.AddCommand(NewStatsCommand(NewSearchService()))
 	rootCmd.AddCommand(NewServerStatusCommand())
+	rootCmd.AddCommand(NewPingCommand())
+	rootCmd.AddCommand(NewCacheCommand())
+	rootCmd.AddCommand(NewAPIKeyCommand())
 }
@@ -6,30 +6,62 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sigstore/cosign/v2/pkg/oci/remote"
 
 	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/constants"
 	"zotregistry.dev/zot/pkg/common"
+	"zotregistry.dev/zot/pkg/extensions/tracing"
 )
 
 var (
 	httpClientsMap = make(map[string]*http.Client) //nolint: gochecknoglobals
 	httpClientLock sync.Mutex                      //nolint: gochecknoglobals
+
+	// docker manifests and manifest lists have the same layout as the oci ones,
+	// so they can be decoded in the same structures.
+	supportedManifestMediaTypes = []string{ //nolint: gochecknoglobals
+		ispec.MediaTypeImageManifest,
+		ispec.MediaTypeImageIndex,
+		schema2.MediaTypeManifest,
+		manifestlist.MediaTypeManifestList,
+	}
+
+	// the schemes of the proxies supported by the http transport.
+	proxySchemes = []string{"http", "https", "socks5"} //nolint: gochecknoglobals
 )
 
-func makeGETRequest(ctx context.Context, url, username, password string,
-	verifyTLS bool, debug bool, resultsPtr interface{}, configWriter io.Writer,
+const (
+	unixSocketScheme = "unix"
+	// the host of the requests sent through a unix socket, the server only sees it in the Host header
+	unixSocketHost = "localhost"
+	// the most pages of a paginated listing followed
+	maxPaginatedPages = 10000
+)
+
+func makeGETRequest(ctx context.Context, url, username, password string, config SearchConfig,
+	resultsPtr interface{},
 ) (http.Header, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -38,11 +70,26 @@ func makeGETRequest(ctx context.Context, url, username, password string,
 
 	req.SetBasicAuth(username, password)
 
-	return doHTTPRequest(req, verifyTLS, debug, resultsPtr, configWriter)
+	return doHTTPRequest(req, config, resultsPtr)
 }
 
-func makeHEADRequest(ctx context.Context, url, username, password string, verifyTLS bool,
-	debug bool,
+// makeManifestGETRequest is the same as makeGETRequest, but it also advertises the supported
+// manifest media types, otherwise some registries serve docker image indexes in the legacy schema1 format.
+func makeManifestGETRequest(ctx context.Context, url, username, password string, config SearchConfig,
+	resultsPtr interface{},
+) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(username, password)
+	req.Header.Set("Accept", strings.Join(supportedManifestMediaTypes, ","))
+
+	return doHTTPRequest(req, config, resultsPtr)
+}
+
+func makeHEADRequest(ctx context.Context, url, username, password string, config SearchConfig,
 ) (http.Header, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
 	if err != nil {
@@ -50,12 +97,39 @@ func makeHEADRequest(ctx context.Context, url, username, password string, verify
 	}
 
 	req.SetBasicAuth(username, password)
+	req.Header.Set("Accept", strings.Join(supportedManifestMediaTypes, ","))
 
-	return doHTTPRequest(req, verifyTLS, debug, nil, io.Discard)
+	return doHTTPRequest(req, config, nil)
 }
 
-func makeGraphQLRequest(ctx context.Context, url, query, username,
-	password string, verifyTLS bool, debug bool, resultsPtr interface{}, configWriter io.Writer,
+func makeDELETERequest(ctx context.Context, url, username, password string, config SearchConfig,
+) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(username, password)
+
+	return doHTTPRequest(req, config, nil)
+}
+
+func makePOSTRequest(ctx context.Context, url, username, password string, config SearchConfig,
+	body []byte, resultsPtr interface{},
+) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(username, password)
+	req.Header.Set("Content-Type", "application/json")
+
+	return doHTTPRequest(req, config, resultsPtr)
+}
+
+func makeGraphQLRequest(ctx context.Context, url, query, username, password string, config SearchConfig,
+	resultsPtr interface{},
 ) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, bytes.NewBufferString(query))
 	if err != nil {
@@ -70,7 +144,7 @@ func makeGraphQLRequest(ctx context.Context, url, query, username,
 	req.SetBasicAuth(username, password)
 	req.Header.Add("Content-Type", "application/json")
 
-	_, err = doHTTPRequest(req, verifyTLS, debug, resultsPtr, configWriter)
+	_, err = doHTTPRequest(req, config, resultsPtr)
 	if err != nil {
 		return err
 	}
@@ -78,62 +152,143 @@ func makeGraphQLRequest(ctx context.Context, url, query, username,
 	return nil
 }
 
-func doHTTPRequest(req *http.Request, verifyTLS bool, debug bool,
-	resultsPtr interface{}, configWriter io.Writer,
-) (http.Header, error) {
-	var httpClient *http.Client
+func getHTTPClient(host string, config SearchConfig) (*http.Client, error) {
+	httpClientLock.Lock()
+	defer httpClientLock.Unlock()
 
-	var err error
+	// the same host may be reached with different certificates or connection settings
+	clientKey := strings.Join([]string{host, config.CertFile, config.KeyFile, config.CACertFile, config.Proxy,
+		config.Socket, strconv.Itoa(getMaxIdleConnsPerHost(config)), config.RequestTimeout.String()}, "|")
 
-	host := req.Host
+	if httpClient, ok := httpClientsMap[clientKey]; ok {
+		return httpClient, nil
+	}
 
-	httpClientLock.Lock()
+	httpClient, err := createHTTPClient(host, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// passes the trace of zli to the server, if it's built with tracing
+	httpClient.Transport = tracing.Transport(httpClient.Transport)
+
+	httpClientsMap[clientKey] = httpClient
+
+	return httpClient, nil
+}
 
-	if httpClientsMap[host] == nil {
-		httpClient, err = common.CreateHTTPClient(verifyTLS, host, "")
+// createHTTPClient creates a client trusting the certificates of the system and of the certs.d
+// directories, to which are added the ones given by the user, and presenting the user's client
+// certificate if there is one.
+// The requests go through the proxy given by the user, else through the one of the HTTPS_PROXY,
+// HTTP_PROXY and NO_PROXY environment variables, unless they're sent through a unix socket.
+// The client is shared by all the requests to the host, so its transport keeps enough idle connections
+// for the concurrent manifest fetches to reuse them instead of opening new ones.
+func createHTTPClient(host string, config SearchConfig) (*http.Client, error) {
+	httpClient, err := common.CreateHTTPClient(config.VerifyTLS, host, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if config.RequestTimeout > 0 {
+		httpClient.Timeout = config.RequestTimeout
+	}
+
+	transport := httpClient.Transport.(*http.Transport) //nolint: forcetypeassert
+
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConnsPerHost = getMaxIdleConnsPerHost(config)
+
+	if transport.MaxIdleConns < transport.MaxIdleConnsPerHost {
+		transport.MaxIdleConns = transport.MaxIdleConnsPerHost
+	}
+
+	if config.Proxy != "" {
+		proxyURL, err := parseProxyURL(config.Proxy)
 		if err != nil {
 			return nil, err
 		}
 
-		httpClientsMap[host] = httpClient
-	} else {
-		httpClient = httpClientsMap[host]
+		transport.Proxy = http.ProxyURL(proxyURL)
 	}
 
-	httpClientLock.Unlock()
+	if config.Socket != "" {
+		dialer := &net.Dialer{}
 
-	if debug {
-		fmt.Fprintln(configWriter, "[debug] ", req.Method, " ", req.URL, "[request header] ", req.Header)
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", config.Socket)
+		}
 	}
 
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
+	if config.CertFile == "" && config.CACertFile == "" {
+		return httpClient, nil
 	}
 
-	if debug {
-		fmt.Fprintln(configWriter, "[debug] ", req.Method, req.URL, "[status] ",
-			resp.StatusCode, " ", "[response header] ", resp.Header)
-	}
+	tlsConfig := transport.TLSClientConfig
 
-	defer resp.Body.Close()
+	if config.CACertFile != "" {
+		caCert, err := os.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, err
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		var err error
+		if tlsConfig.RootCAs == nil {
+			tlsConfig.RootCAs = x509.NewCertPool()
+		}
 
-		switch resp.StatusCode {
-		case http.StatusNotFound:
-			err = zerr.ErrURLNotFound
-		case http.StatusUnauthorized:
-			err = zerr.ErrUnauthorizedAccess
-		default:
-			err = zerr.ErrBadHTTPStatusCode
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("%w: %s", zerr.ErrBadCACert, config.CACertFile)
 		}
+	}
 
-		bodyBytes, _ := io.ReadAll(resp.Body)
+	if config.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
 
-		return nil, fmt.Errorf("%w: Expected: %d, Got: %d, Body: '%s'", err, http.StatusOK,
-			resp.StatusCode, string(bodyBytes))
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+	}
+
+	return httpClient, nil
+}
+
+// getMaxIdleConnsPerHost defaults to the number of manifests fetched at the same time,
+// so each of the fetches finds an idle connection when it starts.
+func getMaxIdleConnsPerHost(config SearchConfig) int {
+	if config.MaxIdleConnsPerHost > 0 {
+		return config.MaxIdleConnsPerHost
+	}
+
+	if config.MaxConcurrentRequests > 0 {
+		return config.MaxConcurrentRequests
+	}
+
+	return defaultMaxConcurrentRequests
+}
+
+func doHTTPRequest(req *http.Request, config SearchConfig, resultsPtr interface{}) (http.Header, error) {
+	httpClient, err := getHTTPClient(req.Host, config)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := doWithBearerAuth(httpClient, req, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// the connection is only reused if the body was read until the end
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	// deletes are answered with 202 Accepted and creations with 201 Created
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted &&
+		resp.StatusCode != http.StatusCreated {
+		return nil, newHTTPStatusError(resp)
 	}
 
 	if resultsPtr == nil {
@@ -147,6 +302,335 @@ func doHTTPRequest(req *http.Request, verifyTLS bool, debug bool,
 	return resp.Header, nil
 }
 
+// doStreamRequest sends the request and returns the response with its body left to be read and closed by
+// the caller, unless the registry answered with an error status.
+func doStreamRequest(req *http.Request, config SearchConfig) (*http.Response, error) {
+	httpClient, err := getHTTPClient(req.Host, config)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := doWithBearerAuth(httpClient, req, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+
+		return nil, newHTTPStatusError(resp)
+	}
+
+	return resp, nil
+}
+
+// makeBlobGETRequest returns the content of the blob, to be closed by the caller.
+func makeBlobGETRequest(ctx context.Context, url, username, password string, config SearchConfig,
+) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(username, password)
+
+	resp, err := doStreamRequest(req, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// makeUploadRequest sends the content to the registry, with the given method since pushing blobs and
+// manifests uses POST, PATCH and PUT requests. It returns the status code, as the same request can be
+// answered with different successful statuses, like a blob mount which may be replaced by an upload.
+func makeUploadRequest(ctx context.Context, method, url, username, password string, config SearchConfig,
+	contentType string, body io.Reader, size int64,
+) (int, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	req.SetBasicAuth(username, password)
+
+	if body != nil {
+		req.ContentLength = size
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := doStreamRequest(req, config)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return resp.StatusCode, resp.Header, nil
+}
+
+// makeChunkUploadRequest sends a chunk of a blob, starting at the given offset, to the upload session.
+// It returns the headers of the response, with the location the next chunk is sent to.
+func makeChunkUploadRequest(ctx context.Context, url, username, password string, config SearchConfig,
+	body io.Reader, offset, size int64,
+) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(username, password)
+
+	req.ContentLength = size
+	req.Header.Set("Content-Type", constants.BinaryMediaType)
+	req.Header.Set("Content-Range", fmt.Sprintf("%d-%d", offset, offset+size-1))
+
+	resp, err := doStreamRequest(req, config)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return resp.Header, nil
+}
+
+func newHTTPStatusError(resp *http.Response) error {
+	var err error
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		err = zerr.ErrURLNotFound
+	case http.StatusUnauthorized:
+		err = zerr.ErrUnauthorizedAccess
+	default:
+		err = zerr.ErrBadHTTPStatusCode
+	}
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+
+	return &httpStatusError{
+		statusCode: resp.StatusCode,
+		err: fmt.Errorf("%w: Expected: %d, Got: %d, Body: '%s'", err, http.StatusOK,
+			resp.StatusCode, string(bodyBytes)),
+	}
+}
+
+// httpStatusError keeps the status code the registry answered with, for the errors summary.
+type httpStatusError struct {
+	statusCode int
+	err        error
+}
+
+func (e *httpStatusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *httpStatusError) Unwrap() error {
+	return e.err
+}
+
+const (
+	defaultRequestTimeout = 5 * time.Minute
+	defaultRetries        = 3
+	defaultRetryMaxWait   = 30 * time.Second
+	retryBaseWait         = 500 * time.Millisecond
+)
+
+// doWithRetries sends the request, retrying up to config.Retries times if the registry is busy or the
+// connection fails in a way likely to be transient. Between attempts it waits for the duration asked by
+// the Retry-After header if present, else for an exponentially growing duration with jitter, but never
+// longer than config.RetryMaxWait.
+// With --debug, every attempt is shown on stderr with the id of the request, its status and its latency.
+func doWithRetries(httpClient *http.Client, req *http.Request, config SearchConfig) (*http.Response, error) {
+	var requestID string
+
+	if config.Debug {
+		requestID = newRequestID(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if config.Debug {
+			debugRequest(config, req, requestID, attempt)
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			req.Body = config.BandwidthLimiter.limitReader(req.Context(), req.Body)
+		}
+
+		start := time.Now()
+		resp, err := httpClient.Do(req)
+		if err == nil {
+			resp.Body = config.BandwidthLimiter.limitReader(req.Context(), resp.Body)
+		}
+
+		if config.Debug {
+			debugResponse(config, req, requestID, resp, err, time.Since(start))
+		}
+
+		// a streamed body can't be sent again
+		canResend := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+		if attempt >= config.Retries || !canResend || !isTransientFailure(resp, err) {
+			return resp, err
+		}
+
+		wait := getRetryWait(resp, attempt, config.RetryMaxWait)
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if config.Debug {
+			writeDebugOutput(config, fmt.Sprintf("[debug] %s retrying in %s\n", requestID, wait))
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func isTransientFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) ||
+			(errors.As(err, &netErr) && netErr.Timeout())
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusNotImplemented)
+}
+
+func getRetryWait(resp *http.Response, attempt int, maxWait time.Duration) time.Duration {
+	var wait time.Duration
+
+	if retryAfter := getRetryAfter(resp); retryAfter > 0 {
+		wait = retryAfter
+	} else {
+		wait = retryBaseWait << attempt
+		// wait between half and the whole backoff, so clients don't retry all at the same time
+		wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1)) //nolint: gosec
+	}
+
+	if maxWait > 0 && wait > maxWait {
+		wait = maxWait
+	}
+
+	return wait
+}
+
+// getRetryAfter parses the Retry-After header, which is either a number of seconds or a date.
+func getRetryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+
+	retryAfter := resp.Header.Get("Retry-After")
+	if retryAfter == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+// makePaginatedGETRequest requests every page of a paginated listing endpoint, like the catalog or
+// the tags list, following the rel="next" links returned by the registry in the "Link" header.
+// When config.PageSize is positive it is sent as the "n" parameter of the first request.
+func makePaginatedGETRequest[T any](ctx context.Context, endpoint, username, password string, config SearchConfig,
+	addPage func(page T),
+) error {
+	pageURL, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+
+	if config.PageSize > 0 {
+		query := pageURL.Query()
+		query.Set("n", strconv.Itoa(config.PageSize))
+		pageURL.RawQuery = query.Encode()
+	}
+
+	// a registry sending the same next link again, or too many of them, would be followed forever
+	visited := map[string]bool{}
+
+	for pageURL != nil {
+		if visited[pageURL.String()] {
+			return fmt.Errorf("%w: %s was already requested", zerr.ErrPaginationLoop, pageURL)
+		}
+
+		if len(visited) == maxPaginatedPages {
+			return fmt.Errorf("%w: more than %d pages", zerr.ErrPaginationLoop, maxPaginatedPages)
+		}
+
+		visited[pageURL.String()] = true
+
+		var page T
+
+		header, err := makeGETRequest(ctx, pageURL.String(), username, password, config, &page)
+		if err != nil {
+			return err
+		}
+
+		addPage(page)
+
+		pageURL, err = getNextPageURL(pageURL, header)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getNextPageURL returns the rel="next" link from the response header resolved against the URL of
+// the current page, or nil if this was the last page.
+func getNextPageURL(pageURL *url.URL, header http.Header) (*url.URL, error) {
+	for _, link := range header.Values("Link") {
+		for _, entry := range strings.Split(link, ",") {
+			target, params, found := strings.Cut(entry, ";")
+			if !found || !strings.Contains(strings.ReplaceAll(params, " ", ""), `rel="next"`) {
+				continue
+			}
+
+			target = strings.TrimSpace(target)
+			target = strings.TrimPrefix(target, "<")
+			target = strings.TrimSuffix(target, ">")
+
+			nextURL, err := url.Parse(target)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid pagination link '%s'", zerr.ErrParsingHTTPHeader, link)
+			}
+
+			return pageURL.ResolveReference(nextURL), nil
+		}
+	}
+
+	return nil, nil
+}
+
 func validateURL(str string) error {
 	parsedURL, err := url.Parse(str)
 	if err != nil {
@@ -157,6 +641,15 @@ func validateURL(str string) error {
 		return err
 	}
 
+	if parsedURL.Scheme == unixSocketScheme {
+		if parsedURL.Host != "" || parsedURL.Path == "" {
+			return fmt.Errorf("%w: the unix socket should be an absolute path (ex: unix:///var/run/zot.sock)",
+				zerr.ErrInvalidURL)
+		}
+
+		return nil
+	}
+
 	if parsedURL.Scheme == "" || parsedURL.Host == "" {
 		return fmt.Errorf("%w: scheme not provided (ex: https://)", zerr.ErrInvalidURL)
 	}
@@ -164,11 +657,48 @@ func validateURL(str string) error {
 	return nil
 }
 
+// splitUnixSocketURL returns the url the requests to the registry are made with, and the unix socket
+// they're sent through for a unix:// url, e.g. unix:///var/run/zot.sock.
+func splitUnixSocketURL(serverURL string) (string, string) {
+	socket, found := strings.CutPrefix(serverURL, unixSocketScheme+"://")
+	if !found {
+		return serverURL, ""
+	}
+
+	return "http://" + unixSocketHost, socket
+}
+
+// registryURL is the url given by the user, it tells the registries reached through unix sockets apart.
+func (config SearchConfig) registryURL() string {
+	if config.Socket != "" {
+		return unixSocketScheme + "://" + config.Socket
+	}
+
+	return config.ServURL
+}
+
+// parseProxyURL parses the url of a proxy, which can be an http, https or socks5 proxy.
+// The url isn't part of the error since it may hold the credentials of the proxy.
+func parseProxyURL(proxy string) (*url.URL, error) {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Host == "" || !slices.Contains(proxySchemes, proxyURL.Scheme) {
+		return nil, fmt.Errorf("%w: the proxy should be an http://, https:// or socks5:// url", zerr.ErrInvalidURL)
+	}
+
+	return proxyURL, nil
+}
+
 type requestsPool struct {
-	jobs     chan *httpJob
-	done     chan struct{}
-	wtgrp    *sync.WaitGroup
-	outputCh chan stringResult
+	jobs      chan *httpJob
+	done      chan struct{}
+	wtgrp     *sync.WaitGroup
+	outputCh  chan stringResult
+	rateLimit time.Duration
+	// a job has to take a slot before starting, so at most cap(slots) jobs run at the same time
+	slots    chan struct{}
+	progress *progressReporter
+	// if set, the jobs send the fetched images on it instead of their text representation
+	imagesCh chan imageStruct
 }
 
 type httpJob struct {
@@ -177,75 +707,150 @@ type httpJob struct {
 	password  string
 	imageName string
 	tagName   string
+	digest    string
+	labels    map[string]string
 	config    SearchConfig
+	// set with the digest if the tags list returned them, the manifest isn't checked with a HEAD request then
+	mediaType string
+
+	// if set, the image is only shown if the tag points at this digest
+	matchDigest string
 }
 
-const rateLimiterBuffer = 5000
+const (
+	rateLimiterBuffer = 5000
 
-func newSmoothRateLimiter(wtgrp *sync.WaitGroup, opch chan stringResult) *requestsPool {
+	defaultRequestsPerSecond     = 10
+	defaultMaxConcurrentRequests = 10
+)
+
+func newSmoothRateLimiter(wtgrp *sync.WaitGroup, opch chan stringResult, config SearchConfig) *requestsPool {
 	ch := make(chan *httpJob, rateLimiterBuffer)
 
+	requestsPerSecond := config.RequestsPerSecond
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = defaultRequestsPerSecond
+	}
+
+	maxConcurrentRequests := config.MaxConcurrentRequests
+	if maxConcurrentRequests <= 0 {
+		maxConcurrentRequests = defaultMaxConcurrentRequests
+	}
+
 	return &requestsPool{
-		jobs:     ch,
-		done:     make(chan struct{}),
-		wtgrp:    wtgrp,
-		outputCh: opch,
+		jobs:      ch,
+		done:      make(chan struct{}),
+		wtgrp:     wtgrp,
+		outputCh:  opch,
+		rateLimit: time.Second / time.Duration(requestsPerSecond),
+		slots:     make(chan struct{}, maxConcurrentRequests),
+		progress:  config.Progress,
 	}
 }
 
-// block every "rateLimit" time duration.
-const rateLimit = 100 * time.Millisecond
-
+// start a job every "rateLimit" time duration, as long as there is a free slot.
+// Once the context is canceled, the jobs left are dropped right away instead of at the throttle pace.
 func (p *requestsPool) startRateLimiter(ctx context.Context) {
 	p.wtgrp.Done()
 
-	throttle := time.NewTicker(rateLimit).C
+	throttle := time.NewTicker(p.rateLimit)
+	defer throttle.Stop()
 
 	for {
 		select {
 		case job := <-p.jobs:
-			go p.doJob(ctx, job)
+			if !p.takeSlot(ctx) {
+				p.wtgrp.Done()
+
+				continue
+			}
+
+			go func() {
+				defer func() { <-p.slots }()
+
+				p.doJob(ctx, job)
+			}()
 		case <-p.done:
 			return
 		}
-		<-throttle
+
+		select {
+		case <-throttle.C:
+		case <-ctx.Done():
+		}
+	}
+}
+
+func (p *requestsPool) takeSlot(ctx context.Context) bool {
+	if common.IsContextDone(ctx) {
+		return false
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
 	}
 }
 
 func (p *requestsPool) doJob(ctx context.Context, job *httpJob) {
 	defer p.wtgrp.Done()
 
+	imageName := getRegistryRepoName(job.config, job.imageName) + ":" + job.tagName
+
+	mediaType := job.mediaType
+
 	// Check manifest media type
-	header, err := makeHEADRequest(ctx, job.url, job.username, job.password, job.config.VerifyTLS,
-		job.config.Debug)
-	if err != nil {
-		if common.IsContextDone(ctx) {
+	if mediaType == "" {
+		header, err := makeHEADRequest(ctx, job.url, job.username, job.password, job.config)
+		if err != nil {
+			if common.IsContextDone(ctx) {
+				return
+			}
+			p.sendResult(ctx, stringResult{"", newImageError(imageName, err)})
+
 			return
 		}
-		p.outputCh <- stringResult{"", err}
+
+		mediaType = header.Get("Content-Type")
+		job.digest = header.Get(constants.DistContentDigestKey)
 	}
 
 	verbose := job.config.Verbose
 
-	switch header.Get("Content-Type") {
-	case ispec.MediaTypeImageManifest:
-		image, err := fetchImageManifestStruct(ctx, job)
+	if job.matchDigest != "" && job.digest != job.matchDigest {
+		return
+	}
+
+	switch mediaType {
+	case ispec.MediaTypeImageManifest, schema2.MediaTypeManifest:
+		image, err := fetchImageManifestStruct(ctx, job, mediaType)
 		if err != nil {
-			if common.IsContextDone(ctx) {
+			if common.IsContextDone(ctx) || errors.Is(err, zerr.ErrImageLabelsMismatch) {
 				return
 			}
-			p.outputCh <- stringResult{"", err}
+			p.sendResult(ctx, stringResult{"", newImageError(imageName, err)})
 
 			return
 		}
+
+		if p.imagesCh != nil {
+			p.sendImage(ctx, image)
+
+			return
+		}
+
 		platformStr := getPlatformStr(image.Manifests[0].Platform)
+		image.RepoName = getRegistryRepoName(job.config, image.RepoName)
 
-		str, err := image.string(job.config.OutputFormat, len(job.imageName), len(job.tagName), len(platformStr), verbose)
+		str, err := image.string(job.config.OutputFormat, len(image.RepoName), len(job.tagName), len(platformStr), verbose,
+			getImageLayout(job.config))
 		if err != nil {
 			if common.IsContextDone(ctx) {
 				return
 			}
-			p.outputCh <- stringResult{"", err}
+			p.sendResult(ctx, stringResult{"", err})
 
 			return
 		}
@@ -254,26 +859,34 @@ func (p *requestsPool) doJob(ctx context.Context, job *httpJob) {
 			return
 		}
 
-		p.outputCh <- stringResult{str, nil}
-	case ispec.MediaTypeImageIndex:
-		image, err := fetchImageIndexStruct(ctx, job)
+		p.sendResult(ctx, stringResult{str, nil})
+	case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
+		image, err := fetchImageIndexStruct(ctx, job, mediaType)
 		if err != nil {
-			if common.IsContextDone(ctx) {
+			if common.IsContextDone(ctx) || errors.Is(err, zerr.ErrImageLabelsMismatch) {
 				return
 			}
-			p.outputCh <- stringResult{"", err}
+			p.sendResult(ctx, stringResult{"", newImageError(imageName, err)})
+
+			return
+		}
+
+		if p.imagesCh != nil {
+			p.sendImage(ctx, image)
 
 			return
 		}
 
 		platformStr := getPlatformStr(image.Manifests[0].Platform)
+		image.RepoName = getRegistryRepoName(job.config, image.RepoName)
 
-		str, err := image.string(job.config.OutputFormat, len(job.imageName), len(job.tagName), len(platformStr), verbose)
+		str, err := image.string(job.config.OutputFormat, len(image.RepoName), len(job.tagName), len(platformStr), verbose,
+			getImageLayout(job.config))
 		if err != nil {
 			if common.IsContextDone(ctx) {
 				return
 			}
-			p.outputCh <- stringResult{"", err}
+			p.sendResult(ctx, stringResult{"", err})
 
 			return
 		}
@@ -282,17 +895,16 @@ func (p *requestsPool) doJob(ctx context.Context, job *httpJob) {
 			return
 		}
 
-		p.outputCh <- stringResult{str, nil}
+		p.sendResult(ctx, stringResult{str, nil})
 	default:
 		return
 	}
 }
 
-func fetchImageIndexStruct(ctx context.Context, job *httpJob) (*imageStruct, error) {
+func fetchImageIndexStruct(ctx context.Context, job *httpJob, mediaType string) (*imageStruct, error) {
 	var indexContent ispec.Index
 
-	header, err := makeGETRequest(ctx, job.url, job.username, job.password,
-		job.config.VerifyTLS, job.config.Debug, &indexContent, job.config.ResultWriter)
+	header, err := makeManifestGETRequest(ctx, job.url, job.username, job.password, job.config, &indexContent)
 	if err != nil {
 		if common.IsContextDone(ctx) {
 			return nil, context.Canceled
@@ -310,17 +922,38 @@ func fetchImageIndexStruct(ctx context.Context, job *httpJob) (*imageStruct, err
 
 	imageSize := indexSize
 
+	// an index is as recent as its most recent manifest
+	var lastUpdated time.Time
+
 	manifestList := make([]common.ManifestSummary, 0, len(indexContent.Manifests))
 
+	// an index matches the label selectors if its own annotations do,
+	// or if any of its manifests do
+	labelsMatch := matchesLabels(indexContent.Annotations, job.labels)
+
+	// the index authors, else the ones of its first manifest which has any
+	author := indexContent.Annotations[ispec.AnnotationAuthors]
+
 	for _, manifestDescriptor := range indexContent.Manifests {
-		manifest, err := fetchManifestStruct(ctx, job.imageName, manifestDescriptor.Digest.String(),
+		manifestEntry, err := fetchManifestStruct(ctx, job.imageName, manifestDescriptor.Digest.String(),
 			job.config, job.username, job.password)
 		if err != nil {
 			return nil, err
 		}
 
+		manifest := manifestEntry.Manifest
+		labelsMatch = labelsMatch || matchesLabels(mergeLabels(indexContent.Annotations, manifestEntry.Labels), job.labels)
+
+		if author == "" {
+			author = manifestEntry.Author
+		}
+
 		imageSize += int64(atoiWithDefault(manifest.Size, 0))
 
+		if manifest.LastUpdated.After(lastUpdated) {
+			lastUpdated = manifest.LastUpdated
+		}
+
 		if manifestDescriptor.Platform != nil {
 			manifest.Platform = common.Platform{
 				Os:      manifestDescriptor.Platform.OS,
@@ -332,17 +965,24 @@ func fetchImageIndexStruct(ctx context.Context, job *httpJob) (*imageStruct, err
 		manifestList = append(manifestList, manifest)
 	}
 
-	isIndexSigned := isCosignSigned(ctx, job.imageName, indexDigest, job.config, job.username, job.password) ||
-		isNotationSigned(ctx, job.imageName, indexDigest, job.config, job.username, job.password)
+	if !labelsMatch {
+		return nil, zerr.ErrImageLabelsMismatch
+	}
+
+	isIndexSigned, indexSignatures := getSignatureStatus(ctx, job.imageName, indexDigest, job.config,
+		job.username, job.password)
 
 	return &imageStruct{
-		RepoName:  job.imageName,
-		Tag:       job.tagName,
-		Digest:    indexDigest,
-		MediaType: ispec.MediaTypeImageIndex,
-		Manifests: manifestList,
-		Size:      strconv.FormatInt(imageSize, 10),
-		IsSigned:  isIndexSigned,
+		RepoName:      job.imageName,
+		Tag:           job.tagName,
+		Digest:        indexDigest,
+		MediaType:     mediaType,
+		Manifests:     manifestList,
+		Size:          strconv.FormatInt(imageSize, 10),
+		IsSigned:      isIndexSigned,
+		SignatureInfo: indexSignatures,
+		LastUpdated:   lastUpdated,
+		Authors:       author,
 	}, nil
 }
 
@@ -355,41 +995,66 @@ func atoiWithDefault(size string, defaultVal int) int {
 	return val
 }
 
-func fetchImageManifestStruct(ctx context.Context, job *httpJob) (*imageStruct, error) {
-	manifest, err := fetchManifestStruct(ctx, job.imageName, job.tagName, job.config, job.username, job.password)
+func fetchImageManifestStruct(ctx context.Context, job *httpJob, mediaType string) (*imageStruct, error) {
+	// prefer the digest so the manifest can be served from the cache
+	reference := job.tagName
+	if job.digest != "" {
+		reference = job.digest
+	}
+
+	manifestEntry, err := fetchManifestStruct(ctx, job.imageName, reference, job.config,
+		job.username, job.password)
 	if err != nil {
 		return nil, err
 	}
 
+	if !matchesLabels(manifestEntry.Labels, job.labels) {
+		return nil, zerr.ErrImageLabelsMismatch
+	}
+
+	manifest := manifestEntry.Manifest
+
 	return &imageStruct{
 		RepoName:  job.imageName,
 		Tag:       job.tagName,
 		Digest:    manifest.Digest,
-		MediaType: ispec.MediaTypeImageManifest,
+		MediaType: mediaType,
 		Manifests: []common.ManifestSummary{
 			manifest,
 		},
-		Size:     manifest.Size,
-		IsSigned: manifest.IsSigned,
+		Size:          manifest.Size,
+		IsSigned:      manifest.IsSigned,
+		SignatureInfo: manifest.SignatureInfo,
+		LastUpdated:   manifest.LastUpdated,
+		Authors:       manifestEntry.Author,
 	}, nil
 }
 
+// fetchManifestStruct also returns the labels of the image: the config labels
+// merged with the manifest annotations, the latter taking precedence, and its author:
+// the authors annotation or label, else the config author.
 func fetchManifestStruct(ctx context.Context, repo, manifestReference string, searchConf SearchConfig,
 	username, password string,
-) (common.ManifestSummary, error) {
+) (manifestCacheEntry, error) {
+	if entry, found := searchConf.Cache.get(searchConf.registryURL(), repo, manifestReference); found {
+		entry.Manifest.IsSigned, entry.Manifest.SignatureInfo = getSignatureStatus(ctx, repo, entry.Manifest.Digest,
+			searchConf, username, password)
+
+		return entry, nil
+	}
+
 	manifestResp := ispec.Manifest{}
 
 	URL := fmt.Sprintf("%s/v2/%s/manifests/%s",
 		searchConf.ServURL, repo, manifestReference)
 
-	header, err := makeGETRequest(ctx, URL, username, password,
-		searchConf.VerifyTLS, searchConf.Debug, &manifestResp, searchConf.ResultWriter)
+	header, err := makeManifestGETRequest(ctx, URL, username, password, searchConf, &manifestResp)
 	if err != nil {
 		if common.IsContextDone(ctx) {
-			return common.ManifestSummary{}, context.Canceled
+			return manifestCacheEntry{}, context.Canceled
 		}
 
-		return common.ManifestSummary{}, err
+		return manifestCacheEntry{}, err
 	}
 
 	manifestDigest := header.Get("docker-content-digest")
@@ -398,10 +1063,10 @@ func fetchManifestStruct(ctx context.Context, repo, manifestReference string, se
 	configContent, err := fetchConfig(ctx, repo, configDigest, searchConf, username, password)
 	if err != nil {
 		if common.IsContextDone(ctx) {
-			return common.ManifestSummary{}, context.Canceled
+			return manifestCacheEntry{}, context.Canceled
 		}
 
-		return common.ManifestSummary{}, err
+		return manifestCacheEntry{}, err
 	}
 
 	opSys := ""
@@ -428,7 +1093,7 @@ func fetchManifestStruct(ctx context.Context, repo, manifestReference string, se
 
 	manifestSize, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
 	if err != nil {
-		return common.ManifestSummary{}, err
+		return manifestCacheEntry{}, err
 	}
 
 	var imageSize int64
@@ -450,17 +1115,34 @@ func fetchManifestStruct(ctx context.Context, repo, manifestReference string, se
 		)
 	}
 
-	isSigned := isCosignSigned(ctx, repo, manifestDigest, searchConf, username, password) ||
-		isNotationSigned(ctx, repo, manifestDigest, searchConf, username, password)
+	isSigned, signatures := getSignatureStatus(ctx, repo, manifestDigest, searchConf, username, password)
 
-	return common.ManifestSummary{
-		ConfigDigest: configDigest,
-		Digest:       manifestDigest,
-		Layers:       layers,
-		Platform:     common.Platform{Os: opSys, Arch: arch, Variant: variant},
-		Size:         strconv.FormatInt(imageSize, 10),
-		IsSigned:     isSigned,
-	}, nil
+	manifestSummary := common.ManifestSummary{
+		ConfigDigest:  configDigest,
+		Digest:        manifestDigest,
+		Layers:        layers,
+		Platform:      common.Platform{Os: opSys, Arch: arch, Variant: variant},
+		Size:          strconv.FormatInt(imageSize, 10),
+		IsSigned:      isSigned,
+		SignatureInfo: signatures,
+	}
+
+	if configContent.Created != nil {
+		manifestSummary.LastUpdated = *configContent.Created
+	}
+	labels := mergeLabels(configContent.Config.Labels, manifestResp.Annotations)
+
+	author := labels[ispec.AnnotationAuthors]
+	if author == "" {
+		author = configContent.Author
+	}
+
+	entry := manifestCacheEntry{Manifest: manifestSummary, Labels: labels, Author: author}
+
+	// the cache is only an optimization, failing to update it doesn't affect the result
+	_ = searchConf.Cache.put(searchConf.registryURL(), repo, entry)
+
+	return entry, nil
 }
 
 func fetchConfig(ctx context.Context, repo, configDigest string, searchConf SearchConfig,
@@ -471,8 +1153,7 @@ func fetchConfig(ctx context.Context, repo, configDigest string, searchConf Sear
 	URL := fmt.Sprintf("%s/v2/%s/blobs/%s",
 		searchConf.ServURL, repo, configDigest)
 
-	_, err := makeGETRequest(ctx, URL, username, password,
-		searchConf.VerifyTLS, searchConf.Debug, &configContent, searchConf.ResultWriter)
+	_, err := makeGETRequest(ctx, URL, username, password, searchConf, &configContent)
 	if err != nil {
 		if common.IsContextDone(ctx) {
 			return ispec.Image{}, context.Canceled
@@ -492,8 +1173,7 @@ func isNotationSigned(ctx context.Context, repo, digestStr string, searchConf Se
 	URL := fmt.Sprintf("%s/v2/%s/referrers/%s?artifactType=%s",
 		searchConf.ServURL, repo, digestStr, common.ArtifactTypeNotation)
 
-	_, err := makeGETRequest(ctx, URL, username, password,
-		searchConf.VerifyTLS, searchConf.Debug, &referrers, searchConf.ResultWriter)
+	_, err := makeGETRequest(ctx, URL, username, password, searchConf, &referrers)
 	if err != nil {
 		return false
 	}
@@ -513,8 +1193,7 @@ func isCosignSigned(ctx context.Context, repo, digestStr string, searchConf Sear
 
 	URL := fmt.Sprintf("%s/v2/%s/manifests/%s", searchConf.ServURL, repo, cosignTag)
 
-	_, err := makeGETRequest(ctx, URL, username, password, searchConf.VerifyTLS,
-		searchConf.Debug, &result, searchConf.ResultWriter)
+	_, err := makeGETRequest(ctx, URL, username, password, searchConf, &result)
 
 	if err == nil {
 		return true
@@ -526,8 +1205,7 @@ func isCosignSigned(ctx context.Context, repo, digestStr string, searchConf Sear
 	URL = fmt.Sprintf("%s/v2/%s/referrers/%s?artifactType=%s",
 		searchConf.ServURL, repo, digestStr, artifactType)
 
-	_, err = makeGETRequest(ctx, URL, username, password, searchConf.VerifyTLS,
-		searchConf.Debug, &referrers, searchConf.ResultWriter)
+	_, err = makeGETRequest(ctx, URL, username, password, searchConf, &referrers)
 	if err != nil {
 		return false
 	}
@@ -542,3 +1220,49 @@ func isCosignSigned(ctx context.Context, repo, digestStr string, searchConf Sear
 func (p *requestsPool) submitJob(job *httpJob) {
 	p.jobs <- job
 }
+
+func (p *requestsPool) sendImage(ctx context.Context, image *imageStruct) {
+	p.progress.addManifests(1)
+
+	select {
+	case p.imagesCh <- *image:
+	case <-ctx.Done():
+	}
+}
+
+func (p *requestsPool) sendResult(ctx context.Context, result stringResult) {
+	if result.Err != nil {
+		p.progress.addErrors(1)
+	} else {
+		p.progress.addManifests(1)
+	}
+
+	select {
+	case p.outputCh <- result:
+	case <-ctx.Done():
+	}
+}
+
+func mergeLabels(labelMaps ...map[string]string) map[string]string {
+	merged := map[string]string{}
+
+	for _, labels := range labelMaps {
+		for key, value := range labels {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
+
+// matchesLabels returns true if all the selectors are found in labels, no selectors match everything.
+func matchesLabels(labels, selectors map[string]string) bool {
+	for key, value := range selectors {
+		labelValue, ok := labels[key]
+		if !ok || labelValue != value {
+			return false
+		}
+	}
+
+	return true
+}
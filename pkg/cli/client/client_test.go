@@ -7,21 +7,28 @@ import (
 	"bytes"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"gopkg.in/resty.v1"
 
+	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api"
 	"zotregistry.dev/zot/pkg/api/config"
 	"zotregistry.dev/zot/pkg/api/constants"
 	"zotregistry.dev/zot/pkg/cli/client"
 	extConf "zotregistry.dev/zot/pkg/extensions/config"
 	test "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
 )
 
 const (
@@ -227,6 +234,177 @@ func TestTLSBadCerts(t *testing.T) {
 	})
 }
 
+func TestProxy(t *testing.T) {
+	Convey("Requests sent through a proxy", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(conf.HTTP.Port)
+		defer cm.StopServer()
+
+		err := UploadImage(CreateRandomImage(), baseURL, "repo", "1.0")
+		So(err, ShouldBeNil)
+
+		var proxied atomic.Int32
+
+		// a forward proxy receives the full url of the requests
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied.Add(1)
+
+			req, err := http.NewRequestWithContext(r.Context(), r.Method, r.URL.String(), r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+
+				return
+			}
+
+			req.Header = r.Header.Clone()
+
+			resp, err := (&http.Transport{}).RoundTrip(req)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+
+				return
+			}
+			defer resp.Body.Close()
+
+			for key, values := range resp.Header {
+				w.Header()[key] = values
+			}
+
+			w.WriteHeader(resp.StatusCode)
+			_, _ = io.Copy(w, resp.Body)
+		}))
+		defer proxy.Close()
+
+		// nothing listens on this port
+		unreachableProxy := "http://127.0.0.1:1"
+
+		runImageCommand := func(args ...string) (string, error) {
+			cmd := client.NewImageCommand(client.NewSearchService())
+			buff := bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs(append(args, "--retries", "0"))
+			err := cmd.Execute()
+
+			return buff.String(), err
+		}
+
+		Convey("given with --proxy", func() {
+			output, err := runImageCommand("list", "--url", baseURL, "--proxy", proxy.URL)
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "repo")
+			So(proxied.Load(), ShouldBeGreaterThan, 0)
+
+			_, err = runImageCommand("list", "--url", baseURL, "--proxy", unreachableProxy)
+			So(err, ShouldNotBeNil)
+
+			_, err = runImageCommand("list", "--url", baseURL, "--proxy", "ftp://127.0.0.1:21")
+			So(errors.Is(err, zerr.ErrInvalidURL), ShouldBeTrue)
+
+			_, err = runImageCommand("list", "--url", baseURL, "--proxy", "127.0.0.1:3128")
+			So(errors.Is(err, zerr.ErrInvalidURL), ShouldBeTrue)
+		})
+
+		Convey("set in the config of the registry", func() {
+			configPath := makeConfigFile(fmt.Sprintf(`{"configs":[
+				{"_name":"proxied","url":"%s","proxy":"%s","showspinner":false},
+				{"_name":"unreachable","url":"http://localhost:%s","proxy":"%s","showspinner":false}]}`,
+				baseURL, proxy.URL, port, unreachableProxy))
+			defer os.Remove(configPath)
+
+			output, err := runImageCommand("list", "--config", "proxied")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "repo")
+			So(proxied.Load(), ShouldBeGreaterThan, 0)
+
+			_, err = runImageCommand("list", "--config", "unreachable")
+			So(err, ShouldNotBeNil)
+
+			// --proxy replaces the one of the config
+			_, err = runImageCommand("list", "--config", "unreachable", "--proxy", proxy.URL)
+			So(err, ShouldBeNil)
+
+			// each registry is reached through its own proxy
+			output, err = runImageCommand("list", "--config", "proxied,unreachable")
+			So(errors.Is(err, zerr.ErrRegistrySearchFailed), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "unreachable: ")
+			So(err.Error(), ShouldNotContainSubstring, "proxied: ")
+			So(output, ShouldContainSubstring, "proxied/repo")
+		})
+	})
+}
+
+func TestUnixSocketURL(t *testing.T) {
+	Convey("A registry reached through a unix socket", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		socket := path.Join(t.TempDir(), "zot.sock")
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Socket = socket
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(conf.HTTP.Port)
+		defer cm.StopServer()
+
+		err := UploadImage(CreateRandomImage(), baseURL, "repo", "1.0")
+		So(err, ShouldBeNil)
+
+		runImageCommand := func(args ...string) (string, error) {
+			cmd := client.NewImageCommand(client.NewSearchService())
+			buff := bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs(args)
+			err := cmd.Execute()
+
+			return buff.String(), err
+		}
+
+		output, err := runImageCommand("list", "--url", "unix://"+socket)
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "repo")
+		So(output, ShouldContainSubstring, "1.0")
+
+		// the proxies don't apply to the socket
+		output, err = runImageCommand("list", "--url", "unix://"+socket, "--proxy", "http://127.0.0.1:1")
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "repo")
+
+		// the registry is named after its socket
+		output, err = runImageCommand("list", "--url", "unix://"+socket, "--url", baseURL)
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "zot.sock/repo")
+		So(output, ShouldContainSubstring, "127.0.0.1:"+port+"/repo")
+
+		configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"local","url":"unix://%s","showspinner":false}]}`,
+			socket))
+		defer os.Remove(configPath)
+
+		output, err = runImageCommand("list", "--config", "local")
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "repo")
+
+		for _, badURL := range []string{"unix://", "unix://zot.sock"} {
+			_, err = runImageCommand("list", "--url", badURL, "--url", baseURL)
+			So(errors.Is(err, zerr.ErrInvalidURL), ShouldBeTrue)
+		}
+
+		_, err = runImageCommand("list", "--url", "unix://"+path.Join(t.TempDir(), "missing.sock"), "--retries", "0")
+		So(err, ShouldNotBeNil)
+	})
+}
+
 func makeConfigFile(content string) string {
 	os.Setenv("HOME", os.TempDir())
 
@@ -0,0 +1,162 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	"github.com/spf13/cobra"
+)
+
+const (
+	completionCacheDirName = "completions"
+	// the completions are fetched while the user waits at the prompt, and are reused for a short while
+	completionTimeout  = 2 * time.Second
+	completionCacheTTL = 30 * time.Second
+)
+
+type completionCacheEntry struct {
+	Created time.Time `json:"created"`
+	Values  []string  `json:"values"`
+}
+
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completeImageArgs completes the first maxArgs arguments of the command with the repositories of the
+// registry, then once the repo is followed by a colon, with its tags.
+func completeImageArgs(searchService SearchService, maxArgs int) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= maxArgs {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		config, err := GetSearchConfigFromFlags(cmd, searchService)
+		if err != nil || len(config.Registries) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		config.Spinner.enabled = false
+		config.Progress = nil
+		config.Retries = 0
+		config.Timeout = completionTimeout
+		config.RequestTimeout = completionTimeout
+
+		completions := []string{}
+		repo, tagPrefix, hasTag := strings.Cut(toComplete, ":")
+
+		if !hasTag {
+			repos, err := getCompletionValues(config, "catalog",
+				func(ctx context.Context, username, password string) ([]string, error) {
+					catalog, err := getCatalog(ctx, config, username, password)
+					if err != nil {
+						return nil, err
+					}
+
+					return catalog.Repositories, nil
+				})
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			for _, repo := range repos {
+				if strings.HasPrefix(repo, toComplete) {
+					completions = append(completions, repo+":")
+				}
+			}
+
+			// the tag is completed next
+			return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+		}
+
+		tags, err := getCompletionValues(config, "tags/"+repo,
+			func(ctx context.Context, username, password string) ([]string, error) {
+				return config.SearchService.getTags(ctx, config, username, password, repo)
+			})
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		for _, tag := range tags {
+			if strings.HasPrefix(tag, tagPrefix) {
+				completions = append(completions, repo+":"+tag)
+			}
+		}
+
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// getCompletionValues returns the values cached for the registry and the user if they're recent enough,
+// else fetches them and caches them. Nothing is cached with --no-cache.
+func getCompletionValues(config SearchConfig, key string,
+	fetch func(ctx context.Context, username, password string) ([]string, error),
+) ([]string, error) {
+	username, password := getUsernameAndPassword(config.User)
+
+	var entryPath string
+
+	if config.Cache != nil {
+		entryKey := godigest.FromString(config.ServURL + "/" + username + "/" + key)
+		entryPath = path.Join(config.Cache.rootDir, completionCacheDirName, entryKey.Encoded()+".json")
+
+		if content, err := os.ReadFile(entryPath); err == nil {
+			entry := completionCacheEntry{}
+
+			if err := json.Unmarshal(content, &entry); err == nil && time.Since(entry.Created) < completionCacheTTL {
+				return entry.Values, nil
+			}
+		}
+	}
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	values, err := fetch(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if entryPath != "" {
+		if content, err := json.Marshal(completionCacheEntry{Created: time.Now(), Values: values}); err == nil {
+			// the completions are still shown if they can't be cached
+			_ = writeCacheFile(entryPath, content)
+		}
+	}
+
+	return values, nil
+}
+
+// completeConfigNames completes --config with the names of the registry configurations.
+func completeConfigNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	configs, err := loadConfigs(path.Join(home, "/.zot"))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := []string{}
+
+	for _, config := range configs {
+		configMap, ok := config.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if name, ok := configMap[nameKey].(string); ok && strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
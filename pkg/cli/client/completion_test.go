@@ -0,0 +1,136 @@
+//go:build search
+// +build search
+
+package client_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/cli/client"
+	test "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+func TestCompletion(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	for _, image := range []string{"app:1.0", "app:2.0", "lib:1.0"} {
+		repo, tag, _ := strings.Cut(image, ":")
+
+		if err := UploadImage(CreateRandomImage(), baseURL, repo, tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"main","url":"%s","showspinner":false},`+
+		`{"_name":"other","url":"%s","showspinner":false}]}`, baseURL, baseURL))
+	defer os.Remove(configPath)
+
+	runZli := func(args ...string) (string, error) {
+		cmd := client.NewCliRootCmd()
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(bytes.NewBufferString(""))
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	Convey("Test shell completion", t, func() {
+		Convey("completion scripts", func() {
+			output, err := runZli("completion", "bash")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "bash completion V2 for zli")
+
+			output, err = runZli("completion", "zsh")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "#compdef zli")
+
+			output, err = runZli("completion", "fish")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "complete -c zli")
+		})
+
+		Convey("repos then tags", func() {
+			output, err := runZli("__complete", "image", "inspect", "--config", "main", "")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "app:\nlib:\n:6\n")
+
+			output, err = runZli("__complete", "image", "inspect", "--config", "main", "ap")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "app:\n:6\n")
+
+			output, err = runZli("__complete", "image", "inspect", "--config", "main", "app:")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "app:1.0\napp:2.0\n:4\n")
+
+			output, err = runZli("__complete", "image", "inspect", "--config", "main", "app:2")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "app:2.0\n:4\n")
+		})
+
+		Convey("only the image arguments", func() {
+			output, err := runZli("__complete", "image", "inspect", "--config", "main", "app:1.0", "")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, ":4\n")
+
+			output, err = runZli("__complete", "image", "diff", "--config", "main", "app:1.0", "lib:")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "lib:1.0\n:4\n")
+		})
+
+		Convey("the completions are cached", func() {
+			_, err := runZli("__complete", "sbom", "get", "--url", baseURL, "")
+			So(err, ShouldBeNil)
+
+			err = UploadImage(CreateRandomImage(), baseURL, "new", "1.0")
+			So(err, ShouldBeNil)
+
+			output, err := runZli("__complete", "sbom", "get", "--url", baseURL, "")
+			So(err, ShouldBeNil)
+			So(output, ShouldNotContainSubstring, "new:")
+
+			output, err = runZli("__complete", "image", "inspect", "--url", baseURL, "--no-cache", "")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "new:")
+		})
+
+		Convey("config names", func() {
+			output, err := runZli("__complete", "image", "list", "--config", "")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "main\nother\n:4\n")
+
+			output, err = runZli("__complete", "repo", "list", "--config", "o")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "other\n:4\n")
+		})
+
+		Convey("no registry", func() {
+			output, err := runZli("__complete", "image", "inspect", "--url", test.GetBaseURL(test.GetFreePort()),
+				"--no-cache", "")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, ":4\n")
+		})
+	})
+}
@@ -32,8 +32,12 @@ func NewConfigCommand() *cobra.Command {
 		Use:     "config <config-name> [variable] [value]",
 		Example: examples,
 		Short:   "Configure zot registry parameters for CLI",
-		Long:    `Configure zot registry parameters for CLI`,
-		Args:    cobra.ArbitraryArgs,
+		Long: `Configure zot registry parameters for CLI, stored in ~/.zot.
+
+The string values of the file can reference environment variables, as ${NAME} or ${NAME:-default},
+and its "include" list can name other config files, relative to it, whose configs are added to its own.
+The file is checked against the config schema before being used, see 'zli config validate'.`,
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			home, err := os.UserHomeDir()
 			if err != nil {
@@ -97,27 +101,46 @@ func NewConfigCommand() *cobra.Command {
 	configCmd.Flags().BoolVar(&isReset, "reset", false, "Reset a variable value")
 	configCmd.SetUsageTemplate(configCmd.UsageTemplate() + supportedOptions)
 	configCmd.AddCommand(NewConfigAddCommand())
+	configCmd.AddCommand(NewConfigListCommand())
 	configCmd.AddCommand(NewConfigRemoveCommand())
+	configCmd.AddCommand(NewConfigSetDefaultCommand())
+	configCmd.AddCommand(NewConfigValidateCommand())
 
 	return configCmd
 }
 
 func NewConfigAddCommand() *cobra.Command {
 	configAddCmd := &cobra.Command{
-		Use:     "add <config-name> <url>",
-		Example: "  zli config add main https://zot-foo.com:8080",
-		Short:   "Add configuration for a zot registry",
-		Long:    "Add configuration for a zot registry",
-		Args:    cobra.ExactArgs(twoArgs),
+		Use: "add <config-name> <url>",
+		Example: `  zli config add main https://zot-foo.com:8080
+  zli config add prod https://zot-prod.com --cacert ca.crt --credentials-env ZOT_PROD_CREDS --format json`,
+		Short: "Add configuration for a zot registry",
+		Long: `Add configuration for a zot registry, the connection options given here are used
+by the commands run with '--config <config-name>'`,
+		Args: cobra.ExactArgs(twoArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			home, err := os.UserHomeDir()
 			if err != nil {
 				return err
 			}
 
+			options := map[string]interface{}{}
+
+			if cmd.Flags().Changed(verifyTLSConfig) {
+				options[verifyTLSConfig] = defaultIfError(cmd.Flags().GetBool(verifyTLSConfig))
+			}
+
+			for _, option := range []string{
+				certConfig, keyConfig, caCertConfig, proxyConfig, credentialsEnvConfig, outputConfig,
+			} {
+				if value := defaultIfError(cmd.Flags().GetString(option)); value != "" {
+					options[option] = value
+				}
+			}
+
 			configPath := path.Join(home, "/.zot")
 			// zot config add <config-name> <url>
-			err = addConfig(configPath, args[0], args[1])
+			err = addConfig(configPath, args[0], args[1], options)
 			if err != nil {
 				return err
 			}
@@ -126,12 +149,51 @@ func NewConfigAddCommand() *cobra.Command {
 		},
 	}
 
+	configAddCmd.Flags().Bool(verifyTLSConfig, true, "Verify the TLS certificate of the registry")
+	configAddCmd.Flags().String(certConfig, "", "Client certificate file presented to the registry")
+	configAddCmd.Flags().String(keyConfig, "", "Key file of the client certificate")
+	configAddCmd.Flags().String(caCertConfig, "", "CA certificate file used to verify the registry")
+	configAddCmd.Flags().String(proxyConfig, "", "Proxy the registry is reached through, an http, https or socks5 url")
+	configAddCmd.Flags().String(credentialsEnvConfig, "",
+		"Environment variable holding the registry credentials in username:password format")
+	configAddCmd.Flags().String(outputConfig, "", "Default output format of the commands using this registry")
+
 	// Prevent parent template from overwriting default template
 	configAddCmd.SetUsageTemplate(configAddCmd.UsageTemplate())
 
 	return configAddCmd
 }
 
+func NewConfigListCommand() *cobra.Command {
+	configListCmd := &cobra.Command{
+		Use:     "list",
+		Example: "  zli config list",
+		Short:   "List the configured zot registries",
+		Long:    "List the configured zot registries, the default one is marked with '*'",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+
+			res, err := getConfigNames(path.Join(home, "/.zot"))
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), res)
+
+			return nil
+		},
+	}
+
+	// Prevent parent template from overwriting default template
+	configListCmd.SetUsageTemplate(configListCmd.UsageTemplate())
+
+	return configListCmd
+}
+
 func NewConfigRemoveCommand() *cobra.Command {
 	configRemoveCmd := &cobra.Command{
 		Use:     "remove <config-name>",
@@ -162,6 +224,72 @@ func NewConfigRemoveCommand() *cobra.Command {
 	return configRemoveCmd
 }
 
+func NewConfigSetDefaultCommand() *cobra.Command {
+	configSetDefaultCmd := &cobra.Command{
+		Use:     "set-default <config-name>",
+		Example: "  zli config set-default main",
+		Short:   "Set the zot registry used when neither --url nor --config are given",
+		Long:    "Set the zot registry used when neither --url nor --config are given",
+		Args:    cobra.ExactArgs(oneArg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+
+			// zot config set-default <config-name>
+			return setDefaultConfig(path.Join(home, "/.zot"), args[0])
+		},
+	}
+
+	// Prevent parent template from overwriting default template
+	configSetDefaultCmd.SetUsageTemplate(configSetDefaultCmd.UsageTemplate())
+
+	return configSetDefaultCmd
+}
+
+func NewConfigValidateCommand() *cobra.Command {
+	configValidateCmd := &cobra.Command{
+		Use:     "validate [file]",
+		Example: "  zli config validate\n  zli config validate ./zli-config.json",
+		Short:   "Check a zli config file",
+		Long: `Check a zli config file, by default ~/.zot, and the files it includes against the config schema,
+with its environment variables expanded. The errors give the line and column of the invalid values`,
+		Args: cobra.MaximumNArgs(oneArg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var configPath string
+
+			if len(args) == oneArg {
+				configPath = args[0]
+
+				if _, err := os.Stat(configPath); err != nil {
+					return err
+				}
+			} else {
+				home, err := os.UserHomeDir()
+				if err != nil {
+					return err
+				}
+
+				configPath = path.Join(home, "/.zot")
+			}
+
+			if _, err := loadConfigFile(configPath); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is valid\n", configPath)
+
+			return nil
+		},
+	}
+
+	// Prevent parent template from overwriting default template
+	configValidateCmd.SetUsageTemplate(configValidateCmd.UsageTemplate())
+
+	return configValidateCmd
+}
+
 func getConfigMapFromFile(filePath string) ([]interface{}, error) {
 	file, err := os.OpenFile(filePath, os.O_RDONLY|os.O_CREATE, defaultConfigPerms)
 	if err != nil {
@@ -197,6 +325,16 @@ func saveConfigMapToFile(filePath string, configMap []interface{}) error {
 	json := jsoniter.ConfigCompatibleWithStandardLibrary
 
 	listMap := make(map[string]interface{})
+
+	// keep the other settings of the file, like the default config
+	if data, err := os.ReadFile(filePath); err == nil {
+		_ = json.Unmarshal(data, &listMap)
+	}
+
+	if listMap == nil {
+		listMap = make(map[string]interface{})
+	}
+
 	listMap["configs"] = configMap
 
 	marshalled, err := json.MarshalIndent(&listMap, "", "  ")
@@ -212,7 +350,7 @@ func saveConfigMapToFile(filePath string, configMap []interface{}) error {
 }
 
 func getConfigNames(configPath string) (string, error) {
-	configs, err := getConfigMapFromFile(configPath)
+	configs, err := loadConfigs(configPath)
 	if err != nil {
 		if errors.Is(err, zerr.ErrEmptyJSON) {
 			return "", nil
@@ -221,6 +359,11 @@ func getConfigNames(configPath string) (string, error) {
 		return "", err
 	}
 
+	defaultConfig, err := getDefaultConfig(configPath)
+	if err != nil {
+		return "", err
+	}
+
 	var builder strings.Builder
 
 	writer := tabwriter.NewWriter(&builder, 0, 8, 1, '\t', tabwriter.AlignRight) //nolint:gomnd
@@ -231,6 +374,12 @@ func getConfigNames(configPath string) (string, error) {
 			return "", zerr.ErrBadConfig
 		}
 
+		if defaultConfig != "" && configMap[nameKey] == defaultConfig {
+			fmt.Fprintf(writer, "%s\t%s\t*\n", configMap[nameKey], configMap["url"])
+
+			continue
+		}
+
 		fmt.Fprintf(writer, "%s\t%s\n", configMap[nameKey], configMap["url"])
 	}
 
@@ -242,7 +391,7 @@ func getConfigNames(configPath string) (string, error) {
 	return builder.String(), nil
 }
 
-func addConfig(configPath, configName, url string) error {
+func addConfig(configPath, configName, url string, options map[string]interface{}) error {
 	configs, err := getConfigMapFromFile(configPath)
 	if err != nil && !errors.Is(err, zerr.ErrEmptyJSON) {
 		return err
@@ -257,6 +406,11 @@ func addConfig(configPath, configName, url string) error {
 	}
 
 	configMap := make(map[string]interface{})
+
+	for key, value := range options {
+		configMap[key] = value
+	}
+
 	configMap["url"] = url
 	configMap[nameKey] = configName
 	addDefaultConfigs(configMap)
@@ -296,12 +450,85 @@ func removeConfig(configPath, configName string) error {
 			return err
 		}
 
+		defaultConfig, err := getDefaultConfig(configPath)
+		if err != nil {
+			return err
+		}
+
+		if defaultConfig == configName {
+			return saveDefaultConfig(configPath, "")
+		}
+
 		return nil
 	}
 
 	return zerr.ErrConfigNotFound
 }
 
+// getDefaultConfig returns the name of the config used when none is given, if there is one.
+func getDefaultConfig(configPath string) (string, error) {
+	configFile, err := loadConfigFile(configPath)
+	if err != nil {
+		return "", err
+	}
+
+	if configFile[defaultConfigKey] == nil {
+		return "", nil
+	}
+
+	defaultConfig, ok := configFile[defaultConfigKey].(string)
+	if !ok {
+		return "", zerr.ErrCliBadConfig
+	}
+
+	return defaultConfig, nil
+}
+
+func setDefaultConfig(configPath, configName string) error {
+	configs, err := loadConfigs(configPath)
+	if err != nil {
+		if errors.Is(err, zerr.ErrEmptyJSON) {
+			return zerr.ErrConfigNotFound
+		}
+
+		return err
+	}
+
+	if !configNameExists(configs, configName) {
+		return zerr.ErrConfigNotFound
+	}
+
+	return saveDefaultConfig(configPath, configName)
+}
+
+func saveDefaultConfig(configPath, configName string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	listMap := make(map[string]interface{})
+
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	if err := json.Unmarshal(data, &listMap); err != nil {
+		return err
+	}
+
+	if configName == "" {
+		delete(listMap, defaultConfigKey)
+	} else {
+		listMap[defaultConfigKey] = configName
+	}
+
+	marshalled, err := json.MarshalIndent(&listMap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(configPath, marshalled, defaultFilePerms)
+}
+
 func addDefaultConfigs(config map[string]interface{}) {
 	if _, ok := config[showspinnerConfig]; !ok {
 		config[showspinnerConfig] = true
@@ -313,7 +540,7 @@ func addDefaultConfigs(config map[string]interface{}) {
 }
 
 func getConfigValue(configPath, configName, key string) (string, error) {
-	configs, err := getConfigMapFromFile(configPath)
+	configs, err := loadConfigs(configPath)
 	if err != nil {
 		if errors.Is(err, zerr.ErrEmptyJSON) {
 			return "", zerr.ErrConfigNotFound
@@ -425,7 +652,7 @@ func setConfigValue(configPath, configName, key, value string) error {
 }
 
 func getAllConfig(configPath, configName string) (string, error) {
-	configs, err := getConfigMapFromFile(configPath)
+	configs, err := loadConfigs(configPath)
 	if err != nil {
 		if errors.Is(err, zerr.ErrEmptyJSON) {
 			return "", nil
@@ -481,6 +708,8 @@ const (
   zli config --list
   zli config main url
   zli config main --list
+  zli config set-default main
+  zli config validate
   zli config remove main`
 
 	supportedOptions = `
@@ -488,15 +717,34 @@ Useful variables:
   url		zot server URL
   showspinner	show spinner while loading data [true/false]
   verify-tls	enable TLS certificate verification of the server [default: true]
+  max-concurrent-requests	maximum number of manifests fetched at the same time [default: 10]
+  requests-per-second	maximum number of manifest fetches started per second [default: 10]
+  max-idle-conns-per-host	maximum number of idle connections kept open to the server [default: max-concurrent-requests]
+  cert		client certificate file presented to the server
+  key		key file of the client certificate
+  cacert	CA certificate file used in addition to the system ones to verify the server
+  proxy		http, https or socks5 proxy the server is reached through [default: HTTPS_PROXY/NO_PROXY]
+  credentials-env	environment variable holding the credentials in username:password format
+  format	default output format [text/json/ndjson/yaml/csv/tsv]
 `
 
-	nameKey = "_name"
+	nameKey          = "_name"
+	defaultConfigKey = "default"
 
 	noArgs    = 0
 	oneArg    = 1
 	twoArgs   = 2
 	threeArgs = 3
 
-	showspinnerConfig = "showspinner"
-	verifyTLSConfig   = "verify-tls"
+	showspinnerConfig           = "showspinner"
+	verifyTLSConfig             = "verify-tls"
+	maxConcurrentRequestsConfig = "max-concurrent-requests"
+	requestsPerSecondConfig     = "requests-per-second"
+	maxIdleConnsPerHostConfig   = "max-idle-conns-per-host"
+	certConfig                  = "cert"
+	keyConfig                   = "key"
+	caCertConfig                = "cacert"
+	proxyConfig                 = "proxy"
+	credentialsEnvConfig        = "credentials-env"
+	outputConfig                = "format"
 )
@@ -5,8 +5,10 @@ package client_test
 
 import (
 	"bytes"
+	"fmt"
 	"log"
 	"os"
+	"path"
 	"regexp"
 	"strings"
 	"testing"
@@ -147,7 +149,7 @@ func TestConfigCmdMain(t *testing.T) {
 		cmd.SetErr(buff)
 		cmd.SetArgs(args)
 		err := cmd.Execute()
-		So(err, ShouldEqual, zerr.ErrCliBadConfig)
+		So(err, ShouldWrap, zerr.ErrCliBadConfig)
 	})
 
 	Convey("Test add config with invalid URL", t, func() {
@@ -466,3 +468,224 @@ func TestConfigCmdMain(t *testing.T) {
 		So(buff.String(), ShouldContainSubstring, "cli config name already added")
 	})
 }
+
+func TestConfigCmdProfiles(t *testing.T) {
+	Convey("Test add config with options", t, func() {
+		configPath := makeConfigFile("")
+		defer os.Remove(configPath)
+
+		args := []string{
+			"add", "prod", "https://prod-url.com", "--verify-tls=false",
+			"--cacert", "ca.crt", "--proxy", "http://proxy.example.com:3128", "--credentials-env", "PROD_CREDS",
+			"--format", "json",
+		}
+		cmd := client.NewConfigCommand()
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		So(err, ShouldBeNil)
+
+		actual, err := os.ReadFile(configPath)
+		So(err, ShouldBeNil)
+		actualStr := string(actual)
+		So(actualStr, ShouldContainSubstring, `"verify-tls": false`)
+		So(actualStr, ShouldContainSubstring, `"cacert": "ca.crt"`)
+		So(actualStr, ShouldContainSubstring, `"proxy": "http://proxy.example.com:3128"`)
+		So(actualStr, ShouldContainSubstring, `"credentials-env": "PROD_CREDS"`)
+		So(actualStr, ShouldContainSubstring, `"format": "json"`)
+		So(actualStr, ShouldNotContainSubstring, `"cert"`)
+	})
+
+	Convey("Test the default config", t, func() {
+		configPath := makeConfigFile(`{"configs":[{"_name":"main","url":"https://main-url.com"},` +
+			`{"_name":"prod","url":"https://prod-url.com"}]}`)
+		defer os.Remove(configPath)
+
+		runConfigCmd := func(args ...string) (string, error) {
+			cmd := client.NewConfigCommand()
+			buff := bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs(args)
+			err := cmd.Execute()
+
+			return buff.String(), err
+		}
+
+		_, err := runConfigCmd("set-default", "prod")
+		So(err, ShouldBeNil)
+
+		output, err := runConfigCmd("list")
+		So(err, ShouldBeNil)
+		space := regexp.MustCompile(`\s+`)
+		So(strings.TrimSpace(space.ReplaceAllString(output, " ")), ShouldEqual,
+			"main https://main-url.com prod https://prod-url.com *")
+
+		// the default is kept when the configs change
+		_, err = runConfigCmd("main", "showspinner", "false")
+		So(err, ShouldBeNil)
+
+		actual, err := os.ReadFile(configPath)
+		So(err, ShouldBeNil)
+		So(string(actual), ShouldContainSubstring, `"default": "prod"`)
+
+		output, err = runConfigCmd("set-default", "unknown")
+		So(err, ShouldNotBeNil)
+		So(output, ShouldContainSubstring, zerr.ErrConfigNotFound.Error())
+
+		// removing the default config unsets it
+		_, err = runConfigCmd("remove", "prod")
+		So(err, ShouldBeNil)
+
+		actual, err = os.ReadFile(configPath)
+		So(err, ShouldBeNil)
+		So(string(actual), ShouldNotContainSubstring, `"default"`)
+		So(string(actual), ShouldContainSubstring, `"main"`)
+	})
+}
+
+func TestConfigFile(t *testing.T) {
+	runConfigCmd := func(args ...string) (string, error) {
+		cmd := client.NewConfigCommand()
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	Convey("Test environment variables in the config file", t, func() {
+		t.Setenv("ZLI_TEST_HOST", "zot.example.com")
+
+		configPath := makeConfigFile(`{"configs":[{"_name":"main","url":"https://${ZLI_TEST_HOST}:8080",` +
+			`"format":"${ZLI_TEST_FORMAT:-json}","credentials-env":"PA$$WORD"}]}`)
+		defer os.Remove(configPath)
+
+		output, err := runConfigCmd("main", "url")
+		So(err, ShouldBeNil)
+		So(strings.TrimSpace(output), ShouldEqual, "https://zot.example.com:8080")
+
+		output, err = runConfigCmd("main", "format")
+		So(err, ShouldBeNil)
+		So(strings.TrimSpace(output), ShouldEqual, "json")
+
+		output, err = runConfigCmd("main", "credentials-env")
+		So(err, ShouldBeNil)
+		So(strings.TrimSpace(output), ShouldEqual, "PA$$WORD")
+
+		// the references are kept when the config file changes
+		_, err = runConfigCmd("main", "showspinner", "false")
+		So(err, ShouldBeNil)
+
+		actual, err := os.ReadFile(configPath)
+		So(err, ShouldBeNil)
+		So(string(actual), ShouldContainSubstring, "${ZLI_TEST_HOST}")
+
+		configPath = makeConfigFile("{\n  \"configs\": [\n    {\"_name\": \"main\", \"url\": \"${ZLI_TEST_UNSET}\"}\n  ]\n}")
+
+		_, err = runConfigCmd("main", "url")
+		So(err, ShouldWrap, zerr.ErrCliBadConfig)
+		So(err.Error(), ShouldContainSubstring, configPath+":3:30: environment variable ZLI_TEST_UNSET is not set")
+	})
+
+	Convey("Test the config file is checked against the schema", t, func() {
+		configPath := makeConfigFile(`{
+  "configs": [
+    {
+      "_name": "main",
+      "url": "https://main-url.com",
+      "showspinner": "maybe",
+      "max-concurrent-requests": 5
+    },
+    {
+      "url": "https://prod-url.com"
+    }
+  ]
+}`)
+		defer os.Remove(configPath)
+
+		output, err := runConfigCmd("validate")
+		So(err, ShouldWrap, zerr.ErrCliBadConfig)
+		So(output, ShouldContainSubstring, configPath+":6:22: ")
+		So(output, ShouldContainSubstring, configPath+":9:5: missing properties: '_name'")
+
+		_, err = runConfigCmd("main", "url")
+		So(err, ShouldWrap, zerr.ErrCliBadConfig)
+
+		configPath = makeConfigFile(`{"configs":[{"_name":"main","url":"https://main-url.com","verify_tls":false}]}`)
+
+		output, err = runConfigCmd("validate", configPath)
+		So(err, ShouldNotBeNil)
+		So(output, ShouldContainSubstring, "verify_tls")
+
+		configPath = makeConfigFile("{\n  \"configs\": [\n    {\"_name\": \"main\",}\n  ]\n}")
+
+		output, err = runConfigCmd("validate")
+		So(err, ShouldNotBeNil)
+		So(output, ShouldContainSubstring, configPath+":3:22: ")
+
+		configPath = makeConfigFile(`{"configs":[{"_name":"main","url":"https://main-url.com","showspinner":"false",` +
+			`"max-concurrent-requests":"5"}]}`)
+
+		output, err = runConfigCmd("validate")
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "is valid")
+
+		_, err = runConfigCmd("validate", path.Join(t.TempDir(), "missing.json"))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test included config files", t, func() {
+		dir := t.TempDir()
+
+		err := os.WriteFile(path.Join(dir, "team.json"), []byte(`{"default":"team",`+
+			`"configs":[{"_name":"team","url":"https://team-url.com"},{"_name":"main","url":"https://old-url.com"}]}`),
+			0o600)
+		So(err, ShouldBeNil)
+
+		configPath := makeConfigFile(fmt.Sprintf(`{"include":["%s"],`+
+			`"configs":[{"_name":"main","url":"https://main-url.com"}]}`, path.Join(dir, "team.json")))
+		defer os.Remove(configPath)
+
+		output, err := runConfigCmd("list")
+		So(err, ShouldBeNil)
+		space := regexp.MustCompile(`\s+`)
+		So(strings.TrimSpace(space.ReplaceAllString(output, " ")), ShouldEqual,
+			"team https://team-url.com * main https://main-url.com")
+
+		output, err = runConfigCmd("team", "url")
+		So(err, ShouldBeNil)
+		So(strings.TrimSpace(output), ShouldEqual, "https://team-url.com")
+
+		// the included files are relative to the including one
+		err = os.WriteFile(path.Join(dir, "zli.json"), []byte(`{"include":["team.json"]}`), 0o600)
+		So(err, ShouldBeNil)
+
+		output, err = runConfigCmd("validate", path.Join(dir, "zli.json"))
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "is valid")
+
+		err = os.WriteFile(path.Join(dir, "team.json"), []byte(`{"include":["zli.json"]}`), 0o600)
+		So(err, ShouldBeNil)
+
+		output, err = runConfigCmd("validate", path.Join(dir, "zli.json"))
+		So(err, ShouldNotBeNil)
+		So(output, ShouldContainSubstring, "included in a loop")
+
+		err = os.WriteFile(path.Join(dir, "team.json"), []byte(`{"configs":[{"_name":"team","showspinner":1.5}]}`),
+			0o600)
+		So(err, ShouldBeNil)
+
+		output, err = runConfigCmd("validate", path.Join(dir, "zli.json"))
+		So(err, ShouldNotBeNil)
+		So(output, ShouldContainSubstring, path.Join(dir, "zli.json")+":1:13: failed to include")
+		So(output, ShouldContainSubstring, path.Join(dir, "team.json")+":1:43: expected boolean or string")
+
+		_, err = runConfigCmd("validate", configPath)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,415 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	zerr "zotregistry.dev/zot/errors"
+)
+
+const (
+	includeKey = "include"
+	schemaURL  = "zli-config.json"
+)
+
+//go:embed config_schema.json
+var configSchema string
+
+// envVarRegexp matches the ${NAME} and ${NAME:-default} references expanded in the string values,
+// the other dollar signs, e.g. in passwords, are kept as they are.
+var envVarRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`) //nolint: gochecknoglobals
+
+// configLocation is the position of a value in a config file, reported in the errors.
+type configLocation struct {
+	file   string
+	line   int
+	column int
+}
+
+func (location configLocation) String() string {
+	return fmt.Sprintf("%s:%d:%d", location.file, location.line, location.column)
+}
+
+// loadConfigs returns the registry configs as the commands use them: the included files are merged,
+// the environment variables are expanded and the files are checked against the config schema.
+// The commands changing the config file read it as it is instead, so they never write the expanded values.
+func loadConfigs(configPath string) ([]interface{}, error) {
+	configFile, err := loadConfigFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if configFile["configs"] == nil {
+		return nil, zerr.ErrEmptyJSON
+	}
+
+	configs, ok := configFile["configs"].([]interface{})
+	if !ok {
+		return nil, zerr.ErrCliBadConfig
+	}
+
+	return configs, nil
+}
+
+func loadConfigFile(configPath string) (map[string]interface{}, error) {
+	schema, err := compileConfigSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	return loadIncludedConfigFile(schema, configPath, []string{})
+}
+
+func compileConfigSchema() (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+
+	if err := compiler.AddResource(schemaURL, strings.NewReader(configSchema)); err != nil {
+		return nil, err
+	}
+
+	return compiler.Compile(schemaURL)
+}
+
+// loadIncludedConfigFile loads a config file and the ones it includes, the configs of the file replacing
+// the included ones with the same name. includedBy lists the files including it, to detect the cycles.
+func loadIncludedConfigFile(schema *jsonschema.Schema, configPath string, includedBy []string,
+) (map[string]interface{}, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		// a missing config file is an empty one, unless it's included
+		if errors.Is(err, os.ErrNotExist) && len(includedBy) == 0 {
+			return map[string]interface{}{}, nil
+		}
+
+		return nil, fmt.Errorf("%w: %w", zerr.ErrCliBadConfig, err)
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	configFile, err := parseConfigFile(schema, configPath, data)
+	if err != nil {
+		return nil, err
+	}
+
+	includes, _ := configFile[includeKey].([]interface{})
+	delete(configFile, includeKey)
+
+	if len(includes) == 0 {
+		return configFile, nil
+	}
+
+	merged := map[string]interface{}{}
+	configs := []interface{}{}
+	chain := append(append([]string{}, includedBy...), configPath)
+
+	for i, include := range includes {
+		includePath, _ := include.(string)
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(configPath), includePath)
+		}
+
+		for _, file := range chain {
+			if filepath.Clean(file) == filepath.Clean(includePath) {
+				return nil, fmt.Errorf("%w: %s: %s is included in a loop", zerr.ErrCliBadConfig,
+					locateValue(configPath, data, fmt.Sprintf("/%s/%d", includeKey, i)), includePath)
+			}
+		}
+
+		includedFile, err := loadIncludedConfigFile(schema, includePath, chain)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to include %s: %w",
+				locateValue(configPath, data, fmt.Sprintf("/%s/%d", includeKey, i)), includePath, err)
+		}
+
+		if defaultConfig, ok := includedFile[defaultConfigKey]; ok {
+			merged[defaultConfigKey] = defaultConfig
+		}
+
+		includedConfigs, _ := includedFile["configs"].([]interface{})
+		configs = mergeConfigs(configs, includedConfigs)
+	}
+
+	if defaultConfig, ok := configFile[defaultConfigKey]; ok {
+		merged[defaultConfigKey] = defaultConfig
+	}
+
+	fileConfigs, _ := configFile["configs"].([]interface{})
+	merged["configs"] = mergeConfigs(configs, fileConfigs)
+
+	return merged, nil
+}
+
+// mergeConfigs adds the configs to the list, replacing the ones with the same name.
+func mergeConfigs(configs, newConfigs []interface{}) []interface{} {
+	for _, newConfig := range newConfigs {
+		newConfigMap, _ := newConfig.(map[string]interface{})
+		replaced := false
+
+		for i, config := range configs {
+			configMap, _ := config.(map[string]interface{})
+
+			if configMap[nameKey] == newConfigMap[nameKey] {
+				configs[i] = newConfig
+				replaced = true
+
+				break
+			}
+		}
+
+		if !replaced {
+			configs = append(configs, newConfig)
+		}
+	}
+
+	return configs
+}
+
+// parseConfigFile decodes a config file, expands the environment variables of its string values and validates it.
+// encoding/json is used for the offsets of its errors.
+func parseConfigFile(schema *jsonschema.Schema, configPath string, data []byte) (map[string]interface{}, error) {
+	var configFile interface{}
+
+	if err := json.Unmarshal(data, &configFile); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// the offset is the one of the byte after the invalid one
+			return nil, fmt.Errorf("%w: %s: %s", zerr.ErrCliBadConfig,
+				locateOffset(configPath, data, syntaxErr.Offset-1), syntaxErr.Error())
+		}
+
+		return nil, fmt.Errorf("%w: %s: %w", zerr.ErrCliBadConfig, configPath, err)
+	}
+
+	configFile, err := expandEnvVars(configFile, "", func(pointer, name string) error {
+		return fmt.Errorf("%w: %s: environment variable %s is not set", zerr.ErrCliBadConfig,
+			locateValue(configPath, data, pointer), name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := schema.Validate(configFile); err != nil {
+		var validationErr *jsonschema.ValidationError
+		if !errors.As(err, &validationErr) {
+			return nil, err
+		}
+
+		return nil, schemaErrors(configPath, data, validationErr)
+	}
+
+	configMap, _ := configFile.(map[string]interface{})
+
+	return configMap, nil
+}
+
+// expandEnvVars replaces the environment variables referenced in the string values, pointer being
+// the JSON pointer of the value.
+func expandEnvVars(value interface{}, pointer string, unsetErr func(pointer, name string) error,
+) (interface{}, error) {
+	switch typedValue := value.(type) {
+	case string:
+		var err error
+
+		expanded := envVarRegexp.ReplaceAllStringFunc(typedValue, func(reference string) string {
+			match := envVarRegexp.FindStringSubmatch(reference)
+
+			envValue, ok := os.LookupEnv(match[1])
+			if ok {
+				return envValue
+			}
+
+			if match[2] != "" {
+				return match[3]
+			}
+
+			if err == nil {
+				err = unsetErr(pointer, match[1])
+			}
+
+			return reference
+		})
+
+		return expanded, err
+	case map[string]interface{}:
+		for key, item := range typedValue {
+			expanded, err := expandEnvVars(item, pointer+"/"+escapePointerToken(key), unsetErr)
+			if err != nil {
+				return nil, err
+			}
+
+			typedValue[key] = expanded
+		}
+	case []interface{}:
+		for i, item := range typedValue {
+			expanded, err := expandEnvVars(item, pointer+"/"+strconv.Itoa(i), unsetErr)
+			if err != nil {
+				return nil, err
+			}
+
+			typedValue[i] = expanded
+		}
+	}
+
+	return value, nil
+}
+
+// schemaErrors returns an error listing the schema violations with their location in the file.
+func schemaErrors(configPath string, data []byte, validationErr *jsonschema.ValidationError) error {
+	leaves := []*jsonschema.ValidationError{}
+
+	var collect func(validationErr *jsonschema.ValidationError)
+
+	collect = func(validationErr *jsonschema.ValidationError) {
+		if len(validationErr.Causes) == 0 {
+			leaves = append(leaves, validationErr)
+
+			return
+		}
+
+		for _, cause := range validationErr.Causes {
+			collect(cause)
+		}
+	}
+
+	collect(validationErr)
+
+	type violation struct {
+		location configLocation
+		message  string
+	}
+
+	violations := make([]violation, 0, len(leaves))
+
+	for _, leaf := range leaves {
+		violations = append(violations, violation{
+			location: locateValue(configPath, data, leaf.InstanceLocation),
+			message:  leaf.Message,
+		})
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].location.line != violations[j].location.line {
+			return violations[i].location.line < violations[j].location.line
+		}
+
+		return violations[i].location.column < violations[j].location.column
+	})
+
+	messages := make([]string, 0, len(violations))
+
+	for _, violation := range violations {
+		messages = append(messages, fmt.Sprintf("%s: %s", violation.location, violation.message))
+	}
+
+	return fmt.Errorf("%w:\n%s", zerr.ErrCliBadConfig, strings.Join(messages, "\n"))
+}
+
+// locateValue returns the position of the value at the JSON pointer, or the start of the file if it's not found.
+func locateValue(configPath string, data []byte, pointer string) configLocation {
+	locator := valueLocator{decoder: json.NewDecoder(bytes.NewReader(data)), data: data, pointer: pointer}
+
+	if err := locator.walk(""); !errors.Is(err, errValueFound) {
+		return configLocation{file: configPath, line: 1, column: 1}
+	}
+
+	return locateOffset(configPath, data, locator.offset)
+}
+
+// locateOffset returns the line and column of the byte at the offset.
+func locateOffset(configPath string, data []byte, offset int64) configLocation {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+
+	return configLocation{file: configPath, line: line, column: column}
+}
+
+var errValueFound = errors.New("value found")
+
+// valueLocator walks the JSON tokens to find the offset of the value at a JSON pointer.
+type valueLocator struct {
+	decoder *json.Decoder
+	data    []byte
+	pointer string
+	offset  int64
+}
+
+func (locator *valueLocator) walk(pointer string) error {
+	// the decoder stops after the previous token, the value starts after the separators
+	offset := locator.decoder.InputOffset()
+	for offset < int64(len(locator.data)) && strings.ContainsRune(" \t\r\n:,", rune(locator.data[offset])) {
+		offset++
+	}
+
+	if pointer == locator.pointer {
+		locator.offset = offset
+
+		return errValueFound
+	}
+
+	token, err := locator.decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	switch delim {
+	case '{':
+		for locator.decoder.More() {
+			keyToken, err := locator.decoder.Token()
+			if err != nil {
+				return err
+			}
+
+			key, _ := keyToken.(string)
+
+			if err := locator.walk(pointer + "/" + escapePointerToken(key)); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; locator.decoder.More(); i++ {
+			if err := locator.walk(pointer + "/" + strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the closing delimiter
+	_, err = locator.decoder.Token()
+
+	return err
+}
+
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
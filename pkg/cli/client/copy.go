@@ -0,0 +1,316 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/constants"
+)
+
+// defaultChunkSize is the size of the chunks the blobs are pushed in by default.
+const defaultChunkSize = "64MiB"
+
+// imageSource is where the copied manifests and blobs are read from, a repo of a registry or an oci layout.
+type imageSource interface {
+	// getManifest returns the content and the media type of the manifest
+	getManifest(ctx context.Context, reference string) ([]byte, string, error)
+	getBlob(ctx context.Context, blob ispec.Descriptor) (io.ReadCloser, error)
+}
+
+// imageDestination is where the copied manifests and blobs are written to.
+type imageDestination interface {
+	// putBlob copies the blob from the source, unless the destination already has it
+	putBlob(ctx context.Context, source imageSource, blob ispec.Descriptor) error
+	putManifest(ctx context.Context, reference, mediaType string, content []byte) error
+}
+
+// copyManifest copies the manifest and everything it references, the blobs of an image or
+// the images of an index, then pushes it under the destination reference.
+// The manifest is pushed as it was fetched, so it keeps the same digest.
+func copyManifest(ctx context.Context, source imageSource, dest imageDestination, reference,
+	destReference string,
+) error {
+	content, mediaType, err := source.getManifest(ctx, reference)
+	if err != nil {
+		return err
+	}
+
+	switch mediaType {
+	case ispec.MediaTypeImageManifest, schema2.MediaTypeManifest:
+		var manifest ispec.Manifest
+
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			return err
+		}
+
+		for _, blob := range append([]ispec.Descriptor{manifest.Config}, manifest.Layers...) {
+			if err := dest.putBlob(ctx, source, blob); err != nil {
+				return err
+			}
+		}
+	case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
+		var index ispec.Index
+
+		if err := json.Unmarshal(content, &index); err != nil {
+			return err
+		}
+
+		for _, descriptor := range index.Manifests {
+			digest := descriptor.Digest.String()
+
+			if err := copyManifest(ctx, source, dest, digest, digest); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %s", zerr.ErrMediaTypeNotSupported, mediaType)
+	}
+
+	return dest.putManifest(ctx, destReference, mediaType, content)
+}
+
+// getImageCreated returns the creation time of the image, from its config, or the latest one of the images
+// of an index. It's the zero time if the configs don't have one.
+func getImageCreated(ctx context.Context, source imageSource, reference string) (time.Time, error) {
+	content, mediaType, err := source.getManifest(ctx, reference)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	switch mediaType {
+	case ispec.MediaTypeImageManifest, schema2.MediaTypeManifest:
+		var manifest ispec.Manifest
+
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			return time.Time{}, err
+		}
+
+		configBlob, err := source.getBlob(ctx, manifest.Config)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		defer configBlob.Close()
+
+		var config ispec.Image
+
+		if err := json.NewDecoder(configBlob).Decode(&config); err != nil {
+			return time.Time{}, err
+		}
+
+		if config.Created == nil {
+			return time.Time{}, nil
+		}
+
+		return *config.Created, nil
+	case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
+		var index ispec.Index
+
+		if err := json.Unmarshal(content, &index); err != nil {
+			return time.Time{}, err
+		}
+
+		var latest time.Time
+
+		for _, descriptor := range index.Manifests {
+			created, err := getImageCreated(ctx, source, descriptor.Digest.String())
+			if err != nil {
+				return time.Time{}, err
+			}
+
+			if created.After(latest) {
+				latest = created
+			}
+		}
+
+		return latest, nil
+	default:
+		return time.Time{}, fmt.Errorf("%w: %s", zerr.ErrMediaTypeNotSupported, mediaType)
+	}
+}
+
+// registryRepo is a repo of a registry, read and written with the distribution api.
+type registryRepo struct {
+	config   SearchConfig
+	username string
+	password string
+	repo     string
+	// the blobs bigger than chunkSize are uploaded in chunks of this size, 0 uploads them in one request
+	chunkSize int64
+}
+
+func newRegistryRepo(config SearchConfig, username, password, repo string) registryRepo {
+	return registryRepo{config: config, username: username, password: password, repo: repo}
+}
+
+func (r registryRepo) getManifest(ctx context.Context, reference string) ([]byte, string, error) {
+	content, mediaType, _, err := fetchRawManifest(ctx, r.config, r.username, r.password, r.repo, reference)
+
+	return content, mediaType, err
+}
+
+func (r registryRepo) getBlob(ctx context.Context, blob ispec.Descriptor) (io.ReadCloser, error) {
+	blobURL, err := combineServerAndEndpointURL(r.config.ServURL, fmt.Sprintf("/v2/%s/blobs/%s", r.repo, blob.Digest))
+	if err != nil {
+		return nil, err
+	}
+
+	return makeBlobGETRequest(ctx, blobURL, r.username, r.password, r.config)
+}
+
+func (r registryRepo) putManifest(ctx context.Context, reference, mediaType string, content []byte) error {
+	manifestURL, err := combineServerAndEndpointURL(r.config.ServURL,
+		fmt.Sprintf("/v2/%s/manifests/%s", r.repo, reference))
+	if err != nil {
+		return err
+	}
+
+	_, _, err = makeUploadRequest(ctx, http.MethodPut, manifestURL, r.username, r.password, r.config,
+		mediaType, bytes.NewReader(content), int64(len(content)))
+
+	return err
+}
+
+// sameRegistry tells if the blobs can be mounted from the source repo instead of being uploaded again.
+func (r registryRepo) sameRegistry(source imageSource) (registryRepo, bool) {
+	srcRepo, ok := source.(registryRepo)
+
+	return srcRepo, ok &&
+		strings.TrimSuffix(srcRepo.config.registryURL(), "/") == strings.TrimSuffix(r.config.registryURL(), "/")
+}
+
+// putBlob copies the blob unless the repo already has it. On the same registry the blob is mounted
+// from the source repo, and only uploaded if the registry doesn't support mounting it.
+func (r registryRepo) putBlob(ctx context.Context, source imageSource, blob ispec.Descriptor) error {
+	blobURL, err := combineServerAndEndpointURL(r.config.ServURL,
+		fmt.Sprintf("/v2/%s/blobs/%s", r.repo, blob.Digest))
+	if err != nil {
+		return err
+	}
+
+	if _, err := makeHEADRequest(ctx, blobURL, r.username, r.password, r.config); err == nil {
+		return nil
+	}
+
+	uploadsURL, err := combineServerAndEndpointURL(r.config.ServURL, fmt.Sprintf("/v2/%s/blobs/uploads/", r.repo))
+	if err != nil {
+		return err
+	}
+
+	if srcRepo, ok := r.sameRegistry(source); ok {
+		uploadsURL += "?" + url.Values{"mount": {blob.Digest.String()}, "from": {srcRepo.repo}}.Encode()
+	}
+
+	status, header, err := makeUploadRequest(ctx, http.MethodPost, uploadsURL, r.username, r.password,
+		r.config, "", nil, 0)
+	if err != nil {
+		return err
+	}
+
+	// the blob was mounted
+	if status == http.StatusCreated {
+		return nil
+	}
+
+	content, err := source.getBlob(ctx, blob)
+	if err != nil {
+		return err
+	}
+
+	defer content.Close()
+
+	if r.chunkSize > 0 && blob.Size > r.chunkSize {
+		return r.putBlobChunks(ctx, uploadsURL, header, blob, content)
+	}
+
+	uploadURL, err := getUploadURL(uploadsURL, header, blob)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = makeUploadRequest(ctx, http.MethodPut, uploadURL, r.username, r.password, r.config,
+		constants.BinaryMediaType, content, blob.Size)
+
+	return err
+}
+
+// putBlobChunks sends the blob in chunks with PATCH requests, each one to the location returned by
+// the previous one, then closes the upload session with the digest of the blob.
+func (r registryRepo) putBlobChunks(ctx context.Context, uploadsURL string, header http.Header,
+	blob ispec.Descriptor, content io.Reader,
+) error {
+	location, err := getUploadLocation(uploadsURL, header, blob)
+	if err != nil {
+		return err
+	}
+
+	for offset := int64(0); offset < blob.Size; offset += r.chunkSize {
+		size := min(r.chunkSize, blob.Size-offset)
+
+		header, err := makeChunkUploadRequest(ctx, location.String(), r.username, r.password, r.config,
+			io.LimitReader(content, size), offset, size)
+		if err != nil {
+			return err
+		}
+
+		location, err = getUploadLocation(location.String(), header, blob)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := location.Query()
+	query.Set("digest", blob.Digest.String())
+	location.RawQuery = query.Encode()
+
+	_, _, err = makeUploadRequest(ctx, http.MethodPut, location.String(), r.username, r.password, r.config,
+		"", nil, 0)
+
+	return err
+}
+
+// getUploadURL returns the URL the whole blob is sent to, from the location of the upload session
+// which may be relative to the uploads URL.
+func getUploadURL(uploadsURL string, header http.Header, blob ispec.Descriptor) (string, error) {
+	uploadURL, err := getUploadLocation(uploadsURL, header, blob)
+	if err != nil {
+		return "", err
+	}
+
+	query := uploadURL.Query()
+	query.Set("digest", blob.Digest.String())
+	uploadURL.RawQuery = query.Encode()
+
+	return uploadURL.String(), nil
+}
+
+// getUploadLocation returns the location of the upload session, resolved against the URL of the request
+// which returned it.
+func getUploadLocation(requestURL string, header http.Header, blob ispec.Descriptor) (*url.URL, error) {
+	location := header.Get("Location")
+	if location == "" {
+		return nil, fmt.Errorf("%w: no upload location for blob %s", zerr.ErrBadHTTPStatusCode, blob.Digest)
+	}
+
+	baseURL, err := url.Parse(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return baseURL.Parse(location)
+}
@@ -21,11 +21,26 @@ func NewCVECommand(searchService SearchService) *cobra.Command {
 		"Specify zot server URL if config-name is not mentioned")
 	cvesCmd.PersistentFlags().String(ConfigFlag, "",
 		"Specify the registry configuration to use for connection")
+	_ = cvesCmd.RegisterFlagCompletionFunc(ConfigFlag, completeConfigNames)
 	cvesCmd.PersistentFlags().StringP(UserFlag, "u", "",
 		`User Credentials of zot server in "username:password" format`)
-	cvesCmd.PersistentFlags().StringP(OutputFormatFlag, "f", "", "Specify output format [text/json/yaml]")
+	cvesCmd.PersistentFlags().StringP(OutputFormatFlag, "f", "", "Specify output format [text/json/ndjson/yaml]")
 	cvesCmd.PersistentFlags().Bool(VerboseFlag, false, "Show verbose output")
-	cvesCmd.PersistentFlags().Bool(DebugFlag, false, "Show debug output")
+	cvesCmd.PersistentFlags().Bool(DebugFlag, false,
+		"Show every request made to the registry on stderr, with its status, latency and request id")
+	cvesCmd.PersistentFlags().Bool(DebugBodyFlag, false, "With --debug, also show the headers and the textual bodies")
+	cvesCmd.PersistentFlags().Duration(TimeoutFlag, 0,
+		"Maximum time the whole search can take, the results received until then are still shown, 0 means no limit")
+	cvesCmd.PersistentFlags().Int(RetriesFlag, defaultRetries,
+		"Number of times a request is retried when the registry is busy or the connection fails")
+	cvesCmd.PersistentFlags().Duration(RetryMaxWaitFlag, defaultRetryMaxWait,
+		"Maximum time to wait between two retries of a request")
+	cvesCmd.PersistentFlags().String(CertFlag, "", "Client certificate file to present to the server")
+	cvesCmd.PersistentFlags().String(KeyFlag, "", "Key file of the client certificate")
+	cvesCmd.PersistentFlags().String(CACertFlag, "",
+		"CA certificate file used to verify the server, in addition to the system ones")
+	cvesCmd.PersistentFlags().String(ProxyFlag, "",
+		"Proxy the server is reached through, an http, https or socks5 url [default: HTTPS_PROXY/NO_PROXY]")
 
 	cvesCmd.AddCommand(NewCveForImageCommand(searchService))
 	cvesCmd.AddCommand(NewImagesByCVEIDCommand(searchService))
@@ -24,10 +24,11 @@ func NewCveForImageCommand(searchService SearchService) *cobra.Command {
 	)
 
 	cveForImageCmd := &cobra.Command{
-		Use:   "list [repo:tag]|[repo@digest]",
-		Short: "List CVEs by REPO:TAG or REPO@DIGEST",
-		Long:  `List CVEs by REPO:TAG or REPO@DIGEST`,
-		Args:  OneImageWithRefArg,
+		Use:               "list [repo:tag]|[repo@digest]",
+		Short:             "List CVEs by REPO:TAG or REPO@DIGEST",
+		Long:              `List CVEs by REPO:TAG or REPO@DIGEST`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              OneImageWithRefArg,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
 			if err != nil {
@@ -90,7 +91,7 @@ func NewImagesByCVEIDCommand(searchService SearchService) *cobra.Command {
 		},
 	}
 
-	imagesByCVEIDCmd.Flags().StringVar(&repo, "repo", "", "Search for a specific CVE by name/id")
+	imagesByCVEIDCmd.Flags().StringVar(&repo, "repo", "", "Show only the affected images from the given repository")
 	imagesByCVEIDCmd.Flags().Var(&imageListSortFlag, SortByFlag,
 		fmt.Sprintf("Options for sorting the output: [%s]", ImageListSortOptionsStr()))
 
@@ -0,0 +1,186 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	requestIDHeader = "X-Request-Id"
+	// the bodies bigger than this, like most blobs, are only shown with their size
+	maxDebugBodySize = 64 * 1024
+)
+
+//nolint:gochecknoglobals
+var lastRequestID atomic.Uint64
+
+// redactedHeaders are the headers whose values are never shown in the debug output.
+//
+//nolint:gochecknoglobals
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// newRequestID returns the id the request is shown with in the debug output. It's also sent to the
+// registry, so the request can be found in its logs.
+func newRequestID(req *http.Request) string {
+	requestID := req.Header.Get(requestIDHeader)
+	if requestID != "" {
+		return requestID
+	}
+
+	requestID = fmt.Sprintf("zli-%d-%d", os.Getpid(), lastRequestID.Add(1))
+	req.Header.Set(requestIDHeader, requestID)
+
+	return requestID
+}
+
+// debugRequest shows the request before it's sent, with its headers and body if --debug-body is set.
+func debugRequest(config SearchConfig, req *http.Request, requestID string, attempt int) {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "[debug] %s --> %s %s", requestID, req.Method, req.URL.Redacted())
+
+	if attempt > 0 {
+		fmt.Fprintf(&builder, " (retry %d)", attempt)
+	}
+
+	builder.WriteString("\n")
+
+	if config.DebugBody {
+		writeDebugHeaders(&builder, req.Header)
+
+		switch {
+		case req.Body == nil || req.Body == http.NoBody:
+		case req.GetBody == nil:
+			// a streamed body, like a blob upload, can only be read once
+			fmt.Fprintf(&builder, "    <streamed body of %d bytes>\n", req.ContentLength)
+		default:
+			body, err := req.GetBody()
+			if err == nil {
+				writeDebugBody(&builder, req.Header.Get("Content-Type"), req.ContentLength, body)
+				body.Close()
+			}
+		}
+	}
+
+	writeDebugOutput(config, builder.String())
+}
+
+// debugResponse shows the status and the latency of the request, or the error it failed with. With
+// --debug-body the textual bodies are read to be shown, and replaced by a copy for the caller.
+func debugResponse(config SearchConfig, req *http.Request, requestID string, resp *http.Response, err error,
+	latency time.Duration,
+) {
+	var builder strings.Builder
+
+	if err != nil {
+		fmt.Fprintf(&builder, "[debug] %s <-- %s %s error: %s (%s)\n", requestID, req.Method, req.URL.Redacted(),
+			err, latency.Round(time.Microsecond))
+		writeDebugOutput(config, builder.String())
+
+		return
+	}
+
+	fmt.Fprintf(&builder, "[debug] %s <-- %d %s %s (%s)\n", requestID, resp.StatusCode, req.Method,
+		req.URL.Redacted(), latency.Round(time.Microsecond))
+
+	if config.DebugBody {
+		writeDebugHeaders(&builder, resp.Header)
+
+		contentType := resp.Header.Get("Content-Type")
+
+		if isTextContent(contentType) && resp.ContentLength <= maxDebugBodySize {
+			body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxDebugBodySize+1))
+			// the rest of the body is still given to the caller
+			resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+			if readErr == nil {
+				writeDebugBody(&builder, contentType, int64(len(body)), bytes.NewReader(body))
+			}
+		} else if resp.ContentLength > 0 {
+			fmt.Fprintf(&builder, "    <body of %d bytes>\n", resp.ContentLength)
+		}
+	}
+
+	writeDebugOutput(config, builder.String())
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func writeDebugHeaders(builder *strings.Builder, header http.Header) {
+	names := make([]string, 0, len(header))
+
+	for name := range header {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		values := strings.Join(header.Values(name), ", ")
+
+		if slices.Contains(redactedHeaders, http.CanonicalHeaderKey(name)) {
+			values = "******"
+		}
+
+		fmt.Fprintf(builder, "    %s: %s\n", name, values)
+	}
+}
+
+func writeDebugBody(builder *strings.Builder, contentType string, size int64, body io.Reader) {
+	if !isTextContent(contentType) || size > maxDebugBodySize {
+		fmt.Fprintf(builder, "    <body of %d bytes>\n", size)
+
+		return
+	}
+
+	content, err := io.ReadAll(io.LimitReader(body, maxDebugBodySize+1))
+	if err != nil {
+		return
+	}
+
+	if len(content) > maxDebugBodySize {
+		fmt.Fprintf(builder, "    <body of more than %d bytes>\n", maxDebugBodySize)
+
+		return
+	}
+
+	if len(content) > 0 {
+		builder.WriteString("\n")
+		builder.Write(content)
+		builder.WriteString("\n\n")
+	}
+}
+
+// isTextContent tells if the body is worth showing, json documents and error messages are but blobs aren't.
+func isTextContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json")
+}
+
+// writeDebugOutput writes the whole message at once, so the lines of concurrent requests aren't mixed.
+func writeDebugOutput(config SearchConfig, message string) {
+	writer := config.ErrorWriter
+	if writer == nil {
+		writer = os.Stderr
+	}
+
+	_, _ = io.WriteString(writer, message)
+}
@@ -6,6 +6,7 @@ package client
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	distext "github.com/opencontainers/distribution-spec/specs-go/v1/extensions"
 
@@ -62,14 +63,36 @@ func containsGQLQueryWithParams(queryList []field, serverGQLTypesList []typeInfo
 			return fmt.Errorf("%w: %s", zerr.ErrGQLQueryNotSupported, reqQuery.Name)
 		}
 
-		// let's check just the name of the returned type
-		returnType := reqQuery.ReturnType.Name
+		if err := containsGQLType(serverGQLTypes, reqQuery.ReturnType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// containsGQLType checks the server knows the type and, recursively, the fields required from it.
+func containsGQLType(serverGQLTypes map[string][]typeField, reqType GQLType) error {
+	serverFields, ok := serverGQLTypes[reqType.Name]
+	if !ok {
+		return fmt.Errorf("%w: server doesn't support needed type '%s'", zerr.ErrGQLQueryNotSupported, reqType.Name)
+	}
+
+	for _, reqField := range reqType.Fields {
+		if !slices.ContainsFunc(serverFields, func(serverField typeField) bool {
+			return serverField.Name == reqField.Name
+		}) {
+			return fmt.Errorf("%w: server doesn't support needed field '%s.%s'", zerr.ErrGQLQueryNotSupported,
+				reqType.Name, reqField.Name)
+		}
+
+		// the fields of scalar types aren't checked
+		if reqField.Type.Name == "" {
+			continue
+		}
 
-		// we can next define fields of the returned types and check them recursively
-		// for now we will just check the name of the returned type to be known by the server
-		_, ok := serverGQLTypes[returnType]
-		if !ok {
-			return fmt.Errorf("%w: server doesn't support needed type '%s'", zerr.ErrGQLQueryNotSupported, returnType)
+		if err := containsGQLType(serverGQLTypes, reqField.Type); err != nil {
+			return err
 		}
 	}
 
@@ -102,8 +125,7 @@ func CheckExtEndPointQuery(config SearchConfig, requiredQueries ...GQLQuery) err
 
 	discoverResponse := &distext.ExtensionList{}
 
-	_, err = makeGETRequest(ctx, discoverEndPoint, username, password, config.VerifyTLS,
-		config.Debug, &discoverResponse, config.ResultWriter)
+	_, err = makeGETRequest(ctx, discoverEndPoint, username, password, config, &discoverResponse)
 	if err != nil {
 		return err
 	}
@@ -153,8 +175,7 @@ func CheckExtEndPointQuery(config SearchConfig, requiredQueries ...GQLQuery) err
 
 	queryResponse := &schemaList{}
 
-	err = makeGraphQLRequest(ctx, searchEndPoint, schemaQuery, username, password, config.VerifyTLS,
-		config.Debug, queryResponse, config.ResultWriter)
+	err = makeGraphQLRequest(ctx, searchEndPoint, schemaQuery, username, password, config, queryResponse)
 	if err != nil {
 		return fmt.Errorf("gql query failed: %w", err)
 	}
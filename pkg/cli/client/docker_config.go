@@ -0,0 +1,170 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/docker/docker-credential-helpers/client"
+	"github.com/docker/docker-credential-helpers/credentials"
+
+	zerr "zotregistry.dev/zot/errors"
+)
+
+const (
+	dockerConfigDirEnv     = "DOCKER_CONFIG"
+	dockerConfigFileName   = "config.json"
+	dockerHomeConfigDir    = ".docker"
+	credentialHelperPrefix = "docker-credential-"
+)
+
+// dockerConfig holds the fields of the docker client configuration related to credentials.
+type dockerConfig struct {
+	Auths       map[string]dockerAuthConfig `json:"auths"`
+	CredsStore  string                      `json:"credsStore"`
+	CredHelpers map[string]string           `json:"credHelpers"`
+}
+
+type dockerAuthConfig struct {
+	Auth     string `json:"auth"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func getDockerConfigPath() (string, error) {
+	if configDir := os.Getenv(dockerConfigDirEnv); configDir != "" {
+		return path.Join(configDir, dockerConfigFileName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(home, dockerHomeConfigDir, dockerConfigFileName), nil
+}
+
+// getDockerCredentials returns the credentials the docker client would use for the registry at serverURL,
+// looking first at the credential helper configured for the registry, then at the default credentials
+// store and last at the credentials written in the config file.
+// Empty credentials are returned if the docker config doesn't exist or doesn't know the registry, and for
+// the registries reached through a unix socket, which have no host to look up.
+func getDockerCredentials(serverURL string) (string, string, error) {
+	if strings.HasPrefix(serverURL, unixSocketScheme+"://") {
+		return "", "", nil
+	}
+
+	registry, err := getRegistryHost(serverURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	configPath, err := getDockerConfigPath()
+	if err != nil {
+		return "", "", err
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", "", nil
+		}
+
+		return "", "", err
+	}
+
+	config := dockerConfig{}
+
+	if err := json.Unmarshal(content, &config); err != nil {
+		return "", "", fmt.Errorf("%w: failed to parse docker config %s: %w", zerr.ErrBadConfig, configPath, err)
+	}
+
+	if helper, ok := config.CredHelpers[registry]; ok {
+		return getHelperCredentials(helper, registry)
+	}
+
+	if config.CredsStore != "" {
+		return getHelperCredentials(config.CredsStore, registry)
+	}
+
+	for key, authConfig := range config.Auths {
+		keyRegistry, err := getRegistryHost(key)
+		if err != nil || keyRegistry != registry {
+			continue
+		}
+
+		if authConfig.Auth == "" {
+			return authConfig.Username, authConfig.Password, nil
+		}
+
+		decodedAuth, err := base64.StdEncoding.DecodeString(authConfig.Auth)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: invalid auth for %s in docker config %s: %w",
+				zerr.ErrBadConfig, key, configPath, err)
+		}
+
+		username, password, _ := strings.Cut(string(decodedAuth), ":")
+
+		return username, password, nil
+	}
+
+	return "", "", nil
+}
+
+func getHelperCredentials(helper, registry string) (string, string, error) {
+	creds, err := client.Get(client.NewShellProgramFunc(credentialHelperPrefix+helper), registry)
+	if err != nil {
+		if credentials.IsErrCredentialsNotFound(err) {
+			return "", "", nil
+		}
+
+		return "", "", fmt.Errorf("credential helper %s failed for %s: %w", helper, registry, err)
+	}
+
+	return creds.Username, creds.Secret, nil
+}
+
+// getRegistryHost returns the host of a registry given as an url or as a docker config key,
+// which may or may not have a scheme and a path.
+func getRegistryHost(registry string) (string, error) {
+	if !strings.Contains(registry, "://") {
+		registry = "https://" + registry
+	}
+
+	registryURL, err := url.Parse(registry)
+	if err != nil {
+		return "", err
+	}
+
+	if registryURL.Host == "" {
+		return "", fmt.Errorf("%w: %s", zerr.ErrInvalidURL, registry)
+	}
+
+	return registryURL.Host, nil
+}
+
+// resolveUser returns the credentials given by the user in "username:password" format,
+// or if there are none, the docker credentials of the registry at serverURL in the same format.
+func resolveUser(user, serverURL string) (string, error) {
+	if user != "" {
+		return user, nil
+	}
+
+	username, password, err := getDockerCredentials(serverURL)
+	if err != nil {
+		return "", err
+	}
+
+	if username == "" && password == "" {
+		return "", nil
+	}
+
+	return username + ":" + password, nil
+}
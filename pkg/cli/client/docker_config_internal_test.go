@@ -0,0 +1,118 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.dev/zot/errors"
+)
+
+func TestDockerCredentials(t *testing.T) {
+	Convey("Docker config credentials", t, func() {
+		configDir := t.TempDir()
+		t.Setenv(dockerConfigDirEnv, configDir)
+
+		writeDockerConfig := func(content string) {
+			err := os.WriteFile(path.Join(configDir, dockerConfigFileName), []byte(content), 0o600)
+			So(err, ShouldBeNil)
+		}
+
+		Convey("no docker config", func() {
+			user, err := resolveUser("", "http://registry:8080")
+			So(err, ShouldBeNil)
+			So(user, ShouldBeEmpty)
+		})
+
+		Convey("the given user has priority", func() {
+			writeDockerConfig(`{"auths": {"registry:8080": {"username": "docker", "password": "pass"}}}`)
+
+			user, err := resolveUser("user:pass", "http://registry:8080")
+			So(err, ShouldBeNil)
+			So(user, ShouldEqual, "user:pass")
+		})
+
+		Convey("auths", func() {
+			auth := base64.StdEncoding.EncodeToString([]byte("user:pass:with:colons"))
+			writeDockerConfig(`{"auths": {
+				"https://registry:8080/v1/": {"auth": "` + auth + `"},
+				"other:8080": {"username": "other", "password": "otherpass"},
+				"bad": {"auth": "not base64"}
+			}}`)
+
+			user, err := resolveUser("", "http://registry:8080")
+			So(err, ShouldBeNil)
+
+			username, password := getUsernameAndPassword(user)
+			So(username, ShouldEqual, "user")
+			So(password, ShouldEqual, "pass:with:colons")
+
+			user, err = resolveUser("", "https://other:8080")
+			So(err, ShouldBeNil)
+			So(user, ShouldEqual, "other:otherpass")
+
+			user, err = resolveUser("", "https://unknown")
+			So(err, ShouldBeNil)
+			So(user, ShouldBeEmpty)
+
+			_, err = resolveUser("", "https://bad")
+			So(errors.Is(err, zerr.ErrBadConfig), ShouldBeTrue)
+		})
+
+		Convey("bad docker config", func() {
+			writeDockerConfig(`bad json`)
+
+			_, err := resolveUser("", "http://registry:8080")
+			So(errors.Is(err, zerr.ErrBadConfig), ShouldBeTrue)
+		})
+
+		Convey("credential helpers", func() {
+			binDir := t.TempDir()
+			t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+			// the helpers answer only for the registry they know
+			helper := `#!/bin/sh
+read server
+if [ "$server" = "registry:8080" ]; then
+	echo '{"Username": "helper-user", "Secret": "helper-secret"}'
+	exit 0
+fi
+echo "credentials not found in native keychain"
+exit 1
+`
+			err := os.WriteFile(path.Join(binDir, credentialHelperPrefix+"test"), []byte(helper), 0o700) //nolint:gosec
+			So(err, ShouldBeNil)
+
+			Convey("credHelpers", func() {
+				writeDockerConfig(`{"credHelpers": {"registry:8080": "test", "missing:8080": "missing"},
+					"auths": {"registry:8080": {"username": "docker", "password": "pass"}}}`)
+
+				user, err := resolveUser("", "http://registry:8080")
+				So(err, ShouldBeNil)
+				So(user, ShouldEqual, "helper-user:helper-secret")
+
+				_, err = resolveUser("", "http://missing:8080")
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("credsStore", func() {
+				writeDockerConfig(`{"credsStore": "test"}`)
+
+				user, err := resolveUser("", "http://registry:8080")
+				So(err, ShouldBeNil)
+				So(user, ShouldEqual, "helper-user:helper-secret")
+
+				user, err = resolveUser("", "http://unknown:8080")
+				So(err, ShouldBeNil)
+				So(user, ShouldBeEmpty)
+			})
+		})
+	})
+}
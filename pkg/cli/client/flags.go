@@ -20,8 +20,61 @@ const (
 	VerboseFlag      = "verbose"
 	VersionFlag      = "version"
 	DebugFlag        = "debug"
+	DebugBodyFlag    = "debug-body"
 	SearchedCVEID    = "cve-id"
 	SortByFlag       = "sort-by"
+	LabelFlag        = "label"
+	PageSizeFlag     = "page-size"
+	NoCacheFlag      = "no-cache"
+
+	MaxConcurrentRequestsFlag = "max-concurrent-requests"
+	RequestsPerSecondFlag     = "requests-per-second"
+	RetriesFlag               = "retries"
+	RetryMaxWaitFlag          = "retry-max-wait"
+	CertFlag                  = "cert"
+	KeyFlag                   = "key"
+	CACertFlag                = "cacert"
+	ProgressFlag              = "progress"
+	RegexFlag                 = "regex"
+	FormatColumnsFlag         = "format-columns"
+	NoTruncFlag               = "no-trunc"
+	FailFastFlag              = "fail-fast"
+	MaxIdleConnsPerHostFlag   = "max-idle-conns-per-host"
+	RequestTimeoutFlag        = "request-timeout"
+	TimeoutFlag               = "timeout"
+	DestURLFlag               = "dest-url"
+	DestConfigFlag            = "dest-config"
+	DestUserFlag              = "dest-user"
+	AllTagsFlag               = "all-tags"
+	VerifySignatureFlag       = "verify-signature"
+	PublicKeyFlag             = "public-key"
+	SBOMTypeFlag              = "type"
+	RawFlag                   = "raw"
+	ArtifactTypeFlag          = "artifact-type"
+	DerivedFromFlag           = "derived-from"
+	BaseOfFlag                = "base-of"
+	OCILayoutFlag             = "oci-layout"
+	PlatformFlag              = "platform"
+	QuietFlag                 = "quiet"
+	DigestsFlag               = "digests"
+	WatchFlag                 = "watch"
+	ToFlag                    = "to"
+	ChunkSizeFlag             = "chunk-size"
+	ScopeFlag                 = "scope"
+	ExpiresInFlag             = "expires-in"
+	TagFlag                   = "tag"
+	RemoveFlag                = "remove"
+	LimitRateFlag             = "limit-rate"
+	IfNewerFlag               = "if-newer"
+	MissingOnlyFlag           = "missing-only"
+	ReferenceFlag             = "reference"
+	ProxyFlag                 = "proxy"
+	StarredFlag               = "starred"
+	BookmarkedFlag            = "bookmarked"
+	PinOutputFlag             = "pin-output"
+	PinFileFlag               = "pin-file"
+	AgainstFlag               = "against"
+	ImagesFileFlag            = "file"
 )
 
 const (
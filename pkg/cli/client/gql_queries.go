@@ -31,12 +31,30 @@ func PaginatedImagesResult() GQLType {
 	}
 }
 
+func PaginatedReposResult() GQLType {
+	return GQLType{
+		Name: "PaginatedReposResult",
+	}
+}
+
 func Referrer() GQLType {
 	return GQLType{
 		Name: "Referrer",
 	}
 }
 
+func LayerUsage() GQLType {
+	return GQLType{
+		Name: "LayerUsage",
+	}
+}
+
+func RepoEvent() GQLType {
+	return GQLType{
+		Name: "RepoEvent",
+	}
+}
+
 func GlobalSearchResult() GQLType {
 	return GQLType{
 		Name: "GlobalSearchResult",
@@ -51,6 +69,30 @@ func ImageListQuery() GQLQuery {
 	}
 }
 
+// ImageListWithAnnotationsQuery is the ImageList query of the servers returning the annotations of the images.
+func ImageListWithAnnotationsQuery() GQLQuery {
+	return GQLQuery{
+		Name: "ImageList",
+		Args: []string{"repo", "requestedPage"},
+		ReturnType: GQLType{
+			Name: "PaginatedImagesResult",
+			Fields: []GQLField{
+				{Name: "Results", Type: ImageSummaryWithAnnotations()},
+			},
+		},
+	}
+}
+
+func ImageSummaryWithAnnotations() GQLType {
+	return GQLType{
+		Name: "ImageSummary",
+		Fields: []GQLField{
+			{Name: "Annotations"},
+			{Name: "Manifests", Type: GQLType{Name: "ManifestSummary", Fields: []GQLField{{Name: "Annotations"}}}},
+		},
+	}
+}
+
 func ImageListForDigestQuery() GQLQuery {
 	return GQLQuery{
 		Name:       "ImageListForDigest",
@@ -107,6 +149,38 @@ func ReferrersQuery() GQLQuery {
 	}
 }
 
+func ImageLayersQuery() GQLQuery {
+	return GQLQuery{
+		Name:       "ImageLayers",
+		Args:       []string{"image"},
+		ReturnType: LayerUsage(),
+	}
+}
+
+func RepoActivityQuery() GQLQuery {
+	return GQLQuery{
+		Name:       "RepoActivity",
+		Args:       []string{"repo", "reference"},
+		ReturnType: RepoEvent(),
+	}
+}
+
+func StarredReposQuery() GQLQuery {
+	return GQLQuery{
+		Name:       "StarredRepos",
+		Args:       []string{"requestedPage"},
+		ReturnType: PaginatedReposResult(),
+	}
+}
+
+func BookmarkedReposQuery() GQLQuery {
+	return GQLQuery{
+		Name:       "BookmarkedRepos",
+		Args:       []string{"requestedPage"},
+		ReturnType: PaginatedReposResult(),
+	}
+}
+
 func GlobalSearchQuery() GQLQuery {
 	return GQLQuery{
 		Name:       "GlobalSearch",
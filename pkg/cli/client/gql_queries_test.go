@@ -86,6 +86,26 @@ func TestGQLQueries(t *testing.T) {
 			So(err, ShouldBeNil)
 		})
 
+		Convey("ImageLayers", func() {
+			err := client.CheckExtEndPointQuery(searchConfig, client.ImageLayersQuery())
+			So(err, ShouldBeNil)
+		})
+
+		Convey("RepoActivity", func() {
+			err := client.CheckExtEndPointQuery(searchConfig, client.RepoActivityQuery())
+			So(err, ShouldBeNil)
+		})
+
+		Convey("StarredRepos", func() {
+			err := client.CheckExtEndPointQuery(searchConfig, client.StarredReposQuery())
+			So(err, ShouldBeNil)
+		})
+
+		Convey("BookmarkedRepos", func() {
+			err := client.CheckExtEndPointQuery(searchConfig, client.BookmarkedReposQuery())
+			So(err, ShouldBeNil)
+		})
+
 		Convey("GlobalSearch", func() {
 			err := client.CheckExtEndPointQuery(searchConfig, client.GlobalSearchQuery())
 			So(err, ShouldBeNil)
@@ -0,0 +1,429 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v2"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/common"
+)
+
+const (
+	layerCommon  = "common"
+	layerRemoved = "removed"
+	layerAdded   = "added"
+
+	createdByWidth = 60
+	layerWidth     = 8
+)
+
+// imageHistoryStruct is the history of the layers of an image, the most recent first.
+// An image index has one entry per image, a single manifest has a single entry.
+type imageHistoryStruct struct {
+	RepoName  string                  `json:"repoName"`
+	Reference string                  `json:"reference"`
+	Digest    string                  `json:"digest"`
+	Manifests []manifestHistoryStruct `json:"manifests"`
+}
+
+type manifestHistoryStruct struct {
+	Digest   string               `json:"digest"`
+	Platform common.Platform      `json:"platform"`
+	History  []historyEntryStruct `json:"history"`
+}
+
+type historyEntryStruct struct {
+	Created    *time.Time `json:"created,omitempty"`
+	CreatedBy  string     `json:"createdBy"`
+	Comment    string     `json:"comment,omitempty"`
+	EmptyLayer bool       `json:"emptyLayer"`
+	Layer      string     `json:"layer,omitempty"`
+	Size       int64      `json:"size"`
+}
+
+func fetchImageHistory(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+) (*imageHistoryStruct, error) {
+	image, err := fetchImageInspectStruct(ctx, config, username, password, repo, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &imageHistoryStruct{
+		RepoName:  image.RepoName,
+		Reference: image.Reference,
+		Digest:    image.Digest,
+		Manifests: make([]manifestHistoryStruct, 0, len(image.Manifests)),
+	}
+
+	for _, manifest := range image.Manifests {
+		history.Manifests = append(history.Manifests, manifestHistoryStruct{
+			Digest:   manifest.Digest,
+			Platform: manifest.Platform,
+			History:  getManifestHistory(manifest),
+		})
+	}
+
+	return history, nil
+}
+
+// getManifestHistory matches the config history with the layers, the same way getLayersInspect does.
+// Without a history matching the layers, there is one entry per layer with only its size.
+func getManifestHistory(manifest manifestInspectStruct) []historyEntryStruct {
+	entries := []historyEntryStruct{}
+	nonEmptyEntries := 0
+
+	for _, entry := range manifest.Config.History {
+		if !entry.EmptyLayer {
+			nonEmptyEntries++
+		}
+	}
+
+	if len(manifest.Config.History) == 0 || nonEmptyEntries != len(manifest.Layers) {
+		for _, layer := range manifest.Layers {
+			entries = append(entries, historyEntryStruct{Layer: layer.Digest, Size: layer.Size})
+		}
+	} else {
+		layerIndex := 0
+
+		for _, entry := range manifest.Config.History {
+			historyEntry := historyEntryStruct{
+				Created:    entry.Created,
+				CreatedBy:  entry.CreatedBy,
+				Comment:    entry.Comment,
+				EmptyLayer: entry.EmptyLayer,
+			}
+
+			if !entry.EmptyLayer {
+				historyEntry.Layer = manifest.Layers[layerIndex].Digest
+				historyEntry.Size = manifest.Layers[layerIndex].Size
+				layerIndex++
+			}
+
+			entries = append(entries, historyEntry)
+		}
+	}
+
+	slices.Reverse(entries)
+
+	return entries
+}
+
+// filterPlatform keeps only the images of the platform, given as os[/arch[/variant]].
+func (history *imageHistoryStruct) filterPlatform(platform string) error {
+	history.Manifests = slices.DeleteFunc(history.Manifests, func(manifest manifestHistoryStruct) bool {
+		return !matchesPlatform(manifest.Platform, platform)
+	})
+
+	if len(history.Manifests) == 0 {
+		return fmt.Errorf("%w: %s", zerr.ErrPlatformNotFound, platform)
+	}
+
+	return nil
+}
+
+func matchesPlatform(platform common.Platform, wanted string) bool {
+	wantedOS, wantedArch, _ := strings.Cut(wanted, "/")
+	wantedArch, wantedVariant, _ := strings.Cut(wantedArch, "/")
+
+	return platform.Os == wantedOS && (wantedArch == "" || platform.Arch == wantedArch) &&
+		(wantedVariant == "" || platform.Variant == wantedVariant)
+}
+
+// selectManifest returns the image of the given platform, the platform is only needed
+// to choose between the images of an index.
+func selectManifest(image *imageInspectStruct, platform string) (manifestInspectStruct, error) {
+	manifests := image.Manifests
+
+	if platform != "" {
+		manifests = slices.DeleteFunc(slices.Clone(manifests), func(manifest manifestInspectStruct) bool {
+			return !matchesPlatform(manifest.Platform, platform)
+		})
+	}
+
+	switch len(manifests) {
+	case 0:
+		return manifestInspectStruct{}, fmt.Errorf("%w: %s %s", zerr.ErrPlatformNotFound,
+			common.GetFullImageName(image.RepoName, image.Reference), platform)
+	case 1:
+		return manifests[0], nil
+	default:
+		platforms := make([]string, 0, len(manifests))
+
+		for _, manifest := range manifests {
+			platforms = append(platforms, getPlatformStr(manifest.Platform))
+		}
+
+		return manifestInspectStruct{}, fmt.Errorf("%w: %s is an image index, choose one of its platforms "+
+			"with --%s: %s", zerr.ErrInvalidCLIParameter, common.GetFullImageName(image.RepoName, image.Reference),
+			PlatformFlag, strings.Join(platforms, ", "))
+	}
+}
+
+func (history imageHistoryStruct) string(format string, noTrunc bool) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return history.stringPlainText(noTrunc)
+	case jsonFormat:
+		return history.stringJSON()
+	case ndjsonFormat:
+		return history.stringNDJSON()
+	case ymlFormat, yamlFormat:
+		return history.stringYAML()
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (history imageHistoryStruct) stringPlainText(noTrunc bool) (string, error) {
+	var builder strings.Builder
+
+	writer := tabwriter.NewWriter(&builder, 0, 8, 2, ' ', 0) //nolint:gomnd
+
+	fmt.Fprintf(writer, "Repository:\t%s\n", history.RepoName)
+	fmt.Fprintf(writer, "Reference:\t%s\n", history.Reference)
+	fmt.Fprintf(writer, "Digest:\t%s\n", history.Digest)
+
+	for _, manifest := range history.Manifests {
+		fmt.Fprintln(writer)
+
+		if len(history.Manifests) > 1 || manifest.Digest != history.Digest {
+			fmt.Fprintf(writer, "Manifest:\t%s\n", manifest.Digest)
+		}
+
+		fmt.Fprintf(writer, "Platform:\t%s\n", getPlatformStr(manifest.Platform))
+
+		writeInspectRow(writer, "LAYER", "CREATED", sizeColumn, "CREATED BY")
+
+		for _, entry := range manifest.History {
+			layer := "<empty>"
+			if entry.Layer != "" {
+				layer = getShortDigest(entry.Layer)
+			}
+
+			created := "N/A"
+			if entry.Created != nil {
+				created = getCreatedStr(*entry.Created)
+			}
+
+			createdBy := entry.CreatedBy
+			if !noTrunc {
+				createdBy = ellipsize(createdBy, createdByWidth, ellipsis)
+			}
+
+			writeInspectRow(writer, layer, created, getSizeStr(entry.Size), createdBy)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+func (history imageHistoryStruct) stringJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+func (history imageHistoryStruct) stringNDJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.Marshal(history)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+func (history imageHistoryStruct) stringYAML() (string, error) {
+	body, err := yaml.Marshal(history)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(body), nil
+}
+
+// imageDiffStruct tells which layers two images have in common and which ones only one of them has.
+type imageDiffStruct struct {
+	From   diffImageStruct   `json:"from"`
+	To     diffImageStruct   `json:"to"`
+	Layers []layerDiffStruct `json:"layers"`
+}
+
+type diffImageStruct struct {
+	Name     string          `json:"name"`
+	Digest   string          `json:"digest"`
+	Platform common.Platform `json:"platform"`
+}
+
+// layerDiffStruct is a layer of the images, common to both, removed if only the first one has it
+// or added if only the second one has it.
+type layerDiffStruct struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+	CreatedBy string `json:"createdBy,omitempty"`
+}
+
+func diffImageLayers(fromName string, from manifestInspectStruct, toName string, to manifestInspectStruct,
+) *imageDiffStruct {
+	diff := &imageDiffStruct{
+		From:   diffImageStruct{Name: fromName, Digest: from.Digest, Platform: from.Platform},
+		To:     diffImageStruct{Name: toName, Digest: to.Digest, Platform: to.Platform},
+		Layers: []layerDiffStruct{},
+	}
+
+	fromLayers := map[string]bool{}
+	toLayers := map[string]bool{}
+
+	for _, layer := range from.Layers {
+		fromLayers[layer.Digest] = true
+	}
+
+	for _, layer := range to.Layers {
+		toLayers[layer.Digest] = true
+	}
+
+	for _, layer := range from.Layers {
+		status := layerRemoved
+		if toLayers[layer.Digest] {
+			status = layerCommon
+		}
+
+		diff.Layers = append(diff.Layers, layerDiffStruct{Status: status, Digest: layer.Digest, Size: layer.Size,
+			CreatedBy: layer.CreatedBy})
+	}
+
+	for _, layer := range to.Layers {
+		if !fromLayers[layer.Digest] {
+			diff.Layers = append(diff.Layers, layerDiffStruct{Status: layerAdded, Digest: layer.Digest,
+				Size: layer.Size, CreatedBy: layer.CreatedBy})
+		}
+	}
+
+	return diff
+}
+
+func (diff imageDiffStruct) string(format string, noTrunc bool) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return diff.stringPlainText(noTrunc)
+	case jsonFormat:
+		return diff.stringJSON()
+	case ndjsonFormat:
+		return diff.stringNDJSON()
+	case ymlFormat, yamlFormat:
+		return diff.stringYAML()
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (diff imageDiffStruct) stringPlainText(noTrunc bool) (string, error) {
+	var builder strings.Builder
+
+	writer := tabwriter.NewWriter(&builder, 0, 8, 2, ' ', 0) //nolint:gomnd
+
+	fmt.Fprintf(writer, "From:\t%s\t%s\t%s\n", diff.From.Name, diff.From.Digest, getPlatformStr(diff.From.Platform))
+	fmt.Fprintf(writer, "To:\t%s\t%s\t%s\n", diff.To.Name, diff.To.Digest, getPlatformStr(diff.To.Platform))
+	fmt.Fprintln(writer, "Layers:")
+
+	counts := map[string]int{}
+	sizes := map[string]int64{}
+	markers := map[string]string{layerCommon: "=", layerRemoved: "-", layerAdded: "+"}
+
+	for _, layer := range diff.Layers {
+		counts[layer.Status]++
+		sizes[layer.Status] += layer.Size
+
+		createdBy := layer.CreatedBy
+		if !noTrunc {
+			createdBy = ellipsize(createdBy, createdByWidth, ellipsis)
+		}
+
+		writeInspectRow(writer, markers[layer.Status], layer.Digest, getSizeStr(layer.Size), createdBy)
+	}
+
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "Common:\t%s\n", getLayerCountStr(counts[layerCommon], sizes[layerCommon]))
+	fmt.Fprintf(writer, "Removed:\t%s\n", getLayerCountStr(counts[layerRemoved], sizes[layerRemoved]))
+	fmt.Fprintf(writer, "Added:\t%s\n", getLayerCountStr(counts[layerAdded], sizes[layerAdded]))
+
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+func getLayerCountStr(count int, size int64) string {
+	if count == 1 {
+		return "1 layer, " + getSizeStr(size)
+	}
+
+	return fmt.Sprintf("%d layers, %s", count, getSizeStr(size))
+}
+
+func getSizeStr(size int64) string {
+	return strings.ReplaceAll(humanize.Bytes(uint64(size)), " ", "")
+}
+
+func getShortDigest(digest string) string {
+	_, encoded, found := strings.Cut(digest, ":")
+	if !found || len(encoded) < layerWidth {
+		return digest
+	}
+
+	return encoded[:layerWidth]
+}
+
+func (diff imageDiffStruct) stringJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+func (diff imageDiffStruct) stringNDJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.Marshal(diff)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+func (diff imageDiffStruct) stringYAML() (string, error) {
+	body, err := yaml.Marshal(diff)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(body), nil
+}
@@ -4,10 +4,13 @@
 package client
 
 import (
+	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/spf13/cobra"
+
+	zerr "zotregistry.dev/zot/errors"
 )
 
 const (
@@ -27,22 +30,78 @@ func NewImageCommand(searchService SearchService) *cobra.Command {
 
 	imageCmd.SetUsageTemplate(imageCmd.UsageTemplate() + usageFooter)
 
-	imageCmd.PersistentFlags().String(URLFlag, "",
-		"Specify zot server URL if config-name is not mentioned")
+	imageCmd.PersistentFlags().StringSlice(URLFlag, nil,
+		"Specify zot server URL if config-name is not mentioned, the images can be listed from several of them")
 	imageCmd.PersistentFlags().String(ConfigFlag, "",
-		"Specify the registry configuration to use for connection")
+		"Specify the registry configuration to use for connection, the images can be listed from several "+
+			"comma separated ones")
+	_ = imageCmd.RegisterFlagCompletionFunc(ConfigFlag, completeConfigNames)
 	imageCmd.PersistentFlags().StringP(UserFlag, "u", "",
 		`User Credentials of zot server in "username:password" format`)
-	imageCmd.PersistentFlags().StringP(OutputFormatFlag, "f", "", "Specify output format [text/json/yaml]")
-	imageCmd.PersistentFlags().Bool(VerboseFlag, false, "Show verbose output")
-	imageCmd.PersistentFlags().Bool(DebugFlag, false, "Show debug output")
+	imageCmd.PersistentFlags().StringP(OutputFormatFlag, "f", "",
+		"Specify output format [text/json/ndjson/yaml/csv/tsv], "+
+			"or a Go template for the images, e.g. '{{.RepoName}}:{{.Tag}}'")
+	imageCmd.PersistentFlags().Bool(VerboseFlag, false,
+		"Show verbose output, with the config and layers of the images and, from the search extension, "+
+			"how many times and when they were last pulled")
+	imageCmd.PersistentFlags().Bool(DebugFlag, false,
+		"Show every request made to the registry on stderr, with its status, latency and request id")
+	imageCmd.PersistentFlags().Bool(DebugBodyFlag, false, "With --debug, also show the headers and the textual bodies")
+	imageCmd.PersistentFlags().Bool(NoCacheFlag, false, "Don't use the local cache of image manifests")
+	imageCmd.PersistentFlags().Bool(ProgressFlag, false, "Show the progress of the listing on stderr")
+	imageCmd.PersistentFlags().Bool(NoTruncFlag, false, "Show the full digests, authors and signers in the text output")
+	imageCmd.PersistentFlags().Bool(FailFastFlag, false,
+		"Stop at the first image which can't be fetched, instead of listing the others and the errors at the end")
+	imageCmd.PersistentFlags().Bool(VerifySignatureFlag, false,
+		"Only show the images as signed if a cosign signature is verified with one of the --public-key")
+	imageCmd.PersistentFlags().StringSlice(PublicKeyFlag, []string{},
+		"Public key file the cosign signatures are verified with, can be given several times")
+	imageCmd.PersistentFlags().String(FormatColumnsFlag, "",
+		fmt.Sprintf("Comma separated columns of the text output, in order, from: [%s]", imageColumnsStr()))
+	imageCmd.PersistentFlags().Int(MaxConcurrentRequestsFlag, defaultMaxConcurrentRequests,
+		"Maximum number of image manifests fetched at the same time")
+	imageCmd.PersistentFlags().Int(RequestsPerSecondFlag, defaultRequestsPerSecond,
+		"Maximum number of image manifest fetches started per second")
+	imageCmd.PersistentFlags().Int(MaxIdleConnsPerHostFlag, 0,
+		"Maximum number of idle connections kept open to the registry, 0 keeps one per concurrent request")
+	imageCmd.PersistentFlags().Duration(RequestTimeoutFlag, defaultRequestTimeout,
+		"Maximum time a single request to the registry can take")
+	imageCmd.PersistentFlags().Int(PageSizeFlag, 0,
+		"Number of entries to request per page when listing the catalog and the tags, 0 lets the registry decide")
+	imageCmd.PersistentFlags().Duration(TimeoutFlag, 0,
+		"Maximum time the whole search can take, the results received until then are still shown, 0 means no limit")
+	imageCmd.PersistentFlags().Int(RetriesFlag, defaultRetries,
+		"Number of times a request is retried when the registry is busy or the connection fails")
+	imageCmd.PersistentFlags().Duration(RetryMaxWaitFlag, defaultRetryMaxWait,
+		"Maximum time to wait between two retries of a request")
+	imageCmd.PersistentFlags().String(LimitRateFlag, "",
+		"Maximum bandwidth of all the transfers together, in bytes per second, e.g. 512KiB, empty means no limit")
+	imageCmd.PersistentFlags().String(CertFlag, "", "Client certificate file to present to the server")
+	imageCmd.PersistentFlags().String(KeyFlag, "", "Key file of the client certificate")
+	imageCmd.PersistentFlags().String(CACertFlag, "",
+		"CA certificate file used to verify the server, in addition to the system ones")
+	imageCmd.PersistentFlags().String(ProxyFlag, "",
+		"Proxy the server is reached through, an http, https or socks5 url [default: HTTPS_PROXY/NO_PROXY]")
 
 	imageCmd.AddCommand(NewImageListCommand(searchService))
+	imageCmd.AddCommand(NewImageDeleteCommand(searchService))
+	imageCmd.AddCommand(NewImageCopyCommand(searchService))
+	imageCmd.AddCommand(NewImagePullCommand(searchService))
+	imageCmd.AddCommand(NewImagePushCommand(searchService))
+	imageCmd.AddCommand(NewImageAnnotateCommand(searchService))
+	imageCmd.AddCommand(NewImageTagCommand(searchService))
+	imageCmd.AddCommand(NewImageUntagCommand(searchService))
 	imageCmd.AddCommand(NewImageCVEListCommand(searchService))
 	imageCmd.AddCommand(NewImageBaseCommand(searchService))
 	imageCmd.AddCommand(NewImageDerivedCommand(searchService))
 	imageCmd.AddCommand(NewImageDigestCommand(searchService))
 	imageCmd.AddCommand(NewImageNameCommand(searchService))
+	imageCmd.AddCommand(NewImageInspectCommand(searchService))
+	imageCmd.AddCommand(NewImageVerifyCommand(searchService))
+	imageCmd.AddCommand(NewImageCheckUpdateCommand(searchService))
+	imageCmd.AddCommand(NewImageHistoryCommand(searchService))
+	imageCmd.AddCommand(NewImageLayersCommand(searchService))
+	imageCmd.AddCommand(NewImageDiffCommand(searchService))
 
 	return imageCmd
 }
@@ -60,3 +119,22 @@ func parseBooleanConfig(configPath, configName, configParam string) (bool, error
 
 	return val, nil
 }
+
+// parseIntConfig returns 0 if the config doesn't set the given parameter.
+func parseIntConfig(configPath, configName, configParam string) (int, error) {
+	config, err := getConfigValue(configPath, configName, configParam)
+	if err != nil {
+		return 0, err
+	}
+
+	if config == "" {
+		return 0, nil
+	}
+
+	val, err := strconv.Atoi(config)
+	if err != nil {
+		return 0, fmt.Errorf("%w: '%s' should be a number, got '%s'", zerr.ErrCliBadConfig, configParam, config)
+	}
+
+	return val, nil
+}
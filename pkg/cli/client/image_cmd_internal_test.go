@@ -9,6 +9,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
@@ -25,6 +27,7 @@ import (
 	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api"
 	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/api/constants"
 	"zotregistry.dev/zot/pkg/common"
 	extconf "zotregistry.dev/zot/pkg/extensions/config"
 	stypes "zotregistry.dev/zot/pkg/storage/types"
@@ -885,6 +888,102 @@ func TestImagesCommandGQL(t *testing.T) {
 	})
 }
 
+// labelsGQLSearchService fails the client-side label search, the labels must be matched with the annotations
+// returned by the search extension.
+type labelsGQLSearchService struct {
+	searchService
+}
+
+func (service labelsGQLSearchService) getImagesByLabel(ctx context.Context, config SearchConfig, username,
+	password string, labels map[string]string, channel chan stringResult, wtgrp *sync.WaitGroup,
+) {
+	defer wtgrp.Done()
+	defer close(channel)
+
+	channel <- stringResult{"", zerr.ErrAPINotSupported}
+}
+
+func TestImageListLabelsGQL(t *testing.T) {
+	Convey("list the images by label with the search extension", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+			},
+		}
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+
+		cm.StartAndWait(conf.HTTP.Port)
+		defer cm.StopServer()
+
+		image := CreateImageWith().RandomLayers(1, 10).ImageConfig(ispec.Image{
+			Config: ispec.ImageConfig{Labels: map[string]string{"team": "infra"}},
+		}).Annotations(map[string]string{ispec.AnnotationVendor: "acme"}).Build()
+
+		So(UploadImage(image, baseURL, "repo", "labeled"), ShouldBeNil)
+		So(UploadImage(CreateRandomImage(), baseURL, "repo", "plain"), ShouldBeNil)
+
+		multiarch := CreateMultiarchWith().Images([]Image{image, CreateRandomImage()}).Build()
+		So(UploadMultiarchImage(multiarch, baseURL, "repo", "multiarch"), ShouldBeNil)
+
+		runImageList := func(args ...string) (string, error) {
+			cmd := NewImageCommand(labelsGQLSearchService{})
+			buff := bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs(append([]string{"list", "--url", baseURL}, args...))
+			err := cmd.Execute()
+
+			return buff.String(), err
+		}
+
+		output, err := runImageList("--label", ispec.AnnotationVendor+"=acme", "--label", "team=infra")
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "labeled")
+		// one of the manifests of the index has the labels
+		So(output, ShouldContainSubstring, "multiarch")
+		So(output, ShouldNotContainSubstring, "plain")
+
+		output, err = runImageList("--label", "team=dev")
+		So(err, ShouldBeNil)
+		So(output, ShouldNotContainSubstring, "labeled")
+	})
+
+	Convey("servers without the annotations in the search results are listed client-side", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			switch r.URL.Path {
+			case constants.RoutePrefix + constants.ExtOciDiscoverPrefix:
+				_, _ = w.Write([]byte(`{"extensions":[{"name":"_zot","endpoints":["` +
+					constants.FullSearchPrefix + `"]}]}`))
+			case constants.FullSearchPrefix:
+				_, _ = w.Write([]byte(`{"data":{"__schema":{"queryType":{"fields":[{"name":"ImageList",` +
+					`"args":[{"name":"repo"},{"name":"requestedPage"}]}]},"types":[` +
+					`{"name":"PaginatedImagesResult","fields":[{"name":"Results"}]},` +
+					`{"name":"ImageSummary","fields":[{"name":"RepoName"},{"name":"Manifests"}]}]}}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		cmd := NewImageCommand(labelsGQLSearchService{})
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs([]string{"list", "--url", server.URL, "--label", "team=infra"})
+		So(errors.Is(cmd.Execute(), zerr.ErrAPINotSupported), ShouldBeTrue)
+	})
+}
+
 func TestImageCommandREST(t *testing.T) {
 	port := test.GetFreePort()
 	baseURL := test.GetBaseURL(port)
@@ -982,6 +1081,53 @@ func TestImageCommandREST(t *testing.T) {
 			fmt.Println()
 		})
 
+		Convey("list command with labels", func() {
+			image := CreateImageWith().RandomLayers(1, 10).ImageConfig(ispec.Image{
+				Config: ispec.ImageConfig{Labels: map[string]string{"team": "infra"}},
+			}).Annotations(map[string]string{ispec.AnnotationVendor: "acme"}).Build()
+
+			err := UploadImage(image, baseURL, "repo", "labeled")
+			So(err, ShouldBeNil)
+
+			err = UploadImage(CreateRandomImage(), baseURL, "repo", "plain")
+			So(err, ShouldBeNil)
+
+			configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"imagetest","url":"%s","showspinner":false}]}`,
+				baseURL))
+			defer os.Remove(configPath)
+
+			args := []string{"list", "--config", "imagetest", "--label", ispec.AnnotationVendor + "=acme",
+				"--label", "team=infra"}
+			cmd := NewImageCommand(NewSearchService())
+			buff := bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs(args)
+			err = cmd.Execute()
+			So(err, ShouldBeNil)
+			So(buff.String(), ShouldContainSubstring, "labeled")
+			So(buff.String(), ShouldNotContainSubstring, "plain")
+
+			args = []string{"list", "--config", "imagetest", "--label", "team=dev"}
+			cmd = NewImageCommand(NewSearchService())
+			buff = bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs(args)
+			err = cmd.Execute()
+			So(err, ShouldBeNil)
+			So(buff.String(), ShouldNotContainSubstring, "labeled")
+
+			args = []string{"list", "--config", "imagetest", "--label", "team"}
+			cmd = NewImageCommand(NewSearchService())
+			buff = bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs(args)
+			err = cmd.Execute()
+			So(err, ShouldNotBeNil)
+		})
+
 		Convey("name command", func() {
 			image := CreateRandomImage()
 
@@ -1027,13 +1173,165 @@ func TestImageCommandREST(t *testing.T) {
 	})
 }
 
+func TestImageNamePatterns(t *testing.T) {
+	for _, searchEnabled := range []bool{true, false} {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		if searchEnabled {
+			conf.Extensions = &extconf.ExtensionConfig{
+				Search: &extconf.SearchConfig{
+					BaseConfig: extconf.BaseConfig{Enable: &searchEnabled},
+				},
+			}
+		}
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+
+		cm.StartAndWait(conf.HTTP.Port)
+
+		Convey(fmt.Sprintf("name patterns with search enabled: %v", searchEnabled), t, func() {
+			for _, image := range []string{"app/front:v1.0", "app/front:v2.0", "app/back:v1.1", "lib/util:v1.0"} {
+				repo, tag := common.GetImageDirAndTag(image)
+
+				err := UploadImage(CreateRandomImage(), baseURL, repo, tag)
+				So(err, ShouldBeNil)
+			}
+
+			configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"imagetest","url":"%s","showspinner":false}]}`,
+				baseURL))
+			defer os.Remove(configPath)
+
+			runNameCmd := func(args ...string) (string, error) {
+				cmd := NewImageCommand(NewSearchService())
+				buff := bytes.NewBufferString("")
+				cmd.SetOut(buff)
+				cmd.SetErr(buff)
+				cmd.SetArgs(append([]string{"name", "--config", "imagetest"}, args...))
+				err := cmd.Execute()
+
+				return buff.String(), err
+			}
+
+			Convey("glob repo", func() {
+				output, err := runNameCmd("app/*")
+				So(err, ShouldBeNil)
+				So(output, ShouldContainSubstring, "app/front")
+				So(output, ShouldContainSubstring, "app/back")
+				So(output, ShouldNotContainSubstring, "lib/util")
+			})
+
+			Convey("glob repo and tag", func() {
+				output, err := runNameCmd("app/*:v1.*")
+				So(err, ShouldBeNil)
+				So(output, ShouldContainSubstring, "v1.0")
+				So(output, ShouldContainSubstring, "v1.1")
+				So(output, ShouldNotContainSubstring, "v2.0")
+				So(output, ShouldNotContainSubstring, "lib/util")
+			})
+
+			Convey("regex", func() {
+				output, err := runNameCmd("--regex", "^(lib|app/b)")
+				So(err, ShouldBeNil)
+				So(output, ShouldContainSubstring, "app/back")
+				So(output, ShouldContainSubstring, "lib/util")
+				So(output, ShouldNotContainSubstring, "app/front")
+			})
+
+			Convey("no match", func() {
+				output, err := runNameCmd("none/*")
+				So(err, ShouldBeNil)
+				So(output, ShouldNotContainSubstring, "app/")
+			})
+
+			Convey("digest", func() {
+				image := CreateRandomImage()
+
+				for _, tag := range []string{"v3.0", "stable"} {
+					err := UploadImage(image, baseURL, "app/front", tag)
+					So(err, ShouldBeNil)
+				}
+
+				output, err := runNameCmd("app/front@" + image.DigestStr())
+				So(err, ShouldBeNil)
+				So(output, ShouldContainSubstring, "v3.0")
+				So(output, ShouldContainSubstring, "stable")
+				So(output, ShouldNotContainSubstring, "v1.0")
+				So(output, ShouldNotContainSubstring, "v2.0")
+
+				_, err = runNameCmd("app/front@sha256:bad")
+				So(errors.Is(err, zerr.ErrInvalidRepoRefFormat), ShouldBeTrue)
+			})
+
+			Convey("invalid patterns", func() {
+				_, err := runNameCmd("--regex", "app/(")
+				So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+				_, err = runNameCmd("app/[")
+				So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+			})
+		})
+
+		cm.StopServer()
+	}
+}
+
+func TestImageChanges(t *testing.T) {
+	Convey("Test the changes between two runs of a watched listing", t, func() {
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		previous := []imageStruct{
+			{RepoName: "app", Tag: "1.0", Digest: "sha256:1111111111111111"},
+			{RepoName: "app", Tag: "2.0", Digest: "sha256:2222222222222222"},
+			{RepoName: "lib", Tag: "1.0", Digest: "sha256:3333333333333333"},
+		}
+		current := []imageStruct{
+			{RepoName: "lib", Tag: "1.0", Digest: "sha256:3333333333333333"},
+			{RepoName: "app", Tag: "1.0", Digest: "sha256:4444444444444444"},
+			{RepoName: "app", Tag: "0.9", Digest: "sha256:5555555555555555"},
+		}
+
+		changes := getImageChanges(previous, current, now)
+		So(changes, ShouldResemble, imageChanges{
+			{Time: now, Change: imageAdded, RepoName: "app", Tag: "0.9", Digest: "sha256:5555555555555555"},
+			{Time: now, Change: imageUpdated, RepoName: "app", Tag: "1.0", Digest: "sha256:4444444444444444",
+				PreviousDigest: "sha256:1111111111111111"},
+			{Time: now, Change: imageRemoved, RepoName: "app", Tag: "2.0", Digest: "sha256:2222222222222222"},
+		})
+
+		So(getImageChanges(current, current, now), ShouldBeEmpty)
+
+		output, err := changes.string("text", false)
+		So(err, ShouldBeNil)
+		So(output, ShouldEqual, "2024-01-01T00:00:00Z  ADDED    app:0.9  55555555\n"+
+			"2024-01-01T00:00:00Z  UPDATED  app:1.0  11111111 -> 44444444\n"+
+			"2024-01-01T00:00:00Z  REMOVED  app:2.0  22222222\n")
+
+		output, err = changes.string("yaml", false)
+		So(err, ShouldBeNil)
+		So(strings.Count(output, "---\n"), ShouldEqual, 3)
+
+		_, err = changes.string("csv", false)
+		So(err, ShouldEqual, zerr.ErrInvalidOutputFormat)
+	})
+}
+
 type mockService struct {
 	getAllImagesFn func(ctx context.Context, config SearchConfig, username, password string,
 		channel chan stringResult, wtgrp *sync.WaitGroup)
 
+	getImagesByLabelFn func(ctx context.Context, config SearchConfig, username, password string,
+		labels map[string]string, channel chan stringResult, wtgrp *sync.WaitGroup)
+
 	getImagesGQLFn func(ctx context.Context, config SearchConfig, username, password string,
 		imageName string) (*common.ImageListResponse, error)
 
+	getAnnotatedImagesGQLFn func(ctx context.Context, config SearchConfig, username, password string,
+	) (*annotatedImageListResponse, error)
+
 	getImageByNameFn func(ctx context.Context, config SearchConfig,
 		username, password, imageName string, channel chan stringResult, wtgrp *sync.WaitGroup,
 	)
@@ -1050,6 +1348,24 @@ type mockService struct {
 		query string,
 	) (*common.GlobalSearch, error)
 
+	getRepoActivityGQLFn func(ctx context.Context, config SearchConfig, username, password string,
+		repo, reference string) (*common.RepoActivityResp, error)
+
+	getStarredReposGQLFn func(ctx context.Context, config SearchConfig, username, password string,
+	) (*common.PaginatedReposResult, error)
+
+	getBookmarkedReposGQLFn func(ctx context.Context, config SearchConfig, username, password string,
+	) (*common.PaginatedReposResult, error)
+
+	getRepoSummaryGQLFn func(ctx context.Context, config SearchConfig, username, password, repo string,
+	) (*common.RepoSummary, error)
+
+	toggleRepoPreferenceFn func(ctx context.Context, config SearchConfig, username, password, repo, action string,
+	) error
+
+	getImageLayersGQLFn func(ctx context.Context, config SearchConfig, username, password string,
+		image string) (*common.ImageLayersResp, error)
+
 	getReferrersGQLFn func(ctx context.Context, config SearchConfig, username, password string,
 		repo, digest string,
 	) (*common.ReferrersResp, error)
@@ -1077,6 +1393,228 @@ type mockService struct {
 	getFixedTagsForCVEGQLFn func(ctx context.Context, config SearchConfig, username, password,
 		imageName, cveID string,
 	) (*common.ImageListWithCVEFixedResponse, error)
+
+	getTagsFn func(ctx context.Context, config SearchConfig, username, password, repo string,
+	) ([]string, error)
+
+	deleteImageFn func(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+	) error
+
+	getRepoStatsFn func(ctx context.Context, config SearchConfig, username, password string,
+	) ([]repoStatsStruct, error)
+
+	getStorageStatsFn func(ctx context.Context, config SearchConfig, username, password string,
+	) (*storageStatsStruct, error)
+
+	inspectImageFn func(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+	) (*imageInspectStruct, error)
+
+	verifyImageFn func(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+	) (*imageVerifyStruct, error)
+
+	copyImageFn func(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+		destConfig SearchConfig, destRepo, destReference string) error
+
+	getManifestDigestFn func(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+	) (string, error)
+
+	getImageCreatedFn func(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+	) (time.Time, error)
+
+	pullImageFn func(ctx context.Context, config SearchConfig, username, password, repo, reference,
+		layoutPath string) error
+
+	pushImageFn func(ctx context.Context, config SearchConfig, username, password, layoutPath, repo, tag string,
+		chunkSize int64) (string, error)
+
+	annotateImageFn func(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+		annotations map[string]string, removed []string, tag string) (godigest.Digest, error)
+
+	tagImageFn func(ctx context.Context, config SearchConfig, username, password, repo, reference, tag string,
+	) (godigest.Digest, error)
+
+	getSBOMsFn func(ctx context.Context, config SearchConfig, username, password, repo, digest string,
+	) ([]sbomDocument, error)
+
+	getArtifactsFn func(ctx context.Context, config SearchConfig, username, password, repo, digest,
+		artifactType string) (artifactsResult, error)
+
+	getImageHistoryFn func(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+	) (*imageHistoryStruct, error)
+
+	diffImagesFn func(ctx context.Context, config SearchConfig, username, password, fromRepo, fromReference,
+		toRepo, toReference, platform string) (*imageDiffStruct, error)
+}
+
+func (service mockService) copyImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string, destConfig SearchConfig, destRepo, destReference string,
+) error {
+	if service.copyImageFn != nil {
+		return service.copyImageFn(ctx, config, username, password, repo, reference, destConfig, destRepo, destReference)
+	}
+
+	return nil
+}
+
+func (service mockService) getManifestDigest(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string,
+) (string, error) {
+	if service.getManifestDigestFn != nil {
+		return service.getManifestDigestFn(ctx, config, username, password, repo, reference)
+	}
+
+	return "", zerr.ErrURLNotFound
+}
+
+func (service mockService) getImageCreated(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string,
+) (time.Time, error) {
+	if service.getImageCreatedFn != nil {
+		return service.getImageCreatedFn(ctx, config, username, password, repo, reference)
+	}
+
+	return time.Time{}, nil
+}
+
+func (service mockService) pullImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference, layoutPath string,
+) error {
+	if service.pullImageFn != nil {
+		return service.pullImageFn(ctx, config, username, password, repo, reference, layoutPath)
+	}
+
+	return nil
+}
+
+func (service mockService) pushImage(ctx context.Context, config SearchConfig, username, password,
+	layoutPath, repo, tag string, chunkSize int64,
+) (string, error) {
+	if service.pushImageFn != nil {
+		return service.pushImageFn(ctx, config, username, password, layoutPath, repo, tag, chunkSize)
+	}
+
+	return tag, nil
+}
+
+func (service mockService) annotateImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string, annotations map[string]string, removed []string, tag string,
+) (godigest.Digest, error) {
+	if service.annotateImageFn != nil {
+		return service.annotateImageFn(ctx, config, username, password, repo, reference, annotations, removed, tag)
+	}
+
+	return "", nil
+}
+
+func (service mockService) tagImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference, tag string,
+) (godigest.Digest, error) {
+	if service.tagImageFn != nil {
+		return service.tagImageFn(ctx, config, username, password, repo, reference, tag)
+	}
+
+	return "", nil
+}
+
+func (service mockService) getSBOMs(ctx context.Context, config SearchConfig, username, password,
+	repo, digest string,
+) ([]sbomDocument, error) {
+	if service.getSBOMsFn != nil {
+		return service.getSBOMsFn(ctx, config, username, password, repo, digest)
+	}
+
+	return []sbomDocument{}, nil
+}
+
+func (service mockService) getArtifacts(ctx context.Context, config SearchConfig, username, password,
+	repo, digest, artifactType string,
+) (artifactsResult, error) {
+	if service.getArtifactsFn != nil {
+		return service.getArtifactsFn(ctx, config, username, password, repo, digest, artifactType)
+	}
+
+	return artifactsResult{}, nil
+}
+
+func (service mockService) getImageHistory(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string,
+) (*imageHistoryStruct, error) {
+	if service.getImageHistoryFn != nil {
+		return service.getImageHistoryFn(ctx, config, username, password, repo, reference)
+	}
+
+	return &imageHistoryStruct{RepoName: repo, Reference: reference}, nil
+}
+
+func (service mockService) diffImages(ctx context.Context, config SearchConfig, username, password,
+	fromRepo, fromReference, toRepo, toReference, platform string,
+) (*imageDiffStruct, error) {
+	if service.diffImagesFn != nil {
+		return service.diffImagesFn(ctx, config, username, password, fromRepo, fromReference, toRepo, toReference,
+			platform)
+	}
+
+	return &imageDiffStruct{}, nil
+}
+
+func (service mockService) inspectImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string,
+) (*imageInspectStruct, error) {
+	if service.inspectImageFn != nil {
+		return service.inspectImageFn(ctx, config, username, password, repo, reference)
+	}
+
+	return &imageInspectStruct{RepoName: repo, Reference: reference}, nil
+}
+
+func (service mockService) verifyImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string,
+) (*imageVerifyStruct, error) {
+	if service.verifyImageFn != nil {
+		return service.verifyImageFn(ctx, config, username, password, repo, reference)
+	}
+
+	return &imageVerifyStruct{RepoName: repo, Reference: reference}, nil
+}
+
+func (service mockService) getRepoStats(ctx context.Context, config SearchConfig, username, password string,
+) ([]repoStatsStruct, error) {
+	if service.getRepoStatsFn != nil {
+		return service.getRepoStatsFn(ctx, config, username, password)
+	}
+
+	return []repoStatsStruct{
+		{Name: "repo1", TagCount: 2, Size: "1500", LastUpdated: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "repo2", TagCount: 0, Size: "0"},
+	}, nil
+}
+
+func (service mockService) getStorageStats(ctx context.Context, config SearchConfig, username, password string,
+) (*storageStatsStruct, error) {
+	if service.getStorageStatsFn != nil {
+		return service.getStorageStatsFn(ctx, config, username, password)
+	}
+
+	return getStorageStats([]string{"repo1"}, nil), nil
+}
+
+func (service mockService) getTags(ctx context.Context, config SearchConfig, username, password, repo string,
+) ([]string, error) {
+	if service.getTagsFn != nil {
+		return service.getTagsFn(ctx, config, username, password, repo)
+	}
+
+	return []string{"tag1", "tag2"}, nil
+}
+
+func (service mockService) deleteImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string,
+) error {
+	if service.deleteImageFn != nil {
+		return service.deleteImageFn(ctx, config, username, password, repo, reference)
+	}
+
+	return nil
 }
 
 func (service mockService) getRepos(ctx context.Context, config SearchConfig, username,
@@ -1140,6 +1678,65 @@ func (service mockService) globalSearchGQL(ctx context.Context, config SearchCon
 	}, nil
 }
 
+func (service mockService) getRepoActivityGQL(ctx context.Context, config SearchConfig, username, password string,
+	repo, reference string,
+) (*common.RepoActivityResp, error) {
+	if service.getRepoActivityGQLFn != nil {
+		return service.getRepoActivityGQLFn(ctx, config, username, password, repo, reference)
+	}
+
+	return &common.RepoActivityResp{}, nil
+}
+
+func (service mockService) getStarredReposGQL(ctx context.Context, config SearchConfig, username, password string,
+) (*common.PaginatedReposResult, error) {
+	if service.getStarredReposGQLFn != nil {
+		return service.getStarredReposGQLFn(ctx, config, username, password)
+	}
+
+	return &common.PaginatedReposResult{}, nil
+}
+
+func (service mockService) getBookmarkedReposGQL(ctx context.Context, config SearchConfig, username,
+	password string,
+) (*common.PaginatedReposResult, error) {
+	if service.getBookmarkedReposGQLFn != nil {
+		return service.getBookmarkedReposGQLFn(ctx, config, username, password)
+	}
+
+	return &common.PaginatedReposResult{}, nil
+}
+
+func (service mockService) getRepoSummaryGQL(ctx context.Context, config SearchConfig, username, password,
+	repo string,
+) (*common.RepoSummary, error) {
+	if service.getRepoSummaryGQLFn != nil {
+		return service.getRepoSummaryGQLFn(ctx, config, username, password, repo)
+	}
+
+	return &common.RepoSummary{Name: repo}, nil
+}
+
+func (service mockService) toggleRepoPreference(ctx context.Context, config SearchConfig, username, password,
+	repo, action string,
+) error {
+	if service.toggleRepoPreferenceFn != nil {
+		return service.toggleRepoPreferenceFn(ctx, config, username, password, repo, action)
+	}
+
+	return nil
+}
+
+func (service mockService) getImageLayersGQL(ctx context.Context, config SearchConfig, username, password string,
+	image string,
+) (*common.ImageLayersResp, error) {
+	if service.getImageLayersGQLFn != nil {
+		return service.getImageLayersGQLFn(ctx, config, username, password, image)
+	}
+
+	return &common.ImageLayersResp{}, nil
+}
+
 func (service mockService) getReferrersGQL(ctx context.Context, config SearchConfig, username, password string,
 	repo, digest string,
 ) (*common.ReferrersResp, error) {
@@ -1247,6 +1844,16 @@ func (service mockService) getImagesGQL(ctx context.Context, config SearchConfig
 	return imageListGQLResponse, nil
 }
 
+func (service mockService) getAnnotatedImagesGQL(ctx context.Context, config SearchConfig, username,
+	password string,
+) (*annotatedImageListResponse, error) {
+	if service.getAnnotatedImagesGQLFn != nil {
+		return service.getAnnotatedImagesGQLFn(ctx, config, username, password)
+	}
+
+	return &annotatedImageListResponse{}, nil
+}
+
 func (service mockService) getImagesForDigestGQL(ctx context.Context, config SearchConfig, username, password string,
 	digest string,
 ) (*common.ImagesForDigest, error) {
@@ -1413,7 +2020,8 @@ func (service mockService) getAllImages(ctx context.Context, config SearchConfig
 	}
 	image.Size = "123445"
 
-	str, err := image.string(config.OutputFormat, len(image.RepoName), len(image.Tag), len("os/Arch"), config.Verbose)
+	str, err := image.string(config.OutputFormat, len(image.RepoName), len(image.Tag), len("os/Arch"), config.Verbose,
+		getImageLayout(config))
 	if err != nil {
 		channel <- stringResult{"", err}
 
@@ -1423,6 +2031,21 @@ func (service mockService) getAllImages(ctx context.Context, config SearchConfig
 	channel <- stringResult{str, nil}
 }
 
+func (service mockService) getImagesByLabel(ctx context.Context, config SearchConfig, username, password string,
+	labels map[string]string, channel chan stringResult, wtgrp *sync.WaitGroup,
+) {
+	if service.getImagesByLabelFn != nil {
+		defer wtgrp.Done()
+		defer close(channel)
+
+		service.getImagesByLabelFn(ctx, config, username, password, labels, channel, wtgrp)
+
+		return
+	}
+
+	service.getAllImages(ctx, config, username, password, channel, wtgrp)
+}
+
 func (service mockService) getImageByName(ctx context.Context, config SearchConfig,
 	username, password, imageName string, channel chan stringResult, wtgrp *sync.WaitGroup,
 ) {
@@ -1451,7 +2074,8 @@ func (service mockService) getImageByName(ctx context.Context, config SearchConf
 	}
 	image.Size = "123445"
 
-	str, err := image.string(config.OutputFormat, len(image.RepoName), len(image.Tag), len("os/Arch"), config.Verbose)
+	str, err := image.string(config.OutputFormat, len(image.RepoName), len(image.Tag), len("os/Arch"), config.Verbose,
+		getImageLayout(config))
 	if err != nil {
 		channel <- stringResult{"", err}
 
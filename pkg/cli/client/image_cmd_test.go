@@ -7,11 +7,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -23,9 +28,11 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 	"gopkg.in/resty.v1"
 
+	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api"
 	"zotregistry.dev/zot/pkg/api/config"
 	"zotregistry.dev/zot/pkg/cli/client"
+	"zotregistry.dev/zot/pkg/common"
 	extconf "zotregistry.dev/zot/pkg/extensions/config"
 	zlog "zotregistry.dev/zot/pkg/log"
 	test "zotregistry.dev/zot/pkg/test/common"
@@ -220,6 +227,99 @@ func TestSignature(t *testing.T) {
 		So(actual, ShouldContainSubstring, "REPOSITORY TAG OS/ARCH DIGEST SIGNED SIZE")
 		So(actual, ShouldContainSubstring, "repo7 0.0.1 linux/amd64 db573b01 true 854B")
 	})
+
+	Convey("Test verifying cosign signatures with public keys", t, func() {
+		currentWorkingDir, err := os.Getwd()
+		So(err, ShouldBeNil)
+
+		defer func() { _ = os.Chdir(currentWorkingDir) }()
+
+		otherKeyDir := t.TempDir()
+		err = os.Chdir(otherKeyDir)
+		So(err, ShouldBeNil)
+
+		os.Setenv("COSIGN_PASSWORD", "")
+		err = generate.GenerateKeyPairCmd(context.TODO(), "", "cosign", nil)
+		So(err, ShouldBeNil)
+
+		currentDir := t.TempDir()
+		err = os.Chdir(currentDir)
+		So(err, ShouldBeNil)
+
+		err = generate.GenerateKeyPairCmd(context.TODO(), "", "cosign", nil)
+		So(err, ShouldBeNil)
+
+		for _, searchEnabled := range []bool{true, false} {
+			port := test.GetFreePort()
+			url := test.GetBaseURL(port)
+			conf := config.New()
+			conf.HTTP.Port = port
+			conf.Extensions = &extconf.ExtensionConfig{
+				Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &searchEnabled}},
+			}
+			ctlr := api.NewController(conf)
+			ctlr.Config.Storage.RootDirectory = t.TempDir()
+			cm := test.NewControllerManager(ctlr)
+			cm.StartAndWait(conf.HTTP.Port)
+
+			image := CreateDefaultImage()
+			err = UploadImage(image, url, repoName, "1.0")
+			So(err, ShouldBeNil)
+
+			err = UploadImage(CreateRandomImage(), url, repoName, "unsigned")
+			So(err, ShouldBeNil)
+
+			err = sign.SignCmd(&options.RootOptions{Verbose: true, Timeout: 1 * time.Minute},
+				options.KeyOpts{KeyRef: path.Join(currentDir, "cosign.key"), PassFunc: generate.GetPass},
+				options.SignOptions{
+					Registry: options.RegistryOptions{AllowInsecure: true},
+					Upload:   true,
+				},
+				[]string{fmt.Sprintf("localhost:%s/%s@%s", port, repoName, image.DigestStr())})
+			So(err, ShouldBeNil)
+
+			runList := func(args ...string) (string, error) {
+				cmd := client.NewImageCommand(client.NewSearchService())
+				buff := bytes.NewBufferString("")
+				cmd.SetOut(buff)
+				cmd.SetErr(buff)
+				cmd.SetArgs(append([]string{"list", "--url", url, "--format-columns", "repository,tag,signed,signer"},
+					args...))
+				err := cmd.Execute()
+
+				return strings.TrimSpace(space.ReplaceAllString(buff.String(), " ")), err
+			}
+
+			actual, err := runList("--verify-signature", "--public-key", path.Join(currentDir, "cosign.pub"))
+			So(err, ShouldBeNil)
+			So(actual, ShouldContainSubstring, "REPOSITORY TAG SIGNED SIGNER")
+			So(actual, ShouldContainSubstring, "repo7 1.0 true cosign:cosign.pub")
+			So(actual, ShouldContainSubstring, "repo7 unsigned false")
+
+			actual, err = runList("--verify-signature", "--public-key", path.Join(otherKeyDir, "cosign.pub"))
+			So(err, ShouldBeNil)
+			So(actual, ShouldContainSubstring, "repo7 1.0 false")
+
+			actual, err = runList("--verify-signature", "--public-key", path.Join(otherKeyDir, "cosign.pub"),
+				"--public-key", path.Join(currentDir, "cosign.pub"), "-f", "json")
+			So(err, ShouldBeNil)
+			So(actual, ShouldContainSubstring, `"isTrusted":true,"author":"cosign.pub"`)
+
+			_, err = runList("--verify-signature")
+			So(err, ShouldNotBeNil)
+
+			_, err = runList("--public-key", path.Join(currentDir, "cosign.pub"))
+			So(err, ShouldNotBeNil)
+
+			_, err = runList("--verify-signature", "--public-key", path.Join(currentDir, "cosign.key"))
+			So(err, ShouldNotBeNil)
+
+			_, err = runList("--verify-signature", "--public-key", path.Join(currentDir, "missing.pub"))
+			So(err, ShouldNotBeNil)
+
+			cm.StopServer()
+		}
+	})
 }
 
 //nolint:dupl
@@ -264,6 +364,44 @@ func TestDerivedImageList(t *testing.T) {
 			So(actual, ShouldContainSubstring, "repo7 test:1.0 linux/amd64 9d9461ed false 860B")
 		})
 
+		Convey("Test image list --derived-from", func() {
+			cmd := client.NewImageCommand(client.NewSearchService())
+			buff := &bytes.Buffer{}
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs([]string{"list", "--url", url, "--derived-from", "repo7:test:2.0"})
+			err := cmd.Execute()
+			So(err, ShouldBeNil)
+
+			actual := strings.TrimSpace(space.ReplaceAllString(buff.String(), " "))
+			So(actual, ShouldContainSubstring, "repo7 test:1.0 linux/amd64 9d9461ed false 860B")
+			So(actual, ShouldNotContainSubstring, "test:2.0")
+		})
+
+		Convey("Test image list --derived-from with other filters", func() {
+			cmd := client.NewImageCommand(client.NewSearchService())
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs([]string{"list", "--url", url, "--derived-from", "repo7:test:2.0", "--base-of", "repo7:test:1.0"})
+			err := cmd.Execute()
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			cmd = client.NewImageCommand(client.NewSearchService())
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs([]string{"list", "--url", url, "--derived-from", "repo7:test:2.0", "--label", "a=b"})
+			err = cmd.Execute()
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			cmd = client.NewImageCommand(client.NewSearchService())
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs([]string{"list", "--url", url, "--url", test.GetBaseURL(test.GetFreePort()), "--derived-from",
+				"repo7:test:2.0"})
+			err = cmd.Execute()
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+		})
+
 		Convey("Test derived images list fails", func() {
 			buff := &bytes.Buffer{}
 			searchConfig.ResultWriter = buff
@@ -321,6 +459,20 @@ func TestBaseImageList(t *testing.T) {
 			So(actual, ShouldContainSubstring, "repo7 test:2.0 linux/amd64 214e4bed false 530B")
 		})
 
+		Convey("Test image list --base-of", func() {
+			cmd := client.NewImageCommand(client.NewSearchService())
+			buff := &bytes.Buffer{}
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs([]string{"list", "--url", url, "--base-of", "repo7:test:1.0"})
+			err := cmd.Execute()
+			So(err, ShouldBeNil)
+
+			actual := strings.TrimSpace(space.ReplaceAllString(buff.String(), " "))
+			So(actual, ShouldContainSubstring, "repo7 test:2.0 linux/amd64 214e4bed false 530B")
+			So(actual, ShouldNotContainSubstring, "test:1.0")
+		})
+
 		Convey("Test base images list fail", func() {
 			buff := &bytes.Buffer{}
 			searchConfig.ResultWriter = buff
@@ -381,11 +533,11 @@ func TestOutputFormatGQL(t *testing.T) {
 				`"sha256:b8781e8844f5b7bf6f2f8fa343de18ec471c3b278027355bc34c120585ff04f6","score":0}],` +
 				`"history":null,"vulnerabilities":{"maxSeverity":"","unknownCount":0,"lowCount":0,"mediumCount":0,` +
 				`"highCount":0,"criticalCount":0,"count":0},` +
-				`"referrers":null,"artifactType":"","signatureInfo":null}],` +
+				`"referrers":null,"artifactType":"","signatureInfo":[]}],` +
 				`"size":"528","downloadCount":0,"lastUpdated":"2023-01-01T12:00:00Z","description":"","isSigned":false,` +
-				`"licenses":"","labels":"","title":"","source":"","documentation":"","authors":"","vendor":"",` +
+				`"licenses":"","labels":"","title":"","source":"","documentation":"","authors":"some author","vendor":"",` +
 				`"vulnerabilities":{"maxSeverity":"","unknownCount":0,"lowCount":0,"mediumCount":0,` +
-				`"highCount":0,"criticalCount":0,"count":0},"referrers":null,"signatureInfo":null}` + "\n" +
+				`"highCount":0,"criticalCount":0,"count":0},"referrers":null,"signatureInfo":[]}` + "\n" +
 				`{"repoName":"repo7","tag":"test:2.0",` +
 				`"digest":"sha256:51e18f508fd7125b0831ff9a22ba74cd79f0b934e77661ff72cfb54896951a06",` +
 				`"mediaType":"application/vnd.oci.image.manifest.v1+json",` +
@@ -396,11 +548,11 @@ func TestOutputFormatGQL(t *testing.T) {
 				`"sha256:b8781e8844f5b7bf6f2f8fa343de18ec471c3b278027355bc34c120585ff04f6","score":0}],` +
 				`"history":null,"vulnerabilities":{"maxSeverity":"","unknownCount":0,"lowCount":0,"mediumCount":0,` +
 				`"highCount":0,"criticalCount":0,"count":0},` +
-				`"referrers":null,"artifactType":"","signatureInfo":null}],` +
+				`"referrers":null,"artifactType":"","signatureInfo":[]}],` +
 				`"size":"528","downloadCount":0,"lastUpdated":"2023-01-01T12:00:00Z","description":"","isSigned":false,` +
-				`"licenses":"","labels":"","title":"","source":"","documentation":"","authors":"","vendor":"",` +
+				`"licenses":"","labels":"","title":"","source":"","documentation":"","authors":"some author","vendor":"",` +
 				`"vulnerabilities":{"maxSeverity":"","unknownCount":0,"lowCount":0,"mediumCount":0,` +
-				`"highCount":0,"criticalCount":0,"count":0},"referrers":null,"signatureInfo":null}` + "\n"
+				`"highCount":0,"criticalCount":0,"count":0},"referrers":null,"signatureInfo":[]}` + "\n"
 			// Output is supposed to be in json lines format, keep all spaces as is for verification
 			So(buff.String(), ShouldEqual, expectedStr)
 			So(err, ShouldBeNil)
@@ -432,7 +584,7 @@ func TestOutputFormatGQL(t *testing.T) {
 				`referrers: [] artifacttype: "" signatureinfo: [] ` +
 				`size: "528" downloadcount: 0 lastupdated: 2023-01-01T12:00:00Z description: "" ` +
 				`issigned: false licenses: "" labels: "" title: "" source: "" documentation: "" ` +
-				`authors: "" vendor: "" vulnerabilities: maxseverity: "" ` +
+				`authors: some author vendor: "" vulnerabilities: maxseverity: "" ` +
 				`unknowncount: 0 lowcount: 0 mediumcount: 0 highcount: 0 criticalcount: 0 count: 0 ` +
 				`referrers: [] signatureinfo: [] ` +
 				`--- reponame: repo7 tag: test:2.0 ` +
@@ -448,7 +600,7 @@ func TestOutputFormatGQL(t *testing.T) {
 				`referrers: [] artifacttype: "" signatureinfo: [] ` +
 				`size: "528" downloadcount: 0 lastupdated: 2023-01-01T12:00:00Z description: "" ` +
 				`issigned: false licenses: "" labels: "" title: "" source: "" documentation: "" ` +
-				`authors: "" vendor: "" vulnerabilities: maxseverity: "" ` +
+				`authors: some author vendor: "" vulnerabilities: maxseverity: "" ` +
 				`unknowncount: 0 lowcount: 0 mediumcount: 0 highcount: 0 criticalcount: 0 count: 0 ` +
 				`referrers: [] signatureinfo: []`
 			So(strings.TrimSpace(str), ShouldEqual, expectedStr)
@@ -481,7 +633,7 @@ func TestOutputFormatGQL(t *testing.T) {
 				`referrers: [] artifacttype: "" signatureinfo: [] ` +
 				`size: "528" downloadcount: 0 lastupdated: 2023-01-01T12:00:00Z description: "" ` +
 				`issigned: false licenses: "" labels: "" title: "" source: "" documentation: "" ` +
-				`authors: "" vendor: "" vulnerabilities: maxseverity: "" ` +
+				`authors: some author vendor: "" vulnerabilities: maxseverity: "" ` +
 				`unknowncount: 0 lowcount: 0 mediumcount: 0 highcount: 0 criticalcount: 0 count: 0 ` +
 				`referrers: [] signatureinfo: [] ` +
 				`--- reponame: repo7 tag: test:2.0 ` +
@@ -497,7 +649,7 @@ func TestOutputFormatGQL(t *testing.T) {
 				`referrers: [] artifacttype: "" signatureinfo: [] ` +
 				`size: "528" downloadcount: 0 lastupdated: 2023-01-01T12:00:00Z description: "" ` +
 				`issigned: false licenses: "" labels: "" title: "" source: "" documentation: "" ` +
-				`authors: "" vendor: "" vulnerabilities: maxseverity: "" ` +
+				`authors: some author vendor: "" vulnerabilities: maxseverity: "" ` +
 				`unknowncount: 0 lowcount: 0 mediumcount: 0 highcount: 0 criticalcount: 0 count: 0 ` +
 				`referrers: [] signatureinfo: []`
 			So(strings.TrimSpace(str), ShouldEqual, expectedStr)
@@ -588,14 +740,43 @@ func TestServerResponseGQL(t *testing.T) {
 			str := space.ReplaceAllString(buff.String(), " ")
 			actual := strings.TrimSpace(str)
 			// Actual cli output should be something similar to (order of images may differ):
-			// REPOSITORY    TAG       OS/ARCH     DIGEST    CONFIG    SIGNED  LAYERS    SIZE
-			// repo7         test:2.0  linux/amd64 51e18f50  d14faead  false             528B
+			// REPOSITORY    TAG       OS/ARCH     DIGEST    CONFIG    SIGNED  LAYERS    SIZE  PULLS  LAST PULL
+			// repo7         test:2.0  linux/amd64 51e18f50  d14faead  false             528B  0      N/A
 			//                                                                 b8781e88  15B
-			// repo7         test:1.0  linux/amd64 51e18f50  d14faead  false             528B
+			// repo7         test:1.0  linux/amd64 51e18f50  d14faead  false             528B  0      N/A
 			//                                                                 b8781e88  15B
-			So(actual, ShouldContainSubstring, "REPOSITORY TAG OS/ARCH DIGEST CONFIG SIGNED LAYERS SIZE")
-			So(actual, ShouldContainSubstring, "repo7 test:2.0 linux/amd64 51e18f50 d14faead false 528B b8781e88 15B")
-			So(actual, ShouldContainSubstring, "repo7 test:1.0 linux/amd64 51e18f50 d14faead false 528B b8781e88 15B")
+			So(actual, ShouldContainSubstring, "REPOSITORY TAG OS/ARCH DIGEST CONFIG SIGNED LAYERS SIZE PULLS LAST PULL")
+			So(actual, ShouldContainSubstring, "repo7 test:2.0 linux/amd64 51e18f50 d14faead false 528B 0 N/A b8781e88 15B")
+			So(actual, ShouldContainSubstring, "repo7 test:1.0 linux/amd64 51e18f50 d14faead false 528B 0 N/A b8781e88 15B")
+		})
+
+		Convey("Test all images verbose with pulls", func() {
+			for i := 0; i < 2; i++ {
+				resp, err := resty.R().Get(url + "/v2/repo7/manifests/test:1.0")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			}
+
+			args := []string{
+				"list", "--config", "imagetest", "--verbose", "--format-columns", "repository,tag,pulls,last pull",
+			}
+			configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"imagetest","url":"%s","showspinner":false}]}`, url))
+			defer os.Remove(configPath)
+			cmd := client.NewImageCommand(client.NewSearchService())
+			buff := bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs(args)
+			err := cmd.Execute()
+			So(err, ShouldBeNil)
+			space := regexp.MustCompile(`\s+`)
+			str := space.ReplaceAllString(buff.String(), " ")
+			actual := strings.TrimSpace(str)
+			So(actual, ShouldContainSubstring, "REPOSITORY TAG PULLS LAST PULL")
+			// the statistics are the ones of the manifest, which both tags point to
+			lastPull := time.Now().UTC().Format("2006-01-02")
+			So(actual, ShouldContainSubstring, "repo7 test:1.0 2 "+lastPull)
+			So(actual, ShouldContainSubstring, "repo7 test:2.0 2 "+lastPull)
 		})
 
 		Convey("Test all images with debug flag", func() {
@@ -789,14 +970,14 @@ func TestServerResponse(t *testing.T) {
 			str := space.ReplaceAllString(buff.String(), " ")
 			actual := strings.TrimSpace(str)
 			// Actual cli output should be something similar to (order of images may differ):
-			// REPOSITORY    TAG        OS/ARCH     DIGEST    CONFIG     SIGNED  LAYERS    SIZE
-			// repo7         test:2.0   linux/amd64 51e18f50  d14faead   false             528B
+			// REPOSITORY    TAG        OS/ARCH     DIGEST    CONFIG     SIGNED  LAYERS    SIZE  PULLS  LAST PULL
+			// repo7         test:2.0   linux/amd64 51e18f50  d14faead   false             528B  0      N/A
 			//                                                                    b8781e88  15B
-			// repo7         test:1.0   linux/amd64 51e18f50  d14faead   false             528B
+			// repo7         test:1.0   linux/amd64 51e18f50  d14faead   false             528B  0      N/A
 			//                                                                    b8781e88  15B
-			So(actual, ShouldContainSubstring, "REPOSITORY TAG OS/ARCH DIGEST CONFIG SIGNED LAYERS SIZE")
-			So(actual, ShouldContainSubstring, "repo7 test:2.0 linux/amd64 51e18f50 d14faead false 528B b8781e88 15B")
-			So(actual, ShouldContainSubstring, "repo7 test:1.0 linux/amd64 51e18f50 d14faead false 528B b8781e88 15B")
+			So(actual, ShouldContainSubstring, "REPOSITORY TAG OS/ARCH DIGEST CONFIG SIGNED LAYERS SIZE PULLS LAST PULL")
+			So(actual, ShouldContainSubstring, "repo7 test:2.0 linux/amd64 51e18f50 d14faead false 528B 0 N/A b8781e88 15B")
+			So(actual, ShouldContainSubstring, "repo7 test:1.0 linux/amd64 51e18f50 d14faead false 528B 0 N/A b8781e88 15B")
 		})
 
 		Convey("Test image by name", func() {
@@ -963,14 +1144,14 @@ func runDisplayIndexTests(baseURL string) {
 		str := space.ReplaceAllString(buff.String(), " ")
 		actual := strings.TrimSpace(str)
 		// Actual cli output should be something similar to (order of images may differ):
-		// REPOSITORY    TAG        OS/ARCH           DIGEST    CONFIG    SIGNED  LAYERS    SIZE
-		// repo          multi-arch *                 4780eafe            false             1.5kB
-		//                          linux/amd64       02e0ac42  58cc9abe  false             644B
+		// REPOSITORY    TAG        OS/ARCH           DIGEST    CONFIG    SIGNED  LAYERS    SIZE   PULLS  LAST PULL
+		// repo          multi-arch *                 4780eafe            false             1.5kB  0      N/A
+		//                          linux/amd64       02e0ac42  58cc9abe  false             644B   0      N/A
 		//                                                                        cbb5b121  4B
 		//                                                                        a00291e8  4B
 		//                          windows/arm64/v6  5e09b7f9  5132a1cd  false             506B
 		//                                                                        7d08ce29  4B
-		So(actual, ShouldContainSubstring, "REPOSITORY TAG OS/ARCH DIGEST CONFIG SIGNED LAYERS SIZE")
+		So(actual, ShouldContainSubstring, "REPOSITORY TAG OS/ARCH DIGEST CONFIG SIGNED LAYERS SIZE PULLS LAST PULL")
 		So(actual, ShouldContainSubstring, "repo multi-arch * 4780eafe false 1.5kB")
 		So(actual, ShouldContainSubstring, "linux/amd64 02e0ac42 58cc9abe false 644B")
 		So(actual, ShouldContainSubstring, "cbb5b121 4B")
@@ -1325,3 +1506,1687 @@ func getTestSearchConfig(url string, searchService client.SearchService) client.
 		ResultWriter:  nil,
 	}
 }
+
+func TestImageDelete(t *testing.T) {
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"deletetest","url":"%s","showspinner":false}]}`,
+		baseURL))
+	defer os.Remove(configPath)
+
+	runDelete := func(input string, args ...string) (string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetIn(strings.NewReader(input))
+		cmd.SetArgs(append([]string{"delete", "--config", "deletetest"}, args...))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	getTags := func(repo string) []string {
+		resp, err := resty.R().Get(fmt.Sprintf("%s/v2/%s/tags/list", baseURL, repo))
+		So(err, ShouldBeNil)
+
+		var tagList struct {
+			Tags []string `json:"tags"`
+		}
+
+		err = json.Unmarshal(resp.Body(), &tagList)
+		So(err, ShouldBeNil)
+
+		return tagList.Tags
+	}
+
+	Convey("Test image delete", t, func() {
+		image := CreateRandomImage()
+
+		for _, tag := range []string{"1.0", "1.1", "2.0"} {
+			err := UploadImage(CreateRandomImage(), baseURL, "repo", tag)
+			So(err, ShouldBeNil)
+		}
+
+		err := UploadImage(image, baseURL, "digestrepo", "tag")
+		So(err, ShouldBeNil)
+
+		Convey("aborted when not confirmed", func() {
+			output, err := runDelete("n\n", "repo:1.0")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "repo:1.0")
+			So(output, ShouldContainSubstring, "Aborted")
+			So(getTags("repo"), ShouldContain, "1.0")
+		})
+
+		Convey("delete a tag after confirmation", func() {
+			output, err := runDelete("y\n", "repo:1.0")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "Deleted repo:1.0")
+			So(getTags("repo"), ShouldNotContain, "1.0")
+			So(getTags("repo"), ShouldContain, "1.1")
+		})
+
+		Convey("delete the tags matching a pattern", func() {
+			output, err := runDelete("", "repo:1.*", "--force")
+			So(err, ShouldBeNil)
+			So(output, ShouldNotContainSubstring, "Continue?")
+			So(getTags("repo"), ShouldResemble, []string{"2.0"})
+
+			_, err = runDelete("", "repo:3.*", "--force")
+			So(err, ShouldNotBeNil)
+
+			_, err = runDelete("", "repo:[", "--force")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("delete by digest", func() {
+			output, err := runDelete("", "digestrepo@"+image.DigestStr(), "--force")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "Deleted digestrepo@"+image.DigestStr())
+			So(getTags("digestrepo"), ShouldBeEmpty)
+		})
+
+		Convey("delete a missing image", func() {
+			_, err := runDelete("", "repo:missing", "--force")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestImageCopy(t *testing.T) {
+	startServer := func() string {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+
+		cm.StartAndWait(conf.HTTP.Port)
+		t.Cleanup(cm.StopServer)
+
+		return baseURL
+	}
+
+	baseURL := startServer()
+	destURL := startServer()
+
+	configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"copytest","url":"%s","showspinner":false},`+
+		`{"_name":"copydest","url":"%s","showspinner":false}]}`, baseURL, destURL))
+	defer os.Remove(configPath)
+
+	runCopy := func(args ...string) (string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append([]string{"copy", "--config", "copytest"}, args...))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	getManifestDigest := func(serverURL, repo, reference string) string {
+		resp, err := resty.R().Head(fmt.Sprintf("%s/v2/%s/manifests/%s", serverURL, repo, reference))
+		So(err, ShouldBeNil)
+
+		if resp.StatusCode() != http.StatusOK {
+			return ""
+		}
+
+		return resp.Header().Get("Docker-Content-Digest")
+	}
+
+	Convey("Test image copy", t, func() {
+		image := CreateRandomImage()
+		err := UploadImage(image, baseURL, "repo", "1.0")
+		So(err, ShouldBeNil)
+
+		err = UploadImage(CreateRandomImage(), baseURL, "repo", "2.0")
+		So(err, ShouldBeNil)
+
+		multiarch := CreateMultiarchWith().Images([]Image{CreateRandomImage(), CreateRandomImage()}).Build()
+		err = UploadMultiarchImage(multiarch, baseURL, "multi", "latest")
+		So(err, ShouldBeNil)
+
+		Convey("to another repo of the same registry", func() {
+			output, err := runCopy("repo:1.0", "mirror")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "Copied repo:1.0 to mirror:1.0")
+			So(getManifestDigest(baseURL, "mirror", "1.0"), ShouldEqual, image.DigestStr())
+		})
+
+		Convey("to another registry under another tag", func() {
+			output, err := runCopy("repo@"+image.DigestStr(), "mirror:stable", "--dest-url", destURL)
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, destURL+"/mirror:stable")
+			So(getManifestDigest(destURL, "mirror", "stable"), ShouldEqual, image.DigestStr())
+		})
+
+		Convey("a multi-arch image", func() {
+			_, err := runCopy("multi:latest", "multi", "--dest-config", "copydest")
+			So(err, ShouldBeNil)
+			So(getManifestDigest(destURL, "multi", "latest"), ShouldEqual, multiarch.DigestStr())
+
+			for _, img := range multiarch.Images {
+				So(getManifestDigest(destURL, "multi", img.DigestStr()), ShouldEqual, img.DigestStr())
+			}
+		})
+
+		Convey("all the tags", func() {
+			output, err := runCopy("repo", "all", "--all-tags", "--dest-url", destURL)
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "Copied repo:1.0")
+			So(output, ShouldContainSubstring, "Copied repo:2.0")
+			So(getManifestDigest(destURL, "all", "1.0"), ShouldNotBeEmpty)
+			So(getManifestDigest(destURL, "all", "2.0"), ShouldNotBeEmpty)
+		})
+
+		Convey("only the missing or newer images", func() {
+			createdImage := func(year int) Image {
+				return CreateImageWith().RandomLayers(1, 10).
+					ImageConfig(ispec.Image{Created: DateRef(year, 1, 1, 0, 0, 0, 0, time.UTC)}).Build()
+			}
+
+			digestOf := func(img Image) string {
+				return img.DigestStr()
+			}
+
+			same := createdImage(2020)
+			srcImages := map[string]Image{
+				"old": createdImage(2020), "new": createdImage(2022), "same": same, "extra": createdImage(2020),
+			}
+			destImages := map[string]Image{"old": createdImage(2021), "new": createdImage(2021), "same": same}
+
+			for tag, img := range srcImages {
+				So(UploadImage(img, baseURL, "sync", tag), ShouldBeNil)
+			}
+
+			for tag, img := range destImages {
+				So(UploadImage(img, destURL, "sync", tag), ShouldBeNil)
+			}
+
+			output, err := runCopy("sync", "sync", "--all-tags", "--missing-only", "--dest-url", destURL)
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "Copied sync:extra")
+			So(output, ShouldContainSubstring, "Skipped sync:new: "+destURL+"/sync:new already exists")
+			So(output, ShouldContainSubstring, "1 copied, 3 skipped")
+			So(getManifestDigest(destURL, "sync", "extra"), ShouldEqual, digestOf(srcImages["extra"]))
+			So(getManifestDigest(destURL, "sync", "new"), ShouldEqual, digestOf(destImages["new"]))
+
+			output, err = runCopy("sync", "sync", "--all-tags", "--if-newer", "--dest-url", destURL)
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "Copied sync:new")
+			So(output, ShouldContainSubstring, "Skipped sync:same: "+destURL+"/sync:same is up to date")
+			So(output, ShouldContainSubstring, "Skipped sync:extra: "+destURL+"/sync:extra is up to date")
+			So(output, ShouldContainSubstring, "Skipped sync:old: "+destURL+"/sync:old isn't older")
+			So(output, ShouldContainSubstring, "1 copied, 3 skipped")
+			So(getManifestDigest(destURL, "sync", "new"), ShouldEqual, digestOf(srcImages["new"]))
+			So(getManifestDigest(destURL, "sync", "old"), ShouldEqual, digestOf(destImages["old"]))
+
+			// a multi-arch image is as recent as its latest image
+			srcMulti := CreateMultiarchWith().Images([]Image{createdImage(2019), createdImage(2023)}).Build()
+			So(UploadMultiarchImage(srcMulti, baseURL, "syncmulti", "latest"), ShouldBeNil)
+
+			destMulti := CreateMultiarchWith().Images([]Image{createdImage(2021)}).Build()
+			So(UploadMultiarchImage(destMulti, destURL, "syncmulti", "latest"), ShouldBeNil)
+
+			output, err = runCopy("syncmulti:latest", "syncmulti", "--if-newer", "--dest-url", destURL)
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "1 copied, 0 skipped")
+			So(getManifestDigest(destURL, "syncmulti", "latest"), ShouldEqual, srcMulti.DigestStr())
+
+			_, err = runCopy("sync", "sync", "--all-tags", "--if-newer", "--missing-only", "--dest-url", destURL)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("errors", func() {
+			_, err := runCopy("repo:missing", "mirror")
+			So(err, ShouldNotBeNil)
+
+			_, err = runCopy("repo", "mirror")
+			So(err, ShouldNotBeNil)
+
+			_, err = runCopy("repo", "mirror:tag", "--all-tags")
+			So(err, ShouldNotBeNil)
+
+			_, err = runCopy("repo:1.0", "mirror", "--dest-url", destURL, "--dest-config", "copydest")
+			So(err, ShouldNotBeNil)
+
+			_, err = runCopy("repo:1.0", "mirror", "--dest-url", "invalid")
+			So(err, ShouldNotBeNil)
+
+			_, err = runCopy("repo:1.0")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestImageAnnotate(t *testing.T) {
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"annotatetest","url":"%s","showspinner":false}]}`,
+		baseURL))
+	defer os.Remove(configPath)
+
+	runAnnotate := func(args ...string) (string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append([]string{"annotate", "--config", "annotatetest"}, args...))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	getManifest := func(repo, reference string) (ispec.Manifest, string) {
+		resp, err := resty.R().Get(fmt.Sprintf("%s/v2/%s/manifests/%s", baseURL, repo, reference))
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var manifest ispec.Manifest
+
+		So(json.Unmarshal(resp.Body(), &manifest), ShouldBeNil)
+
+		return manifest, resp.Header().Get("Docker-Content-Digest")
+	}
+
+	Convey("Test image annotate", t, func() {
+		image := CreateRandomImage()
+		err := UploadImage(image, baseURL, "repo", "1.0")
+		So(err, ShouldBeNil)
+
+		Convey("replaces the tagged image", func() {
+			output, err := runAnnotate("repo:1.0", "owner=team-a", "ticket=OPS-1234")
+			So(err, ShouldBeNil)
+
+			manifest, digest := getManifest("repo", "1.0")
+			So(digest, ShouldNotEqual, image.DigestStr())
+			So(output, ShouldContainSubstring, "Annotated repo:1.0 as repo:1.0@"+digest)
+			So(manifest.Annotations, ShouldResemble, map[string]string{"owner": "team-a", "ticket": "OPS-1234"})
+			So(manifest.Config, ShouldResemble, image.Manifest.Config)
+			So(manifest.Layers, ShouldResemble, image.Manifest.Layers)
+
+			_, err = runAnnotate("repo:1.0", "owner=team-b", "--remove", "ticket")
+			So(err, ShouldBeNil)
+
+			manifest, _ = getManifest("repo", "1.0")
+			So(manifest.Annotations, ShouldResemble, map[string]string{"owner": "team-b"})
+
+			_, err = runAnnotate("repo:1.0", "--remove", "owner")
+			So(err, ShouldBeNil)
+
+			manifest, _ = getManifest("repo", "1.0")
+			So(manifest.Annotations, ShouldBeEmpty)
+		})
+
+		Convey("retags the image referenced by digest", func() {
+			_, err := runAnnotate("repo@"+image.DigestStr(), "expiry=2030-01-01", "--tag", "1.0-annotated")
+			So(err, ShouldBeNil)
+
+			manifest, _ := getManifest("repo", "1.0-annotated")
+			So(manifest.Annotations, ShouldResemble, map[string]string{"expiry": "2030-01-01"})
+
+			_, digest := getManifest("repo", "1.0")
+			So(digest, ShouldEqual, image.DigestStr())
+		})
+
+		Convey("errors", func() {
+			_, err := runAnnotate("repo:1.0")
+			So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+
+			_, err = runAnnotate("repo:1.0", "owner")
+			So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+
+			_, err = runAnnotate("repo:1.0", "=team-a")
+			So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+
+			_, err = runAnnotate("repo@"+image.DigestStr(), "owner=team-a")
+			So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+
+			_, err = runAnnotate("repo:missing", "owner=team-a")
+			So(err, ShouldNotBeNil)
+
+			_, err = runAnnotate("repo", "owner=team-a")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestImageTagUntag(t *testing.T) {
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"tagtest","url":"%s","showspinner":false}]}`,
+		baseURL))
+	defer os.Remove(configPath)
+
+	runImageCmd := func(args ...string) (string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append(args, "--config", "tagtest"))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	getManifestDigest := func(repo, reference string) string {
+		resp, err := resty.R().Head(fmt.Sprintf("%s/v2/%s/manifests/%s", baseURL, repo, reference))
+		So(err, ShouldBeNil)
+
+		if resp.StatusCode() != http.StatusOK {
+			return ""
+		}
+
+		return resp.Header().Get("Docker-Content-Digest")
+	}
+
+	Convey("Test image tag and untag", t, func() {
+		image := CreateRandomImage()
+		err := UploadImage(image, baseURL, "repo", "rc1")
+		So(err, ShouldBeNil)
+
+		multiarch := CreateMultiarchWith().Images([]Image{CreateRandomImage(), CreateRandomImage()}).Build()
+		err = UploadMultiarchImage(multiarch, baseURL, "multi", "rc1")
+		So(err, ShouldBeNil)
+
+		Convey("tag an image by digest", func() {
+			output, err := runImageCmd("tag", "repo@"+image.DigestStr(), "1.0", "stable")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "Tagged repo@"+image.DigestStr()+" as repo:1.0@"+image.DigestStr())
+			So(output, ShouldContainSubstring, "as repo:stable@")
+			So(getManifestDigest("repo", "1.0"), ShouldEqual, image.DigestStr())
+			So(getManifestDigest("repo", "stable"), ShouldEqual, image.DigestStr())
+
+			Convey("then untag it", func() {
+				output, err := runImageCmd("untag", "repo:rc1")
+				So(err, ShouldBeNil)
+				So(output, ShouldContainSubstring, "Untagged repo:rc1")
+				So(getManifestDigest("repo", "rc1"), ShouldBeEmpty)
+				So(getManifestDigest("repo", "1.0"), ShouldEqual, image.DigestStr())
+			})
+		})
+
+		Convey("tag a multi-arch image by tag", func() {
+			_, err := runImageCmd("tag", "multi:rc1", "stable")
+			So(err, ShouldBeNil)
+			So(getManifestDigest("multi", "stable"), ShouldEqual, multiarch.DigestStr())
+		})
+
+		Convey("errors", func() {
+			_, err := runImageCmd("tag", "repo:missing", "stable")
+			So(err, ShouldNotBeNil)
+
+			_, err = runImageCmd("tag", "repo:rc1", image.DigestStr())
+			So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+
+			_, err = runImageCmd("tag", "repo:rc1", "other:stable")
+			So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+
+			_, err = runImageCmd("tag", "repo:rc1")
+			So(err, ShouldNotBeNil)
+
+			_, err = runImageCmd("untag", "repo@"+image.DigestStr())
+			So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+
+			_, err = runImageCmd("untag", "repo:missing")
+			So(err, ShouldNotBeNil)
+
+			_, err = runImageCmd("untag", "repo")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestImageInspect(t *testing.T) {
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"inspecttest","url":"%s","showspinner":false}]}`,
+		baseURL))
+	defer os.Remove(configPath)
+
+	runInspect := func(args ...string) (string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append([]string{"inspect", "--config", "inspecttest"}, args...))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	Convey("Test image inspect", t, func() {
+		image := CreateImageWith().LayerBlobs([][]byte{{1, 2, 3}, {4, 5, 6}}).
+			ImageConfig(ispec.Image{
+				Created:  DateRef(2020, 1, 1, 1, 1, 1, 0, time.UTC),
+				Author:   "some author",
+				Platform: ispec.Platform{OS: "linux", Architecture: "amd64"},
+				Config: ispec.ImageConfig{
+					Env:        []string{"PATH=/usr/bin"},
+					Entrypoint: []string{"/bin/sh", "-c"},
+					Labels:     map[string]string{"vendor": "acme"},
+				},
+				History: []ispec.History{
+					{CreatedBy: "ADD first"},
+					{CreatedBy: "ENV PATH=/usr/bin", EmptyLayer: true},
+					{CreatedBy: "RUN second"},
+				},
+			}).Build()
+
+		err := UploadImage(image, baseURL, "repo", "1.0")
+		So(err, ShouldBeNil)
+
+		output, err := runInspect("repo:1.0")
+		So(err, ShouldBeNil)
+
+		space := regexp.MustCompile(`\s+`)
+		str := space.ReplaceAllString(output, " ")
+		So(str, ShouldContainSubstring, "Repository: repo")
+		So(str, ShouldContainSubstring, "Digest: "+image.DigestStr())
+		So(str, ShouldContainSubstring, "Platform: linux/amd64")
+		So(str, ShouldContainSubstring, "Author: some author")
+		So(str, ShouldContainSubstring, `Entrypoint: ["/bin/sh", "-c"]`)
+		So(str, ShouldContainSubstring, `Env: ["PATH=/usr/bin"]`)
+		So(str, ShouldContainSubstring, "Labels: vendor=acme")
+		So(str, ShouldContainSubstring, image.Manifest.Layers[0].Digest.String()+" "+
+			ispec.MediaTypeImageLayerGzip+" 3B ADD first")
+		So(str, ShouldContainSubstring, image.Manifest.Layers[1].Digest.String()+" "+
+			ispec.MediaTypeImageLayerGzip+" 3B RUN second")
+		So(str, ShouldContainSubstring, "ENV PATH=/usr/bin (empty layer)")
+
+		Convey("in json", func() {
+			output, err := runInspect("repo@"+image.DigestStr(), "-f", "json")
+			So(err, ShouldBeNil)
+
+			var inspected struct {
+				Digest    string `json:"digest"`
+				Manifests []struct {
+					Config ispec.Image `json:"config"`
+					Layers []struct {
+						CreatedBy string `json:"createdBy"`
+					} `json:"layers"`
+				} `json:"manifests"`
+			}
+
+			err = json.Unmarshal([]byte(output), &inspected)
+			So(err, ShouldBeNil)
+			So(inspected.Digest, ShouldEqual, image.DigestStr())
+			So(inspected.Manifests, ShouldHaveLength, 1)
+			So(inspected.Manifests[0].Config.Config.Labels, ShouldResemble, map[string]string{"vendor": "acme"})
+			So(inspected.Manifests[0].Layers[1].CreatedBy, ShouldEqual, "RUN second")
+		})
+
+		Convey("in yaml", func() {
+			output, err := runInspect("repo:1.0", "-f", "yaml")
+			So(err, ShouldBeNil)
+			So(output, ShouldStartWith, "---\n")
+			So(output, ShouldContainSubstring, "mediaType: "+ispec.MediaTypeImageManifest)
+			So(output, ShouldContainSubstring, "createdBy: RUN second")
+		})
+
+		Convey("an image index", func() {
+			uploadTestMultiarch(baseURL)
+
+			output, err := runInspect("repo:multi-arch")
+			So(err, ShouldBeNil)
+
+			str := space.ReplaceAllString(output, " ")
+			So(str, ShouldContainSubstring, "Media type: "+ispec.MediaTypeImageIndex)
+			So(str, ShouldContainSubstring, "Platform: linux/amd64")
+			So(str, ShouldContainSubstring, "Platform: windows/arm64/v6")
+			So(strings.Count(str, "Manifest: "), ShouldEqual, 2)
+		})
+
+		Convey("errors", func() {
+			_, err := runInspect("repo:missing")
+			So(err, ShouldNotBeNil)
+
+			_, err = runInspect("repo")
+			So(err, ShouldNotBeNil)
+
+			_, err = runInspect("repo:1.0", "-f", "csv")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestImageVerify(t *testing.T) {
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	rootDir := t.TempDir()
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = rootDir
+	ctlr.Config.Storage.Dedupe = false
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"verifytest","url":"%s","showspinner":false}]}`,
+		baseURL))
+	defer os.Remove(configPath)
+
+	runVerify := func(args ...string) (string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append([]string{"verify", "--config", "verifytest"}, args...))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	blobPath := func(repo string, digest godigest.Digest) string {
+		return path.Join(rootDir, repo, "blobs", digest.Algorithm().String(), digest.Encoded())
+	}
+
+	Convey("Test image verify", t, func() {
+		image := CreateImageWith().LayerBlobs([][]byte{{1, 2, 3}, {4, 5, 6}}).DefaultConfig().Build()
+		err := UploadImage(image, baseURL, "repo", "1.0")
+		So(err, ShouldBeNil)
+
+		multiarch := CreateMultiarchWith().Images([]Image{CreateRandomImage(), CreateRandomImage()}).Build()
+		err = UploadMultiarchImage(multiarch, baseURL, "multi", "latest")
+		So(err, ShouldBeNil)
+
+		Convey("an intact image", func() {
+			output, err := runVerify("repo:1.0")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "repo:1.0 ("+image.DigestStr()+"): 1 manifests, 3 blobs")
+			So(output, ShouldContainSubstring, ": OK")
+
+			output, err = runVerify("multi@"+multiarch.DigestStr(), "-f", "json")
+			So(err, ShouldBeNil)
+
+			var result map[string]interface{}
+
+			So(json.Unmarshal([]byte(output), &result), ShouldBeNil)
+			So(result["manifests"], ShouldEqual, 3)
+			So(result["mismatches"], ShouldBeNil)
+		})
+
+		Convey("a corrupted layer and a missing config", func() {
+			layer := image.Manifest.Layers[0].Digest
+			err := os.WriteFile(blobPath("repo", layer), []byte{7, 8, 9}, 0o600)
+			So(err, ShouldBeNil)
+
+			err = os.Remove(blobPath("repo", image.Manifest.Config.Digest))
+			So(err, ShouldBeNil)
+
+			output, err := runVerify("repo:1.0")
+			So(err, ShouldWrap, zerr.ErrImageVerificationFailed)
+			So(output, ShouldContainSubstring, "2 mismatches")
+			So(output, ShouldContainSubstring, "layer   "+layer.String())
+			So(output, ShouldContainSubstring, "content has the digest "+godigest.FromBytes([]byte{7, 8, 9}).String())
+			So(output, ShouldContainSubstring, "config  "+image.Manifest.Config.Digest.String())
+			So(output, ShouldContainSubstring, "failed to download")
+		})
+
+		Convey("errors", func() {
+			_, err := runVerify("repo:missing")
+			So(err, ShouldNotBeNil)
+
+			_, err = runVerify("repo")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestImagePullPush(t *testing.T) {
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	runImage := func(args ...string) (string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append(args, "--url", baseURL))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	getManifestDigest := func(repo, reference string) string {
+		resp, err := resty.R().Head(fmt.Sprintf("%s/v2/%s/manifests/%s", baseURL, repo, reference))
+		So(err, ShouldBeNil)
+
+		if resp.StatusCode() != http.StatusOK {
+			return ""
+		}
+
+		return resp.Header().Get("Docker-Content-Digest")
+	}
+
+	readIndex := func(layoutDir string) ispec.Index {
+		content, err := os.ReadFile(path.Join(layoutDir, "index.json"))
+		So(err, ShouldBeNil)
+
+		var index ispec.Index
+
+		err = json.Unmarshal(content, &index)
+		So(err, ShouldBeNil)
+
+		return index
+	}
+
+	image := CreateImageWith().LayerBlobs([][]byte{bytes.Repeat([]byte("1"), 1000)}).DefaultConfig().Build()
+	if err := UploadImage(image, baseURL, "repo", "1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	otherImage := CreateRandomImage()
+	if err := UploadImage(otherImage, baseURL, "repo", "2.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	multiarch := CreateMultiarchWith().Images([]Image{CreateRandomImage(), CreateRandomImage()}).Build()
+	if err := UploadMultiarchImage(multiarch, baseURL, "multi", "latest"); err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("Test image pull and push", t, func() {
+		layoutDir := path.Join(t.TempDir(), "layout")
+
+		Convey("an image by tag", func() {
+			output, err := runImage("pull", "repo:1.0", "--to", layoutDir)
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "Pulled repo:1.0 to "+layoutDir)
+
+			index := readIndex(layoutDir)
+			So(len(index.Manifests), ShouldEqual, 1)
+			So(index.Manifests[0].Digest.String(), ShouldEqual, image.DigestStr())
+			So(index.Manifests[0].Annotations[ispec.AnnotationRefName], ShouldEqual, "1.0")
+
+			for _, blob := range []godigest.Digest{image.ConfigDescriptor.Digest, image.Manifest.Layers[0].Digest} {
+				_, err := os.Stat(path.Join(layoutDir, "blobs", blob.Algorithm().String(), blob.Encoded()))
+				So(err, ShouldBeNil)
+			}
+
+			// pulling it again doesn't add another entry
+			_, err = runImage("pull", "repo:1.0", "--to", layoutDir)
+			So(err, ShouldBeNil)
+			So(len(readIndex(layoutDir).Manifests), ShouldEqual, 1)
+
+			output, err = runImage("push", layoutDir, "pushed")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "Pushed "+layoutDir+" to pushed:1.0")
+			So(getManifestDigest("pushed", "1.0"), ShouldEqual, image.DigestStr())
+		})
+
+		Convey("in chunks", func() {
+			// the blobs the registry already has wouldn't be uploaded
+			rootDir := t.TempDir()
+			storeController := ociutils.GetDefaultStoreController(rootDir, zlog.NewLogger("debug", ""))
+			newImage := CreateImageWith().LayerBlobs([][]byte{bytes.Repeat([]byte("2"), 1000)}).DefaultConfig().Build()
+
+			err := WriteImageToFileSystem(newImage, "layout", "1.0", storeController)
+			So(err, ShouldBeNil)
+
+			layoutDir := path.Join(rootDir, "layout")
+
+			output, err := runImage("push", layoutDir, "chunked:stable", "--chunk-size", "300")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, "Pushed "+layoutDir+" to chunked:stable")
+			So(getManifestDigest("chunked", "stable"), ShouldEqual, newImage.DigestStr())
+		})
+
+		Convey("a multi-arch image", func() {
+			_, err := runImage("pull", "multi:latest", "--to", layoutDir)
+			So(err, ShouldBeNil)
+
+			cmd := client.NewImageCommand(client.NewSearchService())
+			buff := bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetArgs([]string{"list", "--oci-layout", layoutDir, "-f", "json"})
+			err = cmd.Execute()
+			So(err, ShouldBeNil)
+			So(buff.String(), ShouldContainSubstring, multiarch.DigestStr())
+
+			_, err = runImage("push", layoutDir, "multicopy")
+			So(err, ShouldBeNil)
+			So(getManifestDigest("multicopy", "latest"), ShouldEqual, multiarch.DigestStr())
+
+			for _, img := range multiarch.Images {
+				So(getManifestDigest("multicopy", img.DigestStr()), ShouldEqual, img.DigestStr())
+			}
+		})
+
+		Convey("a layout with several images", func() {
+			_, err := runImage("pull", "repo:1.0", "--to", layoutDir)
+			So(err, ShouldBeNil)
+
+			_, err = runImage("pull", "repo:2.0", "--to", layoutDir)
+			So(err, ShouldBeNil)
+			So(len(readIndex(layoutDir).Manifests), ShouldEqual, 2)
+
+			_, err = runImage("push", layoutDir, "several")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			_, err = runImage("push", layoutDir, "several:2.0")
+			So(err, ShouldBeNil)
+			So(getManifestDigest("several", "2.0"), ShouldEqual, otherImage.DigestStr())
+		})
+
+		Convey("an image by digest", func() {
+			_, err := runImage("pull", "repo@"+image.DigestStr(), "--to", layoutDir)
+			So(err, ShouldBeNil)
+
+			index := readIndex(layoutDir)
+			So(len(index.Manifests), ShouldEqual, 1)
+			So(index.Manifests[0].Digest.String(), ShouldEqual, image.DigestStr())
+			So(index.Manifests[0].Annotations[ispec.AnnotationRefName], ShouldBeEmpty)
+
+			// the image has no tag to push it under
+			_, err = runImage("push", layoutDir, "bydigest:1.0")
+			So(errors.Is(err, zerr.ErrManifestNotFound), ShouldBeTrue)
+		})
+
+		Convey("errors", func() {
+			_, err := runImage("pull", "repo:1.0")
+			So(err, ShouldNotBeNil)
+
+			_, err = runImage("pull", "repo:missing", "--to", layoutDir)
+			So(err, ShouldNotBeNil)
+
+			_, err = runImage("push", t.TempDir(), "repo:3.0")
+			So(err, ShouldNotBeNil)
+
+			_, err = runImage("pull", "repo:1.0", "--to", layoutDir)
+			So(err, ShouldBeNil)
+
+			_, err = runImage("push", layoutDir, "repo:3.0", "--chunk-size", "big")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			_, err = runImage("push", layoutDir)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestImageListOCILayout(t *testing.T) {
+	space := regexp.MustCompile(`\s+`)
+
+	rootDir := t.TempDir()
+	storeController := ociutils.GetDefaultStoreController(rootDir, zlog.NewLogger("debug", ""))
+
+	image := CreateDefaultImageWith().Annotations(map[string]string{"team": "a"}).Build()
+	err := WriteImageToFileSystem(image, "app", "1.0", storeController)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the storage can only be written images with an image config
+	signature := CreateImageWith().RandomLayers(1, 10).DefaultConfig().Subject(image.DescriptorRef()).
+		ArtifactType(common.ArtifactTypeCosign).Build()
+	err = WriteImageToFileSystem(signature, "app", signature.DigestStr(), storeController)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nestedImage := CreateRandomImage()
+	err = WriteImageToFileSystem(nestedImage, "team/lib", "2.0", storeController)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multiarch := CreateRandomMultiarch()
+	err = WriteMultiArchImageToFileSystem(multiarch, "multi", "latest", storeController)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runImageList := func(args ...string) (string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append([]string{"list"}, args...))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	Convey("Test image list --oci-layout", t, func() {
+		Convey("the repositories of a storage directory", func() {
+			output, err := runImageList("--oci-layout", rootDir)
+			So(err, ShouldBeNil)
+
+			actual := strings.TrimSpace(space.ReplaceAllString(output, " "))
+			So(actual, ShouldStartWith, "REPOSITORY TAG OS/ARCH DIGEST SIGNED SIZE")
+			So(actual, ShouldContainSubstring, "app 1.0 linux/amd64 "+image.DigestStr()[7:15]+" true")
+			So(actual, ShouldContainSubstring, "team/lib 2.0")
+			So(actual, ShouldContainSubstring, "multi latest * "+multiarch.DigestStr()[7:15]+" false")
+			So(actual, ShouldNotContainSubstring, signature.DigestStr()[7:15])
+			So(strings.Index(actual, "app 1.0"), ShouldBeLessThan, strings.Index(actual, "multi latest"))
+			So(strings.Index(actual, "multi latest"), ShouldBeLessThan, strings.Index(actual, "team/lib 2.0"))
+
+			output, err = runImageList("--oci-layout", rootDir, "--sort-by", "alpha-dsc")
+			So(err, ShouldBeNil)
+
+			actual = strings.TrimSpace(space.ReplaceAllString(output, " "))
+			So(strings.Index(actual, "team/lib 2.0"), ShouldBeLessThan, strings.Index(actual, "app 1.0"))
+		})
+
+		Convey("a single layout", func() {
+			output, err := runImageList("--oci-layout", path.Join(rootDir, "team", "lib"), "-f", "json")
+			So(err, ShouldBeNil)
+
+			var result map[string]any
+
+			err = json.Unmarshal([]byte(output), &result)
+			So(err, ShouldBeNil)
+			So(result["repoName"], ShouldEqual, "lib")
+			So(result["tag"], ShouldEqual, "2.0")
+			So(result["digest"], ShouldEqual, nestedImage.DigestStr())
+		})
+
+		Convey("the images having a label", func() {
+			output, err := runImageList("--oci-layout", rootDir, "--label", "team=a")
+			So(err, ShouldBeNil)
+
+			actual := strings.TrimSpace(space.ReplaceAllString(output, " "))
+			So(actual, ShouldContainSubstring, "app 1.0")
+			So(actual, ShouldNotContainSubstring, "team/lib")
+			So(actual, ShouldNotContainSubstring, "multi")
+		})
+
+		Convey("only the references", func() {
+			output, err := runImageList("--oci-layout", rootDir, "-q")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "app:1.0\nmulti:latest\nteam/lib:2.0\n")
+		})
+
+		Convey("errors", func() {
+			_, err := runImageList("--oci-layout", t.TempDir())
+			So(errors.Is(err, zerr.ErrOCILayoutNotFound), ShouldBeTrue)
+
+			_, err = runImageList("--oci-layout", path.Join(rootDir, "missing"))
+			So(err, ShouldNotBeNil)
+
+			_, err = runImageList("--oci-layout", rootDir, "--url", "http://127.0.0.1:8080")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			_, err = runImageList("--oci-layout", rootDir, "--derived-from", "app:1.0")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+		})
+	})
+}
+
+func TestImageHistoryAndDiff(t *testing.T) {
+	space := regexp.MustCompile(`\s+`)
+
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	longCommand := "RUN apk add --no-cache wget curl ca-certificates tzdata && rm -rf /var/cache/apk/*"
+
+	createImage := func(layers [][]byte, history []ispec.History, platform ispec.Platform) Image {
+		imageConfig := GetDefaultConfig()
+		imageConfig.Platform = platform
+		imageConfig.History = history
+
+		return CreateImageWith().LayerBlobs(layers).ImageConfig(imageConfig).Build()
+	}
+
+	amd64 := ispec.Platform{OS: "linux", Architecture: "amd64"}
+	arm64 := ispec.Platform{OS: "linux", Architecture: "arm64"}
+
+	image1 := createImage([][]byte{{1, 2, 3}, {4, 5, 6}}, []ispec.History{
+		{CreatedBy: "ADD rootfs.tar /"},
+		{CreatedBy: "ENV A=b", EmptyLayer: true},
+		{CreatedBy: "RUN apk add curl"},
+	}, amd64)
+	image2 := createImage([][]byte{{1, 2, 3}, {7, 8, 9}}, []ispec.History{
+		{CreatedBy: "ADD rootfs.tar /"},
+		{CreatedBy: longCommand},
+	}, amd64)
+
+	err := UploadImage(image1, baseURL, "repo", "1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = UploadImage(image2, baseURL, "repo", "2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multiarch := CreateMultiarchWith().Images([]Image{
+		createImage([][]byte{{1, 2, 3}, {10, 11, 12}}, nil, amd64),
+		createImage([][]byte{{13, 14, 15}}, nil, arm64),
+	}).Build()
+
+	err = UploadMultiarchImage(multiarch, baseURL, "multi", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shortDigest := func(layer []byte) string {
+		return godigest.FromBytes(layer).Encoded()[:8]
+	}
+
+	runImageCommand := func(args ...string) (string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append(args, "--url", baseURL))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	Convey("Test image history", t, func() {
+		Convey("the history of a manifest", func() {
+			output, err := runImageCommand("history", "repo:1.0")
+			So(err, ShouldBeNil)
+
+			actual := strings.TrimSpace(space.ReplaceAllString(output, " "))
+			So(actual, ShouldContainSubstring, "Digest: "+image1.DigestStr())
+			So(actual, ShouldContainSubstring, "Platform: linux/amd64 LAYER CREATED SIZE CREATED BY "+
+				shortDigest([]byte{4, 5, 6})+" N/A 3B RUN apk add curl <empty> N/A 0B ENV A=b "+
+				shortDigest([]byte{1, 2, 3})+" N/A 3B ADD rootfs.tar /")
+		})
+
+		Convey("the long commands are truncated", func() {
+			output, err := runImageCommand("history", "repo:2.0")
+			So(err, ShouldBeNil)
+			So(output, ShouldNotContainSubstring, longCommand)
+			So(output, ShouldContainSubstring, longCommand[:57]+"...")
+
+			output, err = runImageCommand("history", "repo:2.0", "--no-trunc")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, longCommand)
+		})
+
+		Convey("the history of an image index", func() {
+			output, err := runImageCommand("history", "multi:latest", "-f", "json")
+			So(err, ShouldBeNil)
+
+			var history map[string]any
+
+			err = json.Unmarshal([]byte(output), &history)
+			So(err, ShouldBeNil)
+			So(history["digest"], ShouldEqual, multiarch.DigestStr())
+			So(len(history["manifests"].([]any)), ShouldEqual, 2)
+
+			// without history there's an entry per layer
+			output, err = runImageCommand("history", "multi:latest", "--platform", "linux/arm64")
+			So(err, ShouldBeNil)
+
+			actual := strings.TrimSpace(space.ReplaceAllString(output, " "))
+			So(actual, ShouldContainSubstring, "Platform: linux/arm64 LAYER CREATED SIZE CREATED BY "+
+				shortDigest([]byte{13, 14, 15})+" N/A 3B")
+			So(actual, ShouldNotContainSubstring, "linux/amd64")
+
+			_, err = runImageCommand("history", "multi:latest", "--platform", "windows")
+			So(errors.Is(err, zerr.ErrPlatformNotFound), ShouldBeTrue)
+		})
+	})
+
+	Convey("Test image diff", t, func() {
+		Convey("the layers of two images", func() {
+			output, err := runImageCommand("diff", "repo:1.0", "repo@"+image2.DigestStr())
+			So(err, ShouldBeNil)
+
+			actual := strings.TrimSpace(space.ReplaceAllString(output, " "))
+			So(actual, ShouldContainSubstring, "From: repo:1.0 "+image1.DigestStr()+" linux/amd64")
+			So(actual, ShouldContainSubstring, "To: repo@"+image2.DigestStr()+" "+image2.DigestStr()+" linux/amd64")
+			So(actual, ShouldContainSubstring, "= "+godigest.FromBytes([]byte{1, 2, 3}).String()+" 3B ADD rootfs.tar /")
+			So(actual, ShouldContainSubstring, "- "+godigest.FromBytes([]byte{4, 5, 6}).String()+" 3B RUN apk add curl")
+			So(actual, ShouldContainSubstring, "+ "+godigest.FromBytes([]byte{7, 8, 9}).String()+" 3B RUN apk")
+			So(actual, ShouldEndWith, "Common: 1 layer, 3B Removed: 1 layer, 3B Added: 1 layer, 3B")
+		})
+
+		Convey("the image of a platform of an index", func() {
+			_, err := runImageCommand("diff", "repo:1.0", "multi:latest")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			output, err := runImageCommand("diff", "repo:1.0", "multi:latest", "--platform", "linux/amd64", "-f", "json")
+			So(err, ShouldBeNil)
+
+			var diff map[string]any
+
+			err = json.Unmarshal([]byte(output), &diff)
+			So(err, ShouldBeNil)
+			So(diff["from"].(map[string]any)["name"], ShouldEqual, "repo:1.0")
+			So(diff["to"].(map[string]any)["digest"], ShouldEqual, multiarch.Images[0].DigestStr())
+
+			statuses := []string{}
+
+			for _, layer := range diff["layers"].([]any) {
+				statuses = append(statuses, layer.(map[string]any)["status"].(string))
+			}
+
+			So(statuses, ShouldResemble, []string{"common", "removed", "added"})
+
+			_, err = runImageCommand("diff", "repo:1.0", "multi:latest", "--platform", "linux/s390x")
+			So(errors.Is(err, zerr.ErrPlatformNotFound), ShouldBeTrue)
+		})
+
+		Convey("errors", func() {
+			_, err := runImageCommand("diff", "repo:1.0")
+			So(err, ShouldNotBeNil)
+
+			_, err = runImageCommand("diff", "repo:1.0", "repo")
+			So(errors.Is(err, zerr.ErrInvalidRepoRefFormat), ShouldBeTrue)
+
+			_, err = runImageCommand("diff", "repo:1.0", "repo:missing")
+			So(err, ShouldNotBeNil)
+
+			_, err = runImageCommand("diff", "repo:1.0", "repo:2.0", "-f", "csv")
+			So(errors.Is(err, zerr.ErrInvalidOutputFormat), ShouldBeTrue)
+		})
+	})
+}
+
+func TestImageListQuiet(t *testing.T) {
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	image := CreateRandomImage()
+	multiarch := CreateRandomMultiarch()
+
+	for _, tag := range []string{"1.0", "latest"} {
+		if err := UploadImage(image, baseURL, "app", tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := UploadMultiarchImage(multiarch, baseURL, "multi", "1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	runImageCmd := func(args ...string) ([]string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append(args, "--url", baseURL))
+		err := cmd.Execute()
+
+		lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+		slices.Sort(lines)
+
+		return lines, err
+	}
+
+	Convey("Test image listing with --quiet", t, func() {
+		Convey("only the references", func() {
+			lines, err := runImageCmd("list", "-q")
+			So(err, ShouldBeNil)
+			So(lines, ShouldResemble, []string{"app:1.0", "app:latest", "multi:1.0"})
+
+			lines, err = runImageCmd("name", "app", "--quiet")
+			So(err, ShouldBeNil)
+			So(lines, ShouldResemble, []string{"app:1.0", "app:latest"})
+		})
+
+		Convey("only the digests", func() {
+			lines, err := runImageCmd("list", "-q", "--digests")
+			So(err, ShouldBeNil)
+			expected := []string{image.DigestStr(), image.DigestStr(), multiarch.DigestStr()}
+			slices.Sort(expected)
+			So(lines, ShouldResemble, expected)
+
+			lines, err = runImageCmd("name", "multi:1.0", "-q", "--digests")
+			So(err, ShouldBeNil)
+			So(lines, ShouldResemble, []string{multiarch.DigestStr()})
+		})
+
+		Convey("errors", func() {
+			_, err := runImageCmd("list", "-q", "-f", "json")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			_, err = runImageCmd("list", "--digests")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+		})
+	})
+}
+
+type syncBuffer struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (buffer *syncBuffer) Write(data []byte) (int, error) {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	return buffer.buffer.Write(data)
+}
+
+func (buffer *syncBuffer) String() string {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	return buffer.buffer.String()
+}
+
+func TestImageListWatch(t *testing.T) {
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	image1, image2, image3 := CreateRandomImage(), CreateRandomImage(), CreateRandomImage()
+
+	for tag, image := range map[string]Image{"1.0": image1, "2.0": image2} {
+		if err := UploadImage(image, baseURL, "app", tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Convey("Test image list --watch", t, func() {
+		Convey("the changes since the previous run", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			output := &syncBuffer{}
+			cmd := client.NewImageCommand(client.NewSearchService())
+			cmd.SetOut(output)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs([]string{"list", "--url", baseURL, "--watch", "100ms", "-f", "ndjson", "--no-cache"})
+
+			errCh := make(chan error, 1)
+
+			go func() {
+				errCh <- cmd.ExecuteContext(ctx)
+			}()
+
+			waitForOutput := func(substrings ...string) string {
+				for i := 0; i < 100; i++ {
+					found := true
+
+					for _, substring := range substrings {
+						found = found && strings.Contains(output.String(), substring)
+					}
+
+					if found {
+						break
+					}
+
+					time.Sleep(100 * time.Millisecond)
+				}
+
+				return output.String()
+			}
+
+			// the first run shows the images
+			actual := waitForOutput(image1.DigestStr(), image2.DigestStr())
+			So(actual, ShouldContainSubstring, `"tag":"1.0"`)
+			So(actual, ShouldNotContainSubstring, `"change"`)
+
+			err := UploadImage(image3, baseURL, "app", "3.0")
+			So(err, ShouldBeNil)
+
+			err = UploadImage(image3, baseURL, "app", "1.0")
+			So(err, ShouldBeNil)
+
+			resp, err := resty.R().Delete(baseURL + "/v2/app/manifests/" + image2.DigestStr())
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+			added := fmt.Sprintf(`"change":"added","repoName":"app","tag":"3.0","digest":"%s"`, image3.DigestStr())
+			updated := fmt.Sprintf(`"change":"updated","repoName":"app","tag":"1.0","digest":"%s","previousDigest":"%s"`,
+				image3.DigestStr(), image1.DigestStr())
+			removed := fmt.Sprintf(`"change":"removed","repoName":"app","tag":"2.0","digest":"%s"`, image2.DigestStr())
+
+			actual = waitForOutput(added, updated, removed)
+			So(actual, ShouldContainSubstring, added)
+			So(actual, ShouldContainSubstring, updated)
+			So(actual, ShouldContainSubstring, removed)
+
+			cancel()
+			So(<-errCh, ShouldBeNil)
+		})
+
+		Convey("errors", func() {
+			runImageList := func(args ...string) error {
+				cmd := client.NewImageCommand(client.NewSearchService())
+				cmd.SetOut(io.Discard)
+				cmd.SetErr(io.Discard)
+				cmd.SetArgs(append([]string{"list", "--url", baseURL}, args...))
+
+				return cmd.Execute()
+			}
+
+			err := runImageList("--watch", "1s", "-f", "csv")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			err = runImageList("--watch", "1s", "-q")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			err = runImageList("--watch", "-1s")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			err = runImageList("--watch", "1s", "--oci-layout", t.TempDir())
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+		})
+	})
+}
+
+func TestImagePinOutput(t *testing.T) {
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	image1, image2 := CreateRandomImage(), CreateRandomImage()
+
+	for _, upload := range []struct {
+		image     Image
+		repo, tag string
+	}{{image1, "app", "1.0"}, {image2, "app", "2.0"}, {image1, "lib", "1.0"}} {
+		if err := UploadImage(upload.image, baseURL, upload.repo, upload.tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runImage := func(args ...string) (string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs(append(args, "--url", baseURL, "--no-cache"))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	Convey("Test the pin file of the images", t, func() {
+		pinOutput := path.Join(t.TempDir(), "pins.yaml")
+
+		Convey("of all the images", func() {
+			output, err := runImage("list", "--pin-output", pinOutput, "-q")
+			So(err, ShouldBeNil)
+
+			// without the search extension, the images are shown as they're fetched
+			lines := strings.Split(strings.TrimSpace(output), "\n")
+			slices.Sort(lines)
+			So(lines, ShouldResemble, []string{"app:1.0", "app:2.0", "lib:1.0"})
+
+			pins, err := os.ReadFile(pinOutput)
+			So(err, ShouldBeNil)
+			So(string(pins), ShouldEqual, fmt.Sprintf("app:1.0: %s\napp:2.0: %s\nlib:1.0: %s\n",
+				image1.DigestStr(), image2.DigestStr(), image1.DigestStr()))
+		})
+
+		Convey("of the images matching a name", func() {
+			output, err := runImage("name", "app:*", "--pin-output", pinOutput, "-f", "json")
+			So(err, ShouldBeNil)
+			So(output, ShouldContainSubstring, image2.DigestStr())
+
+			pins, err := os.ReadFile(pinOutput)
+			So(err, ShouldBeNil)
+			So(string(pins), ShouldEqual, fmt.Sprintf("app:1.0: %s\napp:2.0: %s\n",
+				image1.DigestStr(), image2.DigestStr()))
+		})
+
+		Convey("errors", func() {
+			_, err := runImage("list", "--pin-output", pinOutput, "--watch", "1s")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			_, err = runImage("list", "--pin-output", pinOutput, "--oci-layout", t.TempDir())
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			_, err = runImage("list", "--pin-output", path.Join(t.TempDir(), "missing", "pins.yaml"))
+			So(err, ShouldNotBeNil)
+
+			_, err = os.Stat(pinOutput)
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+	})
+}
+
+func TestImageCheckUpdate(t *testing.T) {
+	startServer := func() string {
+		port := test.GetFreePort()
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+
+		cm.StartAndWait(conf.HTTP.Port)
+		t.Cleanup(cm.StopServer)
+
+		return test.GetBaseURL(port)
+	}
+
+	mirrorURL, upstreamURL := startServer(), startServer()
+	image1, image2 := CreateRandomImage(), CreateRandomImage()
+
+	for _, upload := range []struct {
+		image          Image
+		url, repo, tag string
+	}{
+		{image1, mirrorURL, "alpine", "3.18"}, {image1, upstreamURL, "alpine", "3.18"},
+		{image1, mirrorURL, "alpine", "3.19"}, {image2, upstreamURL, "alpine", "3.19"},
+		{image2, mirrorURL, "mirror/alpine", "3.19"},
+	} {
+		if err := UploadImage(upload.image, upload.url, upload.repo, upload.tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runCheckUpdate := func(stdin string, args ...string) (string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(io.Discard)
+		cmd.SetIn(strings.NewReader(stdin))
+		cmd.SetArgs(append([]string{"check-update", "--url", mirrorURL}, args...))
+		// like zli, only the results are shown when some images are not up to date
+		cmd.SilenceUsage = true
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	Convey("Test checking whether images are up to date", t, func() {
+		Convey("against the same image of another registry", func() {
+			output, err := runCheckUpdate("", "alpine:3.18", "--dest-url", upstreamURL, "-f", "ndjson")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, fmt.Sprintf(`{"image":"alpine:3.18","digest":"%s","against":"%s/alpine:3.18",`+
+				`"againstDigest":"%s","status":"same"}`+"\n", image1.DigestStr(), upstreamURL, image1.DigestStr()))
+
+			output, err = runCheckUpdate("", "alpine:3.18", "alpine:3.19", "--dest-url", upstreamURL)
+			So(err, ShouldWrap, zerr.ErrImagesNotUpToDate)
+			So(err.Error(), ShouldContainSubstring, "1 of 2")
+
+			lines := strings.Split(strings.TrimSpace(output), "\n")
+			So(lines, ShouldHaveLength, 3)
+			So(strings.Fields(lines[0]), ShouldResemble, []string{"IMAGE", "DIGEST", "AGAINST", "DIGEST", "STATUS"})
+			So(strings.Fields(lines[2]), ShouldResemble, []string{"alpine:3.19", image1.Digest().Encoded()[:8],
+				upstreamURL + "/alpine:3.19", image2.Digest().Encoded()[:8], "DIFFERS"})
+		})
+
+		Convey("against another image", func() {
+			_, err := runCheckUpdate("", "mirror/alpine:3.19", "--against", "alpine:3.19", "--dest-url", upstreamURL)
+			So(err, ShouldBeNil)
+
+			// in the same registry by default
+			_, err = runCheckUpdate("", "mirror/alpine:3.19", "--against", "alpine:3.19")
+			So(err, ShouldWrap, zerr.ErrImagesNotUpToDate)
+
+			output, err := runCheckUpdate("", "mirror/alpine:3.19", "--dest-url", upstreamURL, "-f", "json")
+			So(err, ShouldWrap, zerr.ErrImagesNotUpToDate)
+			So(output, ShouldContainSubstring, `"againstDigest": ""`)
+			So(output, ShouldContainSubstring, `"status": "missing"`)
+		})
+
+		Convey("of a file", func() {
+			imagesFile := path.Join(t.TempDir(), "images.txt")
+			err := os.WriteFile(imagesFile, []byte("# mirrored images\nalpine:3.18\n\nmirror/alpine:3.19 alpine:3.19\n"),
+				0o600)
+			So(err, ShouldBeNil)
+
+			output, err := runCheckUpdate("", "--file", imagesFile, "--dest-url", upstreamURL, "-f", "ndjson")
+			So(err, ShouldBeNil)
+			So(strings.Count(output, `"status":"same"`), ShouldEqual, 2)
+			So(output, ShouldContainSubstring, `"against":"`+upstreamURL+`/alpine:3.19"`)
+
+			output, err = runCheckUpdate("alpine:3.19\n", "--file", "-", "--dest-url", upstreamURL, "-f", "ndjson")
+			So(err, ShouldWrap, zerr.ErrImagesNotUpToDate)
+			So(output, ShouldContainSubstring, `"status":"differs"`)
+		})
+
+		Convey("against a pin file", func() {
+			pinFile := path.Join(t.TempDir(), "pins.yaml")
+
+			cmd := client.NewImageCommand(client.NewSearchService())
+			cmd.SetOut(io.Discard)
+			cmd.SetArgs([]string{"list", "--url", mirrorURL, "--pin-output", pinFile})
+			err := cmd.Execute()
+			So(err, ShouldBeNil)
+
+			output, err := runCheckUpdate("", "--pin-file", pinFile, "-f", "ndjson")
+			So(err, ShouldBeNil)
+			So(strings.Count(output, `"status":"same"`), ShouldEqual, 3)
+			So(output, ShouldStartWith, `{"image":"alpine:3.18"`)
+
+			err = UploadImage(image2, mirrorURL, "alpine", "3.18")
+			So(err, ShouldBeNil)
+
+			output, err = runCheckUpdate("", "alpine:3.18", "alpine:edge", "--pin-file", pinFile, "-f", "ndjson")
+			So(err, ShouldWrap, zerr.ErrImagesNotUpToDate)
+			So(output, ShouldEqual, fmt.Sprintf(`{"image":"alpine:3.18","digest":"%s","against":"%s",`+
+				`"againstDigest":"%s","status":"differs"}`+"\n"+`{"image":"alpine:edge","digest":"","against":"%s",`+
+				`"againstDigest":"","status":"missing"}`+"\n", image2.DigestStr(), pinFile, image1.DigestStr(), pinFile))
+		})
+
+		Convey("errors", func() {
+			_, err := runCheckUpdate("")
+			So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+
+			_, err = runCheckUpdate("", "alpine")
+			So(err, ShouldWrap, zerr.ErrInvalidRepoRefFormat)
+
+			_, err = runCheckUpdate("", "alpine:3.18", "alpine:3.19", "--against", "alpine:edge")
+			So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+
+			_, err = runCheckUpdate("", "alpine:3.18", "--against", "alpine")
+			So(err, ShouldWrap, zerr.ErrInvalidRepoRefFormat)
+
+			_, err = runCheckUpdate("", "alpine:3.18", "--pin-file", "pins.yaml", "--dest-url", upstreamURL)
+			So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+
+			_, err = runCheckUpdate("", "alpine:3.18", "--pin-file", path.Join(t.TempDir(), "missing.yaml"))
+			So(err, ShouldNotBeNil)
+
+			_, err = runCheckUpdate("alpine:3.18 alpine:3.19 alpine:edge\n", "--file", "-")
+			So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+
+			_, err = runCheckUpdate("", "--file", path.Join(t.TempDir(), "missing.txt"))
+			So(err, ShouldNotBeNil)
+
+			_, err = runCheckUpdate("", "alpine:3.18", "--dest-url", "http://127.0.0.1:1", "--retries", "0")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestImageLayers(t *testing.T) {
+	space := regexp.MustCompile(`\s+`)
+
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+	defaultVal := true
+	conf.Extensions = &extconf.ExtensionConfig{
+		Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}},
+	}
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	baseLayer, appLayer, otherLayer := []byte("base layer"), []byte("app layer"), []byte("other layer")
+	appImage := CreateImageWith().LayerBlobs([][]byte{baseLayer, appLayer}).DefaultConfig().Build()
+	otherImage := CreateImageWith().LayerBlobs([][]byte{baseLayer, otherLayer}).DefaultConfig().Build()
+
+	for repo, image := range map[string]Image{"app": appImage, "mirror": appImage, "other": otherImage} {
+		err := UploadImage(image, baseURL, repo, "1.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runImageCommand := func(args ...string) (string, error) {
+		cmd := client.NewImageCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append(args, "--url", baseURL))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	shortDigest := func(layer []byte) string {
+		return godigest.FromBytes(layer).Encoded()[:8]
+	}
+
+	Convey("Test image layers", t, func() {
+		Convey("the layers shared with other repos", func() {
+			output, err := runImageCommand("layers", "app:1.0")
+			So(err, ShouldBeNil)
+
+			actual := strings.TrimSpace(space.ReplaceAllString(output, " "))
+			So(actual, ShouldStartWith, "DIGEST MEDIA TYPE SIZE SHARED "+
+				shortDigest(baseLayer)+" "+ispec.MediaTypeImageLayerGzip+" 10B 2 "+
+				shortDigest(appLayer)+" "+ispec.MediaTypeImageLayerGzip+" 9B 1")
+			// the base layer is stored once instead of 3 times, the app layer once instead of twice
+			So(actual, ShouldEndWith, "Total: 2 layers, 19B Shared: 2 layers, 19B Saved by dedupe: 29B")
+
+			output, err = runImageCommand("layers", "other@"+otherImage.DigestStr(), "--no-trunc")
+			So(err, ShouldBeNil)
+
+			actual = strings.TrimSpace(space.ReplaceAllString(output, " "))
+			So(actual, ShouldContainSubstring, godigest.FromBytes(otherLayer).String()+" "+
+				ispec.MediaTypeImageLayerGzip+" 11B 0")
+			So(actual, ShouldEndWith, "Total: 2 layers, 21B Shared: 1 layer, 10B Saved by dedupe: 20B")
+		})
+
+		Convey("other formats", func() {
+			output, err := runImageCommand("layers", "app:1.0", "-f", "json")
+			So(err, ShouldBeNil)
+
+			var layers map[string]any
+
+			err = json.Unmarshal([]byte(output), &layers)
+			So(err, ShouldBeNil)
+			So(layers["image"], ShouldEqual, "app:1.0")
+			So(layers["layers"], ShouldResemble, []any{
+				map[string]any{
+					"digest":          godigest.FromBytes(baseLayer).String(),
+					"mediaType":       ispec.MediaTypeImageLayerGzip,
+					"size":            float64(len(baseLayer)),
+					"sharedRepoCount": float64(2),
+				},
+				map[string]any{
+					"digest":          godigest.FromBytes(appLayer).String(),
+					"mediaType":       ispec.MediaTypeImageLayerGzip,
+					"size":            float64(len(appLayer)),
+					"sharedRepoCount": float64(1),
+				},
+			})
+
+			output, err = runImageCommand("layers", "app:1.0", "-f", "ndjson")
+			So(err, ShouldBeNil)
+			So(len(strings.Split(strings.TrimSpace(output), "\n")), ShouldEqual, 2)
+		})
+
+		Convey("errors", func() {
+			_, err := runImageCommand("layers", "app")
+			So(errors.Is(err, zerr.ErrInvalidRepoRefFormat), ShouldBeTrue)
+
+			_, err = runImageCommand("layers", "app:missing")
+			So(err, ShouldNotBeNil)
+
+			_, err = runImageCommand("layers", "missing:1.0")
+			So(err, ShouldNotBeNil)
+
+			_, err = runImageCommand("layers", "app:1.0", "-f", "csv")
+			So(errors.Is(err, zerr.ErrInvalidOutputFormat), ShouldBeTrue)
+		})
+	})
+}
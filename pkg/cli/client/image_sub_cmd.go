@@ -5,7 +5,12 @@ package client
 
 import (
 	"fmt"
+	"math"
+	"strings"
+	"time"
 
+	"github.com/dustin/go-humanize"
+	godigest "github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
 
 	zerr "zotregistry.dev/zot/errors"
@@ -13,33 +18,178 @@ import (
 )
 
 func NewImageListCommand(searchService SearchService) *cobra.Command {
+	var (
+		labels      []string
+		derivedFrom string
+		baseOf      string
+		layoutPath  string
+		watch       time.Duration
+		pinOutput   string
+	)
+
 	imageListSortFlag := ImageListSortFlag(SortByAlphabeticAsc)
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all images",
 		Long:  "List all images",
-		Args:  cobra.NoArgs,
+		Example: `zli image list --label org.opencontainers.image.vendor=acme
+zli image list --url https://registry1:5000 --url https://registry2:5000
+zli image list --derived-from alpine:3.18
+zli image list --base-of app:v1.2
+zli image list --oci-layout /var/lib/registry
+zli image list --watch 30s
+zli image list --pin-output pins.yaml`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{multiRegistryAnnotation: ""},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if layoutPath != "" {
+				if watch != 0 || pinOutput != "" {
+					return fmt.Errorf("%w: --%s and --%s can't be used with --%s", zerr.ErrInvalidCLIParameter,
+						WatchFlag, PinOutputFlag, OCILayoutFlag)
+				}
+
+				return searchOCILayoutImages(cmd, searchService, layoutPath, labels, derivedFrom != "" || baseOf != "")
+			}
+
 			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
 			if err != nil {
 				return err
 			}
 
-			if err := CheckExtEndPointQuery(searchConfig, ImageListQuery()); err == nil {
-				return SearchAllImagesGQL(searchConfig)
+			if (derivedFrom != "" || baseOf != "") && len(labels) > 0 {
+				return fmt.Errorf("%w: --%s can't be used with --%s or --%s", zerr.ErrInvalidCLIParameter,
+					LabelFlag, DerivedFromFlag, BaseOfFlag)
+			}
+
+			if watch < 0 {
+				return fmt.Errorf("%w: --%s should be a positive interval", zerr.ErrInvalidCLIParameter, WatchFlag)
+			}
+
+			listImages := func(config SearchConfig) error {
+				return searchImageList(config, labels, derivedFrom, baseOf)
+			}
+
+			if watch > 0 && pinOutput != "" {
+				return fmt.Errorf("%w: --%s can't be used with --%s", zerr.ErrInvalidCLIParameter, WatchFlag,
+					PinOutputFlag)
+			}
+
+			if watch > 0 {
+				return WatchImages(cmd.Context(), searchConfig, watch, listImages)
 			}
 
-			return SearchAllImages(searchConfig)
+			if pinOutput != "" {
+				return PinImages(cmd.Context(), searchConfig, pinOutput, listImages)
+			}
+
+			return listImages(searchConfig)
 		},
 	}
 
 	cmd.Flags().Var(&imageListSortFlag, SortByFlag,
 		fmt.Sprintf("Options for sorting the output: [%s]", ImageListSortOptionsStr()))
+	addQuietFlags(cmd)
+	cmd.Flags().StringArrayVar(&labels, LabelFlag, nil,
+		"Show only images having the given label or annotation, in 'key=value' format, can be repeated")
+	cmd.Flags().StringVar(&derivedFrom, DerivedFromFlag, "",
+		"Show only the images built on top of the given image, sharing all its layers")
+	cmd.Flags().StringVar(&baseOf, BaseOfFlag, "",
+		"Show only the images the given image is built on top of, whose layers it all contains")
+	cmd.Flags().StringVar(&layoutPath, OCILayoutFlag, "",
+		"List the images of a local oci layout, or of the layouts in a directory like the zot storage, "+
+			"instead of a registry")
+	cmd.Flags().DurationVar(&watch, WatchFlag, 0,
+		"Run the listing again at this interval, e.g. 30s, and only show the images added, removed or "+
+			"pointing to another digest since the previous run")
+	addPinOutputFlag(cmd, &pinOutput)
 
 	return cmd
 }
 
+// searchImageList lists the images of the registries, all of them or only the ones matching the options.
+func searchImageList(searchConfig SearchConfig, labels []string, derivedFrom, baseOf string) error {
+	if derivedFrom != "" || baseOf != "" {
+		return searchImagesSharingLayers(searchConfig, derivedFrom, baseOf)
+	}
+
+	if len(labels) > 0 {
+		labelSelectors, err := parseLabelSelectors(labels)
+		if err != nil {
+			return err
+		}
+
+		if len(searchConfig.Registries) == 0 &&
+			CheckExtEndPointQuery(searchConfig, ImageListWithAnnotationsQuery()) == nil {
+			return SearchImagesByLabelGQL(searchConfig, labelSelectors)
+		}
+
+		// the registries without the annotations in their search results are listed client-side
+		return SearchImagesByLabel(searchConfig, labelSelectors)
+	}
+
+	// the registries may not all have the search extension, so they're listed the same way
+	if len(searchConfig.Registries) > 0 {
+		return SearchAllImages(searchConfig)
+	}
+
+	if err := CheckExtEndPointQuery(searchConfig, ImageListQuery()); err == nil {
+		return SearchAllImagesGQL(searchConfig)
+	}
+
+	return SearchAllImages(searchConfig)
+}
+
+func searchOCILayoutImages(cmd *cobra.Command, searchService SearchService, layoutPath string, labels []string,
+	sharingLayers bool,
+) error {
+	// finding the images sharing layers needs the search extension
+	if sharingLayers {
+		return fmt.Errorf("%w: --%s and --%s can't be used with --%s", zerr.ErrInvalidCLIParameter,
+			DerivedFromFlag, BaseOfFlag, OCILayoutFlag)
+	}
+
+	searchConfig, err := GetOCILayoutSearchConfigFromFlags(cmd, searchService)
+	if err != nil {
+		return err
+	}
+
+	labelSelectors, err := parseLabelSelectors(labels)
+	if err != nil {
+		return err
+	}
+
+	return SearchOCILayoutImages(searchConfig, layoutPath, labelSelectors)
+}
+
+// searchImagesSharingLayers lists the images derived from or base of the given image, which is only
+// known to the search extension of a single registry.
+func searchImagesSharingLayers(searchConfig SearchConfig, derivedFrom, baseOf string) error {
+	if derivedFrom != "" && baseOf != "" {
+		return fmt.Errorf("%w: --%s and --%s can't be used together", zerr.ErrInvalidCLIParameter,
+			DerivedFromFlag, BaseOfFlag)
+	}
+
+	if len(searchConfig.Registries) > 0 {
+		return fmt.Errorf("%w: --%s and --%s can only search a single registry", zerr.ErrInvalidCLIParameter,
+			DerivedFromFlag, BaseOfFlag)
+	}
+
+	if derivedFrom != "" {
+		if err := CheckExtEndPointQuery(searchConfig, DerivedImageListQuery()); err != nil {
+			return err
+		}
+
+		return SearchDerivedImageListGQL(searchConfig, derivedFrom)
+	}
+
+	if err := CheckExtEndPointQuery(searchConfig, BaseImageListQuery()); err != nil {
+		return err
+	}
+
+	return SearchBaseImageListGQL(searchConfig, baseOf)
+}
+
 func NewImageCVEListCommand(searchService SearchService) *cobra.Command {
 	var (
 		searchedCVEID   string
@@ -47,10 +197,11 @@ func NewImageCVEListCommand(searchService SearchService) *cobra.Command {
 	)
 
 	cmd := &cobra.Command{
-		Use:   "cve [repo]|[repo-name:tag]|[repo-name@digest]",
-		Short: "List all CVE's of the image",
-		Long:  "List all CVE's of the image",
-		Args:  OneImageWithRefArg,
+		Use:               "cve [repo]|[repo-name:tag]|[repo-name@digest]",
+		Short:             "List all CVE's of the image",
+		Long:              "List all CVE's of the image",
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              OneImageWithRefArg,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
 			if err != nil {
@@ -78,10 +229,11 @@ func NewImageDerivedCommand(searchService SearchService) *cobra.Command {
 	imageListSortFlag := ImageListSortFlag(SortByAlphabeticAsc)
 
 	cmd := &cobra.Command{
-		Use:   "derived [repo-name:tag]|[repo-name@digest]",
-		Short: "List images that are derived from given image",
-		Long:  "List images that are derived from given image",
-		Args:  OneImageWithRefArg,
+		Use:               "derived [repo-name:tag]|[repo-name@digest]",
+		Short:             "List images that are derived from given image",
+		Long:              "List images that are derived from given image",
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              OneImageWithRefArg,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
 			if err != nil {
@@ -98,6 +250,7 @@ func NewImageDerivedCommand(searchService SearchService) *cobra.Command {
 
 	cmd.Flags().Var(&imageListSortFlag, SortByFlag,
 		fmt.Sprintf("Options for sorting the output: [%s]", ImageListSortOptionsStr()))
+	addQuietFlags(cmd)
 
 	return cmd
 }
@@ -106,10 +259,11 @@ func NewImageBaseCommand(searchService SearchService) *cobra.Command {
 	imageListSortFlag := ImageListSortFlag(SortByAlphabeticAsc)
 
 	cmd := &cobra.Command{
-		Use:   "base [repo-name:tag]|[repo-name@digest]",
-		Short: "List images that are base for the given image",
-		Long:  "List images that are base for the given image",
-		Args:  OneImageWithRefArg,
+		Use:               "base [repo-name:tag]|[repo-name@digest]",
+		Short:             "List images that are base for the given image",
+		Long:              "List images that are base for the given image",
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              OneImageWithRefArg,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
 			if err != nil {
@@ -126,6 +280,7 @@ func NewImageBaseCommand(searchService SearchService) *cobra.Command {
 
 	cmd.Flags().Var(&imageListSortFlag, SortByFlag,
 		fmt.Sprintf("Options for sorting the output: [%s]", ImageListSortOptionsStr()))
+	addQuietFlags(cmd)
 
 	return cmd
 }
@@ -156,17 +311,29 @@ zli image digest sha256:8a1930f0...`,
 
 	cmd.Flags().Var(&imageListSortFlag, SortByFlag,
 		fmt.Sprintf("Options for sorting the output: [%s]", ImageListSortOptionsStr()))
+	addQuietFlags(cmd)
 
 	return cmd
 }
 
 func NewImageNameCommand(searchService SearchService) *cobra.Command {
+	var pinOutput string
+
 	imageListSortFlag := ImageListSortFlag(SortByAlphabeticAsc)
 
 	cmd := &cobra.Command{
-		Use:   "name [repo:tag]",
+		Use:   "name [repo:tag]|[repo@digest]",
 		Short: "List image details by name",
-		Long:  "List image details by name",
+		Long: `List image details by name.
+The repo and tag can be glob patterns, or with --regex the name is a regex matched against the repo names.
+With a digest, the tags of the repo pointing at it are listed.`,
+		Example: `  zli image name alpine:3.18
+  zli image name 'app/*:v1.*'
+  zli image name --regex '^(app|lib)/'
+  zli image name 'app/*:v1.*' --pin-output pins.yaml
+  zli image name alpine@sha256:8b0b6b4f6b5a3c1e2e5c6f4c3d0f7b1a9a8e6d5c4b3a2f1e0d9c8b7a6f5e4d3c`,
+		Annotations:       map[string]string{multiRegistryAnnotation: ""},
+		ValidArgsFunction: completeImageArgs(searchService, 1),
 		Args: func(cmd *cobra.Command, args []string) error {
 			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
 				return err
@@ -174,6 +341,18 @@ func NewImageNameCommand(searchService SearchService) *cobra.Command {
 
 			image := args[0]
 
+			if isRegex, _ := cmd.Flags().GetBool(RegexFlag); isRegex {
+				return nil
+			}
+
+			if repo, digest, found := strings.Cut(image, "@"); found {
+				if _, err := godigest.Parse(digest); repo == "" || err != nil {
+					return zerr.ErrInvalidRepoRefFormat
+				}
+
+				return nil
+			}
+
 			if dir, _ := zcommon.GetImageDirAndTag(image); dir == "" {
 				return zerr.ErrInvalidRepoRefFormat
 			}
@@ -186,16 +365,498 @@ func NewImageNameCommand(searchService SearchService) *cobra.Command {
 				return err
 			}
 
-			if err := CheckExtEndPointQuery(searchConfig, ImageListQuery()); err == nil {
-				return SearchImageByNameGQL(searchConfig, args[0])
+			searchImages := func(config SearchConfig) error {
+				if len(config.Registries) > 0 {
+					return SearchImageByName(config, args[0])
+				}
+
+				if err := CheckExtEndPointQuery(config, ImageListQuery()); err == nil {
+					return SearchImageByNameGQL(config, args[0])
+				}
+
+				return SearchImageByName(config, args[0])
+			}
+
+			if pinOutput != "" {
+				return PinImages(cmd.Context(), searchConfig, pinOutput, searchImages)
 			}
 
-			return SearchImageByName(searchConfig, args[0])
+			return searchImages(searchConfig)
 		},
 	}
 
 	cmd.Flags().Var(&imageListSortFlag, SortByFlag,
 		fmt.Sprintf("Options for sorting the output: [%s]", ImageListSortOptionsStr()))
+	addQuietFlags(cmd)
+	cmd.Flags().Bool(RegexFlag, false, "Match the repo names against the given regex")
+	addPinOutputFlag(cmd, &pinOutput)
+
+	return cmd
+}
+
+func NewImageInspectCommand(searchService SearchService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect [repo-name:tag]|[repo-name@digest]",
+		Short: "Show the manifest, config and layers of an image",
+		Long: `Show the full manifest of the image, its config (env, entrypoint, labels and history)
+and the size and origin of each of its layers. For an image index, all its images are shown.`,
+		Example: `  zli image inspect alpine:3.18
+  zli image inspect alpine:3.18 -f json`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              OneImageWithRefArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return InspectImage(searchConfig, args[0])
+		},
+	}
+
+	return cmd
+}
+
+func NewImageVerifyCommand(searchService SearchService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify [repo-name:tag]|[repo-name@digest]",
+		Short: "Download an image and check its integrity",
+		Long: `Download the manifest and all the blobs of the image, or of all the images of an index, and check
+that their digests and sizes are the ones they are referenced with. Unlike the scrub of the server, this
+checks the content as the clients receive it, e.g. through a proxy. The blobs aren't kept, and the command
+fails if any mismatch or missing blob is found.`,
+		Example: `  zli image verify alpine:3.18
+  zli image verify alpine:3.18 -f json`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              OneImageWithRefArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return VerifyImage(searchConfig, args[0])
+		},
+	}
 
 	return cmd
 }
+
+func NewImageCheckUpdateCommand(searchService SearchService) *cobra.Command {
+	var against, pinFile, imagesFile string
+
+	cmd := &cobra.Command{
+		Use:   "check-update [repo-name:tag]|[repo-name@digest]...",
+		Short: "Check whether images have the same digests as other images or a pin file",
+		Long: `Compare the digest of each image with the one of the image it's checked against, e.g. to check a
+mirror is up to date. By default an image is checked against the same repo and tag of the registry given with
+--dest-url or --dest-config, or against --against. With --pin-file, the images are checked against the digests
+written by --pin-output, all the pinned images if none are given.
+The images can also be read from --file, one per line, optionally followed by the image it's checked against,
+'-' reads them from the standard input. The command fails if any of the digests differ or is missing.`,
+		Example: `  zli image check-update alpine:3.18 --dest-url https://upstream:8080
+  zli image check-update mirror/alpine:3.18 --against alpine:3.18 --dest-config upstream
+  zli image check-update --pin-file pins.yaml
+  zli image check-update --file images.txt --dest-config upstream -f json`,
+		ValidArgsFunction: completeImageArgs(searchService, math.MaxInt),
+		Args: func(cmd *cobra.Command, args []string) error {
+			for _, image := range args {
+				if dir, ref, _ := zcommon.GetImageDirAndReference(image); dir == "" || ref == "" {
+					return zerr.ErrInvalidRepoRefFormat
+				}
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			flags := cmd.Flags()
+
+			if pinFile != "" && (against != "" || flags.Changed(DestURLFlag) || flags.Changed(DestConfigFlag)) {
+				return fmt.Errorf("%w: --%s can't be used with --%s, --%s or --%s", zerr.ErrInvalidCLIParameter,
+					PinFileFlag, AgainstFlag, DestURLFlag, DestConfigFlag)
+			}
+
+			images := []imageToCheck{}
+
+			for _, image := range args {
+				images = append(images, imageToCheck{image: image, against: image})
+			}
+
+			if imagesFile != "" {
+				fileImages, err := readImagesToCheckFile(imagesFile, cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+
+				images = append(images, fileImages...)
+			}
+
+			if against != "" {
+				if len(images) != 1 {
+					return fmt.Errorf("%w: --%s can only be used with a single image", zerr.ErrInvalidCLIParameter,
+						AgainstFlag)
+				}
+
+				if dir, ref, _ := zcommon.GetImageDirAndReference(against); dir == "" || ref == "" {
+					return zerr.ErrInvalidRepoRefFormat
+				}
+
+				images[0].against = against
+			}
+
+			againstConfig, err := getDestSearchConfig(cmd, searchConfig)
+			if err != nil {
+				return err
+			}
+
+			return CheckImageUpdates(searchConfig, againstConfig, images, pinFile)
+		},
+	}
+
+	cmd.Flags().StringVar(&against, AgainstFlag, "",
+		"The image the given one is checked against, by default the same repo and tag")
+	cmd.Flags().String(DestURLFlag, "", "Specify the url of the registry the images are checked against")
+	cmd.Flags().String(DestConfigFlag, "", "Specify the config of the registry the images are checked against")
+	_ = cmd.RegisterFlagCompletionFunc(DestConfigFlag, completeConfigNames)
+	cmd.Flags().String(DestUserFlag, "",
+		`User credentials for the registry the images are checked against, "username:password"`)
+	cmd.Flags().StringVar(&pinFile, PinFileFlag, "", "Check the images against the digests of this pin file")
+	cmd.Flags().StringVar(&imagesFile, ImagesFileFlag, "",
+		"Read the images to check from this file, one per line, optionally followed by the image it's checked against")
+
+	return cmd
+}
+
+func NewImageHistoryCommand(searchService SearchService) *cobra.Command {
+	var platform string
+
+	cmd := &cobra.Command{
+		Use:   "history [repo-name:tag]|[repo-name@digest]",
+		Short: "Show the history of the layers of an image",
+		Long: `Show the command which created each layer of the image, and its size, the most recent first.
+For an image index, the history of all its images is shown, or only of the one of the given platform.`,
+		Example: `  zli image history alpine:3.18
+  zli image history alpine:3.18 --platform linux/arm64 --no-trunc`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              OneImageWithRefArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return ShowImageHistory(searchConfig, args[0], platform)
+		},
+	}
+
+	cmd.Flags().StringVar(&platform, PlatformFlag, "",
+		"Only show the image of this platform of an image index, in os[/arch[/variant]] format")
+
+	return cmd
+}
+
+func NewImageLayersCommand(searchService SearchService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "layers [repo-name:tag]|[repo-name@digest]",
+		Short: "Show the layers of an image and the repos sharing them",
+		Long: `Show the digest, media type and compressed size of each layer of the image, or of all the images
+of an image index, and the number of other repos having an image with the same layer. The registry
+stores a shared layer only once, the summary shows how much storage this saves.`,
+		Example: `  zli image layers alpine:3.18
+  zli image layers app@sha256:8b1f... --no-trunc -f json`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              OneImageWithRefArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			if err := CheckExtEndPointQuery(searchConfig, ImageLayersQuery()); err != nil {
+				return err
+			}
+
+			return ShowImageLayers(searchConfig, args[0])
+		},
+	}
+
+	return cmd
+}
+
+func NewImageDiffCommand(searchService SearchService) *cobra.Command {
+	var platform string
+
+	cmd := &cobra.Command{
+		Use:   "diff [repo-name:tag]|[repo-name@digest] [repo-name:tag]|[repo-name@digest]",
+		Short: "Show the layers which differ between two images",
+		Long: `Show the layers the two images have in common (=), the ones only the first image has (-)
+and the ones only the second image has (+). If an image is an image index, the image of the given
+platform is compared.`,
+		Example: `  zli image diff alpine:3.18 alpine:3.19
+  zli image diff app:v1 app:v2 --platform linux/amd64 -f json`,
+		ValidArgsFunction: completeImageArgs(searchService, 2),
+		Args:              TwoImagesWithRefArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return DiffImages(searchConfig, args[0], args[1], platform)
+		},
+	}
+
+	cmd.Flags().StringVar(&platform, PlatformFlag, "",
+		"The platform of the images to compare if they are image indexes, in os[/arch[/variant]] format")
+
+	return cmd
+}
+
+func NewImageDeleteCommand(searchService SearchService) *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "delete [repo-name:tag]|[repo-name@digest]",
+		Short: "Delete an image from the registry",
+		Long: `Delete the given tag or manifest from the registry.
+The tag can be a glob pattern, in which case all the matching tags are deleted.`,
+		Example: `  zli image delete alpine:3.18
+  zli image delete alpine@sha256:8b0b6b4f6b5a3c1e2e5c6f4c3d0f7b1a9a8e6d5c4b3a2f1e0d9c8b7a6f5e4d3c
+  zli image delete alpine:'3.*' --force`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              OneImageWithRefArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return DeleteImages(searchConfig, args[0], force, cmd.InOrStdin())
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Delete the images without asking for confirmation")
+
+	return cmd
+}
+
+func NewImageCopyCommand(searchService SearchService) *cobra.Command {
+	var allTags, ifNewer, missingOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "copy [repo-name:tag]|[repo-name@digest]|[repo-name] [dest-repo[:tag]]",
+		Short: "Copy an image to another repo or registry",
+		Long: `Copy the image, with all its blobs, to the destination repo. For an image index, all its images
+are copied too. The destination registry is given with --dest-url or --dest-config, by default it's the
+source one, in which case the blobs are mounted instead of being uploaded again.
+With --all-tags, all the tags of the source repo are copied.
+With --missing-only, the tags the destination already has are skipped, whatever their image. With --if-newer,
+the destination tags are only replaced by a different image created after theirs, according to the configs
+of the images, or the latest config of the images of an index. Both show the number of copied and skipped
+images at the end.`,
+		Example: `  zli image copy alpine:3.18 mirror/alpine
+  zli image copy alpine:3.18 alpine:stable --dest-url https://other-registry:8080
+  zli image copy alpine mirror/alpine --all-tags --dest-config other-registry
+  zli image copy alpine mirror/alpine --all-tags --if-newer --dest-config other-registry`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              cobra.ExactArgs(2), //nolint:gomnd
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			destConfig, err := getDestSearchConfig(cmd, searchConfig)
+			if err != nil {
+				return err
+			}
+
+			return CopyImages(searchConfig, destConfig, args[0], args[1], allTags, ifNewer, missingOnly)
+		},
+	}
+
+	cmd.Flags().String(DestURLFlag, "", "Specify the url of the destination registry")
+	cmd.Flags().String(DestConfigFlag, "", "Specify the config of the destination registry")
+	_ = cmd.RegisterFlagCompletionFunc(DestConfigFlag, completeConfigNames)
+	cmd.Flags().String(DestUserFlag, "", `User credentials for the destination registry, "username:password"`)
+	cmd.Flags().BoolVar(&allTags, AllTagsFlag, false, "Copy all the tags of the source repo")
+	cmd.Flags().BoolVar(&ifNewer, IfNewerFlag, false,
+		"Only replace the destination tags with an image created after theirs")
+	cmd.Flags().BoolVar(&missingOnly, MissingOnlyFlag, false, "Skip the tags the destination already has")
+	cmd.MarkFlagsMutuallyExclusive(IfNewerFlag, MissingOnlyFlag)
+
+	return cmd
+}
+
+func NewImagePullCommand(searchService SearchService) *cobra.Command {
+	var layoutPath string
+
+	cmd := &cobra.Command{
+		Use:   "pull [repo-name:tag]|[repo-name@digest] --to [layout-dir]",
+		Short: "Pull an image to an OCI layout",
+		Long: `Pull the image, with all its blobs, to the OCI layout directory, which is created if it doesn't
+exist. For an image index, all its images are pulled too. A tagged image is added to the index.json of the
+layout with its tag as ref name, the blobs the layout already has aren't downloaded again.`,
+		Example: `  zli image pull alpine:3.18 --to ./alpine
+  zli image pull alpine@sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b --to ./alpine`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return PullImage(searchConfig, args[0], layoutPath)
+		},
+	}
+
+	cmd.Flags().StringVar(&layoutPath, ToFlag, "", "The OCI layout directory to pull the image to")
+	_ = cmd.MarkFlagRequired(ToFlag)
+	_ = cmd.MarkFlagDirname(ToFlag)
+
+	return cmd
+}
+
+func NewImagePushCommand(searchService SearchService) *cobra.Command {
+	var chunkSize string
+
+	cmd := &cobra.Command{
+		Use:   "push [layout-dir] [repo-name[:tag]]",
+		Short: "Push an image of an OCI layout",
+		Long: `Push the image of the OCI layout directory, with all its blobs, to the repo. The image is the one
+whose ref name in the index.json is the tag, or the only one of the layout, in which case its ref name is
+the tag if none is given. The blobs the repo already has aren't uploaded again, the bigger ones than
+--chunk-size are uploaded in chunks.`,
+		Example: `  zli image push ./alpine alpine:3.18
+  zli image push ./alpine mirror/alpine --chunk-size 16MiB`,
+		Args: cobra.ExactArgs(2), //nolint:gomnd
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string,
+			cobra.ShellCompDirective,
+		) {
+			if len(args) == 0 {
+				return nil, cobra.ShellCompDirectiveFilterDirs
+			}
+
+			return completeImageArgs(searchService, 2)(cmd, args, toComplete) //nolint:gomnd
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			size, err := humanize.ParseBytes(chunkSize)
+			if err != nil {
+				return fmt.Errorf("%w: invalid --%s %q", zerr.ErrInvalidCLIParameter, ChunkSizeFlag, chunkSize)
+			}
+
+			return PushImage(searchConfig, args[0], args[1], int64(size))
+		},
+	}
+
+	cmd.Flags().StringVar(&chunkSize, ChunkSizeFlag, defaultChunkSize,
+		"Size of the chunks the blobs are uploaded in, e.g. 16MiB, 0 uploads each blob in a single request")
+
+	return cmd
+}
+
+func NewImageAnnotateCommand(searchService SearchService) *cobra.Command {
+	var (
+		removed []string
+		tag     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "annotate [repo-name:tag]|[repo-name@digest] [key=value]...",
+		Short: "Set or remove annotations of an image manifest",
+		Long: `Set the key=value annotations of the image manifest, or of the index for a multi-arch image, and
+remove the --remove ones, without rebuilding the image: the new manifest references the same blobs or images.
+It replaces the image under its tag, or is pushed under the --tag one, the original image keeping its tag.
+As the new manifest has another digest, the signatures and other referrers of the original image don't apply
+to it. Docker manifests have no annotations and can't be annotated.`,
+		Example: `  zli image annotate alpine:3.18 owner=team-a ticket=OPS-1234
+  zli image annotate alpine:3.18 --remove expiry
+  zli image annotate alpine@sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b \
+    org.opencontainers.image.vendor=acme --tag 3.18-acme`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return AnnotateImage(searchConfig, args[0], args[1:], removed, tag)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&removed, RemoveFlag, nil, "Annotation keys to remove, e.g. --remove expiry,ticket")
+	cmd.Flags().StringVar(&tag, TagFlag, "",
+		"Push the annotated image under this tag instead of replacing the image, required for a digest")
+
+	return cmd
+}
+
+func NewImageTagCommand(searchService SearchService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tag [repo-name:tag]|[repo-name@digest] [new-tag]...",
+		Short: "Add tags to an image",
+		Long: `Add the tags to the image, in its repo. The manifest is pushed again under each tag, no blob is
+copied, e.g. to promote a tested image to a release tag. A tag already used by another image is moved.`,
+		Example: `  zli image tag alpine@sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b 3.18 latest
+  zli image tag app:rc1 stable`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              cobra.MinimumNArgs(2), //nolint:gomnd
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return TagImage(searchConfig, args[0], args[1:])
+		},
+	}
+
+	return cmd
+}
+
+func NewImageUntagCommand(searchService SearchService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "untag [repo-name:tag]",
+		Short: "Remove a tag from its repo",
+		Long: `Remove the tag from its repo without asking for confirmation. The image stays in the repo under
+its other tags, but is deleted along with the tag if it was its last one.`,
+		Example:           `  zli image untag app:rc1`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return UntagImage(searchConfig, args[0])
+		},
+	}
+
+	return cmd
+}
+
+// addQuietFlags adds the flags printing only the references or the digests of the listed images.
+func addQuietFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP(QuietFlag, "q", false,
+		"Only show the images as 'repo:tag', one per line, e.g. to feed them to xargs")
+	cmd.Flags().Bool(DigestsFlag, false, "With --quiet, show the digests of the images instead of 'repo:tag'")
+}
+
+func addPinOutputFlag(cmd *cobra.Command, pinOutput *string) {
+	cmd.Flags().StringVar(pinOutput, PinOutputFlag, "",
+		"Also write the digest of each image listed to this yaml file, as 'repo:tag: digest' sorted by name, "+
+			"so deployments can be pinned to them and the file of a later run diffed with it to find the drift")
+}
@@ -0,0 +1,393 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/dustin/go-humanize"
+	jsoniter "github.com/json-iterator/go"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gopkg.in/yaml.v2"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/constants"
+	"zotregistry.dev/zot/pkg/common"
+)
+
+// imageInspectStruct is the full content of an image, unlike imageStruct which only summarizes it.
+// An image index has one entry per manifest, a single manifest has a single entry.
+type imageInspectStruct struct {
+	RepoName    string                  `json:"repoName"`
+	Reference   string                  `json:"reference"`
+	Digest      string                  `json:"digest"`
+	MediaType   string                  `json:"mediaType"`
+	Size        int64                   `json:"size"`
+	Annotations map[string]string       `json:"annotations,omitempty"`
+	Manifests   []manifestInspectStruct `json:"manifests"`
+}
+
+type manifestInspectStruct struct {
+	Digest    string               `json:"digest"`
+	MediaType string               `json:"mediaType"`
+	Platform  common.Platform      `json:"platform"`
+	Manifest  ispec.Manifest       `json:"manifest"`
+	Config    ispec.Image          `json:"config"`
+	Layers    []layerInspectStruct `json:"layers"`
+}
+
+type layerInspectStruct struct {
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType"`
+	Size      int64  `json:"size"`
+	CreatedBy string `json:"createdBy,omitempty"`
+}
+
+func fetchImageInspectStruct(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+) (*imageInspectStruct, error) {
+	content, mediaType, digest, err := fetchRawManifest(ctx, config, username, password, repo, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &imageInspectStruct{
+		RepoName:  repo,
+		Reference: reference,
+		Digest:    digest,
+		MediaType: mediaType,
+		Size:      int64(len(content)),
+	}
+
+	switch mediaType {
+	case ispec.MediaTypeImageManifest, schema2.MediaTypeManifest:
+		manifest, err := getManifestInspectStruct(ctx, config, username, password, repo, digest, mediaType, content)
+		if err != nil {
+			return nil, err
+		}
+
+		image.Annotations = manifest.Manifest.Annotations
+		image.Manifests = []manifestInspectStruct{manifest}
+	case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
+		var index ispec.Index
+
+		if err := json.Unmarshal(content, &index); err != nil {
+			return nil, err
+		}
+
+		image.Annotations = index.Annotations
+
+		for _, descriptor := range index.Manifests {
+			// the index can also reference other indexes or artifacts, only the images are inspected
+			if descriptor.MediaType != ispec.MediaTypeImageManifest && descriptor.MediaType != schema2.MediaTypeManifest {
+				continue
+			}
+
+			content, mediaType, digest, err := fetchRawManifest(ctx, config, username, password, repo,
+				descriptor.Digest.String())
+			if err != nil {
+				return nil, err
+			}
+
+			manifest, err := getManifestInspectStruct(ctx, config, username, password, repo, digest, mediaType, content)
+			if err != nil {
+				return nil, err
+			}
+
+			if descriptor.Platform != nil {
+				manifest.Platform = common.Platform{
+					Os:      descriptor.Platform.OS,
+					Arch:    descriptor.Platform.Architecture,
+					Variant: descriptor.Platform.Variant,
+				}
+			}
+
+			image.Manifests = append(image.Manifests, manifest)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", zerr.ErrMediaTypeNotSupported, mediaType)
+	}
+
+	return image, nil
+}
+
+// fetchRawManifest returns the manifest as it is stored in the registry, with its media type and digest.
+func fetchRawManifest(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+) ([]byte, string, string, error) {
+	var content json.RawMessage
+
+	URL := fmt.Sprintf("%s/v2/%s/manifests/%s", config.ServURL, repo, reference)
+
+	header, err := makeManifestGETRequest(ctx, URL, username, password, config, &content)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	mediaType := header.Get("Content-Type")
+
+	if mediaType == "" {
+		var manifest struct {
+			MediaType string `json:"mediaType"`
+		}
+
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			return nil, "", "", err
+		}
+
+		mediaType = manifest.MediaType
+	}
+
+	digest := header.Get(constants.DistContentDigestKey)
+	if digest == "" {
+		digest = godigest.FromBytes(content).String()
+	}
+
+	return content, mediaType, digest, nil
+}
+
+func getManifestInspectStruct(ctx context.Context, config SearchConfig, username, password, repo, digest,
+	mediaType string, content []byte,
+) (manifestInspectStruct, error) {
+	var manifest ispec.Manifest
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return manifestInspectStruct{}, err
+	}
+
+	configContent, err := fetchConfig(ctx, repo, manifest.Config.Digest.String(), config, username, password)
+	if err != nil {
+		return manifestInspectStruct{}, err
+	}
+
+	platform := common.Platform{
+		Os:      configContent.OS,
+		Arch:    configContent.Architecture,
+		Variant: configContent.Variant,
+	}
+
+	return manifestInspectStruct{
+		Digest:    digest,
+		MediaType: mediaType,
+		Platform:  platform,
+		Manifest:  manifest,
+		Config:    configContent,
+		Layers:    getLayersInspect(manifest.Layers, configContent.History),
+	}, nil
+}
+
+// getLayersInspect matches the layers with the history entries which created them.
+// The entries without a layer are skipped, and if the history doesn't match the layers it isn't used.
+func getLayersInspect(layers []ispec.Descriptor, history []ispec.History) []layerInspectStruct {
+	layerHistory := []ispec.History{}
+
+	for _, entry := range history {
+		if !entry.EmptyLayer {
+			layerHistory = append(layerHistory, entry)
+		}
+	}
+
+	layersInspect := make([]layerInspectStruct, 0, len(layers))
+
+	for i, layer := range layers {
+		layerInspect := layerInspectStruct{
+			Digest:    layer.Digest.String(),
+			MediaType: layer.MediaType,
+			Size:      layer.Size,
+		}
+
+		if len(layerHistory) == len(layers) {
+			layerInspect.CreatedBy = layerHistory[i].CreatedBy
+		}
+
+		layersInspect = append(layersInspect, layerInspect)
+	}
+
+	return layersInspect
+}
+
+func (img imageInspectStruct) string(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return img.stringPlainText()
+	case jsonFormat:
+		return img.stringJSON()
+	case ndjsonFormat:
+		return img.stringNDJSON()
+	case ymlFormat, yamlFormat:
+		return img.stringYAML()
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (img imageInspectStruct) stringPlainText() (string, error) {
+	var builder strings.Builder
+
+	writer := tabwriter.NewWriter(&builder, 0, 8, 2, ' ', 0) //nolint:gomnd
+
+	fmt.Fprintf(writer, "Repository:\t%s\n", img.RepoName)
+	fmt.Fprintf(writer, "Reference:\t%s\n", img.Reference)
+	fmt.Fprintf(writer, "Digest:\t%s\n", img.Digest)
+	fmt.Fprintf(writer, "Media type:\t%s\n", img.MediaType)
+
+	writeInspectMap(writer, "Annotations", img.Annotations)
+
+	for _, manifest := range img.Manifests {
+		config := manifest.Config
+
+		fmt.Fprintln(writer)
+
+		if len(img.Manifests) > 1 || manifest.Digest != img.Digest {
+			fmt.Fprintf(writer, "Manifest:\t%s\n", manifest.Digest)
+		}
+
+		fmt.Fprintf(writer, "Platform:\t%s\n", getPlatformStr(manifest.Platform))
+		fmt.Fprintf(writer, "Config:\t%s\n", manifest.Manifest.Config.Digest)
+
+		if config.Created != nil {
+			fmt.Fprintf(writer, "Created:\t%s\n", getCreatedStr(*config.Created))
+		}
+
+		writeInspectValue(writer, "Author", config.Author)
+		writeInspectValue(writer, "User", config.Config.User)
+		writeInspectValue(writer, "Working dir", config.Config.WorkingDir)
+		writeInspectList(writer, "Entrypoint", config.Config.Entrypoint)
+		writeInspectList(writer, "Cmd", config.Config.Cmd)
+		writeInspectList(writer, "Env", config.Config.Env)
+		writeInspectMap(writer, "Labels", config.Config.Labels)
+
+		fmt.Fprintln(writer, "Layers:")
+
+		for _, layer := range manifest.Layers {
+			writeInspectRow(writer, layer.Digest, layer.MediaType,
+				strings.ReplaceAll(humanize.Bytes(uint64(layer.Size)), " ", ""), layer.CreatedBy)
+		}
+
+		if len(config.History) > 0 {
+			fmt.Fprintln(writer, "History:")
+		}
+
+		for _, entry := range config.History {
+			created := "N/A"
+			if entry.Created != nil {
+				created = getCreatedStr(*entry.Created)
+			}
+
+			emptyLayer := ""
+			if entry.EmptyLayer {
+				emptyLayer = "(empty layer)"
+			}
+
+			writeInspectRow(writer, created, entry.CreatedBy, emptyLayer)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// writeInspectRow writes an indented table row, without the empty trailing cells.
+func writeInspectRow(writer *tabwriter.Writer, cells ...string) {
+	for len(cells) > 0 && cells[len(cells)-1] == "" {
+		cells = cells[:len(cells)-1]
+	}
+
+	fmt.Fprintln(writer, "  "+strings.Join(cells, "\t"))
+}
+
+func writeInspectValue(writer *tabwriter.Writer, name, value string) {
+	if value != "" {
+		fmt.Fprintf(writer, "%s:\t%s\n", name, value)
+	}
+}
+
+func writeInspectList(writer *tabwriter.Writer, name string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	quoted := make([]string, 0, len(values))
+
+	for _, value := range values {
+		quoted = append(quoted, strconv.Quote(value))
+	}
+
+	fmt.Fprintf(writer, "%s:\t[%s]\n", name, strings.Join(quoted, ", "))
+}
+
+func writeInspectMap(writer *tabwriter.Writer, name string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	fmt.Fprintf(writer, "%s:\n", name)
+
+	for _, key := range keys {
+		fmt.Fprintf(writer, "  %s=%s\n", key, values[key])
+	}
+}
+
+func (img imageInspectStruct) stringJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.MarshalIndent(img, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+func (img imageInspectStruct) stringNDJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.Marshal(img)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+func (img imageInspectStruct) stringYAML() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	// the image-spec structures only have json tags, going through json keeps their field names
+	body, err := json.Marshal(img)
+	if err != nil {
+		return "", err
+	}
+
+	var document yaml.MapSlice
+
+	if err := yaml.Unmarshal(body, &document); err != nil {
+		return "", err
+	}
+
+	body, err = yaml.Marshal(document)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(body), nil
+}
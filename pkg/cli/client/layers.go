@@ -0,0 +1,148 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v2"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/common"
+)
+
+// imageLayersStruct is the list of the layers of an image, in the order of its manifest, with the number
+// of the other repos sharing each of them.
+type imageLayersStruct struct {
+	Image  string             `json:"image"`
+	Layers []imageLayerStruct `json:"layers"`
+}
+
+type imageLayerStruct struct {
+	Digest          string `json:"digest"`
+	MediaType       string `json:"mediaType"`
+	Size            int64  `json:"size"`
+	SharedRepoCount int    `json:"sharedRepoCount"`
+}
+
+func getImageLayersStruct(image string, layers []common.LayerUsage) (imageLayersStruct, error) {
+	result := imageLayersStruct{Image: image, Layers: make([]imageLayerStruct, 0, len(layers))}
+
+	for _, layer := range layers {
+		size, err := strconv.ParseInt(layer.Size, 10, 64)
+		if err != nil {
+			return imageLayersStruct{}, err
+		}
+
+		result.Layers = append(result.Layers, imageLayerStruct{
+			Digest:          layer.Digest,
+			MediaType:       layer.MediaType,
+			Size:            size,
+			SharedRepoCount: layer.SharedRepoCount,
+		})
+	}
+
+	return result, nil
+}
+
+func (layers imageLayersStruct) string(format string, noTrunc bool) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return layers.stringPlainText(noTrunc)
+	case jsonFormat:
+		return layers.stringJSON()
+	case ndjsonFormat:
+		return layers.stringNDJSON()
+	case ymlFormat, yamlFormat:
+		return layers.stringYAML()
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (layers imageLayersStruct) stringPlainText(noTrunc bool) (string, error) {
+	var builder strings.Builder
+
+	writer := tabwriter.NewWriter(&builder, 0, 8, 2, ' ', 0) //nolint:gomnd
+
+	fmt.Fprintln(writer, "DIGEST\tMEDIA TYPE\tSIZE\tSHARED")
+
+	var (
+		totalSize, sharedSize, savedSize int64
+		sharedCount                      int
+	)
+
+	for _, layer := range layers.Layers {
+		digest := layer.Digest
+		if !noTrunc {
+			digest = getShortDigest(digest)
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d\n", digest, layer.MediaType, getSizeStr(layer.Size),
+			layer.SharedRepoCount)
+
+		totalSize += layer.Size
+
+		if layer.SharedRepoCount > 0 {
+			sharedCount++
+			sharedSize += layer.Size
+			// the layer is stored once instead of once per repo
+			savedSize += layer.Size * int64(layer.SharedRepoCount)
+		}
+	}
+
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "Total:\t%s\n", getLayerCountStr(len(layers.Layers), totalSize))
+	fmt.Fprintf(writer, "Shared:\t%s\n", getLayerCountStr(sharedCount, sharedSize))
+	fmt.Fprintf(writer, "Saved by dedupe:\t%s\n", getSizeStr(savedSize))
+
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+func (layers imageLayersStruct) stringJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.MarshalIndent(layers, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+// stringNDJSON writes one layer per line.
+func (layers imageLayersStruct) stringNDJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	var builder strings.Builder
+
+	for _, layer := range layers.Layers {
+		body, err := json.Marshal(layer)
+		if err != nil {
+			return "", err
+		}
+
+		builder.Write(body)
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
+
+func (layers imageLayersStruct) stringYAML() (string, error) {
+	body, err := yaml.Marshal(layers)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(body), nil
+}
@@ -0,0 +1,595 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	godigest "github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sigstore/cosign/v2/pkg/oci/remote"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/common"
+)
+
+const (
+	layoutDirPerms  = 0o755
+	layoutFilePerms = 0o644
+)
+
+// ociLayout is a local oci image layout, named like the repository it holds.
+type ociLayout struct {
+	name string
+	path string
+}
+
+func isOCILayout(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ispec.ImageLayoutFile)); err != nil {
+		return false
+	}
+
+	_, err := os.Stat(filepath.Join(dir, ispec.ImageIndexFile))
+
+	return err == nil
+}
+
+// findOCILayouts returns the layout at the given path, or the layouts found under it, like the
+// repositories in a zot storage directory, named after their path relative to it.
+func findOCILayouts(root string) ([]ociLayout, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if isOCILayout(root) {
+		return []ociLayout{{name: filepath.Base(root), path: root}}, nil
+	}
+
+	layouts := []ociLayout{}
+
+	err = filepath.WalkDir(root, func(dir string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() || dir == root {
+			return nil
+		}
+
+		// the uploads and the other zot internal directories
+		if strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		if !isOCILayout(dir) {
+			return nil
+		}
+
+		name, err := filepath.Rel(root, dir)
+		if err != nil {
+			return err
+		}
+
+		layouts = append(layouts, ociLayout{name: filepath.ToSlash(name), path: dir})
+
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(layouts) == 0 {
+		return nil, fmt.Errorf("%w: %s", zerr.ErrOCILayoutNotFound, root)
+	}
+
+	return layouts, nil
+}
+
+func (layout ociLayout) readBlob(digest godigest.Digest) ([]byte, error) {
+	if err := digest.Validate(); err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(layout.blobPath(digest))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s in %s", zerr.ErrBlobNotFound, digest, layout.name)
+		}
+
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func (layout ociLayout) readIndex() (ispec.Index, error) {
+	var index ispec.Index
+
+	content, err := os.ReadFile(filepath.Join(layout.path, ispec.ImageIndexFile))
+	if err != nil {
+		return index, err
+	}
+
+	if err := json.Unmarshal(content, &index); err != nil {
+		return index, fmt.Errorf("invalid index of %s: %w", layout.name, err)
+	}
+
+	return index, nil
+}
+
+// listImages returns the tagged images of the layout, only the ones having all the labels if some are given.
+func (layout ociLayout) listImages(labels map[string]string) ([]imageStruct, error) {
+	index, err := layout.readIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	signed := layout.getSignedDigests(index)
+	images := []imageStruct{}
+
+	for _, descriptor := range index.Manifests {
+		tag := descriptor.Annotations[ispec.AnnotationRefName]
+
+		// untagged manifests and signatures aren't shown
+		if tag == "" || strings.HasPrefix(tag, "sha256-") && strings.HasSuffix(tag, "."+remote.SignatureTagSuffix) {
+			continue
+		}
+
+		var image *imageStruct
+
+		switch descriptor.MediaType {
+		case ispec.MediaTypeImageManifest, schema2.MediaTypeManifest:
+			image, err = layout.getManifestImage(descriptor, tag, labels, signed)
+		case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
+			image, err = layout.getIndexImage(descriptor, tag, labels, signed)
+		default:
+			continue
+		}
+
+		if err != nil {
+			if errors.Is(err, zerr.ErrImageLabelsMismatch) {
+				continue
+			}
+
+			return nil, newImageError(layout.name+":"+tag, err)
+		}
+
+		images = append(images, *image)
+	}
+
+	return images, nil
+}
+
+// getSignedDigests returns the digests of the manifests signed with cosign or notation, either with
+// a signature tag or a signature referrer.
+func (layout ociLayout) getSignedDigests(index ispec.Index) map[string]bool {
+	signed := map[string]bool{}
+
+	for _, descriptor := range index.Manifests {
+		tag := descriptor.Annotations[ispec.AnnotationRefName]
+
+		if strings.HasPrefix(tag, "sha256-") && strings.HasSuffix(tag, "."+remote.SignatureTagSuffix) {
+			signed[strings.Replace(strings.TrimSuffix(tag, "."+remote.SignatureTagSuffix), "-", ":", 1)] = true
+
+			continue
+		}
+
+		if descriptor.MediaType != ispec.MediaTypeImageManifest {
+			continue
+		}
+
+		content, err := layout.readBlob(descriptor.Digest)
+		if err != nil {
+			continue
+		}
+
+		var manifest ispec.Manifest
+
+		if err := json.Unmarshal(content, &manifest); err != nil || manifest.Subject == nil {
+			continue
+		}
+
+		artifactType := manifest.ArtifactType
+		if artifactType == "" {
+			artifactType = manifest.Config.MediaType
+		}
+
+		if artifactType == common.ArtifactTypeCosign || artifactType == common.ArtifactTypeNotation {
+			signed[manifest.Subject.Digest.String()] = true
+		}
+	}
+
+	return signed
+}
+
+func (layout ociLayout) getManifestImage(descriptor ispec.Descriptor, tag string, labels map[string]string,
+	signed map[string]bool,
+) (*imageStruct, error) {
+	manifestEntry, err := layout.getManifestEntry(descriptor.Digest, signed)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesLabels(manifestEntry.Labels, labels) {
+		return nil, zerr.ErrImageLabelsMismatch
+	}
+
+	manifest := manifestEntry.Manifest
+
+	return &imageStruct{
+		RepoName:    layout.name,
+		Tag:         tag,
+		Digest:      manifest.Digest,
+		MediaType:   descriptor.MediaType,
+		Manifests:   []common.ManifestSummary{manifest},
+		Size:        manifest.Size,
+		IsSigned:    manifest.IsSigned,
+		LastUpdated: manifest.LastUpdated,
+		Authors:     manifestEntry.Author,
+	}, nil
+}
+
+func (layout ociLayout) getIndexImage(descriptor ispec.Descriptor, tag string, labels map[string]string,
+	signed map[string]bool,
+) (*imageStruct, error) {
+	content, err := layout.readBlob(descriptor.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	var index ispec.Index
+
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, err
+	}
+
+	imageSize := int64(len(content))
+	manifestList := make([]common.ManifestSummary, 0, len(index.Manifests))
+	labelsMatch := matchesLabels(index.Annotations, labels)
+	author := index.Annotations[ispec.AnnotationAuthors]
+
+	var image imageStruct
+
+	for _, manifestDescriptor := range index.Manifests {
+		manifestEntry, err := layout.getManifestEntry(manifestDescriptor.Digest, signed)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest := manifestEntry.Manifest
+		labelsMatch = labelsMatch || matchesLabels(mergeLabels(index.Annotations, manifestEntry.Labels), labels)
+
+		if author == "" {
+			author = manifestEntry.Author
+		}
+
+		imageSize += int64(atoiWithDefault(manifest.Size, 0))
+
+		if manifest.LastUpdated.After(image.LastUpdated) {
+			image.LastUpdated = manifest.LastUpdated
+		}
+
+		if manifestDescriptor.Platform != nil {
+			manifest.Platform = common.Platform{
+				Os:      manifestDescriptor.Platform.OS,
+				Arch:    manifestDescriptor.Platform.Architecture,
+				Variant: manifestDescriptor.Platform.Variant,
+			}
+		}
+
+		manifestList = append(manifestList, manifest)
+	}
+
+	if !labelsMatch {
+		return nil, zerr.ErrImageLabelsMismatch
+	}
+
+	image.RepoName = layout.name
+	image.Tag = tag
+	image.Digest = descriptor.Digest.String()
+	image.MediaType = descriptor.MediaType
+	image.Manifests = manifestList
+	image.Size = strconv.FormatInt(imageSize, 10)
+	image.IsSigned = signed[image.Digest]
+	image.Authors = author
+
+	return &image, nil
+}
+
+// getManifestEntry reads the manifest and its config from the layout blobs, the same way
+// fetchManifestStruct gets them from the registry.
+func (layout ociLayout) getManifestEntry(digest godigest.Digest, signed map[string]bool,
+) (manifestCacheEntry, error) {
+	content, err := layout.readBlob(digest)
+	if err != nil {
+		return manifestCacheEntry{}, err
+	}
+
+	var manifest ispec.Manifest
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return manifestCacheEntry{}, err
+	}
+
+	configContent, err := layout.readBlob(manifest.Config.Digest)
+	if err != nil {
+		return manifestCacheEntry{}, err
+	}
+
+	var imageConfig ispec.Image
+
+	// artifacts may have a config which isn't an image config
+	_ = json.Unmarshal(configContent, &imageConfig)
+
+	platform := common.Platform{Os: imageConfig.OS, Arch: imageConfig.Architecture, Variant: imageConfig.Variant}
+
+	if manifest.Config.Platform != nil {
+		platform = common.Platform{
+			Os:      manifest.Config.Platform.OS,
+			Arch:    manifest.Config.Platform.Architecture,
+			Variant: manifest.Config.Platform.Variant,
+		}
+	}
+
+	imageSize := manifest.Config.Size + int64(len(content))
+	layers := []common.LayerSummary{}
+
+	for _, layer := range manifest.Layers {
+		imageSize += layer.Size
+
+		layers = append(layers, common.LayerSummary{
+			Size:   strconv.FormatInt(layer.Size, 10),
+			Digest: layer.Digest.String(),
+		})
+	}
+
+	manifestSummary := common.ManifestSummary{
+		ConfigDigest: manifest.Config.Digest.String(),
+		Digest:       digest.String(),
+		Layers:       layers,
+		Platform:     platform,
+		Size:         strconv.FormatInt(imageSize, 10),
+		IsSigned:     signed[digest.String()],
+	}
+
+	if imageConfig.Created != nil {
+		manifestSummary.LastUpdated = *imageConfig.Created
+	}
+
+	labels := mergeLabels(imageConfig.Config.Labels, manifest.Annotations)
+
+	author := labels[ispec.AnnotationAuthors]
+	if author == "" {
+		author = imageConfig.Author
+	}
+
+	return manifestCacheEntry{Manifest: manifestSummary, Labels: labels, Author: author}, nil
+}
+
+// openOCILayout returns the layout at the given path, to copy images from it or to it. A missing
+// directory is created as an empty layout if create is set.
+func openOCILayout(dir string, create bool) (ociLayout, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ociLayout{}, err
+	}
+
+	layout := ociLayout{name: filepath.Base(dir), path: dir}
+
+	if isOCILayout(dir) {
+		return layout, nil
+	}
+
+	if !create {
+		return ociLayout{}, fmt.Errorf("%w: %s", zerr.ErrOCILayoutNotFound, dir)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ispec.ImageBlobsDir), layoutDirPerms); err != nil {
+		return ociLayout{}, err
+	}
+
+	layoutFile, err := json.Marshal(ispec.ImageLayout{Version: ispec.ImageLayoutVersion})
+	if err != nil {
+		return ociLayout{}, err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ispec.ImageLayoutFile), layoutFile, layoutFilePerms); err != nil {
+		return ociLayout{}, err
+	}
+
+	index := ispec.Index{Versioned: specs.Versioned{SchemaVersion: 2}, MediaType: ispec.MediaTypeImageIndex,
+		Manifests: []ispec.Descriptor{}}
+
+	return layout, layout.writeIndex(index)
+}
+
+func (layout ociLayout) blobPath(digest godigest.Digest) string {
+	return filepath.Join(layout.path, ispec.ImageBlobsDir, digest.Algorithm().String(), digest.Encoded())
+}
+
+func (layout ociLayout) writeIndex(index ispec.Index) error {
+	content, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(layout.path, ispec.ImageIndexFile), content, layoutFilePerms)
+}
+
+// getTaggedManifests returns the descriptors of the manifests of the index.json which have a ref name.
+func (layout ociLayout) getTaggedManifests() ([]ispec.Descriptor, error) {
+	index, err := layout.readIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	tagged := []ispec.Descriptor{}
+
+	for _, descriptor := range index.Manifests {
+		if descriptor.Annotations[ispec.AnnotationRefName] != "" {
+			tagged = append(tagged, descriptor)
+		}
+	}
+
+	return tagged, nil
+}
+
+// getManifest returns the manifest with the reference as digest, or with the reference as ref name
+// in the index.json.
+func (layout ociLayout) getManifest(ctx context.Context, reference string) ([]byte, string, error) {
+	digest, err := godigest.Parse(reference)
+	if err != nil {
+		index, err := layout.readIndex()
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, descriptor := range index.Manifests {
+			if descriptor.Annotations[ispec.AnnotationRefName] == reference {
+				digest = descriptor.Digest
+			}
+		}
+
+		if digest == "" {
+			return nil, "", fmt.Errorf("%w: %s in %s", zerr.ErrManifestNotFound, reference, layout.name)
+		}
+	}
+
+	content, err := layout.readBlob(digest)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var manifest struct {
+		MediaType string `json:"mediaType"`
+	}
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, "", err
+	}
+
+	return content, manifest.MediaType, nil
+}
+
+func (layout ociLayout) getBlob(ctx context.Context, blob ispec.Descriptor) (io.ReadCloser, error) {
+	if err := blob.Digest.Validate(); err != nil {
+		return nil, err
+	}
+
+	return os.Open(layout.blobPath(blob.Digest))
+}
+
+// putBlob copies the blob from the source unless the layout already has it. The content is checked
+// against the digest before being added to the blobs.
+func (layout ociLayout) putBlob(ctx context.Context, source imageSource, blob ispec.Descriptor) error {
+	if err := blob.Digest.Validate(); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(layout.blobPath(blob.Digest)); err == nil && info.Size() == blob.Size {
+		return nil
+	}
+
+	content, err := source.getBlob(ctx, blob)
+	if err != nil {
+		return err
+	}
+
+	defer content.Close()
+
+	return layout.writeBlob(blob.Digest, content)
+}
+
+func (layout ociLayout) writeBlob(digest godigest.Digest, content io.Reader) error {
+	blobPath := layout.blobPath(digest)
+
+	if err := os.MkdirAll(filepath.Dir(blobPath), layoutDirPerms); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so the layout never has a partial blob
+	tmpFile, err := os.CreateTemp(filepath.Dir(blobPath), "blob-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	verifier := digest.Verifier()
+
+	if _, err := io.Copy(io.MultiWriter(tmpFile, verifier), content); err != nil {
+		tmpFile.Close()
+
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	if !verifier.Verified() {
+		return fmt.Errorf("%w: %s", zerr.ErrBadBlobDigest, digest)
+	}
+
+	return os.Rename(tmpFile.Name(), blobPath)
+}
+
+// putManifest adds the manifest to the blobs. With a tag as reference it's also added to the index.json
+// with the tag as ref name, replacing the manifest which had it.
+func (layout ociLayout) putManifest(ctx context.Context, reference, mediaType string, content []byte) error {
+	digest := godigest.FromBytes(content)
+
+	if err := layout.writeBlob(digest, bytes.NewReader(content)); err != nil {
+		return err
+	}
+
+	if _, err := godigest.Parse(reference); err == nil {
+		return nil
+	}
+
+	return layout.addToIndex(ispec.Descriptor{
+		MediaType:   mediaType,
+		Digest:      digest,
+		Size:        int64(len(content)),
+		Annotations: map[string]string{ispec.AnnotationRefName: reference},
+	})
+}
+
+// addToIndex adds the manifest to the index.json, unless it's already there with the same ref name.
+func (layout ociLayout) addToIndex(descriptor ispec.Descriptor) error {
+	index, err := layout.readIndex()
+	if err != nil {
+		return err
+	}
+
+	refName := descriptor.Annotations[ispec.AnnotationRefName]
+	manifests := []ispec.Descriptor{}
+
+	for _, manifest := range index.Manifests {
+		if manifest.Annotations[ispec.AnnotationRefName] == refName &&
+			(refName != "" || manifest.Digest == descriptor.Digest) {
+			continue
+		}
+
+		manifests = append(manifests, manifest)
+	}
+
+	index.Manifests = append(manifests, descriptor)
+
+	return layout.writeIndex(index)
+}
@@ -0,0 +1,350 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/distribution/registry/client/auth/challenge"
+	jsoniter "github.com/json-iterator/go"
+	distext "github.com/opencontainers/distribution-spec/specs-go/v1/extensions"
+	"gopkg.in/yaml.v2"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/constants"
+	zcommon "zotregistry.dev/zot/pkg/common"
+)
+
+const (
+	// the repo whose referrers are asked for when the catalog doesn't list any.
+	pingProbeRepo = "zli-ping"
+	// an invalid digest, registries implementing the referrers API answer 400 Bad Request for it,
+	// the others don't know the route and answer 404 Not Found.
+	pingProbeDigest = "sha256:zli-ping"
+	// the query is answered with an error about the missing reference if the CVE scanning is enabled.
+	pingCVEQuery = `{CVEListForImage(image: ""){Tag}}`
+)
+
+// pingResult is what a registry answers about itself. The latency is the one of the unauthenticated
+// request to /v2/, the extensions are the endpoints listed by the registry's discover endpoint.
+type pingResult struct {
+	URL             string   `json:"url"                       yaml:"url"`
+	Status          string   `json:"status"                    yaml:"status"`
+	Error           string   `json:"error,omitempty"           yaml:"error,omitempty"`
+	LatencyMs       float64  `json:"latencyMs"                 yaml:"latencyMs"`
+	APIVersion      string   `json:"apiVersion,omitempty"      yaml:"apiVersion,omitempty"`
+	ServerVersion   string   `json:"serverVersion,omitempty"   yaml:"serverVersion,omitempty"`
+	DistSpecVersion string   `json:"distSpecVersion,omitempty" yaml:"distSpecVersion,omitempty"`
+	AuthSchemes     []string `json:"authSchemes"               yaml:"authSchemes"`
+	User            string   `json:"user,omitempty"            yaml:"user,omitempty"`
+	Authenticated   bool     `json:"authenticated"             yaml:"authenticated"`
+	Search          bool     `json:"search"                    yaml:"search"`
+	Referrers       bool     `json:"referrers"                 yaml:"referrers"`
+	CVE             bool     `json:"cve"                       yaml:"cve"`
+	Extensions      []string `json:"extensions"                yaml:"extensions"`
+}
+
+// PingRegistry checks the registry implements the distribution API, shows how it authenticates the
+// clients and which extensions it supports. An error is returned, after showing the result, if the registry
+// can't be reached, doesn't implement the API or refuses the credentials.
+func PingRegistry(config SearchConfig) error {
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	result, pingErr := pingRegistry(ctx, config)
+
+	out, err := result.string(config.OutputFormat)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(config.ResultWriter, out)
+
+	return pingErr
+}
+
+func pingRegistry(ctx context.Context, config SearchConfig) (pingResult, error) {
+	username, password := getUsernameAndPassword(config.User)
+
+	result := pingResult{
+		URL:         config.registryURL(),
+		Status:      StatusOffline,
+		User:        username,
+		AuthSchemes: []string{},
+		Extensions:  []string{},
+	}
+
+	versionEndpoint, err := combineServerAndEndpointURL(config.ServURL, constants.RoutePrefix+"/")
+	if err != nil {
+		return result, err
+	}
+
+	// the probes say what the registry supports, they aren't retried to hide a failure
+	config.Retries = 0
+
+	resp, latency, err := pingVersionEndpoint(ctx, versionEndpoint, config)
+	if err != nil {
+		result.Error = err.Error()
+
+		return result, err
+	}
+
+	result.LatencyMs = float64(latency) / float64(time.Millisecond)
+	result.APIVersion = resp.Header.Get(constants.DistAPIVersion)
+	result.AuthSchemes = getAuthSchemes(resp)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		err := fmt.Errorf("%w: %s answered %d", zerr.ErrAPINotSupported, versionEndpoint, resp.StatusCode)
+		result.Error = err.Error()
+
+		return result, err
+	}
+
+	result.Status = StatusOnline
+
+	switch {
+	case username != "" || password != "":
+		// the credentials are checked even if the registry allows anonymous requests
+		if _, err := makeGETRequest(ctx, versionEndpoint, username, password, config, nil); err != nil {
+			if errors.Is(err, zerr.ErrUnauthorizedAccess) {
+				result.Error = getCredentialsSuggestion(username)
+			} else {
+				result.Error = err.Error()
+			}
+
+			return result, err
+		}
+	case resp.StatusCode == http.StatusUnauthorized:
+		result.Error = getCredentialsSuggestion(username)
+
+		return result, zerr.ErrUnauthorizedAccess
+	}
+
+	result.Authenticated = true
+
+	pingExtensions(ctx, config, &result)
+
+	return result, nil
+}
+
+// pingVersionEndpoint sends the unauthenticated request to /v2/ and measures its latency.
+func pingVersionEndpoint(ctx context.Context, endpoint string, config SearchConfig,
+) (*http.Response, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	httpClient, err := getHTTPClient(req.Host, config)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := time.Now()
+
+	resp, err := doWithRetries(httpClient, req, config)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	latency := time.Since(start)
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return resp, latency, nil
+}
+
+// getAuthSchemes returns the challenges of the registry, e.g. `bearer realm="https://auth.example.com"`.
+func getAuthSchemes(resp *http.Response) []string {
+	schemes := []string{}
+
+	for _, authChallenge := range challenge.ResponseChallenges(resp) {
+		params := make([]string, 0, len(authChallenge.Parameters))
+
+		for name, value := range authChallenge.Parameters {
+			params = append(params, fmt.Sprintf("%s=%q", name, value))
+		}
+
+		sort.Strings(params)
+
+		schemes = append(schemes, strings.TrimSpace(authChallenge.Scheme+" "+strings.Join(params, ",")))
+	}
+
+	return schemes
+}
+
+// pingExtensions fills in the extensions of the result. A failed probe only means the extension isn't
+// available to the user.
+func pingExtensions(ctx context.Context, config SearchConfig, result *pingResult) {
+	username, password := getUsernameAndPassword(config.User)
+
+	discoverEndpoint, _ := combineServerAndEndpointURL(config.ServURL,
+		constants.RoutePrefix+constants.ExtOciDiscoverPrefix)
+
+	extensions := distext.ExtensionList{}
+
+	if _, err := makeGETRequest(ctx, discoverEndpoint, username, password, config, &extensions); err == nil {
+		for _, extension := range extensions.Extensions {
+			result.Extensions = append(result.Extensions, extension.Endpoints...)
+		}
+	}
+
+	result.Search = slices.Contains(result.Extensions, constants.FullSearchPrefix)
+	result.Referrers = pingReferrers(ctx, config)
+
+	if slices.Contains(result.Extensions, constants.FullMgmt) {
+		mgmtEndpoint, _ := combineServerAndEndpointURL(config.ServURL, constants.FullMgmt)
+
+		serverInfo := ServerInfo{}
+
+		if _, err := makeGETRequest(ctx, mgmtEndpoint, username, password, config, &serverInfo); err == nil {
+			result.ServerVersion = serverInfo.ReleaseTag
+			result.DistSpecVersion = serverInfo.DistSpecVersion
+		}
+	}
+
+	if result.Search {
+		searchEndpoint, _ := combineServerAndEndpointURL(config.ServURL, constants.FullSearchPrefix)
+
+		response := struct {
+			Errors []zcommon.ErrorGQL `json:"errors"`
+		}{}
+
+		err := makeGraphQLRequest(ctx, searchEndpoint, pingCVEQuery, username, password, config, &response)
+
+		result.CVE = err == nil && !slices.ContainsFunc(response.Errors, func(gqlErr zcommon.ErrorGQL) bool {
+			return strings.Contains(gqlErr.Message, zerr.ErrCVESearchDisabled.Error())
+		})
+	}
+}
+
+// pingReferrers asks for the referrers of an invalid digest, in a repo the user can read if there's one.
+func pingReferrers(ctx context.Context, config SearchConfig) bool {
+	username, password := getUsernameAndPassword(config.User)
+
+	repo := pingProbeRepo
+
+	catalogEndpoint, _ := combineServerAndEndpointURL(config.ServURL, constants.RoutePrefix+constants.ExtCatalogPrefix)
+
+	catalog := catalogResponse{}
+
+	if _, err := makeGETRequest(ctx, catalogEndpoint+"?n=1", username, password, config, &catalog); err == nil &&
+		len(catalog.Repositories) > 0 {
+		repo = catalog.Repositories[0]
+	}
+
+	referrersEndpoint, _ := combineServerAndEndpointURL(config.ServURL,
+		fmt.Sprintf("%s/%s/referrers/%s", constants.RoutePrefix, repo, pingProbeDigest))
+
+	_, err := makeGETRequest(ctx, referrersEndpoint, username, password, config, nil)
+	if err == nil {
+		return true
+	}
+
+	var statusErr *httpStatusError
+
+	return errors.As(err, &statusErr) && statusErr.statusCode == http.StatusBadRequest
+}
+
+func (result pingResult) string(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return result.stringPlainText()
+	case jsonFormat:
+		return result.stringJSON()
+	case ymlFormat, yamlFormat:
+		return result.stringYAML()
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (result pingResult) stringPlainText() (string, error) {
+	var builder strings.Builder
+
+	writer := tabwriter.NewWriter(&builder, 0, 8, 2, ' ', 0) //nolint:gomnd
+
+	yesNo := func(value bool) string {
+		if value {
+			return "yes"
+		}
+
+		return "no"
+	}
+
+	orNone := func(values []string) string {
+		if len(values) == 0 {
+			return "none"
+		}
+
+		return strings.Join(values, ", ")
+	}
+
+	fmt.Fprintf(writer, "URL:\t%s\n", result.URL)
+	fmt.Fprintf(writer, "Status:\t%s\n", result.Status)
+
+	if result.Error != "" {
+		fmt.Fprintf(writer, "Error:\t%s\n", result.Error)
+	}
+
+	if result.Status == StatusOnline {
+		fmt.Fprintf(writer, "Latency:\t%.1fms\n", result.LatencyMs)
+		fmt.Fprintf(writer, "API version:\t%s\n", result.APIVersion)
+
+		if result.ServerVersion != "" || result.DistSpecVersion != "" {
+			fmt.Fprintf(writer, "Server version:\t%s (dist-spec %s)\n", result.ServerVersion, result.DistSpecVersion)
+		}
+
+		fmt.Fprintf(writer, "Auth:\t%s\n", orNone(result.AuthSchemes))
+
+		if result.User != "" {
+			fmt.Fprintf(writer, "User:\t%s\n", result.User)
+		}
+
+		fmt.Fprintf(writer, "Authenticated:\t%s\n", yesNo(result.Authenticated))
+	}
+
+	if result.Authenticated {
+		fmt.Fprintf(writer, "Search:\t%s\n", yesNo(result.Search))
+		fmt.Fprintf(writer, "Referrers:\t%s\n", yesNo(result.Referrers))
+		fmt.Fprintf(writer, "CVE scanning:\t%s\n", yesNo(result.CVE))
+		fmt.Fprintf(writer, "Extensions:\t%s\n", orNone(result.Extensions))
+	}
+
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+func (result pingResult) stringJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+func (result pingResult) stringYAML() (string, error) {
+	body, err := yaml.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(body), nil
+}
@@ -0,0 +1,51 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewPingCommand() *cobra.Command {
+	pingCmd := &cobra.Command{
+		Use:   "ping",
+		Short: "Check the registry is reachable and show what it supports",
+		Long: `Check the registry answers on /v2/ and show its latency, the API version it reports, how it
+authenticates the clients, whether the given credentials are accepted and which extensions are available:
+search, referrers, CVE scanning and the other endpoints listed by the registry.
+The command fails if the registry can't be reached, doesn't implement the distribution API or refuses the
+credentials, so it can be used in scripts, e.g. zli ping --url https://registry -f json | jq .cve`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, NewSearchService())
+			if err != nil {
+				return err
+			}
+
+			return PingRegistry(searchConfig)
+		},
+	}
+
+	pingCmd.Flags().String(URLFlag, "",
+		"Specify zot server URL if config-name is not mentioned")
+	pingCmd.Flags().String(ConfigFlag, "",
+		"Specify the registry configuration to use for connection")
+	_ = pingCmd.RegisterFlagCompletionFunc(ConfigFlag, completeConfigNames)
+	pingCmd.Flags().StringP(UserFlag, "u", "",
+		`User Credentials of zot server in "username:password" format`)
+	pingCmd.Flags().StringP(OutputFormatFlag, "f", "", "Specify output format [text/json/yaml]")
+	pingCmd.Flags().Bool(DebugFlag, false,
+		"Show every request made to the registry on stderr, with its status, latency and request id")
+	pingCmd.Flags().Bool(DebugBodyFlag, false, "With --debug, also show the headers and the textual bodies")
+	pingCmd.Flags().Duration(TimeoutFlag, 0,
+		"Maximum time the whole check can take, 0 means no limit")
+	pingCmd.Flags().String(CertFlag, "", "Client certificate file to present to the server")
+	pingCmd.Flags().String(KeyFlag, "", "Key file of the client certificate")
+	pingCmd.Flags().String(CACertFlag, "",
+		"CA certificate file used to verify the server, in addition to the system ones")
+	pingCmd.Flags().String(ProxyFlag, "",
+		"Proxy the server is reached through, an http, https or socks5 url [default: HTTPS_PROXY/NO_PROXY]")
+
+	return pingCmd
+}
@@ -0,0 +1,244 @@
+//go:build search
+// +build search
+
+package client_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/cli/client"
+	extconf "zotregistry.dev/zot/pkg/extensions/config"
+	test "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+type pingResult struct {
+	Status        string   `json:"status"`
+	Error         string   `json:"error"`
+	LatencyMs     float64  `json:"latencyMs"`
+	APIVersion    string   `json:"apiVersion"`
+	AuthSchemes   []string `json:"authSchemes"`
+	Authenticated bool     `json:"authenticated"`
+	Search        bool     `json:"search"`
+	Referrers     bool     `json:"referrers"`
+	CVE           bool     `json:"cve"`
+	Extensions    []string `json:"extensions"`
+}
+
+func runPingCommand(args ...string) (string, error) {
+	cmd := client.NewPingCommand()
+	buff := bytes.NewBufferString("")
+	cmd.SetOut(buff)
+	cmd.SetErr(buff)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+
+	return buff.String(), err
+}
+
+func TestPingCommand(t *testing.T) {
+	space := regexp.MustCompile(`\s+`)
+
+	Convey("Ping a registry without authentication", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		defaultVal := true
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}},
+		}
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+
+		cm.StartAndWait(conf.HTTP.Port)
+		defer cm.StopServer()
+
+		err := UploadImage(CreateRandomImage(), baseURL, "repo", "1.0")
+		So(err, ShouldBeNil)
+
+		output, err := runPingCommand("--url", baseURL)
+		So(err, ShouldBeNil)
+
+		actual := space.ReplaceAllString(output, " ")
+		So(actual, ShouldContainSubstring, "Status: online")
+		So(actual, ShouldContainSubstring, "API version: registry/2.0")
+		So(actual, ShouldContainSubstring, "Auth: none")
+		So(actual, ShouldContainSubstring, "Authenticated: yes")
+		So(actual, ShouldContainSubstring, "Search: yes")
+		So(actual, ShouldContainSubstring, "Referrers: yes")
+		// the CVE scanning isn't configured
+		So(actual, ShouldContainSubstring, "CVE scanning: no")
+		So(actual, ShouldContainSubstring, "/v2/_zot/ext/search")
+
+		output, err = runPingCommand("--url", baseURL, "-f", "json")
+		So(err, ShouldBeNil)
+
+		result := pingResult{}
+		So(json.Unmarshal([]byte(output), &result), ShouldBeNil)
+		So(result.Status, ShouldEqual, client.StatusOnline)
+		So(result.LatencyMs, ShouldBeGreaterThan, 0)
+		So(result.AuthSchemes, ShouldBeEmpty)
+		So(result.Search, ShouldBeTrue)
+		So(result.Referrers, ShouldBeTrue)
+		So(result.CVE, ShouldBeFalse)
+
+		output, err = runPingCommand("--url", baseURL, "-f", "yaml")
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "search: true")
+
+		_, err = runPingCommand("--url", baseURL, "-f", "csv")
+		So(err, ShouldEqual, zerr.ErrInvalidOutputFormat)
+	})
+
+	Convey("Ping a registry scanning the images for CVEs", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		defaultVal := true
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+				CVE: &extconf.CVEConfig{
+					UpdateInterval: 2,
+					Trivy:          &extconf.TrivyConfig{DBRepository: "ghcr.io/project-zot/trivy-db"},
+				},
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		err := ctlr.Init()
+		So(err, ShouldBeNil)
+
+		ctlr.CveScanner = getMockCveScanner(ctlr.MetaDB)
+
+		go func() {
+			if err := ctlr.Run(); !errors.Is(err, http.ErrServerClosed) {
+				panic(err)
+			}
+		}()
+
+		defer ctlr.Shutdown()
+
+		test.WaitTillServerReady(baseURL)
+
+		output, err := runPingCommand("--url", baseURL, "-f", "json")
+		So(err, ShouldBeNil)
+
+		result := pingResult{}
+		So(json.Unmarshal([]byte(output), &result), ShouldBeNil)
+		So(result.Search, ShouldBeTrue)
+		So(result.CVE, ShouldBeTrue)
+	})
+
+	Convey("Ping a registry with authentication", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		username, _ := test.GenerateRandomString()
+		password, _ := test.GenerateRandomString()
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString(username, password))
+		defer os.Remove(htpasswdPath)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Realm = "zot"
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{Path: htpasswdPath},
+		}
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+
+		cm.StartAndWait(conf.HTTP.Port)
+		defer cm.StopServer()
+
+		output, err := runPingCommand("--url", baseURL)
+		So(errors.Is(err, zerr.ErrUnauthorizedAccess), ShouldBeTrue)
+
+		actual := space.ReplaceAllString(output, " ")
+		So(actual, ShouldContainSubstring, "Status: online")
+		So(actual, ShouldContainSubstring, `Auth: basic realm="zot"`)
+		So(actual, ShouldContainSubstring, "Authenticated: no")
+		So(actual, ShouldContainSubstring, "--user")
+		So(actual, ShouldNotContainSubstring, "Search:")
+
+		output, err = runPingCommand("--url", baseURL, "-u", username+":wrong")
+		So(errors.Is(err, zerr.ErrUnauthorizedAccess), ShouldBeTrue)
+		So(output, ShouldContainSubstring, "given credentials are invalid")
+
+		output, err = runPingCommand("--url", baseURL, "-u", fmt.Sprintf("%s:%s", username, password), "-f", "json")
+		So(err, ShouldBeNil)
+
+		result := pingResult{}
+		So(json.Unmarshal([]byte(output), &result), ShouldBeNil)
+		So(result.Authenticated, ShouldBeTrue)
+		So(result.AuthSchemes, ShouldResemble, []string{`basic realm="zot"`})
+		// the catalog is empty, the referrers are asked for in a repo which doesn't exist
+		So(result.Referrers, ShouldBeTrue)
+		So(result.Search, ShouldBeFalse)
+	})
+
+	Convey("Ping a registry which can't be reached", t, func() {
+		baseURL := test.GetBaseURL(test.GetFreePort())
+
+		output, err := runPingCommand("--url", baseURL)
+		So(err, ShouldNotBeNil)
+		So(space.ReplaceAllString(output, " "), ShouldContainSubstring, "Status: offline")
+		So(output, ShouldNotContainSubstring, "Latency")
+	})
+
+	Convey("Ping a server which isn't a registry", t, func() {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		output, err := runPingCommand("--url", server.URL)
+		So(errors.Is(err, zerr.ErrAPINotSupported), ShouldBeTrue)
+		So(space.ReplaceAllString(output, " "), ShouldContainSubstring, "Status: offline")
+	})
+
+	Convey("Ping a registry without extensions nor referrers", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v2/" {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		output, err := runPingCommand("--url", server.URL)
+		So(err, ShouldBeNil)
+
+		actual := space.ReplaceAllString(output, " ")
+		So(actual, ShouldContainSubstring, "Status: online")
+		So(actual, ShouldContainSubstring, "Search: no")
+		So(actual, ShouldContainSubstring, "Referrers: no")
+		So(actual, ShouldContainSubstring, "Extensions: none")
+		So(output, ShouldNotContainSubstring, "Server version")
+	})
+}
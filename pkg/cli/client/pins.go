@@ -0,0 +1,61 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+const pinFilePerms = 0o644
+
+// imagePins maps the images, as 'repo:tag', to the digests they're pinned to. The keys are sorted when
+// written, so the pin files of two runs can be diffed.
+type imagePins map[string]string
+
+func getImagePins(images []imageStruct) imagePins {
+	pins := imagePins{}
+
+	for _, image := range images {
+		if image.Tag == "" {
+			continue
+		}
+
+		pins[image.RepoName+":"+image.Tag] = image.Digest
+	}
+
+	return pins
+}
+
+func (pins imagePins) write(path string) error {
+	body, err := yaml.Marshal(pins)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, body, pinFilePerms)
+}
+
+// PinImages runs the listing, writes the digests of the images found to the pin file, then shows the images
+// like without --pin-output. Nothing is written if some of the images couldn't be listed.
+func PinImages(ctx context.Context, config SearchConfig, pinOutput string,
+	listImages func(config SearchConfig) error,
+) error {
+	images, err := readImageListing(config, listImages)
+	if err != nil {
+		return err
+	}
+
+	if err := getImagePins(images).write(pinOutput); err != nil {
+		return err
+	}
+
+	// the signatures were already verified by the listing
+	printConfig := config
+	printConfig.VerifySignature = false
+
+	return printImageResult(ctx, printConfig, images)
+}
@@ -0,0 +1,142 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	progressTTYInterval = 200 * time.Millisecond
+	progressLogInterval = 5 * time.Second
+)
+
+const (
+	progressIdle int32 = iota
+	progressRunning
+	progressStopped
+)
+
+// progressReporter counts what the requests pool discovers and fetches, and periodically shows the counts,
+// on a single refreshed line if the writer is a terminal, else as log lines.
+// A nil reporter is valid and doesn't count or show anything.
+type progressReporter struct {
+	writer   io.Writer
+	isTTY    bool
+	interval time.Duration
+
+	repos     atomic.Int64
+	tags      atomic.Int64
+	manifests atomic.Int64
+	errors    atomic.Int64
+
+	state   atomic.Int32
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newProgressReporter(writer io.Writer) *progressReporter {
+	isTTY := false
+
+	if file, ok := writer.(*os.File); ok {
+		isTTY = term.IsTerminal(int(file.Fd()))
+	}
+
+	interval := progressLogInterval
+	if isTTY {
+		interval = progressTTYInterval
+	}
+
+	return &progressReporter{
+		writer:   writer,
+		isTTY:    isTTY,
+		interval: interval,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+func (progress *progressReporter) addRepos(count int) {
+	if progress != nil {
+		progress.repos.Add(int64(count))
+	}
+}
+
+func (progress *progressReporter) addTags(count int) {
+	if progress != nil {
+		progress.tags.Add(int64(count))
+	}
+}
+
+func (progress *progressReporter) addManifests(count int) {
+	if progress != nil {
+		progress.manifests.Add(int64(count))
+	}
+}
+
+func (progress *progressReporter) addErrors(count int) {
+	if progress != nil {
+		progress.errors.Add(int64(count))
+	}
+}
+
+func (progress *progressReporter) String() string {
+	return fmt.Sprintf("repos: %d, tags: %d, manifests fetched: %d, errors: %d",
+		progress.repos.Load(), progress.tags.Load(), progress.manifests.Load(), progress.errors.Load())
+}
+
+func (progress *progressReporter) show() {
+	if progress.isTTY {
+		// go back to the start of the line and clear it
+		fmt.Fprint(progress.writer, "\r\033[K"+progress.String())
+
+		return
+	}
+
+	fmt.Fprintln(progress.writer, "[progress] "+progress.String())
+}
+
+func (progress *progressReporter) start() {
+	if progress == nil || !progress.state.CompareAndSwap(progressIdle, progressRunning) {
+		return
+	}
+
+	go func() {
+		defer close(progress.stopped)
+
+		ticker := time.NewTicker(progress.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				progress.show()
+			case <-progress.done:
+				return
+			}
+		}
+	}()
+}
+
+// stop shows the final counts, it does nothing if the reporter isn't running.
+func (progress *progressReporter) stop() {
+	if progress == nil || !progress.state.CompareAndSwap(progressRunning, progressStopped) {
+		return
+	}
+
+	close(progress.done)
+	<-progress.stopped
+
+	progress.show()
+
+	if progress.isTTY {
+		fmt.Fprintln(progress.writer)
+	}
+}
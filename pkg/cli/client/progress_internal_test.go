@@ -0,0 +1,190 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	test "zotregistry.dev/zot/pkg/test/common"
+)
+
+type syncBuffer struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (buf *syncBuffer) Write(p []byte) (int, error) {
+	buf.lock.Lock()
+	defer buf.lock.Unlock()
+
+	return buf.buffer.Write(p)
+}
+
+func (buf *syncBuffer) String() string {
+	buf.lock.Lock()
+	defer buf.lock.Unlock()
+
+	return buf.buffer.String()
+}
+
+func TestProgressReporter(t *testing.T) {
+	Convey("Progress reporter", t, func() {
+		output := &syncBuffer{}
+
+		progress := newProgressReporter(output)
+		So(progress.isTTY, ShouldBeFalse)
+		So(progress.interval, ShouldEqual, progressLogInterval)
+
+		progress.interval = 10 * time.Millisecond
+
+		progress.addRepos(2)
+		progress.addTags(5)
+		progress.addManifests(4)
+		progress.addErrors(1)
+		So(progress.String(), ShouldEqual, "repos: 2, tags: 5, manifests fetched: 4, errors: 1")
+
+		// stopping a reporter which didn't start shows nothing
+		progress.stop()
+		So(output.String(), ShouldBeEmpty)
+
+		progress.start()
+		progress.start()
+		time.Sleep(50 * time.Millisecond)
+		progress.stop()
+		progress.stop()
+
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		So(len(lines), ShouldBeGreaterThan, 1)
+
+		for _, line := range lines {
+			So(line, ShouldEqual, "[progress] repos: 2, tags: 5, manifests fetched: 4, errors: 1")
+		}
+
+		// it can't be restarted
+		progress.start()
+		So(progress.state.Load(), ShouldEqual, progressStopped)
+
+		Convey("terminal output refreshes the same line", func() {
+			output := &syncBuffer{}
+
+			progress := newProgressReporter(output)
+			progress.isTTY = true
+			progress.addRepos(1)
+
+			progress.start()
+			progress.stop()
+
+			So(output.String(), ShouldEqual, "\r\033[Krepos: 1, tags: 0, manifests fetched: 0, errors: 0\n")
+		})
+	})
+
+	Convey("Nil progress reporter", t, func() {
+		var progress *progressReporter
+
+		So(func() {
+			progress.addRepos(1)
+			progress.addTags(1)
+			progress.addManifests(1)
+			progress.addErrors(1)
+			progress.start()
+			progress.stop()
+		}, ShouldNotPanic)
+	})
+
+	Convey("Progress of an image listing", t, func() {
+		configContent := []byte(`{"architecture": "amd64", "os": "linux"}`)
+		configDigest := godigest.FromBytes(configContent)
+		manifestContent := []byte(`{"schemaVersion": 2, "mediaType": "` + ispec.MediaTypeImageManifest + `",
+			"config": {"digest": "` + configDigest.String() + `", "size": ` + strconv.Itoa(len(configContent)) + `},
+			"layers": []}`)
+
+		port := test.GetFreePort()
+		server := StartTestHTTPServer(HTTPRoutes{
+			{
+				Route: "/v2/_catalog",
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write([]byte(`{"repositories": ["repo", "missing"]}`))
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+			{
+				Route: "/v2/{name}/tags/list",
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					if mux.Vars(r)["name"] != "repo" {
+						w.WriteHeader(http.StatusNotFound)
+
+						return
+					}
+
+					_, _ = w.Write([]byte(`{"name": "repo", "tags": ["1.0", "2.0"]}`))
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+			{
+				Route: "/v2/{name}/manifests/{reference}",
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					if strings.HasSuffix(mux.Vars(r)["reference"], ".sig") {
+						w.WriteHeader(http.StatusNotFound)
+
+						return
+					}
+
+					w.Header().Set("Content-Type", ispec.MediaTypeImageManifest)
+					w.Header().Set("Docker-Content-Digest", godigest.FromBytes(manifestContent).String())
+					w.Header().Set("Content-Length", strconv.Itoa(len(manifestContent)))
+
+					if r.Method == http.MethodGet {
+						_, _ = w.Write(manifestContent)
+					}
+				},
+				AllowedMethods: []string{http.MethodGet, http.MethodHead},
+			},
+			{
+				Route: "/v2/{name}/blobs/{digest}",
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write(configContent)
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+		}, port)
+		defer server.Close()
+
+		searchConf := getDefaultSearchConf(test.GetBaseURL(port))
+		searchConf.RequestsPerSecond = 1000
+		searchConf.Progress = newProgressReporter(&syncBuffer{})
+
+		var wtgrp sync.WaitGroup
+
+		rch := make(chan stringResult)
+
+		wtgrp.Add(1)
+
+		go getCatalogImages(context.Background(), searchConf, "", "", nil, rch, &wtgrp)
+
+		errorCount := 0
+
+		for result := range rch {
+			if result.Err != nil {
+				errorCount++
+			}
+		}
+
+		wtgrp.Wait()
+
+		So(errorCount, ShouldEqual, 1)
+		So(searchConf.Progress.String(), ShouldEqual, "repos: 2, tags: 2, manifests fetched: 2, errors: 1")
+	})
+}
@@ -0,0 +1,279 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	zerr "zotregistry.dev/zot/errors"
+)
+
+// commands listing images from several registries at once are annotated with this key.
+const multiRegistryAnnotation = "multi-registry"
+
+// registry is one of the registries a search runs against, the results are prefixed with its name.
+type registry struct {
+	name  string
+	url   string
+	user  string
+	proxy string
+}
+
+type searchFn func(ctx context.Context, config SearchConfig, username, password string,
+	rch chan stringResult, wtgrp *sync.WaitGroup)
+
+// getRegistries returns the registries given with several --url values, or several comma separated
+// --config names. It returns nothing if a single registry is given.
+func getRegistries(cmd *cobra.Command, user string) ([]registry, error) {
+	registries := []registry{}
+
+	for _, serverURL := range getServerURLs(cmd) {
+		if err := validateURL(serverURL); err != nil {
+			return nil, err
+		}
+
+		registryUser, err := resolveUser(user, serverURL)
+		if err != nil {
+			return nil, err
+		}
+
+		registryProxy, err := getRegistryProxy(cmd, "")
+		if err != nil {
+			return nil, err
+		}
+
+		parsedURL, _ := url.Parse(serverURL)
+
+		name := parsedURL.Host
+		if parsedURL.Scheme == unixSocketScheme {
+			name = path.Base(parsedURL.Path)
+		}
+
+		registries = append(registries, registry{name: name, url: serverURL, user: registryUser,
+			proxy: registryProxy})
+	}
+
+	if len(registries) == 0 {
+		for _, configName := range getFlagConfigNames(cmd) {
+			serverURL, err := ReadServerURLFromConfig(configName)
+			if err != nil {
+				return nil, fmt.Errorf("reading url from config failed: %w", err)
+			}
+
+			if err := validateURL(serverURL); err != nil {
+				return nil, err
+			}
+
+			registryUser, err := getConfigUser(user, configName, serverURL)
+			if err != nil {
+				return nil, err
+			}
+
+			registryProxy, err := getRegistryProxy(cmd, configName)
+			if err != nil {
+				return nil, err
+			}
+
+			registries = append(registries, registry{name: configName, url: serverURL, user: registryUser,
+				proxy: registryProxy})
+		}
+	}
+
+	// the same registry given twice is searched once
+	seen := map[string]bool{}
+	uniqueRegistries := []registry{}
+
+	for _, reg := range registries {
+		if !seen[reg.url] {
+			seen[reg.url] = true
+			uniqueRegistries = append(uniqueRegistries, reg)
+		}
+	}
+
+	registries = uniqueRegistries
+
+	if len(registries) < 2 { //nolint:gomnd
+		return nil, nil
+	}
+
+	if _, ok := cmd.Annotations[multiRegistryAnnotation]; !ok {
+		return nil, fmt.Errorf("%w: '%s' works with a single registry", zerr.ErrInvalidCLIParameter, cmd.CommandPath())
+	}
+
+	return registries, nil
+}
+
+// getServerURLs returns the --url values, the flag can be a list or a single url depending on the command.
+func getServerURLs(cmd *cobra.Command) []string {
+	flags := cmd.Flags()
+
+	if serverURLs, err := flags.GetStringSlice(URLFlag); err == nil {
+		return serverURLs
+	}
+
+	if serverURL := defaultIfError(flags.GetString(URLFlag)); serverURL != "" {
+		return []string{serverURL}
+	}
+
+	return nil
+}
+
+// getFlagConfigNames returns the comma separated --config names, or the default config
+// if neither --config nor --url are given.
+func getFlagConfigNames(cmd *cobra.Command) []string {
+	configNames, err := cmd.Flags().GetString(ConfigFlag)
+	if err != nil {
+		return nil
+	}
+
+	if configNames == "" && len(getServerURLs(cmd)) == 0 {
+		configNames = getDefaultConfigName()
+	}
+
+	if configNames == "" {
+		return nil
+	}
+
+	return strings.Split(configNames, ",")
+}
+
+func getDefaultConfigName() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return defaultIfError(getDefaultConfig(path.Join(home, "/.zot")))
+}
+
+// getConfigName returns the config used for the options, the first one if several registries are given.
+func getConfigName(cmd *cobra.Command) string {
+	configNames := getFlagConfigNames(cmd)
+	if len(configNames) == 0 {
+		return ""
+	}
+
+	return configNames[0]
+}
+
+// searchRegistries runs the search against all the registries at once. A registry failing doesn't stop
+// the search on the others, the errors are reported together at the end.
+func searchRegistries(config SearchConfig, search searchFn, printHeader printHeader) error {
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	results := make(chan stringResult)
+	registryErrors := make([]error, len(config.Registries))
+
+	var forwardWg sync.WaitGroup
+
+	for i, registry := range config.Registries {
+		registryConfig := config
+		registryConfig.ServURL, registryConfig.Socket = splitUnixSocketURL(registry.url)
+		registryConfig.User = registry.user
+		registryConfig.Proxy = registry.proxy
+		registryConfig.RegistryName = registry.name
+		registryConfig.Registries = nil
+
+		username, password := getUsernameAndPassword(registry.user)
+		registryCtx, registryCancel := context.WithCancel(ctx)
+		registryResults := make(chan stringResult)
+
+		var searchWg sync.WaitGroup
+
+		searchWg.Add(1)
+
+		go search(registryCtx, registryConfig, username, password, registryResults, &searchWg)
+
+		forwardWg.Add(1)
+
+		go func(index int, registryName string) {
+			defer forwardWg.Done()
+			defer registryCancel()
+
+			// read everything until the search closes the channel, so it doesn't block after an error
+			for result := range registryResults {
+				if registryErrors[index] != nil {
+					continue
+				}
+
+				// a repo missing from some of the registries isn't an error
+				if result.Err != nil && strings.Contains(result.Err.Error(), "NAME_UNKNOWN") {
+					continue
+				}
+
+				var failedImage *imageError
+
+				// the images which couldn't be fetched are summarized with the others
+				isImageError := errors.As(result.Err, &failedImage) && !config.FailFast
+
+				if result.Err != nil && !isImageError {
+					registryErrors[index] = fmt.Errorf("%s: %w", registryName, result.Err)
+
+					registryCancel()
+
+					continue
+				}
+
+				select {
+				case results <- result:
+				case <-ctx.Done():
+				}
+			}
+		}(i, registry.name)
+	}
+
+	go func() {
+		forwardWg.Wait()
+		close(results)
+	}()
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	errCh := make(chan error, 1)
+
+	go collectResults(ctx, config, &wg, results, cancel, printHeader, errCh)
+	wg.Wait()
+
+	select {
+	case err := <-errCh:
+		return err
+	default:
+	}
+
+	messages := []string{}
+
+	for _, err := range registryErrors {
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+
+	if len(messages) > 0 {
+		return fmt.Errorf("%w: %s", zerr.ErrRegistrySearchFailed, strings.Join(messages, "; "))
+	}
+
+	return nil
+}
+
+// getRegistryRepoName prefixes the repo with the name of the registry it was found on,
+// when searching several registries.
+func getRegistryRepoName(config SearchConfig, repo string) string {
+	if config.RegistryName == "" {
+		return repo
+	}
+
+	return config.RegistryName + "/" + repo
+}
@@ -23,11 +23,34 @@ func NewRepoCommand(searchService SearchService) *cobra.Command {
 		"Specify zot server URL if config-name is not mentioned")
 	repoCmd.PersistentFlags().String(ConfigFlag, "",
 		"Specify the registry configuration to use for connection")
+	_ = repoCmd.RegisterFlagCompletionFunc(ConfigFlag, completeConfigNames)
 	repoCmd.PersistentFlags().StringP(UserFlag, "u", "",
 		`User Credentials of zot server in "username:password" format`)
-	repoCmd.PersistentFlags().Bool(DebugFlag, false, "Show debug output")
+	repoCmd.PersistentFlags().Bool(DebugFlag, false,
+		"Show every request made to the registry on stderr, with its status, latency and request id")
+	repoCmd.PersistentFlags().Bool(DebugBodyFlag, false, "With --debug, also show the headers and the textual bodies")
+	repoCmd.PersistentFlags().Int(PageSizeFlag, 0,
+		"Number of entries to request per page when listing the catalog and the tags, 0 lets the registry decide")
+	repoCmd.PersistentFlags().Duration(TimeoutFlag, 0,
+		"Maximum time the whole search can take, the results received until then are still shown, 0 means no limit")
+	repoCmd.PersistentFlags().Int(RetriesFlag, defaultRetries,
+		"Number of times a request is retried when the registry is busy or the connection fails")
+	repoCmd.PersistentFlags().Duration(RetryMaxWaitFlag, defaultRetryMaxWait,
+		"Maximum time to wait between two retries of a request")
+	repoCmd.PersistentFlags().String(CertFlag, "", "Client certificate file to present to the server")
+	repoCmd.PersistentFlags().String(KeyFlag, "", "Key file of the client certificate")
+	repoCmd.PersistentFlags().String(CACertFlag, "",
+		"CA certificate file used to verify the server, in addition to the system ones")
+	repoCmd.PersistentFlags().String(ProxyFlag, "",
+		"Proxy the server is reached through, an http, https or socks5 url [default: HTTPS_PROXY/NO_PROXY]")
 
 	repoCmd.AddCommand(NewListReposCommand(searchService))
+	repoCmd.AddCommand(NewRepoStatsCommand(searchService))
+	repoCmd.AddCommand(NewRepoActivityCommand(searchService))
+	repoCmd.AddCommand(NewRepoStarCommand(searchService))
+	repoCmd.AddCommand(NewRepoUnstarCommand(searchService))
+	repoCmd.AddCommand(NewRepoBookmarkCommand(searchService))
+	repoCmd.AddCommand(NewRepoUnbookmarkCommand(searchService))
 
 	return repoCmd
 }
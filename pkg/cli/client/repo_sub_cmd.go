@@ -9,26 +9,146 @@ import (
 	"github.com/spf13/cobra"
 )
 
+func NewRepoStatsCommand(searchService SearchService) *cobra.Command {
+	repoListSortFlag := RepoListSortFlag(SortByAlphabeticAsc)
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "List all repositories with their number of tags, size and last update time",
+		Long:  "List all repositories with their number of tags, size and last update time",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return SearchRepoStats(searchConfig)
+		},
+	}
+
+	cmd.Flags().Var(&repoListSortFlag, SortByFlag,
+		fmt.Sprintf("Options for sorting the output: [%s]", RepoListSortOptionsStr()))
+	cmd.Flags().StringP(OutputFormatFlag, "f", "", "Specify output format [text/json/ndjson/yaml]")
+	cmd.Flags().Bool(ProgressFlag, false, "Show the progress of the listing on stderr")
+
+	return cmd
+}
+
+func NewRepoActivityCommand(searchService SearchService) *cobra.Command {
+	var reference string
+
+	cmd := &cobra.Command{
+		Use:   "activity [repo-name]",
+		Short: "Show the activity log of a repository",
+		Long: `Show the pushes, pulls and deletes of the repository, and the tags moved to another manifest,
+the most recent first, with the user who made them. The registry keeps this log only when the
+activity log of the search extension is enabled, and drops the events past its limits.`,
+		Example: `  zli repo activity alpine
+  zli repo activity alpine --reference 3.18 -f json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			if err := CheckExtEndPointQuery(searchConfig, RepoActivityQuery()); err != nil {
+				return err
+			}
+
+			return ShowRepoActivity(searchConfig, args[0], reference)
+		},
+	}
+
+	cmd.Flags().StringVar(&reference, ReferenceFlag, "", "Only show the events of this tag or digest")
+	cmd.Flags().StringP(OutputFormatFlag, "f", "", "Specify output format [text/json/ndjson/yaml]")
+
+	return cmd
+}
+
 func NewListReposCommand(searchService SearchService) *cobra.Command {
+	var starred, bookmarked bool
+
 	repoListSortFlag := RepoListSortFlag(SortByAlphabeticAsc)
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all repositories",
-		Long:  "List all repositories",
-		Args:  cobra.NoArgs,
+		Long: `List all repositories, or only the ones starred or bookmarked by the user, with their size,
+downloads and stars. These need the search and user preferences extensions of the registry.`,
+		Example: `  zli repo list
+  zli repo list --starred`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
 			if err != nil {
 				return err
 			}
 
+			if starred || bookmarked {
+				query := StarredReposQuery()
+				if bookmarked {
+					query = BookmarkedReposQuery()
+				}
+
+				if err := CheckExtEndPointQuery(searchConfig, query); err != nil {
+					return err
+				}
+
+				return SearchUserRepos(searchConfig, bookmarked)
+			}
+
 			return SearchRepos(searchConfig)
 		},
 	}
 
 	cmd.Flags().Var(&repoListSortFlag, SortByFlag,
 		fmt.Sprintf("Options for sorting the output: [%s]", RepoListSortOptionsStr()))
+	cmd.Flags().BoolVar(&starred, StarredFlag, false, "Only list the repositories starred by the user")
+	cmd.Flags().BoolVar(&bookmarked, BookmarkedFlag, false, "Only list the repositories bookmarked by the user")
+	cmd.MarkFlagsMutuallyExclusive(StarredFlag, BookmarkedFlag)
 
 	return cmd
 }
+
+func NewRepoStarCommand(searchService SearchService) *cobra.Command {
+	return newRepoPreferenceCommand(searchService, "star", "Star a repository for the user", SetRepoStarred, true)
+}
+
+func NewRepoUnstarCommand(searchService SearchService) *cobra.Command {
+	return newRepoPreferenceCommand(searchService, "unstar", "Remove the star of the user from a repository",
+		SetRepoStarred, false)
+}
+
+func NewRepoBookmarkCommand(searchService SearchService) *cobra.Command {
+	return newRepoPreferenceCommand(searchService, "bookmark", "Bookmark a repository for the user",
+		SetRepoBookmarked, true)
+}
+
+func NewRepoUnbookmarkCommand(searchService SearchService) *cobra.Command {
+	return newRepoPreferenceCommand(searchService, "unbookmark", "Remove the bookmark of the user from a repository",
+		SetRepoBookmarked, false)
+}
+
+// newRepoPreferenceCommand returns a command setting a preference of the user on a repository.
+func newRepoPreferenceCommand(searchService SearchService, use, short string,
+	setPreference func(config SearchConfig, repo string, enabled bool) error, enabled bool,
+) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " [repo-name]",
+		Short: short,
+		Long: short + `. The preferences are kept per user by the user preferences extension of the registry,
+so they need an authenticated user.`,
+		Example: "  zli repo " + use + " alpine",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return setPreference(searchConfig, args[0], enabled)
+		},
+	}
+}
@@ -5,17 +5,21 @@ package client_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 
 	"zotregistry.dev/zot/pkg/api"
 	"zotregistry.dev/zot/pkg/api/config"
 	"zotregistry.dev/zot/pkg/cli/client"
+	extconf "zotregistry.dev/zot/pkg/extensions/config"
 	test "zotregistry.dev/zot/pkg/test/common"
 	. "zotregistry.dev/zot/pkg/test/image-utils"
 )
@@ -89,6 +93,186 @@ func TestReposCommand(t *testing.T) {
 	})
 }
 
+func TestRepoStatsCommand(t *testing.T) {
+	Convey("repo stats", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+
+		cm.StartAndWait(conf.HTTP.Port)
+		defer cm.StopServer()
+
+		image1 := CreateRandomImage()
+		image2 := CreateRandomImage()
+
+		err := UploadImage(image1, baseURL, "repo1", "tag1")
+		So(err, ShouldBeNil)
+		err = UploadImage(image2, baseURL, "repo1", "tag2")
+		So(err, ShouldBeNil)
+		err = UploadImage(image1, baseURL, "repo2", "tag1")
+		So(err, ShouldBeNil)
+
+		configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"repostatstest","url":"%s","showspinner":false}]}`,
+			baseURL))
+		defer os.Remove(configPath)
+
+		imageSize := func(image Image) int64 {
+			size := image.ManifestDescriptor.Size + image.ConfigDescriptor.Size
+
+			for _, layer := range image.Manifest.Layers {
+				size += layer.Size
+			}
+
+			return size
+		}
+
+		runStats := func(args ...string) (string, error) {
+			cmd := client.NewRepoCommand(client.NewSearchService())
+			buff := bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs(append([]string{"stats", "--config", "repostatstest"}, args...))
+			err := cmd.Execute()
+
+			return buff.String(), err
+		}
+
+		output, err := runStats("-f", "json")
+		So(err, ShouldBeNil)
+
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		So(len(lines), ShouldEqual, 2)
+
+		repoStats := map[string]any{}
+		err = json.Unmarshal([]byte(lines[0]), &repoStats)
+		So(err, ShouldBeNil)
+		So(repoStats["name"], ShouldEqual, "repo1")
+		So(repoStats["tagCount"], ShouldEqual, 2)
+		So(repoStats["size"], ShouldEqual, strconv.FormatInt(imageSize(image1)+imageSize(image2), 10))
+
+		lastUpdated := *image1.Config.Created
+		if image2.Config.Created.After(lastUpdated) {
+			lastUpdated = *image2.Config.Created
+		}
+
+		So(repoStats["lastUpdated"], ShouldEqual, lastUpdated.Format(time.RFC3339Nano))
+
+		err = json.Unmarshal([]byte(lines[1]), &repoStats)
+		So(err, ShouldBeNil)
+		So(repoStats["name"], ShouldEqual, "repo2")
+		So(repoStats["tagCount"], ShouldEqual, 1)
+		So(repoStats["size"], ShouldEqual, strconv.FormatInt(imageSize(image1), 10))
+
+		output, err = runStats("--sort-by", "alpha-dsc")
+		So(err, ShouldBeNil)
+
+		actual := strings.TrimSpace(regexp.MustCompile(`\s+`).ReplaceAllString(output, " "))
+		So(actual, ShouldStartWith, "NAME TAGS SIZE LAST UPDATED repo2 1")
+		So(actual, ShouldContainSubstring, "repo1 2")
+		So(strings.Index(actual, "repo2"), ShouldBeLessThan, strings.Index(actual, "repo1"))
+
+		output, err = runStats("-f", "yaml")
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "tagcount: 2")
+
+		_, err = runStats("-f", "bad")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestRepoActivityCommand(t *testing.T) {
+	Convey("repo activity", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+				Activity:   &extconf.ActivityConfig{MaxEvents: 100},
+			},
+		}
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+
+		cm.StartAndWait(conf.HTTP.Port)
+		defer cm.StopServer()
+
+		image1 := CreateRandomImage()
+		image2 := CreateRandomImage()
+
+		err := UploadImage(image1, baseURL, "repo1", "tag1")
+		So(err, ShouldBeNil)
+		err = UploadImage(image2, baseURL, "repo1", "tag1")
+		So(err, ShouldBeNil)
+		err = UploadImage(image2, baseURL, "repo1", "tag2")
+		So(err, ShouldBeNil)
+
+		configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"activitytest","url":"%s","showspinner":false}]}`,
+			baseURL))
+		defer os.Remove(configPath)
+
+		runActivity := func(args ...string) (string, error) {
+			cmd := client.NewRepoCommand(client.NewSearchService())
+			buff := bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs(append([]string{"activity", "--config", "activitytest"}, args...))
+			err := cmd.Execute()
+
+			return buff.String(), err
+		}
+
+		// the events are recorded in the background
+		output, err := runActivity("repo1")
+		for i := 0; i < 100 && err == nil && strings.Count(strings.TrimSpace(output), "\n") < 4; i++ {
+			time.Sleep(50 * time.Millisecond)
+
+			output, err = runActivity("repo1")
+		}
+		So(err, ShouldBeNil)
+
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		So(len(lines), ShouldEqual, 5)
+		So(strings.Fields(lines[0]), ShouldResemble, []string{"TIME", "TYPE", "REFERENCE", "DIGEST", "ACTOR"})
+		So(strings.Fields(lines[1])[1:], ShouldResemble, []string{"push", "tag2",
+			image2.DigestStr()[7:15], "-"})
+		So(strings.Fields(lines[2])[1:], ShouldResemble, []string{"tag", "tag1",
+			image1.DigestStr()[7:15], "->", image2.DigestStr()[7:15], "-"})
+
+		output, err = runActivity("repo1", "--reference", "tag1", "-f", "ndjson")
+		So(err, ShouldBeNil)
+
+		lines = strings.Split(strings.TrimSpace(output), "\n")
+		So(len(lines), ShouldEqual, 3)
+
+		event := map[string]any{}
+		err = json.Unmarshal([]byte(lines[2]), &event)
+		So(err, ShouldBeNil)
+		So(event["type"], ShouldEqual, "push")
+		So(event["digest"], ShouldEqual, image1.DigestStr())
+
+		output, err = runActivity("repo2")
+		So(err, ShouldBeNil)
+		So(strings.TrimSpace(output), ShouldStartWith, "TIME")
+		So(strings.Count(strings.TrimSpace(output), "\n"), ShouldEqual, 0)
+
+		_, err = runActivity("repo1", "-f", "bad")
+		So(err, ShouldNotBeNil)
+
+		_, err = runActivity()
+		So(err, ShouldNotBeNil)
+	})
+}
+
 func TestSuggestions(t *testing.T) {
 	Convey("Suggestions", t, func() {
 		space := regexp.MustCompile(`\s+`)
@@ -4,13 +4,28 @@
 package client
 
 import (
+	"errors"
+
 	distspec "github.com/opencontainers/distribution-spec/specs-go"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
+	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api/config"
 )
 
+// partialResultsExitCode is the exit code when only some of the images could be listed.
+const partialResultsExitCode = 2
+
+// ExitCode returns the exit code of zli for the error returned by the command.
+func ExitCode(err error) int {
+	if errors.Is(err, zerr.ErrPartialResults) {
+		return partialResultsExitCode
+	}
+
+	return 1
+}
+
 // "zli" - client-side cli.
 func NewCliRootCmd() *cobra.Command {
 	showVersion := false
@@ -0,0 +1,371 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	jsoniter "github.com/json-iterator/go"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sigstore/cosign/v2/pkg/oci/remote"
+	"gopkg.in/yaml.v2"
+
+	zerr "zotregistry.dev/zot/errors"
+)
+
+const (
+	spdxFormat      = "spdx"
+	cycloneDXFormat = "cyclonedx"
+
+	// spdx and cyclonedx also have xml or tag-value encodings, only the json ones can be summarized.
+	spdxJSONMediaType       = "application/spdx+json"
+	cosignSPDXJSONMediaType = "text/spdx+json"
+	cycloneDXJSONMediaType  = "application/vnd.cyclonedx+json"
+	spdxNoAssertion         = "NOASSERTION"
+)
+
+// sbomMediaTypes are the media types of the sbom layers, and the artifact types of the sbom referrers,
+// with the format of the sbom.
+var sbomMediaTypes = map[string]string{ //nolint: gochecknoglobals
+	spdxJSONMediaType:       spdxFormat,
+	cosignSPDXJSONMediaType: spdxFormat,
+	cycloneDXJSONMediaType:  cycloneDXFormat,
+}
+
+// sbomDocument is an sbom attached to an image, as stored in the registry.
+type sbomDocument struct {
+	format    string
+	mediaType string
+	digest    string
+	content   []byte
+}
+
+// fetchSBOMs returns the sboms attached to the manifest, either as referrers with an sbom artifact type
+// or with the cosign tag convention, with cosign attach sbom.
+func fetchSBOMs(ctx context.Context, config SearchConfig, username, password, repo, digest string,
+) ([]sbomDocument, error) {
+	sbomManifests := []string{}
+
+	referrers, err := config.SearchService.getReferrers(ctx, config, username, password, repo, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, referrer := range referrers {
+		if _, ok := sbomMediaTypes[referrer.ArtifactType]; ok {
+			sbomManifests = append(sbomManifests, referrer.Digest)
+		}
+	}
+
+	cosignTag := strings.Replace(digest, ":", "-", 1) + "." + remote.SBOMTagSuffix
+
+	cosignURL := fmt.Sprintf("%s/v2/%s/manifests/%s", config.ServURL, repo, cosignTag)
+
+	if _, err := makeHEADRequest(ctx, cosignURL, username, password, config); err == nil {
+		sbomManifests = append(sbomManifests, cosignTag)
+	}
+
+	documents := []sbomDocument{}
+	seen := map[string]bool{}
+
+	for _, reference := range sbomManifests {
+		var manifest ispec.Manifest
+
+		URL := fmt.Sprintf("%s/v2/%s/manifests/%s", config.ServURL, repo, reference)
+
+		if _, err := makeGETRequest(ctx, URL, username, password, config, &manifest); err != nil {
+			return nil, err
+		}
+
+		for _, layer := range manifest.Layers {
+			format, ok := sbomMediaTypes[layer.MediaType]
+			if !ok || seen[layer.Digest.String()] {
+				continue
+			}
+
+			seen[layer.Digest.String()] = true
+
+			content, err := fetchBlob(ctx, config, username, password, repo, layer)
+			if err != nil {
+				return nil, err
+			}
+
+			documents = append(documents, sbomDocument{
+				format:    format,
+				mediaType: layer.MediaType,
+				digest:    layer.Digest.String(),
+				content:   content,
+			})
+		}
+	}
+
+	return documents, nil
+}
+
+func fetchBlob(ctx context.Context, config SearchConfig, username, password, repo string, blob ispec.Descriptor,
+) ([]byte, error) {
+	URL := fmt.Sprintf("%s/v2/%s/blobs/%s", config.ServURL, repo, blob.Digest)
+
+	body, err := makeBlobGETRequest(ctx, URL, username, password, config)
+	if err != nil {
+		return nil, err
+	}
+
+	defer body.Close()
+
+	return io.ReadAll(body)
+}
+
+// sbomSummary is what an sbom says about the image: the tool which generated it and the packages found.
+type sbomSummary struct {
+	Format   string        `json:"format"`
+	Version  string        `json:"version"`
+	Digest   string        `json:"digest"`
+	Name     string        `json:"name,omitempty"`
+	Created  string        `json:"created,omitempty"`
+	Creators []string      `json:"creators,omitempty"`
+	Packages []sbomPackage `json:"packages"`
+}
+
+type sbomPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	License string `json:"license"`
+}
+
+type spdxDocument struct {
+	SPDXVersion  string `json:"spdxVersion"`
+	Name         string `json:"name"`
+	CreationInfo struct {
+		Created  string   `json:"created"`
+		Creators []string `json:"creators"`
+	} `json:"creationInfo"`
+	Packages []struct {
+		Name             string `json:"name"`
+		VersionInfo      string `json:"versionInfo"`
+		LicenseConcluded string `json:"licenseConcluded"`
+		LicenseDeclared  string `json:"licenseDeclared"`
+	} `json:"packages"`
+}
+
+type cycloneDXTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type cycloneDXDocument struct {
+	SpecVersion string `json:"specVersion"`
+	Metadata    struct {
+		Timestamp string `json:"timestamp"`
+		Component struct {
+			Name string `json:"name"`
+		} `json:"component"`
+		// a list of tools until cyclonedx 1.5, then an object with a list of tool components
+		Tools json.RawMessage `json:"tools"`
+	} `json:"metadata"`
+	Components []struct {
+		Name     string `json:"name"`
+		Version  string `json:"version"`
+		Licenses []struct {
+			License struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"license"`
+			Expression string `json:"expression"`
+		} `json:"licenses"`
+	} `json:"components"`
+}
+
+func getSBOMSummary(document sbomDocument) (sbomSummary, error) {
+	switch document.format {
+	case spdxFormat:
+		return getSPDXSummary(document)
+	case cycloneDXFormat:
+		return getCycloneDXSummary(document)
+	default:
+		return sbomSummary{}, fmt.Errorf("%w: %s", zerr.ErrMediaTypeNotSupported, document.mediaType)
+	}
+}
+
+func getSPDXSummary(document sbomDocument) (sbomSummary, error) {
+	var spdx spdxDocument
+
+	if err := json.Unmarshal(document.content, &spdx); err != nil {
+		return sbomSummary{}, fmt.Errorf("invalid spdx document %s: %w", document.digest, err)
+	}
+
+	summary := sbomSummary{
+		Format:   spdxFormat,
+		Version:  spdx.SPDXVersion,
+		Digest:   document.digest,
+		Name:     spdx.Name,
+		Created:  spdx.CreationInfo.Created,
+		Creators: spdx.CreationInfo.Creators,
+		Packages: make([]sbomPackage, 0, len(spdx.Packages)),
+	}
+
+	for _, pkg := range spdx.Packages {
+		license := pkg.LicenseConcluded
+		if license == "" || license == spdxNoAssertion {
+			license = pkg.LicenseDeclared
+		}
+
+		if license == spdxNoAssertion {
+			license = ""
+		}
+
+		summary.Packages = append(summary.Packages, sbomPackage{Name: pkg.Name, Version: pkg.VersionInfo,
+			License: license})
+	}
+
+	return summary, nil
+}
+
+func getCycloneDXSummary(document sbomDocument) (sbomSummary, error) {
+	var cycloneDX cycloneDXDocument
+
+	if err := json.Unmarshal(document.content, &cycloneDX); err != nil {
+		return sbomSummary{}, fmt.Errorf("invalid cyclonedx document %s: %w", document.digest, err)
+	}
+
+	summary := sbomSummary{
+		Format:   cycloneDXFormat,
+		Version:  cycloneDX.SpecVersion,
+		Digest:   document.digest,
+		Name:     cycloneDX.Metadata.Component.Name,
+		Created:  cycloneDX.Metadata.Timestamp,
+		Packages: make([]sbomPackage, 0, len(cycloneDX.Components)),
+	}
+
+	var tools []cycloneDXTool
+
+	if err := json.Unmarshal(cycloneDX.Metadata.Tools, &tools); err != nil {
+		var toolComponents struct {
+			Components []cycloneDXTool `json:"components"`
+		}
+
+		// the tools are only informative, they are left out if they can't be read
+		_ = json.Unmarshal(cycloneDX.Metadata.Tools, &toolComponents)
+
+		tools = toolComponents.Components
+	}
+
+	for _, tool := range tools {
+		summary.Creators = append(summary.Creators, strings.TrimSpace(tool.Name+" "+tool.Version))
+	}
+
+	for _, component := range cycloneDX.Components {
+		licenses := []string{}
+
+		for _, license := range component.Licenses {
+			switch {
+			case license.Expression != "":
+				licenses = append(licenses, license.Expression)
+			case license.License.ID != "":
+				licenses = append(licenses, license.License.ID)
+			case license.License.Name != "":
+				licenses = append(licenses, license.License.Name)
+			}
+		}
+
+		summary.Packages = append(summary.Packages, sbomPackage{Name: component.Name, Version: component.Version,
+			License: strings.Join(licenses, " OR ")})
+	}
+
+	return summary, nil
+}
+
+type sbomSummaries []sbomSummary
+
+func (summaries sbomSummaries) string(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return summaries.stringPlainText()
+	case jsonFormat:
+		return summaries.stringJSON()
+	case ndjsonFormat:
+		return summaries.stringNDJSON()
+	case ymlFormat, yamlFormat:
+		return summaries.stringYAML()
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (summaries sbomSummaries) stringPlainText() (string, error) {
+	var builder strings.Builder
+
+	writer := tabwriter.NewWriter(&builder, 0, 8, 2, ' ', 0) //nolint:gomnd
+
+	for i, summary := range summaries {
+		if i > 0 {
+			fmt.Fprintln(writer)
+		}
+
+		fmt.Fprintf(writer, "Format:\t%s %s\n", summary.Format, summary.Version)
+		fmt.Fprintf(writer, "Digest:\t%s\n", summary.Digest)
+		writeInspectValue(writer, "Name", summary.Name)
+		writeInspectValue(writer, "Created", summary.Created)
+		writeInspectValue(writer, "Creators", strings.Join(summary.Creators, ", "))
+		fmt.Fprintf(writer, "Packages:\t%d\n", len(summary.Packages))
+
+		if len(summary.Packages) > 0 {
+			writeInspectRow(writer, "NAME", "VERSION", "LICENSE")
+		}
+
+		for _, pkg := range summary.Packages {
+			writeInspectRow(writer, pkg.Name, pkg.Version, pkg.License)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+func (summaries sbomSummaries) stringJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.MarshalIndent(summaries, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+// stringNDJSON renders one sbom per line.
+func (summaries sbomSummaries) stringNDJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	var builder strings.Builder
+
+	for _, summary := range summaries {
+		body, err := json.Marshal(summary)
+		if err != nil {
+			return "", err
+		}
+
+		builder.Write(body)
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
+
+func (summaries sbomSummaries) stringYAML() (string, error) {
+	body, err := yaml.Marshal(summaries)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(body), nil
+}
@@ -0,0 +1,47 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewSBOMCommand(searchService SearchService) *cobra.Command {
+	sbomCmd := &cobra.Command{
+		Use:   "sbom [command]",
+		Short: "Get the SBOMs attached to images",
+		Long:  `Get the SBOMs (Software Bill Of Materials) attached to images hosted on the zot registry`,
+		RunE:  ShowSuggestionsIfUnknownCommand,
+	}
+
+	sbomCmd.SetUsageTemplate(sbomCmd.UsageTemplate() + usageFooter)
+
+	sbomCmd.PersistentFlags().String(URLFlag, "",
+		"Specify zot server URL if config-name is not mentioned")
+	sbomCmd.PersistentFlags().String(ConfigFlag, "",
+		"Specify the registry configuration to use for connection")
+	_ = sbomCmd.RegisterFlagCompletionFunc(ConfigFlag, completeConfigNames)
+	sbomCmd.PersistentFlags().StringP(UserFlag, "u", "",
+		`User Credentials of zot server in "username:password" format`)
+	sbomCmd.PersistentFlags().StringP(OutputFormatFlag, "f", "", "Specify output format [text/json/ndjson/yaml]")
+	sbomCmd.PersistentFlags().Bool(DebugFlag, false,
+		"Show every request made to the registry on stderr, with its status, latency and request id")
+	sbomCmd.PersistentFlags().Bool(DebugBodyFlag, false, "With --debug, also show the headers and the textual bodies")
+	sbomCmd.PersistentFlags().Duration(TimeoutFlag, 0,
+		"Maximum time the whole search can take, 0 means no limit")
+	sbomCmd.PersistentFlags().Int(RetriesFlag, defaultRetries,
+		"Number of times a request is retried when the registry is busy or the connection fails")
+	sbomCmd.PersistentFlags().Duration(RetryMaxWaitFlag, defaultRetryMaxWait,
+		"Maximum time to wait between two retries of a request")
+	sbomCmd.PersistentFlags().String(CertFlag, "", "Client certificate file to present to the server")
+	sbomCmd.PersistentFlags().String(KeyFlag, "", "Key file of the client certificate")
+	sbomCmd.PersistentFlags().String(CACertFlag, "",
+		"CA certificate file used to verify the server, in addition to the system ones")
+	sbomCmd.PersistentFlags().String(ProxyFlag, "",
+		"Proxy the server is reached through, an http, https or socks5 url [default: HTTPS_PROXY/NO_PROXY]")
+
+	sbomCmd.AddCommand(NewSBOMGetCommand(searchService))
+
+	return sbomCmd
+}
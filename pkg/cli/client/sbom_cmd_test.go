@@ -0,0 +1,168 @@
+//go:build search
+// +build search
+
+package client_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/cli/client"
+	test "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+const (
+	testSPDXDocument = `{
+  "spdxVersion": "SPDX-2.3",
+  "name": "repo",
+  "creationInfo": {"created": "2024-01-01T00:00:00Z", "creators": ["Tool: syft-0.98.0"]},
+  "packages": [
+    {"name": "busybox", "versionInfo": "1.36.1-r15", "licenseConcluded": "NOASSERTION", "licenseDeclared": "GPL-2.0-only"},
+    {"name": "musl", "versionInfo": "1.2.4-r2", "licenseConcluded": "MIT"},
+    {"name": "unknown", "versionInfo": "1.0", "licenseConcluded": "NOASSERTION"}
+  ]
+}`
+	testCycloneDXDocument = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "metadata": {
+    "timestamp": "2024-01-02T00:00:00Z",
+    "component": {"name": "repo"},
+    "tools": {"components": [{"name": "trivy", "version": "0.48.1"}]}
+  },
+  "components": [
+    {"name": "openssl", "version": "3.1.4", "licenses": [{"license": {"id": "Apache-2.0"}}]},
+    {"name": "zlib", "version": "1.3", "licenses": [{"expression": "Zlib"}]}
+  ]
+}`
+)
+
+func TestSBOMGet(t *testing.T) {
+	space := regexp.MustCompile(`\s+`)
+
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	image := CreateRandomImage()
+	err := UploadImage(image, baseURL, "repo", "1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = UploadImage(CreateRandomImage(), baseURL, "repo", "nosbom")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createSBOM := func(content, mediaType string, subject *ispec.Descriptor) Image {
+		builder := CreateImageWith().Layers([]Layer{{
+			Blob:      []byte(content),
+			MediaType: mediaType,
+			Digest:    godigest.FromString(content),
+		}}).EmptyConfig()
+
+		if subject != nil {
+			return builder.ArtifactType(mediaType).Subject(subject).Build()
+		}
+
+		return builder.Build()
+	}
+
+	// an spdx sbom attached as a referrer
+	spdxSBOM := createSBOM(testSPDXDocument, "application/spdx+json", image.DescriptorRef())
+
+	err = UploadImage(spdxSBOM, baseURL, "repo", spdxSBOM.DigestStr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a cyclonedx sbom attached with cosign attach sbom
+	cosignTag := strings.Replace(image.DigestStr(), ":", "-", 1) + ".sbom"
+
+	err = UploadImage(createSBOM(testCycloneDXDocument, "application/vnd.cyclonedx+json", nil), baseURL, "repo",
+		cosignTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runSBOMGet := func(args ...string) (string, error) {
+		cmd := client.NewSBOMCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append([]string{"get", "--url", baseURL}, args...))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	Convey("Test sbom get", t, func() {
+		Convey("the summaries of all the sboms", func() {
+			output, err := runSBOMGet("repo:1.0")
+			So(err, ShouldBeNil)
+
+			actual := strings.TrimSpace(space.ReplaceAllString(output, " "))
+			So(actual, ShouldContainSubstring, "Format: spdx SPDX-2.3")
+			So(actual, ShouldContainSubstring, "Creators: Tool: syft-0.98.0 Packages: 3")
+			So(actual, ShouldContainSubstring, "NAME VERSION LICENSE busybox 1.36.1-r15 GPL-2.0-only "+
+				"musl 1.2.4-r2 MIT unknown 1.0")
+			So(actual, ShouldContainSubstring, "Format: cyclonedx 1.5")
+			So(actual, ShouldContainSubstring, "Creators: trivy 0.48.1 Packages: 2")
+			So(actual, ShouldContainSubstring, "openssl 3.1.4 Apache-2.0 zlib 1.3 Zlib")
+		})
+
+		Convey("the sboms of a format, by digest", func() {
+			output, err := runSBOMGet("repo@"+image.DigestStr(), "--type", "cyclonedx", "-f", "json")
+			So(err, ShouldBeNil)
+
+			var summaries []map[string]any
+
+			err = json.Unmarshal([]byte(output), &summaries)
+			So(err, ShouldBeNil)
+			So(len(summaries), ShouldEqual, 1)
+			So(summaries[0]["format"], ShouldEqual, "cyclonedx")
+			So(summaries[0]["name"], ShouldEqual, "repo")
+		})
+
+		Convey("the raw sbom", func() {
+			output, err := runSBOMGet("repo:1.0", "--type", "spdx", "--raw")
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, testSPDXDocument+"\n")
+		})
+
+		Convey("errors", func() {
+			_, err := runSBOMGet("repo:nosbom")
+			So(errors.Is(err, zerr.ErrSBOMNotFound), ShouldBeTrue)
+
+			_, err = runSBOMGet("repo:1.0", "--type", "swid")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+
+			_, err = runSBOMGet("repo:missing")
+			So(err, ShouldNotBeNil)
+
+			_, err = runSBOMGet("repo")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
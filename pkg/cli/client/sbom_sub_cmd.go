@@ -0,0 +1,41 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewSBOMGetCommand(searchService SearchService) *cobra.Command {
+	var (
+		sbomType string
+		raw      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "get [repo-name:tag]|[repo-name@digest]",
+		Short: "Show the SBOMs attached to an image",
+		Long: `Show the SPDX and CycloneDX SBOMs attached to the image, found with the referrers API
+or the cosign tag convention. By default the packages each SBOM lists are summarized,
+with --raw the SBOM documents are printed as they are stored.`,
+		Example: `  zli sbom get alpine:3.18
+  zli sbom get alpine:3.18 --type cyclonedx -f json
+  zli sbom get alpine:3.18 --type spdx --raw > alpine.spdx.json`,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              OneImageWithRefArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return GetSBOMs(searchConfig, args[0], sbomType, raw)
+		},
+	}
+
+	cmd.Flags().StringVar(&sbomType, SBOMTypeFlag, "", "Only show the SBOMs of this format [spdx/cyclonedx]")
+	cmd.Flags().BoolVar(&raw, RawFlag, false, "Print the SBOM documents instead of their summary")
+
+	return cmd
+}
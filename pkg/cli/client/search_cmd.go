@@ -4,6 +4,8 @@
 package client
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 )
 
@@ -21,11 +23,49 @@ func NewSearchCommand(searchService SearchService) *cobra.Command {
 		"Specify zot server URL if config-name is not mentioned")
 	searchCmd.PersistentFlags().String(ConfigFlag, "",
 		"Specify the registry configuration to use for connection")
+	_ = searchCmd.RegisterFlagCompletionFunc(ConfigFlag, completeConfigNames)
 	searchCmd.PersistentFlags().StringP(UserFlag, "u", "",
 		`User Credentials of zot server in "username:password" format`)
-	searchCmd.PersistentFlags().StringP(OutputFormatFlag, "f", "", "Specify output format [text/json/yaml]")
+	searchCmd.PersistentFlags().StringP(OutputFormatFlag, "f", "",
+		"Specify output format [text/json/ndjson/yaml/csv/tsv], "+
+			"or a Go template for the images, e.g. '{{.RepoName}}:{{.Tag}}'")
 	searchCmd.PersistentFlags().Bool(VerboseFlag, false, "Show verbose output")
-	searchCmd.PersistentFlags().Bool(DebugFlag, false, "Show debug output")
+	searchCmd.PersistentFlags().Bool(DebugFlag, false,
+		"Show every request made to the registry on stderr, with its status, latency and request id")
+	searchCmd.PersistentFlags().Bool(DebugBodyFlag, false, "With --debug, also show the headers and the textual bodies")
+	searchCmd.PersistentFlags().Bool(NoCacheFlag, false, "Don't use the local cache of image manifests")
+	searchCmd.PersistentFlags().Bool(ProgressFlag, false, "Show the progress of the listing on stderr")
+	searchCmd.PersistentFlags().Bool(NoTruncFlag, false, "Show the full digests, authors and signers in the text output")
+	searchCmd.PersistentFlags().Bool(FailFastFlag, false,
+		"Stop at the first image which can't be fetched, instead of listing the others and the errors at the end")
+	searchCmd.PersistentFlags().Bool(VerifySignatureFlag, false,
+		"Only show the images as signed if a cosign signature is verified with one of the --public-key")
+	searchCmd.PersistentFlags().StringSlice(PublicKeyFlag, []string{},
+		"Public key file the cosign signatures are verified with, can be given several times")
+	searchCmd.PersistentFlags().String(FormatColumnsFlag, "",
+		fmt.Sprintf("Comma separated columns of the text output, in order, from: [%s]", imageColumnsStr()))
+	searchCmd.PersistentFlags().Int(MaxConcurrentRequestsFlag, defaultMaxConcurrentRequests,
+		"Maximum number of image manifests fetched at the same time")
+	searchCmd.PersistentFlags().Int(RequestsPerSecondFlag, defaultRequestsPerSecond,
+		"Maximum number of image manifest fetches started per second")
+	searchCmd.PersistentFlags().Int(MaxIdleConnsPerHostFlag, 0,
+		"Maximum number of idle connections kept open to the registry, 0 keeps one per concurrent request")
+	searchCmd.PersistentFlags().Duration(RequestTimeoutFlag, defaultRequestTimeout,
+		"Maximum time a single request to the registry can take")
+	searchCmd.PersistentFlags().Duration(TimeoutFlag, 0,
+		"Maximum time the whole search can take, the results received until then are still shown, 0 means no limit")
+	searchCmd.PersistentFlags().Int(RetriesFlag, defaultRetries,
+		"Number of times a request is retried when the registry is busy or the connection fails")
+	searchCmd.PersistentFlags().Duration(RetryMaxWaitFlag, defaultRetryMaxWait,
+		"Maximum time to wait between two retries of a request")
+	searchCmd.PersistentFlags().String(LimitRateFlag, "",
+		"Maximum bandwidth of all the transfers together, in bytes per second, e.g. 512KiB, empty means no limit")
+	searchCmd.PersistentFlags().String(CertFlag, "", "Client certificate file to present to the server")
+	searchCmd.PersistentFlags().String(KeyFlag, "", "Key file of the client certificate")
+	searchCmd.PersistentFlags().String(CACertFlag, "",
+		"CA certificate file used to verify the server, in addition to the system ones")
+	searchCmd.PersistentFlags().String(ProxyFlag, "",
+		"Proxy the server is reached through, an http, https or socks5 url [default: HTTPS_PROXY/NO_PROXY]")
 
 	searchCmd.AddCommand(NewSearchQueryCommand(searchService))
 	searchCmd.AddCommand(NewSearchSubjectCommand(searchService))
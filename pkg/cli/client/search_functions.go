@@ -4,9 +4,13 @@
 package client
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -18,9 +22,13 @@ import (
 const CveDBRetryInterval = 3
 
 func SearchAllImages(config SearchConfig) error {
+	if len(config.Registries) > 0 {
+		return searchRegistries(config, config.SearchService.getAllImages, printImageTableHeader)
+	}
+
 	username, password := getUsernameAndPassword(config.User)
 	imageErr := make(chan stringResult)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	var wg sync.WaitGroup
 
@@ -31,7 +39,39 @@ func SearchAllImages(config SearchConfig) error {
 
 	errCh := make(chan error, 1)
 
-	go collectResults(config, &wg, imageErr, cancel, printImageTableHeader, errCh)
+	go collectResults(ctx, config, &wg, imageErr, cancel, printImageTableHeader, errCh)
+	wg.Wait()
+	select {
+	case err := <-errCh:
+		return err
+	default:
+		return nil
+	}
+}
+
+func SearchImagesByLabel(config SearchConfig, labels map[string]string) error {
+	if len(config.Registries) > 0 {
+		return searchRegistries(config, func(ctx context.Context, config SearchConfig, username, password string,
+			rch chan stringResult, wtgrp *sync.WaitGroup,
+		) {
+			config.SearchService.getImagesByLabel(ctx, config, username, password, labels, rch, wtgrp)
+		}, printImageTableHeader)
+	}
+
+	username, password := getUsernameAndPassword(config.User)
+	imageErr := make(chan stringResult)
+	ctx, cancel := newSearchContext(config)
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go config.SearchService.getImagesByLabel(ctx, config, username, password, labels, imageErr, &wg)
+	wg.Add(1)
+
+	errCh := make(chan error, 1)
+
+	go collectResults(ctx, config, &wg, imageErr, cancel, printImageTableHeader, errCh)
 	wg.Wait()
 	select {
 	case err := <-errCh:
@@ -41,9 +81,33 @@ func SearchAllImages(config SearchConfig) error {
 	}
 }
 
+// SearchImagesByLabelGQL lists the images having all the labels, matched against the annotations returned
+// by the search extension.
+func SearchImagesByLabelGQL(config SearchConfig, labels map[string]string) error {
+	username, password := getUsernameAndPassword(config.User)
+	ctx, cancel := newSearchContext(config)
+
+	defer cancel()
+
+	imageList, err := config.SearchService.getAnnotatedImagesGQL(ctx, config, username, password)
+	if err != nil {
+		return err
+	}
+
+	imageListData := []imageStruct{}
+
+	for _, image := range imageList.Data.ImageList.Results {
+		if image.matchesLabels(labels) {
+			imageListData = append(imageListData, image.imageStruct())
+		}
+	}
+
+	return printImageResult(ctx, config, imageListData)
+}
+
 func SearchAllImagesGQL(config SearchConfig) error {
 	username, password := getUsernameAndPassword(config.User)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	defer cancel()
 
@@ -58,13 +122,64 @@ func SearchAllImagesGQL(config SearchConfig) error {
 		imageListData = append(imageListData, imageStruct(image))
 	}
 
-	return printImageResult(config, imageListData)
+	return printImageResult(ctx, config, imageListData)
+}
+
+// SearchOCILayoutImages lists the tagged images of the oci layout at the given path, or of the layouts
+// under it like the repositories of a zot storage directory, without any registry.
+func SearchOCILayoutImages(config SearchConfig, layoutPath string, labels map[string]string) error {
+	layouts, err := findOCILayouts(layoutPath)
+	if err != nil {
+		return err
+	}
+
+	imageList := []imageStruct{}
+
+	for _, layout := range layouts {
+		images, err := layout.listImages(labels)
+		if err != nil {
+			return err
+		}
+
+		imageList = append(imageList, images...)
+	}
+
+	switch config.SortBy {
+	case SortByUpdateTime:
+		slices.SortStableFunc(imageList, func(a, b imageStruct) int {
+			return b.LastUpdated.Compare(a.LastUpdated)
+		})
+	case SortByAlphabeticDsc:
+		slices.SortFunc(imageList, func(a, b imageStruct) int {
+			return compareImageNames(b, a)
+		})
+	default:
+		slices.SortFunc(imageList, compareImageNames)
+	}
+
+	return printImageResult(context.Background(), config, imageList)
+}
+
+func compareImageNames(a, b imageStruct) int {
+	if a.RepoName != b.RepoName {
+		return cmp.Compare(a.RepoName, b.RepoName)
+	}
+
+	return cmp.Compare(a.Tag, b.Tag)
 }
 
 func SearchImageByName(config SearchConfig, image string) error {
+	if len(config.Registries) > 0 {
+		return searchRegistries(config, func(ctx context.Context, config SearchConfig, username, password string,
+			rch chan stringResult, wtgrp *sync.WaitGroup,
+		) {
+			config.SearchService.getImageByName(ctx, config, username, password, image, rch, wtgrp)
+		}, printImageTableHeader)
+	}
+
 	username, password := getUsernameAndPassword(config.User)
 	imageErr := make(chan stringResult)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	var wg sync.WaitGroup
 
@@ -75,7 +190,7 @@ func SearchImageByName(config SearchConfig, image string) error {
 	wg.Add(1)
 
 	errCh := make(chan error, 1)
-	go collectResults(config, &wg, imageErr, cancel, printImageTableHeader, errCh)
+	go collectResults(ctx, config, &wg, imageErr, cancel, printImageTableHeader, errCh)
 
 	wg.Wait()
 
@@ -93,11 +208,34 @@ func SearchImageByName(config SearchConfig, image string) error {
 
 func SearchImageByNameGQL(config SearchConfig, imageName string) error {
 	username, password := getUsernameAndPassword(config.User)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	defer cancel()
 
 	repo, tag := zcommon.GetImageDirAndTag(imageName)
+	digest := ""
+
+	if name, reference, found := strings.Cut(imageName, "@"); found {
+		repo, tag, digest = name, "", reference
+	}
+
+	if config.NameRegex {
+		repo, tag, digest = imageName, "", ""
+	}
+
+	matchesRepo := func(string) bool { return true }
+
+	if config.NameRegex || isGlobPattern(repo) {
+		var err error
+
+		matchesRepo, err = getRepoNameMatcher(repo, config.NameRegex)
+		if err != nil {
+			return err
+		}
+
+		// the images of all the repos are listed and filtered here
+		repo = ""
+	}
 
 	imageList, err := config.SearchService.getImagesGQL(ctx, config, username, password, repo)
 	if err != nil {
@@ -107,18 +245,18 @@ func SearchImageByNameGQL(config SearchConfig, imageName string) error {
 	imageListData := []imageStruct{}
 
 	for _, image := range imageList.Results {
-		if tag == "" || image.Tag == tag {
+		if matchesRepo(image.RepoName) && matchesImageTag(image.Tag, tag) && (digest == "" || image.Digest == digest) {
 			imageListData = append(imageListData, imageStruct(image))
 		}
 	}
 
-	return printImageResult(config, imageListData)
+	return printImageResult(ctx, config, imageListData)
 }
 
 func SearchImagesByDigest(config SearchConfig, digest string) error {
 	username, password := getUsernameAndPassword(config.User)
 	imageErr := make(chan stringResult)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	var wg sync.WaitGroup
 
@@ -129,7 +267,7 @@ func SearchImagesByDigest(config SearchConfig, digest string) error {
 	wg.Add(1)
 
 	errCh := make(chan error, 1)
-	go collectResults(config, &wg, imageErr, cancel, printImageTableHeader, errCh)
+	go collectResults(ctx, config, &wg, imageErr, cancel, printImageTableHeader, errCh)
 
 	wg.Wait()
 
@@ -143,7 +281,7 @@ func SearchImagesByDigest(config SearchConfig, digest string) error {
 
 func SearchDerivedImageListGQL(config SearchConfig, derivedImage string) error {
 	username, password := getUsernameAndPassword(config.User)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	defer cancel()
 
@@ -159,12 +297,12 @@ func SearchDerivedImageListGQL(config SearchConfig, derivedImage string) error {
 		imageListData = append(imageListData, imageStruct(image))
 	}
 
-	return printImageResult(config, imageListData)
+	return printImageResult(ctx, config, imageListData)
 }
 
 func SearchBaseImageListGQL(config SearchConfig, baseImage string) error {
 	username, password := getUsernameAndPassword(config.User)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	defer cancel()
 
@@ -180,12 +318,12 @@ func SearchBaseImageListGQL(config SearchConfig, baseImage string) error {
 		imageListData = append(imageListData, imageStruct(image))
 	}
 
-	return printImageResult(config, imageListData)
+	return printImageResult(ctx, config, imageListData)
 }
 
 func SearchImagesForDigestGQL(config SearchConfig, digest string) error {
 	username, password := getUsernameAndPassword(config.User)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	defer cancel()
 
@@ -200,7 +338,7 @@ func SearchImagesForDigestGQL(config SearchConfig, digest string) error {
 		imageListData = append(imageListData, imageStruct(image))
 	}
 
-	if err := printImageResult(config, imageListData); err != nil {
+	if err := printImageResult(ctx, config, imageListData); err != nil {
 		return err
 	}
 
@@ -209,7 +347,7 @@ func SearchImagesForDigestGQL(config SearchConfig, digest string) error {
 
 func SearchCVEForImageGQL(config SearchConfig, image, searchedCveID string) error {
 	username, password := getUsernameAndPassword(config.User)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	defer cancel()
 
@@ -269,7 +407,7 @@ func SearchCVEForImageGQL(config SearchConfig, image, searchedCveID string) erro
 
 func SearchImagesByCVEIDGQL(config SearchConfig, repo, cveid string) error {
 	username, password := getUsernameAndPassword(config.User)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	defer cancel()
 
@@ -303,12 +441,12 @@ func SearchImagesByCVEIDGQL(config SearchConfig, repo, cveid string) error {
 		imageListData = append(imageListData, imageStruct(image))
 	}
 
-	return printImageResult(config, imageListData)
+	return printImageResult(ctx, config, imageListData)
 }
 
 func SearchFixedTagsGQL(config SearchConfig, repo, cveid string) error {
 	username, password := getUsernameAndPassword(config.User)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	defer cancel()
 
@@ -342,12 +480,12 @@ func SearchFixedTagsGQL(config SearchConfig, repo, cveid string) error {
 		imageList = append(imageList, imageStruct(image))
 	}
 
-	return printImageResult(config, imageList)
+	return printImageResult(ctx, config, imageList)
 }
 
 func GlobalSearchGQL(config SearchConfig, query string) error {
 	username, password := getUsernameAndPassword(config.User)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	defer cancel()
 
@@ -368,7 +506,7 @@ func GlobalSearchGQL(config SearchConfig, query string) error {
 		reposList = append(reposList, repoStruct(repo))
 	}
 
-	if err := printImageResult(config, imagesList); err != nil {
+	if err := printImageResult(ctx, config, imagesList); err != nil {
 		return err
 	}
 
@@ -392,7 +530,10 @@ func SearchReferrersGQL(config SearchConfig, subject string) error {
 		}
 	}
 
-	response, err := config.SearchService.getReferrersGQL(context.Background(), config, username, password, repo, digest)
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	response, err := config.SearchService.getReferrersGQL(ctx, config, username, password, repo, digest)
 	if err != nil {
 		return err
 	}
@@ -429,8 +570,10 @@ func SearchReferrers(config SearchConfig, subject string) error {
 		}
 	}
 
-	referrersList, err := config.SearchService.getReferrers(context.Background(), config, username, password,
-		repo, digest)
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	referrersList, err := config.SearchService.getReferrers(ctx, config, username, password, repo, digest)
 	if err != nil {
 		return err
 	}
@@ -451,7 +594,7 @@ func SearchReferrers(config SearchConfig, subject string) error {
 func SearchRepos(config SearchConfig) error {
 	username, password := getUsernameAndPassword(config.User)
 	repoErr := make(chan stringResult)
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := newSearchContext(config)
 
 	var wg sync.WaitGroup
 
@@ -462,7 +605,7 @@ func SearchRepos(config SearchConfig) error {
 
 	errCh := make(chan error, 1)
 
-	go collectResults(config, &wg, repoErr, cancel, printImageTableHeader, errCh)
+	go collectResults(ctx, config, &wg, repoErr, cancel, printImageTableHeader, errCh)
 	wg.Wait()
 	select {
 	case err := <-errCh:
@@ -471,3 +614,979 @@ func SearchRepos(config SearchConfig) error {
 		return nil
 	}
 }
+
+func SearchRepoStats(config SearchConfig) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	config.Spinner.startSpinner()
+	config.Progress.start()
+
+	repoStatsList, err := config.SearchService.getRepoStats(ctx, config, username, password)
+
+	config.Spinner.stopSpinner()
+	config.Progress.stop()
+
+	if err != nil {
+		return err
+	}
+
+	if config.SortBy == SortByAlphabeticDsc {
+		slices.Reverse(repoStatsList)
+	}
+
+	return printRepoStatsResults(config, repoStatsList)
+}
+
+func ShowStorageStats(config SearchConfig) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	config.Spinner.startSpinner()
+	config.Progress.start()
+
+	stats, err := config.SearchService.getStorageStats(ctx, config, username, password)
+
+	config.Spinner.stopSpinner()
+	config.Progress.stop()
+
+	if err != nil {
+		return err
+	}
+
+	out, err := stats.string(config.OutputFormat)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(config.ResultWriter, out)
+
+	return nil
+}
+
+// InspectImage prints the manifest, the config and the layers of the given tag or manifest.
+func InspectImage(config SearchConfig, image string) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	repo, ref, _, err := zcommon.GetRepoReference(image)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	config.Spinner.startSpinner()
+
+	inspected, err := config.SearchService.inspectImage(ctx, config, username, password, repo, ref)
+
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return err
+	}
+
+	out, err := inspected.string(config.OutputFormat)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(config.ResultWriter, out)
+
+	return nil
+}
+
+// VerifyImage downloads the manifests and blobs of the image and checks them against their digests and
+// sizes. The mismatches are printed and make it fail.
+func VerifyImage(config SearchConfig, image string) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	repo, ref, _, err := zcommon.GetRepoReference(image)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	config.Spinner.startSpinner()
+
+	verified, err := config.SearchService.verifyImage(ctx, config, username, password, repo, ref)
+
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return err
+	}
+
+	out, err := verified.string(config.OutputFormat)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(config.ResultWriter, out)
+
+	if len(verified.Mismatches) > 0 {
+		return fmt.Errorf("%w: %d mismatches in %s", zerr.ErrImageVerificationFailed, len(verified.Mismatches),
+			zcommon.GetFullImageName(repo, ref))
+	}
+
+	return nil
+}
+
+// CheckImageUpdates compares the digests of the images with the ones of the images they're checked against,
+// in the registry of againstConfig or, if a pin file is given, in this file. Without images, all the pinned
+// ones are checked. All the results are shown, then it fails if any of the digests differ or is missing.
+func CheckImageUpdates(config, againstConfig SearchConfig, images []imageToCheck, pinFile string) error {
+	var pins imagePins
+
+	if pinFile != "" {
+		var err error
+
+		pins, err = readImagePins(pinFile)
+		if err != nil {
+			return err
+		}
+
+		if len(images) == 0 {
+			for image := range pins {
+				images = append(images, imageToCheck{image: image, against: image})
+			}
+
+			slices.SortFunc(images, func(a, b imageToCheck) int {
+				return cmp.Compare(a.image, b.image)
+			})
+		}
+	}
+
+	if len(images) == 0 {
+		return fmt.Errorf("%w: no image to check", zerr.ErrInvalidCLIParameter)
+	}
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	config.Spinner.startSpinner()
+
+	checks, err := checkImageUpdates(ctx, config, againstConfig, images, pins, pinFile)
+
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return err
+	}
+
+	out, err := checks.string(config.OutputFormat, config.NoTrunc)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(config.ResultWriter, out)
+
+	outdated := 0
+
+	for _, check := range checks {
+		if check.Status != updateCheckSame {
+			outdated++
+		}
+	}
+
+	if outdated > 0 {
+		return fmt.Errorf("%w: %d of %d", zerr.ErrImagesNotUpToDate, outdated, len(checks))
+	}
+
+	return nil
+}
+
+func checkImageUpdates(ctx context.Context, config, againstConfig SearchConfig, images []imageToCheck,
+	pins imagePins, pinFile string,
+) (imageUpdateChecks, error) {
+	checks := imageUpdateChecks{}
+
+	for _, image := range images {
+		digest, err := getCheckedImageDigest(ctx, config, image.image)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", image.image, err)
+		}
+
+		check := imageUpdateCheck{Image: image.image, Digest: digest}
+
+		if pins != nil {
+			check.Against = pinFile
+			check.AgainstDigest = pins[image.against]
+		} else {
+			repo, reference, _, err := zcommon.GetRepoReference(image.against)
+			if err != nil {
+				return nil, err
+			}
+
+			check.Against = getCopyDestName(config, againstConfig, repo, reference)
+
+			check.AgainstDigest, err = getCheckedImageDigest(ctx, againstConfig, image.against)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check %s: %w", check.Against, err)
+			}
+		}
+
+		check.Status = getUpdateCheckStatus(check.Digest, check.AgainstDigest)
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// getCheckedImageDigest returns the digest of the image, or an empty one if the registry doesn't have it.
+func getCheckedImageDigest(ctx context.Context, config SearchConfig, image string) (string, error) {
+	username, password := getUsernameAndPassword(config.User)
+
+	repo, reference, _, err := zcommon.GetRepoReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	digest, err := config.SearchService.getManifestDigest(ctx, config, username, password, repo, reference)
+	if errors.Is(err, zerr.ErrURLNotFound) {
+		return "", nil
+	}
+
+	return digest, err
+}
+
+// GetSBOMs prints a summary of the sboms attached to the given tag or manifest, or the sboms as they
+// are stored if raw is set. If sbomType is given, only the sboms of this format are shown.
+func GetSBOMs(config SearchConfig, image, sbomType string, raw bool) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	repo, ref, refIsTag, err := zcommon.GetRepoReference(image)
+	if err != nil {
+		return err
+	}
+
+	sbomType = strings.ToLower(sbomType)
+
+	if sbomType != "" && sbomType != spdxFormat && sbomType != cycloneDXFormat {
+		return fmt.Errorf("%w: --%s should be %s or %s", zerr.ErrInvalidCLIParameter, SBOMTypeFlag, spdxFormat,
+			cycloneDXFormat)
+	}
+
+	digest := ref
+
+	if refIsTag {
+		digest, err = fetchImageDigest(repo, ref, username, password, config)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	config.Spinner.startSpinner()
+
+	documents, err := config.SearchService.getSBOMs(ctx, config, username, password, repo, digest)
+
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return err
+	}
+
+	if sbomType != "" {
+		documents = slices.DeleteFunc(documents, func(document sbomDocument) bool {
+			return document.format != sbomType
+		})
+	}
+
+	if len(documents) == 0 {
+		return fmt.Errorf("%w: %s", zerr.ErrSBOMNotFound, image)
+	}
+
+	if raw {
+		for _, document := range documents {
+			content := string(document.content)
+			if !strings.HasSuffix(content, "\n") {
+				content += "\n"
+			}
+
+			fmt.Fprint(config.ResultWriter, content)
+		}
+
+		return nil
+	}
+
+	summaries := make(sbomSummaries, 0, len(documents))
+
+	for _, document := range documents {
+		summary, err := getSBOMSummary(document)
+		if err != nil {
+			return err
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	out, err := summaries.string(config.OutputFormat)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(config.ResultWriter, out)
+
+	return nil
+}
+
+// ListArtifacts lists the artifacts attached to the image: its referrers and the artifacts
+// attached by cosign with a tag. If artifactType is given only the artifacts of this type are listed.
+func ListArtifacts(config SearchConfig, image, artifactType string) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	repo, ref, refIsTag, err := zcommon.GetRepoReference(image)
+	if err != nil {
+		return err
+	}
+
+	digest := ref
+
+	if refIsTag {
+		digest, err = fetchImageDigest(repo, ref, username, password, config)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	config.Spinner.startSpinner()
+
+	artifacts, err := config.SearchService.getArtifacts(ctx, config, username, password, repo, digest, artifactType)
+
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return err
+	}
+
+	out, err := artifacts.string(config.OutputFormat)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(config.ResultWriter, out)
+
+	return nil
+}
+
+// ShowRepoActivity prints the activity log of the repository, only the events of the reference if one is given.
+func ShowRepoActivity(config SearchConfig, repo, reference string) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	config.Spinner.startSpinner()
+
+	response, err := config.SearchService.getRepoActivityGQL(ctx, config, username, password, repo, reference)
+
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return err
+	}
+
+	activity := repoActivityStruct{RepoName: repo, Events: response.RepoActivity}
+
+	out, err := activity.string(config.OutputFormat)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(config.ResultWriter, out)
+
+	return nil
+}
+
+// SearchUserRepos lists the repositories starred by the user, or bookmarked.
+func SearchUserRepos(config SearchConfig, bookmarked bool) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	config.Spinner.startSpinner()
+
+	var (
+		repos *zcommon.PaginatedReposResult
+		err   error
+	)
+
+	if bookmarked {
+		repos, err = config.SearchService.getBookmarkedReposGQL(ctx, config, username, password)
+	} else {
+		repos, err = config.SearchService.getStarredReposGQL(ctx, config, username, password)
+	}
+
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return err
+	}
+
+	reposList := []repoStruct{}
+
+	for _, repo := range repos.Results {
+		reposList = append(reposList, repoStruct(repo))
+	}
+
+	return printRepoResults(config, reposList)
+}
+
+// SetRepoStarred stars the repository for the user, or removes the star, unless it already is.
+func SetRepoStarred(config SearchConfig, repo string, starred bool) error {
+	return setRepoPreference(config, repo, toggleStarAction, starred)
+}
+
+// SetRepoBookmarked bookmarks the repository for the user, or removes the bookmark, unless it already is.
+func SetRepoBookmarked(config SearchConfig, repo string, bookmarked bool) error {
+	return setRepoPreference(config, repo, toggleBookmarkAction, bookmarked)
+}
+
+// setRepoPreference sets a preference the userprefs extension only toggles, so its current value is checked first.
+func setRepoPreference(config SearchConfig, repo, action string, enabled bool) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	summary, err := config.SearchService.getRepoSummaryGQL(ctx, config, username, password, repo)
+	if err != nil {
+		return err
+	}
+
+	isSet, preference := summary.IsStarred, "starred"
+	if action == toggleBookmarkAction {
+		isSet, preference = summary.IsBookmarked, "bookmarked"
+	}
+
+	if isSet == enabled {
+		if enabled {
+			fmt.Fprintf(config.ResultWriter, "%s is already %s\n", repo, preference)
+		} else {
+			fmt.Fprintf(config.ResultWriter, "%s isn't %s\n", repo, preference)
+		}
+
+		return nil
+	}
+
+	if err := config.SearchService.toggleRepoPreference(ctx, config, username, password, repo, action); err != nil {
+		return err
+	}
+
+	if enabled {
+		fmt.Fprintf(config.ResultWriter, "%s is now %s\n", repo, preference)
+	} else {
+		fmt.Fprintf(config.ResultWriter, "%s is no longer %s\n", repo, preference)
+	}
+
+	return nil
+}
+
+// ShowImageLayers prints the layers of the given tag or manifest, with the number of the other repos
+// sharing each of them.
+func ShowImageLayers(config SearchConfig, image string) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	config.Spinner.startSpinner()
+
+	response, err := config.SearchService.getImageLayersGQL(ctx, config, username, password, image)
+
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return err
+	}
+
+	layers, err := getImageLayersStruct(image, response.ImageLayers)
+	if err != nil {
+		return err
+	}
+
+	out, err := layers.string(config.OutputFormat, config.NoTrunc)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(config.ResultWriter, out)
+
+	return nil
+}
+
+// ShowImageHistory prints the history of the layers of the given tag or manifest, only the one
+// of the image of the platform if one is given.
+func ShowImageHistory(config SearchConfig, image, platform string) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	repo, ref, _, err := zcommon.GetRepoReference(image)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	config.Spinner.startSpinner()
+
+	history, err := config.SearchService.getImageHistory(ctx, config, username, password, repo, ref)
+
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return err
+	}
+
+	if platform != "" {
+		if err := history.filterPlatform(platform); err != nil {
+			return err
+		}
+	}
+
+	out, err := history.string(config.OutputFormat, config.NoTrunc)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(config.ResultWriter, out)
+
+	return nil
+}
+
+// DiffImages prints the layers the two images have in common and the ones only one of them has.
+func DiffImages(config SearchConfig, fromImage, toImage, platform string) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	fromRepo, fromRef, _, err := zcommon.GetRepoReference(fromImage)
+	if err != nil {
+		return err
+	}
+
+	toRepo, toRef, _, err := zcommon.GetRepoReference(toImage)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	config.Spinner.startSpinner()
+
+	diff, err := config.SearchService.diffImages(ctx, config, username, password, fromRepo, fromRef, toRepo, toRef,
+		platform)
+
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return err
+	}
+
+	out, err := diff.string(config.OutputFormat, config.NoTrunc)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(config.ResultWriter, out)
+
+	return nil
+}
+
+// DeleteImages deletes the given tag or manifest from the registry, the tag can also be a glob
+// pattern in which case all the matching tags are deleted. Unless force is set, the user is asked
+// to confirm the list of images on the confirmation reader before anything gets deleted.
+func DeleteImages(config SearchConfig, image string, force bool, confirmation io.Reader) error {
+	username, password := getUsernameAndPassword(config.User)
+	ctx := context.Background()
+
+	repo, ref, refIsTag, err := zcommon.GetRepoReference(image)
+	if err != nil {
+		return err
+	}
+
+	references := []string{ref}
+
+	if refIsTag && isGlobPattern(ref) {
+		tags, err := config.SearchService.getTags(ctx, config, username, password, repo)
+		if err != nil {
+			return err
+		}
+
+		references, err = filterTagsByPattern(tags, ref)
+		if err != nil {
+			return err
+		}
+
+		if len(references) == 0 {
+			return fmt.Errorf("%w: no tags of '%s' match '%s'", zerr.ErrManifestNotFound, repo, ref)
+		}
+	}
+
+	if !force {
+		fmt.Fprintln(config.ResultWriter, "The following images will be deleted:")
+
+		for _, reference := range references {
+			fmt.Fprintln(config.ResultWriter, "  "+zcommon.GetFullImageName(repo, reference))
+		}
+
+		if !askForConfirmation(config.ResultWriter, confirmation) {
+			fmt.Fprintln(config.ResultWriter, "Aborted")
+
+			return nil
+		}
+	}
+
+	for _, reference := range references {
+		err := config.SearchService.deleteImage(ctx, config, username, password, repo, reference)
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %w", zcommon.GetFullImageName(repo, reference), err)
+		}
+
+		fmt.Fprintln(config.ResultWriter, "Deleted "+zcommon.GetFullImageName(repo, reference))
+	}
+
+	return nil
+}
+
+// CopyImages copies the given tag or manifest, with all its blobs and for an index all its images, to the
+// destination repo on the registry of destConfig. The destination tag defaults to the source one.
+// With allTags, the source is a repo of which all the tags are copied under the same names.
+// CopyImages copies the image, or all the tags of the repo, to the destination. With ifNewer or missingOnly,
+// the images the destination doesn't need are skipped and a summary of the copied and skipped ones is shown.
+func CopyImages(config, destConfig SearchConfig, source, dest string, allTags, ifNewer, missingOnly bool) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	destRepo, destTag := zcommon.GetImageDirAndTag(dest)
+
+	if ifNewer && missingOnly {
+		return fmt.Errorf("%w: --%s and --%s can't be used together", zerr.ErrInvalidCLIParameter,
+			IfNewerFlag, MissingOnlyFlag)
+	}
+
+	var (
+		repo       string
+		references []string
+	)
+
+	if allTags {
+		if destTag != "" {
+			return fmt.Errorf("%w: the destination can't have a tag with --%s", zerr.ErrInvalidCLIParameter,
+				AllTagsFlag)
+		}
+
+		repo = source
+
+		tags, err := config.SearchService.getTags(ctx, config, username, password, repo)
+		if err != nil {
+			return err
+		}
+
+		references = tags
+	} else {
+		var (
+			ref string
+			err error
+		)
+
+		repo, ref, _, err = zcommon.GetRepoReference(source)
+		if err != nil {
+			return err
+		}
+
+		references = []string{ref}
+	}
+
+	copied, skipped := 0, 0
+
+	for _, reference := range references {
+		destReference := reference
+		if destTag != "" {
+			destReference = destTag
+		}
+
+		if ifNewer || missingOnly {
+			reason, err := getCopySkipReason(ctx, config, destConfig, username, password, repo, reference,
+				destRepo, destReference, ifNewer)
+			if err != nil {
+				return fmt.Errorf("failed to compare %s to %s: %w", zcommon.GetFullImageName(repo, reference),
+					getCopyDestName(config, destConfig, destRepo, destReference), err)
+			}
+
+			if reason != "" {
+				skipped++
+
+				fmt.Fprintf(config.ResultWriter, "Skipped %s: %s\n", zcommon.GetFullImageName(repo, reference), reason)
+
+				continue
+			}
+		}
+
+		err := config.SearchService.copyImage(ctx, config, username, password, repo, reference,
+			destConfig, destRepo, destReference)
+		if err != nil {
+			return fmt.Errorf("failed to copy %s: %w", zcommon.GetFullImageName(repo, reference), err)
+		}
+
+		copied++
+
+		fmt.Fprintf(config.ResultWriter, "Copied %s to %s\n", zcommon.GetFullImageName(repo, reference),
+			getCopyDestName(config, destConfig, destRepo, destReference))
+	}
+
+	if ifNewer || missingOnly {
+		fmt.Fprintf(config.ResultWriter, "%d copied, %d skipped\n", copied, skipped)
+	}
+
+	return nil
+}
+
+// getCopySkipReason returns why the destination doesn't need the image, or an empty string if it does.
+// With missing-only, the destination doesn't need it as soon as it has the reference, else only if it has
+// the same image or one created at the same time or later.
+func getCopySkipReason(ctx context.Context, config, destConfig SearchConfig, username, password,
+	repo, reference, destRepo, destReference string, ifNewer bool,
+) (string, error) {
+	destUsername, destPassword := getUsernameAndPassword(destConfig.User)
+	destName := getCopyDestName(config, destConfig, destRepo, destReference)
+
+	destDigest, err := config.SearchService.getManifestDigest(ctx, destConfig, destUsername, destPassword,
+		destRepo, destReference)
+	if errors.Is(err, zerr.ErrURLNotFound) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if !ifNewer {
+		return destName + " already exists", nil
+	}
+
+	digest, err := config.SearchService.getManifestDigest(ctx, config, username, password, repo, reference)
+	if err != nil {
+		return "", err
+	}
+
+	if digest == destDigest {
+		return destName + " is up to date", nil
+	}
+
+	created, err := config.SearchService.getImageCreated(ctx, config, username, password, repo, reference)
+	if err != nil {
+		return "", err
+	}
+
+	destCreated, err := config.SearchService.getImageCreated(ctx, destConfig, destUsername, destPassword,
+		destRepo, destReference)
+	if err != nil {
+		return "", err
+	}
+
+	if !created.After(destCreated) {
+		return fmt.Sprintf("%s isn't older, created %s against %s", destName, getCreatedStr(destCreated),
+			getCreatedStr(created)), nil
+	}
+
+	return "", nil
+}
+
+func PullImage(config SearchConfig, image, layoutPath string) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	repo, reference, _, err := zcommon.GetRepoReference(image)
+	if err != nil {
+		return err
+	}
+
+	config.Spinner.startSpinner()
+	err = config.SearchService.pullImage(ctx, config, username, password, repo, reference, layoutPath)
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", zcommon.GetFullImageName(repo, reference), err)
+	}
+
+	fmt.Fprintf(config.ResultWriter, "Pulled %s to %s\n", zcommon.GetFullImageName(repo, reference), layoutPath)
+
+	return nil
+}
+
+func PushImage(config SearchConfig, layoutPath, image string, chunkSize int64) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	repo, tag := zcommon.GetImageDirAndTag(image)
+
+	config.Spinner.startSpinner()
+	tag, err := config.SearchService.pushImage(ctx, config, username, password, layoutPath, repo, tag, chunkSize)
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return fmt.Errorf("failed to push %s: %w", layoutPath, err)
+	}
+
+	fmt.Fprintf(config.ResultWriter, "Pushed %s to %s\n", layoutPath, zcommon.GetFullImageName(repo, tag))
+
+	return nil
+}
+
+// AnnotateImage sets the key=value annotations and removes the given keys from the manifest of the image,
+// then pushes the new manifest under the tag, the one of the image if none is given.
+func AnnotateImage(config SearchConfig, image string, args, removed []string, tag string) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	repo, reference, isTag, err := zcommon.GetRepoReference(image)
+	if err != nil {
+		return err
+	}
+
+	annotations, err := parseAnnotations(args)
+	if err != nil {
+		return err
+	}
+
+	if len(annotations) == 0 && len(removed) == 0 {
+		return fmt.Errorf("%w: no annotation to set or remove", zerr.ErrInvalidCLIParameter)
+	}
+
+	if tag == "" {
+		// pushing the new manifest by its digest would leave it untagged
+		if !isTag {
+			return fmt.Errorf("%w: --%s is required to annotate an image referenced by digest",
+				zerr.ErrInvalidCLIParameter, TagFlag)
+		}
+
+		tag = reference
+	}
+
+	config.Spinner.startSpinner()
+	digest, err := config.SearchService.annotateImage(ctx, config, username, password, repo, reference,
+		annotations, removed, tag)
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return fmt.Errorf("failed to annotate %s: %w", zcommon.GetFullImageName(repo, reference), err)
+	}
+
+	fmt.Fprintf(config.ResultWriter, "Annotated %s as %s@%s\n", zcommon.GetFullImageName(repo, reference),
+		zcommon.GetFullImageName(repo, tag), digest)
+
+	return nil
+}
+
+// TagImage adds the tags to the image, in its repo.
+func TagImage(config SearchConfig, image string, tags []string) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	repo, reference, _, err := zcommon.GetRepoReference(image)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if tag == "" || !zcommon.IsTag(tag) || !zcommon.CheckIsCorrectRepoNameFormat(tag) {
+			return fmt.Errorf("%w: %q is not a tag", zerr.ErrInvalidCLIParameter, tag)
+		}
+	}
+
+	for _, tag := range tags {
+		config.Spinner.startSpinner()
+		digest, err := config.SearchService.tagImage(ctx, config, username, password, repo, reference, tag)
+		config.Spinner.stopSpinner()
+
+		if err != nil {
+			return fmt.Errorf("failed to tag %s as %s: %w", zcommon.GetFullImageName(repo, reference),
+				zcommon.GetFullImageName(repo, tag), err)
+		}
+
+		fmt.Fprintf(config.ResultWriter, "Tagged %s as %s@%s\n", zcommon.GetFullImageName(repo, reference),
+			zcommon.GetFullImageName(repo, tag), digest)
+	}
+
+	return nil
+}
+
+// UntagImage removes the tag from the repo, the image is kept as long as it has other tags.
+func UntagImage(config SearchConfig, image string) error {
+	username, password := getUsernameAndPassword(config.User)
+
+	ctx, cancel := newSearchContext(config)
+	defer cancel()
+
+	repo, reference, isTag, err := zcommon.GetRepoReference(image)
+	if err != nil {
+		return err
+	}
+
+	if !isTag {
+		return fmt.Errorf("%w: %s is not a tag, use 'zli image delete' to delete an image by digest",
+			zerr.ErrInvalidCLIParameter, image)
+	}
+
+	config.Spinner.startSpinner()
+	err = config.SearchService.deleteImage(ctx, config, username, password, repo, reference)
+	config.Spinner.stopSpinner()
+
+	if err != nil {
+		return fmt.Errorf("failed to untag %s: %w", zcommon.GetFullImageName(repo, reference), err)
+	}
+
+	fmt.Fprintln(config.ResultWriter, "Untagged "+zcommon.GetFullImageName(repo, reference))
+
+	return nil
+}
+
+// parseAnnotations parses the key=value arguments, a value may be empty but not a key.
+func parseAnnotations(args []string) (map[string]string, error) {
+	annotations := make(map[string]string, len(args))
+
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("%w: %q is not a key=value annotation", zerr.ErrInvalidCLIParameter, arg)
+		}
+
+		annotations[key] = value
+	}
+
+	return annotations, nil
+}
+
+// getCopyDestName prefixes the destination image with its registry, if it isn't the source one.
+func getCopyDestName(config, destConfig SearchConfig, repo, reference string) string {
+	name := zcommon.GetFullImageName(repo, reference)
+
+	if destConfig.registryURL() == config.registryURL() {
+		return name
+	}
+
+	return strings.TrimSuffix(destConfig.registryURL(), "/") + "/" + name
+}
@@ -8,11 +8,16 @@ package client
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"regexp"
 	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
@@ -32,7 +37,7 @@ func TestSearchAllImages(t *testing.T) {
 			getAllImagesFn: func(ctx context.Context, config SearchConfig, username, password string,
 				channel chan stringResult, wtgrp *sync.WaitGroup,
 			) {
-				str, err := getMockImageStruct().stringPlainText(10, 10, 10, false)
+				str, err := getMockImageStruct().stringPlainText(10, 10, 10, false, imageLayout{})
 
 				channel <- stringResult{StrValue: str, Err: err}
 			},
@@ -94,7 +99,7 @@ func TestSearchImageByName(t *testing.T) {
 			getImageByNameFn: func(ctx context.Context, config SearchConfig, username string, password string, imageName string,
 				channel chan stringResult, wtgrp *sync.WaitGroup,
 			) {
-				str, err := getMockImageStruct().stringPlainText(10, 10, 10, false)
+				str, err := getMockImageStruct().stringPlainText(10, 10, 10, false, imageLayout{})
 
 				channel <- stringResult{StrValue: str, Err: err}
 			},
@@ -170,7 +175,7 @@ func TestSearchImagesByDigest(t *testing.T) {
 			getImagesByDigestFn: func(ctx context.Context, config SearchConfig, username string, password string, digest string,
 				rch chan stringResult, wtgrp *sync.WaitGroup,
 			) {
-				str, err := getMockImageStruct().stringPlainText(10, 10, 10, false)
+				str, err := getMockImageStruct().stringPlainText(10, 10, 10, false, imageLayout{})
 
 				rch <- stringResult{StrValue: str, Err: err}
 			},
@@ -664,6 +669,470 @@ func getMockSearchConfig(buff *bytes.Buffer, mockService mockService) SearchConf
 	}
 }
 
+func TestImageColumns(t *testing.T) {
+	Convey("Image columns", t, func() {
+		Convey("parse", func() {
+			columns, err := parseImageColumns("")
+			So(err, ShouldBeNil)
+			So(columns, ShouldBeNil)
+			So(columns.get(), ShouldResemble, defaultImageColumns())
+
+			columns, err = parseImageColumns("repository, Created,author,os/arch")
+			So(err, ShouldBeNil)
+			So(columns, ShouldResemble, imageColumns{colImageNameIndex, colCreatedIndex, colAuthorIndex,
+				colPlatformIndex})
+
+			_, err = parseImageColumns("repository,unknown")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "unknown")
+		})
+
+		Convey("render the selected columns", func() {
+			buff := bytes.NewBufferString("")
+			searchConfig := getMockSearchConfig(buff, mockService{
+				getImagesGQLFn: func(ctx context.Context, config SearchConfig, username, password, imageName string,
+				) (*common.ImageListResponse, error) {
+					imageSummary := getMockImageSummary()
+					imageSummary.Authors = "author"
+					imageSummary.Manifests[0].LastUpdated = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+					return &common.ImageListResponse{ImageList: common.ImageList{
+						PaginatedImagesResult: common.PaginatedImagesResult{
+							Results: []common.ImageSummary{imageSummary},
+						},
+					}}, nil
+				},
+			})
+
+			searchConfig.ImageColumns = imageColumns{colTagIndex, colCreatedIndex, colAuthorIndex, colPlatformIndex}
+
+			err := SearchAllImagesGQL(searchConfig)
+			So(err, ShouldBeNil)
+			space := regexp.MustCompile(`\s+`)
+			actual := strings.TrimSpace(space.ReplaceAllString(buff.String(), " "))
+			So(actual, ShouldEqual, "TAG CREATED AUTHOR OS/ARCH tag 2024-01-02T03:04:05Z author os/arch")
+
+			Convey("unknown creation time", func() {
+				str, err := getMockImageStruct().stringPlainText(10, 10, 10, false,
+					imageLayout{columns: imageColumns{colImageNameIndex, colCreatedIndex}})
+				So(err, ShouldBeNil)
+				So(strings.Fields(str), ShouldResemble, []string{"repo", "N/A"})
+			})
+		})
+	})
+}
+
+func TestImageTemplateFormat(t *testing.T) {
+	Convey("Go template output format", t, func() {
+		buff := bytes.NewBufferString("")
+		searchConfig := getMockSearchConfig(buff, mockService{
+			getImagesGQLFn: func(ctx context.Context, config SearchConfig, username, password, imageName string,
+			) (*common.ImageListResponse, error) {
+				return &common.ImageListResponse{ImageList: common.ImageList{
+					PaginatedImagesResult: common.PaginatedImagesResult{
+						Results: []common.ImageSummary{getMockImageSummary(), getMockImageSummary()},
+					},
+				}}, nil
+			},
+		})
+
+		searchConfig.OutputFormat = `{{.RepoName}}:{{.Tag}} {{(index .Manifests 0).Platform | json}}`
+
+		err := SearchAllImagesGQL(searchConfig)
+		So(err, ShouldBeNil)
+		So(buff.String(), ShouldEqual, `repo:tag {"os":"os","arch":"arch","variant":""}`+"\n"+
+			`repo:tag {"os":"os","arch":"arch","variant":""}`+"\n")
+
+		Convey("unknown fields", func() {
+			_, err := getMockImageStruct().string("{{.Missing}}", 0, 0, 0, false, imageLayout{})
+			So(errors.Is(err, zerr.ErrInvalidOutputFormat), ShouldBeTrue)
+		})
+
+		Convey("bad templates are reported before the requests", func() {
+			_, err := getImageListSearchConfig("--url", "http://127.0.0.1:8080", "-f", "{{.RepoName")
+			So(errors.Is(err, zerr.ErrInvalidOutputFormat), ShouldBeTrue)
+		})
+	})
+}
+
+func TestImageSeparatedValuesFormat(t *testing.T) {
+	Convey("csv and tsv output formats", t, func() {
+		buff := bytes.NewBufferString("")
+		searchConfig := getMockSearchConfig(buff, mockService{
+			getImagesGQLFn: func(ctx context.Context, config SearchConfig, username, password, imageName string,
+			) (*common.ImageListResponse, error) {
+				imageSummary := getMockImageSummary()
+				imageSummary.Authors = "Doe, John"
+				imageSummary.Manifests[0].Layers = []common.LayerSummary{{Digest: "sha256:a"}, {Digest: "sha256:b"}}
+				imageSummary.Manifests[0].DownloadCount = 3
+				lastPull := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+				imageSummary.Manifests[0].LastPullTimestamp = &lastPull
+
+				return &common.ImageListResponse{ImageList: common.ImageList{
+					PaginatedImagesResult: common.PaginatedImagesResult{
+						Results: []common.ImageSummary{imageSummary},
+					},
+				}}, nil
+			},
+		})
+
+		digest := godigest.FromString("str").String()
+
+		Convey("csv with all the columns", func() {
+			searchConfig.OutputFormat = "csv"
+
+			err := SearchAllImagesGQL(searchConfig)
+			So(err, ShouldBeNil)
+			So(buff.String(), ShouldEqual, "repository,tag,os/arch,digest,config,signed,layers,size,created,author,signer,"+
+				"pulls,last pull\n"+
+				"repo,tag,os/arch,"+digest+","+digest+",false,sha256:a sha256:b,100,,\"Doe, John\",,3,2024-01-02T03:04:05Z\n")
+		})
+
+		Convey("verbose text with the pull statistics", func() {
+			image := imageStruct(getMockImageSummary())
+			image.Manifests[0].DownloadCount = 3
+			lastPull := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+			image.Manifests[0].LastPullTimestamp = &lastPull
+
+			str, err := image.stringPlainText(10, 10, 10, true, imageLayout{
+				columns: imageColumns{colImageNameIndex, colSizeIndex, colPullsIndex, colLastPullIndex},
+			})
+			So(err, ShouldBeNil)
+			So(strings.Join(strings.Fields(str), " "), ShouldEqual, "repo 100B 3 2024-01-02T03:04:05Z")
+
+			// a manifest never pulled, the pull columns are only filled when verbose
+			image.Manifests[0].DownloadCount = 0
+			image.Manifests[0].LastPullTimestamp = nil
+
+			str, err = image.stringPlainText(10, 10, 10, true, imageLayout{})
+			So(err, ShouldBeNil)
+			So(strings.Join(strings.Fields(str), " "), ShouldEndWith, "100B 0 N/A")
+
+			str, err = image.stringPlainText(10, 10, 10, false, imageLayout{})
+			So(err, ShouldBeNil)
+			So(strings.Join(strings.Fields(str), " "), ShouldEndWith, "100B")
+		})
+
+		Convey("tsv with the selected columns", func() {
+			searchConfig.OutputFormat = "tsv"
+			searchConfig.ImageColumns = imageColumns{colImageNameIndex, colDigestIndex, colAuthorIndex}
+
+			err := SearchAllImagesGQL(searchConfig)
+			So(err, ShouldBeNil)
+			So(buff.String(), ShouldEqual, "repository\tdigest\tauthor\nrepo\t"+digest+"\tDoe, John\n")
+		})
+	})
+}
+
+func TestImageNDJSONFormat(t *testing.T) {
+	Convey("Images in ndjson format", t, func() {
+		output := &syncBuffer{}
+		streamed := false
+
+		searchConfig := getMockSearchConfig(bytes.NewBufferString(""), mockService{
+			getAllImagesFn: func(ctx context.Context, config SearchConfig, username, password string,
+				channel chan stringResult, wtgrp *sync.WaitGroup,
+			) {
+				for _, tag := range []string{"tag1", "tag2"} {
+					image := getMockImageStruct()
+					image.Tag = tag
+
+					str, err := image.string(ndjsonFormat, 0, 0, 0, false, imageLayout{})
+					channel <- stringResult{StrValue: str, Err: err}
+
+					// the first image is written before the next one is fetched
+					if tag == "tag1" {
+						for i := 0; i < 100 && !streamed; i++ {
+							streamed = strings.Contains(output.String(), `"tag":"tag1"`)
+
+							time.Sleep(10 * time.Millisecond)
+						}
+					}
+				}
+			},
+		})
+		searchConfig.ResultWriter = output
+		searchConfig.OutputFormat = ndjsonFormat
+
+		err := SearchAllImages(searchConfig)
+		So(err, ShouldBeNil)
+		So(streamed, ShouldBeTrue)
+
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		So(lines, ShouldHaveLength, 2)
+
+		for i, line := range lines {
+			var image common.ImageSummary
+
+			err := json.Unmarshal([]byte(line), &image)
+			So(err, ShouldBeNil)
+			So(image.Tag, ShouldEqual, fmt.Sprintf("tag%d", i+1))
+		}
+
+		inspected, err := imageInspectStruct{RepoName: "repo"}.string(ndjsonFormat)
+		So(err, ShouldBeNil)
+		So(inspected, ShouldEqual,
+			`{"repoName":"repo","reference":"","digest":"","mediaType":"","size":0,"manifests":null}`+"\n")
+	})
+}
+
+func TestImageLayout(t *testing.T) {
+	Convey("Image table layout", t, func() {
+		digest := godigest.FromString("str")
+
+		Convey("without truncation", func() {
+			layout := getImageLayout(SearchConfig{NoTrunc: true, TerminalWidth: 80})
+			So(layout.digest(digest), ShouldEqual, digest.String())
+			So(layout.digestColumnWidth(), ShouldEqual, len(digest.String()))
+			So(layout.author(strings.Repeat("a", 100)), ShouldHaveLength, 100)
+
+			str, err := getMockImageStruct().stringPlainText(10, 10, 10, false, layout)
+			So(err, ShouldBeNil)
+			So(str, ShouldContainSubstring, digest.String())
+		})
+
+		Convey("not on a terminal", func() {
+			layout := getImageLayout(SearchConfig{})
+			So(layout.digest(digest), ShouldEqual, digest.Encoded()[:digestWidth])
+			So(layout.author(strings.Repeat("a", 100)), ShouldHaveLength, authorWidth)
+			So(getTerminalWidth(bytes.NewBufferString("")), ShouldEqual, 0)
+		})
+
+		Convey("sized to the terminal", func() {
+			// the other default columns and the paddings take 100 characters
+			So(getImageLayout(SearchConfig{TerminalWidth: 134}).digestColumnWidth(), ShouldEqual, 34)
+			So(getImageLayout(SearchConfig{TerminalWidth: 100}).digestColumnWidth(), ShouldEqual, digestWidth)
+			So(getImageLayout(SearchConfig{TerminalWidth: 300}).digestColumnWidth(), ShouldEqual, maxDigestWidth)
+
+			// the config and layers digests share the width, the pull columns take 26 more characters
+			So(getImageLayout(SearchConfig{TerminalWidth: 230, Verbose: true}).digestColumnWidth(), ShouldEqual, 34)
+
+			layout := getImageLayout(SearchConfig{TerminalWidth: 134})
+			So(layout.digest(digest), ShouldEqual, digest.Encoded()[:34])
+		})
+	})
+}
+
+func TestSearchRegistries(t *testing.T) {
+	Convey("Search several registries", t, func() {
+		buff := bytes.NewBufferString("")
+		searchConfig := getMockSearchConfig(buff, mockService{
+			getAllImagesFn: func(ctx context.Context, config SearchConfig, username, password string,
+				channel chan stringResult, wtgrp *sync.WaitGroup,
+			) {
+				if config.ServURL == "http://127.0.0.1:8002" {
+					channel <- stringResult{Err: zerr.ErrUnauthorizedAccess}
+
+					return
+				}
+
+				image := getMockImageStruct()
+				image.RepoName = getRegistryRepoName(config, image.RepoName)
+
+				str, err := image.stringPlainText(20, 10, 10, false, imageLayout{})
+
+				channel <- stringResult{StrValue: str, Err: err}
+			},
+		})
+
+		searchConfig.Registries = []registry{
+			{name: "first", url: "http://127.0.0.1:8000"},
+			{name: "second", url: "http://127.0.0.1:8001"},
+		}
+
+		err := SearchAllImages(searchConfig)
+		So(err, ShouldBeNil)
+		space := regexp.MustCompile(`\s+`)
+		str := space.ReplaceAllString(buff.String(), " ")
+		So(str, ShouldContainSubstring, "first/repo tag os/arch 8c25cb36 false 100B")
+		So(str, ShouldContainSubstring, "second/repo tag os/arch 8c25cb36 false 100B")
+		So(strings.Count(str, "REPOSITORY"), ShouldEqual, 1)
+
+		Convey("a failing registry doesn't stop the others", func() {
+			buff.Reset()
+
+			searchConfig.Registries = append(searchConfig.Registries,
+				registry{name: "third", url: "http://127.0.0.1:8002"})
+
+			err := SearchAllImages(searchConfig)
+			So(errors.Is(err, zerr.ErrRegistrySearchFailed), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "third: "+zerr.ErrUnauthorizedAccess.Error())
+			So(err.Error(), ShouldNotContainSubstring, "first")
+			So(buff.String(), ShouldContainSubstring, "first/repo")
+			So(buff.String(), ShouldContainSubstring, "second/repo")
+		})
+	})
+
+	Convey("Registries from the flags", t, func() {
+		cmd := &cobra.Command{Annotations: map[string]string{multiRegistryAnnotation: ""}}
+		cmd.Flags().StringSlice(URLFlag, nil, "")
+		cmd.Flags().String(ConfigFlag, "", "")
+		cmd.Flags().String(UserFlag, "", "")
+
+		registries, err := getRegistries(cmd, "")
+		So(err, ShouldBeNil)
+		So(registries, ShouldBeEmpty)
+
+		err = cmd.Flags().Set(URLFlag, "http://127.0.0.1:8000")
+		So(err, ShouldBeNil)
+
+		// a single registry is searched as usual
+		registries, err = getRegistries(cmd, "")
+		So(err, ShouldBeNil)
+		So(registries, ShouldBeEmpty)
+
+		url, err := GetServerURLFromFlags(cmd)
+		So(err, ShouldBeNil)
+		So(url, ShouldEqual, "http://127.0.0.1:8000")
+
+		err = cmd.Flags().Set(URLFlag, "https://127.0.0.1:8001")
+		So(err, ShouldBeNil)
+
+		registries, err = getRegistries(cmd, "user:pass")
+		So(err, ShouldBeNil)
+		So(registries, ShouldResemble, []registry{
+			{name: "127.0.0.1:8000", url: "http://127.0.0.1:8000", user: "user:pass"},
+			{name: "127.0.0.1:8001", url: "https://127.0.0.1:8001", user: "user:pass"},
+		})
+
+		// the same registry given twice is searched once
+		err = cmd.Flags().Set(URLFlag, "http://127.0.0.1:8000")
+		So(err, ShouldBeNil)
+
+		registries, err = getRegistries(cmd, "user:pass")
+		So(err, ShouldBeNil)
+		So(registries, ShouldHaveLength, 2)
+
+		err = cmd.Flags().Set(URLFlag, "bad-url")
+		So(err, ShouldBeNil)
+
+		_, err = getRegistries(cmd, "")
+		So(err, ShouldNotBeNil)
+
+		Convey("commands searching a single registry", func() {
+			cmd := &cobra.Command{Use: "single"}
+			cmd.Flags().StringSlice(URLFlag, []string{"http://127.0.0.1:8000", "http://127.0.0.1:8001"}, "")
+
+			_, err := getRegistries(cmd, "")
+			So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+		})
+
+		Convey("config names", func() {
+			cmd := &cobra.Command{}
+			cmd.Flags().String(ConfigFlag, "first,second", "")
+
+			So(getFlagConfigNames(cmd), ShouldResemble, []string{"first", "second"})
+			So(getConfigName(cmd), ShouldEqual, "first")
+		})
+	})
+}
+
+func TestPartialResults(t *testing.T) {
+	Convey("Images which can't be fetched", t, func() {
+		buff := bytes.NewBufferString("")
+		errBuff := bytes.NewBufferString("")
+		searchConfig := getMockSearchConfig(buff, mockService{
+			getAllImagesFn: func(ctx context.Context, config SearchConfig, username, password string,
+				channel chan stringResult, wtgrp *sync.WaitGroup,
+			) {
+				str, err := getMockImageStruct().stringPlainText(10, 10, 10, false, imageLayout{})
+
+				results := []stringResult{
+					{Err: newImageError("broken:tag", &httpStatusError{
+						statusCode: http.StatusNotFound, err: zerr.ErrBadHTTPStatusCode,
+					})},
+					{Err: newImageError("other", zerr.ErrUnauthorizedAccess)},
+					{StrValue: str, Err: err},
+				}
+
+				for _, result := range results {
+					select {
+					case channel <- result:
+					case <-ctx.Done():
+						return
+					}
+				}
+			},
+		})
+		searchConfig.ErrorWriter = errBuff
+
+		err := SearchAllImages(searchConfig)
+		So(errors.Is(err, zerr.ErrPartialResults), ShouldBeTrue)
+		So(errors.Is(err, zerr.ErrBadHTTPStatusCode), ShouldBeTrue)
+		So(ExitCode(err), ShouldEqual, 2)
+		So(buff.String(), ShouldContainSubstring, "repo")
+		So(buff.String(), ShouldNotContainSubstring, "broken")
+
+		space := regexp.MustCompile(`\s+`)
+		str := space.ReplaceAllString(errBuff.String(), " ")
+		So(str, ShouldContainSubstring, "2 of the images couldn't be fetched")
+		So(str, ShouldContainSubstring, "IMAGE HTTP STATUS ERROR")
+		So(str, ShouldContainSubstring, "broken:tag 404 "+zerr.ErrBadHTTPStatusCode.Error())
+		So(str, ShouldContainSubstring, "other N/A "+zerr.ErrUnauthorizedAccess.Error())
+
+		Convey("fail fast", func() {
+			buff.Reset()
+			errBuff.Reset()
+
+			searchConfig.FailFast = true
+
+			err := SearchAllImages(searchConfig)
+			So(errors.Is(err, zerr.ErrPartialResults), ShouldBeFalse)
+			So(errors.Is(err, zerr.ErrBadHTTPStatusCode), ShouldBeTrue)
+			So(ExitCode(err), ShouldEqual, 1)
+			So(errBuff.String(), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestSearchTimeout(t *testing.T) {
+	Convey("the results received before the timeout are shown", t, func() {
+		buff := bytes.NewBufferString("")
+		searchConfig := getMockSearchConfig(buff, mockService{
+			getAllImagesFn: func(ctx context.Context, config SearchConfig, username, password string,
+				channel chan stringResult, wtgrp *sync.WaitGroup,
+			) {
+				str, err := getMockImageStruct().stringPlainText(10, 10, 10, false, imageLayout{})
+
+				channel <- stringResult{StrValue: str, Err: err}
+
+				// the other images never come
+				<-ctx.Done()
+			},
+		})
+		searchConfig.Timeout = 50 * time.Millisecond
+
+		err := SearchAllImages(searchConfig)
+		So(errors.Is(err, zerr.ErrPartialResults), ShouldBeTrue)
+		So(errors.Is(err, zerr.ErrCLITimeout), ShouldBeTrue)
+		So(ExitCode(err), ShouldEqual, 2)
+		So(buff.String(), ShouldContainSubstring, "repo")
+
+		Convey("interrupted by the user", func() {
+			buff.Reset()
+
+			searchConfig.Timeout = 0
+			searchConfig.SearchService = mockService{
+				getAllImagesFn: func(ctx context.Context, config SearchConfig, username, password string,
+					channel chan stringResult, wtgrp *sync.WaitGroup,
+				) {
+					str, err := getMockImageStruct().stringPlainText(10, 10, 10, false, imageLayout{})
+
+					channel <- stringResult{StrValue: str, Err: err}
+
+					_ = syscall.Kill(os.Getpid(), syscall.SIGINT)
+
+					<-ctx.Done()
+				},
+			}
+
+			err := SearchAllImages(searchConfig)
+			So(errors.Is(err, zerr.ErrSearchInterrupted), ShouldBeTrue)
+			So(buff.String(), ShouldContainSubstring, "repo")
+		})
+	})
+}
+
 func getMockImageStruct() imageStruct {
 	return imageStruct(common.ImageSummary{
 		RepoName: "repo", Tag: "tag",
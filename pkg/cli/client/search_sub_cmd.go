@@ -23,7 +23,8 @@ func NewSearchSubjectCommand(searchService SearchService) *cobra.Command {
 		Example: `# For referrers search specify the referred subject using it's full digest or tag:
   zli search subject "repo@sha256:f9a0981..."
   zli search subject "repo:tag"`,
-		Args: OneImageWithRefArg,
+		ValidArgsFunction: completeImageArgs(searchService, 1),
+		Args:              OneImageWithRefArg,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
 			if err != nil {
@@ -100,3 +101,17 @@ func OneImageWithRefArg(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func TwoImagesWithRefArgs(cmd *cobra.Command, args []string) error {
+	if err := cobra.ExactArgs(2)(cmd, args); err != nil { //nolint:gomnd
+		return err
+	}
+
+	for _, image := range args {
+		if dir, ref, _ := zcommon.GetImageDirAndReference(image); dir == "" || ref == "" {
+			return zerr.ErrInvalidRepoRefFormat
+		}
+	}
+
+	return nil
+}
@@ -43,6 +43,7 @@ func NewServerStatusCommand() *cobra.Command {
 		"Specify zot server URL if config-name is not mentioned")
 	serverInfoCmd.PersistentFlags().StringP(ConfigFlag, "c", "",
 		"Specify the registry configuration to use for connection")
+	_ = serverInfoCmd.RegisterFlagCompletionFunc(ConfigFlag, completeConfigNames)
 	serverInfoCmd.PersistentFlags().StringP(UserFlag, "u", "",
 		`User Credentials of zot server in "username:password" format`)
 	serverInfoCmd.Flags().StringP(OutputFormatFlag, "f", "text", "Specify the output format [text|json|yaml]")
@@ -59,8 +60,7 @@ func GetServerStatus(config SearchConfig) error {
 		return err
 	}
 
-	_, err = makeGETRequest(ctx, checkAPISupportEndpoint, username, password, config.VerifyTLS, config.Debug,
-		nil, config.ResultWriter)
+	_, err = makeGETRequest(ctx, checkAPISupportEndpoint, username, password, config, nil)
 	if err != nil {
 		serverInfo := ServerInfo{}
 
@@ -88,8 +88,7 @@ func GetServerStatus(config SearchConfig) error {
 
 	serverInfo := ServerInfo{}
 
-	_, err = makeGETRequest(ctx, mgmtEndpoint, username, password, config.VerifyTLS, config.Debug,
-		&serverInfo, config.ResultWriter)
+	_, err = makeGETRequest(ctx, mgmtEndpoint, username, password, config, &serverInfo)
 
 	switch {
 	case err == nil:
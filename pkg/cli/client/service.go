@@ -8,10 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/dustin/go-humanize"
 	jsoniter "github.com/json-iterator/go"
@@ -26,14 +29,29 @@ import (
 )
 
 const (
-	jsonFormat = "json"
-	yamlFormat = "yaml"
-	ymlFormat  = "yml"
+	jsonFormat   = "json"
+	ndjsonFormat = "ndjson" // one json result per line, written as soon as it is fetched
+	yamlFormat   = "yaml"
+	ymlFormat    = "yml"
+	csvFormat    = "csv"
+	tsvFormat    = "tsv"
+
+	// the templates of --quiet, the images are printed one per line without the text table
+	quietFormat        = "{{.RepoName}}:{{.Tag}}"
+	quietDigestsFormat = "{{.Digest}}"
+)
+
+// the userprefs extension actions.
+const (
+	toggleStarAction     = "toggleStar"
+	toggleBookmarkAction = "toggleBookmark"
 )
 
 type SearchService interface { //nolint:interfacebloat
 	getImagesGQL(ctx context.Context, config SearchConfig, username, password string,
 		imageName string) (*common.ImageListResponse, error)
+	getAnnotatedImagesGQL(ctx context.Context, config SearchConfig, username, password string,
+	) (*annotatedImageListResponse, error)
 	getImagesForDigestGQL(ctx context.Context, config SearchConfig, username, password string,
 		digest string) (*common.ImagesForDigest, error)
 	getCveByImageGQL(ctx context.Context, config SearchConfig, username, password,
@@ -48,33 +66,103 @@ type SearchService interface { //nolint:interfacebloat
 		baseImage string) (*common.BaseImageListResponse, error)
 	getReferrersGQL(ctx context.Context, config SearchConfig, username, password string,
 		repo, digest string) (*common.ReferrersResp, error)
+	getRepoActivityGQL(ctx context.Context, config SearchConfig, username, password string,
+		repo, reference string) (*common.RepoActivityResp, error)
+	getImageLayersGQL(ctx context.Context, config SearchConfig, username, password string,
+		image string) (*common.ImageLayersResp, error)
+	getStarredReposGQL(ctx context.Context, config SearchConfig, username, password string,
+	) (*common.PaginatedReposResult, error)
+	getBookmarkedReposGQL(ctx context.Context, config SearchConfig, username, password string,
+	) (*common.PaginatedReposResult, error)
+	getRepoSummaryGQL(ctx context.Context, config SearchConfig, username, password, repo string,
+	) (*common.RepoSummary, error)
 	globalSearchGQL(ctx context.Context, config SearchConfig, username, password string,
 		query string) (*common.GlobalSearch, error)
 
 	getAllImages(ctx context.Context, config SearchConfig, username, password string,
 		channel chan stringResult, wtgrp *sync.WaitGroup)
+	getImagesByLabel(ctx context.Context, config SearchConfig, username, password string,
+		labels map[string]string, channel chan stringResult, wtgrp *sync.WaitGroup)
 	getImagesByDigest(ctx context.Context, config SearchConfig, username, password, digest string,
 		channel chan stringResult, wtgrp *sync.WaitGroup)
 	getRepos(ctx context.Context, config SearchConfig, username, password string,
 		channel chan stringResult, wtgrp *sync.WaitGroup)
+	getRepoStats(ctx context.Context, config SearchConfig, username, password string) ([]repoStatsStruct, error)
+	getStorageStats(ctx context.Context, config SearchConfig, username, password string) (*storageStatsStruct, error)
 	getImageByName(ctx context.Context, config SearchConfig, username, password, imageName string,
 		channel chan stringResult, wtgrp *sync.WaitGroup)
 	getReferrers(ctx context.Context, config SearchConfig, username, password string, repo, digest string,
 	) (referrersResult, error)
+	getTags(ctx context.Context, config SearchConfig, username, password, repo string) ([]string, error)
+	inspectImage(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+	) (*imageInspectStruct, error)
+	verifyImage(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+	) (*imageVerifyStruct, error)
+	deleteImage(ctx context.Context, config SearchConfig, username, password, repo, reference string) error
+	toggleRepoPreference(ctx context.Context, config SearchConfig, username, password, repo, action string) error
+	copyImage(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+		destConfig SearchConfig, destRepo, destReference string) error
+	getManifestDigest(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+	) (string, error)
+	getImageCreated(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+	) (time.Time, error)
+	pullImage(ctx context.Context, config SearchConfig, username, password, repo, reference, layoutPath string,
+	) error
+	pushImage(ctx context.Context, config SearchConfig, username, password, layoutPath, repo, tag string,
+		chunkSize int64) (string, error)
+	annotateImage(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+		annotations map[string]string, removed []string, tag string) (godigest.Digest, error)
+	tagImage(ctx context.Context, config SearchConfig, username, password, repo, reference, tag string,
+	) (godigest.Digest, error)
+	getSBOMs(ctx context.Context, config SearchConfig, username, password, repo, digest string,
+	) ([]sbomDocument, error)
+	getArtifacts(ctx context.Context, config SearchConfig, username, password, repo, digest, artifactType string,
+	) (artifactsResult, error)
+	getImageHistory(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+	) (*imageHistoryStruct, error)
+	diffImages(ctx context.Context, config SearchConfig, username, password, fromRepo, fromReference,
+		toRepo, toReference, platform string) (*imageDiffStruct, error)
 }
 
 type SearchConfig struct {
-	SearchService SearchService
-	ServURL       string
-	User          string
-	OutputFormat  string
-	SortBy        string
-	VerifyTLS     bool
-	FixedFlag     bool
-	Verbose       bool
-	Debug         bool
-	ResultWriter  io.Writer
-	Spinner       spinnerState
+	SearchService         SearchService
+	ServURL               string
+	RegistryName          string
+	Registries            []registry
+	User                  string
+	OutputFormat          string
+	ImageColumns          imageColumns
+	NoTrunc               bool
+	FailFast              bool
+	VerifySignature       bool
+	PublicKeys            []publicKey
+	TerminalWidth         int
+	SortBy                string
+	VerifyTLS             bool
+	FixedFlag             bool
+	NameRegex             bool
+	Verbose               bool
+	Debug                 bool
+	DebugBody             bool
+	PageSize              int
+	Cache                 *manifestCache
+	MaxConcurrentRequests int
+	RequestsPerSecond     int
+	MaxIdleConnsPerHost   int
+	RequestTimeout        time.Duration
+	Timeout               time.Duration
+	Retries               int
+	RetryMaxWait          time.Duration
+	Progress              *progressReporter
+	BandwidthLimiter      *bandwidthLimiter
+	CertFile              string
+	KeyFile               string
+	CACertFile            string
+	Proxy                 string
+	Socket                string // dialed instead of the host of ServURL for a unix:// url
+	ResultWriter          io.Writer
+	ErrorWriter           io.Writer
+	Spinner               spinnerState
 }
 
 type searchService struct{}
@@ -99,12 +187,19 @@ func (service searchService) getDerivedImageListGQL(ctx context.Context, config
 						Size
 						Platform {Os Arch}
 						IsSigned
+						SignatureInfo {Tool IsTrusted Author}
 						Layers {Size Digest}
 						LastUpdated
+						DownloadCount
+						LastPullTimestamp
 					}
 					LastUpdated
+					DownloadCount
+					LastPullTimestamp
 					Size
 					IsSigned
+					SignatureInfo {Tool IsTrusted Author}
+					Authors
 				}
 			}
 		}`, derivedImage, Flag2SortCriteria(config.SortBy))
@@ -146,6 +241,117 @@ func (service searchService) getReferrersGQL(ctx context.Context, config SearchC
 	return result, nil
 }
 
+func (service searchService) getRepoActivityGQL(ctx context.Context, config SearchConfig, username, password string,
+	repo, reference string,
+) (*common.RepoActivityResp, error) {
+	query := fmt.Sprintf(`
+		{
+			RepoActivity( repo: "%s", reference: "%s" ){
+				Type
+				Reference
+				Digest
+				MediaType
+				PreviousDigest
+				Actor
+				Timestamp
+			}
+		}`, repo, reference)
+
+	result := &common.RepoActivityResp{}
+
+	err := service.makeGraphQLQuery(ctx, config, username, password, query, result)
+	if errResult := checkResultGraphQLQuery(ctx, err, result.Errors); errResult != nil {
+		return nil, errResult
+	}
+
+	return result, nil
+}
+
+// the fields of the repositories shown in the repo tables.
+const repoSummaryFields = `Name LastUpdated Size DownloadCount StarCount IsStarred IsBookmarked
+	Platforms { Os Arch }`
+
+func (service searchService) getStarredReposGQL(ctx context.Context, config SearchConfig, username, password string,
+) (*common.PaginatedReposResult, error) {
+	query := fmt.Sprintf(`
+		{
+			StarredRepos(requestedPage: {sortBy: %s}){
+				Results { %s }
+			}
+		}`, Flag2SortCriteria(config.SortBy), repoSummaryFields)
+
+	result := &common.StarredReposResponse{}
+
+	err := service.makeGraphQLQuery(ctx, config, username, password, query, result)
+	if errResult := checkResultGraphQLQuery(ctx, err, result.Errors); errResult != nil {
+		return nil, errResult
+	}
+
+	return &result.StarredRepos.PaginatedReposResult, nil
+}
+
+func (service searchService) getBookmarkedReposGQL(ctx context.Context, config SearchConfig, username, password string,
+) (*common.PaginatedReposResult, error) {
+	query := fmt.Sprintf(`
+		{
+			BookmarkedRepos(requestedPage: {sortBy: %s}){
+				Results { %s }
+			}
+		}`, Flag2SortCriteria(config.SortBy), repoSummaryFields)
+
+	result := &common.BookmarkedReposResponse{}
+
+	err := service.makeGraphQLQuery(ctx, config, username, password, query, result)
+	if errResult := checkResultGraphQLQuery(ctx, err, result.Errors); errResult != nil {
+		return nil, errResult
+	}
+
+	return &result.BookmarkedRepos.PaginatedReposResult, nil
+}
+
+func (service searchService) getRepoSummaryGQL(ctx context.Context, config SearchConfig, username, password,
+	repo string,
+) (*common.RepoSummary, error) {
+	query := fmt.Sprintf(`
+		{
+			ExpandedRepoInfo(repo: "%s"){
+				Summary { %s }
+			}
+		}`, repo, repoSummaryFields)
+
+	result := &common.ExpandedRepoInfoResp{}
+
+	err := service.makeGraphQLQuery(ctx, config, username, password, query, result)
+	if errResult := checkResultGraphQLQuery(ctx, err, result.Errors); errResult != nil {
+		return nil, errResult
+	}
+
+	return &result.ExpandedRepoInfo.RepoInfo.Summary, nil
+}
+
+func (service searchService) getImageLayersGQL(ctx context.Context, config SearchConfig, username, password string,
+	image string,
+) (*common.ImageLayersResp, error) {
+	query := fmt.Sprintf(`
+		{
+			ImageLayers( image: "%s" ){
+				Digest
+				MediaType
+				Size
+				SharedRepoCount
+			}
+		}`, image)
+
+	result := &common.ImageLayersResp{}
+
+	err := service.makeGraphQLQuery(ctx, config, username, password, query, result)
+	if errResult := checkResultGraphQLQuery(ctx, err, result.Errors); errResult != nil {
+		return nil, errResult
+	}
+
+	return result, nil
+}
+
 func (service searchService) globalSearchGQL(ctx context.Context, config SearchConfig, username, password string,
 	query string,
 ) (*common.GlobalSearch, error) {
@@ -159,15 +365,21 @@ func (service searchService) globalSearchGQL(ctx context.Context, config SearchC
 					Digest
 					Size
 					IsSigned
+					SignatureInfo {Tool IsTrusted Author}
 					LastUpdated
+					DownloadCount
+					LastPullTimestamp
 					Manifests {
 						Digest
 						ConfigDigest
 						Platform {Os Arch}
 						Size
 						IsSigned
+						SignatureInfo {Tool IsTrusted Author}
 						Layers {Size Digest}
 						LastUpdated
+						DownloadCount
+						LastPullTimestamp
 					}
 				}
 				Repos {
@@ -207,12 +419,19 @@ func (service searchService) getBaseImageListGQL(ctx context.Context, config Sea
 						Size
 						Platform {Os Arch}
 						IsSigned
+						SignatureInfo {Tool IsTrusted Author}
 						Layers {Size Digest}
 						LastUpdated
+						DownloadCount
+						LastPullTimestamp
 					}
 					LastUpdated
+					DownloadCount
+					LastPullTimestamp
 					Size
 					IsSigned
+					SignatureInfo {Tool IsTrusted Author}
+					Authors
 				}
 			}
 		}`, baseImage, Flag2SortCriteria(config.SortBy))
@@ -243,12 +462,19 @@ func (service searchService) getImagesGQL(ctx context.Context, config SearchConf
 					Size
 					Platform {Os Arch}
 					IsSigned
+					SignatureInfo {Tool IsTrusted Author}
 					Layers {Size Digest}
 					LastUpdated
+					DownloadCount
+					LastPullTimestamp
 				}
 				LastUpdated
+				DownloadCount
+				LastPullTimestamp
 				Size
 				IsSigned
+				SignatureInfo {Tool IsTrusted Author}
+				Authors
 			}
 		}
 	}`, imageName, Flag2SortCriteria(config.SortBy))
@@ -263,6 +489,53 @@ func (service searchService) getImagesGQL(ctx context.Context, config SearchConf
 	return result, nil
 }
 
+// getAnnotatedImagesGQL lists the images of all the repositories with their annotations, the ones of the
+// manifests including the labels of their config.
+func (service searchService) getAnnotatedImagesGQL(ctx context.Context, config SearchConfig, username,
+	password string,
+) (*annotatedImageListResponse, error) {
+	query := fmt.Sprintf(`
+	{
+		ImageList(repo: "", requestedPage: {sortBy: %s}) {
+			Results {
+				RepoName Tag
+				Digest
+				MediaType
+				Manifests {
+					Digest
+					ConfigDigest
+					Size
+					Platform {Os Arch}
+					IsSigned
+					SignatureInfo {Tool IsTrusted Author}
+					Layers {Size Digest}
+					LastUpdated
+					DownloadCount
+					LastPullTimestamp
+					Annotations {Key Value}
+				}
+				LastUpdated
+				DownloadCount
+				LastPullTimestamp
+				Size
+				IsSigned
+				SignatureInfo {Tool IsTrusted Author}
+				Authors
+				Annotations {Key Value}
+			}
+		}
+	}`, Flag2SortCriteria(config.SortBy))
+	result := &annotatedImageListResponse{}
+
+	err := service.makeGraphQLQuery(ctx, config, username, password, query, result)
+
+	if errResult := checkResultGraphQLQuery(ctx, err, result.Errors); errResult != nil {
+		return nil, errResult
+	}
+
+	return result, nil
+}
+
 func (service searchService) getImagesForDigestGQL(ctx context.Context, config SearchConfig, username, password string,
 	digest string,
 ) (*common.ImagesForDigest, error) {
@@ -279,12 +552,19 @@ func (service searchService) getImagesForDigestGQL(ctx context.Context, config S
 					Size
 					Platform {Os Arch}
 					IsSigned
+					SignatureInfo {Tool IsTrusted Author}
 					Layers {Size Digest}
 					LastUpdated
+					DownloadCount
+					LastPullTimestamp
 				}
 				LastUpdated
+				DownloadCount
+				LastPullTimestamp
 				Size
 				IsSigned
+				SignatureInfo {Tool IsTrusted Author}
+				Authors
 			}
 		}
 	}`, digest, Flag2SortCriteria(config.SortBy))
@@ -342,12 +622,19 @@ func (service searchService) getTagsForCVEGQL(ctx context.Context, config Search
 						Size
 						Platform {Os Arch}
 						IsSigned
+						SignatureInfo {Tool IsTrusted Author}
 						Layers {Size Digest}
 						LastUpdated
+						DownloadCount
+						LastPullTimestamp
 					}
 					LastUpdated
+					DownloadCount
+					LastPullTimestamp
 					Size
 					IsSigned
+					SignatureInfo {Tool IsTrusted Author}
+					Authors
 				}
 			}
 		}`,
@@ -391,12 +678,19 @@ func (service searchService) getFixedTagsForCVEGQL(ctx context.Context, config S
 						Size
 						Platform {Os Arch}
 						IsSigned
+						SignatureInfo {Tool IsTrusted Author}
 						Layers {Size Digest}
 						LastUpdated
+						DownloadCount
+						LastPullTimestamp
 					}
 					LastUpdated
+					DownloadCount
+					LastPullTimestamp
 					Size
 					IsSigned
+					SignatureInfo {Tool IsTrusted Author}
+					Authors
 				}
 			}
 		}`,
@@ -427,8 +721,7 @@ func (service searchService) getReferrers(ctx context.Context, config SearchConf
 	}
 
 	referrerResp := &ispec.Index{}
-	_, err = makeGETRequest(ctx, referrersEndpoint, username, password, config.VerifyTLS,
-		config.Debug, &referrerResp, config.ResultWriter)
+	_, err = makeGETRequest(ctx, referrersEndpoint, username, password, config, &referrerResp)
 
 	if err != nil {
 		if common.IsContextDone(ctx) {
@@ -457,29 +750,108 @@ func (service searchService) getImageByName(ctx context.Context, config SearchCo
 	defer wtgrp.Done()
 	defer close(rch)
 
+	repo, tag := common.GetImageDirAndTag(imageName)
+	digest := ""
+
+	if name, reference, found := strings.Cut(imageName, "@"); found {
+		// the tags pointing at the digest are looked for among all the tags
+		repo, tag, digest = name, "", reference
+	}
+
+	if config.NameRegex {
+		// the whole name is a regex matched against the repo names
+		repo, tag, digest = imageName, "", ""
+	}
+
+	repos := []string{repo}
+
+	if config.NameRegex || isGlobPattern(repo) {
+		var err error
+
+		repos, err = getMatchingRepos(ctx, config, username, password, repo)
+		if err != nil {
+			if common.IsContextDone(ctx) {
+				return
+			}
+			rch <- stringResult{"", err}
+
+			return
+		}
+	}
+
 	var localWg sync.WaitGroup
-	rlim := newSmoothRateLimiter(&localWg, rch)
+	rlim := newSmoothRateLimiter(&localWg, rch, config)
+
+	config.Progress.addRepos(len(repos))
 
 	localWg.Add(1)
 
 	go rlim.startRateLimiter(ctx)
-	localWg.Add(1)
 
-	go getImage(ctx, config, username, password, imageName, rch, &localWg, rlim)
+	for _, repo := range repos {
+		image := repo
+		if tag != "" {
+			image += ":" + tag
+		}
+
+		if digest != "" {
+			image += "@" + digest
+		}
+
+		localWg.Add(1)
+
+		go getImage(ctx, config, username, password, image, nil, rch, &localWg, rlim)
+	}
 
 	localWg.Wait()
 }
 
+// getMatchingRepos returns the repositories in the catalog matching the given glob pattern,
+// or regex if config.NameRegex is set.
+func getMatchingRepos(ctx context.Context, config SearchConfig, username, password, pattern string,
+) ([]string, error) {
+	matchesRepo, err := getRepoNameMatcher(pattern, config.NameRegex)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := getCatalog(ctx, config, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := []string{}
+
+	for _, repo := range catalog.Repositories {
+		if matchesRepo(repo) {
+			repos = append(repos, repo)
+		}
+	}
+
+	return repos, nil
+}
+
 func (service searchService) getAllImages(ctx context.Context, config SearchConfig, username, password string,
 	rch chan stringResult, wtgrp *sync.WaitGroup,
+) {
+	getCatalogImages(ctx, config, username, password, nil, rch, wtgrp)
+}
+
+// getImagesByLabel lists the images from all the repositories in the catalog, filtering out the ones
+// which don't have all the given labels, matched against the manifest annotations and config labels.
+func (service searchService) getImagesByLabel(ctx context.Context, config SearchConfig, username, password string,
+	labels map[string]string, rch chan stringResult, wtgrp *sync.WaitGroup,
+) {
+	getCatalogImages(ctx, config, username, password, labels, rch, wtgrp)
+}
+
+func getCatalogImages(ctx context.Context, config SearchConfig, username, password string,
+	labels map[string]string, rch chan stringResult, wtgrp *sync.WaitGroup,
 ) {
 	defer wtgrp.Done()
 	defer close(rch)
 
-	catalog := &catalogResponse{}
-
-	catalogEndPoint, err := combineServerAndEndpointURL(config.ServURL, fmt.Sprintf("%s%s",
-		constants.RoutePrefix, constants.ExtCatalogPrefix))
+	catalog, err := getCatalog(ctx, config, username, password)
 	if err != nil {
 		if common.IsContextDone(ctx) {
 			return
@@ -489,86 +861,427 @@ func (service searchService) getAllImages(ctx context.Context, config SearchConf
 		return
 	}
 
-	_, err = makeGETRequest(ctx, catalogEndPoint, username, password, config.VerifyTLS,
-		config.Debug, catalog, config.ResultWriter)
+	var localWg sync.WaitGroup
+
+	rlim := newSmoothRateLimiter(&localWg, rch, config)
+
+	config.Progress.addRepos(len(catalog.Repositories))
+
+	localWg.Add(1)
+
+	go rlim.startRateLimiter(ctx)
+
+	for _, repo := range catalog.Repositories {
+		localWg.Add(1)
+
+		go getImage(ctx, config, username, password, repo, labels, rch, &localWg, rlim)
+	}
+
+	localWg.Wait()
+}
+
+// getImage lists the images of the repo, or only the matching tags if the name is repo:tag,
+// or only the tags pointing at the digest if the name is repo@digest.
+func getImage(ctx context.Context, config SearchConfig, username, password, imageName string,
+	labels map[string]string, rch chan stringResult, wtgrp *sync.WaitGroup, pool *requestsPool,
+) {
+	defer wtgrp.Done()
+
+	imageName, digest, _ := strings.Cut(imageName, "@")
+	repo, imageTag := common.GetImageDirAndTag(imageName)
+
+	tagList, err := getTagList(ctx, config, username, password, repo, true)
 	if err != nil {
 		if common.IsContextDone(ctx) {
 			return
 		}
-		rch <- stringResult{"", err}
+		config.Progress.addErrors(1)
+
+		select {
+		case rch <- stringResult{"", newImageError(getRegistryRepoName(config, repo), err)}:
+		case <-ctx.Done():
+		}
 
 		return
 	}
 
-	var localWg sync.WaitGroup
+	details := make(map[string]common.TagDetails, len(tagList.Details))
+
+	for _, tagDetails := range tagList.Details {
+		details[tagDetails.Tag] = tagDetails
+	}
+
+	for _, tag := range tagList.Tags {
+		if common.IsContextDone(ctx) {
+			return
+		}
+
+		hasTagPrefix := strings.HasPrefix(tag, "sha256-")
+		hasTagSuffix := strings.HasSuffix(tag, ".sig")
+
+		// check if it's an image or a signature
+		// we don't want to show signatures in cli responses
+		if hasTagPrefix && hasTagSuffix {
+			continue
+		}
+
+		if !matchesImageTag(tag, imageTag) {
+			continue
+		}
+
+		config.Progress.addTags(1)
+		wtgrp.Add(1)
+
+		go addManifestCallToPool(ctx, config, pool, username, password, repo, tag, digest, details[tag], labels,
+			rch, wtgrp)
+	}
+}
+
+func (service searchService) getTags(ctx context.Context, config SearchConfig, username, password, repo string,
+) ([]string, error) {
+	tagList, err := getTagList(ctx, config, username, password, repo, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return tagList.Tags, nil
+}
+
+func (service searchService) inspectImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string,
+) (*imageInspectStruct, error) {
+	return fetchImageInspectStruct(ctx, config, username, password, repo, reference)
+}
+
+func (service searchService) verifyImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string,
+) (*imageVerifyStruct, error) {
+	return fetchImageVerifyStruct(ctx, config, username, password, repo, reference)
+}
+
+func (service searchService) deleteImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string,
+) error {
+	manifestEndpoint, err := combineServerAndEndpointURL(config.ServURL,
+		fmt.Sprintf("/v2/%s/manifests/%s", repo, reference))
+	if err != nil {
+		return err
+	}
+
+	_, err = makeDELETERequest(ctx, manifestEndpoint, username, password, config)
+
+	return err
+}
+
+// toggleRepoPreference stars or bookmarks the repository for the user if it isn't yet, else removes it.
+func (service searchService) toggleRepoPreference(ctx context.Context, config SearchConfig, username, password,
+	repo, action string,
+) error {
+	userPrefsEndpoint, err := combineServerAndEndpointURL(config.ServURL, constants.FullUserPrefs)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	params.Set("action", action)
+	params.Set("repo", repo)
+
+	_, _, err = makeUploadRequest(ctx, http.MethodPut, userPrefsEndpoint+"?"+params.Encode(), username, password,
+		config, "", nil, 0)
+
+	return err
+}
+
+func (service searchService) copyImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string, destConfig SearchConfig, destRepo, destReference string,
+) error {
+	destUsername, destPassword := getUsernameAndPassword(destConfig.User)
+
+	return copyManifest(ctx, newRegistryRepo(config, username, password, repo),
+		newRegistryRepo(destConfig, destUsername, destPassword, destRepo), reference, destReference)
+}
+
+// getManifestDigest returns the digest of the manifest the reference points at, without downloading it.
+func (service searchService) getManifestDigest(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string,
+) (string, error) {
+	manifestURL, err := combineServerAndEndpointURL(config.ServURL,
+		fmt.Sprintf("/v2/%s/manifests/%s", repo, reference))
+	if err != nil {
+		return "", err
+	}
+
+	header, err := makeHEADRequest(ctx, manifestURL, username, password, config)
+	if err != nil {
+		return "", err
+	}
+
+	return header.Get(constants.DistContentDigestKey), nil
+}
+
+func (service searchService) getImageCreated(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string,
+) (time.Time, error) {
+	return getImageCreated(ctx, newRegistryRepo(config, username, password, repo), reference)
+}
+
+// pullImage copies the image to the oci layout, which is created if needed. A tagged image gets its
+// tag as ref name in the layout.
+func (service searchService) pullImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference, layoutPath string,
+) error {
+	layout, err := openOCILayout(layoutPath, true)
+	if err != nil {
+		return err
+	}
+
+	if err := copyManifest(ctx, newRegistryRepo(config, username, password, repo), layout, reference,
+		reference); err != nil {
+		return err
+	}
+
+	digest, err := godigest.Parse(reference)
+	if err != nil {
+		return nil
+	}
+
+	// an image pulled by digest is added to the index.json without a ref name
+	content, mediaType, err := layout.getManifest(ctx, reference)
+	if err != nil {
+		return err
+	}
+
+	return layout.addToIndex(ispec.Descriptor{MediaType: mediaType, Digest: digest, Size: int64(len(content))})
+}
+
+// pushImage copies an image of the oci layout to the repo and returns the tag it was pushed with. The image
+// is the one with the tag as ref name, or the only one the layout has, in which case its ref name is
+// the default tag.
+func (service searchService) pushImage(ctx context.Context, config SearchConfig, username, password,
+	layoutPath, repo, tag string, chunkSize int64,
+) (string, error) {
+	layout, err := openOCILayout(layoutPath, false)
+	if err != nil {
+		return "", err
+	}
+
+	tagged, err := layout.getTaggedManifests()
+	if err != nil {
+		return "", err
+	}
+
+	refNames := make([]string, 0, len(tagged))
+
+	for _, descriptor := range tagged {
+		refNames = append(refNames, descriptor.Annotations[ispec.AnnotationRefName])
+	}
+
+	var reference string
+
+	switch {
+	case slices.Contains(refNames, tag):
+		reference = tag
+	case len(refNames) == 1:
+		reference = refNames[0]
+	case len(refNames) == 0:
+		return "", fmt.Errorf("%w: no tagged image in %s", zerr.ErrManifestNotFound, layoutPath)
+	default:
+		return "", fmt.Errorf("%w: %s has several images, the tag should be one of: %s", zerr.ErrInvalidCLIParameter,
+			layoutPath, strings.Join(refNames, ", "))
+	}
+
+	if tag == "" {
+		tag = reference
+	}
+
+	dest := newRegistryRepo(config, username, password, repo)
+	dest.chunkSize = chunkSize
+
+	return tag, copyManifest(ctx, layout, dest, reference, tag)
+}
+
+// annotateImage sets and removes annotations of the manifest, then pushes the edited manifest under the tag
+// and returns its digest. The blobs and the images of an index are the same, only the manifest changes.
+func (service searchService) annotateImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string, annotations map[string]string, removed []string, tag string,
+) (godigest.Digest, error) {
+	registry := newRegistryRepo(config, username, password, repo)
+
+	content, mediaType, err := registry.getManifest(ctx, reference)
+	if err != nil {
+		return "", err
+	}
+
+	// the docker manifests have no annotations
+	if mediaType != ispec.MediaTypeImageManifest && mediaType != ispec.MediaTypeImageIndex {
+		return "", fmt.Errorf("%w: %s can't be annotated", zerr.ErrMediaTypeNotSupported, mediaType)
+	}
+
+	content, err = setManifestAnnotations(content, annotations, removed)
+	if err != nil {
+		return "", err
+	}
+
+	if err := registry.putManifest(ctx, tag, mediaType, content); err != nil {
+		return "", err
+	}
+
+	return godigest.FromBytes(content), nil
+}
+
+// tagImage pushes the manifest again under the tag and returns its digest. The repo already has
+// everything the manifest references, so no blob is copied.
+func (service searchService) tagImage(ctx context.Context, config SearchConfig, username, password,
+	repo, reference, tag string,
+) (godigest.Digest, error) {
+	registry := newRegistryRepo(config, username, password, repo)
+
+	content, mediaType, err := registry.getManifest(ctx, reference)
+	if err != nil {
+		return "", err
+	}
+
+	if err := registry.putManifest(ctx, tag, mediaType, content); err != nil {
+		return "", err
+	}
+
+	return godigest.FromBytes(content), nil
+}
+
+// setManifestAnnotations edits the annotations of the manifest, keeping its other fields as they are.
+func setManifestAnnotations(content []byte, annotations map[string]string, removed []string) ([]byte, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	var manifest map[string]jsoniter.RawMessage
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+
+	manifestAnnotations := map[string]string{}
+
+	if raw, ok := manifest["annotations"]; ok {
+		if err := json.Unmarshal(raw, &manifestAnnotations); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range removed {
+		delete(manifestAnnotations, key)
+	}
+
+	for key, value := range annotations {
+		manifestAnnotations[key] = value
+	}
+
+	if len(manifestAnnotations) == 0 {
+		delete(manifest, "annotations")
+	} else {
+		raw, err := json.Marshal(manifestAnnotations)
+		if err != nil {
+			return nil, err
+		}
 
-	rlim := newSmoothRateLimiter(&localWg, rch)
+		manifest["annotations"] = raw
+	}
 
-	localWg.Add(1)
+	return json.Marshal(manifest)
+}
 
-	go rlim.startRateLimiter(ctx)
+// getCatalog returns the repositories in the registry catalog, going through all the pages
+// if the registry paginates the results.
+func getCatalog(ctx context.Context, config SearchConfig, username, password string) (*catalogResponse, error) {
+	catalogEndPoint, err := combineServerAndEndpointURL(config.ServURL, fmt.Sprintf("%s%s",
+		constants.RoutePrefix, constants.ExtCatalogPrefix))
+	if err != nil {
+		return nil, err
+	}
 
-	for _, repo := range catalog.Repositories {
-		localWg.Add(1)
+	catalog := &catalogResponse{}
 
-		go getImage(ctx, config, username, password, repo, rch, &localWg, rlim)
+	err = makePaginatedGETRequest(ctx, catalogEndPoint, username, password, config, func(page catalogResponse) {
+		catalog.Repositories = append(catalog.Repositories, page.Repositories...)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	localWg.Wait()
+	return catalog, nil
 }
 
-func getImage(ctx context.Context, config SearchConfig, username, password, imageName string,
-	rch chan stringResult, wtgrp *sync.WaitGroup, pool *requestsPool,
-) {
-	defer wtgrp.Done()
-
-	repo, imageTag := common.GetImageDirAndTag(imageName)
-
+// getTagList returns the tags of the given repository, going through all the pages
+// if the registry paginates the results.
+// getTagList returns all the tags of the repo. withDetails asks zot for the manifest descriptor of each tag too,
+// the other registries ignore it and only return the tags.
+func getTagList(ctx context.Context, config SearchConfig, username, password, repo string, withDetails bool,
+) (*tagListResp, error) {
 	tagListEndpoint, err := combineServerAndEndpointURL(config.ServURL, fmt.Sprintf("/v2/%s/tags/list", repo))
 	if err != nil {
-		if common.IsContextDone(ctx) {
-			return
-		}
-		rch <- stringResult{"", err}
+		return nil, err
+	}
 
-		return
+	if withDetails {
+		tagListEndpoint += "?details=true"
 	}
 
 	tagList := &tagListResp{}
-	_, err = makeGETRequest(ctx, tagListEndpoint, username, password, config.VerifyTLS,
-		config.Debug, &tagList, config.ResultWriter)
 
+	err = makePaginatedGETRequest(ctx, tagListEndpoint, username, password, config, func(page tagListResp) {
+		tagList.Name = page.Name
+		tagList.Tags = append(tagList.Tags, page.Tags...)
+		tagList.Details = append(tagList.Details, page.Details...)
+	})
 	if err != nil {
-		if common.IsContextDone(ctx) {
-			return
-		}
-		rch <- stringResult{"", err}
-
-		return
+		return nil, err
 	}
 
-	for _, tag := range tagList.Tags {
-		hasTagPrefix := strings.HasPrefix(tag, "sha256-")
-		hasTagSuffix := strings.HasSuffix(tag, ".sig")
+	return tagList, nil
+}
 
-		// check if it's an image or a signature
-		// we don't want to show signatures in cli responses
-		if hasTagPrefix && hasTagSuffix {
-			continue
-		}
+func (service searchService) getSBOMs(ctx context.Context, config SearchConfig, username, password,
+	repo, digest string,
+) ([]sbomDocument, error) {
+	return fetchSBOMs(ctx, config, username, password, repo, digest)
+}
 
-		shouldMatchTag := imageTag != ""
-		matchesTag := tag == imageTag
+func (service searchService) getArtifacts(ctx context.Context, config SearchConfig, username, password,
+	repo, digest, artifactType string,
+) (artifactsResult, error) {
+	return fetchArtifacts(ctx, config, username, password, repo, digest, artifactType)
+}
 
-		// when the tag is empty we match everything
-		if shouldMatchTag && !matchesTag {
-			continue
-		}
+func (service searchService) getImageHistory(ctx context.Context, config SearchConfig, username, password,
+	repo, reference string,
+) (*imageHistoryStruct, error) {
+	return fetchImageHistory(ctx, config, username, password, repo, reference)
+}
 
-		wtgrp.Add(1)
+// diffImages compares the layers of the two images, for an image index the image of the platform is compared.
+func (service searchService) diffImages(ctx context.Context, config SearchConfig, username, password,
+	fromRepo, fromReference, toRepo, toReference, platform string,
+) (*imageDiffStruct, error) {
+	fromImage, err := fetchImageInspectStruct(ctx, config, username, password, fromRepo, fromReference)
+	if err != nil {
+		return nil, err
+	}
+
+	toImage, err := fetchImageInspectStruct(ctx, config, username, password, toRepo, toReference)
+	if err != nil {
+		return nil, err
+	}
 
-		go addManifestCallToPool(ctx, config, pool, username, password, repo, tag, rch, wtgrp)
+	fromManifest, err := selectManifest(fromImage, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	toManifest, err := selectManifest(toImage, platform)
+	if err != nil {
+		return nil, err
 	}
+
+	return diffImageLayers(common.GetFullImageName(fromRepo, fromReference), fromManifest,
+		common.GetFullImageName(toRepo, toReference), toManifest), nil
 }
 
 func (service searchService) getImagesByDigest(ctx context.Context, config SearchConfig, username,
@@ -590,12 +1303,19 @@ func (service searchService) getImagesByDigest(ctx context.Context, config Searc
 						Size
 						Platform {Os Arch}
 						IsSigned
+						SignatureInfo {Tool IsTrusted Author}
 						Layers {Size Digest}
 						LastUpdated
+						DownloadCount
+						LastPullTimestamp
 					}
 					LastUpdated
+					DownloadCount
+					LastPullTimestamp
 					Size
 					IsSigned
+					SignatureInfo {Tool IsTrusted Author}
+					Authors
 				}
 			}
 		}`,
@@ -630,7 +1350,7 @@ func (service searchService) getImagesByDigest(ctx context.Context, config Searc
 
 	var localWg sync.WaitGroup
 
-	rlim := newSmoothRateLimiter(&localWg, rch)
+	rlim := newSmoothRateLimiter(&localWg, rch, config)
 	localWg.Add(1)
 
 	go rlim.startRateLimiter(ctx)
@@ -638,7 +1358,8 @@ func (service searchService) getImagesByDigest(ctx context.Context, config Searc
 	for _, image := range result.Results {
 		localWg.Add(1)
 
-		go addManifestCallToPool(ctx, config, rlim, username, password, image.RepoName, image.Tag, rch, &localWg)
+		go addManifestCallToPool(ctx, config, rlim, username, password, image.RepoName, image.Tag, "", common.TagDetails{}, nil,
+			rch, &localWg)
 	}
 
 	localWg.Wait()
@@ -655,8 +1376,7 @@ func (service searchService) makeGraphQLQuery(ctx context.Context,
 		return err
 	}
 
-	err = makeGraphQLRequest(ctx, endPoint, query, username, password, config.VerifyTLS,
-		config.Debug, resultPtr, config.ResultWriter)
+	err = makeGraphQLRequest(ctx, endPoint, query, username, password, config, resultPtr)
 	if err != nil {
 		return err
 	}
@@ -692,18 +1412,25 @@ func checkResultGraphQLQuery(ctx context.Context, err error, resultErrors []comm
 	return nil
 }
 
+// addManifestCallToPool fetches the image of the tag, if a digest is given the image is only shown
+// if the tag points at it.
+// addManifestCallToPool submits the fetch of the tag's image. If the registry returned the details of the tag,
+// its manifest is fetched directly, else the job first checks its media type.
 func addManifestCallToPool(ctx context.Context, config SearchConfig, pool *requestsPool,
-	username, password, imageName, tagName string, rch chan stringResult, wtgrp *sync.WaitGroup,
+	username, password, imageName, tagName, digest string, details common.TagDetails, labels map[string]string,
+	rch chan stringResult, wtgrp *sync.WaitGroup,
 ) {
 	defer wtgrp.Done()
 
 	manifestEndpoint, err := combineServerAndEndpointURL(config.ServURL,
 		fmt.Sprintf("/v2/%s/manifests/%s", imageName, tagName))
 	if err != nil {
-		if common.IsContextDone(ctx) {
-			return
+		select {
+		case rch <- stringResult{"", err}:
+		case <-ctx.Done():
 		}
-		rch <- stringResult{"", err}
+
+		return
 	}
 
 	job := httpJob{
@@ -712,7 +1439,12 @@ func addManifestCallToPool(ctx context.Context, config SearchConfig, pool *reque
 		imageName: imageName,
 		password:  password,
 		tagName:   tagName,
+		labels:    labels,
 		config:    config,
+		digest:    details.Digest,
+		mediaType: details.MediaType,
+
+		matchDigest: digest,
 	}
 
 	wtgrp.Add(1)
@@ -727,6 +1459,8 @@ type cveResult struct {
 type tagListResp struct {
 	Name string   `json:"name"`
 	Tags []string `json:"tags"`
+	// only returned by zot, when asked for
+	Details []common.TagDetails `json:"details"`
 }
 
 //nolint:tagliatelle // graphQL schema
@@ -762,7 +1496,7 @@ func (cve cveResult) string(format string) (string, error) {
 	switch strings.ToLower(format) {
 	case "", defaultOutputFormat:
 		return cve.stringPlainText()
-	case jsonFormat:
+	case jsonFormat, ndjsonFormat:
 		return cve.stringJSON()
 	case ymlFormat, yamlFormat:
 		return cve.stringYAML()
@@ -821,7 +1555,7 @@ func (ref referrersResult) string(format string, maxArtifactTypeLen int) (string
 	switch strings.ToLower(format) {
 	case "", defaultOutputFormat:
 		return ref.stringPlainText(maxArtifactTypeLen)
-	case jsonFormat:
+	case jsonFormat, ndjsonFormat:
 		return ref.stringJSON()
 	case ymlFormat, yamlFormat:
 		return ref.stringYAML()
@@ -885,7 +1619,7 @@ func (repo repoStruct) string(format string, maxImgNameLen, maxTimeLen int, verb
 	switch strings.ToLower(format) {
 	case "", defaultOutputFormat:
 		return repo.stringPlainText(maxImgNameLen, maxTimeLen, verbose)
-	case jsonFormat:
+	case jsonFormat, ndjsonFormat:
 		return repo.stringJSON()
 	case ymlFormat, yamlFormat:
 		return repo.stringYAML()
@@ -971,36 +1705,276 @@ func (repo repoStruct) stringYAML() (string, error) {
 	return "---\n" + string(body), nil
 }
 
+type repoStatsStruct struct {
+	Name        string    `json:"name"`
+	TagCount    int       `json:"tagCount"`
+	Size        string    `json:"size"`
+	LastUpdated time.Time `json:"lastUpdated"`
+}
+
+func (repo repoStatsStruct) string(format string, maxRepoNameLen int) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return repo.stringPlainText(maxRepoNameLen)
+	case jsonFormat, ndjsonFormat:
+		return repo.stringJSON()
+	case ymlFormat, yamlFormat:
+		return repo.stringYAML()
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (repo repoStatsStruct) stringPlainText(maxRepoNameLen int) (string, error) {
+	var builder strings.Builder
+
+	table := getRepoTableWriter(&builder)
+
+	table.SetColMinWidth(repoStatsNameIndex, maxRepoNameLen)
+	table.SetColMinWidth(repoStatsTagsIndex, tagsCountWidth)
+	table.SetColMinWidth(repoStatsSizeIndex, sizeWidth)
+
+	repoSize, err := strconv.ParseUint(repo.Size, 10, 64)
+	if err != nil {
+		return "", err
+	}
+
+	lastUpdated := "N/A"
+	if !repo.LastUpdated.IsZero() {
+		lastUpdated = repo.LastUpdated.String()
+	}
+
+	row := make([]string, repoStatsRowWidth)
+	row[repoStatsNameIndex] = repo.Name
+	row[repoStatsTagsIndex] = strconv.Itoa(repo.TagCount)
+	row[repoStatsSizeIndex] = ellipsize(strings.ReplaceAll(humanize.Bytes(repoSize), " ", ""), sizeWidth, ellipsis)
+	row[repoStatsLastUpdatedIndex] = lastUpdated
+
+	table.Append(row)
+	table.Render()
+
+	return builder.String(), nil
+}
+
+func (repo repoStatsStruct) stringJSON() (string, error) {
+	// Output is in json lines format - do not indent, append new line after json
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.Marshal(repo)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+func (repo repoStatsStruct) stringYAML() (string, error) {
+	// Output will be a multidoc yaml - use triple-dash to indicate a new document
+	body, err := yaml.Marshal(&repo)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(body), nil
+}
+
 type imageStruct common.ImageSummary
 
-func (img imageStruct) string(format string, maxImgNameLen, maxTagLen, maxPlatformLen int, verbose bool) (string, error) { //nolint: lll
+// annotatedImageListResponse is the answer of the ImageList query asking for the annotations, which only
+// the recent servers have.
+type annotatedImageListResponse struct {
+	Data struct {
+		ImageList struct {
+			Results []annotatedImageSummary `json:"results"`
+		} `json:"imageList"`
+	} `json:"data"`
+	Errors []common.ErrorGQL `json:"errors"`
+}
+
+type annotatedImageSummary struct {
+	common.ImageSummary
+	Manifests   []annotatedManifestSummary `json:"manifests"`
+	Annotations []common.Annotation        `json:"annotations"`
+}
+
+type annotatedManifestSummary struct {
+	common.ManifestSummary
+	Annotations []common.Annotation `json:"annotations"`
+}
+
+// matchesLabels tells if the image, or one of its manifests, has all the labels. The labels of an index
+// are its annotations, the ones of its manifests are added to them.
+func (img annotatedImageSummary) matchesLabels(labels map[string]string) bool {
+	imageLabels := annotations2Map(img.Annotations)
+
+	if matchesLabels(imageLabels, labels) {
+		return true
+	}
+
+	for _, manifest := range img.Manifests {
+		if matchesLabels(mergeLabels(imageLabels, annotations2Map(manifest.Annotations)), labels) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// imageStruct returns the image without its annotations, as the other listings show it.
+func (img annotatedImageSummary) imageStruct() imageStruct {
+	image := img.ImageSummary
+	image.Manifests = make([]common.ManifestSummary, 0, len(img.Manifests))
+
+	for _, manifest := range img.Manifests {
+		image.Manifests = append(image.Manifests, manifest.ManifestSummary)
+	}
+
+	return imageStruct(image)
+}
+
+func annotations2Map(annotations []common.Annotation) map[string]string {
+	annotationsMap := make(map[string]string, len(annotations))
+
+	for _, annotation := range annotations {
+		annotationsMap[annotation.Key] = annotation.Value
+	}
+
+	return annotationsMap
+}
+
+func (img imageStruct) string(format string, maxImgNameLen, maxTagLen, maxPlatformLen int, verbose bool,
+	layout imageLayout,
+) (string, error) {
 	switch strings.ToLower(format) {
 	case "", defaultOutputFormat:
-		return img.stringPlainText(maxImgNameLen, maxTagLen, maxPlatformLen, verbose)
-	case jsonFormat:
+		return img.stringPlainText(maxImgNameLen, maxTagLen, maxPlatformLen, verbose, layout)
+	case jsonFormat, ndjsonFormat:
 		return img.stringJSON()
 	case ymlFormat, yamlFormat:
 		return img.stringYAML()
+	case csvFormat, tsvFormat:
+		return img.stringSeparatedValues(format, layout.columns)
 	default:
+		if isTemplateFormat(format) {
+			return img.stringTemplate(format)
+		}
+
 		return "", zerr.ErrInvalidOutputFormat
 	}
 }
 
-func (img imageStruct) stringPlainText(maxImgNameLen, maxTagLen, maxPlatformLen int, verbose bool) (string, error) {
+// stringSeparatedValues renders the image as csv or tsv rows, an index also has a row for each of its manifests.
+// Unlike the text table, all the values are shown in full.
+func (img imageStruct) stringSeparatedValues(format string, columns imageColumns) (string, error) {
+	rows := [][]string{}
+
+	if img.MediaType == ispec.MediaTypeImageIndex {
+		row := make([]string, rowWidth)
+		row[colImageNameIndex] = img.RepoName
+		row[colTagIndex] = img.Tag
+		row[colPlatformIndex] = "*"
+		row[colDigestIndex] = img.Digest
+		row[colIsSignedIndex] = strconv.FormatBool(img.IsSigned)
+		row[colSizeIndex] = img.Size
+		row[colAuthorIndex] = img.Authors
+		row[colSignerIndex] = getSignersStr(img.SignatureInfo)
+		row[colPullsIndex] = strconv.Itoa(img.DownloadCount)
+
+		if !img.LastUpdated.IsZero() {
+			row[colCreatedIndex] = img.LastUpdated.Format(time.RFC3339)
+		}
+
+		if img.LastPullTimestamp != nil {
+			row[colLastPullIndex] = img.LastPullTimestamp.Format(time.RFC3339)
+		}
+
+		rows = append(rows, row)
+	}
+
+	for _, manifest := range img.Manifests {
+		layers := make([]string, 0, len(manifest.Layers))
+
+		for _, layer := range manifest.Layers {
+			layers = append(layers, layer.Digest)
+		}
+
+		row := make([]string, rowWidth)
+		row[colImageNameIndex] = img.RepoName
+		row[colTagIndex] = img.Tag
+		row[colPlatformIndex] = getPlatformStr(manifest.Platform)
+		row[colDigestIndex] = manifest.Digest
+		row[colConfigIndex] = manifest.ConfigDigest
+		row[colIsSignedIndex] = strconv.FormatBool(manifest.IsSigned)
+		row[colLayersIndex] = strings.Join(layers, " ")
+		row[colSizeIndex] = manifest.Size
+		row[colAuthorIndex] = img.Authors
+		row[colSignerIndex] = getSignersStr(manifest.SignatureInfo)
+		row[colPullsIndex] = strconv.Itoa(manifest.DownloadCount)
+
+		if !manifest.LastUpdated.IsZero() {
+			row[colCreatedIndex] = manifest.LastUpdated.Format(time.RFC3339)
+		}
+
+		if manifest.LastPullTimestamp != nil {
+			row[colLastPullIndex] = manifest.LastPullTimestamp.Format(time.RFC3339)
+		}
+
+		rows = append(rows, row)
+	}
+
+	shownColumns := columns.getOrAll()
+
+	for i := range rows {
+		rows[i] = shownColumns.row(rows[i])
+	}
+
+	var builder strings.Builder
+
+	if err := writeSeparatedValues(&builder, format, rows); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// stringTemplate renders the image with the given Go template, one line per image.
+func (img imageStruct) stringTemplate(format string) (string, error) {
+	outputTemplate, err := parseOutputTemplate(format)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+
+	if err := outputTemplate.Execute(&builder, img); err != nil {
+		return "", fmt.Errorf("%w: %w", zerr.ErrInvalidOutputFormat, err)
+	}
+
+	return builder.String() + "\n", nil
+}
+
+func (img imageStruct) stringPlainText(maxImgNameLen, maxTagLen, maxPlatformLen int, verbose bool,
+	layout imageLayout,
+) (string, error) {
 	var builder strings.Builder
 
 	table := getImageTableWriter(&builder)
 
-	table.SetColMinWidth(colImageNameIndex, maxImgNameLen)
-	table.SetColMinWidth(colTagIndex, maxTagLen)
-	table.SetColMinWidth(colPlatformIndex, platformWidth)
-	table.SetColMinWidth(colDigestIndex, digestWidth)
-	table.SetColMinWidth(colSizeIndex, sizeWidth)
-	table.SetColMinWidth(colIsSignedIndex, isSignedWidth)
+	layout.columns.setMinWidth(table, colImageNameIndex, maxImgNameLen)
+	layout.columns.setMinWidth(table, colTagIndex, maxTagLen)
+	layout.columns.setMinWidth(table, colPlatformIndex, platformWidth)
+	layout.columns.setMinWidth(table, colDigestIndex, layout.digestColumnWidth())
+	layout.columns.setMinWidth(table, colSizeIndex, sizeWidth)
+	layout.columns.setMinWidth(table, colIsSignedIndex, isSignedWidth)
+	layout.columns.setMinWidth(table, colCreatedIndex, createdWidth)
+	layout.columns.setMinWidth(table, colAuthorIndex, authorWidth)
+	layout.columns.setMinWidth(table, colSignerIndex, signerWidth)
 
 	if verbose {
-		table.SetColMinWidth(colConfigIndex, configWidth)
-		table.SetColMinWidth(colLayersIndex, layersWidth)
+		layout.columns.setMinWidth(table, colConfigIndex, layout.digestColumnWidth())
+		layout.columns.setMinWidth(table, colLayersIndex, layout.digestColumnWidth())
+		layout.columns.setMinWidth(table, colPullsIndex, pullsWidth)
+		layout.columns.setMinWidth(table, colLastPullIndex, lastPullWidth)
 	}
 
 	var imageName, tagName string
@@ -1029,7 +2003,7 @@ func (img imageStruct) stringPlainText(maxImgNameLen, maxTagLen, maxPlatformLen
 		tagName += offset
 	}
 
-	err := addImageToTable(table, &img, maxPlatformLen, imageName, tagName, verbose)
+	err := addImageToTable(table, layout, &img, maxPlatformLen, imageName, tagName, verbose)
 	if err != nil {
 		return "", err
 	}
@@ -1039,20 +2013,21 @@ func (img imageStruct) stringPlainText(maxImgNameLen, maxTagLen, maxPlatformLen
 	return builder.String(), nil
 }
 
-func addImageToTable(table *tablewriter.Table, img *imageStruct, maxPlatformLen int,
+func addImageToTable(table *tablewriter.Table, layout imageLayout, img *imageStruct, maxPlatformLen int,
 	imageName, tagName string, verbose bool,
 ) error {
 	switch img.MediaType {
 	case ispec.MediaTypeImageManifest:
-		return addManifestToTable(table, imageName, tagName, &img.Manifests[0], maxPlatformLen, verbose)
+		return addManifestToTable(table, layout, imageName, tagName, img.Authors, &img.Manifests[0],
+			maxPlatformLen, verbose)
 	case ispec.MediaTypeImageIndex:
-		return addImageIndexToTable(table, img, maxPlatformLen, imageName, tagName, verbose)
+		return addImageIndexToTable(table, layout, img, maxPlatformLen, imageName, tagName, verbose)
 	}
 
 	return nil
 }
 
-func addImageIndexToTable(table *tablewriter.Table, img *imageStruct, maxPlatformLen int,
+func addImageIndexToTable(table *tablewriter.Table, layout imageLayout, img *imageStruct, maxPlatformLen int,
 	imageName, tagName string, verbose bool,
 ) error {
 	indexDigest, err := godigest.Parse(img.Digest)
@@ -1062,22 +2037,27 @@ func addImageIndexToTable(table *tablewriter.Table, img *imageStruct, maxPlatfor
 	row := make([]string, rowWidth)
 	row[colImageNameIndex] = imageName
 	row[colTagIndex] = tagName
-	row[colDigestIndex] = ellipsize(indexDigest.Encoded(), digestWidth, "")
+	row[colDigestIndex] = layout.digest(indexDigest)
 	row[colPlatformIndex] = "*"
 
 	imgSize, _ := strconv.ParseUint(img.Size, 10, 64)
 	row[colSizeIndex] = ellipsize(strings.ReplaceAll(humanize.Bytes(imgSize), " ", ""), sizeWidth, ellipsis)
 	row[colIsSignedIndex] = strconv.FormatBool(img.IsSigned)
+	row[colCreatedIndex] = getCreatedStr(img.LastUpdated)
+	row[colAuthorIndex] = layout.author(img.Authors)
+	row[colSignerIndex] = layout.signer(img.SignatureInfo)
 
 	if verbose {
 		row[colConfigIndex] = ""
 		row[colLayersIndex] = ""
+		row[colPullsIndex] = strconv.Itoa(img.DownloadCount)
+		row[colLastPullIndex] = getLastPullStr(img.LastPullTimestamp)
 	}
 
-	table.Append(row)
+	table.Append(layout.columns.row(row))
 
 	for i := range img.Manifests {
-		err := addManifestToTable(table, "", "", &img.Manifests[i], maxPlatformLen, verbose)
+		err := addManifestToTable(table, layout, "", "", "", &img.Manifests[i], maxPlatformLen, verbose)
 		if err != nil {
 			return err
 		}
@@ -1086,8 +2066,8 @@ func addImageIndexToTable(table *tablewriter.Table, img *imageStruct, maxPlatfor
 	return nil
 }
 
-func addManifestToTable(table *tablewriter.Table, imageName, tagName string, manifest *common.ManifestSummary,
-	maxPlatformLen int, verbose bool,
+func addManifestToTable(table *tablewriter.Table, layout imageLayout, imageName, tagName, author string,
+	manifest *common.ManifestSummary, maxPlatformLen int, verbose bool,
 ) error {
 	manifestDigest, err := godigest.Parse(manifest.Digest)
 	if err != nil {
@@ -1106,12 +2086,12 @@ func addManifestToTable(table *tablewriter.Table, imageName, tagName string, man
 		platform += offset
 	}
 
-	manifestDigestStr := ellipsize(manifestDigest.Encoded(), digestWidth, "")
-	configDigestStr := ellipsize(configDigest.Encoded(), configWidth, "")
+	manifestDigestStr := layout.digest(manifestDigest)
+	configDigestStr := layout.digest(configDigest)
 	imgSize, _ := strconv.ParseUint(manifest.Size, 10, 64)
 	size := ellipsize(strings.ReplaceAll(humanize.Bytes(imgSize), " ", ""), sizeWidth, ellipsis)
 	isSigned := manifest.IsSigned
-	row := make([]string, 8) //nolint:gomnd
+	row := make([]string, rowWidth)
 
 	row[colImageNameIndex] = imageName
 	row[colTagIndex] = tagName
@@ -1119,13 +2099,18 @@ func addManifestToTable(table *tablewriter.Table, imageName, tagName string, man
 	row[colPlatformIndex] = platform
 	row[colSizeIndex] = size
 	row[colIsSignedIndex] = strconv.FormatBool(isSigned)
+	row[colCreatedIndex] = getCreatedStr(manifest.LastUpdated)
+	row[colAuthorIndex] = layout.author(author)
+	row[colSignerIndex] = layout.signer(manifest.SignatureInfo)
 
 	if verbose {
 		row[colConfigIndex] = configDigestStr
 		row[colLayersIndex] = ""
+		row[colPullsIndex] = strconv.Itoa(manifest.DownloadCount)
+		row[colLastPullIndex] = getLastPullStr(manifest.LastPullTimestamp)
 	}
 
-	table.Append(row)
+	table.Append(layout.columns.row(row))
 
 	if verbose {
 		for _, entry := range manifest.Layers {
@@ -1137,9 +2122,9 @@ func addManifestToTable(table *tablewriter.Table, imageName, tagName string, man
 				return fmt.Errorf("error parsing layer digest %s: %w", entry.Digest, err)
 			}
 
-			layerDigestStr := ellipsize(layerDigest.Encoded(), digestWidth, "")
+			layerDigestStr := layout.digest(layerDigest)
 
-			layerRow := make([]string, 8) //nolint:gomnd
+			layerRow := make([]string, rowWidth)
 			layerRow[colImageNameIndex] = ""
 			layerRow[colTagIndex] = ""
 			layerRow[colDigestIndex] = ""
@@ -1148,13 +2133,30 @@ func addManifestToTable(table *tablewriter.Table, imageName, tagName string, man
 			layerRow[colConfigIndex] = ""
 			layerRow[colLayersIndex] = layerDigestStr
 
-			table.Append(layerRow)
+			table.Append(layout.columns.row(layerRow))
 		}
 	}
 
 	return nil
 }
 
+// getCreatedStr returns the time shown in the tables, like the image creation or last pull, "N/A" if it isn't known.
+func getCreatedStr(created time.Time) string {
+	if created.IsZero() {
+		return "N/A"
+	}
+
+	return created.Format(time.RFC3339)
+}
+
+func getLastPullStr(lastPull *time.Time) string {
+	if lastPull == nil {
+		return "N/A"
+	}
+
+	return getCreatedStr(*lastPull)
+}
+
 func getPlatformStr(platform common.Platform) string {
 	if platform.Arch == "" && platform.Os == "" {
 		return ""
@@ -1307,21 +2309,7 @@ func (service searchService) getRepos(ctx context.Context, config SearchConfig,
 	defer wtgrp.Done()
 	defer close(rch)
 
-	catalog := &catalogResponse{}
-
-	catalogEndPoint, err := combineServerAndEndpointURL(config.ServURL, fmt.Sprintf("%s%s",
-		constants.RoutePrefix, constants.ExtCatalogPrefix))
-	if err != nil {
-		if common.IsContextDone(ctx) {
-			return
-		}
-		rch <- stringResult{"", err}
-
-		return
-	}
-
-	_, err = makeGETRequest(ctx, catalogEndPoint, username, password, config.VerifyTLS,
-		config.Debug, catalog, config.ResultWriter)
+	catalog, err := getCatalog(ctx, config, username, password)
 	if err != nil {
 		if common.IsContextDone(ctx) {
 			return
@@ -1344,6 +2332,124 @@ func (service searchService) getRepos(ctx context.Context, config SearchConfig,
 	}
 }
 
+// getRepoStats lists the repositories in the catalog with the number of tags, the size and the
+// last update time of their images. The images are fetched like when listing them, and the per-tag
+// results are aggregated per repository.
+func (service searchService) getRepoStats(ctx context.Context, config SearchConfig, username, password string,
+) ([]repoStatsStruct, error) {
+	stats := map[string]*repoStatsStruct{}
+	sizes := map[string]uint64{}
+
+	repos, err := collectCatalogImages(ctx, config, username, password, func(image imageStruct) {
+		repoStats, found := stats[image.RepoName]
+		if !found {
+			repoStats = &repoStatsStruct{Name: image.RepoName}
+			stats[image.RepoName] = repoStats
+		}
+
+		repoStats.TagCount++
+		sizes[image.RepoName] += uint64(atoiWithDefault(image.Size, 0))
+
+		if image.LastUpdated.After(repoStats.LastUpdated) {
+			repoStats.LastUpdated = image.LastUpdated
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statsList := make([]repoStatsStruct, 0, len(repos))
+
+	for _, repo := range repos {
+		repoStats, found := stats[repo]
+		if !found {
+			repoStats = &repoStatsStruct{Name: repo}
+		}
+
+		repoStats.Size = strconv.FormatUint(sizes[repo], 10)
+		statsList = append(statsList, *repoStats)
+	}
+
+	return statsList, nil
+}
+
+// getStorageStats returns the storage used by each repository in the catalog and by the whole registry,
+// computed from the blobs of the tagged images.
+func (service searchService) getStorageStats(ctx context.Context, config SearchConfig, username, password string,
+) (*storageStatsStruct, error) {
+	images := []imageStruct{}
+
+	repos, err := collectCatalogImages(ctx, config, username, password, func(image imageStruct) {
+		images = append(images, image)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return getStorageStats(repos, images), nil
+}
+
+// collectCatalogImages fetches the tagged images of all the repositories in the catalog and calls collect
+// for each of them, from the calling goroutine. It returns the repositories of the catalog.
+func collectCatalogImages(ctx context.Context, config SearchConfig, username, password string,
+	collect func(image imageStruct),
+) ([]string, error) {
+	catalog, err := getCatalog(ctx, config, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errCh := make(chan stringResult)
+	imagesCh := make(chan imageStruct)
+
+	var localWg sync.WaitGroup
+
+	pool := newSmoothRateLimiter(&localWg, errCh, config)
+	pool.imagesCh = imagesCh
+
+	config.Progress.addRepos(len(catalog.Repositories))
+
+	localWg.Add(1)
+
+	go pool.startRateLimiter(ctx)
+
+	for _, repo := range catalog.Repositories {
+		localWg.Add(1)
+
+		go getImage(ctx, config, username, password, repo, nil, errCh, &localWg, pool)
+	}
+
+	go func() {
+		localWg.Wait()
+		close(imagesCh)
+	}()
+
+	repos := make(map[string]bool, len(catalog.Repositories))
+
+	for _, repo := range catalog.Repositories {
+		repos[repo] = true
+	}
+
+	for {
+		select {
+		case image, ok := <-imagesCh:
+			if !ok {
+				return catalog.Repositories, nil
+			}
+
+			if repos[image.RepoName] {
+				collect(image)
+			}
+		case result := <-errCh:
+			// the first error stops the listing, like for the other commands
+			return nil, result.Err
+		}
+	}
+}
+
 const (
 	imageNameWidth   = 10
 	tagWidth         = 8
@@ -1354,8 +2460,12 @@ const (
 	downloadsWidth   = 10
 	signedWidth      = 10
 	lastUpdatedWidth = 14
-	configWidth      = 8
-	layersWidth      = 8
+	tagsCountWidth   = 6
+	createdWidth     = 20
+	authorWidth      = 24
+	signerWidth      = 24
+	pullsWidth       = 6
+	lastPullWidth    = 20
 	ellipsis         = "..."
 
 	cveIDWidth       = 16
@@ -1378,10 +2488,33 @@ const (
 	colIsSignedIndex
 	colLayersIndex
 	colSizeIndex
+	colCreatedIndex
+	colAuthorIndex
+	colSignerIndex
+	colPullsIndex
+	colLastPullIndex
 
 	rowWidth
 )
 
+// imageColumnNames are the headers of the image tables columns,
+// lowercased they are also the names accepted by --format-columns.
+var imageColumnNames = [rowWidth]string{ //nolint: gochecknoglobals
+	colImageNameIndex: "REPOSITORY",
+	colTagIndex:       "TAG",
+	colPlatformIndex:  "OS/ARCH",
+	colDigestIndex:    "DIGEST",
+	colConfigIndex:    "CONFIG",
+	colIsSignedIndex:  "SIGNED",
+	colLayersIndex:    "LAYERS",
+	colSizeIndex:      sizeColumn,
+	colCreatedIndex:   "CREATED",
+	colAuthorIndex:    "AUTHOR",
+	colSignerIndex:    "SIGNER",
+	colPullsIndex:     "PULLS",
+	colLastPullIndex:  "LAST PULL",
+}
+
 const (
 	repoNameIndex = iota
 	repoSizeIndex
@@ -1393,6 +2526,15 @@ const (
 	repoRowWidth
 )
 
+const (
+	repoStatsNameIndex = iota
+	repoStatsTagsIndex
+	repoStatsSizeIndex
+	repoStatsLastUpdatedIndex
+
+	repoStatsRowWidth
+)
+
 const (
 	refArtifactTypeIndex = iota
 	refSizeIndex
@@ -0,0 +1,223 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sigstore/cosign/v2/pkg/oci/remote"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	sigstoreSigs "github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/common"
+)
+
+const (
+	cosignTool   = "cosign"
+	notationTool = "notation"
+)
+
+// publicKey is one of the keys given with --public-key, the cosign signatures are verified against.
+type publicKey struct {
+	name     string
+	verifier sigstoreSigs.Verifier
+}
+
+func loadPublicKeys(keyFiles []string) ([]publicKey, error) {
+	publicKeys := make([]publicKey, 0, len(keyFiles))
+
+	for _, keyFile := range keyFiles {
+		content, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := cryptoutils.UnmarshalPEMToPublicKey(content)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s is not a public key: %w", zerr.ErrInvalidCLIParameter, keyFile, err)
+		}
+
+		verifier, err := sigstoreSigs.LoadVerifier(key, crypto.SHA256)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s can't verify signatures: %w", zerr.ErrInvalidCLIParameter, keyFile, err)
+		}
+
+		publicKeys = append(publicKeys, publicKey{name: path.Base(keyFile), verifier: verifier})
+	}
+
+	return publicKeys, nil
+}
+
+// getSignatureStatus tells if the manifest is signed. With --verify-signature, only a signature
+// verified with one of the public keys counts, and the signatures found are returned too.
+func getSignatureStatus(ctx context.Context, repo, digestStr string, searchConf SearchConfig,
+	username, password string,
+) (bool, []common.SignatureSummary) {
+	if !searchConf.VerifySignature {
+		isSigned := isCosignSigned(ctx, repo, digestStr, searchConf, username, password) ||
+			isNotationSigned(ctx, repo, digestStr, searchConf, username, password)
+
+		return isSigned, nil
+	}
+
+	signatures := getSignatures(ctx, repo, digestStr, searchConf, username, password)
+
+	return hasTrustedSignature(signatures), signatures
+}
+
+// verifyImageSignatures replaces the signature status the server gave for the image and its manifests
+// with the one verified with the public keys.
+func verifyImageSignatures(ctx context.Context, config SearchConfig, username, password string, img *imageStruct) {
+	for i := range img.Manifests {
+		manifest := &img.Manifests[i]
+		manifest.IsSigned, manifest.SignatureInfo = getSignatureStatus(ctx, img.RepoName, manifest.Digest, config,
+			username, password)
+	}
+
+	if len(img.Manifests) == 1 && img.Manifests[0].Digest == img.Digest {
+		img.IsSigned, img.SignatureInfo = img.Manifests[0].IsSigned, img.Manifests[0].SignatureInfo
+
+		return
+	}
+
+	img.IsSigned, img.SignatureInfo = getSignatureStatus(ctx, img.RepoName, img.Digest, config, username, password)
+}
+
+// getSignatures returns the cosign and notation signatures of the manifest. The cosign ones are
+// trusted if verified with one of the public keys, the notation ones need certificates which
+// can't be given, so they are never trusted.
+func getSignatures(ctx context.Context, repo, digestStr string, searchConf SearchConfig,
+	username, password string,
+) []common.SignatureSummary {
+	signatures := []common.SignatureSummary{}
+
+	cosignManifests := []string{strings.Replace(digestStr, ":", "-", 1) + "." + remote.SignatureTagSuffix}
+
+	for _, descriptor := range getReferrersOfType(ctx, repo, digestStr, common.ArtifactTypeCosign, searchConf,
+		username, password) {
+		cosignManifests = append(cosignManifests, descriptor.Digest.String())
+	}
+
+	for _, reference := range cosignManifests {
+		var manifest ispec.Manifest
+
+		URL := fmt.Sprintf("%s/v2/%s/manifests/%s", searchConf.ServURL, repo, reference)
+
+		if _, err := makeGETRequest(ctx, URL, username, password, searchConf, &manifest); err != nil {
+			continue
+		}
+
+		for _, layer := range manifest.Layers {
+			signatures = append(signatures, verifyCosignSignature(ctx, repo, layer, searchConf, username, password))
+		}
+	}
+
+	for range getReferrersOfType(ctx, repo, digestStr, common.ArtifactTypeNotation, searchConf, username, password) {
+		signatures = append(signatures, common.SignatureSummary{Tool: notationTool})
+	}
+
+	return signatures
+}
+
+func getReferrersOfType(ctx context.Context, repo, digestStr, artifactType string, searchConf SearchConfig,
+	username, password string,
+) []ispec.Descriptor {
+	var referrers ispec.Index
+
+	URL := fmt.Sprintf("%s/v2/%s/referrers/%s?artifactType=%s",
+		searchConf.ServURL, repo, digestStr, url.QueryEscape(artifactType))
+
+	if _, err := makeGETRequest(ctx, URL, username, password, searchConf, &referrers); err != nil {
+		return nil
+	}
+
+	return referrers.Manifests
+}
+
+// verifyCosignSignature verifies the signature in the annotation of the layer against its payload,
+// which is the content of the layer.
+func verifyCosignSignature(ctx context.Context, repo string, layer ispec.Descriptor, searchConf SearchConfig,
+	username, password string,
+) common.SignatureSummary {
+	summary := common.SignatureSummary{Tool: cosignTool}
+
+	signature, err := base64.StdEncoding.DecodeString(layer.Annotations[common.CosignSigKey])
+	if err != nil || len(signature) == 0 {
+		return summary
+	}
+
+	URL := fmt.Sprintf("%s/v2/%s/blobs/%s", searchConf.ServURL, repo, layer.Digest)
+
+	body, err := makeBlobGETRequest(ctx, URL, username, password, searchConf)
+	if err != nil {
+		return summary
+	}
+
+	defer body.Close()
+
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		return summary
+	}
+
+	for _, key := range searchConf.PublicKeys {
+		err := key.verifier.VerifySignature(bytes.NewReader(signature), bytes.NewReader(payload),
+			options.WithContext(ctx))
+		if err == nil {
+			summary.IsTrusted = true
+			summary.Author = key.name
+
+			break
+		}
+	}
+
+	return summary
+}
+
+func hasTrustedSignature(signatures []common.SignatureSummary) bool {
+	for _, signature := range signatures {
+		if signature.IsTrusted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getSignersStr returns the tools and the authors of the trusted signatures. The authors of cosign
+// signatures verified by the server are their public keys, so only the tool is shown for them.
+func getSignersStr(signatures []common.SignatureSummary) string {
+	signers := []string{}
+
+	for _, signature := range signatures {
+		if !signature.IsTrusted {
+			continue
+		}
+
+		signer := signature.Tool
+
+		if signature.Author != "" && !strings.HasPrefix(signature.Author, "-----BEGIN") {
+			signer += ":" + signature.Author
+		}
+
+		if !slices.Contains(signers, signer) {
+			signers = append(signers, signer)
+		}
+	}
+
+	return strings.Join(signers, ",")
+}
@@ -0,0 +1,237 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v2"
+
+	zerr "zotregistry.dev/zot/errors"
+)
+
+const totalStatsName = "TOTAL"
+
+// storageStatsStruct is the storage used by the repositories of a registry. The logical size is what
+// the tagged images would take if each of them was stored on its own, the deduplicated size counts
+// the blobs shared between images once. The exclusive size of a repository is what is only used by it,
+// which is what deleting it would free.
+type storageStatsStruct struct {
+	Repositories []repoStorageStruct `json:"repositories"`
+	Total        repoStorageStruct   `json:"total"`
+}
+
+type repoStorageStruct struct {
+	Name          string `json:"name"`
+	TagCount      int    `json:"tagCount"`
+	BlobCount     int    `json:"blobCount"`
+	LogicalSize   uint64 `json:"logicalSize"`
+	DedupedSize   uint64 `json:"dedupedSize"`
+	ExclusiveSize uint64 `json:"exclusiveSize"`
+}
+
+// getStorageStats aggregates the blobs of the images per repository and for the registry. The
+// repositories are sorted by deduplicated size, the biggest first.
+func getStorageStats(repos []string, images []imageStruct) *storageStatsStruct {
+	repoBlobs := make(map[string]map[string]uint64, len(repos))
+	repoStorage := make(map[string]*repoStorageStruct, len(repos))
+
+	for _, repo := range repos {
+		repoBlobs[repo] = map[string]uint64{}
+		repoStorage[repo] = &repoStorageStruct{Name: repo}
+	}
+
+	for _, image := range images {
+		storage, found := repoStorage[image.RepoName]
+		if !found {
+			continue
+		}
+
+		storage.TagCount++
+		storage.LogicalSize += uint64(atoiWithDefault(image.Size, 0))
+
+		for digest, size := range getImageBlobs(image) {
+			repoBlobs[image.RepoName][digest] = size
+		}
+	}
+
+	// the repositories each blob is used by
+	blobRepos := map[string]int{}
+	total := repoStorageStruct{Name: totalStatsName}
+
+	for _, repo := range repos {
+		for digest, size := range repoBlobs[repo] {
+			if blobRepos[digest] == 0 {
+				total.BlobCount++
+				total.DedupedSize += size
+			}
+
+			blobRepos[digest]++
+		}
+	}
+
+	stats := &storageStatsStruct{Repositories: make([]repoStorageStruct, 0, len(repos))}
+
+	for _, repo := range repos {
+		storage := repoStorage[repo]
+
+		for digest, size := range repoBlobs[repo] {
+			storage.BlobCount++
+			storage.DedupedSize += size
+
+			if blobRepos[digest] == 1 {
+				storage.ExclusiveSize += size
+			}
+		}
+
+		total.TagCount += storage.TagCount
+		total.LogicalSize += storage.LogicalSize
+
+		stats.Repositories = append(stats.Repositories, *storage)
+	}
+
+	total.ExclusiveSize = total.DedupedSize
+	stats.Total = total
+
+	slices.SortStableFunc(stats.Repositories, func(a, b repoStorageStruct) int {
+		if a.DedupedSize != b.DedupedSize {
+			return cmp.Compare(b.DedupedSize, a.DedupedSize)
+		}
+
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return stats
+}
+
+// getImageBlobs returns the sizes of the blobs of the image by digest. The layers are known, the config
+// of each manifest is counted with the manifest, as what is left of the manifest size after its layers,
+// and the same goes for the index and its manifests.
+func getImageBlobs(image imageStruct) map[string]uint64 {
+	blobs := map[string]uint64{}
+
+	var manifestsSize int64
+
+	for _, manifest := range image.Manifests {
+		var layersSize int64
+
+		for _, layer := range manifest.Layers {
+			layerSize := int64(atoiWithDefault(layer.Size, 0))
+			blobs[layer.Digest] = uint64(layerSize)
+			layersSize += layerSize
+		}
+
+		manifestSize := int64(atoiWithDefault(manifest.Size, 0))
+		blobs[manifest.Digest] = uint64(max(manifestSize-layersSize, 0))
+		manifestsSize += manifestSize
+	}
+
+	if len(image.Manifests) != 1 || image.Manifests[0].Digest != image.Digest {
+		imageSize := int64(atoiWithDefault(image.Size, 0))
+		blobs[image.Digest] = uint64(max(imageSize-manifestsSize, 0))
+	}
+
+	return blobs
+}
+
+func (stats storageStatsStruct) string(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return stats.stringPlainText()
+	case jsonFormat:
+		return stats.stringJSON()
+	case ndjsonFormat:
+		return stats.stringNDJSON()
+	case ymlFormat, yamlFormat:
+		return stats.stringYAML()
+	case csvFormat, tsvFormat:
+		return stats.stringSeparatedValues(format)
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (stats storageStatsStruct) stringPlainText() (string, error) {
+	var builder strings.Builder
+
+	table := getImageTableWriter(&builder)
+
+	table.Append([]string{"REPOSITORY", "TAGS", "BLOBS", "LOGICAL SIZE", "DEDUPED SIZE", "EXCLUSIVE SIZE"})
+
+	for _, storage := range append(slices.Clone(stats.Repositories), stats.Total) {
+		table.Append([]string{storage.Name, strconv.Itoa(storage.TagCount), strconv.Itoa(storage.BlobCount),
+			getStorageSizeStr(storage.LogicalSize), getStorageSizeStr(storage.DedupedSize),
+			getStorageSizeStr(storage.ExclusiveSize)})
+	}
+
+	table.Render()
+
+	return builder.String(), nil
+}
+
+func getStorageSizeStr(size uint64) string {
+	return strings.ReplaceAll(humanize.Bytes(size), " ", "")
+}
+
+func (stats storageStatsStruct) stringJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+// stringNDJSON renders one repository per line, then the total.
+func (stats storageStatsStruct) stringNDJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	var builder strings.Builder
+
+	for _, storage := range append(slices.Clone(stats.Repositories), stats.Total) {
+		body, err := json.Marshal(storage)
+		if err != nil {
+			return "", err
+		}
+
+		builder.Write(body)
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
+
+func (stats storageStatsStruct) stringYAML() (string, error) {
+	body, err := yaml.Marshal(stats)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(body), nil
+}
+
+func (stats storageStatsStruct) stringSeparatedValues(format string) (string, error) {
+	rows := [][]string{{"repository", "tags", "blobs", "logical size", "deduped size", "exclusive size"}}
+
+	for _, storage := range append(slices.Clone(stats.Repositories), stats.Total) {
+		rows = append(rows, []string{storage.Name, strconv.Itoa(storage.TagCount), strconv.Itoa(storage.BlobCount),
+			strconv.FormatUint(storage.LogicalSize, 10), strconv.FormatUint(storage.DedupedSize, 10),
+			strconv.FormatUint(storage.ExclusiveSize, 10)})
+	}
+
+	var builder strings.Builder
+
+	if err := writeSeparatedValues(&builder, format, rows); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
@@ -0,0 +1,58 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewStatsCommand(searchService SearchService) *cobra.Command {
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show the storage used by each repository and by the whole registry",
+		Long: `Show the storage used by each repository and by the whole registry, the biggest repositories first.
+The logical size counts the blobs of every tagged image, the deduplicated size counts the blobs shared
+between images once, and the exclusive size counts only the blobs no other repository uses.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			searchConfig, err := GetSearchConfigFromFlags(cmd, searchService)
+			if err != nil {
+				return err
+			}
+
+			return ShowStorageStats(searchConfig)
+		},
+	}
+
+	statsCmd.SetUsageTemplate(statsCmd.UsageTemplate() + usageFooter)
+
+	statsCmd.Flags().String(URLFlag, "",
+		"Specify zot server URL if config-name is not mentioned")
+	statsCmd.Flags().String(ConfigFlag, "",
+		"Specify the registry configuration to use for connection")
+	_ = statsCmd.RegisterFlagCompletionFunc(ConfigFlag, completeConfigNames)
+	statsCmd.Flags().StringP(UserFlag, "u", "",
+		`User Credentials of zot server in "username:password" format`)
+	statsCmd.Flags().StringP(OutputFormatFlag, "f", "", "Specify output format [text/json/ndjson/yaml/csv/tsv]")
+	statsCmd.Flags().Bool(DebugFlag, false,
+		"Show every request made to the registry on stderr, with its status, latency and request id")
+	statsCmd.Flags().Bool(DebugBodyFlag, false, "With --debug, also show the headers and the textual bodies")
+	statsCmd.Flags().Bool(ProgressFlag, false, "Show the progress of the listing on stderr")
+	statsCmd.Flags().Int(PageSizeFlag, 0,
+		"Number of entries to request per page when listing the catalog and the tags, 0 lets the registry decide")
+	statsCmd.Flags().Duration(TimeoutFlag, 0,
+		"Maximum time the whole listing can take, 0 means no limit")
+	statsCmd.Flags().Int(RetriesFlag, defaultRetries,
+		"Number of times a request is retried when the registry is busy or the connection fails")
+	statsCmd.Flags().Duration(RetryMaxWaitFlag, defaultRetryMaxWait,
+		"Maximum time to wait between two retries of a request")
+	statsCmd.Flags().String(CertFlag, "", "Client certificate file to present to the server")
+	statsCmd.Flags().String(KeyFlag, "", "Key file of the client certificate")
+	statsCmd.Flags().String(CACertFlag, "",
+		"CA certificate file used to verify the server, in addition to the system ones")
+	statsCmd.Flags().String(ProxyFlag, "",
+		"Proxy the server is reached through, an http, https or socks5 url [default: HTTPS_PROXY/NO_PROXY]")
+
+	return statsCmd
+}
@@ -0,0 +1,179 @@
+//go:build search
+// +build search
+
+package client_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/cli/client"
+	test "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+type repoStorage struct {
+	Name          string `json:"name"`
+	TagCount      int    `json:"tagCount"`
+	BlobCount     int    `json:"blobCount"`
+	LogicalSize   int64  `json:"logicalSize"`
+	DedupedSize   int64  `json:"dedupedSize"`
+	ExclusiveSize int64  `json:"exclusiveSize"`
+}
+
+func getImageSize(image Image) int64 {
+	size := image.ManifestDescriptor.Size + image.ConfigDescriptor.Size
+
+	for _, layer := range image.Layers {
+		size += int64(len(layer))
+	}
+
+	return size
+}
+
+func TestStorageStats(t *testing.T) {
+	space := regexp.MustCompile(`\s+`)
+
+	port := test.GetFreePort()
+	baseURL := test.GetBaseURL(port)
+	conf := config.New()
+	conf.HTTP.Port = port
+
+	ctlr := api.NewController(conf)
+	ctlr.Config.Storage.RootDirectory = t.TempDir()
+	cm := test.NewControllerManager(ctlr)
+
+	cm.StartAndWait(conf.HTTP.Port)
+	defer cm.StopServer()
+
+	sharedLayer := bytes.Repeat([]byte("s"), 1000)
+
+	image1 := CreateImageWith().LayerBlobs([][]byte{sharedLayer, bytes.Repeat([]byte("1"), 200)}).
+		DefaultConfig().Build()
+	image2 := CreateImageWith().LayerBlobs([][]byte{sharedLayer, bytes.Repeat([]byte("2"), 300)}).
+		DefaultConfig().Build()
+	image3 := CreateImageWith().LayerBlobs([][]byte{sharedLayer, bytes.Repeat([]byte("3"), 50)}).
+		DefaultConfig().Build()
+	multiarch := CreateMultiarchWith().Images([]Image{
+		CreateImageWith().LayerBlobs([][]byte{bytes.Repeat([]byte("4"), 10)}).DefaultConfig().Build(),
+		CreateImageWith().LayerBlobs([][]byte{bytes.Repeat([]byte("5"), 20)}).DefaultConfig().Build(),
+	}).Build()
+
+	for _, upload := range []struct {
+		image     Image
+		repo, tag string
+	}{
+		{image1, "big", "1.0"},
+		{image2, "big", "2.0"},
+		// the same image under another tag doesn't take more space
+		{image2, "big", "latest"},
+		{image3, "small", "1.0"},
+	} {
+		if err := UploadImage(upload.image, baseURL, upload.repo, upload.tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := UploadMultiarchImage(multiarch, baseURL, "multiarch", "1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	runStats := func(args ...string) (string, error) {
+		cmd := client.NewStatsCommand(client.NewSearchService())
+		buff := bytes.NewBufferString("")
+		cmd.SetOut(buff)
+		cmd.SetErr(buff)
+		cmd.SetArgs(append([]string{"--url", baseURL}, args...))
+		err := cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	size1, size2, size3 := getImageSize(image1), getImageSize(image2), getImageSize(image3)
+	multiarchSize := multiarch.IndexDescriptor.Size + getImageSize(multiarch.Images[0]) +
+		getImageSize(multiarch.Images[1])
+
+	Convey("Test storage stats", t, func() {
+		Convey("json output", func() {
+			output, err := runStats("-f", "json")
+			So(err, ShouldBeNil)
+
+			var stats struct {
+				Repositories []repoStorage `json:"repositories"`
+				Total        repoStorage   `json:"total"`
+			}
+
+			err = json.Unmarshal([]byte(output), &stats)
+			So(err, ShouldBeNil)
+			So(len(stats.Repositories), ShouldEqual, 3)
+
+			// sorted by deduplicated size
+			big, small, multi := stats.Repositories[0], stats.Repositories[1], stats.Repositories[2]
+			So(big.Name, ShouldEqual, "big")
+			So(small.Name, ShouldEqual, "small")
+			So(multi.Name, ShouldEqual, "multiarch")
+
+			So(big.TagCount, ShouldEqual, 3)
+			So(big.BlobCount, ShouldEqual, 5)
+			So(big.LogicalSize, ShouldEqual, size1+2*size2)
+			So(big.DedupedSize, ShouldEqual, size1+size2-1000)
+			So(big.ExclusiveSize, ShouldEqual, size1+size2-2000)
+
+			So(small.TagCount, ShouldEqual, 1)
+			So(small.BlobCount, ShouldEqual, 3)
+			So(small.LogicalSize, ShouldEqual, size3)
+			So(small.DedupedSize, ShouldEqual, size3)
+			So(small.ExclusiveSize, ShouldEqual, size3-1000)
+
+			So(multi.TagCount, ShouldEqual, 1)
+			So(multi.BlobCount, ShouldEqual, 5)
+			So(multi.LogicalSize, ShouldEqual, multiarchSize)
+			So(multi.DedupedSize, ShouldEqual, multiarchSize)
+			So(multi.ExclusiveSize, ShouldEqual, multiarchSize)
+
+			So(stats.Total.Name, ShouldEqual, "TOTAL")
+			So(stats.Total.TagCount, ShouldEqual, 5)
+			So(stats.Total.BlobCount, ShouldEqual, 12)
+			So(stats.Total.LogicalSize, ShouldEqual, size1+2*size2+size3+multiarchSize)
+			So(stats.Total.DedupedSize, ShouldEqual, size1+size2+size3-2000+multiarchSize)
+		})
+
+		Convey("text output", func() {
+			output, err := runStats()
+			So(err, ShouldBeNil)
+
+			actual := strings.TrimSpace(space.ReplaceAllString(output, " "))
+			So(actual, ShouldStartWith, "REPOSITORY TAGS BLOBS LOGICAL SIZE DEDUPED SIZE EXCLUSIVE SIZE big 3 5")
+			So(actual, ShouldContainSubstring, "small 1 3")
+			So(actual, ShouldContainSubstring, "TOTAL 5 12")
+		})
+
+		Convey("csv output", func() {
+			output, err := runStats("-f", "csv")
+			So(err, ShouldBeNil)
+
+			lines := strings.Split(strings.TrimSpace(output), "\n")
+			So(len(lines), ShouldEqual, 5)
+			So(lines[0], ShouldEqual, "repository,tags,blobs,logical size,deduped size,exclusive size")
+			So(lines[1], ShouldStartWith, "big,3,5,")
+			So(lines[4], ShouldStartWith, "TOTAL,5,12,")
+		})
+
+		Convey("errors", func() {
+			_, err := runStats("-f", "xml")
+			So(errors.Is(err, zerr.ErrInvalidOutputFormat), ShouldBeTrue)
+
+			_, err = runStats("extra")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
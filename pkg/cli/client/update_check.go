@@ -0,0 +1,201 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v2"
+
+	zerr "zotregistry.dev/zot/errors"
+	zcommon "zotregistry.dev/zot/pkg/common"
+)
+
+const (
+	updateCheckSame    = "same"
+	updateCheckDiffers = "differs"
+	updateCheckMissing = "missing"
+)
+
+// imageUpdateCheck compares the digest of an image with the one of the image it's checked against, in
+// another repo or registry, or in a pin file. The digest is empty if the image wasn't found.
+type imageUpdateCheck struct {
+	Image         string `json:"image"         yaml:"image"`
+	Digest        string `json:"digest"        yaml:"digest"`
+	Against       string `json:"against"       yaml:"against"`
+	AgainstDigest string `json:"againstDigest" yaml:"againstDigest"`
+	Status        string `json:"status"        yaml:"status"`
+}
+
+// imageToCheck is an image and the reference it's checked against, which is the same by default.
+type imageToCheck struct {
+	image   string
+	against string
+}
+
+// readImagesToCheck reads one image per line, optionally followed by the reference it's checked against.
+// Empty lines and the ones starting with '#' are skipped.
+func readImagesToCheck(reader io.Reader) ([]imageToCheck, error) {
+	images := []imageToCheck{}
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) > 2 { //nolint:gomnd
+			return nil, fmt.Errorf("%w: %q should be an image, optionally followed by the one it's checked against",
+				zerr.ErrInvalidCLIParameter, line)
+		}
+
+		image := imageToCheck{image: fields[0], against: fields[len(fields)-1]}
+
+		for _, reference := range fields {
+			if dir, ref, _ := zcommon.GetImageDirAndReference(reference); dir == "" || ref == "" {
+				return nil, fmt.Errorf("%w: %s", zerr.ErrInvalidRepoRefFormat, reference)
+			}
+		}
+
+		images = append(images, image)
+	}
+
+	return images, scanner.Err()
+}
+
+// readImagesToCheckFile reads the images to check from the file, or from stdin if the path is '-'.
+func readImagesToCheckFile(path string, stdin io.Reader) ([]imageToCheck, error) {
+	if path == "-" {
+		return readImagesToCheck(stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return readImagesToCheck(file)
+}
+
+// readImagePins reads a pin file written by --pin-output.
+func readImagePins(path string) (imagePins, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pins := imagePins{}
+
+	if err := yaml.Unmarshal(content, &pins); err != nil {
+		return nil, fmt.Errorf("%w: %s isn't a pin file: %w", zerr.ErrInvalidCLIParameter, path, err)
+	}
+
+	return pins, nil
+}
+
+func getUpdateCheckStatus(digest, againstDigest string) string {
+	switch {
+	case digest == "" || againstDigest == "":
+		return updateCheckMissing
+	case digest != againstDigest:
+		return updateCheckDiffers
+	default:
+		return updateCheckSame
+	}
+}
+
+type imageUpdateChecks []imageUpdateCheck
+
+func (checks imageUpdateChecks) string(format string, noTrunc bool) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return checks.stringPlainText(noTrunc)
+	case jsonFormat:
+		return checks.stringJSON()
+	case ndjsonFormat:
+		return checks.stringNDJSON()
+	case ymlFormat, yamlFormat:
+		return checks.stringYAML()
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (checks imageUpdateChecks) stringPlainText(noTrunc bool) (string, error) {
+	var builder strings.Builder
+
+	writer := tabwriter.NewWriter(&builder, 0, 8, 2, ' ', 0) //nolint:gomnd
+
+	shorten := func(digest string) string {
+		switch {
+		case digest == "":
+			return "-"
+		case noTrunc:
+			return digest
+		default:
+			return getShortDigest(digest)
+		}
+	}
+
+	fmt.Fprintln(writer, "IMAGE\tDIGEST\tAGAINST\tDIGEST\tSTATUS")
+
+	for _, check := range checks {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", check.Image, shorten(check.Digest), check.Against,
+			shorten(check.AgainstDigest), strings.ToUpper(check.Status))
+	}
+
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+func (checks imageUpdateChecks) stringJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.MarshalIndent(checks, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+func (checks imageUpdateChecks) stringNDJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	var builder strings.Builder
+
+	for _, check := range checks {
+		body, err := json.Marshal(check)
+		if err != nil {
+			return "", err
+		}
+
+		builder.Write(body)
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
+
+func (checks imageUpdateChecks) stringYAML() (string, error) {
+	body, err := yaml.Marshal(checks)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(body), nil
+}
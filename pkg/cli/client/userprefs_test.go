@@ -0,0 +1,139 @@
+//go:build search && userprefs
+// +build search,userprefs
+
+package client_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/cli/client"
+	extconf "zotregistry.dev/zot/pkg/extensions/config"
+	test "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+func TestRepoPreferencesCommands(t *testing.T) {
+	Convey("starred and bookmarked repos", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		defaultVal := true
+
+		username, password := "alice", "deepGoesTheRabbitBurrow"
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString(username, password))
+		defer os.Remove(htpasswdPath)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.HTTP.Auth = &config.AuthConfig{HTPasswd: config.AuthHTPasswd{Path: htpasswdPath}}
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}},
+			UI:     &extconf.UIConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}},
+		}
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+		cm := test.NewControllerManager(ctlr)
+
+		cm.StartAndWait(conf.HTTP.Port)
+		defer cm.StopServer()
+
+		for _, repo := range []string{"repo1", "repo2", "repo3"} {
+			err := UploadImageWithBasicAuth(CreateRandomImage(), baseURL, repo, "tag", username, password)
+			So(err, ShouldBeNil)
+		}
+
+		configPath := makeConfigFile(fmt.Sprintf(`{"configs":[{"_name":"prefstest","url":"%s","showspinner":false}]}`,
+			baseURL))
+		defer os.Remove(configPath)
+
+		runRepo := func(args ...string) (string, error) {
+			cmd := client.NewRepoCommand(client.NewSearchService())
+			buff := bytes.NewBufferString("")
+			cmd.SetOut(buff)
+			cmd.SetErr(buff)
+			cmd.SetArgs(append(args, "--config", "prefstest", "--user", username+":"+password))
+			err := cmd.Execute()
+
+			return buff.String(), err
+		}
+
+		listedRepos := func(output string) []string {
+			repos := []string{}
+
+			// the first line is the header
+			for _, line := range strings.Split(strings.TrimSpace(output), "\n")[1:] {
+				repos = append(repos, strings.Fields(line)[0])
+			}
+
+			return repos
+		}
+
+		output, err := runRepo("list", "--starred")
+		So(err, ShouldBeNil)
+		So(strings.TrimSpace(output), ShouldBeEmpty)
+
+		output, err = runRepo("star", "repo1")
+		So(err, ShouldBeNil)
+		So(output, ShouldEqual, "repo1 is now starred\n")
+
+		output, err = runRepo("star", "repo3")
+		So(err, ShouldBeNil)
+		So(output, ShouldEqual, "repo3 is now starred\n")
+
+		// starring again doesn't toggle the star off
+		output, err = runRepo("star", "repo1")
+		So(err, ShouldBeNil)
+		So(output, ShouldEqual, "repo1 is already starred\n")
+
+		output, err = runRepo("bookmark", "repo2")
+		So(err, ShouldBeNil)
+		So(output, ShouldEqual, "repo2 is now bookmarked\n")
+
+		output, err = runRepo("list", "--starred")
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "STARS")
+		So(listedRepos(output), ShouldResemble, []string{"repo1", "repo3"})
+
+		output, err = runRepo("list", "--starred", "--sort-by", "alpha-dsc")
+		So(err, ShouldBeNil)
+		So(listedRepos(output), ShouldResemble, []string{"repo3", "repo1"})
+
+		output, err = runRepo("list", "--bookmarked")
+		So(err, ShouldBeNil)
+		So(listedRepos(output), ShouldResemble, []string{"repo2"})
+
+		output, err = runRepo("unstar", "repo1")
+		So(err, ShouldBeNil)
+		So(output, ShouldEqual, "repo1 is no longer starred\n")
+
+		output, err = runRepo("unstar", "repo1")
+		So(err, ShouldBeNil)
+		So(output, ShouldEqual, "repo1 isn't starred\n")
+
+		output, err = runRepo("unbookmark", "repo2")
+		So(err, ShouldBeNil)
+		So(output, ShouldEqual, "repo2 is no longer bookmarked\n")
+
+		output, err = runRepo("list", "--starred")
+		So(err, ShouldBeNil)
+		So(listedRepos(output), ShouldResemble, []string{"repo3"})
+
+		output, err = runRepo("list", "--bookmarked")
+		So(err, ShouldBeNil)
+		So(strings.TrimSpace(output), ShouldBeEmpty)
+
+		_, err = runRepo("star", "missing")
+		So(err, ShouldNotBeNil)
+
+		_, err = runRepo("list", "--starred", "--bookmarked")
+		So(err, ShouldNotBeNil)
+	})
+}
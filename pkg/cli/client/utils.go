@@ -4,20 +4,35 @@
 package client
 
 import (
+	"bufio"
 	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/dustin/go-humanize"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/olekukonko/tablewriter"
+	godigest "github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
 	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api/constants"
+	"zotregistry.dev/zot/pkg/common"
 )
 
 const (
@@ -36,20 +51,80 @@ func fetchImageDigest(repo, ref, username, password string, config SearchConfig)
 		return "", err
 	}
 
-	res, err := makeHEADRequest(context.Background(), url, username, password, config.VerifyTLS, false)
+	res, err := makeHEADRequest(context.Background(), url, username, password, config)
 
 	digestStr := res.Get(constants.DistContentDigestKey)
 
 	return digestStr, err
 }
 
-func collectResults(config SearchConfig, wg *sync.WaitGroup, imageErr chan stringResult,
+// imageError is the failure to fetch one of the repos or images, unless config.FailFast is set
+// the other results are still shown and the failures are summarized at the end.
+type imageError struct {
+	image string
+	err   error
+}
+
+func newImageError(image string, err error) *imageError {
+	return &imageError{image: image, err: err}
+}
+
+func (e *imageError) Error() string {
+	return e.image + ": " + e.err.Error()
+}
+
+func (e *imageError) Unwrap() error {
+	return e.err
+}
+
+// newSearchContext returns the context of a search, which is canceled once config.Timeout is over
+// or when the user interrupts zli. A second interrupt isn't caught anymore, so it stops zli right away.
+func newSearchContext(config SearchConfig) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if config.Timeout <= 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// getSearchStopError returns why the search was stopped before its end, if it was.
+func getSearchStopError(ctx context.Context) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", zerr.ErrPartialResults, zerr.ErrCLITimeout)
+	case ctx.Err() != nil:
+		return fmt.Errorf("%w: %w", zerr.ErrPartialResults, zerr.ErrSearchInterrupted)
+	default:
+		return nil
+	}
+}
+
+// collectResults prints the results as they come. If the search is stopped by its timeout or by
+// the user, the results received until then are still printed, and the error says they're partial.
+func collectResults(ctx context.Context, config SearchConfig, wg *sync.WaitGroup, imageErr chan stringResult,
 	cancel context.CancelFunc, printHeader printHeader, errCh chan error,
 ) {
 	var foundResult bool
 
+	imageErrors := []*imageError{}
+
 	defer wg.Done()
 	config.Spinner.startSpinner()
+	config.Progress.start()
+
+	defer config.Progress.stop()
 
 	for {
 		select {
@@ -57,11 +132,34 @@ func collectResults(config SearchConfig, wg *sync.WaitGroup, imageErr chan strin
 			config.Spinner.stopSpinner()
 
 			if !ok {
+				stopErr := getSearchStopError(ctx)
+
 				cancel()
 
+				if len(imageErrors) > 0 {
+					printImageErrors(config, imageErrors)
+
+					if stopErr == nil {
+						stopErr = fmt.Errorf("%w: %d failed, the first error: %w", zerr.ErrPartialResults,
+							len(imageErrors), imageErrors[0])
+					}
+				}
+
+				if stopErr != nil {
+					errCh <- stopErr
+				}
+
 				return
 			}
 
+			var failedImage *imageError
+
+			if result.Err != nil && !config.FailFast && errors.As(result.Err, &failedImage) {
+				imageErrors = append(imageErrors, failedImage)
+
+				continue
+			}
+
 			if result.Err != nil {
 				cancel()
 				errCh <- result.Err
@@ -72,10 +170,14 @@ func collectResults(config SearchConfig, wg *sync.WaitGroup, imageErr chan strin
 			if !foundResult && (config.OutputFormat == defaultOutputFormat || config.OutputFormat == "") {
 				var builder strings.Builder
 
-				printHeader(&builder, config.Verbose, 0, 0, 0)
+				printHeader(&builder, config.Verbose, 0, 0, 0, getImageLayout(config))
 				fmt.Fprint(config.ResultWriter, builder.String())
 			}
 
+			if !foundResult && isSeparatedValuesFormat(config.OutputFormat) {
+				printImageSeparatedValuesHeader(config.ResultWriter, config.OutputFormat, config.ImageColumns)
+			}
+
 			foundResult = true
 
 			fmt.Fprint(config.ResultWriter, result.StrValue)
@@ -90,11 +192,56 @@ func collectResults(config SearchConfig, wg *sync.WaitGroup, imageErr chan strin
 	}
 }
 
-func getUsernameAndPassword(user string) (string, string) {
-	if strings.Contains(user, ":") {
-		split := strings.Split(user, ":")
+// printImageErrors shows the images which couldn't be fetched, on stderr so the results stay parsable.
+func printImageErrors(config SearchConfig, imageErrors []*imageError) {
+	writer := config.ErrorWriter
+	if writer == nil {
+		writer = config.ResultWriter
+	}
 
-		return split[0], split[1]
+	if writer == nil {
+		return
+	}
+
+	table := tabwriter.NewWriter(writer, 0, 8, 2, ' ', 0) //nolint:gomnd
+
+	fmt.Fprintf(table, "\n%d of the images couldn't be fetched:\n", len(imageErrors))
+	fmt.Fprintln(table, "IMAGE\tHTTP STATUS\tERROR")
+
+	for _, imageErr := range imageErrors {
+		status := "N/A"
+
+		var statusErr *httpStatusError
+		if errors.As(imageErr.err, &statusErr) {
+			status = strconv.Itoa(statusErr.statusCode)
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\n", imageErr.image, status, strings.ReplaceAll(imageErr.err.Error(), "\n", " "))
+	}
+
+	_ = table.Flush()
+}
+
+func parseLabelSelectors(labels []string) (map[string]string, error) {
+	selectors := make(map[string]string, len(labels))
+
+	for _, label := range labels {
+		key, value, found := strings.Cut(label, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("%w: expected a label in 'key=value' format, got '%s'",
+				zerr.ErrInvalidCLIParameter, label)
+		}
+
+		selectors[key] = value
+	}
+
+	return selectors, nil
+}
+
+func getUsernameAndPassword(user string) (string, string) {
+	// the password may contain colons, the username can't
+	if username, password, found := strings.Cut(user, ":"); found {
+		return username, password
 	}
 
 	return "", ""
@@ -126,24 +273,252 @@ type stringResult struct {
 	Err      error
 }
 
-type printHeader func(writer io.Writer, verbose bool, maxImageNameLen, maxTagLen, maxPlatformLen int)
+type printHeader func(writer io.Writer, verbose bool, maxImageNameLen, maxTagLen, maxPlatformLen int,
+	layout imageLayout)
+
+// imageColumns are the columns of the image tables to show, in order, empty means the default ones.
+type imageColumns []int
+
+func defaultImageColumns() imageColumns {
+	return imageColumns{
+		colImageNameIndex, colTagIndex, colPlatformIndex, colDigestIndex,
+		colConfigIndex, colIsSignedIndex, colLayersIndex, colSizeIndex,
+		colPullsIndex, colLastPullIndex,
+	}
+}
+
+func (columns imageColumns) get() imageColumns {
+	if len(columns) == 0 {
+		return defaultImageColumns()
+	}
+
+	return columns
+}
+
+// getOrAll returns the shown columns, or all of them if none are selected, which is the default
+// of the machine readable formats.
+func (columns imageColumns) getOrAll() imageColumns {
+	if len(columns) == 0 {
+		columns = make(imageColumns, 0, rowWidth)
+
+		for column := 0; column < rowWidth; column++ {
+			columns = append(columns, column)
+		}
+	}
+
+	return columns
+}
+
+// row returns the cells of the shown columns from a full image table row.
+func (columns imageColumns) row(fullRow []string) []string {
+	row := make([]string, 0, len(columns.get()))
+
+	for _, column := range columns.get() {
+		row = append(row, fullRow[column])
+	}
+
+	return row
+}
+
+// setMinWidth sets the min width of the column if it's shown.
+func (columns imageColumns) setMinWidth(table *tablewriter.Table, column, width int) {
+	for position, shownColumn := range columns.get() {
+		if shownColumn == column {
+			table.SetColMinWidth(position, width)
+		}
+	}
+}
+
+const (
+	// the digests are shown with their algorithm when they aren't truncated, like in the image references
+	fullDigestWidth = len("sha256:") + 64
+	maxDigestWidth  = 64
+
+	// when sizing the columns to the terminal the repo names and tags are assumed this long
+	assumedImageNameWidth = 32
+	assumedTagWidth       = 16
+	imageTablePadding     = 2
+)
+
+// imageLayout is how the images are shown in the text tables.
+type imageLayout struct {
+	columns imageColumns
+	// the length the digests are truncated to, 0 means the default one
+	digestWidth int
+	noTrunc     bool
+}
+
+// getImageLayout returns the layout of the image tables. On a terminal the digests take the width
+// the other columns leave, else they keep their fixed width.
+func getImageLayout(config SearchConfig) imageLayout {
+	layout := imageLayout{columns: config.ImageColumns, noTrunc: config.NoTrunc}
+
+	if config.NoTrunc || config.TerminalWidth == 0 {
+		return layout
+	}
+
+	digestColumns := 0
+	usedWidth := 0
+
+	for _, column := range layout.columns.get() {
+		switch column {
+		case colDigestIndex:
+			digestColumns++
+		case colConfigIndex, colLayersIndex:
+			// these are empty unless verbose
+			if config.Verbose {
+				digestColumns++
+			}
+		case colPullsIndex, colLastPullIndex:
+			if config.Verbose {
+				usedWidth += getImageColumnWidth(column)
+			}
+		default:
+			usedWidth += getImageColumnWidth(column)
+		}
+
+		usedWidth += imageTablePadding
+	}
+
+	if digestColumns > 0 {
+		layout.digestWidth = min(max((config.TerminalWidth-usedWidth)/digestColumns, digestWidth), maxDigestWidth)
+	}
+
+	return layout
+}
+
+func getImageColumnWidth(column int) int {
+	switch column {
+	case colImageNameIndex:
+		return assumedImageNameWidth
+	case colTagIndex:
+		return assumedTagWidth
+	case colPlatformIndex:
+		return platformWidth
+	case colIsSignedIndex:
+		return isSignedWidth
+	case colSizeIndex:
+		return sizeWidth
+	case colCreatedIndex:
+		return createdWidth
+	case colAuthorIndex:
+		return authorWidth
+	case colSignerIndex:
+		return signerWidth
+	case colPullsIndex:
+		return pullsWidth
+	case colLastPullIndex:
+		return lastPullWidth
+	default:
+		return 0
+	}
+}
+
+func (layout imageLayout) digestColumnWidth() int {
+	if layout.noTrunc {
+		return fullDigestWidth
+	}
+
+	if layout.digestWidth == 0 {
+		return digestWidth
+	}
+
+	return layout.digestWidth
+}
+
+func (layout imageLayout) digest(digest godigest.Digest) string {
+	if layout.noTrunc {
+		return digest.String()
+	}
+
+	return ellipsize(digest.Encoded(), layout.digestColumnWidth(), "")
+}
+
+func (layout imageLayout) author(author string) string {
+	if layout.noTrunc {
+		return author
+	}
+
+	return ellipsize(author, authorWidth, ellipsis)
+}
+
+func (layout imageLayout) signer(signatures []common.SignatureSummary) string {
+	if layout.noTrunc {
+		return getSignersStr(signatures)
+	}
+
+	return ellipsize(getSignersStr(signatures), signerWidth, ellipsis)
+}
+
+// getTerminalWidth returns the width of the terminal the writer is, 0 if it isn't one.
+func getTerminalWidth(writer io.Writer) int {
+	file, ok := writer.(*os.File)
+	if !ok || !term.IsTerminal(int(file.Fd())) {
+		return 0
+	}
+
+	width, _, err := term.GetSize(int(file.Fd()))
+	if err != nil {
+		return 0
+	}
 
-func printImageTableHeader(writer io.Writer, verbose bool, maxImageNameLen, maxTagLen, maxPlatformLen int) {
+	return width
+}
+
+// parseImageColumns parses a comma separated list of image table column names.
+func parseImageColumns(columnsList string) (imageColumns, error) {
+	if columnsList == "" {
+		return nil, nil
+	}
+
+	columns := imageColumns{}
+
+	for _, name := range strings.Split(columnsList, ",") {
+		column := slices.Index(imageColumnNames[:], strings.ToUpper(strings.TrimSpace(name)))
+		if column < 0 {
+			return nil, fmt.Errorf("%w: unknown column '%s' for --%s, the columns are: %s",
+				zerr.ErrInvalidCLIParameter, name, FormatColumnsFlag, imageColumnsStr())
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+func imageColumnsStr() string {
+	names := make([]string, 0, len(imageColumnNames))
+
+	for _, name := range imageColumnNames {
+		names = append(names, strings.ToLower(name))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func printImageTableHeader(writer io.Writer, verbose bool, maxImageNameLen, maxTagLen, maxPlatformLen int,
+	layout imageLayout,
+) {
 	table := getImageTableWriter(writer)
 
-	table.SetColMinWidth(colImageNameIndex, imageNameWidth)
-	table.SetColMinWidth(colTagIndex, tagWidth)
-	table.SetColMinWidth(colPlatformIndex, platformWidth)
-	table.SetColMinWidth(colDigestIndex, digestWidth)
-	table.SetColMinWidth(colSizeIndex, sizeWidth)
-	table.SetColMinWidth(colIsSignedIndex, isSignedWidth)
+	layout.columns.setMinWidth(table, colImageNameIndex, imageNameWidth)
+	layout.columns.setMinWidth(table, colTagIndex, tagWidth)
+	layout.columns.setMinWidth(table, colPlatformIndex, platformWidth)
+	layout.columns.setMinWidth(table, colDigestIndex, layout.digestColumnWidth())
+	layout.columns.setMinWidth(table, colSizeIndex, sizeWidth)
+	layout.columns.setMinWidth(table, colIsSignedIndex, isSignedWidth)
+	layout.columns.setMinWidth(table, colCreatedIndex, createdWidth)
+	layout.columns.setMinWidth(table, colAuthorIndex, authorWidth)
+	layout.columns.setMinWidth(table, colSignerIndex, signerWidth)
 
 	if verbose {
-		table.SetColMinWidth(colConfigIndex, configWidth)
-		table.SetColMinWidth(colLayersIndex, layersWidth)
+		layout.columns.setMinWidth(table, colConfigIndex, layout.digestColumnWidth())
+		layout.columns.setMinWidth(table, colLayersIndex, layout.digestColumnWidth())
+		layout.columns.setMinWidth(table, colPullsIndex, pullsWidth)
+		layout.columns.setMinWidth(table, colLastPullIndex, lastPullWidth)
 	}
 
-	row := make([]string, 8) //nolint:gomnd
+	row := make([]string, rowWidth)
 
 	// adding spaces so that repository and tag columns are aligned
 	// in case the name/tag are fully shown and too long
@@ -169,19 +544,36 @@ func printImageTableHeader(writer io.Writer, verbose bool, maxImageNameLen, maxT
 		row[colPlatformIndex] = "OS/ARCH"
 	}
 
-	row[colDigestIndex] = "DIGEST"
-	row[colSizeIndex] = sizeColumn
-	row[colIsSignedIndex] = "SIGNED"
+	row[colDigestIndex] = imageColumnNames[colDigestIndex]
+	row[colSizeIndex] = imageColumnNames[colSizeIndex]
+	row[colIsSignedIndex] = imageColumnNames[colIsSignedIndex]
+	row[colCreatedIndex] = imageColumnNames[colCreatedIndex]
+	row[colAuthorIndex] = imageColumnNames[colAuthorIndex]
+	row[colSignerIndex] = imageColumnNames[colSignerIndex]
 
 	if verbose {
-		row[colConfigIndex] = "CONFIG"
-		row[colLayersIndex] = "LAYERS"
+		row[colConfigIndex] = imageColumnNames[colConfigIndex]
+		row[colLayersIndex] = imageColumnNames[colLayersIndex]
+		row[colPullsIndex] = imageColumnNames[colPullsIndex]
+		row[colLastPullIndex] = imageColumnNames[colLastPullIndex]
 	}
 
-	table.Append(row)
+	table.Append(layout.columns.row(row))
 	table.Render()
 }
 
+// printImageSeparatedValuesHeader prints the header row of the csv or tsv image results.
+func printImageSeparatedValuesHeader(writer io.Writer, format string, columns imageColumns) {
+	header := []string{}
+
+	for _, column := range columns.getOrAll() {
+		header = append(header, strings.ToLower(imageColumnNames[column]))
+	}
+
+	// writing to a strings.Builder or the results writer, errors would be seen on the results too
+	_ = writeSeparatedValues(writer, format, [][]string{header})
+}
+
 func printCVETableHeader(writer io.Writer) {
 	table := getCVETableWriter(writer)
 	row := make([]string, 3) //nolint:gomnd
@@ -269,6 +661,48 @@ func printRepoTableHeader(writer io.Writer, repoMaxLen, maxTimeLen int, verbose
 	table.Render()
 }
 
+func printRepoStatsTableHeader(writer io.Writer, maxRepoNameLen int) {
+	table := getRepoTableWriter(writer)
+
+	table.SetColMinWidth(repoStatsNameIndex, maxRepoNameLen)
+	table.SetColMinWidth(repoStatsTagsIndex, tagsCountWidth)
+	table.SetColMinWidth(repoStatsSizeIndex, sizeWidth)
+
+	row := make([]string, repoStatsRowWidth)
+	row[repoStatsNameIndex] = "NAME"
+	row[repoStatsTagsIndex] = "TAGS"
+	row[repoStatsSizeIndex] = sizeColumn
+	row[repoStatsLastUpdatedIndex] = "LAST UPDATED"
+
+	table.Append(row)
+	table.Render()
+}
+
+func printRepoStatsResults(config SearchConfig, repoStatsList []repoStatsStruct) error {
+	maxRepoNameLen := len("NAME")
+
+	for _, repo := range repoStatsList {
+		if maxRepoNameLen < len(repo.Name) {
+			maxRepoNameLen = len(repo.Name)
+		}
+	}
+
+	if len(repoStatsList) > 0 && (config.OutputFormat == defaultOutputFormat || config.OutputFormat == "") {
+		printRepoStatsTableHeader(config.ResultWriter, maxRepoNameLen)
+	}
+
+	for _, repo := range repoStatsList {
+		out, err := repo.string(config.OutputFormat, maxRepoNameLen)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(config.ResultWriter, out)
+	}
+
+	return nil
+}
+
 func printReferrersResult(config SearchConfig, referrersList referrersResult, maxArtifactTypeLen int) error {
 	out, err := referrersList.string(config.OutputFormat, maxArtifactTypeLen)
 	if err != nil {
@@ -280,12 +714,20 @@ func printReferrersResult(config SearchConfig, referrersList referrersResult, ma
 	return nil
 }
 
-func printImageResult(config SearchConfig, imageList []imageStruct) error {
+func printImageResult(ctx context.Context, config SearchConfig, imageList []imageStruct) error {
 	var builder strings.Builder
 	maxImgNameLen := 0
 	maxTagLen := 0
 	maxPlatformLen := 0
 
+	if config.VerifySignature {
+		username, password := getUsernameAndPassword(config.User)
+
+		for i := range imageList {
+			verifyImageSignatures(ctx, config, username, password, &imageList[i])
+		}
+	}
+
 	if len(imageList) > 0 {
 		for i := range imageList {
 			if maxImgNameLen < len(imageList[i].RepoName) {
@@ -306,7 +748,11 @@ func printImageResult(config SearchConfig, imageList []imageStruct) error {
 		}
 
 		if config.OutputFormat == defaultOutputFormat || config.OutputFormat == "" {
-			printImageTableHeader(&builder, config.Verbose, maxImgNameLen, maxTagLen, maxPlatformLen)
+			printImageTableHeader(&builder, config.Verbose, maxImgNameLen, maxTagLen, maxPlatformLen, getImageLayout(config))
+		}
+
+		if isSeparatedValuesFormat(config.OutputFormat) {
+			printImageSeparatedValuesHeader(&builder, config.OutputFormat, config.ImageColumns)
 		}
 
 		fmt.Fprint(config.ResultWriter, builder.String())
@@ -316,7 +762,7 @@ func printImageResult(config SearchConfig, imageList []imageStruct) error {
 		img := imageList[i]
 		verbose := config.Verbose
 
-		out, err := img.string(config.OutputFormat, maxImgNameLen, maxTagLen, maxPlatformLen, verbose)
+		out, err := img.string(config.OutputFormat, maxImgNameLen, maxTagLen, maxPlatformLen, verbose, getImageLayout(config))
 		if err != nil {
 			return err
 		}
@@ -369,28 +815,218 @@ func GetSearchConfigFromFlags(cmd *cobra.Command, searchService SearchService) (
 	}
 
 	flags := cmd.Flags()
-	user := defaultIfError(flags.GetString(UserFlag))
+	user, err := getConfigUser(defaultIfError(flags.GetString(UserFlag)), getConfigName(cmd), serverURL)
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	registries, err := getRegistries(cmd, defaultIfError(flags.GetString(UserFlag)))
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
 	fixed := defaultIfError(flags.GetBool(FixedFlag))
-	debug := defaultIfError(flags.GetBool(DebugFlag))
+	nameRegex := defaultIfError(flags.GetBool(RegexFlag))
+	debugBody := defaultIfError(flags.GetBool(DebugBodyFlag))
+	debug := defaultIfError(flags.GetBool(DebugFlag)) || debugBody
 	verbose := defaultIfError(flags.GetBool(VerboseFlag))
-	outputFormat := defaultIfError(flags.GetString(OutputFormatFlag))
+	outputFormat, err := getStringOption(cmd, OutputFormatFlag, outputConfig)
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	outputFormat, err = getQuietOutputFormat(cmd, outputFormat)
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	// a bad template is reported before any request is made
+	if isTemplateFormat(outputFormat) {
+		if _, err := parseOutputTemplate(outputFormat); err != nil {
+			return SearchConfig{}, err
+		}
+	}
+
+	imageColumns, err := parseImageColumns(defaultIfError(flags.GetString(FormatColumnsFlag)))
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	noTrunc := defaultIfError(flags.GetBool(NoTruncFlag))
+	failFast := defaultIfError(flags.GetBool(FailFastFlag))
+
+	verifySignature, publicKeys, err := getSignatureOptions(cmd)
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
 	sortBy := defaultIfError(flags.GetString(SortByFlag))
+	pageSize := defaultIfError(flags.GetInt(PageSizeFlag))
+
+	if pageSize < 0 {
+		return SearchConfig{}, fmt.Errorf("%w: --%s can't be negative", zerr.ErrInvalidCLIParameter, PageSizeFlag)
+	}
+
+	maxConcurrentRequests, err := getIntOption(cmd, MaxConcurrentRequestsFlag, maxConcurrentRequestsConfig)
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	requestsPerSecond, err := getIntOption(cmd, RequestsPerSecondFlag, requestsPerSecondConfig)
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	maxIdleConnsPerHost, err := getIntOption(cmd, MaxIdleConnsPerHostFlag, maxIdleConnsPerHostConfig)
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	requestTimeout := defaultIfError(flags.GetDuration(RequestTimeoutFlag))
+
+	if requestTimeout < 0 {
+		return SearchConfig{}, fmt.Errorf("%w: --%s can't be negative", zerr.ErrInvalidCLIParameter,
+			RequestTimeoutFlag)
+	}
+
+	timeout := defaultIfError(flags.GetDuration(TimeoutFlag))
+
+	if timeout < 0 {
+		return SearchConfig{}, fmt.Errorf("%w: --%s can't be negative", zerr.ErrInvalidCLIParameter, TimeoutFlag)
+	}
+
+	retries := defaultIfError(flags.GetInt(RetriesFlag))
+
+	if retries < 0 {
+		return SearchConfig{}, fmt.Errorf("%w: --%s can't be negative", zerr.ErrInvalidCLIParameter, RetriesFlag)
+	}
+
+	retryMaxWait := defaultIfError(flags.GetDuration(RetryMaxWaitFlag))
+
+	limitRate := defaultIfError(flags.GetString(LimitRateFlag))
+
+	var bandwidthLimit uint64
+
+	if limitRate != "" {
+		bandwidthLimit, err = humanize.ParseBytes(limitRate)
+		if err != nil {
+			return SearchConfig{}, fmt.Errorf("%w: invalid --%s %q", zerr.ErrInvalidCLIParameter, LimitRateFlag,
+				limitRate)
+		}
+	}
+
+	certFile, keyFile, caCertFile, err := getCertOptions(cmd)
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	proxy, err := getRegistryProxy(cmd, getConfigName(cmd))
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	var cache *manifestCache
+
+	if noCache := defaultIfError(flags.GetBool(NoCacheFlag)); !noCache {
+		// without a cache directory the manifests are just fetched every time
+		if cacheDir, err := getDefaultCacheDir(); err == nil {
+			cache = newManifestCache(cacheDir)
+		}
+	}
+
+	var progress *progressReporter
+
+	// the spinner and the progress would write over each other
+	if defaultIfError(flags.GetBool(ProgressFlag)) {
+		progress = newProgressReporter(cmd.ErrOrStderr())
+		isSpinner = false
+	}
 
 	spin := spinner.New(spinner.CharSets[39], spinnerDuration, spinner.WithWriter(cmd.ErrOrStderr()))
 	spin.Prefix = prefix
 
+	serverURL, socket := splitUnixSocketURL(serverURL)
+
 	return SearchConfig{
 		SearchService: searchService,
 		ServURL:       serverURL,
+		Socket:        socket,
+		Registries:    registries,
 		User:          user,
 		OutputFormat:  outputFormat,
+		ImageColumns:  imageColumns,
+		NoTrunc:       noTrunc,
+		FailFast:      failFast,
+		TerminalWidth: getTerminalWidth(cmd.OutOrStdout()),
 		VerifyTLS:     verifyTLS,
 		FixedFlag:     fixed,
+		NameRegex:     nameRegex,
 		Verbose:       verbose,
 		Debug:         debug,
+		DebugBody:     debugBody,
 		SortBy:        sortBy,
+		PageSize:      pageSize,
+		Cache:         cache,
 		Spinner:       spinnerState{spin, isSpinner},
 		ResultWriter:  cmd.OutOrStdout(),
+		ErrorWriter:   cmd.ErrOrStderr(),
+
+		VerifySignature:       verifySignature,
+		PublicKeys:            publicKeys,
+		MaxConcurrentRequests: maxConcurrentRequests,
+		RequestsPerSecond:     requestsPerSecond,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		RequestTimeout:        requestTimeout,
+		Timeout:               timeout,
+		Retries:               retries,
+		RetryMaxWait:          retryMaxWait,
+		CertFile:              certFile,
+		KeyFile:               keyFile,
+		CACertFile:            caCertFile,
+		Proxy:                 proxy,
+		Progress:              progress,
+		BandwidthLimiter:      newBandwidthLimiter(bandwidthLimit),
+	}, nil
+}
+
+// GetOCILayoutSearchConfigFromFlags returns the config to list the images of a local oci layout, only
+// the output options apply since no registry is involved.
+func GetOCILayoutSearchConfigFromFlags(cmd *cobra.Command, searchService SearchService) (SearchConfig, error) {
+	flags := cmd.Flags()
+
+	for _, flagName := range []string{URLFlag, ConfigFlag, UserFlag, VerifySignatureFlag} {
+		if flags.Changed(flagName) {
+			return SearchConfig{}, fmt.Errorf("%w: --%s can't be used with --%s", zerr.ErrInvalidCLIParameter,
+				flagName, OCILayoutFlag)
+		}
+	}
+
+	outputFormat, err := getQuietOutputFormat(cmd, defaultIfError(flags.GetString(OutputFormatFlag)))
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	if isTemplateFormat(outputFormat) {
+		if _, err := parseOutputTemplate(outputFormat); err != nil {
+			return SearchConfig{}, err
+		}
+	}
+
+	imageColumns, err := parseImageColumns(defaultIfError(flags.GetString(FormatColumnsFlag)))
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	return SearchConfig{
+		SearchService: searchService,
+		OutputFormat:  outputFormat,
+		ImageColumns:  imageColumns,
+		NoTrunc:       defaultIfError(flags.GetBool(NoTruncFlag)),
+		TerminalWidth: getTerminalWidth(cmd.OutOrStdout()),
+		Verbose:       defaultIfError(flags.GetBool(VerboseFlag)),
+		SortBy:        defaultIfError(flags.GetString(SortByFlag)),
+		ResultWriter:  cmd.OutOrStdout(),
+		ErrorWriter:   cmd.ErrOrStderr(),
 	}, nil
 }
 
@@ -404,12 +1040,230 @@ func defaultIfError[T any](out T, err error) T {
 	return out
 }
 
-func GetCliConfigOptions(cmd *cobra.Command) (bool, bool, error) {
-	configName, err := cmd.Flags().GetString(ConfigFlag)
+// getIntOption returns the value of the given flag if it was set, else the value of the given
+// parameter from the cli config, 0 meaning neither of them is set.
+func getIntOption(cmd *cobra.Command, flagName, configParam string) (int, error) {
+	flags := cmd.Flags()
+
+	if flags.Changed(flagName) {
+		value, err := flags.GetInt(flagName)
+		if err != nil {
+			return 0, err
+		}
+
+		if value <= 0 {
+			return 0, fmt.Errorf("%w: --%s should be a positive number", zerr.ErrInvalidCLIParameter, flagName)
+		}
+
+		return value, nil
+	}
+
+	configName := getConfigName(cmd)
+	if configName == "" {
+		return 0, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := parseIntConfig(path.Join(home, "/.zot"), configName, configParam)
+	if err != nil {
+		return 0, err
+	}
+
+	if value < 0 {
+		return 0, fmt.Errorf("%w: '%s' should be a positive number", zerr.ErrCliBadConfig, configParam)
+	}
+
+	return value, nil
+}
+
+// getStringOption returns the value of the flag if it was given, else the value set in the config.
+func getStringOption(cmd *cobra.Command, flagName, configParam string) (string, error) {
+	flags := cmd.Flags()
+
+	if flags.Changed(flagName) {
+		return flags.GetString(flagName)
+	}
+
+	configName := getConfigName(cmd)
+	if configName == "" {
+		return "", nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return getConfigValue(path.Join(home, "/.zot"), configName, configParam)
+}
+
+// getDestSearchConfig returns the config of the registry images are copied to, given by --dest-url
+// or --dest-config. It's the source registry, with the same credentials, if neither are given.
+func getDestSearchConfig(cmd *cobra.Command, config SearchConfig) (SearchConfig, error) {
+	flags := cmd.Flags()
+	destURL := defaultIfError(flags.GetString(DestURLFlag))
+	destConfigName := defaultIfError(flags.GetString(DestConfigFlag))
+	destUser := defaultIfError(flags.GetString(DestUserFlag))
+
+	if destURL != "" && destConfigName != "" {
+		return SearchConfig{}, fmt.Errorf("%w: --%s and --%s can't be used together", zerr.ErrInvalidCLIParameter,
+			DestURLFlag, DestConfigFlag)
+	}
+
+	destConfig := config
+
+	if destURL == "" && destConfigName == "" {
+		if destUser != "" {
+			destConfig.User = destUser
+		}
+
+		return destConfig, nil
+	}
+
+	if destConfigName != "" {
+		serverURL, err := ReadServerURLFromConfig(destConfigName)
+		if err != nil {
+			return SearchConfig{}, fmt.Errorf("reading url from config failed: %w", err)
+		}
+
+		destURL = serverURL
+	}
+
+	if err := validateURL(destURL); err != nil {
+		return SearchConfig{}, err
+	}
+
+	user, err := getConfigUser(destUser, destConfigName, destURL)
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	// the proxy of the source registry may not reach the destination
+	proxy, err := getRegistryProxy(cmd, destConfigName)
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	destConfig.ServURL, destConfig.Socket = splitUnixSocketURL(destURL)
+	destConfig.User = user
+	destConfig.Proxy = proxy
+
+	return destConfig, nil
+}
+
+// getConfigUser returns the credentials given by the user, else the ones in the environment variable
+// referenced by the config, else the docker credentials of the registry.
+func getConfigUser(user, configName, serverURL string) (string, error) {
+	if user == "" && configName != "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		credentialsEnv, err := getConfigValue(path.Join(home, "/.zot"), configName, credentialsEnvConfig)
+		if err != nil {
+			return "", err
+		}
+
+		if credentialsEnv != "" {
+			user = os.Getenv(credentialsEnv)
+		}
+	}
+
+	return resolveUser(user, serverURL)
+}
+
+func getCertOptions(cmd *cobra.Command) (string, string, string, error) {
+	certFile, err := getStringOption(cmd, CertFlag, certConfig)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	keyFile, err := getStringOption(cmd, KeyFlag, keyConfig)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	caCertFile, err := getStringOption(cmd, CACertFlag, caCertConfig)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if (certFile == "") != (keyFile == "") {
+		return "", "", "", fmt.Errorf("%w: the client certificate and its key should be given together",
+			zerr.ErrInvalidCLIParameter)
+	}
+
+	return certFile, keyFile, caCertFile, nil
+}
+
+// getRegistryProxy returns the --proxy value, else the proxy of the registry's config if it has one.
+// Without proxy the one of the environment variables is used.
+func getRegistryProxy(cmd *cobra.Command, configName string) (string, error) {
+	flags := cmd.Flags()
+	proxy := defaultIfError(flags.GetString(ProxyFlag))
+
+	if !flags.Changed(ProxyFlag) && configName != "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		proxy, err = getConfigValue(path.Join(home, "/.zot"), configName, proxyConfig)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if proxy == "" {
+		return "", nil
+	}
+
+	if _, err := parseProxyURL(proxy); err != nil {
+		return "", err
+	}
+
+	return proxy, nil
+}
+
+// getSignatureOptions returns if the signatures are verified, and the public keys they're verified with.
+func getSignatureOptions(cmd *cobra.Command) (bool, []publicKey, error) {
+	flags := cmd.Flags()
+	verifySignature := defaultIfError(flags.GetBool(VerifySignatureFlag))
+	keyFiles := defaultIfError(flags.GetStringSlice(PublicKeyFlag))
+
+	if !verifySignature {
+		if len(keyFiles) > 0 {
+			return false, nil, fmt.Errorf("%w: --%s is only used with --%s", zerr.ErrInvalidCLIParameter,
+				PublicKeyFlag, VerifySignatureFlag)
+		}
+
+		return false, nil, nil
+	}
+
+	if len(keyFiles) == 0 {
+		return false, nil, fmt.Errorf("%w: --%s needs at least one --%s", zerr.ErrInvalidCLIParameter,
+			VerifySignatureFlag, PublicKeyFlag)
+	}
+
+	publicKeys, err := loadPublicKeys(keyFiles)
 	if err != nil {
+		return false, nil, err
+	}
+
+	return true, publicKeys, nil
+}
+
+func GetCliConfigOptions(cmd *cobra.Command) (bool, bool, error) {
+	if _, err := cmd.Flags().GetString(ConfigFlag); err != nil {
 		return false, false, err
 	}
 
+	configName := getConfigName(cmd)
 	if configName == "" {
 		return false, false, nil
 	}
@@ -434,22 +1288,18 @@ func GetCliConfigOptions(cmd *cobra.Command) (bool, bool, error) {
 	return isSpinner, verifyTLS, nil
 }
 
+// GetServerURLFromFlags returns the url of the registry, the first one if several are given.
 func GetServerURLFromFlags(cmd *cobra.Command) (string, error) {
-	serverURL, err := cmd.Flags().GetString(URLFlag)
-	if err == nil && serverURL != "" {
-		return serverURL, nil
-	}
-
-	configName, err := cmd.Flags().GetString(ConfigFlag)
-	if err != nil {
-		return "", err
+	if serverURLs := getServerURLs(cmd); len(serverURLs) > 0 && serverURLs[0] != "" {
+		return serverURLs[0], nil
 	}
 
+	configName := getConfigName(cmd)
 	if configName == "" {
 		return "", fmt.Errorf("%w: specify either '--%s' or '--%s' flags", zerr.ErrNoURLProvided, URLFlag, ConfigFlag)
 	}
 
-	serverURL, err = ReadServerURLFromConfig(configName)
+	serverURL, err := ReadServerURLFromConfig(configName)
 	if err != nil {
 		return serverURL, fmt.Errorf("reading url from config failed: %w", err)
 	}
@@ -499,3 +1349,147 @@ func ShowSuggestionsIfUnknownCommand(cmd *cobra.Command, args []string) error {
 
 	return fmt.Errorf("%w '%s' for '%s'%s", zerr.ErrUnknownSubcommand, args[0], cmd.Name(), suggestions)
 }
+
+// filterTagsByPattern returns the tags matching the given glob pattern.
+func filterTagsByPattern(tags []string, pattern string) ([]string, error) {
+	matchingTags := []string{}
+
+	for _, tag := range tags {
+		matches, err := path.Match(pattern, tag)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid tag pattern '%s'", zerr.ErrInvalidCLIParameter, pattern)
+		}
+
+		if matches {
+			matchingTags = append(matchingTags, tag)
+		}
+	}
+
+	return matchingTags, nil
+}
+
+// isGlobPattern reports whether the name has any of the glob special characters.
+func isGlobPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// getRepoNameMatcher returns a function matching repo names against the given glob pattern,
+// or regex if isRegex is set. A regex matches anywhere in the name unless it's anchored.
+func getRepoNameMatcher(pattern string, isRegex bool) (func(repo string) bool, error) {
+	if isRegex {
+		nameRegex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid name regex '%s': %w", zerr.ErrInvalidCLIParameter, pattern, err)
+		}
+
+		return nameRegex.MatchString, nil
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("%w: invalid name pattern '%s'", zerr.ErrInvalidCLIParameter, pattern)
+	}
+
+	return func(repo string) bool {
+		matches, _ := path.Match(pattern, repo)
+
+		return matches
+	}, nil
+}
+
+// matchesImageTag reports whether the tag matches the searched one, which can be a glob pattern.
+// When the searched tag is empty we match everything.
+func matchesImageTag(tag, searchedTag string) bool {
+	if searchedTag == "" {
+		return true
+	}
+
+	if isGlobPattern(searchedTag) {
+		matches, _ := path.Match(searchedTag, tag)
+
+		return matches
+	}
+
+	return tag == searchedTag
+}
+
+func isSeparatedValuesFormat(format string) bool {
+	format = strings.ToLower(format)
+
+	return format == csvFormat || format == tsvFormat
+}
+
+// writeSeparatedValues writes the rows as csv, or tsv with the same quoting rules.
+func writeSeparatedValues(writer io.Writer, format string, rows [][]string) error {
+	csvWriter := csv.NewWriter(writer)
+
+	if strings.ToLower(format) == tsvFormat {
+		csvWriter.Comma = '\t'
+	}
+
+	return csvWriter.WriteAll(rows)
+}
+
+// getQuietOutputFormat returns the template printing only the references or the digests of the images
+// with --quiet, which bypasses the text table, and the given output format otherwise.
+func getQuietOutputFormat(cmd *cobra.Command, outputFormat string) (string, error) {
+	flags := cmd.Flags()
+	quiet := defaultIfError(flags.GetBool(QuietFlag))
+	digests := defaultIfError(flags.GetBool(DigestsFlag))
+
+	if !quiet {
+		if digests {
+			return "", fmt.Errorf("%w: --%s can only be used with --%s", zerr.ErrInvalidCLIParameter, DigestsFlag,
+				QuietFlag)
+		}
+
+		return outputFormat, nil
+	}
+
+	if flags.Changed(OutputFormatFlag) {
+		return "", fmt.Errorf("%w: --%s can't be used with --%s", zerr.ErrInvalidCLIParameter, OutputFormatFlag,
+			QuietFlag)
+	}
+
+	if digests {
+		return quietDigestsFormat, nil
+	}
+
+	return quietFormat, nil
+}
+
+// isTemplateFormat reports whether the output format is a Go template instead of a format name.
+func isTemplateFormat(format string) bool {
+	return strings.Contains(format, "{{")
+}
+
+func parseOutputTemplate(format string) (*template.Template, error) {
+	outputTemplate, err := template.New("format").Funcs(template.FuncMap{
+		"json": func(value any) (string, error) {
+			body, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(value)
+
+			return string(body), err
+		},
+		"join":  strings.Join,
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+	}).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", zerr.ErrInvalidOutputFormat, err)
+	}
+
+	return outputTemplate, nil
+}
+
+// askForConfirmation prints a [y/N] prompt and reports whether the answer read from input is a yes.
+func askForConfirmation(writer io.Writer, input io.Reader) bool {
+	fmt.Fprint(writer, "Continue? [y/N]: ")
+
+	answer, err := bufio.NewReader(input).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
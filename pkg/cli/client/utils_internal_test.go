@@ -4,19 +4,44 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
 	"github.com/gorilla/mux"
 	godigest "github.com/opencontainers/go-digest"
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/cobra"
 
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/common"
 	test "zotregistry.dev/zot/pkg/test/common"
 )
 
@@ -88,7 +113,7 @@ func TestDoHTTPRequest(t *testing.T) {
 		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, nil)
 		So(err, ShouldBeNil)
 
-		So(func() { _, _ = doHTTPRequest(req, false, false, nil, io.Discard) }, ShouldNotPanic)
+		So(func() { _, _ = doHTTPRequest(req, SearchConfig{}, nil) }, ShouldNotPanic)
 	})
 
 	Convey("doHTTPRequest bad return json", t, func() {
@@ -111,21 +136,21 @@ func TestDoHTTPRequest(t *testing.T) {
 		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
 		So(err, ShouldBeNil)
 
-		So(func() { _, _ = doHTTPRequest(req, false, false, &ispec.Manifest{}, io.Discard) }, ShouldNotPanic)
+		So(func() { _, _ = doHTTPRequest(req, SearchConfig{}, &ispec.Manifest{}) }, ShouldNotPanic)
 	})
 
 	Convey("makeGraphQLRequest bad request context", t, func() {
-		err := makeGraphQLRequest(nil, "", "", "", "", false, false, nil, io.Discard) //nolint:staticcheck
+		err := makeGraphQLRequest(nil, "", "", "", "", SearchConfig{}, nil) //nolint:staticcheck
 		So(err, ShouldNotBeNil)
 	})
 
 	Convey("makeHEADRequest bad request context", t, func() {
-		_, err := makeHEADRequest(nil, "", "", "", false, false) //nolint:staticcheck
+		_, err := makeHEADRequest(nil, "", "", "", SearchConfig{}) //nolint:staticcheck
 		So(err, ShouldNotBeNil)
 	})
 
 	Convey("makeGETRequest bad request context", t, func() {
-		_, err := makeGETRequest(nil, "", "", "", false, false, nil, io.Discard) //nolint:staticcheck
+		_, err := makeGETRequest(nil, "", "", "", SearchConfig{}, nil) //nolint:staticcheck
 		So(err, ShouldNotBeNil)
 	})
 
@@ -147,7 +172,7 @@ func TestDoHTTPRequest(t *testing.T) {
 			imageName: "repo",
 			tagName:   "tag",
 			config:    searchConf,
-		})
+		}, ispec.MediaTypeImageManifest)
 
 		So(err, ShouldNotBeNil)
 	})
@@ -321,7 +346,7 @@ func TestDoHTTPRequest(t *testing.T) {
 				imageName: "repo",
 				tagName:   "tag",
 				config:    searchConf,
-			})
+			}, ispec.MediaTypeImageIndex)
 			So(err, ShouldBeNil)
 			So(imageStruct, ShouldNotBeNil)
 		})
@@ -343,7 +368,7 @@ func TestDoHTTPRequest(t *testing.T) {
 				imageName: "repo",
 				tagName:   "tag",
 				config:    searchConf,
-			})
+			}, ispec.MediaTypeImageIndex)
 			So(err, ShouldNotBeNil)
 			So(imageStruct, ShouldBeNil)
 		})
@@ -361,7 +386,7 @@ func TestDoHTTPRequest(t *testing.T) {
 				imageName: "repo",
 				tagName:   "tag",
 				config:    searchConf,
-			})
+			}, ispec.MediaTypeImageIndex)
 			So(err, ShouldNotBeNil)
 			So(imageStruct, ShouldBeNil)
 		})
@@ -657,3 +682,1042 @@ func TestDoJobErrors(t *testing.T) {
 		})
 	})
 }
+
+func TestLabelSelectors(t *testing.T) {
+	Convey("parseLabelSelectors", t, func() {
+		selectors, err := parseLabelSelectors([]string{"key=value", "empty=", "with=equal=sign"})
+		So(err, ShouldBeNil)
+		So(selectors, ShouldResemble, map[string]string{"key": "value", "empty": "", "with": "equal=sign"})
+
+		_, err = parseLabelSelectors([]string{"novalue"})
+		So(err, ShouldNotBeNil)
+
+		_, err = parseLabelSelectors([]string{"=value"})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("matchesLabels", t, func() {
+		labels := mergeLabels(map[string]string{"a": "1", "b": "2"}, map[string]string{"b": "3"})
+		So(labels, ShouldResemble, map[string]string{"a": "1", "b": "3"})
+
+		So(matchesLabels(labels, nil), ShouldBeTrue)
+		So(matchesLabels(nil, nil), ShouldBeTrue)
+		So(matchesLabels(labels, map[string]string{"a": "1", "b": "3"}), ShouldBeTrue)
+		So(matchesLabels(labels, map[string]string{"b": "2"}), ShouldBeFalse)
+		So(matchesLabels(labels, map[string]string{"c": ""}), ShouldBeFalse)
+	})
+}
+
+func TestPaginatedRequests(t *testing.T) {
+	// serves the entries in pages of size "n", defaulting to 2, starting after "last"
+	servePage := func(writer http.ResponseWriter, request *http.Request, entries []string) []string {
+		pageSize := 2
+		if n := request.URL.Query().Get("n"); n != "" {
+			pageSize, _ = strconv.Atoi(n)
+		}
+
+		start := 0
+		if last := request.URL.Query().Get("last"); last != "" {
+			start = slices.Index(entries, last) + 1
+		}
+
+		end := min(start+pageSize, len(entries))
+		if end < len(entries) {
+			writer.Header().Add("Link", fmt.Sprintf(`<%s?n=%d&last=%s>; rel="next"`,
+				request.URL.Path, pageSize, entries[end-1]))
+		}
+
+		return entries[start:end]
+	}
+
+	repos := []string{"repo1", "repo2", "repo3", "repo4", "repo5"}
+	tags := []string{"tag1", "tag2", "tag3"}
+
+	port := test.GetFreePort()
+	server := StartTestHTTPServer(HTTPRoutes{
+		{
+			// the next links go back to a page already listed
+			Route: "/v2/loop/tags/list",
+			HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				next := map[string]string{"": "a", "a": "b", "b": "a"}[r.URL.Query().Get("last")]
+				w.Header().Add("Link", fmt.Sprintf(`<%s?last=%s>; rel="next"`, r.URL.Path, next))
+				_, _ = w.Write([]byte(`{"name": "loop", "tags": ["tag"]}`))
+			},
+			AllowedMethods: []string{http.MethodGet},
+		},
+		{
+			Route: "/v2/_catalog",
+			HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				page := servePage(w, r, repos)
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"repositories": ["%s"]}`, strings.Join(page, `","`))))
+			},
+			AllowedMethods: []string{http.MethodGet},
+		},
+		{
+			Route: "/v2/{repo}/tags/list",
+			HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				page := servePage(w, r, tags)
+				_, _ = w.Write([]byte(fmt.Sprintf(`{"name": "%s", "tags": ["%s"]}`, mux.Vars(r)["repo"],
+					strings.Join(page, `","`))))
+			},
+			AllowedMethods: []string{http.MethodGet},
+		},
+	}, port)
+	defer server.Close()
+
+	searchConfig := getDefaultSearchConf(test.GetBaseURL(port))
+
+	Convey("all the pages are fetched", t, func() {
+		catalog, err := getCatalog(context.Background(), searchConfig, "", "")
+		So(err, ShouldBeNil)
+		So(catalog.Repositories, ShouldResemble, repos)
+
+		tagList, err := getTagList(context.Background(), searchConfig, "", "", "repo1", false)
+		So(err, ShouldBeNil)
+		So(tagList.Name, ShouldEqual, "repo1")
+		So(tagList.Tags, ShouldResemble, tags)
+	})
+
+	Convey("the page size is sent to the registry", t, func() {
+		searchConfig.PageSize = 1
+
+		catalog, err := getCatalog(context.Background(), searchConfig, "", "")
+		So(err, ShouldBeNil)
+		So(catalog.Repositories, ShouldResemble, repos)
+
+		searchConfig.PageSize = 10
+
+		tagList, err := getTagList(context.Background(), searchConfig, "", "", "repo1", false)
+		So(err, ShouldBeNil)
+		So(tagList.Tags, ShouldResemble, tags)
+	})
+
+	Convey("cyclic next links are reported", t, func() {
+		searchConfig.PageSize = 0
+
+		_, err := getTagList(context.Background(), searchConfig, "", "", "loop", false)
+		So(err, ShouldWrap, zerr.ErrPaginationLoop)
+	})
+
+	Convey("getNextPageURL", t, func() {
+		pageURL, err := url.Parse("http://127.0.0.1:8080/v2/_catalog?n=2")
+		So(err, ShouldBeNil)
+
+		nextURL, err := getNextPageURL(pageURL, http.Header{})
+		So(err, ShouldBeNil)
+		So(nextURL, ShouldBeNil)
+
+		header := http.Header{}
+		header.Add("Link", `<https://other/v2/_catalog>; rel="prev", </v2/_catalog?n=2&last=b>; rel="next"`)
+		nextURL, err = getNextPageURL(pageURL, header)
+		So(err, ShouldBeNil)
+		So(nextURL.String(), ShouldEqual, "http://127.0.0.1:8080/v2/_catalog?n=2&last=b")
+
+		header = http.Header{}
+		header.Add("Link", `<:bad>; rel="next"`)
+		_, err = getNextPageURL(pageURL, header)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestDoJobDockerMediaTypes(t *testing.T) {
+	Convey("Do Job with docker manifest lists", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		searchConf := getDefaultSearchConf(baseURL)
+		searchConf.OutputFormat = "json"
+
+		server := StartTestHTTPServer(HTTPRoutes{
+			{
+				Route: "/v2/{name}/manifests/{reference}",
+				HandlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+					// the registry would serve a schema1 manifest otherwise
+					if !strings.Contains(req.Header.Get("Accept"), manifestlist.MediaTypeManifestList) {
+						writer.WriteHeader(http.StatusNotFound)
+
+						return
+					}
+
+					var content string
+
+					switch mux.Vars(req)["reference"] {
+					case "indexRef":
+						writer.Header().Set("Content-Type", manifestlist.MediaTypeManifestList)
+						writer.Header().Set("docker-content-digest", godigest.FromString("index").String())
+						content = `{
+							"mediaType": "application/vnd.docker.distribution.manifest.list.v2+json",
+							"manifests": [{
+								"mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+								"digest": "manifestRef",
+								"platform": {"architecture": "arm", "os": "linux", "variant": "v7"}
+							}]
+						}`
+					case "manifestRef":
+						writer.Header().Set("Content-Type", schema2.MediaTypeManifest)
+						writer.Header().Set("docker-content-digest", godigest.FromString("manifest").String())
+						content = `{
+							"mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+							"config": {"digest": "configRef", "size": 1},
+							"layers": [{"digest": "layerRef", "size": 10}]
+						}`
+					}
+
+					writer.Header().Set("Content-Length", strconv.Itoa(len(content)))
+
+					if req.Method == http.MethodGet {
+						_, _ = writer.Write([]byte(content))
+					}
+				},
+				AllowedMethods: []string{http.MethodGet, http.MethodHead},
+			},
+			{
+				Route: "/v2/{name}/blobs/{digest}",
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write([]byte(`{"architecture": "arm", "os": "linux"}`))
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+		}, port)
+		defer server.Close()
+
+		reqPool := &requestsPool{
+			jobs:     make(chan *httpJob),
+			done:     make(chan struct{}),
+			wtgrp:    &sync.WaitGroup{},
+			outputCh: make(chan stringResult),
+		}
+
+		reqPool.wtgrp.Add(1)
+
+		go reqPool.doJob(context.Background(), &httpJob{
+			url:       baseURL + "/v2/repo/manifests/indexRef",
+			imageName: "repo",
+			tagName:   "indexRef",
+			config:    searchConf,
+		})
+
+		result := <-reqPool.outputCh
+		So(result.Err, ShouldBeNil)
+
+		image := imageStruct{}
+		err := json.Unmarshal([]byte(result.StrValue), &image)
+		So(err, ShouldBeNil)
+		So(image.MediaType, ShouldEqual, manifestlist.MediaTypeManifestList)
+		So(image.Manifests, ShouldHaveLength, 1)
+		So(image.Manifests[0].Platform.Variant, ShouldEqual, "v7")
+		So(image.Manifests[0].Layers, ShouldHaveLength, 1)
+		So(image.Manifests[0].Layers[0].Digest, ShouldEqual, "layerRef")
+	})
+}
+
+func TestImagesWithTagDetails(t *testing.T) {
+	Convey("The manifests aren't checked when the tags list returns their details", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		searchConf := getDefaultSearchConf(baseURL)
+		searchConf.OutputFormat = "json"
+
+		manifest := `{
+			"schemaVersion": 2,
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"config": {"mediaType": "application/vnd.oci.image.config.v1+json", "digest": "configRef", "size": 1},
+			"layers": [{"mediaType": "application/vnd.oci.image.layer.v1.tar", "digest": "layerRef", "size": 10}]
+		}`
+		manifestDigest := godigest.FromString(manifest)
+
+		var withDetails atomic.Bool
+
+		var headRequests atomic.Int32
+
+		server := StartTestHTTPServer(HTTPRoutes{
+			{
+				Route: "/v2/_catalog",
+				HandlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+					_, _ = writer.Write([]byte(`{"repositories": ["repo"]}`))
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+			{
+				Route: "/v2/{name}/tags/list",
+				HandlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+					tags := common.ImageTags{Name: "repo", Tags: []string{"1.0", "2.0"}}
+
+					if withDetails.Load() && req.URL.Query().Get("details") == "true" {
+						tags.Total = 2
+
+						for _, tag := range tags.Tags {
+							tags.Details = append(tags.Details, common.TagDetails{
+								Tag:       tag,
+								Digest:    manifestDigest.String(),
+								MediaType: ispec.MediaTypeImageManifest,
+								Size:      int64(len(manifest)),
+							})
+						}
+					}
+
+					_ = json.NewEncoder(writer).Encode(tags)
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+			{
+				Route: "/v2/{name}/manifests/{reference}",
+				HandlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+					writer.Header().Set("Content-Type", ispec.MediaTypeImageManifest)
+					writer.Header().Set("Docker-Content-Digest", manifestDigest.String())
+					writer.Header().Set("Content-Length", strconv.Itoa(len(manifest)))
+
+					if req.Method == http.MethodHead {
+						headRequests.Add(1)
+
+						return
+					}
+
+					_, _ = writer.Write([]byte(manifest))
+				},
+				AllowedMethods: []string{http.MethodGet, http.MethodHead},
+			},
+			{
+				Route: "/v2/{name}/blobs/{digest}",
+				HandlerFunc: func(writer http.ResponseWriter, req *http.Request) {
+					_, _ = writer.Write([]byte(`{"architecture": "amd64", "os": "linux"}`))
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+		}, port)
+		defer server.Close()
+
+		getImages := func() []imageStruct {
+			rch := make(chan stringResult)
+			wtgrp := &sync.WaitGroup{}
+			wtgrp.Add(1)
+
+			go searchService{}.getAllImages(context.Background(), searchConf, "", "", rch, wtgrp)
+
+			images := []imageStruct{}
+
+			for result := range rch {
+				So(result.Err, ShouldBeNil)
+
+				image := imageStruct{}
+				err := json.Unmarshal([]byte(result.StrValue), &image)
+				So(err, ShouldBeNil)
+
+				images = append(images, image)
+			}
+
+			wtgrp.Wait()
+
+			return images
+		}
+
+		withDetails.Store(true)
+
+		images := getImages()
+		So(images, ShouldHaveLength, 2)
+		So(images[0].Digest, ShouldEqual, manifestDigest.String())
+		So(images[0].MediaType, ShouldEqual, ispec.MediaTypeImageManifest)
+		So(headRequests.Load(), ShouldEqual, 0)
+
+		// the registries not returning the details
+		withDetails.Store(false)
+
+		images = getImages()
+		So(images, ShouldHaveLength, 2)
+		So(images[0].Digest, ShouldEqual, manifestDigest.String())
+		So(headRequests.Load(), ShouldEqual, 2)
+	})
+}
+
+func TestRequestsPoolLimits(t *testing.T) {
+	Convey("requests pool defaults", t, func() {
+		pool := newSmoothRateLimiter(&sync.WaitGroup{}, make(chan stringResult), SearchConfig{})
+		So(pool.rateLimit, ShouldEqual, time.Second/defaultRequestsPerSecond)
+		So(cap(pool.slots), ShouldEqual, defaultMaxConcurrentRequests)
+
+		pool = newSmoothRateLimiter(&sync.WaitGroup{}, make(chan stringResult), SearchConfig{
+			MaxConcurrentRequests: 3,
+			RequestsPerSecond:     100,
+		})
+		So(pool.rateLimit, ShouldEqual, 10*time.Millisecond)
+		So(cap(pool.slots), ShouldEqual, 3)
+	})
+
+	Convey("requests pool doesn't exceed the max concurrent requests", t, func() {
+		var inFlight, maxInFlight atomic.Int32
+
+		port := test.GetFreePort()
+		server := StartTestHTTPServer(HTTPRoutes{
+			{
+				Route: "/v2/{name}/manifests/{reference}",
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					current := inFlight.Add(1)
+					defer inFlight.Add(-1)
+
+					for {
+						previousMax := maxInFlight.Load()
+						if current <= previousMax || maxInFlight.CompareAndSwap(previousMax, current) {
+							break
+						}
+					}
+
+					time.Sleep(50 * time.Millisecond)
+					// unknown media type, the job ends after the HEAD request
+					w.Header().Set("Content-Type", "application/unknown")
+				},
+				AllowedMethods: []string{http.MethodHead},
+			},
+		}, port)
+		defer server.Close()
+
+		searchConf := getDefaultSearchConf(test.GetBaseURL(port))
+		searchConf.MaxConcurrentRequests = 2
+		searchConf.RequestsPerSecond = 1000
+
+		var wtgrp sync.WaitGroup
+
+		pool := newSmoothRateLimiter(&wtgrp, make(chan stringResult), searchConf)
+
+		wtgrp.Add(1)
+
+		go pool.startRateLimiter(context.Background())
+
+		for i := 0; i < 6; i++ {
+			wtgrp.Add(1)
+			pool.submitJob(&httpJob{
+				url:    fmt.Sprintf("%s/v2/repo/manifests/tag%d", searchConf.ServURL, i),
+				config: searchConf,
+			})
+		}
+
+		wtgrp.Wait()
+		So(maxInFlight.Load(), ShouldEqual, 2)
+	})
+
+	Convey("requests pool drops the jobs left once canceled", t, func() {
+		var wtgrp sync.WaitGroup
+
+		pool := newSmoothRateLimiter(&wtgrp, make(chan stringResult), SearchConfig{RequestsPerSecond: 1})
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		wtgrp.Add(1)
+
+		go pool.startRateLimiter(ctx)
+
+		cancel()
+
+		for i := 0; i < 20; i++ {
+			wtgrp.Add(1)
+			pool.submitJob(&httpJob{url: "http://127.0.0.1:1/v2/repo/manifests/tag", config: SearchConfig{}})
+		}
+
+		done := make(chan struct{})
+
+		go func() {
+			wtgrp.Wait()
+			close(done)
+		}()
+
+		dropped := false
+
+		// one job a second, the jobs would take 20 seconds if they weren't dropped
+		select {
+		case <-done:
+			dropped = true
+		case <-time.After(5 * time.Second):
+		}
+
+		So(dropped, ShouldBeTrue)
+	})
+}
+
+// getImageListSearchConfig returns the search config "zli image list" would use given the args.
+func getImageListSearchConfig(args ...string) (SearchConfig, error) {
+	var searchConfig SearchConfig
+
+	imageCmd := NewImageCommand(NewSearchService())
+
+	listCmd, _, err := imageCmd.Find([]string{"list"})
+	if err != nil {
+		return SearchConfig{}, err
+	}
+
+	listCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		var err error
+
+		searchConfig, err = GetSearchConfigFromFlags(cmd, NewSearchService())
+
+		return err
+	}
+
+	imageCmd.SetArgs(append([]string{"list"}, args...))
+
+	return searchConfig, imageCmd.Execute()
+}
+
+func TestRequestLimitsOptions(t *testing.T) {
+	Convey("request limits from flags and config", t, func() {
+		configPath := makeConfigFile(`{"configs":[{"_name":"limits","url":"http://127.0.0.1:8080",
+			"max-concurrent-requests":"4","requests-per-second":"20"}]}`)
+		defer os.Remove(configPath)
+
+		searchConfig, err := getImageListSearchConfig("--config", "limits")
+		So(err, ShouldBeNil)
+		So(searchConfig.MaxConcurrentRequests, ShouldEqual, 4)
+		So(searchConfig.RequestsPerSecond, ShouldEqual, 20)
+
+		searchConfig, err = getImageListSearchConfig("--config", "limits", "--max-concurrent-requests", "1")
+		So(err, ShouldBeNil)
+		So(searchConfig.MaxConcurrentRequests, ShouldEqual, 1)
+		So(searchConfig.RequestsPerSecond, ShouldEqual, 20)
+
+		searchConfig, err = getImageListSearchConfig("--url", "http://127.0.0.1:8080")
+		So(err, ShouldBeNil)
+		So(searchConfig.MaxConcurrentRequests, ShouldEqual, 0)
+		So(searchConfig.RequestsPerSecond, ShouldEqual, 0)
+
+		_, err = getImageListSearchConfig("--url", "http://127.0.0.1:8080", "--requests-per-second", "0")
+		So(err, ShouldNotBeNil)
+
+		// the config file is checked against its schema before being used
+		makeConfigFile(`{"configs":[{"_name":"badlimits","url":"http://127.0.0.1:8080","requests-per-second":"many"}]}`)
+
+		_, err = getImageListSearchConfig("--config", "badlimits")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestBandwidthLimit(t *testing.T) {
+	Convey("bandwidth limit from flags", t, func() {
+		searchConfig, err := getImageListSearchConfig("--url", "http://127.0.0.1:8080", "--limit-rate", "1KiB")
+		So(err, ShouldBeNil)
+		So(searchConfig.BandwidthLimiter, ShouldNotBeNil)
+		So(searchConfig.BandwidthLimiter.limiter.Burst(), ShouldEqual, 1024)
+
+		searchConfig, err = getImageListSearchConfig("--url", "http://127.0.0.1:8080")
+		So(err, ShouldBeNil)
+		So(searchConfig.BandwidthLimiter, ShouldBeNil)
+
+		searchConfig, err = getImageListSearchConfig("--url", "http://127.0.0.1:8080", "--limit-rate", "0")
+		So(err, ShouldBeNil)
+		So(searchConfig.BandwidthLimiter, ShouldBeNil)
+
+		_, err = getImageListSearchConfig("--url", "http://127.0.0.1:8080", "--limit-rate", "fast")
+		So(err, ShouldWrap, zerr.ErrInvalidCLIParameter)
+	})
+
+	Convey("downloads and uploads are slowed down to the limit", t, func() {
+		content := bytes.Repeat([]byte{1}, 30*1024)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut {
+				_, _ = io.Copy(io.Discard, r.Body)
+				w.WriteHeader(http.StatusCreated)
+
+				return
+			}
+
+			_, _ = w.Write(content)
+		}))
+		defer server.Close()
+
+		// the bucket starts full, so the first 10KiB go through right away
+		config := SearchConfig{ServURL: server.URL, BandwidthLimiter: newBandwidthLimiter(10 * 1024)}
+
+		start := time.Now()
+
+		blob, err := makeBlobGETRequest(context.Background(), server.URL+"/blob", "", "", config)
+		So(err, ShouldBeNil)
+
+		received, err := io.ReadAll(blob)
+		So(err, ShouldBeNil)
+		So(blob.Close(), ShouldBeNil)
+		So(received, ShouldResemble, content)
+		So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 1900*time.Millisecond)
+
+		start = time.Now()
+
+		_, _, err = makeUploadRequest(context.Background(), http.MethodPut, server.URL+"/blob", "", "", config,
+			"application/octet-stream", bytes.NewReader(content), int64(len(content)))
+		So(err, ShouldBeNil)
+		So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 2900*time.Millisecond)
+
+		// a canceled context stops the wait for the tokens
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		blob, err = makeBlobGETRequest(ctx, server.URL+"/blob", "", "", config)
+		So(err, ShouldBeNil)
+
+		defer blob.Close()
+
+		_, err = io.ReadAll(blob)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestHTTPClientConnections(t *testing.T) {
+	Convey("http client transport settings", t, func() {
+		httpClient, err := createHTTPClient("127.0.0.1", SearchConfig{})
+		So(err, ShouldBeNil)
+
+		transport, ok := httpClient.Transport.(*http.Transport)
+		So(ok, ShouldBeTrue)
+		So(transport.ForceAttemptHTTP2, ShouldBeTrue)
+		So(transport.MaxIdleConnsPerHost, ShouldEqual, defaultMaxConcurrentRequests)
+
+		httpClient, err = createHTTPClient("127.0.0.1", SearchConfig{
+			MaxConcurrentRequests: 50,
+			MaxIdleConnsPerHost:   200,
+			RequestTimeout:        time.Second,
+		})
+		So(err, ShouldBeNil)
+		So(httpClient.Timeout, ShouldEqual, time.Second)
+
+		transport, ok = httpClient.Transport.(*http.Transport)
+		So(ok, ShouldBeTrue)
+		So(transport.MaxIdleConnsPerHost, ShouldEqual, 200)
+		So(transport.MaxIdleConns, ShouldBeGreaterThanOrEqualTo, 200)
+
+		So(getMaxIdleConnsPerHost(SearchConfig{MaxConcurrentRequests: 50}), ShouldEqual, 50)
+
+		// the clients are shared by the requests with the same settings
+		first, err := getHTTPClient("127.0.0.1:8080", SearchConfig{MaxIdleConnsPerHost: 3})
+		So(err, ShouldBeNil)
+		second, err := getHTTPClient("127.0.0.1:8080", SearchConfig{MaxIdleConnsPerHost: 3})
+		So(err, ShouldBeNil)
+		So(second, ShouldEqual, first)
+		third, err := getHTTPClient("127.0.0.1:8080", SearchConfig{MaxIdleConnsPerHost: 4})
+		So(err, ShouldBeNil)
+		So(third, ShouldNotEqual, first)
+	})
+
+	Convey("connections are reused between requests", t, func() {
+		var newConns atomic.Int32
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the decoder stops before the trailing spaces
+			_, _ = w.Write([]byte(`{"name":"repo"}` + strings.Repeat(" ", 1024)))
+		}))
+		server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				newConns.Add(1)
+			}
+		}
+		server.Start()
+		defer server.Close()
+
+		searchConf := getDefaultSearchConf(server.URL)
+
+		for i := 0; i < 20; i++ {
+			var result struct {
+				Name string `json:"name"`
+			}
+
+			_, err := makeGETRequest(context.Background(), server.URL+"/v2/repo/tags/list", "", "", searchConf, &result)
+			So(err, ShouldBeNil)
+			So(result.Name, ShouldEqual, "repo")
+		}
+
+		So(newConns.Load(), ShouldEqual, 1)
+	})
+
+	Convey("connection options from flags and config", t, func() {
+		configPath := makeConfigFile(`{"configs":[{"_name":"conns","url":"http://127.0.0.1:8080",
+			"max-idle-conns-per-host":"30"}]}`)
+		defer os.Remove(configPath)
+
+		searchConfig, err := getImageListSearchConfig("--config", "conns")
+		So(err, ShouldBeNil)
+		So(searchConfig.MaxIdleConnsPerHost, ShouldEqual, 30)
+		So(searchConfig.RequestTimeout, ShouldEqual, defaultRequestTimeout)
+
+		searchConfig, err = getImageListSearchConfig("--config", "conns", "--max-idle-conns-per-host", "5",
+			"--request-timeout", "10s")
+		So(err, ShouldBeNil)
+		So(searchConfig.MaxIdleConnsPerHost, ShouldEqual, 5)
+		So(searchConfig.RequestTimeout, ShouldEqual, 10*time.Second)
+
+		_, err = getImageListSearchConfig("--config", "conns", "--request-timeout", "-1s")
+		So(err, ShouldNotBeNil)
+
+		_, err = getImageListSearchConfig("--config", "conns", "--max-idle-conns-per-host", "0")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestConfigProfileOptions(t *testing.T) {
+	Convey("registry profile options", t, func() {
+		configPath := makeConfigFile(`{"default":"prod","configs":[{"_name":"main","url":"http://127.0.0.1:8080"},
+			{"_name":"prod","url":"http://127.0.0.1:8081","format":"json","credentials-env":"ZLI_TEST_CREDS"}]}`)
+		defer os.Remove(configPath)
+
+		t.Setenv("ZLI_TEST_CREDS", "user:pass")
+
+		// the default config is used if neither --url nor --config are given
+		searchConfig, err := getImageListSearchConfig()
+		So(err, ShouldBeNil)
+		So(searchConfig.ServURL, ShouldEqual, "http://127.0.0.1:8081")
+		So(searchConfig.OutputFormat, ShouldEqual, "json")
+		So(searchConfig.User, ShouldEqual, "user:pass")
+
+		searchConfig, err = getImageListSearchConfig("--format", "yaml", "--user", "other:pass")
+		So(err, ShouldBeNil)
+		So(searchConfig.OutputFormat, ShouldEqual, "yaml")
+		So(searchConfig.User, ShouldEqual, "other:pass")
+
+		searchConfig, err = getImageListSearchConfig("--config", "main")
+		So(err, ShouldBeNil)
+		So(searchConfig.ServURL, ShouldEqual, "http://127.0.0.1:8080")
+		So(searchConfig.OutputFormat, ShouldBeEmpty)
+		So(searchConfig.User, ShouldBeEmpty)
+
+		// the options of the default config don't apply to other registries
+		searchConfig, err = getImageListSearchConfig("--url", "http://127.0.0.1:8082")
+		So(err, ShouldBeNil)
+		So(searchConfig.ServURL, ShouldEqual, "http://127.0.0.1:8082")
+		So(searchConfig.OutputFormat, ShouldBeEmpty)
+		So(searchConfig.User, ShouldBeEmpty)
+	})
+}
+
+func TestRequestRetries(t *testing.T) {
+	Convey("Transient errors are retried", t, func() {
+		var (
+			requests  atomic.Int32
+			throttled atomic.Bool
+		)
+
+		port := test.GetFreePort()
+		server := StartTestHTTPServer(HTTPRoutes{
+			{
+				Route: "/v2/_catalog",
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					if throttled.Load() {
+						w.Header().Set("Retry-After", "3600")
+						w.WriteHeader(http.StatusTooManyRequests)
+
+						return
+					}
+
+					if requests.Add(1) < 3 {
+						w.Header().Set("Retry-After", "0")
+						w.WriteHeader(http.StatusServiceUnavailable)
+
+						return
+					}
+
+					_, _ = w.Write([]byte(`{"repositories": ["repo"]}`))
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+			{
+				Route: "/v2/{name}/tags/list",
+				HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
+					requests.Add(1)
+					w.WriteHeader(http.StatusNotFound)
+				},
+				AllowedMethods: []string{http.MethodGet},
+			},
+		}, port)
+		defer server.Close()
+
+		searchConf := getDefaultSearchConf(test.GetBaseURL(port))
+		searchConf.RetryMaxWait = 10 * time.Millisecond
+
+		Convey("without retries the first error is returned", func() {
+			_, err := getCatalog(context.Background(), searchConf, "", "")
+			So(err, ShouldNotBeNil)
+			So(requests.Load(), ShouldEqual, 1)
+		})
+
+		Convey("not enough retries", func() {
+			searchConf.Retries = 1
+
+			_, err := getCatalog(context.Background(), searchConf, "", "")
+			So(err, ShouldNotBeNil)
+			So(requests.Load(), ShouldEqual, 2)
+		})
+
+		Convey("the request eventually succeeds", func() {
+			searchConf.Retries = 3
+
+			catalog, err := getCatalog(context.Background(), searchConf, "", "")
+			So(err, ShouldBeNil)
+			So(catalog.Repositories, ShouldResemble, []string{"repo"})
+			So(requests.Load(), ShouldEqual, 3)
+		})
+
+		Convey("client errors are not retried", func() {
+			searchConf.Retries = 3
+
+			_, err := getTagList(context.Background(), searchConf, "", "", "repo", false)
+			So(err, ShouldNotBeNil)
+			So(requests.Load(), ShouldEqual, 1)
+		})
+
+		Convey("the wait stops when the context is done", func() {
+			searchConf.Retries = 3
+			searchConf.RetryMaxWait = time.Hour
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			throttled.Store(true)
+
+			_, err := getCatalog(ctx, searchConf, "", "")
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+		})
+	})
+
+	Convey("Retry wait", t, func() {
+		resp := &http.Response{Header: http.Header{}}
+
+		for attempt := 0; attempt < 4; attempt++ {
+			wait := getRetryWait(resp, attempt, time.Minute)
+			So(wait, ShouldBeGreaterThanOrEqualTo, (retryBaseWait<<attempt)/2)
+			So(wait, ShouldBeLessThanOrEqualTo, retryBaseWait<<attempt)
+		}
+
+		So(getRetryWait(nil, 10, time.Second), ShouldEqual, time.Second)
+
+		resp.Header.Set("Retry-After", "5")
+		So(getRetryWait(resp, 0, time.Minute), ShouldEqual, 5*time.Second)
+		So(getRetryWait(resp, 0, time.Second), ShouldEqual, time.Second)
+
+		resp.Header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		So(getRetryWait(resp, 0, time.Minute), ShouldEqual, time.Minute)
+
+		resp.Header.Set("Retry-After", "bad value")
+		So(getRetryWait(resp, 0, time.Minute), ShouldBeLessThanOrEqualTo, retryBaseWait)
+
+		So(isTransientFailure(&http.Response{StatusCode: http.StatusBadGateway}, nil), ShouldBeTrue)
+		So(isTransientFailure(&http.Response{StatusCode: http.StatusNotImplemented}, nil), ShouldBeFalse)
+		So(isTransientFailure(&http.Response{StatusCode: http.StatusUnauthorized}, nil), ShouldBeFalse)
+		So(isTransientFailure(nil, io.ErrUnexpectedEOF), ShouldBeTrue)
+		So(isTransientFailure(nil, zerr.ErrBadConfig), ShouldBeFalse)
+	})
+}
+
+func TestDebugOutput(t *testing.T) {
+	Convey("Requests are shown with --debug", t, func() {
+		var requestIDs []string
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestIDs = append(requestIDs, r.Header.Get("X-Request-Id"))
+
+			if r.URL.Path == "/v2/missing/tags/list" {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"repositories": ["repo"]}`))
+		}))
+		defer server.Close()
+
+		errBuff := bytes.NewBufferString("")
+
+		searchConf := getDefaultSearchConf(server.URL)
+		searchConf.SearchService = NewSearchService()
+		searchConf.Debug = true
+		searchConf.ErrorWriter = errBuff
+
+		Convey("the method, url, status, latency and request id", func() {
+			catalog, err := getCatalog(context.Background(), searchConf, "user", "secret")
+			So(err, ShouldBeNil)
+			So(catalog.Repositories, ShouldResemble, []string{"repo"})
+
+			_, err = searchConf.SearchService.getTags(context.Background(), searchConf, "user", "secret", "missing")
+			So(err, ShouldNotBeNil)
+
+			So(len(requestIDs), ShouldEqual, 2)
+			So(requestIDs[0], ShouldNotBeEmpty)
+			So(requestIDs[1], ShouldNotEqual, requestIDs[0])
+
+			lines := strings.Split(strings.TrimSpace(errBuff.String()), "\n")
+			So(len(lines), ShouldEqual, 4)
+			So(lines[0], ShouldEqual, "[debug] "+requestIDs[0]+" --> GET "+server.URL+"/v2/_catalog")
+			So(lines[1], ShouldStartWith, "[debug] "+requestIDs[0]+" <-- 200 GET "+server.URL+"/v2/_catalog (")
+			So(lines[3], ShouldStartWith, "[debug] "+requestIDs[1]+" <-- 404 GET "+server.URL+"/v2/missing/tags/list")
+			So(errBuff.String(), ShouldNotContainSubstring, "secret")
+		})
+
+		Convey("the headers and bodies with --debug-body", func() {
+			searchConf.DebugBody = true
+
+			catalog, err := getCatalog(context.Background(), searchConf, "user", "secret")
+			So(err, ShouldBeNil)
+			// the body is still read by the caller
+			So(catalog.Repositories, ShouldResemble, []string{"repo"})
+
+			output := errBuff.String()
+			So(output, ShouldContainSubstring, "    Authorization: ******")
+			So(output, ShouldContainSubstring, "    Content-Type: application/json")
+			So(output, ShouldContainSubstring, `{"repositories": ["repo"]}`)
+			So(output, ShouldNotContainSubstring, "secret")
+		})
+
+		Convey("the failed requests", func() {
+			server.Close()
+
+			_, err := getCatalog(context.Background(), searchConf, "", "")
+			So(err, ShouldNotBeNil)
+			So(errBuff.String(), ShouldContainSubstring, "<-- GET "+server.URL+"/v2/_catalog error:")
+		})
+	})
+
+	Convey("Flags", t, func() {
+		configPath := makeConfigFile(`{"configs":[{"_name":"debug","url":"http://127.0.0.1:8080"}]}`)
+		defer os.Remove(configPath)
+
+		cmd := NewRepoCommand(NewSearchService())
+
+		err := cmd.ParseFlags([]string{"--config", "debug", "--debug-body"})
+		So(err, ShouldBeNil)
+
+		searchConf, err := GetSearchConfigFromFlags(cmd, NewSearchService())
+		So(err, ShouldBeNil)
+		So(searchConf.Debug, ShouldBeTrue)
+		So(searchConf.DebugBody, ShouldBeTrue)
+	})
+}
+
+func TestClientCertificates(t *testing.T) {
+	Convey("Requests to a server requiring client certificates", t, func() {
+		certDir := t.TempDir()
+		clientCertPath := path.Join(certDir, "client.crt")
+		clientKeyPath := path.Join(certDir, "client.key")
+		caCertPath := path.Join(certDir, "ca.crt")
+
+		clientKey, err := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
+		So(err, ShouldBeNil)
+
+		clientCertTemplate := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "client"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+
+		clientCertDER, err := x509.CreateCertificate(crand.Reader, clientCertTemplate, clientCertTemplate,
+			&clientKey.PublicKey, clientKey)
+		So(err, ShouldBeNil)
+
+		clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+		So(err, ShouldBeNil)
+
+		err = os.WriteFile(clientCertPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientCertDER}),
+			0o600)
+		So(err, ShouldBeNil)
+		err = os.WriteFile(clientKeyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: clientKeyDER}),
+			0o600)
+		So(err, ShouldBeNil)
+
+		clientCert, err := x509.ParseCertificate(clientCertDER)
+		So(err, ShouldBeNil)
+
+		clientCAs := x509.NewCertPool()
+		clientCAs.AddCert(clientCert)
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"repositories": ["repo"]}`))
+		}))
+		server.TLS = &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+			MinVersion: tls.VersionTLS12,
+		}
+		server.StartTLS()
+		defer server.Close()
+
+		err = os.WriteFile(caCertPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+			0o600)
+		So(err, ShouldBeNil)
+
+		searchConf := getDefaultSearchConf(server.URL)
+		searchConf.VerifyTLS = true
+		searchConf.CACertFile = caCertPath
+
+		Convey("with the client certificate", func() {
+			searchConf.CertFile = clientCertPath
+			searchConf.KeyFile = clientKeyPath
+
+			catalog, err := getCatalog(context.Background(), searchConf, "", "")
+			So(err, ShouldBeNil)
+			So(catalog.Repositories, ShouldResemble, []string{"repo"})
+		})
+
+		Convey("without the client certificate", func() {
+			_, err := getCatalog(context.Background(), searchConf, "", "")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("without the server CA", func() {
+			searchConf.CACertFile = ""
+			searchConf.CertFile = clientCertPath
+			searchConf.KeyFile = clientKeyPath
+
+			_, err := getCatalog(context.Background(), searchConf, "", "")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("bad certificate files", func() {
+			searchConf.CACertFile = clientKeyPath
+
+			_, err := getCatalog(context.Background(), searchConf, "", "")
+			So(errors.Is(err, zerr.ErrBadCACert), ShouldBeTrue)
+
+			searchConf.CACertFile = path.Join(certDir, "missing.crt")
+
+			_, err = getCatalog(context.Background(), searchConf, "", "")
+			So(err, ShouldNotBeNil)
+
+			searchConf.CACertFile = caCertPath
+			searchConf.CertFile = clientKeyPath
+			searchConf.KeyFile = clientKeyPath
+
+			_, err = getCatalog(context.Background(), searchConf, "", "")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Certificate options from flags and config", t, func() {
+		configPath := makeConfigFile(`{"configs":[{"_name":"mtls","url":"https://127.0.0.1:8080",
+			"cert":"/config/client.crt","key":"/config/client.key","cacert":"/config/ca.crt"}]}`)
+		defer os.Remove(configPath)
+
+		searchConfig, err := getImageListSearchConfig("--config", "mtls")
+		So(err, ShouldBeNil)
+		So(searchConfig.CertFile, ShouldEqual, "/config/client.crt")
+		So(searchConfig.KeyFile, ShouldEqual, "/config/client.key")
+		So(searchConfig.CACertFile, ShouldEqual, "/config/ca.crt")
+
+		searchConfig, err = getImageListSearchConfig("--config", "mtls", "--cacert", "/flags/ca.crt")
+		So(err, ShouldBeNil)
+		So(searchConfig.CertFile, ShouldEqual, "/config/client.crt")
+		So(searchConfig.CACertFile, ShouldEqual, "/flags/ca.crt")
+
+		_, err = getImageListSearchConfig("--url", "https://127.0.0.1:8080", "--cert", "/flags/client.crt")
+		So(errors.Is(err, zerr.ErrInvalidCLIParameter), ShouldBeTrue)
+	})
+}
+
+func TestGetSignersStr(t *testing.T) {
+	Convey("Only the trusted signatures are shown", t, func() {
+		So(getSignersStr(nil), ShouldBeEmpty)
+		So(getSignersStr([]common.SignatureSummary{{Tool: "cosign"}, {Tool: "notation"}}), ShouldBeEmpty)
+
+		signers := getSignersStr([]common.SignatureSummary{
+			{Tool: "notation", IsTrusted: true, Author: "CN=acme"},
+			{Tool: "cosign", IsTrusted: true, Author: "-----BEGIN PUBLIC KEY-----\nMFkw\n-----END PUBLIC KEY-----\n"},
+			{Tool: "cosign", IsTrusted: true, Author: "release.pub"},
+			{Tool: "cosign", IsTrusted: true, Author: "release.pub"},
+			{Tool: "cosign", Author: "other.pub"},
+		})
+		So(signers, ShouldEqual, "notation:CN=acme,cosign,cosign:release.pub")
+	})
+}
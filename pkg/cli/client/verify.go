@@ -0,0 +1,278 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	"github.com/dustin/go-humanize"
+	jsoniter "github.com/json-iterator/go"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gopkg.in/yaml.v2"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/common"
+)
+
+// imageVerifyStruct is the result of downloading an image and checking each manifest and blob against
+// the digest and size it is referenced with.
+type imageVerifyStruct struct {
+	RepoName   string           `json:"repoName"   yaml:"repoName"`
+	Reference  string           `json:"reference"  yaml:"reference"`
+	Digest     string           `json:"digest"     yaml:"digest"`
+	Manifests  int              `json:"manifests"  yaml:"manifests"`
+	Blobs      int              `json:"blobs"      yaml:"blobs"`
+	Size       int64            `json:"size"       yaml:"size"`
+	Mismatches []verifyMismatch `json:"mismatches" yaml:"mismatches"`
+}
+
+type verifyMismatch struct {
+	// manifest, config or layer
+	Kind    string `json:"kind"    yaml:"kind"`
+	Digest  string `json:"digest"  yaml:"digest"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// imageVerifier downloads the manifests and blobs of an image, each blob once even if several images
+// of an index share it.
+type imageVerifier struct {
+	registry registryRepo
+	result   *imageVerifyStruct
+	verified map[godigest.Digest]bool
+}
+
+func fetchImageVerifyStruct(ctx context.Context, config SearchConfig, username, password, repo, reference string,
+) (*imageVerifyStruct, error) {
+	registry := newRegistryRepo(config, username, password, repo)
+
+	content, mediaType, digest, err := fetchRawManifest(ctx, config, username, password, repo, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier := imageVerifier{
+		registry: registry,
+		result:   &imageVerifyStruct{RepoName: repo, Reference: reference, Digest: digest},
+		verified: map[godigest.Digest]bool{},
+	}
+
+	// the digest returned by the registry is checked too, it's the one the clients pull the image by
+	expected := godigest.Digest(digest)
+	if godigest.Digest(reference).Validate() == nil {
+		expected = godigest.Digest(reference)
+
+		if digest != reference {
+			verifier.addMismatch("manifest", reference, fmt.Sprintf("the registry returned the digest %s", digest))
+		}
+	}
+
+	verifier.result.Manifests++
+	verifier.result.Size += int64(len(content))
+	verifier.checkDigest("manifest", expected, -1, content)
+
+	if err := verifier.verifyManifest(ctx, content, mediaType); err != nil {
+		return nil, err
+	}
+
+	return verifier.result, nil
+}
+
+func (verifier *imageVerifier) addMismatch(kind, digest, message string) {
+	verifier.result.Mismatches = append(verifier.result.Mismatches,
+		verifyMismatch{Kind: kind, Digest: digest, Message: message})
+}
+
+// checkDigest compares the content to the digest and the size it is referenced with, a negative size
+// isn't checked.
+func (verifier *imageVerifier) checkDigest(kind string, digest godigest.Digest, size int64, content []byte) bool {
+	if err := digest.Validate(); err != nil {
+		verifier.addMismatch(kind, digest.String(), err.Error())
+
+		return false
+	}
+
+	if size >= 0 && int64(len(content)) != size {
+		verifier.addMismatch(kind, digest.String(), fmt.Sprintf("size is %d instead of %d", len(content), size))
+
+		return false
+	}
+
+	if actual := digest.Algorithm().FromBytes(content); actual != digest {
+		verifier.addMismatch(kind, digest.String(), "content has the digest "+actual.String())
+
+		return false
+	}
+
+	return true
+}
+
+func (verifier *imageVerifier) verifyManifest(ctx context.Context, content []byte, mediaType string) error {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	switch mediaType {
+	case ispec.MediaTypeImageManifest, schema2.MediaTypeManifest:
+		var manifest ispec.Manifest
+
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			return err
+		}
+
+		verifier.verifyBlob(ctx, "config", manifest.Config)
+
+		for _, layer := range manifest.Layers {
+			verifier.verifyBlob(ctx, "layer", layer)
+		}
+	case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
+		var index ispec.Index
+
+		if err := json.Unmarshal(content, &index); err != nil {
+			return err
+		}
+
+		for _, descriptor := range index.Manifests {
+			if verifier.verified[descriptor.Digest] {
+				continue
+			}
+
+			verifier.verified[descriptor.Digest] = true
+			verifier.result.Manifests++
+
+			manifestContent, manifestMediaType, err := verifier.registry.getManifest(ctx, descriptor.Digest.String())
+			if err != nil {
+				verifier.addMismatch("manifest", descriptor.Digest.String(), "failed to download: "+err.Error())
+
+				continue
+			}
+
+			verifier.result.Size += int64(len(manifestContent))
+
+			if !verifier.checkDigest("manifest", descriptor.Digest, descriptor.Size, manifestContent) {
+				continue
+			}
+
+			if err := verifier.verifyManifest(ctx, manifestContent, manifestMediaType); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %s", zerr.ErrMediaTypeNotSupported, mediaType)
+	}
+
+	return nil
+}
+
+// verifyBlob streams the blob through its digest algorithm, without keeping it.
+func (verifier *imageVerifier) verifyBlob(ctx context.Context, kind string, blob ispec.Descriptor) {
+	if verifier.verified[blob.Digest] {
+		return
+	}
+
+	verifier.verified[blob.Digest] = true
+	verifier.result.Blobs++
+
+	if err := blob.Digest.Validate(); err != nil {
+		verifier.addMismatch(kind, blob.Digest.String(), err.Error())
+
+		return
+	}
+
+	content, err := verifier.registry.getBlob(ctx, blob)
+	if err != nil {
+		verifier.addMismatch(kind, blob.Digest.String(), "failed to download: "+err.Error())
+
+		return
+	}
+
+	defer content.Close()
+
+	digester := blob.Digest.Algorithm().Digester()
+
+	size, err := io.Copy(digester.Hash(), content)
+	verifier.result.Size += size
+
+	switch {
+	case err != nil:
+		verifier.addMismatch(kind, blob.Digest.String(), "failed to download: "+err.Error())
+	case size != blob.Size:
+		verifier.addMismatch(kind, blob.Digest.String(), fmt.Sprintf("size is %d instead of %d", size, blob.Size))
+	case digester.Digest() != blob.Digest:
+		verifier.addMismatch(kind, blob.Digest.String(), "content has the digest "+digester.Digest().String())
+	}
+}
+
+func (img imageVerifyStruct) string(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return img.stringPlainText(), nil
+	case jsonFormat:
+		return img.stringJSON()
+	case ndjsonFormat:
+		return img.stringNDJSON()
+	case ymlFormat, yamlFormat:
+		return img.stringYAML()
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (img imageVerifyStruct) stringPlainText() string {
+	var builder strings.Builder
+
+	status := "OK"
+	if len(img.Mismatches) > 0 {
+		status = fmt.Sprintf("%d mismatches", len(img.Mismatches))
+	}
+
+	fmt.Fprintf(&builder, "%s (%s): %d manifests, %d blobs, %s downloaded: %s\n",
+		common.GetFullImageName(img.RepoName, img.Reference), img.Digest, img.Manifests, img.Blobs,
+		humanize.Bytes(uint64(img.Size)), status)
+
+	writer := tabwriter.NewWriter(&builder, 0, 8, 2, ' ', 0) //nolint:gomnd
+
+	for _, mismatch := range img.Mismatches {
+		fmt.Fprintf(writer, "  %s\t%s\t%s\n", mismatch.Kind, mismatch.Digest, mismatch.Message)
+	}
+
+	writer.Flush()
+
+	return builder.String()
+}
+
+func (img imageVerifyStruct) stringJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.MarshalIndent(img, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+func (img imageVerifyStruct) stringNDJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	body, err := json.Marshal(img)
+	if err != nil {
+		return "", err
+	}
+
+	return string(body) + "\n", nil
+}
+
+func (img imageVerifyStruct) stringYAML() (string, error) {
+	body, err := yaml.Marshal(img)
+	if err != nil {
+		return "", err
+	}
+
+	return "---\n" + string(body), nil
+}
@@ -0,0 +1,241 @@
+//go:build search
+// +build search
+
+package client
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v2"
+
+	zerr "zotregistry.dev/zot/errors"
+)
+
+const (
+	imageAdded   = "added"
+	imageRemoved = "removed"
+	imageUpdated = "updated"
+)
+
+// imageChange is an image added, removed or whose tag points to another digest, between two runs of
+// a watched listing.
+type imageChange struct {
+	Time           time.Time `json:"time"`
+	Change         string    `json:"change"`
+	RepoName       string    `json:"repoName"`
+	Tag            string    `json:"tag"`
+	Digest         string    `json:"digest"`
+	PreviousDigest string    `json:"previousDigest,omitempty"`
+}
+
+// WatchImages runs the listing every interval until the context is done. The images of the first run are
+// shown like without --watch, then only the changes since the previous run.
+func WatchImages(ctx context.Context, config SearchConfig, interval time.Duration,
+	listImages func(config SearchConfig) error,
+) error {
+	// the changes are a stream of events, they can't be rendered as a table of images
+	if isSeparatedValuesFormat(config.OutputFormat) || isTemplateFormat(config.OutputFormat) {
+		return fmt.Errorf("%w: the changes shown with --%s can only be rendered as text, json, ndjson or yaml",
+			zerr.ErrInvalidCLIParameter, WatchFlag)
+	}
+
+	previous, err := readImageListing(config, listImages)
+	if err != nil {
+		return err
+	}
+
+	// the signatures were already verified by the listing
+	printConfig := config
+	printConfig.VerifySignature = false
+
+	if err := printImageResult(ctx, printConfig, previous); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := readImageListing(config, listImages)
+		if err != nil {
+			// the images missing from a failed run would be shown as removed, the next run is compared
+			// with the last complete one instead
+			fmt.Fprintf(config.ErrorWriter, "Error: %s\n", err)
+
+			continue
+		}
+
+		out, err := getImageChanges(previous, current, time.Now()).string(config.OutputFormat, config.NoTrunc)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(config.ResultWriter, out)
+
+		previous = current
+	}
+}
+
+// readImageListing runs the listing with the json output and reads the images back from it.
+func readImageListing(config SearchConfig, listImages func(config SearchConfig) error) ([]imageStruct, error) {
+	var buffer bytes.Buffer
+
+	config.OutputFormat = ndjsonFormat
+	config.ResultWriter = &buffer
+
+	if err := listImages(config); err != nil {
+		return nil, err
+	}
+
+	images := []imageStruct{}
+	decoder := jsoniter.ConfigCompatibleWithStandardLibrary.NewDecoder(&buffer)
+
+	for decoder.More() {
+		var image imageStruct
+
+		if err := decoder.Decode(&image); err != nil {
+			return nil, err
+		}
+
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+// getImageChanges compares the images of two runs by repo and tag, the changes are sorted the same way.
+func getImageChanges(previous, current []imageStruct, now time.Time) imageChanges {
+	previousDigests := make(map[string]string, len(previous))
+	currentDigests := make(map[string]string, len(current))
+	changes := imageChanges{}
+
+	for _, image := range previous {
+		previousDigests[image.RepoName+":"+image.Tag] = image.Digest
+	}
+
+	for _, image := range current {
+		reference := image.RepoName + ":" + image.Tag
+		currentDigests[reference] = image.Digest
+
+		previousDigest, found := previousDigests[reference]
+
+		switch {
+		case !found:
+			changes = append(changes, imageChange{Time: now, Change: imageAdded, RepoName: image.RepoName,
+				Tag: image.Tag, Digest: image.Digest})
+		case previousDigest != image.Digest:
+			changes = append(changes, imageChange{Time: now, Change: imageUpdated, RepoName: image.RepoName,
+				Tag: image.Tag, Digest: image.Digest, PreviousDigest: previousDigest})
+		}
+	}
+
+	for _, image := range previous {
+		if _, found := currentDigests[image.RepoName+":"+image.Tag]; !found {
+			changes = append(changes, imageChange{Time: now, Change: imageRemoved, RepoName: image.RepoName,
+				Tag: image.Tag, Digest: image.Digest})
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b imageChange) int {
+		if a.RepoName != b.RepoName {
+			return cmp.Compare(a.RepoName, b.RepoName)
+		}
+
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+
+	return changes
+}
+
+type imageChanges []imageChange
+
+func (changes imageChanges) string(format string, noTrunc bool) (string, error) {
+	switch strings.ToLower(format) {
+	case "", defaultOutputFormat:
+		return changes.stringPlainText(noTrunc)
+	case jsonFormat, ndjsonFormat:
+		// the changes are streamed, so they're always written one per line
+		return changes.stringNDJSON()
+	case ymlFormat, yamlFormat:
+		return changes.stringYAML()
+	default:
+		return "", zerr.ErrInvalidOutputFormat
+	}
+}
+
+func (changes imageChanges) stringPlainText(noTrunc bool) (string, error) {
+	var builder strings.Builder
+
+	writer := tabwriter.NewWriter(&builder, 0, 8, 2, ' ', 0) //nolint:gomnd
+
+	shorten := getShortDigest
+	if noTrunc {
+		shorten = func(digest string) string { return digest }
+	}
+
+	for _, change := range changes {
+		digest := shorten(change.Digest)
+
+		if change.Change == imageUpdated {
+			digest = shorten(change.PreviousDigest) + " -> " + digest
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s:%s\t%s\n", change.Time.Format(time.RFC3339), strings.ToUpper(change.Change),
+			change.RepoName, change.Tag, digest)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+func (changes imageChanges) stringNDJSON() (string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	var builder strings.Builder
+
+	for _, change := range changes {
+		body, err := json.Marshal(change)
+		if err != nil {
+			return "", err
+		}
+
+		builder.Write(body)
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
+
+// stringYAML renders one yaml document per change.
+func (changes imageChanges) stringYAML() (string, error) {
+	var builder strings.Builder
+
+	for _, change := range changes {
+		body, err := yaml.Marshal(change)
+		if err != nil {
+			return "", err
+		}
+
+		builder.WriteString("---\n")
+		builder.Write(body)
+	}
+
+	return builder.String(), nil
+}
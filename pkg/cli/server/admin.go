@@ -0,0 +1,271 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/api/constants"
+	"zotregistry.dev/zot/pkg/storage"
+	"zotregistry.dev/zot/pkg/storage/gc"
+)
+
+func newAdminCmd(conf *config.Config) *cobra.Command {
+	credentials := ""
+
+	// "admin"
+	adminCmd := &cobra.Command{
+		Use:   "admin",
+		Short: "`admin` manages the repositories of a running server",
+		Long: "`admin` manages the repositories and the background tasks of the server running with the config, " +
+			"admin credentials are needed if authentication is enabled",
+	}
+
+	adminCmd.PersistentFlags().StringVarP(&credentials, "user", "u", "",
+		`admin credentials in "username:password" format`)
+
+	// "admin repos"
+	adminCmd.AddCommand(&cobra.Command{
+		Use:   "repos <config>",
+		Short: "`repos` lists the repositories with their tags, blobs and size",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := LoadConfiguration(conf, args[0]); err != nil {
+				return err
+			}
+
+			stats := []storage.RepoStats{}
+
+			if _, err := doServerRequest(cmd.Context(), conf, credentials, http.MethodGet,
+				constants.RoutePrefix+constants.AdminReposPath, &stats); err != nil {
+				log.Error().Err(err).Msg("failed to list the repositories of the server")
+
+				return err
+			}
+
+			printRepoStats(cmd.OutOrStdout(), stats)
+
+			return nil
+		},
+	})
+
+	// "admin delete"
+	adminCmd.AddCommand(&cobra.Command{
+		Use:   "delete <config> <repository>",
+		Short: "`delete` deletes a repository with all its images",
+		Args:  cobra.ExactArgs(2), //nolint:gomnd
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := LoadConfiguration(conf, args[0]); err != nil {
+				return err
+			}
+
+			repo := args[1]
+
+			statusCode, err := doServerRequest(cmd.Context(), conf, credentials, http.MethodDelete,
+				constants.RoutePrefix+constants.AdminReposPath+"/"+repo, nil)
+			if statusCode == http.StatusNotFound {
+				err = fmt.Errorf("%w: %s", zerr.ErrRepoNotFound, repo)
+			}
+
+			if err != nil {
+				log.Error().Err(err).Str("repository", repo).Msg("failed to delete the repository")
+
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "deleted %s\n", repo)
+
+			return nil
+		},
+	})
+
+	// "admin uploads"
+	adminCmd.AddCommand(&cobra.Command{
+		Use:   "uploads <config>",
+		Short: "`uploads` lists the blob uploads in progress",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := LoadConfiguration(conf, args[0]); err != nil {
+				return err
+			}
+
+			uploads := []storage.RepoUploads{}
+
+			if _, err := doServerRequest(cmd.Context(), conf, credentials, http.MethodGet,
+				constants.RoutePrefix+constants.AdminUploadsPath, &uploads); err != nil {
+				log.Error().Err(err).Msg("failed to list the blob uploads of the server")
+
+				return err
+			}
+
+			printRepoUploads(cmd.OutOrStdout(), uploads)
+
+			return nil
+		},
+	})
+
+	// "admin run"
+	adminCmd.AddCommand(&cobra.Command{
+		Use:       "run <config> gc|scrub|sync",
+		Short:     "`run` starts a garbage collection, a scrub or a sync now",
+		Long:      "`run` starts a garbage collection, a scrub or a sync now, without waiting for its interval",
+		Args:      cobra.ExactArgs(2), //nolint:gomnd
+		ValidArgs: []string{"gc", "scrub", "sync"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			task := args[1]
+			if task != "gc" && task != "scrub" && task != "sync" {
+				return fmt.Errorf("%w: unknown task %s, expected gc, scrub or sync", zerr.ErrInvalidArgs, task)
+			}
+
+			if err := LoadConfiguration(conf, args[0]); err != nil {
+				return err
+			}
+
+			statusCode, err := doServerRequest(cmd.Context(), conf, credentials, http.MethodPost,
+				constants.RoutePrefix+constants.AdminTasksPath+"/"+task, nil)
+			if statusCode == http.StatusMethodNotAllowed {
+				err = fmt.Errorf("%w: %s", zerr.ErrExtensionNotEnabled, task)
+			}
+
+			if err != nil {
+				log.Error().Err(err).Str("task", task).Msg("failed to start the task")
+
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "started %s\n", task)
+
+			return nil
+		},
+	})
+
+	// "admin gc"
+	adminCmd.AddCommand(newGCReportCmd(conf, &credentials, "gc",
+		"`gc` starts a garbage collection now, or with --dry-run lists what it would remove",
+		"`gc` starts a garbage collection now, without waiting for its interval. With --dry-run nothing is "+
+			"removed, the manifests and blobs the garbage collection would remove are listed instead, "+
+			"with the space it would free"))
+
+	// "admin retention"
+	adminCmd.AddCommand(newGCReportCmd(conf, &credentials, "retention",
+		"`retention` lists what the retention policies would remove",
+		"`retention` lists the manifests the retention policies would remove now, and the blobs only they use, "+
+			"with the space it would free. The policies are applied by the garbage collection, so only --dry-run "+
+			"is supported, `admin gc` applies them"))
+
+	return adminCmd
+}
+
+// newGCReportCmd returns the command reporting what the garbage collection, or only its retention policies,
+// would remove. Without --dry-run, the gc one starts a garbage collection like `admin run gc`.
+func newGCReportCmd(conf *config.Config, credentials *string, task, short, long string) *cobra.Command {
+	dryRun := false
+
+	cmd := &cobra.Command{
+		Use:   task + " <config>",
+		Short: short,
+		Long:  long,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !dryRun && task == "retention" {
+				return fmt.Errorf("%w: retention is applied by gc, only --dry-run is supported", zerr.ErrInvalidArgs)
+			}
+
+			if err := LoadConfiguration(conf, args[0]); err != nil {
+				return err
+			}
+
+			method, path := http.MethodPost, constants.RoutePrefix+constants.AdminTasksPath+"/gc"
+			report := gc.Report{}
+
+			var result any
+
+			if dryRun {
+				method, path = http.MethodGet, constants.RoutePrefix+constants.AdminPath+"/"+task+"/dryrun"
+				result = &report
+			}
+
+			statusCode, err := doServerRequest(cmd.Context(), conf, *credentials, method, path, result)
+			if statusCode == http.StatusMethodNotAllowed {
+				err = fmt.Errorf("%w: gc", zerr.ErrExtensionNotEnabled)
+			}
+
+			if err != nil {
+				log.Error().Err(err).Str("task", task).Bool("dry-run", dryRun).Msg("failed to run the task")
+
+				return err
+			}
+
+			if !dryRun {
+				fmt.Fprintln(cmd.OutOrStdout(), "started gc")
+
+				return nil
+			}
+
+			printGCReport(cmd.OutOrStdout(), report)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only list what would be removed")
+
+	return cmd
+}
+
+func printRepoStats(writer io.Writer, stats []storage.RepoStats) {
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0) //nolint:gomnd
+
+	fmt.Fprintln(tabWriter, "REPOSITORY\tTAGS\tMANIFESTS\tBLOBS\tSIZE\tUPLOADS\tLAST UPDATED")
+
+	for _, repoStats := range stats {
+		fmt.Fprintf(tabWriter, "%s\t%d\t%d\t%d\t%s\t%d\t%s\n", repoStats.Name, repoStats.Tags,
+			repoStats.Manifests, repoStats.Blobs, humanize.Bytes(uint64(repoStats.Size)), repoStats.Uploads,
+			repoStats.LastUpdated.Format(time.RFC3339))
+	}
+
+	tabWriter.Flush()
+}
+
+func printRepoUploads(writer io.Writer, repoUploads []storage.RepoUploads) {
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0) //nolint:gomnd
+
+	fmt.Fprintln(tabWriter, "REPOSITORY\tSESSION\tSIZE\tLAST MODIFIED")
+
+	for _, repo := range repoUploads {
+		for _, upload := range repo.Uploads {
+			fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\n", repo.Name, upload.SessionID,
+				humanize.Bytes(uint64(upload.Size)), upload.LastModified.Format(time.RFC3339))
+		}
+	}
+
+	tabWriter.Flush()
+}
+
+func printGCReport(writer io.Writer, report gc.Report) {
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0) //nolint:gomnd
+
+	fmt.Fprintln(tabWriter, "KIND\tREPOSITORY\tREFERENCE\tDIGEST\tSIZE\tREASON")
+
+	for _, manifest := range report.Manifests {
+		fmt.Fprintf(tabWriter, "manifest\t%s\t%s\t%s\t%s\t%s\n", manifest.Repo, manifest.Reference,
+			manifest.Digest, humanize.Bytes(uint64(manifest.Size)), manifest.Reason)
+	}
+
+	for _, blob := range report.Blobs {
+		fmt.Fprintf(tabWriter, "blob\t%s\t\t%s\t%s\t\n", blob.Repo, blob.Digest, humanize.Bytes(uint64(blob.Size)))
+	}
+
+	tabWriter.Flush()
+
+	fmt.Fprintf(writer, "%d manifests and %d blobs would be removed, freeing %s\n", len(report.Manifests),
+		len(report.Blobs), humanize.Bytes(uint64(report.Size)))
+}
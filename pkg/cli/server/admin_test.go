@@ -0,0 +1,253 @@
+package server_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/resty.v1"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	cli "zotregistry.dev/zot/pkg/cli/server"
+	zlog "zotregistry.dev/zot/pkg/log"
+	. "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+	ociutils "zotregistry.dev/zot/pkg/test/oci-utils"
+)
+
+func TestAdmin(t *testing.T) {
+	Convey("admin commands against a running server", t, func() {
+		port := GetFreePort()
+		baseURL := GetBaseURL(port)
+		dir := t.TempDir()
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = dir
+		conf.Storage.GC = true
+		conf.Storage.GCInterval = time.Hour
+
+		storeController := ociutils.GetDefaultStoreController(dir, zlog.NewLogger("debug", ""))
+		So(WriteImageToFileSystem(CreateRandomImage(), "app", "1.0", storeController), ShouldBeNil)
+		So(WriteImageToFileSystem(CreateRandomImage(), "other", "1.0", storeController), ShouldBeNil)
+
+		ctlr := api.NewController(conf)
+
+		cm := NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		resp, err := resty.R().Post(baseURL + "/v2/other/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		sessionID := path.Base(resp.Header().Get("Location"))
+
+		cfgFile := path.Join(t.TempDir(), "zot.json")
+		content := fmt.Sprintf(`{
+			"storage": {"rootDirectory": "%s"},
+			"http": {"address": "127.0.0.1", "port": "%s"}
+		}`, dir, port)
+		So(os.WriteFile(cfgFile, []byte(content), 0o600), ShouldBeNil)
+
+		runAdmin := func(args ...string) (string, error) {
+			output := bytes.NewBufferString("")
+			cmd := cli.NewServerRootCmd()
+			cmd.SetOut(output)
+			cmd.SetArgs(append([]string{"admin"}, args...))
+
+			err := cmd.Execute()
+
+			return output.String(), err
+		}
+
+		output, err := runAdmin("repos", cfgFile)
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "REPOSITORY")
+		So(output, ShouldContainSubstring, "app")
+		So(output, ShouldContainSubstring, "other")
+
+		output, err = runAdmin("uploads", cfgFile)
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "SESSION")
+		So(output, ShouldContainSubstring, sessionID)
+
+		output, err = runAdmin("delete", cfgFile, "app")
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "deleted app")
+
+		_, err = runAdmin("delete", cfgFile, "app")
+		So(err, ShouldWrap, zerr.ErrRepoNotFound)
+
+		output, err = runAdmin("repos", cfgFile)
+		So(err, ShouldBeNil)
+		So(output, ShouldNotContainSubstring, "app")
+		So(output, ShouldContainSubstring, "other")
+
+		output, err = runAdmin("run", cfgFile, "gc")
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "started gc")
+
+		_, err = runAdmin("run", cfgFile, "sync")
+		So(err, ShouldWrap, zerr.ErrExtensionNotEnabled)
+
+		_, err = runAdmin("run", cfgFile, "dedupe")
+		So(err, ShouldWrap, zerr.ErrInvalidArgs)
+
+		Convey("server not running", func() {
+			content := fmt.Sprintf(`{
+				"storage": {"rootDirectory": "%s"},
+				"http": {"address": "127.0.0.1", "port": "%s"}
+			}`, dir, GetFreePort())
+			So(os.WriteFile(cfgFile, []byte(content), 0o600), ShouldBeNil)
+
+			_, err := runAdmin("repos", cfgFile)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("non-existent config", func() {
+			_, err := runAdmin("repos", path.Join(t.TempDir(), "x.json"))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestAdminGCReport(t *testing.T) {
+	Convey("gc and retention dry-runs against a running server", t, func() {
+		port := GetFreePort()
+		baseURL := GetBaseURL(port)
+		dir := t.TempDir()
+
+		trueVal := true
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = dir
+		conf.Storage.GC = true
+		conf.Storage.GCDelay = 0
+		conf.Storage.GCInterval = time.Hour
+		conf.Storage.Retention = config.ImageRetention{
+			Policies: []config.RetentionPolicy{
+				{
+					Repositories:   []string{"**"},
+					DeleteUntagged: &trueVal,
+				},
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		cm := NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		// uploaded once the storage was garbage collected at startup, the next GC is an hour away
+		untagged := CreateRandomImage()
+		So(UploadImage(untagged, baseURL, "app", untagged.DigestStr()), ShouldBeNil)
+
+		cfgFile := path.Join(t.TempDir(), "zot.json")
+		content := fmt.Sprintf(`{
+			"storage": {"rootDirectory": "%s"},
+			"http": {"address": "127.0.0.1", "port": "%s"}
+		}`, dir, port)
+		So(os.WriteFile(cfgFile, []byte(content), 0o600), ShouldBeNil)
+
+		runAdmin := func(args ...string) (string, error) {
+			output := bytes.NewBufferString("")
+			cmd := cli.NewServerRootCmd()
+			cmd.SetOut(output)
+			cmd.SetArgs(append([]string{"admin"}, args...))
+
+			err := cmd.Execute()
+
+			return output.String(), err
+		}
+
+		output, err := runAdmin("gc", "--dry-run", cfgFile)
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "REASON")
+		So(output, ShouldContainSubstring, untagged.DigestStr())
+		So(output, ShouldContainSubstring, "deleteUntagged")
+		So(output, ShouldContainSubstring, untagged.ConfigDescriptor.Digest.String())
+		So(output, ShouldContainSubstring, "1 manifests and 3 blobs would be removed")
+
+		output, err = runAdmin("retention", "--dry-run", cfgFile)
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "deleteUntagged")
+		So(output, ShouldContainSubstring, "1 manifests and 3 blobs would be removed")
+
+		// nothing was removed
+		resp, err := resty.R().Head(baseURL + "/v2/app/manifests/" + untagged.DigestStr())
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		_, err = runAdmin("retention", cfgFile)
+		So(err, ShouldWrap, zerr.ErrInvalidArgs)
+
+		output, err = runAdmin("gc", cfgFile)
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "started gc")
+	})
+}
+
+func TestAdminUnixSocket(t *testing.T) {
+	Convey("admin commands against a server only listening on a unix socket", t, func() {
+		dir := t.TempDir()
+		socket := path.Join(t.TempDir(), "zot.sock")
+
+		storeController := ociutils.GetDefaultStoreController(dir, zlog.NewLogger("debug", ""))
+		So(WriteImageToFileSystem(CreateRandomImage(), "app", "1.0", storeController), ShouldBeNil)
+
+		cfgFile := path.Join(t.TempDir(), "zot.json")
+		content := fmt.Sprintf(`{
+			"storage": {"rootDirectory": "%s"},
+			"http": {"address": "127.0.0.1", "socket": "%s"}
+		}`, dir, socket)
+		So(os.WriteFile(cfgFile, []byte(content), 0o600), ShouldBeNil)
+
+		conf := config.New()
+		So(cli.LoadConfiguration(conf, cfgFile), ShouldBeNil)
+		// without port the server doesn't listen on the default one
+		So(conf.HTTP.Port, ShouldBeEmpty)
+
+		ctlr := api.NewController(conf)
+
+		cm := NewControllerManager(ctlr)
+		cm.StartServer()
+		defer cm.StopServer()
+
+		for {
+			if _, err := os.Stat(socket); err == nil {
+				break
+			}
+
+			time.Sleep(SleepTime)
+		}
+
+		runServerCmd := func(args ...string) (string, error) {
+			output := bytes.NewBufferString("")
+			cmd := cli.NewServerRootCmd()
+			cmd.SetOut(output)
+			cmd.SetArgs(args)
+
+			err := cmd.Execute()
+
+			return output.String(), err
+		}
+
+		output, err := runServerCmd("admin", "repos", cfgFile)
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "app")
+
+		// the commands using the storage directly check the server through its socket
+		_, err = runServerCmd("scrub", cfgFile)
+		So(err, ShouldWrap, zerr.ErrServerIsRunning)
+	})
+}
@@ -0,0 +1,538 @@
+package server
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	zlog "zotregistry.dev/zot/pkg/log"
+	"zotregistry.dev/zot/pkg/storage"
+	storageCommon "zotregistry.dev/zot/pkg/storage/common"
+	storageTypes "zotregistry.dev/zot/pkg/storage/types"
+)
+
+// BundleRepositoryAnnotation is set on the index.json of a bundle to the repository it was exported from.
+const BundleRepositoryAnnotation = "io.zotregistry.bundle.repository"
+
+func newExportCmd(conf *config.Config) *cobra.Command {
+	output := ""
+
+	// "export"
+	exportCmd := &cobra.Command{
+		Use:   "export <config> <repo>[:<tag>]",
+		Short: "`export` writes images of a repository to a bundle",
+		Long: "`export` writes the image with the tag, or all the images of the repository, along with their " +
+			"signatures, SBOMs and other referrers to a tar archive in the OCI image layout, to be imported " +
+			"by `zot import` into another registry, the server should be shut down",
+		Args: cobra.ExactArgs(2), //nolint:gomnd
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := LoadConfiguration(conf, args[0]); err != nil {
+				return err
+			}
+
+			if output == "" {
+				return fmt.Errorf("%w: --to is required", zerr.ErrInvalidArgs)
+			}
+
+			if err := checkServerIsDown(conf, "export"); err != nil {
+				return err
+			}
+
+			repo, tag, _ := strings.Cut(args[1], ":")
+
+			ctlr := api.NewController(conf)
+			ctlr.Metrics = monitoring.NewMetricsServer(false, ctlr.Log)
+
+			if err := ctlr.InitImageStore(); err != nil {
+				return err
+			}
+
+			bundleFile, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+
+			imgStore := ctlr.StoreController.GetImageStore(repo)
+
+			if err := ExportBundle(imgStore, repo, tag, bundleFile, ctlr.Log); err != nil {
+				bundleFile.Close()
+				os.Remove(output)
+
+				return err
+			}
+
+			return bundleFile.Close()
+		},
+	}
+
+	exportCmd.Flags().StringVar(&output, "to", "", "path of the bundle to write")
+
+	return exportCmd
+}
+
+func newImportCmd(conf *config.Config) *cobra.Command {
+	repo := ""
+
+	// "import"
+	importCmd := &cobra.Command{
+		Use:   "import <config> <bundle>",
+		Short: "`import` pushes the images of a bundle",
+		Long: "`import` checks the digests of the blobs of a bundle written by `zot export` and pushes its images " +
+			"to the repository they were exported from, or to the one given with --repo, the server should be shut down",
+		Args: cobra.ExactArgs(2), //nolint:gomnd
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := LoadConfiguration(conf, args[0]); err != nil {
+				return err
+			}
+
+			if err := checkServerIsDown(conf, "import"); err != nil {
+				return err
+			}
+
+			ctlr := api.NewController(conf)
+			ctlr.Metrics = monitoring.NewMetricsServer(false, ctlr.Log)
+
+			if err := ctlr.InitImageStore(); err != nil {
+				return err
+			}
+
+			bundleFile, err := os.Open(args[1])
+			if err != nil {
+				return err
+			}
+
+			defer bundleFile.Close()
+
+			imported, err := ImportBundle(&ctlr.StoreController, repo, bundleFile, ctlr.Log)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "imported %d manifests to %s\n", imported.Manifests, imported.Repository)
+
+			return nil
+		},
+	}
+
+	importCmd.Flags().StringVar(&repo, "repo", "", "repository to import the images to, instead of the exported one")
+
+	return importCmd
+}
+
+// ExportBundle writes the images of the repository with the tag, or all of them if the tag is empty, and the
+// artifacts referring to them to a tar archive in the OCI image layout. The index.json comes first, so the
+// blobs can be checked against it while the bundle is read.
+func ExportBundle(imgStore storageTypes.ImageStore, repo, tag string, output io.Writer, log zlog.Logger) error {
+	index, err := storageCommon.GetIndex(imgStore, repo, log)
+	if err != nil {
+		return err
+	}
+
+	descriptors, err := selectBundleDescriptors(imgStore, repo, index, tag)
+	if err != nil {
+		return err
+	}
+
+	blobs := []godigest.Digest{}
+	seen := map[godigest.Digest]bool{}
+
+	for _, desc := range descriptors {
+		if err := listBundleBlobs(imgStore, repo, desc, seen, &blobs); err != nil {
+			return err
+		}
+	}
+
+	bundleIndex := ispec.Index{
+		MediaType: ispec.MediaTypeImageIndex,
+		Manifests: descriptors,
+		Annotations: map[string]string{
+			BundleRepositoryAnnotation: repo,
+		},
+	}
+	bundleIndex.SchemaVersion = 2
+
+	indexContent, err := json.Marshal(bundleIndex)
+	if err != nil {
+		return err
+	}
+
+	layoutContent, err := json.Marshal(ispec.ImageLayout{Version: ispec.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+
+	tarWriter := tar.NewWriter(output)
+
+	if err := writeTarFile(tarWriter, ispec.ImageLayoutFile, int64(len(layoutContent)),
+		strings.NewReader(string(layoutContent))); err != nil {
+		return err
+	}
+
+	if err := writeTarFile(tarWriter, ispec.ImageIndexFile, int64(len(indexContent)),
+		strings.NewReader(string(indexContent))); err != nil {
+		return err
+	}
+
+	for _, digest := range blobs {
+		if err := writeBundleBlob(tarWriter, imgStore, repo, digest); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Str("repository", repo).Int("manifests", len(descriptors)).Int("blobs", len(blobs)).
+		Msg("exported bundle")
+
+	return tarWriter.Close()
+}
+
+// selectBundleDescriptors returns the descriptors of index.json with the tag, or all of them, along with the ones
+// of the artifacts referring to them: the OCI referrers and the tags of the cosign and referrers tag schemes.
+func selectBundleDescriptors(imgStore storageTypes.ImageStore, repo string, index ispec.Index, tag string,
+) ([]ispec.Descriptor, error) {
+	if tag == "" {
+		return index.Manifests, nil
+	}
+
+	tagged := map[godigest.Digest]bool{}
+	selected := map[godigest.Digest]bool{}
+	pending := []godigest.Digest{}
+
+	for _, desc := range index.Manifests {
+		if desc.Annotations[ispec.AnnotationRefName] == tag && !selected[desc.Digest] {
+			tagged[desc.Digest] = true
+			selected[desc.Digest] = true
+			pending = append(pending, desc.Digest)
+		}
+	}
+
+	if len(pending) == 0 {
+		return nil, fmt.Errorf("%w: %s:%s", zerr.ErrManifestNotFound, repo, tag)
+	}
+
+	subjects, err := getSubjects(imgStore, repo, index)
+	if err != nil {
+		return nil, err
+	}
+
+	for len(pending) > 0 {
+		digest := pending[0]
+		pending = pending[1:]
+
+		for _, desc := range index.Manifests {
+			if selected[desc.Digest] {
+				continue
+			}
+
+			referrerTag := desc.Annotations[ispec.AnnotationRefName]
+
+			if subjects[desc.Digest] == digest ||
+				strings.HasPrefix(referrerTag, fmt.Sprintf("%s-%s", digest.Algorithm(), digest.Encoded())) {
+				selected[desc.Digest] = true
+				pending = append(pending, desc.Digest)
+			}
+		}
+	}
+
+	descriptors := []ispec.Descriptor{}
+
+	for _, desc := range index.Manifests {
+		if !selected[desc.Digest] {
+			continue
+		}
+
+		// the other tags of the exported image stay out of the bundle
+		if tagged[desc.Digest] && desc.Annotations[ispec.AnnotationRefName] != tag {
+			continue
+		}
+
+		descriptors = append(descriptors, desc)
+	}
+
+	return descriptors, nil
+}
+
+// getSubjects returns the subjects of the manifests of index.json which have one.
+func getSubjects(imgStore storageTypes.ImageStore, repo string, index ispec.Index,
+) (map[godigest.Digest]godigest.Digest, error) {
+	subjects := map[godigest.Digest]godigest.Digest{}
+
+	for _, desc := range index.Manifests {
+		content, err := imgStore.GetBlobContent(repo, desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		// manifests and indexes both have the subject field
+		var manifest struct {
+			Subject *ispec.Descriptor `json:"subject,omitempty"`
+		}
+
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			return nil, err
+		}
+
+		if manifest.Subject != nil {
+			subjects[desc.Digest] = manifest.Subject.Digest
+		}
+	}
+
+	return subjects, nil
+}
+
+// listBundleBlobs adds the blobs of the manifest, its config and layers, or of the index and its manifests.
+func listBundleBlobs(imgStore storageTypes.ImageStore, repo string, desc ispec.Descriptor,
+	seen map[godigest.Digest]bool, blobs *[]godigest.Digest,
+) error {
+	if seen[desc.Digest] {
+		return nil
+	}
+
+	content, err := imgStore.GetBlobContent(repo, desc.Digest)
+	if err != nil {
+		return err
+	}
+
+	seen[desc.Digest] = true
+	*blobs = append(*blobs, desc.Digest)
+
+	if isIndexMediaType(desc.MediaType) {
+		var index ispec.Index
+
+		if err := json.Unmarshal(content, &index); err != nil {
+			return err
+		}
+
+		for _, manifest := range index.Manifests {
+			if err := listBundleBlobs(imgStore, repo, manifest, seen, blobs); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	var manifest ispec.Manifest
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return err
+	}
+
+	for _, blob := range append([]ispec.Descriptor{manifest.Config}, manifest.Layers...) {
+		if blob.Digest == "" || seen[blob.Digest] {
+			continue
+		}
+
+		ok, _, err := imgStore.CheckBlob(repo, blob.Digest)
+		if err != nil && !errors.Is(err, zerr.ErrBlobNotFound) {
+			return err
+		}
+
+		// the config of some artifacts is the empty descriptor, which may not be stored
+		if !ok {
+			if blob.Digest == ispec.DescriptorEmptyJSON.Digest {
+				continue
+			}
+
+			return fmt.Errorf("%w: %s", zerr.ErrBlobNotFound, blob.Digest)
+		}
+
+		seen[blob.Digest] = true
+		*blobs = append(*blobs, blob.Digest)
+	}
+
+	return nil
+}
+
+func isIndexMediaType(mediaType string) bool {
+	return mediaType == ispec.MediaTypeImageIndex || mediaType == "application/vnd.docker.distribution.manifest.list.v2+json"
+}
+
+// writeBundleBlob copies the blob to the tar archive, checking its digest on the way.
+func writeBundleBlob(tarWriter *tar.Writer, imgStore storageTypes.ImageStore, repo string,
+	digest godigest.Digest,
+) error {
+	reader, size, err := imgStore.GetBlob(repo, digest, "")
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	verifier := digest.Verifier()
+
+	if err := writeTarFile(tarWriter, path.Join(ispec.ImageBlobsDir, digest.Algorithm().String(), digest.Encoded()),
+		size, io.TeeReader(reader, verifier)); err != nil {
+		return err
+	}
+
+	if !verifier.Verified() {
+		return fmt.Errorf("%w: %s", zerr.ErrBadBlobDigest, digest)
+	}
+
+	return nil
+}
+
+func writeTarFile(tarWriter *tar.Writer, name string, size int64, content io.Reader) error {
+	if err := tarWriter.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644, //nolint:gomnd
+		Size:    size,
+		ModTime: time.Unix(0, 0),
+	}); err != nil {
+		return err
+	}
+
+	_, err := io.Copy(tarWriter, content)
+
+	return err
+}
+
+type ImportResult struct {
+	Repository string
+	Manifests  int
+}
+
+// ImportBundle pushes the images of a bundle written by ExportBundle to the repository, or to the one it was
+// exported from if empty. The blobs are checked against their digests while they're uploaded, and the manifests
+// are pushed once all of them are there.
+func ImportBundle(storeController *storage.StoreController, repo string, input io.Reader, log zlog.Logger,
+) (ImportResult, error) {
+	tarReader := tar.NewReader(input)
+
+	var (
+		bundleIndex *ispec.Index
+		imgStore    storageTypes.ImageStore
+	)
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return ImportResult{}, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+
+		switch {
+		case name == ispec.ImageLayoutFile:
+			continue
+		case name == ispec.ImageIndexFile:
+			bundleIndex = &ispec.Index{}
+
+			if err := json.NewDecoder(tarReader).Decode(bundleIndex); err != nil {
+				return ImportResult{}, fmt.Errorf("%w: %w", zerr.ErrInvalidBundle, err)
+			}
+
+			if repo == "" {
+				repo = bundleIndex.Annotations[BundleRepositoryAnnotation]
+			}
+
+			if repo == "" {
+				return ImportResult{}, fmt.Errorf("%w: no repository given", zerr.ErrInvalidBundle)
+			}
+
+			imgStore = storeController.GetImageStore(repo)
+
+			if err := imgStore.InitRepo(repo); err != nil {
+				return ImportResult{}, err
+			}
+		case strings.HasPrefix(name, ispec.ImageBlobsDir+"/"):
+			if imgStore == nil {
+				return ImportResult{}, fmt.Errorf("%w: %s before %s", zerr.ErrInvalidBundle, name, ispec.ImageIndexFile)
+			}
+
+			digest := godigest.Digest(strings.Replace(strings.TrimPrefix(name, ispec.ImageBlobsDir+"/"), "/", ":", 1))
+
+			if _, _, err := imgStore.FullBlobUpload(repo, tarReader, digest); err != nil {
+				log.Error().Err(err).Str("blob", name).Msg("failed to import blob")
+
+				return ImportResult{}, err
+			}
+		default:
+			return ImportResult{}, fmt.Errorf("%w: unexpected file %s", zerr.ErrInvalidBundle, name)
+		}
+	}
+
+	if bundleIndex == nil {
+		return ImportResult{}, fmt.Errorf("%w: no %s", zerr.ErrInvalidBundle, ispec.ImageIndexFile)
+	}
+
+	pushed := map[string]bool{}
+
+	for _, desc := range bundleIndex.Manifests {
+		if err := pushBundleManifest(imgStore, repo, desc, pushed); err != nil {
+			log.Error().Err(err).Str("repository", repo).Str("digest", desc.Digest.String()).
+				Msg("failed to import manifest")
+
+			return ImportResult{}, err
+		}
+	}
+
+	log.Info().Str("repository", repo).Int("manifests", len(bundleIndex.Manifests)).Msg("imported bundle")
+
+	return ImportResult{Repository: repo, Manifests: len(bundleIndex.Manifests)}, nil
+}
+
+// pushBundleManifest pushes the manifest with its tag, the manifests of an index are pushed by digest first.
+func pushBundleManifest(imgStore storageTypes.ImageStore, repo string, desc ispec.Descriptor,
+	pushed map[string]bool,
+) error {
+	reference := desc.Digest.String()
+	if tag, ok := desc.Annotations[ispec.AnnotationRefName]; ok {
+		reference = tag
+	}
+
+	if pushed[reference] {
+		return nil
+	}
+
+	content, err := imgStore.GetBlobContent(repo, desc.Digest)
+	if err != nil {
+		return fmt.Errorf("%w: %s is missing: %w", zerr.ErrInvalidBundle, desc.Digest, err)
+	}
+
+	if isIndexMediaType(desc.MediaType) {
+		var index ispec.Index
+
+		if err := json.Unmarshal(content, &index); err != nil {
+			return err
+		}
+
+		for _, manifest := range index.Manifests {
+			manifest.Annotations = nil
+
+			if err := pushBundleManifest(imgStore, repo, manifest, pushed); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, _, err := imgStore.PutImageManifest(repo, reference, desc.MediaType, content); err != nil {
+		return err
+	}
+
+	pushed[reference] = true
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package server_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.dev/zot/errors"
+	cli "zotregistry.dev/zot/pkg/cli/server"
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	"zotregistry.dev/zot/pkg/log"
+	"zotregistry.dev/zot/pkg/storage"
+	storageCommon "zotregistry.dev/zot/pkg/storage/common"
+	"zotregistry.dev/zot/pkg/storage/local"
+	. "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+func getTags(storeController storage.StoreController, repo string) []string {
+	index, err := storageCommon.GetIndex(storeController.GetImageStore(repo), repo, log.NewLogger("debug", ""))
+	So(err, ShouldBeNil)
+
+	tags := []string{}
+
+	for _, desc := range index.Manifests {
+		if tag, ok := desc.Annotations[ispec.AnnotationRefName]; ok {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func TestBundle(t *testing.T) {
+	Convey("Export and import bundles", t, func() {
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		srcDir := t.TempDir()
+		srcStore := storage.StoreController{
+			DefaultStore: local.NewImageStore(srcDir, false, false, log, metrics, nil, nil),
+		}
+		dstStore := storage.StoreController{
+			DefaultStore: local.NewImageStore(t.TempDir(), false, false, log, metrics, nil, nil),
+		}
+
+		image := CreateRandomImage()
+		So(WriteImageToFileSystem(image, "app", "1.0", srcStore), ShouldBeNil)
+
+		otherImage := CreateRandomImage()
+		So(WriteImageToFileSystem(otherImage, "app", "2.0", srcStore), ShouldBeNil)
+
+		multiarch := CreateRandomMultiarch()
+		So(WriteMultiArchImageToFileSystem(multiarch, "app", "multi", srcStore), ShouldBeNil)
+
+		// a cosign signature and an OCI referrer of the image
+		signatureTag := fmt.Sprintf("sha256-%s.sig", image.Digest().Encoded())
+		signature := CreateRandomImage()
+		So(WriteImageToFileSystem(signature, "app", signatureTag, srcStore), ShouldBeNil)
+
+		sbom := CreateRandomImageWith().ArtifactType("application/spdx+json").Subject(image.DescriptorRef()).Build()
+		So(WriteImageToFileSystem(sbom, "app", sbom.DigestStr(), srcStore), ShouldBeNil)
+
+		Convey("Export an image with its referrers", func() {
+			bundle := bytes.Buffer{}
+
+			err := cli.ExportBundle(srcStore.DefaultStore, "app", "1.0", &bundle, log)
+			So(err, ShouldBeNil)
+
+			result, err := cli.ImportBundle(&dstStore, "", &bundle, log)
+			So(err, ShouldBeNil)
+			So(result, ShouldResemble, cli.ImportResult{Repository: "app", Manifests: 3})
+
+			So(getTags(dstStore, "app"), ShouldResemble, []string{"1.0", signatureTag})
+
+			referrers, err := dstStore.DefaultStore.GetReferrers("app", image.Digest(), nil)
+			So(err, ShouldBeNil)
+			So(referrers.Manifests, ShouldHaveLength, 1)
+			So(referrers.Manifests[0].Digest, ShouldEqual, sbom.Digest())
+
+			_, _, err = dstStore.DefaultStore.GetBlob("app", image.Manifest.Layers[0].Digest, "")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Export a whole repository to another one", func() {
+			bundle := bytes.Buffer{}
+
+			err := cli.ExportBundle(srcStore.DefaultStore, "app", "", &bundle, log)
+			So(err, ShouldBeNil)
+
+			result, err := cli.ImportBundle(&dstStore, "mirror/app", &bundle, log)
+			So(err, ShouldBeNil)
+			So(result.Repository, ShouldEqual, "mirror/app")
+
+			So(getTags(dstStore, "mirror/app"), ShouldContain, "2.0")
+			So(getTags(dstStore, "mirror/app"), ShouldContain, "multi")
+
+			_, _, _, err = dstStore.DefaultStore.GetImageManifest("mirror/app", multiarch.Images[0].DigestStr())
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Unknown tag", func() {
+			err := cli.ExportBundle(srcStore.DefaultStore, "app", "3.0", &bytes.Buffer{}, log)
+			So(errors.Is(err, zerr.ErrManifestNotFound), ShouldBeTrue)
+		})
+
+		Convey("Corrupted blob in the image store", func() {
+			layer := image.Manifest.Layers[0].Digest
+			err := os.WriteFile(path.Join(srcDir, "app", "blobs", "sha256", layer.Encoded()),
+				bytes.Repeat([]byte("x"), int(image.Manifest.Layers[0].Size)), 0o600)
+			So(err, ShouldBeNil)
+
+			err = cli.ExportBundle(srcStore.DefaultStore, "app", "1.0", &bytes.Buffer{}, log)
+			So(errors.Is(err, zerr.ErrBadBlobDigest), ShouldBeTrue)
+		})
+
+		Convey("Tampered bundle", func() {
+			bundle := bytes.Buffer{}
+			tarWriter := tar.NewWriter(&bundle)
+
+			index := []byte(`{"schemaVersion":2,"manifests":[]}`)
+			So(tarWriter.WriteHeader(&tar.Header{Name: "index.json", Mode: 0o644, Size: int64(len(index))}), ShouldBeNil)
+			_, err := tarWriter.Write(index)
+			So(err, ShouldBeNil)
+
+			content := []byte("tampered")
+			digest := godigest.FromString("original")
+			So(tarWriter.WriteHeader(&tar.Header{
+				Name: "blobs/sha256/" + digest.Encoded(), Mode: 0o644, Size: int64(len(content)),
+			}), ShouldBeNil)
+			_, err = tarWriter.Write(content)
+			So(err, ShouldBeNil)
+			So(tarWriter.Close(), ShouldBeNil)
+
+			// the repository is neither given nor in the bundle
+			_, err = cli.ImportBundle(&dstStore, "", bytes.NewReader(bundle.Bytes()), log)
+			So(errors.Is(err, zerr.ErrInvalidBundle), ShouldBeTrue)
+
+			_, err = cli.ImportBundle(&dstStore, "app", bytes.NewReader(bundle.Bytes()), log)
+			So(errors.Is(err, zerr.ErrBadBlobDigest), ShouldBeTrue)
+		})
+
+		Convey("Bundle without index.json", func() {
+			bundle := bytes.Buffer{}
+			tarWriter := tar.NewWriter(&bundle)
+			So(tarWriter.Close(), ShouldBeNil)
+
+			_, err := cli.ImportBundle(&dstStore, "app", &bundle, log)
+			So(errors.Is(err, zerr.ErrInvalidBundle), ShouldBeTrue)
+		})
+
+		Convey("Export and import commands", func() {
+			oldArgs := os.Args
+
+			defer func() { os.Args = oldArgs }()
+
+			writeConfig := func(rootDir string) string {
+				content := fmt.Sprintf(`{"storage":{"rootDirectory":"%s"},"http":{"port":"%s"},"log":{"level":"debug"}}`,
+					rootDir, GetFreePort())
+				configFile := path.Join(t.TempDir(), "config.json")
+				So(os.WriteFile(configFile, []byte(content), 0o600), ShouldBeNil)
+
+				return configFile
+			}
+
+			bundleFile := path.Join(t.TempDir(), "bundle.tar")
+			dstDir := t.TempDir()
+
+			os.Args = []string{"cli_test", "export", writeConfig(srcDir), "app:2.0"}
+			err := cli.NewServerRootCmd().Execute()
+			So(errors.Is(err, zerr.ErrInvalidArgs), ShouldBeTrue)
+
+			os.Args = []string{"cli_test", "export", writeConfig(srcDir), "app:2.0", "--to", bundleFile}
+			err = cli.NewServerRootCmd().Execute()
+			So(err, ShouldBeNil)
+
+			os.Args = []string{"cli_test", "import", writeConfig(dstDir), bundleFile}
+			err = cli.NewServerRootCmd().Execute()
+			So(err, ShouldBeNil)
+
+			dstStore := storage.StoreController{
+				DefaultStore: local.NewImageStore(dstDir, false, false, log, metrics, nil, nil),
+			}
+			So(getTags(dstStore, "app"), ShouldResemble, []string{"2.0"})
+		})
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	"github.com/fsnotify/fsnotify"
@@ -18,6 +19,8 @@ type HotReloader struct {
 	configPath          string
 	ldapCredentialsPath string
 	ctlr                *api.Controller
+	// the config is reloaded when it's written and on SIGHUP
+	lock sync.Mutex
 }
 
 func NewHotReloader(ctlr *api.Controller, filePath, ldapCredentialsPath string) (*HotReloader, error) {
@@ -37,26 +40,80 @@ func NewHotReloader(ctlr *api.Controller, filePath, ldapCredentialsPath string)
 	return hotReloader, nil
 }
 
-func signalHandler(ctlr *api.Controller, sigCh chan os.Signal) {
-	// if signal then shutdown
-	if sig, ok := <-sigCh; ok {
+func signalHandler(ctlr *api.Controller, hotReloader *HotReloader, sigCh chan os.Signal, done chan struct{}) {
+	for sig := range sigCh {
 		ctlr.Log.Info().Interface("signal", sig).Msg("received signal")
 
+		if sig == syscall.SIGHUP && hotReloader != nil {
+			hotReloader.Reload()
+
+			continue
+		}
+
 		// gracefully shutdown http server
 		ctlr.Shutdown() //nolint: contextcheck
+
+		close(done)
+
+		return
 	}
 }
 
-func initShutDownRoutine(ctlr *api.Controller) {
+// initShutDownRoutine shuts the server down on SIGTERM and SIGINT and reloads its config on SIGHUP,
+// the returned channel is closed once the requests in progress are done.
+func initShutDownRoutine(ctlr *api.Controller, hotReloader *HotReloader) chan struct{} {
 	sigCh := make(chan os.Signal, 1)
+	done := make(chan struct{})
 
-	go signalHandler(ctlr, sigCh)
+	go signalHandler(ctlr, hotReloader, sigCh, done)
 
 	// block all async signals to this server
 	signal.Ignore()
 
-	// handle SIGINT and SIGHUP.
+	// handle SIGINT, SIGTERM and SIGHUP.
 	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+
+	return done
+}
+
+// Reload loads the config file again and applies the settings which can change while the server runs.
+func (hr *HotReloader) Reload() {
+	hr.lock.Lock()
+	defer hr.lock.Unlock()
+
+	log.Info().Msg("trying to reload config")
+
+	newConfig := config.New()
+
+	err := LoadConfiguration(newConfig, hr.configPath)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to reload config, retry writing it.")
+
+		return
+	}
+
+	if hr.ctlr.Config.HTTP.Auth != nil && hr.ctlr.Config.HTTP.Auth.LDAP != nil &&
+		hr.ctlr.Config.HTTP.Auth.LDAP.CredentialsFile != newConfig.HTTP.Auth.LDAP.CredentialsFile {
+		err = hr.watcher.Remove(hr.ctlr.Config.HTTP.Auth.LDAP.CredentialsFile)
+		if err != nil && !errors.Is(err, fsnotify.ErrNonExistentWatch) {
+			log.Error().Err(err).Msg("failed to remove old watch for the credentials file")
+		}
+
+		err = hr.watcher.Add(newConfig.HTTP.Auth.LDAP.CredentialsFile)
+		if err != nil {
+			log.Panic().Err(err).Str("ldap-credentials-file", newConfig.HTTP.Auth.LDAP.CredentialsFile).
+				Msg("failed to watch ldap credentials file")
+		}
+	}
+
+	// stop background tasks gracefully
+	hr.ctlr.StopBackgroundTasks()
+
+	// load new config
+	hr.ctlr.LoadNewConfig(newConfig)
+
+	// start background tasks based on new loaded config
+	hr.ctlr.StartBackgroundTasks()
 }
 
 func (hr *HotReloader) Start() {
@@ -72,39 +129,9 @@ func (hr *HotReloader) Start() {
 				// watch for events
 				case event := <-hr.watcher.Events:
 					if event.Op == fsnotify.Write {
-						log.Info().Msg("config file changed, trying to reload config")
-
-						newConfig := config.New()
-
-						err := LoadConfiguration(newConfig, hr.configPath)
-						if err != nil {
-							log.Error().Err(err).Msg("failed to reload config, retry writing it.")
-
-							continue
-						}
-
-						if hr.ctlr.Config.HTTP.Auth != nil && hr.ctlr.Config.HTTP.Auth.LDAP != nil &&
-							hr.ctlr.Config.HTTP.Auth.LDAP.CredentialsFile != newConfig.HTTP.Auth.LDAP.CredentialsFile {
-							err = hr.watcher.Remove(hr.ctlr.Config.HTTP.Auth.LDAP.CredentialsFile)
-							if err != nil && !errors.Is(err, fsnotify.ErrNonExistentWatch) {
-								log.Error().Err(err).Msg("failed to remove old watch for the credentials file")
-							}
-
-							err = hr.watcher.Add(newConfig.HTTP.Auth.LDAP.CredentialsFile)
-							if err != nil {
-								log.Panic().Err(err).Str("ldap-credentials-file", newConfig.HTTP.Auth.LDAP.CredentialsFile).
-									Msg("failed to watch ldap credentials file")
-							}
-						}
-
-						// stop background tasks gracefully
-						hr.ctlr.StopBackgroundTasks()
-
-						// load new config
-						hr.ctlr.LoadNewConfig(newConfig)
+						log.Info().Msg("config file changed")
 
-						// start background tasks based on new loaded config
-						hr.ctlr.StartBackgroundTasks()
+						hr.Reload()
 					}
 				// watch for errors
 				case err := <-hr.watcher.Errors:
@@ -6,11 +6,14 @@ package server_test
 import (
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"syscall"
 	"testing"
 	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/resty.v1"
 
 	cli "zotregistry.dev/zot/pkg/cli/server"
 	test "zotregistry.dev/zot/pkg/test/common"
@@ -607,4 +610,84 @@ func TestConfigReloader(t *testing.T) {
 		So(string(data), ShouldContainSubstring, "\"Regex\":\".*\"")
 		So(string(data), ShouldContainSubstring, "\"Semver\":true")
 	})
+
+	Convey("reload htpasswd users on SIGHUP", t, func(c C) {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		logFile, err := os.CreateTemp("", "zot-log*.txt")
+		So(err, ShouldBeNil)
+
+		defer os.Remove(logFile.Name()) // clean up
+
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString("alice", "alice"))
+		defer os.Remove(htpasswdPath)
+
+		content := fmt.Sprintf(`{
+			"distSpecVersion": "1.1.0-dev",
+			"storage": {
+				"rootDirectory": "%s"
+			},
+			"http": {
+				"address": "127.0.0.1",
+				"port": "%s",
+				"auth": {
+					"htpasswd": {
+						"path": "%s"
+					}
+				}
+			},
+			"log": {
+				"level": "debug",
+				"output": "%s"
+			}
+		}`, t.TempDir(), port, htpasswdPath, logFile.Name())
+
+		cfgfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+
+		defer os.Remove(cfgfile.Name()) // clean up
+
+		_, err = cfgfile.WriteString(content)
+		So(err, ShouldBeNil)
+
+		err = cfgfile.Close()
+		So(err, ShouldBeNil)
+
+		os.Args = []string{"cli_test", "serve", cfgfile.Name()}
+		go func() {
+			err = cli.NewServerRootCmd().Execute()
+			So(err, ShouldBeNil)
+		}()
+
+		test.WaitTillServerReady(baseURL)
+
+		resp, err := resty.R().SetBasicAuth("bob", "bob").Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+		// the htpasswd file isn't watched, the config is only reloaded on SIGHUP
+		err = os.WriteFile(htpasswdPath, []byte(test.GetCredString("alice", "alice")+test.GetCredString("bob", "bob")),
+			0o600)
+		So(err, ShouldBeNil)
+
+		err = syscall.Kill(os.Getpid(), syscall.SIGHUP)
+		So(err, ShouldBeNil)
+
+		// wait for config reload
+		time.Sleep(2 * time.Second)
+
+		data, err := os.ReadFile(logFile.Name())
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, "loaded new configuration settings")
+
+		resp, err = resty.R().SetBasicAuth("bob", "bob").Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		// the server is still running
+		resp, err = resty.R().SetBasicAuth("alice", "alice").Get(baseURL + "/v2/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+	})
 }
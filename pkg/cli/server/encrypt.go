@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	zlog "zotregistry.dev/zot/pkg/log"
+	"zotregistry.dev/zot/pkg/storage"
+	"zotregistry.dev/zot/pkg/storage/encryption"
+	"zotregistry.dev/zot/pkg/storage/imagestore"
+)
+
+func newEncryptCmd(conf *config.Config) *cobra.Command {
+	// "encrypt"
+	encryptCmd := &cobra.Command{
+		Use:   "encrypt <config>",
+		Short: "`encrypt` encrypts the blobs stored in plaintext or with an old key",
+		Long: "`encrypt` encrypts with the current key of the keyring the blobs of the repositories with encryption " +
+			"enabled which were pushed before it was, or which were encrypted with another key before the keyring " +
+			"was rotated, the server should be shut down",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := LoadConfiguration(conf, args[0]); err != nil {
+				return err
+			}
+
+			if err := checkServerIsDown(conf, "encrypt"); err != nil {
+				return err
+			}
+
+			ctlr := api.NewController(conf)
+
+			storageConfigs := map[string]config.StorageConfig{"/": conf.Storage.StorageConfig}
+			for route, storageConfig := range conf.Storage.SubPaths {
+				storageConfigs[route] = storageConfig
+			}
+
+			routes := make([]string, 0, len(storageConfigs))
+			for route := range storageConfigs {
+				routes = append(routes, route)
+			}
+
+			sort.Strings(routes)
+
+			for _, route := range routes {
+				storageConfig := storageConfigs[route]
+				if storageConfig.Encryption == nil {
+					continue
+				}
+
+				encrypted, err := EncryptStorage(storageConfig, ctlr.Log)
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(cmd.OutOrStdout(), "encrypted %d blobs in %s\n", encrypted, storageConfig.RootDirectory)
+			}
+
+			return nil
+		},
+	}
+
+	return encryptCmd
+}
+
+// EncryptStorage encrypts with the current key the blobs of the local storage which are in plaintext or encrypted
+// with another key, in the repositories its encryption config matches. It returns the number of blobs encrypted.
+func EncryptStorage(storageConfig config.StorageConfig, log zlog.Logger) (int, error) {
+	metrics := monitoring.NewMetricsServer(false, log)
+
+	driver, err := storage.NewLocalDriver(storageConfig, metrics, log)
+	if err != nil {
+		return 0, err
+	}
+
+	encryptionDriver, ok := driver.(*encryption.Driver)
+	if !ok {
+		return 0, nil
+	}
+
+	rootDir := storageConfig.RootDirectory
+	imgStore := imagestore.NewImageStore(rootDir, rootDir, false, false, log, metrics, nil, driver, nil)
+
+	repos, err := imgStore.GetRepositories()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+
+	for _, repo := range repos {
+		encrypted, err := encryptionDriver.EncryptRepo(repo)
+		total += encrypted
+
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
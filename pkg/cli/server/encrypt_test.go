@@ -0,0 +1,85 @@
+package server_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	cli "zotregistry.dev/zot/pkg/cli/server"
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	"zotregistry.dev/zot/pkg/log"
+	"zotregistry.dev/zot/pkg/storage"
+	"zotregistry.dev/zot/pkg/storage/local"
+	. "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+func TestEncrypt(t *testing.T) {
+	Convey("Encrypt the blobs pushed before the encryption was enabled", t, func() {
+		oldArgs := os.Args
+
+		defer func() { os.Args = oldArgs }()
+
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		rootDir := t.TempDir()
+		storeController := storage.StoreController{
+			DefaultStore: local.NewImageStore(rootDir, false, false, log, metrics, nil, nil),
+		}
+
+		image := CreateRandomImage()
+		So(WriteImageToFileSystem(image, "secret/app", "1.0", storeController), ShouldBeNil)
+		So(WriteImageToFileSystem(image, "public/app", "1.0", storeController), ShouldBeNil)
+
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		So(err, ShouldBeNil)
+
+		keyringFile := path.Join(t.TempDir(), "keyring.json")
+		So(os.WriteFile(keyringFile, []byte(`{"currentKey":"k1","keys":{"k1":"`+
+			base64.StdEncoding.EncodeToString(key)+`"}}`), 0o600), ShouldBeNil)
+
+		content := fmt.Sprintf(`{"storage":{"rootDirectory":"%s","encryption":{"keyring":"%s",
+			"repositories":["secret/**"]}},"http":{"port":"%s"},"log":{"level":"debug"}}`,
+			rootDir, keyringFile, GetFreePort())
+		configFile := path.Join(t.TempDir(), "config.json")
+		So(os.WriteFile(configFile, []byte(content), 0o600), ShouldBeNil)
+
+		layerDigest := image.Manifest.Layers[0].Digest
+		readLayer := func(repo string) []byte {
+			content, err := os.ReadFile(path.Join(rootDir, repo, "blobs", "sha256", layerDigest.Encoded()))
+			So(err, ShouldBeNil)
+
+			return content
+		}
+
+		So(bytes.HasPrefix(readLayer("secret/app"), []byte("zotenc01")), ShouldBeFalse)
+
+		output := bytes.Buffer{}
+		rootCmd := cli.NewServerRootCmd()
+		rootCmd.SetOut(&output)
+		os.Args = []string{"cli_test", "encrypt", configFile}
+		So(rootCmd.Execute(), ShouldBeNil)
+		So(output.String(), ShouldContainSubstring, "encrypted 3 blobs in "+rootDir)
+
+		So(bytes.HasPrefix(readLayer("secret/app"), []byte("zotenc01")), ShouldBeTrue)
+		So(bytes.HasPrefix(readLayer("public/app"), []byte("zotenc01")), ShouldBeFalse)
+
+		// the blobs are encrypted already
+		output.Reset()
+		rootCmd = cli.NewServerRootCmd()
+		rootCmd.SetOut(&output)
+		So(rootCmd.Execute(), ShouldBeNil)
+		So(output.String(), ShouldContainSubstring, "encrypted 0 blobs in "+rootDir)
+
+		So(os.WriteFile(keyringFile, []byte(`{"currentKey":"k1"}`), 0o600), ShouldBeNil)
+		So(cli.NewServerRootCmd().Execute(), ShouldNotBeNil)
+	})
+}
@@ -4,18 +4,25 @@
 package server_test
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"os"
+	"path"
 	"testing"
 	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"gopkg.in/resty.v1"
 
+	"zotregistry.dev/zot/pkg/api"
 	"zotregistry.dev/zot/pkg/api/config"
 	cli "zotregistry.dev/zot/pkg/cli/server"
+	extconf "zotregistry.dev/zot/pkg/extensions/config"
+	zlog "zotregistry.dev/zot/pkg/log"
 	. "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+	ociutils "zotregistry.dev/zot/pkg/test/oci-utils"
 )
 
 const readLogFileTimeout = 5 * time.Second
@@ -228,6 +235,57 @@ func TestVerifyExtensionsConfig(t *testing.T) {
 		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
 		So(cli.NewServerRootCmd().Execute(), ShouldNotBeNil)
 	})
+
+	Convey("Test verify sync with a bad semver constraint", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := fmt.Sprintf(`{"storage":{"rootDirectory":"%s"},
+							"http":{"address":"127.0.0.1","port":"8080"},
+							"extensions":{"sync": {"registries": [{"urls":["localhost:9999"],
+							"content": [{"prefix":"repo", "tags": {"semverConstraint": "~> one"}}]}]}}}`, t.TempDir())
+		_, err = tmpfile.WriteString(content)
+		So(err, ShouldBeNil)
+		err = tmpfile.Close()
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(cli.NewServerRootCmd().Execute(), ShouldNotBeNil)
+	})
+
+	Convey("Test verify sync onDemandTTL", t, func(c C) {
+		for _, registry := range []string{
+			`"onDemand": false, "onDemandTTL": "1h"`,
+			`"onDemand": true, "onDemandTTL": "-1h"`,
+		} {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := fmt.Sprintf(`{"storage":{"rootDirectory":"%s"},
+							"http":{"address":"127.0.0.1","port":"8080"},
+							"extensions":{"sync": {"registries": [{"urls":["localhost:9999"], %s}]}}}`,
+				t.TempDir(), registry)
+			_, err = tmpfile.WriteString(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+			So(cli.NewServerRootCmd().Execute(), ShouldNotBeNil)
+		}
+
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := fmt.Sprintf(`{"storage":{"rootDirectory":"%s"},
+							"http":{"address":"127.0.0.1","port":"8080"},
+							"extensions":{"sync": {"registries": [{"urls":["localhost:9999"],
+							"onDemand": true, "onDemandTTL": "1h"}]}}}`, t.TempDir())
+		_, err = tmpfile.WriteString(content)
+		So(err, ShouldBeNil)
+		err = tmpfile.Close()
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		So(cli.NewServerRootCmd().Execute(), ShouldBeNil)
+	})
 }
 
 func TestValidateExtensionsConfig(t *testing.T) {
@@ -262,6 +320,48 @@ func TestValidateExtensionsConfig(t *testing.T) {
 		So(err, ShouldBeNil)
 	})
 
+	Convey("Test activity log limits", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name())
+
+		content := `{
+			"storage": {
+				"rootDirectory": "%%/tmp/zot"
+			},
+			"http": {
+				"address": "127.0.0.1",
+				"port": "8080"
+			},
+			"log": {
+				"level": "debug"
+			},
+			"extensions": {
+				"search": {
+					"enable": true,
+					"activity": %s
+				}
+			}
+		}`
+
+		err = os.WriteFile(tmpfile.Name(), []byte(fmt.Sprintf(content, `{"maxAge": "24h"}`)), 0o0600)
+		So(err, ShouldBeNil)
+
+		conf := config.New()
+		err = cli.LoadConfiguration(conf, tmpfile.Name())
+		So(err, ShouldBeNil)
+		So(conf.IsActivityEnabled(), ShouldBeTrue)
+		So(conf.Extensions.Search.Activity.MaxEvents, ShouldEqual, 100)
+		So(conf.Extensions.Search.Activity.MaxAge, ShouldEqual, 24*time.Hour)
+
+		err = os.WriteFile(tmpfile.Name(), []byte(fmt.Sprintf(content, `{"maxEvents": -1}`)), 0o0600)
+		So(err, ShouldBeNil)
+
+		conf = config.New()
+		err = cli.LoadConfiguration(conf, tmpfile.Name())
+		So(err, ShouldNotBeNil)
+	})
+
 	Convey("Test missing extensions for UI to work", t, func(c C) {
 		config := config.New()
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
@@ -905,6 +1005,78 @@ func TestServeScrubExtension(t *testing.T) {
 	})
 }
 
+func TestScrubStatus(t *testing.T) {
+	Convey("scrub status of a running server", t, func(c C) {
+		port := GetFreePort()
+		dir := t.TempDir()
+
+		logFile, err := os.CreateTemp(t.TempDir(), "zot-log*.txt")
+		So(err, ShouldBeNil)
+
+		trueValue := true
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = dir
+		conf.Storage.GC = false
+		conf.Log.Output = logFile.Name()
+		conf.Extensions = &extconf.ExtensionConfig{
+			Scrub: &extconf.ScrubConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &trueValue},
+				Interval:   2,
+			},
+		}
+
+		image := CreateRandomImage()
+		err = WriteImageToFileSystem(image, "repo", "1.0", ociutils.GetDefaultStoreController(dir, zlog.NewLogger("debug", "")))
+		So(err, ShouldBeNil)
+
+		layerDigest := image.Manifest.Layers[0].Digest
+		So(os.Remove(path.Join(dir, "repo", "blobs", "sha256", layerDigest.Encoded())), ShouldBeNil)
+
+		ctlr := api.NewController(conf)
+
+		cm := NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		found, err := ReadLogFileAndSearchString(logFile.Name(), "scrub successfully completed", 60*time.Second)
+		So(err, ShouldBeNil)
+		So(found, ShouldBeTrue)
+
+		cfgFile := path.Join(t.TempDir(), "zot.json")
+		content := fmt.Sprintf(`{
+			"storage": {"rootDirectory": "%s"},
+			"http": {"address": "127.0.0.1", "port": "%s"},
+			"extensions": {"scrub": {"enable": true}}
+		}`, dir, port)
+		So(os.WriteFile(cfgFile, []byte(content), 0o600), ShouldBeNil)
+
+		output := bytes.NewBufferString("")
+		cmd := cli.NewServerRootCmd()
+		cmd.SetOut(output)
+		cmd.SetArgs([]string{"scrub", "status", cfgFile})
+		So(cmd.Execute(), ShouldBeNil)
+
+		So(output.String(), ShouldContainSubstring, "REPOSITORY")
+		So(output.String(), ShouldContainSubstring, "repo")
+		So(output.String(), ShouldContainSubstring, "affected")
+		So(output.String(), ShouldContainSubstring, layerDigest.Encoded()[:8])
+
+		Convey("scrub not enabled", func() {
+			content := fmt.Sprintf(`{
+				"storage": {"rootDirectory": "%s"},
+				"http": {"address": "127.0.0.1", "port": "%s"}
+			}`, dir, GetFreePort())
+			So(os.WriteFile(cfgFile, []byte(content), 0o600), ShouldBeNil)
+
+			cmd := cli.NewServerRootCmd()
+			cmd.SetOut(bytes.NewBufferString(""))
+			cmd.SetArgs([]string{"scrub", "status", cfgFile})
+			So(cmd.Execute(), ShouldNotBeNil)
+		})
+	})
+}
+
 func TestServeLintExtension(t *testing.T) {
 	oldArgs := os.Args
 
@@ -1000,7 +1172,7 @@ func TestServeSearchEnabled(t *testing.T) {
 		// to avoid data race when multiple go routines write to trivy DB instance.
 		defer os.Remove(logPath) // clean up
 
-		substring := `"Extensions":{"Search":{"Enable":true,"CVE":null}`
+		substring := `"Extensions":{"Search":{"Enable":true,"CVE":null,"Activity":null}`
 
 		found, err := ReadLogFileAndSearchString(logPath, substring, readLogFileTimeout)
 
@@ -1051,7 +1223,7 @@ func TestServeSearchEnabledCVE(t *testing.T) {
 
 		// The default config handling logic will convert the 1h interval to a 2h interval
 		substring := "\"Search\":{\"Enable\":true,\"CVE\":{\"UpdateInterval\":7200000000000,\"Trivy\":" +
-			"{\"DBRepository\":\"ghcr.io/aquasecurity/trivy-db\",\"JavaDBRepository\":\"ghcr.io/aquasecurity/trivy-java-db\"}}}"
+			"{\"DBRepository\":\"ghcr.io/aquasecurity/trivy-db\",\"JavaDBRepository\":\"ghcr.io/aquasecurity/trivy-java-db\"}},\"Activity\":null}"
 
 		found, err := ReadLogFileAndSearchString(logPath, substring, readLogFileTimeout)
 
@@ -1102,7 +1274,7 @@ func TestServeSearchEnabledNoCVE(t *testing.T) {
 		So(err, ShouldBeNil)
 		defer os.Remove(logPath) // clean up
 
-		substring := `"Extensions":{"Search":{"Enable":true,"CVE":null}` //nolint:lll // gofumpt conflicts with lll
+		substring := `"Extensions":{"Search":{"Enable":true,"CVE":null,"Activity":null}` //nolint:lll // gofumpt conflicts with lll
 		found, err := ReadLogFileAndSearchString(logPath, substring, readLogFileTimeout)
 
 		if !found {
@@ -1151,7 +1323,7 @@ func TestServeSearchDisabled(t *testing.T) {
 		defer os.Remove(logPath) // clean up
 		dataStr := string(data)
 		So(dataStr, ShouldContainSubstring,
-			`"Search":{"Enable":false,"CVE":{"UpdateInterval":10800000000000,"Trivy":null}`)
+			`"Search":{"Enable":false,"CVE":{"UpdateInterval":10800000000000,"Trivy":null},"Activity":null}`)
 		So(dataStr, ShouldContainSubstring, "cve config not provided, skipping cve-db update")
 		So(dataStr, ShouldNotContainSubstring,
 			"CVE update interval set to too-short interval < 2h, changing update duration to 2 hours and continuing.")
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/api/constants"
+)
+
+func newPromoteCmd(conf *config.Config) *cobra.Command {
+	credentials := ""
+	tag := ""
+
+	// "promote"
+	promoteCmd := &cobra.Command{
+		Use:   "promote <config> <repository>:<tag>|<repository>@<digest>",
+		Short: "`promote` promotes an image of a staging repository to the production one",
+		Long: "`promote` copies an image pushed to \"<staging prefix>/<repository>\", with its signatures and other " +
+			"referrers, to the production repository of the server running with the config and tags it there, " +
+			"if it passes the checks of the promotion policy matching the repository. The image keeps its tag " +
+			"unless --tag is given, which is needed to promote a digest. Admin credentials are needed if " +
+			"authentication is enabled",
+		Args: cobra.ExactArgs(2), //nolint:gomnd
+		RunE: func(cmd *cobra.Command, args []string) error {
+			promotion, err := parsePromotionReference(args[1])
+			if err != nil {
+				return err
+			}
+
+			promotion.Tag = tag
+
+			if err := LoadConfiguration(conf, args[0]); err != nil {
+				return err
+			}
+
+			result, err := promoteImage(cmd, conf, credentials, promotion)
+			if err != nil {
+				log.Error().Err(err).Str("image", args[1]).Msg("failed to promote the image")
+
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "promoted %s to %s:%s (%s)\n", result.Source, result.Repository,
+				result.Tag, result.Digest)
+
+			return nil
+		},
+	}
+
+	promoteCmd.Flags().StringVarP(&credentials, "user", "u", "",
+		`admin credentials in "username:password" format`)
+	promoteCmd.Flags().StringVarP(&tag, "tag", "t", "",
+		"tag of the image in the production repository, the one of the staging image by default")
+
+	return promoteCmd
+}
+
+// parsePromotionReference parses "<repository>:<tag>" or "<repository>@<digest>", the repository being the
+// production one.
+func parsePromotionReference(image string) (api.PromotionRequest, error) {
+	repo, reference, found := strings.Cut(image, "@")
+	if !found {
+		index := strings.LastIndex(image, ":")
+		if index < 0 {
+			return api.PromotionRequest{}, fmt.Errorf("%w: %s", zerr.ErrInvalidRepoRefFormat, image)
+		}
+
+		repo, reference = image[:index], image[index+1:]
+	}
+
+	if repo == "" || reference == "" {
+		return api.PromotionRequest{}, fmt.Errorf("%w: %s", zerr.ErrInvalidRepoRefFormat, image)
+	}
+
+	return api.PromotionRequest{Repository: repo, Reference: reference}, nil
+}
+
+// promoteImage asks the server to promote the image. The checks are shown if the image doesn't pass them.
+func promoteImage(cmd *cobra.Command, conf *config.Config, credentials string, promotion api.PromotionRequest,
+) (api.PromotionResult, error) {
+	result := api.PromotionResult{}
+
+	resp, err := sendServerRequest(cmd.Context(), conf, credentials, http.MethodPost,
+		constants.RoutePrefix+constants.AdminPromotePath, promotion)
+	if err != nil {
+		return result, err
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return result, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return result, json.Unmarshal(body, &result)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return result, zerr.ErrUnauthorizedAccess
+	case http.StatusMethodNotAllowed:
+		return result, fmt.Errorf("%w: promotion", zerr.ErrExtensionNotEnabled)
+	case http.StatusConflict:
+		// the refused images are answered with their checks, the immutable tags with an error
+		if err := json.Unmarshal(body, &result); err == nil && len(result.Checks) > 0 {
+			printPromotionChecks(cmd.OutOrStdout(), result.Checks)
+
+			return result, zerr.ErrPromotionRefused
+		}
+	}
+
+	return result, fmt.Errorf("%w: %s %s", zerr.ErrBadHTTPStatusCode, resp.Status, strings.TrimSpace(string(body)))
+}
+
+func printPromotionChecks(writer io.Writer, checks []api.PromotionCheck) {
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0) //nolint:gomnd
+
+	fmt.Fprintln(tabWriter, "CHECK\tPASSED\tREASON")
+
+	for _, check := range checks {
+		fmt.Fprintf(tabWriter, "%s\t%t\t%s\n", check.Name, check.Passed, check.Reason)
+	}
+
+	tabWriter.Flush()
+}
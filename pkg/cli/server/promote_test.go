@@ -0,0 +1,90 @@
+package server_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/resty.v1"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/api/constants"
+	cli "zotregistry.dev/zot/pkg/cli/server"
+	. "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+func TestPromote(t *testing.T) {
+	Convey("promote the staging images of a running server", t, func() {
+		port := GetFreePort()
+		baseURL := GetBaseURL(port)
+		dir := t.TempDir()
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = dir
+		conf.Storage.Promotion = &config.PromotionConfig{
+			StagingPrefix: "qa",
+			Policies: []config.PromotionPolicy{
+				{Repositories: []string{"secure/**"}, RequireSignature: true},
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		cm := NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		image := CreateRandomImage()
+		So(UploadImage(image, baseURL, "qa/app", "1.0"), ShouldBeNil)
+		So(UploadImage(CreateRandomImage(), baseURL, "qa/secure/app", "1.0"), ShouldBeNil)
+
+		cfgFile := path.Join(t.TempDir(), "zot.json")
+		content := fmt.Sprintf(`{
+			"storage": {"rootDirectory": "%s", "promotion": {"stagingPrefix": "qa"}},
+			"http": {"address": "127.0.0.1", "port": "%s"}
+		}`, dir, port)
+		So(os.WriteFile(cfgFile, []byte(content), 0o600), ShouldBeNil)
+
+		runPromote := func(args ...string) (string, error) {
+			output := bytes.NewBufferString("")
+			cmd := cli.NewServerRootCmd()
+			cmd.SetOut(output)
+			cmd.SetArgs(append([]string{"promote"}, args...))
+
+			err := cmd.Execute()
+
+			return output.String(), err
+		}
+
+		output, err := runPromote(cfgFile, "app:1.0")
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "promoted qa/app:1.0 to app:1.0 ("+image.DigestStr()+")")
+
+		output, err = runPromote(cfgFile, "app@"+image.DigestStr(), "--tag", "stable")
+		So(err, ShouldBeNil)
+		So(output, ShouldContainSubstring, "to app:stable")
+
+		resp, err := resty.R().Get(baseURL + constants.RoutePrefix + "/app/manifests/stable")
+		So(err, ShouldBeNil)
+		So(resp.Header().Get(constants.DistContentDigestKey), ShouldEqual, image.DigestStr())
+
+		output, err = runPromote(cfgFile, "secure/app:1.0")
+		So(err, ShouldWrap, zerr.ErrPromotionRefused)
+		So(output, ShouldContainSubstring, "CHECK")
+		So(output, ShouldContainSubstring, "signature")
+		So(output, ShouldContainSubstring, "the image isn't signed")
+
+		_, err = runPromote(cfgFile, "app:2.0")
+		So(err, ShouldWrap, zerr.ErrBadHTTPStatusCode)
+
+		_, err = runPromote(cfgFile, "app")
+		So(err, ShouldWrap, zerr.ErrInvalidRepoRefFormat)
+	})
+}
@@ -2,20 +2,25 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Masterminds/semver"
 	glob "github.com/bmatcuk/doublestar/v4"
 	"github.com/mitchellh/mapstructure"
 	distspec "github.com/opencontainers/distribution-spec/specs-go"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -26,9 +31,20 @@ import (
 	"zotregistry.dev/zot/pkg/api/constants"
 	"zotregistry.dev/zot/pkg/common"
 	extconf "zotregistry.dev/zot/pkg/extensions/config"
+	"zotregistry.dev/zot/pkg/extensions/events"
 	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	cvemodel "zotregistry.dev/zot/pkg/extensions/search/cve/model"
 	zlog "zotregistry.dev/zot/pkg/log"
+	zreg "zotregistry.dev/zot/pkg/regexp"
 	storageConstants "zotregistry.dev/zot/pkg/storage/constants"
+	"zotregistry.dev/zot/pkg/storage/recompression"
+)
+
+const (
+	s3MinPartSize = 5 << 20
+	s3MaxPartSize = 5 << 30
+
+	defaultActivityMaxEvents = 100
 )
 
 // metadataConfig reports metadata after parsing, which we use to track
@@ -76,10 +92,17 @@ func newServeCmd(conf *config.Config) *cobra.Command {
 				return err
 			}
 
-			initShutDownRoutine(ctlr)
+			shutdownDone := initShutDownRoutine(ctlr, hotReloader)
 
 			if err := ctlr.Run(); err != nil {
-				log.Error().Err(err).Msg("failed to start controller, exiting")
+				if !errors.Is(err, http.ErrServerClosed) {
+					log.Error().Err(err).Msg("failed to start controller, exiting")
+
+					return nil
+				}
+
+				// the server stops accepting requests right away, wait for the ones in progress
+				<-shutdownDone
 			}
 
 			return nil
@@ -109,55 +132,108 @@ func newScrubCmd(conf *config.Config) *cobra.Command {
 				return nil
 			}
 
-			// checking if the server is  already running
-			req, err := http.NewRequestWithContext(context.Background(),
-				http.MethodGet,
-				fmt.Sprintf("http://%s/v2", net.JoinHostPort(conf.HTTP.Address, conf.HTTP.Port)),
-				nil)
-			if err != nil {
-				log.Error().Err(err).Msg("failed to create a new http request")
+			if err := checkServerIsDown(conf, "scrub"); err != nil {
+				return err
+			}
+
+			ctlr := api.NewController(conf)
+			ctlr.Metrics = monitoring.NewMetricsServer(false, ctlr.Log)
 
+			if err := ctlr.InitImageStore(); err != nil {
 				return err
 			}
 
-			response, err := http.DefaultClient.Do(req)
-			if err == nil {
-				response.Body.Close()
-				log.Warn().Err(zerr.ErrServerIsRunning).
-					Msg("server is running, in order to perform the scrub command the server should be shut down")
+			result, err := ctlr.StoreController.CheckAllBlobsIntegrity(cmd.Context())
+			if err != nil {
+				return err
+			}
 
-				return zerr.ErrServerIsRunning
-			} else {
-				// server is down
-				ctlr := api.NewController(conf)
-				ctlr.Metrics = monitoring.NewMetricsServer(false, ctlr.Log)
+			result.PrintScrubResults(cmd.OutOrStdout())
 
-				if err := ctlr.InitImageStore(); err != nil {
-					return err
-				}
+			return nil
+		},
+	}
 
-				result, err := ctlr.StoreController.CheckAllBlobsIntegrity(cmd.Context())
-				if err != nil {
-					return err
-				}
+	scrubCmd.AddCommand(newScrubStatusCmd(conf))
+
+	return scrubCmd
+}
+
+// checkServerIsDown returns zerr.ErrServerIsRunning if a server answers at the address of the config,
+// the commands using the storage directly can't run along with it.
+func checkServerIsDown(conf *config.Config, command string) error {
+	serverURL := fmt.Sprintf("http://%s/v2", net.JoinHostPort(conf.HTTP.Address, conf.HTTP.Port))
+	client := http.DefaultClient
+
+	// the server may only listen on its socket
+	if conf.HTTP.Socket != "" {
+		serverURL, client = unixSocketServerURL+"/v2", newUnixSocketClient(conf.HTTP.Socket)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, serverURL, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create a new http request")
 
-				result.PrintScrubResults(cmd.OutOrStdout())
+		return err
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		// server is down
+		return nil //nolint:nilerr
+	}
+
+	response.Body.Close()
+	log.Warn().Err(zerr.ErrServerIsRunning).
+		Msgf("server is running, in order to perform the %s command the server should be shut down", command)
+
+	return zerr.ErrServerIsRunning
+}
+
+func newScrubStatusCmd(conf *config.Config) *cobra.Command {
+	credentials := ""
+
+	// "scrub status"
+	statusCmd := &cobra.Command{
+		Use:   "status <config>",
+		Short: "`status` shows the results of the periodic scrub of a running server",
+		Long: "`status` shows the result of the last periodic scrub of each repository, as kept by the server " +
+			"running with the config, admin credentials are needed if authentication is enabled",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := LoadConfiguration(conf, args[0]); err != nil {
+				return err
+			}
+
+			status, err := getScrubStatus(cmd.Context(), conf, credentials)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to get the scrub status from the server")
+
+				return err
 			}
 
+			status.PrintScrubStatus(cmd.OutOrStdout())
+
 			return nil
 		},
 	}
 
-	return scrubCmd
+	statusCmd.Flags().StringVarP(&credentials, "user", "u", "",
+		`admin credentials in "username:password" format`)
+
+	return statusCmd
 }
 
 func newVerifyCmd(conf *config.Config) *cobra.Command {
+	dryRun := false
+
 	// verify
 	verifyCmd := &cobra.Command{
 		Use:     "verify <config>",
 		Aliases: []string{"verify"},
 		Short:   "`verify` validates a zot config file",
-		Long:    "`verify` validates a zot config file",
+		Long: "`verify` validates a zot config file, with --dry-run it also checks the storage and log paths, " +
+			"the TLS material and the files of the auth and extension settings, as `serve` would use them on this host",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) > 0 {
 				if err := LoadConfiguration(conf, args[0]); err != nil {
@@ -166,6 +242,19 @@ func newVerifyCmd(conf *config.Config) *cobra.Command {
 					return err
 				}
 
+				if dryRun {
+					if problems := checkConfigFiles(conf); len(problems) > 0 {
+						for _, problem := range problems {
+							fmt.Fprintf(cmd.ErrOrStderr(), "error: %s\n", problem)
+						}
+
+						log.Error().Str("config", args[0]).Int("problems", len(problems)).
+							Msg("the server can't be started with the config file on this host")
+
+						return fmt.Errorf("%w: %d problems found", zerr.ErrBadConfig, len(problems))
+					}
+				}
+
 				log.Info().Str("config", args[0]).Msg("config file is valid")
 			}
 
@@ -173,6 +262,9 @@ func newVerifyCmd(conf *config.Config) *cobra.Command {
 		},
 	}
 
+	verifyCmd.Flags().BoolVar(&dryRun, "dry-run", false,
+		"also check the files and directories the server would use, without starting it")
+
 	return verifyCmd
 }
 
@@ -204,6 +296,16 @@ func NewServerRootCmd() *cobra.Command {
 	rootCmd.AddCommand(newVerifyCmd(conf))
 	// "scrub"
 	rootCmd.AddCommand(newScrubCmd(conf))
+	// "export"
+	rootCmd.AddCommand(newExportCmd(conf))
+	// "import"
+	rootCmd.AddCommand(newImportCmd(conf))
+	// "encrypt"
+	rootCmd.AddCommand(newEncryptCmd(conf))
+	// "admin"
+	rootCmd.AddCommand(newAdminCmd(conf))
+	// "promote"
+	rootCmd.AddCommand(newPromoteCmd(conf))
 	// "version"
 	rootCmd.Flags().BoolVarP(&showVersion, "version", "v", false, "show the version and exit")
 
@@ -215,7 +317,14 @@ func validateStorageConfig(cfg *config.Config, log zlog.Logger) error {
 
 	defaultRootDir := cfg.Storage.RootDirectory
 
-	for _, storageConfig := range cfg.Storage.SubPaths {
+	if len(cfg.Storage.Repositories) > 0 {
+		log.Error().Err(zerr.ErrBadConfig).
+			Msg("invalid storage config, repositories can only be set for storage subpaths")
+
+		return zerr.ErrBadConfig
+	}
+
+	for route, storageConfig := range cfg.Storage.SubPaths {
 		if strings.EqualFold(defaultRootDir, storageConfig.RootDirectory) {
 			log.Error().Err(zerr.ErrBadConfig).
 				Msg("invalid storage config, storage subpaths cannot use default storage root directory")
@@ -223,6 +332,15 @@ func validateStorageConfig(cfg *config.Config, log zlog.Logger) error {
 			return zerr.ErrBadConfig
 		}
 
+		for _, pattern := range storageConfig.Repositories {
+			if ok := glob.ValidatePattern(pattern); !ok {
+				log.Error().Err(glob.ErrBadPattern).Str("subpath", route).Str("pattern", pattern).
+					Msg("invalid storage config, subpath repositories glob pattern could not be compiled")
+
+				return zerr.ErrBadConfig
+			}
+		}
+
 		expConfig, ok := expConfigMap[storageConfig.RootDirectory]
 		if ok {
 			equal := expConfig.ParamsEqual(storageConfig)
@@ -240,6 +358,66 @@ func validateStorageConfig(cfg *config.Config, log zlog.Logger) error {
 	return nil
 }
 
+// validateStorageDriver checks the parameters the s3 driver would refuse to start with. The s3 compatible
+// storages, like MinIO or the interoperability API of GCS, are also used with the s3 driver and their
+// endpoint in regionendpoint. The route is the one of the subpath, empty for the default storage.
+func validateStorageDriver(route string, storageDriver map[string]interface{}, log zlog.Logger) error {
+	if storageDriver["name"] != storageConstants.S3StorageDriverName {
+		log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).Interface("storageDriver", storageDriver["name"]).
+			Msg("unsupported storage driver, only s3 is supported, s3 compatible storages can be used with it " +
+				"by setting their endpoint in regionendpoint")
+
+		return zerr.ErrBadConfig
+	}
+
+	for _, param := range []string{"bucket", "region"} {
+		if value, ok := storageDriver[param].(string); !ok || value == "" {
+			log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).Str("parameter", param).
+				Msg("invalid s3 storage driver config, missing mandatory parameter")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	// the parts of a multipart upload can't be smaller than 5MiB, except the last one, nor bigger than 5GiB
+	for _, param := range []string{"chunksize", "multipartcopychunksize"} {
+		value, found := storageDriver[param]
+		if !found {
+			continue
+		}
+
+		size, ok := getStorageDriverSize(value)
+		if !ok || size < s3MinPartSize || size > s3MaxPartSize {
+			log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).Str("parameter", param).Interface("value", value).
+				Int64("min", s3MinPartSize).Int64("max", s3MaxPartSize).
+				Msg("invalid s3 storage driver config, the size of the multipart upload parts is out of range")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	return nil
+}
+
+// getStorageDriverSize returns the size parameter, which is a float in json configs, an int in yaml ones and
+// may also be a string.
+func getStorageDriverSize(value interface{}) (int64, bool) {
+	switch value := value.(type) {
+	case int:
+		return int64(value), true
+	case int64:
+		return value, true
+	case float64:
+		return int64(value), value == float64(int64(value))
+	case string:
+		size, err := strconv.ParseInt(value, 10, 64)
+
+		return size, err == nil
+	default:
+		return 0, false
+	}
+}
+
 func validateCacheConfig(cfg *config.Config, log zlog.Logger) error {
 	// global
 	// dedupe true, remote storage, remoteCache true, but no cacheDriver (remote)
@@ -334,6 +512,17 @@ func validateExtensionsConfig(cfg *config.Config, log zlog.Logger) error {
 		}
 	}
 
+	if cfg.Extensions != nil && cfg.Extensions.Search != nil && cfg.Extensions.Search.Activity != nil {
+		activity := cfg.Extensions.Search.Activity
+
+		if activity.MaxEvents < 0 || activity.MaxAge < 0 {
+			log.Error().Err(zerr.ErrBadConfig).Int("maxEvents", activity.MaxEvents).Str("maxAge", activity.MaxAge.String()).
+				Msg("invalid activity log limits, maxEvents and maxAge can't be negative")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
 	//nolint:lll
 	if cfg.Storage.StorageDriver != nil && cfg.Extensions != nil && cfg.Extensions.Search != nil &&
 		cfg.Extensions.Search.Enable != nil && *cfg.Extensions.Search.Enable && cfg.Extensions.Search.CVE != nil {
@@ -374,10 +563,62 @@ func validateConfiguration(config *config.Config, log zlog.Logger) error {
 		return err
 	}
 
+	if err := validatePublicRepositories(config, log); err != nil {
+		return err
+	}
+
+	if err := validateTokenServer(config, log); err != nil {
+		return err
+	}
+
 	if err := validateSync(config, log); err != nil {
 		return err
 	}
 
+	if err := validateEvents(config, log); err != nil {
+		return err
+	}
+
+	if err := validateLog(config, log); err != nil {
+		return err
+	}
+
+	if err := validateAuditLog(config, log); err != nil {
+		return err
+	}
+
+	if err := validateQuota(config, log); err != nil {
+		return err
+	}
+
+	if err := validateNamespaces(config, log); err != nil {
+		return err
+	}
+
+	if err := validateImmutableTags(config, log); err != nil {
+		return err
+	}
+
+	if err := validatePromotion(config, log); err != nil {
+		return err
+	}
+
+	if err := validateTiering(config, log); err != nil {
+		return err
+	}
+
+	if err := validateRecompression(config, log); err != nil {
+		return err
+	}
+
+	if err := validateEncryption(config, log); err != nil {
+		return err
+	}
+
+	if err := validateTrustPolicies(config, log); err != nil {
+		return err
+	}
+
 	if err := validateStorageConfig(config, log); err != nil {
 		return err
 	}
@@ -396,15 +637,16 @@ func validateConfiguration(config *config.Config, log zlog.Logger) error {
 		if err := validateAuthzPolicies(config, log); err != nil {
 			return err
 		}
+
+		if err := validateAuthzActions(config, log); err != nil {
+			return err
+		}
 	}
 
 	if len(config.Storage.StorageDriver) != 0 {
 		// enforce s3 driver in case of using storage driver
-		if config.Storage.StorageDriver["name"] != storageConstants.S3StorageDriverName {
-			log.Error().Err(zerr.ErrBadConfig).Interface("cacheDriver", config.Storage.StorageDriver["name"]).
-				Msg("unsupported storage driver")
-
-			return zerr.ErrBadConfig
+		if err := validateStorageDriver("", config.Storage.StorageDriver, log); err != nil {
+			return err
 		}
 
 		// enforce tmpDir in case sync + s3
@@ -423,11 +665,8 @@ func validateConfiguration(config *config.Config, log zlog.Logger) error {
 
 			for route, storageConfig := range subPaths {
 				if len(storageConfig.StorageDriver) != 0 {
-					if storageConfig.StorageDriver["name"] != storageConstants.S3StorageDriverName {
-						log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).Interface("storageDriver",
-							storageConfig.StorageDriver["name"]).Msg("unsupported storage driver")
-
-						return zerr.ErrBadConfig
+					if err := validateStorageDriver(route, storageConfig.StorageDriver, log); err != nil {
+						return err
 					}
 
 					// enforce tmpDir in case sync + s3
@@ -489,6 +728,66 @@ func validateOpenIDConfig(cfg *config.Config, log zlog.Logger) error {
 	return nil
 }
 
+func validatePublicRepositories(config *config.Config, log zlog.Logger) error {
+	if !config.PublicRepositoriesExist() {
+		return nil
+	}
+
+	if !config.IsBasicAuthnEnabled() && !config.IsBearerAuthEnabled() {
+		log.Error().Err(zerr.ErrBadConfig).
+			Msg("public repositories require authentication, without it all the repositories are public")
+
+		return zerr.ErrBadConfig
+	}
+
+	for _, pattern := range config.HTTP.Auth.PublicRepositories {
+		if ok := glob.ValidatePattern(pattern); !ok {
+			log.Error().Err(glob.ErrBadPattern).Str("pattern", pattern).
+				Msg("public repositories glob pattern could not be compiled")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	return nil
+}
+
+func validateTokenServer(config *config.Config, log zlog.Logger) error {
+	if config.HTTP.Auth == nil || config.HTTP.Auth.Bearer == nil || config.HTTP.Auth.Bearer.TokenServer == nil {
+		return nil
+	}
+
+	if !config.IsBearerAuthEnabled() {
+		log.Error().Err(zerr.ErrBadConfig).Msg("token server requires the bearer realm, service and cert to be set")
+
+		return zerr.ErrBadConfig
+	}
+
+	if !config.IsHtpasswdAuthEnabled() && !config.IsLdapAuthEnabled() && !config.IsAPIKeyEnabled() {
+		log.Error().Err(zerr.ErrBadConfig).
+			Msg("token server requires htpasswd, ldap or api key authentication to check the users credentials")
+
+		return zerr.ErrBadConfig
+	}
+
+	tokenServer := config.HTTP.Auth.Bearer.TokenServer
+
+	if tokenServer.Expiration < 0 {
+		log.Error().Err(zerr.ErrBadConfig).Dur("expiration", tokenServer.Expiration).
+			Msg("token server expiration can't be negative")
+
+		return zerr.ErrBadConfig
+	}
+
+	if _, err := os.Stat(tokenServer.Key); err != nil {
+		log.Error().Err(err).Str("key", tokenServer.Key).Msg("failed to read the token server key")
+
+		return zerr.ErrBadConfig
+	}
+
+	return nil
+}
+
 func validateAuthzPolicies(config *config.Config, log zlog.Logger) error {
 	if (config.HTTP.Auth == nil || (config.HTTP.Auth.HTPasswd.Path == "" && config.HTTP.Auth.LDAP == nil &&
 		config.HTTP.Auth.OpenID == nil)) && !authzContainsOnlyAnonymousPolicy(config) {
@@ -502,10 +801,40 @@ func validateAuthzPolicies(config *config.Config, log zlog.Logger) error {
 	return nil
 }
 
+// validateAuthzActions checks the policies only use the known actions, a misspelled action
+// would otherwise silently grant nothing.
+func validateAuthzActions(config *config.Config, log zlog.Logger) error {
+	accessControl := config.HTTP.AccessControl
+
+	if action, found := accessControl.AdminPolicy.UnknownAction(); found {
+		log.Error().Err(zerr.ErrBadConfig).Str("setting", "accessControl.adminPolicy").Str("action", action).
+			Msg("unknown action in access control policy")
+
+		return zerr.ErrBadConfig
+	}
+
+	// the namespaces policies are checked as the repositories policies they become
+	for pattern, policyGroup := range accessControl.AllRepositories() {
+		if policy, action, found := policyGroup.UnknownAction(); found {
+			log.Error().Err(zerr.ErrBadConfig).Str("setting", fmt.Sprintf("accessControl.repositories[%s].%s", pattern,
+				policy)).Str("action", action).Msg("unknown action in access control policy")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	return nil
+}
+
 //nolint:gocyclo,cyclop,nestif
 func applyDefaultValues(config *config.Config, viperInstance *viper.Viper, log zlog.Logger) {
 	defaultVal := true
 
+	// a server given a unix socket and no port is only reached through the socket
+	if config.HTTP.Socket != "" && !viperInstance.IsSet("http::port") {
+		config.HTTP.Port = ""
+	}
+
 	if config.Extensions == nil && viperInstance.Get("extensions") != nil {
 		config.Extensions = &extconf.ExtensionConfig{}
 
@@ -532,6 +861,20 @@ func applyDefaultValues(config *config.Config, viperInstance *viper.Viper, log z
 			config.Extensions.Scrub = &extconf.ScrubConfig{}
 		}
 
+		_, ok = extMap["events"]
+		if ok {
+			// we found a config like `"extensions": {"events": {}}`
+			// Note: In case events is not empty the config.Extensions will not be nil and we will not reach here
+			config.Extensions.Events = &extconf.EventsConfig{}
+		}
+
+		_, ok = extMap["tracing"]
+		if ok {
+			// we found a config like `"extensions": {"tracing": {}}`
+			// Note: In case tracing is not empty the config.Extensions will not be nil and we will not reach here
+			config.Extensions.Tracing = &extconf.TracingConfig{}
+		}
+
 		_, ok = extMap["trust"]
 		if ok {
 			// we found a config like `"extensions": {"trust:": {}}`
@@ -565,6 +908,10 @@ func applyDefaultValues(config *config.Config, viperInstance *viper.Viper, log z
 				config.Extensions.Search.Enable = &defaultVal
 			}
 
+			if config.Extensions.Search.Activity != nil && config.Extensions.Search.Activity.MaxEvents == 0 {
+				config.Extensions.Search.Activity.MaxEvents = defaultActivityMaxEvents
+			}
+
 			if *config.Extensions.Search.Enable && config.Extensions.Search.CVE != nil {
 				defaultUpdateInterval, _ := time.ParseDuration("2h")
 
@@ -615,6 +962,18 @@ func applyDefaultValues(config *config.Config, viperInstance *viper.Viper, log z
 			}
 		}
 
+		if config.Extensions.Tracing != nil {
+			if config.Extensions.Tracing.Enable == nil {
+				config.Extensions.Tracing.Enable = &defaultVal
+			}
+		}
+
+		if config.Extensions.Events != nil {
+			if config.Extensions.Events.Enable == nil {
+				config.Extensions.Events.Enable = &defaultVal
+			}
+		}
+
 		if config.Extensions.UI != nil {
 			if config.Extensions.UI.Enable == nil {
 				config.Extensions.UI.Enable = &defaultVal
@@ -792,7 +1151,7 @@ func LoadConfiguration(config *config.Config, configPath string) error {
 		return err
 	}
 
-	log := zlog.NewLogger(config.Log.Level, config.Log.Output)
+	log := zlog.NewLoggerWithFormat(config.Log.Level, config.Log.Output, config.Log.Format)
 
 	if len(metaData.Keys) == 0 {
 		log.Error().Err(zerr.ErrBadConfig).
@@ -949,6 +1308,70 @@ func validateHTTP(config *config.Config, log zlog.Logger) error {
 		}
 	}
 
+	if config.HTTP.Ratelimit != nil {
+		limits := map[string]*int{
+			"rate":                 config.HTTP.Ratelimit.Rate,
+			"userRate":             config.HTTP.Ratelimit.UserRate,
+			"maxConcurrentUploads": config.HTTP.Ratelimit.MaxConcurrentUploads,
+		}
+
+		for name, limit := range limits {
+			if limit != nil && *limit <= 0 {
+				log.Error().Err(zerr.ErrBadConfig).Int(name, *limit).Msg("invalid ratelimit, it must be positive")
+
+				return zerr.ErrBadConfig
+			}
+		}
+
+		for _, methodLimit := range config.HTTP.Ratelimit.Methods {
+			if methodLimit.Rate <= 0 {
+				log.Error().Err(zerr.ErrBadConfig).Str("method", methodLimit.Method).Int("rate", methodLimit.Rate).
+					Msg("invalid ratelimit, it must be positive")
+
+				return zerr.ErrBadConfig
+			}
+		}
+	}
+
+	if config.HTTP.ShutdownTimeout < 0 {
+		log.Error().Err(zerr.ErrBadConfig).Dur("shutdownTimeout", config.HTTP.ShutdownTimeout).
+			Msg("invalid shutdown timeout, it can't be negative")
+
+		return zerr.ErrBadConfig
+	}
+
+	return validateIPAccess(config, log)
+}
+
+func validateIPAccess(cfg *config.Config, log zlog.Logger) error {
+	ipAccess := cfg.HTTP.IPAccess
+	if ipAccess == nil {
+		return nil
+	}
+
+	addressLists := map[string][]string{
+		"allow":          ipAccess.Allow,
+		"deny":           ipAccess.Deny,
+		"trustedProxies": ipAccess.TrustedProxies,
+	}
+
+	for class, rules := range map[string]*config.IPRules{"pull": ipAccess.Pull, "push": ipAccess.Push,
+		"admin": ipAccess.Admin} {
+		if rules != nil {
+			addressLists[class+".allow"] = rules.Allow
+			addressLists[class+".deny"] = rules.Deny
+		}
+	}
+
+	for setting, addresses := range addressLists {
+		if _, err := config.ParseCIDRs(addresses); err != nil {
+			log.Error().Err(err).Str("setting", "http.ipAccess."+setting).
+				Msg("invalid address, it must be an IP address or a CIDR")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
 	return nil
 }
 
@@ -968,6 +1391,13 @@ func validateGC(config *config.Config, log zlog.Logger) error {
 		return zerr.ErrBadConfig
 	}
 
+	if config.Storage.UploadSessionTimeout < 0 {
+		log.Error().Err(zerr.ErrBadConfig).Dur("uploadSessionTimeout", config.Storage.UploadSessionTimeout).
+			Msg("invalid upload session timeout specified")
+
+		return zerr.ErrBadConfig
+	}
+
 	if !config.Storage.GC {
 		if config.Storage.GCDelay != 0 {
 			log.Warn().Err(zerr.ErrBadConfig).
@@ -998,6 +1428,14 @@ func validateGC(config *config.Config, log zlog.Logger) error {
 		if err := validateGCRules(subPath.Retention, log); err != nil {
 			return err
 		}
+
+		if subPath.UploadSessionTimeout < 0 {
+			log.Error().Err(zerr.ErrBadConfig).Str("subPath", name).
+				Dur("uploadSessionTimeout", subPath.UploadSessionTimeout).
+				Msg("invalid upload session timeout specified")
+
+			return zerr.ErrBadConfig
+		}
 	}
 
 	return nil
@@ -1030,6 +1468,420 @@ func validateGCRules(retention config.ImageRetention, log zlog.Logger) error {
 	return nil
 }
 
+func validateQuota(config *config.Config, log zlog.Logger) error {
+	quota := config.Storage.Quota
+	if quota == nil {
+		return nil
+	}
+
+	if quota.MaxBytes < 0 {
+		log.Error().Err(zerr.ErrBadConfig).Int64("maxBytes", quota.MaxBytes).Msg("invalid quota, it can't be negative")
+
+		return zerr.ErrBadConfig
+	}
+
+	for _, policy := range quota.Policies {
+		if len(policy.Repositories) == 0 {
+			log.Error().Err(zerr.ErrBadConfig).Msg("quota policy without repositories")
+
+			return zerr.ErrBadConfig
+		}
+
+		for _, pattern := range policy.Repositories {
+			if ok := glob.ValidatePattern(pattern); !ok {
+				log.Error().Err(glob.ErrBadPattern).Str("pattern", pattern).
+					Msg("quota repo glob pattern could not be compiled")
+
+				return zerr.ErrBadConfig
+			}
+		}
+
+		if policy.MaxBytes < 0 || policy.MaxRepositoryBytes < 0 {
+			log.Error().Err(zerr.ErrBadConfig).Strs("repositories", policy.Repositories).
+				Msg("invalid quota, it can't be negative")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	return nil
+}
+
+func validateNamespaces(config *config.Config, log zlog.Logger) error {
+	if config.HTTP.AccessControl == nil {
+		return nil
+	}
+
+	for namespace, namespaceConfig := range config.HTTP.AccessControl.Namespaces {
+		if namespace == "" || strings.ContainsAny(namespace, "*?[]{}") || strings.HasSuffix(namespace, "/") {
+			log.Error().Err(zerr.ErrBadConfig).Str("namespace", namespace).
+				Msg("invalid namespace name, it should be a repository name prefix without glob characters")
+
+			return zerr.ErrBadConfig
+		}
+
+		if quota := namespaceConfig.Quota; quota != nil && (quota.MaxBytes < 0 || quota.MaxRepositoryBytes < 0) {
+			log.Error().Err(zerr.ErrBadConfig).Str("namespace", namespace).
+				Msg("invalid namespace quota, it can't be negative")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	// the tags patterns of the namespaces retention policies
+	return validateGCRules(config.RetentionWithNamespaces(config.Storage.Retention), log)
+}
+
+func validateEvents(config *config.Config, log zlog.Logger) error {
+	if config.Extensions == nil || config.Extensions.Events == nil {
+		return nil
+	}
+
+	for id, webhook := range config.Extensions.Events.Webhooks {
+		webhookURL, err := url.Parse(webhook.URL)
+		if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+			log.Error().Err(zerr.ErrBadConfig).Int("id", id).Str("url", webhook.URL).
+				Msg("webhook url must be an http or https url")
+
+			return zerr.ErrBadConfig
+		}
+
+		for _, eventType := range webhook.Events {
+			if !events.IsValidEventType(eventType) {
+				log.Error().Err(zerr.ErrBadConfig).Int("id", id).Str("event", eventType).
+					Msg("unknown webhook event, must be one of push, delete or tag")
+
+				return zerr.ErrBadConfig
+			}
+		}
+
+		for _, pattern := range webhook.Repositories {
+			if ok := glob.ValidatePattern(pattern); !ok {
+				log.Error().Err(glob.ErrBadPattern).Int("id", id).Str("pattern", pattern).
+					Msg("webhook repo glob pattern could not be compiled")
+
+				return zerr.ErrBadConfig
+			}
+		}
+
+		if (webhook.MaxRetries != nil && *webhook.MaxRetries < 0) ||
+			(webhook.RetryDelay != nil && *webhook.RetryDelay < 0) || webhook.Timeout < 0 {
+			log.Error().Err(zerr.ErrBadConfig).Int("id", id).
+				Msg("webhook maxRetries, retryDelay and timeout can't be negative")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	return nil
+}
+
+func validateLog(config *config.Config, log zlog.Logger) error {
+	if config.Log == nil {
+		return nil
+	}
+
+	if format := config.Log.Format; format != "" && format != zlog.JSONFormat && format != zlog.ConsoleFormat {
+		log.Error().Err(zerr.ErrBadConfig).Str("format", format).Msg("log format must be json or console")
+
+		return zerr.ErrBadConfig
+	}
+
+	for module, level := range config.Log.Modules {
+		if lvl, err := zerolog.ParseLevel(level); err != nil || lvl == zerolog.NoLevel {
+			log.Error().Err(zerr.ErrBadConfig).Str("module", module).Str("level", level).
+				Msg("invalid log level of module")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	return nil
+}
+
+func validateAuditLog(config *config.Config, log zlog.Logger) error {
+	if config.Log == nil {
+		return nil
+	}
+
+	if rotation := config.Log.AuditRotation; rotation != nil {
+		if config.Log.Audit == "" {
+			log.Error().Err(zerr.ErrBadConfig).Msg("audit log rotation needs an audit log file")
+
+			return zerr.ErrBadConfig
+		}
+
+		if rotation.MaxBytes < 0 || rotation.MaxBackups < 0 || rotation.MaxAge < 0 {
+			log.Error().Err(zerr.ErrBadConfig).Msg("audit log maxBytes, maxBackups and maxAge can't be negative")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	if syslog := config.Log.AuditSyslog; syslog != nil {
+		if config.Log.Audit != "" {
+			log.Error().Err(zerr.ErrBadConfig).Msg("audit logs can be sent either to a file or to syslog, not both")
+
+			return zerr.ErrBadConfig
+		}
+
+		if (syslog.Network != "" && syslog.Network != "udp" && syslog.Network != "tcp") ||
+			(syslog.Network != "") != (syslog.Address != "") {
+			log.Error().Err(zerr.ErrBadConfig).Str("network", syslog.Network).Str("address", syslog.Address).
+				Msg("syslog network must be udp or tcp and be set along with the address")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	return nil
+}
+
+func validateImmutableTags(config *config.Config, log zlog.Logger) error {
+	for id, policy := range config.Storage.ImmutableTags {
+		if len(policy.Repositories) == 0 || len(policy.Patterns) == 0 {
+			log.Error().Err(zerr.ErrBadConfig).Int("id", id).
+				Msg("immutable tags policy must have repositories and patterns")
+
+			return zerr.ErrBadConfig
+		}
+
+		for _, pattern := range append(append([]string{}, policy.Repositories...), policy.Patterns...) {
+			if ok := glob.ValidatePattern(pattern); !ok {
+				log.Error().Err(glob.ErrBadPattern).Int("id", id).Str("pattern", pattern).
+					Msg("immutable tags glob pattern could not be compiled")
+
+				return zerr.ErrBadConfig
+			}
+		}
+	}
+
+	return nil
+}
+
+func validatePromotion(config *config.Config, log zlog.Logger) error {
+	if !config.IsPromotionEnabled() {
+		return nil
+	}
+
+	if !zreg.FullNameRegexp.MatchString(config.GetStagingPrefix()) {
+		log.Error().Err(zerr.ErrBadConfig).Str("stagingPrefix", config.GetStagingPrefix()).
+			Msg("promotion staging prefix must be a valid repository name")
+
+		return zerr.ErrBadConfig
+	}
+
+	severities := []string{
+		cvemodel.SeverityNone, cvemodel.SeverityUnknown, cvemodel.SeverityLow,
+		cvemodel.SeverityMedium, cvemodel.SeverityHigh, cvemodel.SeverityCritical,
+	}
+
+	for id, policy := range config.Storage.Promotion.Policies {
+		if len(policy.Repositories) == 0 {
+			log.Error().Err(zerr.ErrBadConfig).Int("id", id).Msg("promotion policy must have repositories")
+
+			return zerr.ErrBadConfig
+		}
+
+		for _, pattern := range policy.Repositories {
+			if ok := glob.ValidatePattern(pattern); !ok {
+				log.Error().Err(glob.ErrBadPattern).Int("id", id).Str("pattern", pattern).
+					Msg("promotion policy glob pattern could not be compiled")
+
+				return zerr.ErrBadConfig
+			}
+		}
+
+		if policy.RequireTrustedSignature && !config.IsImageTrustEnabled() {
+			log.Error().Err(zerr.ErrBadConfig).Int("id", id).
+				Msg("promotion policy requiring trusted signatures needs the trust extension")
+
+			return zerr.ErrBadConfig
+		}
+
+		if policy.MaxSeverity == "" {
+			continue
+		}
+
+		if !slices.Contains(severities, strings.ToUpper(policy.MaxSeverity)) {
+			log.Error().Err(zerr.ErrBadConfig).Int("id", id).Str("maxSeverity", policy.MaxSeverity).
+				Strs("allowed", severities).Msg("invalid promotion policy max severity")
+
+			return zerr.ErrBadConfig
+		}
+
+		if !config.IsCveScanningEnabled() {
+			log.Error().Err(zerr.ErrBadConfig).Int("id", id).
+				Msg("promotion policy with a max severity needs the CVE scanning")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	return nil
+}
+
+func validateTiering(cfg *config.Config, log zlog.Logger) error {
+	storageConfigs := map[string]config.StorageConfig{"": cfg.Storage.StorageConfig}
+	for route, storageConfig := range cfg.Storage.SubPaths {
+		storageConfigs[route] = storageConfig
+	}
+
+	for route, storageConfig := range storageConfigs {
+		tiering := storageConfig.Tiering
+		if tiering == nil {
+			continue
+		}
+
+		if len(storageConfig.StorageDriver) > 0 {
+			log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).
+				Msg("tiering is only supported on local storage")
+
+			return zerr.ErrBadConfig
+		}
+
+		if tiering.RootDirectory == "" || filepath.Clean(tiering.RootDirectory) ==
+			filepath.Clean(storageConfig.RootDirectory) {
+			log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).Str("rootDirectory", tiering.RootDirectory).
+				Msg("tiering needs a root directory other than the storage one")
+
+			return zerr.ErrBadConfig
+		}
+
+		if tiering.ColdAfter <= 0 || tiering.Interval < 0 {
+			log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).Dur("coldAfter", tiering.ColdAfter).
+				Dur("interval", tiering.Interval).Msg("tiering coldAfter must be positive and interval can't be negative")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	return nil
+}
+
+func validateEncryption(cfg *config.Config, log zlog.Logger) error {
+	storageConfigs := map[string]config.StorageConfig{"": cfg.Storage.StorageConfig}
+	for route, storageConfig := range cfg.Storage.SubPaths {
+		storageConfigs[route] = storageConfig
+	}
+
+	for route, storageConfig := range storageConfigs {
+		encryptionConfig := storageConfig.Encryption
+		if encryptionConfig == nil {
+			continue
+		}
+
+		if len(storageConfig.StorageDriver) > 0 {
+			log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).
+				Msg("encryption is only supported on local storage, use the server-side encryption of s3")
+
+			return zerr.ErrBadConfig
+		}
+
+		if storageConfig.Tiering != nil {
+			log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).
+				Msg("encryption can't be enabled along with tiering")
+
+			return zerr.ErrBadConfig
+		}
+
+		if encryptionConfig.Keyring == "" {
+			log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).Msg("encryption needs a keyring")
+
+			return zerr.ErrBadConfig
+		}
+
+		if encryptionConfig.KMS != nil && encryptionConfig.KMS.Region == "" {
+			log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).Msg("encryption KMS needs a region")
+
+			return zerr.ErrBadConfig
+		}
+
+		for _, pattern := range encryptionConfig.Repositories {
+			if ok := glob.ValidatePattern(pattern); !ok {
+				log.Error().Err(glob.ErrBadPattern).Str("subpath", route).Str("pattern", pattern).
+					Msg("encryption glob pattern could not be compiled")
+
+				return glob.ErrBadPattern
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateRecompression(cfg *config.Config, log zlog.Logger) error {
+	storageConfigs := map[string]config.StorageConfig{"": cfg.Storage.StorageConfig}
+	for route, storageConfig := range cfg.Storage.SubPaths {
+		storageConfigs[route] = storageConfig
+	}
+
+	tagSuffixRegexp := regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+	for route, storageConfig := range storageConfigs {
+		recompressionConfig := storageConfig.Recompression
+		if recompressionConfig == nil {
+			continue
+		}
+
+		if recompressionConfig.Format != "" && recompressionConfig.Format != recompression.FormatZstdChunked &&
+			recompressionConfig.Format != recompression.FormatZstd {
+			log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).Str("format", recompressionConfig.Format).
+				Msg("recompression format must be zstd:chunked or zstd")
+
+			return zerr.ErrBadConfig
+		}
+
+		if recompressionConfig.TagSuffix != "" && !tagSuffixRegexp.MatchString(recompressionConfig.TagSuffix) {
+			log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).Str("tagSuffix", recompressionConfig.TagSuffix).
+				Msg("recompression tagSuffix can only have the characters of a tag")
+
+			return zerr.ErrBadConfig
+		}
+
+		if recompressionConfig.Level < 0 || recompressionConfig.Level > 22 { //nolint:gomnd
+			log.Error().Err(zerr.ErrBadConfig).Str("subpath", route).Int("level", recompressionConfig.Level).
+				Msg("recompression level must be between 1 and 22")
+
+			return zerr.ErrBadConfig
+		}
+	}
+
+	return nil
+}
+
+func validateTrustPolicies(config *config.Config, log zlog.Logger) error {
+	if !config.IsTrustPolicyEnabled() {
+		return nil
+	}
+
+	if !config.IsCosignEnabled() && !config.IsNotationEnabled() {
+		log.Error().Err(zerr.ErrBadConfig).
+			Msg("trust policies need the cosign or notation signatures to be verified")
+
+		return zerr.ErrBadConfig
+	}
+
+	for id, policy := range config.Extensions.Trust.Policies {
+		if len(policy.Repositories) == 0 {
+			log.Error().Err(zerr.ErrBadConfig).Int("id", id).Msg("trust policy must have repositories")
+
+			return zerr.ErrBadConfig
+		}
+
+		for _, pattern := range policy.Repositories {
+			if ok := glob.ValidatePattern(pattern); !ok {
+				log.Error().Err(glob.ErrBadPattern).Int("id", id).Str("pattern", pattern).
+					Msg("trust policy repo glob pattern could not be compiled")
+
+				return zerr.ErrBadConfig
+			}
+		}
+	}
+
+	return nil
+}
+
 func validateSync(config *config.Config, log zlog.Logger) error {
 	// check glob patterns in sync config are compilable
 	if config.Extensions != nil && config.Extensions.Sync != nil {
@@ -1042,6 +1894,13 @@ func validateSync(config *config.Config, log zlog.Logger) error {
 				return zerr.ErrBadConfig
 			}
 
+			if regCfg.OnDemandTTL < 0 || (regCfg.OnDemandTTL > 0 && !regCfg.OnDemand) {
+				log.Error().Err(zerr.ErrBadConfig).Int("id", id).Interface("extensions.sync.registries[id]",
+					config.Extensions.Sync.Registries[id]).Msg("onDemandTTL must be positive and used with onDemand")
+
+				return zerr.ErrBadConfig
+			}
+
 			if regCfg.Content != nil {
 				for _, content := range regCfg.Content {
 					ok := glob.ValidatePattern(content.Prefix)
@@ -1062,6 +1921,16 @@ func validateSync(config *config.Config, log zlog.Logger) error {
 						}
 					}
 
+					if content.Tags != nil && content.Tags.SemverConstraint != nil {
+						_, err := semver.NewConstraint(*content.Tags.SemverConstraint)
+						if err != nil {
+							log.Error().Err(err).Str("semverConstraint", *content.Tags.SemverConstraint).
+								Msg("sync content semver constraint could not be parsed")
+
+							return zerr.ErrBadConfig
+						}
+					}
+
 					if content.StripPrefix && !strings.Contains(content.Prefix, "/*") && content.Destination == "/" {
 						log.Error().Err(zerr.ErrBadConfig).
 							Interface("sync content", content).Str("component", "sync").
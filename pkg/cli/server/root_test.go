@@ -1,16 +1,27 @@
 package server_test
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 
+	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api"
 	"zotregistry.dev/zot/pkg/api/config"
 	cli "zotregistry.dev/zot/pkg/cli/server"
@@ -551,6 +562,33 @@ storage:
 		So(err, ShouldBeNil)
 	})
 
+	Convey("Test verify upload session timeout", t, func(c C) {
+		verifyStorage := func(storage string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage": ` + storage + `, "http":{"address":"127.0.0.1","port":"8080"}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyStorage(`{"rootDirectory": "/tmp/zot", "uploadSessionTimeout": "24h",
+							"subPaths": {"/a": {"rootDirectory": "/zot-a", "uploadSessionTimeout": "1h"}}}`)
+		So(err, ShouldBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot", "uploadSessionTimeout": "-1h"}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot",
+							"subPaths": {"/a": {"rootDirectory": "/zot-a", "uploadSessionTimeout": "-1h"}}}`)
+		So(err, ShouldNotBeNil)
+	})
+
 	Convey("Test verify with bad gc retention repo patterns", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
@@ -715,7 +753,8 @@ storage:
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
 		defer os.Remove(tmpfile.Name()) // clean up
-		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot", "storageDriver": {"name": "s3"},
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot", "dedupe": false,
+							"storageDriver": {"name": "s3", "region": "us-east-2", "bucket": "zot-storage"},
 							"subPaths": {"/a": {"rootDirectory": "/zot-a","storageDriver": {"name": "gcs"}}}},
 							"http":{"address":"127.0.0.1","port":"8080","realm":"zot",
 							"auth":{"htpasswd":{"path":"test/data/htpasswd"},"failDelay":1}}}`)
@@ -728,6 +767,39 @@ storage:
 		So(err, ShouldNotBeNil)
 	})
 
+	Convey("Test verify s3 storage driver parameters", t, func(c C) {
+		verify := func(storageDriver string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot", "dedupe": false,
+								"subPaths": {"/a": {"rootDirectory": "/tmp/zot-a", "dedupe": false,
+								"storageDriver": ` + storageDriver + `}}},
+								"http":{"address":"127.0.0.1","port":"8080"},"log":{"level":"debug"}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		// an s3 compatible storage
+		So(verify(`{"name": "s3", "region": "us-east-1", "regionendpoint": "http://minio:9000",
+			"bucket": "zot", "chunksize": 10485760, "multipartcopychunksize": "33554432"}`), ShouldBeNil)
+
+		So(verify(`{"name": "s3", "region": "us-east-1"}`), ShouldNotBeNil)
+		So(verify(`{"name": "s3", "bucket": "zot"}`), ShouldNotBeNil)
+		So(verify(`{"name": "s3", "region": "us-east-1", "bucket": "zot", "chunksize": 1024}`), ShouldNotBeNil)
+		So(verify(`{"name": "s3", "region": "us-east-1", "bucket": "zot", "chunksize": 10485760.5}`), ShouldNotBeNil)
+		So(verify(`{"name": "s3", "region": "us-east-1", "bucket": "zot", "multipartcopychunksize": "6GiB"}`),
+			ShouldNotBeNil)
+
+		err := cli.LoadConfiguration(config.New(), "../../../examples/config-s3-compatible.json")
+		So(err, ShouldBeNil)
+	})
+
 	Convey("Test verify subpath storage config", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
@@ -938,6 +1010,33 @@ storage:
 		So(err, ShouldNotBeNil)
 	})
 
+	Convey("Test verify authz policies with an unknown action", t, func(c C) {
+		tmpfile, err := os.CreateTemp("", "zot-test*.json")
+		So(err, ShouldBeNil)
+		defer os.Remove(tmpfile.Name()) // clean up
+		content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080","realm":"zot",
+								"accessControl":{
+									"repositories": {
+										"**":{"anonymousPolicy": ["read", "detectManifestCollision"]},
+										"/repo":{"anonymousPolicy": ["read", "write"]}
+									}
+								}
+							}}`)
+		_, err = tmpfile.Write(content)
+		So(err, ShouldBeNil)
+		err = tmpfile.Close()
+		So(err, ShouldBeNil)
+		os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+		err = cli.NewServerRootCmd().Execute()
+		So(err, ShouldNotBeNil)
+
+		err = os.WriteFile(tmpfile.Name(), []byte(strings.Replace(string(content), `"write"`, `"create"`, 1)), 0o600)
+		So(err, ShouldBeNil)
+		err = cli.NewServerRootCmd().Execute()
+		So(err, ShouldBeNil)
+	})
+
 	Convey("Test verify w/ sync and w/o filesystem storage", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
@@ -1085,6 +1184,562 @@ storage:
 		So(err, ShouldNotBeNil)
 	})
 
+	Convey("Test verify events config", t, func(c C) {
+		verifyEvents := func(events string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080"},
+							"extensions":{"events": ` + events + `}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyEvents(`{"webhooks": [{"url": "https://ci.example.com/hook", "secret": "secret",
+							"events": ["push", "tag"], "repositories": ["apps/**"], "maxRetries": 5}]}`)
+		So(err, ShouldBeNil)
+
+		err = verifyEvents(`{"webhooks": [{"url": "ci.example.com/hook"}]}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyEvents(`{"webhooks": [{"url": "https://ci.example.com/hook", "events": ["pull"]}]}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyEvents(`{"webhooks": [{"url": "https://ci.example.com/hook", "repositories": ["apps/["]}]}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyEvents(`{"webhooks": [{"url": "https://ci.example.com/hook", "maxRetries": -1}]}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify quota config", t, func(c C) {
+		verifyQuota := func(quota string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot", "quota": ` + quota + `},
+							"http":{"address":"127.0.0.1","port":"8080"}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyQuota(`{"maxBytes": 1000000, "policies": [{"repositories": ["**"], "maxRepositoryBytes": 1000},
+							{"repositories": ["team/**"], "maxBytes": 10000}]}`)
+		So(err, ShouldBeNil)
+
+		err = verifyQuota(`{"maxBytes": -1}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyQuota(`{"policies": [{"maxBytes": 10000}]}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyQuota(`{"policies": [{"repositories": ["team/["], "maxBytes": 10000}]}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyQuota(`{"policies": [{"repositories": ["**"], "maxRepositoryBytes": -1}]}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify immutable tags config", t, func(c C) {
+		verifyImmutableTags := func(immutableTags string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot", "immutableTags": ` + immutableTags + `},
+							"http":{"address":"127.0.0.1","port":"8080"}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyImmutableTags(`[{"repositories": ["**"], "patterns": ["v*", "release-*"]}]`)
+		So(err, ShouldBeNil)
+
+		err = verifyImmutableTags(`[{"patterns": ["v*"]}]`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyImmutableTags(`[{"repositories": ["**"]}]`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyImmutableTags(`[{"repositories": ["**"], "patterns": ["v["]}]`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify promotion config", t, func(c C) {
+		verifyPromotion := func(promotion string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot", "promotion": ` + promotion + `},
+							"http":{"address":"127.0.0.1","port":"8080"}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyPromotion(`{}`)
+		So(err, ShouldBeNil)
+
+		err = verifyPromotion(`{"stagingPrefix": "qa", "policies": [{"repositories": ["**"], "requireSignature": true}]}`)
+		So(err, ShouldBeNil)
+
+		err = verifyPromotion(`{"stagingPrefix": "QA/"}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyPromotion(`{"policies": [{"requireSignature": true}]}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyPromotion(`{"policies": [{"repositories": ["team/["]}]}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyPromotion(`{"policies": [{"repositories": ["**"], "requireTrustedSignature": true}]}`)
+		So(err, ShouldNotBeNil)
+
+		// the CVE scanning isn't enabled
+		err = verifyPromotion(`{"policies": [{"repositories": ["**"], "maxSeverity": "HIGH"}]}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyPromotion(`{"policies": [{"repositories": ["**"], "maxSeverity": "SEVERE"}]}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify IP access config", t, func(c C) {
+		verifyIPAccess := func(ipAccess string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080","ipAccess": ` + ipAccess + `}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyIPAccess(`{"deny": ["192.168.0.0/16"], "push": {"allow": ["10.0.0.0/8", "::1"]},
+			"trustedProxies": ["127.0.0.1"]}`)
+		So(err, ShouldBeNil)
+
+		err = verifyIPAccess(`{"allow": ["10.0.0.0/33"]}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyIPAccess(`{"admin": {"deny": ["localhost"]}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyIPAccess(`{"trustedProxies": ["proxy"]}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify token server config", t, func(c C) {
+		htpasswdPath := MakeHtpasswdFileFromString(GetCredString("user", "user"))
+		defer os.Remove(htpasswdPath)
+
+		keyFile, err := os.CreateTemp("", "token*.key")
+		So(err, ShouldBeNil)
+		defer os.Remove(keyFile.Name()) // clean up
+		So(keyFile.Close(), ShouldBeNil)
+
+		verifyAuth := func(auth string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080","auth": ` + auth + `}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		bearer := func(tokenServer string) string {
+			return fmt.Sprintf(`"bearer": {"realm": "https://zot/zot/auth/token", "service": "zot",
+				"cert": "/etc/zot/token.crt", "tokenServer": %s}`, tokenServer)
+		}
+
+		err = verifyAuth(fmt.Sprintf(`{"htpasswd": {"path": "%s"}, %s}`, htpasswdPath,
+			bearer(fmt.Sprintf(`{"key": "%s", "expiration": "15m"}`, keyFile.Name()))))
+		So(err, ShouldBeNil)
+
+		// no users to issue tokens to
+		err = verifyAuth(fmt.Sprintf(`{%s}`, bearer(fmt.Sprintf(`{"key": "%s"}`, keyFile.Name()))))
+		So(err, ShouldNotBeNil)
+
+		err = verifyAuth(fmt.Sprintf(`{"htpasswd": {"path": "%s"}, %s}`, htpasswdPath,
+			bearer(`{"key": "/does/not/exist"}`)))
+		So(err, ShouldNotBeNil)
+
+		err = verifyAuth(fmt.Sprintf(`{"htpasswd": {"path": "%s"}, %s}`, htpasswdPath,
+			bearer(fmt.Sprintf(`{"key": "%s", "expiration": "-1m"}`, keyFile.Name()))))
+		So(err, ShouldNotBeNil)
+
+		err = verifyAuth(fmt.Sprintf(`{"htpasswd": {"path": "%s"},
+			"bearer": {"service": "zot", "tokenServer": {"key": "%s"}}}`, htpasswdPath, keyFile.Name()))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify namespaces config", t, func(c C) {
+		htpasswdPath := MakeHtpasswdFileFromString(GetCredString("user", "user"))
+		defer os.Remove(htpasswdPath)
+
+		verifyNamespaces := func(namespaces string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(fmt.Sprintf(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080","auth":{"htpasswd":{"path":"%s"}},
+							"accessControl":{"namespaces": %s}}}`, htpasswdPath, namespaces))
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyNamespaces(`{"team-a": {"adminUsers": ["user"],
+			"policies": {"defaultPolicy": ["read"]},
+			"retention": {"keepTags": [{"patterns": ["v.*"]}]},
+			"quota": {"maxBytes": 1000}}}`)
+		So(err, ShouldBeNil)
+
+		err = verifyNamespaces(`{"team-*": {"adminUsers": ["user"]}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyNamespaces(`{"team-a": {"policies": {"defaultPolicy": ["pull"]}}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyNamespaces(`{"team-a": {"retention": {"keepTags": [{"patterns": ["["]}]}}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyNamespaces(`{"team-a": {"quota": {"maxRepositoryBytes": -1}}}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify log config", t, func(c C) {
+		verifyLog := func(logConfig string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080"}, "log": ` + logConfig + `}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyLog(`{"level": "info", "format": "console", "modules": {"sync": "debug"},
+			"sampling": {"pulls": 10}}`)
+		So(err, ShouldBeNil)
+
+		err = verifyLog(`{"level": "info", "format": "xml"}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyLog(`{"level": "info", "modules": {"sync": "verbose"}}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify public repositories config", t, func(c C) {
+		htpasswdPath := MakeHtpasswdFileFromString(GetCredString("user", "user"))
+		defer os.Remove(htpasswdPath)
+
+		verifyAuth := func(auth string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080","auth": ` + auth + `}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyAuth(`{"htpasswd": {"path": "` + htpasswdPath + `"}, "publicRepositories": ["public/**"]}`)
+		So(err, ShouldBeNil)
+
+		// without authentication all the repositories are already public
+		err = verifyAuth(`{"publicRepositories": ["public/**"]}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyAuth(`{"htpasswd": {"path": "` + htpasswdPath + `"}, "publicRepositories": ["public/["]}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify subpaths repositories", t, func(c C) {
+		verifyStorage := func(storage string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage": ` + storage + `, "http":{"address":"127.0.0.1","port":"8080"}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyStorage(`{"rootDirectory": "/tmp/zot",
+			"subPaths": {"/fast": {"rootDirectory": "/tmp/zot-fast", "repositories": ["infra/**", "*-cache"]}}}`)
+		So(err, ShouldBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot",
+			"subPaths": {"/fast": {"rootDirectory": "/tmp/zot-fast", "repositories": ["infra/["]}}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot", "repositories": ["infra/**"]}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify tiering config", t, func(c C) {
+		verifyStorage := func(storage string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage": ` + storage + `, "http":{"address":"127.0.0.1","port":"8080"}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyStorage(`{"rootDirectory": "/tmp/zot",
+			"tiering": {"rootDirectory": "/tmp/zot-cold", "coldAfter": "720h", "interval": "24h"},
+			"subPaths": {"/a": {"rootDirectory": "/tmp/zot-a",
+				"tiering": {"rootDirectory": "/tmp/zot-a-cold", "coldAfter": "168h"}}}}`)
+		So(err, ShouldBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot", "tiering": {"coldAfter": "720h"}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot", "tiering": {"rootDirectory": "/tmp/zot/", "coldAfter": "720h"}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot", "tiering": {"rootDirectory": "/tmp/zot-cold"}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot",
+			"tiering": {"rootDirectory": "/tmp/zot-cold", "coldAfter": "720h", "interval": "-1h"}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot",
+			"subPaths": {"/a": {"rootDirectory": "/tmp/zot-a", "storageDriver": {"name": "s3", "region": "us-east-2",
+				"bucket": "zot-storage"}, "tiering": {"rootDirectory": "/tmp/zot-a-cold", "coldAfter": "168h"}}}}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify recompression config", t, func(c C) {
+		verifyStorage := func(storage string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage": ` + storage + `, "http":{"address":"127.0.0.1","port":"8080"}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyStorage(`{"rootDirectory": "/tmp/zot", "recompression": {},
+			"subPaths": {"/a": {"rootDirectory": "/tmp/zot-a",
+				"recompression": {"format": "zstd", "tagSuffix": "_zstd", "level": 19}}}}`)
+		So(err, ShouldBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot", "recompression": {"format": "estargz"}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot", "recompression": {"tagSuffix": "/zstd"}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot",
+			"subPaths": {"/a": {"rootDirectory": "/tmp/zot-a", "recompression": {"level": 23}}}}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify encryption config", t, func(c C) {
+		verifyStorage := func(storage string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage": ` + storage + `, "http":{"address":"127.0.0.1","port":"8080"}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyStorage(`{"rootDirectory": "/tmp/zot", "encryption": {"keyring": "/etc/zot/keyring.json"},
+			"subPaths": {"/a": {"rootDirectory": "/tmp/zot-a", "encryption": {"keyring": "/etc/zot/keyring-a.json",
+				"kms": {"region": "us-east-2"}, "repositories": ["secret/**"]}}}}`)
+		So(err, ShouldBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot", "encryption": {"repositories": ["secret/**"]}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot",
+			"encryption": {"keyring": "/etc/zot/keyring.json", "kms": {"endpoint": "http://localhost:4566"}}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot",
+			"encryption": {"keyring": "/etc/zot/keyring.json", "repositories": ["secret/[a"]}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot", "encryption": {"keyring": "/etc/zot/keyring.json"},
+			"tiering": {"rootDirectory": "/tmp/zot-cold", "coldAfter": "168h"}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyStorage(`{"rootDirectory": "/tmp/zot",
+			"subPaths": {"/a": {"rootDirectory": "/tmp/zot-a", "storageDriver": {"name": "s3", "region": "us-east-2",
+				"bucket": "zot-storage"}, "encryption": {"keyring": "/etc/zot/keyring.json"}}}}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify audit log config", t, func(c C) {
+		verifyLog := func(log string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080"}, "log": ` + log + `}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyLog(`{"level": "debug", "audit": "/tmp/zot-audit.log",
+			"auditRotation": {"maxBytes": 1048576, "maxBackups": 3, "maxAge": "168h"}}`)
+		So(err, ShouldBeNil)
+
+		err = verifyLog(`{"level": "debug", "auditSyslog": {"network": "udp", "address": "127.0.0.1:514"}}`)
+		So(err, ShouldBeNil)
+
+		err = verifyLog(`{"level": "debug", "auditRotation": {"maxBytes": 1048576}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyLog(`{"level": "debug", "audit": "/tmp/zot-audit.log", "auditRotation": {"maxBackups": -1}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyLog(`{"level": "debug", "audit": "/tmp/zot-audit.log", "auditSyslog": {"tag": "zot"}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyLog(`{"level": "debug", "auditSyslog": {"network": "http", "address": "127.0.0.1:514"}}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyLog(`{"level": "debug", "auditSyslog": {"network": "tcp"}}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify trust policies config", t, func(c C) {
+		verifyTrust := func(trust string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080"},
+							"extensions":{"trust": ` + trust + `}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyTrust(`{"enable": true, "cosign": true,
+							"policies": [{"repositories": ["prod/**"], "enforceOnPull": true, "enforceOnOverwrite": true}]}`)
+		So(err, ShouldBeNil)
+
+		err = verifyTrust(`{"enable": true, "policies": [{"repositories": ["prod/**"], "enforceOnPull": true}]}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyTrust(`{"enable": true, "notation": true, "policies": [{"enforceOnPull": true}]}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyTrust(`{"enable": true, "notation": true, "policies": [{"repositories": ["prod/["]}]}`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test verify ratelimit config", t, func(c C) {
+		verifyRatelimit := func(ratelimit string) error {
+			tmpfile, err := os.CreateTemp("", "zot-test*.json")
+			So(err, ShouldBeNil)
+			defer os.Remove(tmpfile.Name()) // clean up
+			content := []byte(`{"storage":{"rootDirectory":"/tmp/zot"},
+							"http":{"address":"127.0.0.1","port":"8080", "ratelimit": ` + ratelimit + `}}`)
+			_, err = tmpfile.Write(content)
+			So(err, ShouldBeNil)
+			err = tmpfile.Close()
+			So(err, ShouldBeNil)
+			os.Args = []string{"cli_test", "verify", tmpfile.Name()}
+
+			return cli.NewServerRootCmd().Execute()
+		}
+
+		err := verifyRatelimit(`{"rate": 10, "userRate": 5, "maxConcurrentUploads": 2,
+							"methods": [{"method": "GET", "rate": 5}]}`)
+		So(err, ShouldBeNil)
+
+		err = verifyRatelimit(`{"rate": 0}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyRatelimit(`{"userRate": -1}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyRatelimit(`{"maxConcurrentUploads": 0}`)
+		So(err, ShouldNotBeNil)
+
+		err = verifyRatelimit(`{"methods": [{"method": "GET", "rate": 0}]}`)
+		So(err, ShouldNotBeNil)
+	})
+
 	Convey("Test verify config with unknown keys", t, func(c C) {
 		tmpfile, err := os.CreateTemp("", "zot-test*.json")
 		So(err, ShouldBeNil)
@@ -1238,6 +1893,132 @@ storage:
 	})
 }
 
+func TestVerifyDryRun(t *testing.T) {
+	writeCertAndKey := func(dir string) (string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		So(err, ShouldBeNil)
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "127.0.0.1"},
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+
+		certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		So(err, ShouldBeNil)
+
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		So(err, ShouldBeNil)
+
+		certPath, keyPath := path.Join(dir, "server.cert"), path.Join(dir, "server.key")
+
+		err = os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600)
+		So(err, ShouldBeNil)
+
+		err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+		So(err, ShouldBeNil)
+
+		return certPath, keyPath
+	}
+
+	runVerify := func(content string, args ...string) (string, error) {
+		configPath := path.Join(t.TempDir(), "config.json")
+
+		err := os.WriteFile(configPath, []byte(content), 0o600)
+		So(err, ShouldBeNil)
+
+		cmd := cli.NewServerRootCmd()
+		buff := bytes.NewBufferString("")
+		cmd.SetErr(buff)
+		cmd.SetArgs(append([]string{"verify", configPath}, args...))
+		err = cmd.Execute()
+
+		return buff.String(), err
+	}
+
+	Convey("Test verify --dry-run", t, func() {
+		dir := t.TempDir()
+		certPath, keyPath := writeCertAndKey(dir)
+
+		htpasswdPath := path.Join(dir, "htpasswd")
+		htpasswd := "# users\ntest:$2y$05$hlbSXDp6hzDLu6VwACS39ORvVRpr3OMR4RlJ31jtlaOEGnPjKZI1m\n"
+		err := os.WriteFile(htpasswdPath, []byte(htpasswd), 0o600)
+		So(err, ShouldBeNil)
+
+		credentialsPath := path.Join(dir, "credentials.json")
+		err = os.WriteFile(credentialsPath, []byte(`{"registry:5000":{"username":"user","password":"pass"}}`), 0o600)
+		So(err, ShouldBeNil)
+
+		Convey("a config the server can start with", func() {
+			content := fmt.Sprintf(`{"storage":{"rootDirectory":"%s","subPaths":{"/a":{"rootDirectory":"%s"}}},
+				"http":{"address":"127.0.0.1","port":"8080",
+				"tls":{"cert":"%s","key":"%s","cacert":"%s"},
+				"auth":{"htpasswd":{"path":"%s"}}},
+				"log":{"level":"debug","output":"%s"},
+				"extensions":{"sync":{"credentialsFile":"%s","registries":[{"urls":["https://registry:5000"],
+				"certDir":"%s"}]}}}`,
+				path.Join(dir, "zot", "storage"), path.Join(dir, "zot-a"), certPath, keyPath, certPath, htpasswdPath,
+				path.Join(dir, "zot.log"), credentialsPath, dir)
+
+			output, err := runVerify(content, "--dry-run")
+			So(err, ShouldBeNil)
+			So(output, ShouldBeEmpty)
+
+			// the directories which don't exist yet aren't created
+			_, err = os.Stat(path.Join(dir, "zot"))
+			So(errors.Is(err, os.ErrNotExist), ShouldBeTrue)
+		})
+
+		Convey("all the problems are reported", func() {
+			badHTPasswdPath := path.Join(dir, "bad-htpasswd")
+			err := os.WriteFile(badHTPasswdPath, []byte("test\n"), 0o600)
+			So(err, ShouldBeNil)
+
+			storageFile := path.Join(dir, "storage")
+			err = os.WriteFile(storageFile, []byte{}, 0o600)
+			So(err, ShouldBeNil)
+
+			content := fmt.Sprintf(`{"storage":{"rootDirectory":"%s"},
+				"http":{"address":"127.0.0.1","port":"8080",
+				"tls":{"cert":"%s","key":"%s","cacert":"%s"},
+				"auth":{"htpasswd":{"path":"%s"}}},
+				"log":{"level":"debug"},
+				"extensions":{"sync":{"credentialsFile":"%s","registries":[{"urls":["https://registry:5000"],
+				"certDir":"%s"}]}}}`,
+				storageFile, certPath, path.Join(dir, "missing.key"), keyPath, badHTPasswdPath, htpasswdPath,
+				path.Join(dir, "missing"))
+
+			// the config itself is valid
+			_, err = runVerify(content)
+			So(err, ShouldBeNil)
+
+			output, err := runVerify(content, "--dry-run")
+			So(errors.Is(err, zerr.ErrBadConfig), ShouldBeTrue)
+
+			lines := strings.Split(strings.TrimSpace(output), "\n")
+			So(len(lines), ShouldEqual, 7)
+			So(lines[0], ShouldEqual, "error: storage.rootDirectory: "+storageFile+": not a directory")
+			So(lines[1], ShouldStartWith, "error: http.tls.cert, http.tls.key: open "+path.Join(dir, "missing.key"))
+			So(lines[2], ShouldEqual, "error: http.tls.cacert: "+keyPath+": no PEM encoded certificate found")
+			So(lines[3], ShouldEqual, "error: http.auth.htpasswd.path: "+badHTPasswdPath+
+				" line 1: not a 'user:hash' htpasswd entry")
+			So(lines[4], ShouldStartWith, "error: extensions.sync.credentialsFile: "+htpasswdPath)
+			So(lines[5], ShouldStartWith, "error: extensions.sync.registries[0].certDir: stat "+path.Join(dir, "missing"))
+			So(lines[6], ShouldEqual, "Error: invalid server config: 6 problems found")
+		})
+
+		Convey("the remote storage isn't checked", func() {
+			content := `{"storage":{"rootDirectory":"/nonexistent/zot","dedupe":false,
+				"storageDriver":{"name":"s3","rootdirectory":"/zot","region":"us-east-2","bucket":"zot"}},
+				"http":{"address":"127.0.0.1","port":"8080"},"log":{"level":"debug"}}`
+
+			_, err := runVerify(content, "--dry-run")
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
 func TestApiKeyConfig(t *testing.T) {
 	Convey("Test API Keys are enabled if OpenID is enabled", t, func(c C) {
 		config := config.New()
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/api/constants"
+	"zotregistry.dev/zot/pkg/storage"
+)
+
+const (
+	scrubStatusTimeout = 30 * time.Second
+	// the server reached through its unix socket only sees this url
+	unixSocketServerURL = "http://localhost"
+)
+
+// getScrubStatus asks the server running with the config for the results of its periodic scrub.
+// credentials are in "username:password" format, they're needed if authentication is enabled.
+func getScrubStatus(ctx context.Context, conf *config.Config, credentials string) (storage.ScrubStatus, error) {
+	var status storage.ScrubStatus
+
+	statusCode, err := doServerRequest(ctx, conf, credentials, http.MethodGet, constants.FullScrub, &status)
+	if statusCode == http.StatusNotFound {
+		// either the scrub isn't enabled in the config or the binary isn't built with it
+		return status, fmt.Errorf("%w: scrub", zerr.ErrExtensionNotEnabled)
+	}
+
+	return status, err
+}
+
+// doServerRequest sends the request to the server running with the config and decodes its JSON answer into
+// result, unless it's nil. It returns the status code of the answer along with the error of the unexpected ones.
+// credentials are in "username:password" format, they're needed if authentication is enabled.
+func doServerRequest(ctx context.Context, conf *config.Config, credentials, method, path string, result any,
+) (int, error) {
+	resp, err := sendServerRequest(ctx, conf, credentials, method, path, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return resp.StatusCode, zerr.ErrUnauthorizedAccess
+	case resp.StatusCode >= http.StatusMultipleChoices:
+		return resp.StatusCode, fmt.Errorf("%w: %s", zerr.ErrBadHTTPStatusCode, resp.Status)
+	}
+
+	if result == nil {
+		return resp.StatusCode, nil
+	}
+
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(result)
+}
+
+// sendServerRequest sends the request to the server running with the config, with body encoded as JSON
+// unless it's nil, and returns its answer whatever its status code.
+func sendServerRequest(ctx context.Context, conf *config.Config, credentials, method, path string, body any,
+) (*http.Response, error) {
+	client, serverURL, err := newServerClient(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var reqBody io.Reader
+
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+
+		reqBody = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, serverURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if credentials != "" {
+		username, password, _ := strings.Cut(credentials, ":")
+		req.SetBasicAuth(username, password)
+	}
+
+	return client.Do(req)
+}
+
+// newServerClient returns a client for the server running with the config, and its URL.
+// The server is reached through its unix socket if it has one, else with TLS the server certificate
+// and the CA certificate of the config are trusted.
+func newServerClient(conf *config.Config) (*http.Client, string, error) {
+	if conf.HTTP.Socket != "" {
+		return newUnixSocketClient(conf.HTTP.Socket), unixSocketServerURL, nil
+	}
+
+	host := conf.HTTP.Address
+
+	// a server listening on every address is reached on this host
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	serverURL := url.URL{Scheme: "http", Host: net.JoinHostPort(host, conf.HTTP.Port)}
+	client := &http.Client{Timeout: scrubStatusTimeout}
+
+	if conf.HTTP.TLS != nil {
+		serverURL.Scheme = "https"
+
+		certPool, err := x509.SystemCertPool()
+		if err != nil {
+			certPool = x509.NewCertPool()
+		}
+
+		for _, certPath := range []string{conf.HTTP.TLS.Cert, conf.HTTP.TLS.CACert} {
+			if certPath == "" {
+				continue
+			}
+
+			content, err := os.ReadFile(certPath)
+			if err != nil {
+				return nil, "", err
+			}
+
+			certPool.AppendCertsFromPEM(content)
+		}
+
+		client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:    certPool,
+				MinVersion: tls.VersionTLS12,
+			},
+		}
+	}
+
+	return client, serverURL.String(), nil
+}
+
+// newUnixSocketClient returns a client sending the requests through the unix socket of the server.
+func newUnixSocketClient(socket string) *http.Client {
+	dialer := &net.Dialer{}
+
+	return &http.Client{
+		Timeout: scrubStatusTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
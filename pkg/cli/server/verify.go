@@ -0,0 +1,212 @@
+package server
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/config"
+	syncconf "zotregistry.dev/zot/pkg/extensions/config/sync"
+)
+
+// configProblem is a setting the server couldn't start with, or would fail with once started.
+type configProblem struct {
+	setting string
+	err     error
+}
+
+func (problem configProblem) String() string {
+	return problem.setting + ": " + problem.err.Error()
+}
+
+// checkConfigFiles checks the files and directories the server would use, as they are on this host:
+// the storage paths should be writable, the TLS material and the files of the auth settings and
+// the extensions should be readable and well formed. All the problems are returned, not only the first one.
+func checkConfigFiles(conf *config.Config) []configProblem {
+	problems := []configProblem{}
+
+	check := func(setting string, err error) {
+		if err != nil {
+			problems = append(problems, configProblem{setting: setting, err: err})
+		}
+	}
+
+	// the storage drivers other than the local one don't store anything on this host
+	if len(conf.Storage.StorageDriver) == 0 {
+		check("storage.rootDirectory", checkWritableDir(conf.Storage.RootDirectory))
+	}
+
+	routes := make([]string, 0, len(conf.Storage.SubPaths))
+
+	for route := range conf.Storage.SubPaths {
+		routes = append(routes, route)
+	}
+
+	sort.Strings(routes)
+
+	for _, route := range routes {
+		if subPath := conf.Storage.SubPaths[route]; len(subPath.StorageDriver) == 0 {
+			check(fmt.Sprintf("storage.subPaths[%s].rootDirectory", route), checkWritableDir(subPath.RootDirectory))
+		}
+	}
+
+	if conf.HTTP.TLS != nil {
+		_, err := tls.LoadX509KeyPair(conf.HTTP.TLS.Cert, conf.HTTP.TLS.Key)
+		check("http.tls.cert, http.tls.key", err)
+
+		if conf.HTTP.TLS.CACert != "" {
+			check("http.tls.cacert", checkCertificates(conf.HTTP.TLS.CACert))
+		}
+	}
+
+	if auth := conf.HTTP.Auth; auth != nil {
+		if auth.HTPasswd.Path != "" {
+			check("http.auth.htpasswd.path", checkHTPasswdFile(auth.HTPasswd.Path))
+		}
+
+		if auth.Bearer != nil && auth.Bearer.Cert != "" {
+			check("http.auth.bearer.cert", checkCertificates(auth.Bearer.Cert))
+		}
+
+		if auth.LDAP != nil && auth.LDAP.CACert != "" {
+			check("http.auth.ldap.cacert", checkCertificates(auth.LDAP.CACert))
+		}
+	}
+
+	if conf.Extensions != nil && conf.Extensions.Sync != nil {
+		syncConfig := conf.Extensions.Sync
+
+		if syncConfig.CredentialsFile != "" {
+			check("extensions.sync.credentialsFile", checkSyncCredentialsFile(syncConfig.CredentialsFile))
+		}
+
+		if syncConfig.DownloadDir != "" {
+			check("extensions.sync.downloadDir", checkWritableDir(syncConfig.DownloadDir))
+		}
+
+		for i, registry := range syncConfig.Registries {
+			if registry.CertDir != "" {
+				check(fmt.Sprintf("extensions.sync.registries[%d].certDir", i), checkDir(registry.CertDir))
+			}
+		}
+	}
+
+	return problems
+}
+
+func checkDir(dirPath string) error {
+	info, err := os.Stat(dirPath)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%s: %w", dirPath, zerr.ErrNotADirectory)
+	}
+
+	return nil
+}
+
+// checkWritableDir checks the directory can be written to, or if it doesn't exist yet, that it can be
+// created in the closest parent which exists. A file is created and removed to find out.
+func checkWritableDir(dirPath string) error {
+	for {
+		err := checkDir(dirPath)
+		if err == nil {
+			break
+		}
+
+		parent := filepath.Dir(dirPath)
+		if !errors.Is(err, fs.ErrNotExist) || parent == dirPath {
+			return err
+		}
+
+		dirPath = parent
+	}
+
+	file, err := os.CreateTemp(dirPath, ".zot-verify-*")
+	if err != nil {
+		return err
+	}
+
+	file.Close()
+
+	return os.Remove(file.Name())
+}
+
+// checkCertificates checks the file has at least one PEM encoded certificate and that they can all be parsed.
+func checkCertificates(certPath string) error {
+	content, err := os.ReadFile(certPath)
+	if err != nil {
+		return err
+	}
+
+	found := false
+
+	for block, rest := pem.Decode(content); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("%s: %w", certPath, err)
+		}
+
+		found = true
+	}
+
+	if !found {
+		return fmt.Errorf("%s: %w", certPath, zerr.ErrNoPEMCertificate)
+	}
+
+	return nil
+}
+
+func checkHTPasswdFile(htpasswdPath string) error {
+	file, err := os.Open(htpasswdPath)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if user, hash, found := strings.Cut(line, ":"); !found || user == "" || hash == "" {
+			return fmt.Errorf("%s line %d: %w", htpasswdPath, lineNumber, zerr.ErrBadHTPasswdEntry)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func checkSyncCredentialsFile(credentialsPath string) error {
+	content, err := os.ReadFile(credentialsPath)
+	if err != nil {
+		return err
+	}
+
+	var credentials syncconf.CredentialsFile
+
+	if err := json.Unmarshal(content, &credentials); err != nil {
+		return fmt.Errorf("%s: %w", credentialsPath, err)
+	}
+
+	return nil
+}
@@ -12,6 +12,8 @@ import (
 	"syscall"
 	"time"
 	"unicode/utf8"
+
+	glob "github.com/bmatcuk/doublestar/v4"
 )
 
 const (
@@ -129,6 +131,18 @@ func ContainsStringIgnoreCase(strSlice []string, str string) bool {
 	return false
 }
 
+// MatchesAnyGlob returns true if the value, e.g. a repository name, matches one of the doublestar glob patterns,
+// the invalid patterns match nothing.
+func MatchesAnyGlob(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, err := glob.Match(pattern, value); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
 // this function will check if tag is a referrers tag
 // (https://github.com/opencontainers/distribution-spec/blob/main/spec.md#referrers-tag-schema).
 func IsReferrersTag(tag string) bool {
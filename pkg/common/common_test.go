@@ -53,6 +53,15 @@ func TestCommon(t *testing.T) {
 		So(isDir, ShouldBeFalse)
 	})
 
+	Convey("test MatchesAnyGlob()", t, func() {
+		patterns := []string{"team/**", "app", "["}
+		So(common.MatchesAnyGlob(patterns, "team/web/api"), ShouldBeTrue)
+		So(common.MatchesAnyGlob(patterns, "app"), ShouldBeTrue)
+		So(common.MatchesAnyGlob(patterns, "application"), ShouldBeFalse)
+		So(common.MatchesAnyGlob(patterns, "["), ShouldBeFalse)
+		So(common.MatchesAnyGlob(nil, "app"), ShouldBeFalse)
+	})
+
 	Convey("Index func", t, func() {
 		So(common.Index([]string{"a", "b"}, "b"), ShouldEqual, 1)
 		So(common.Index([]string{"a", "b"}, "c"), ShouldEqual, -1)
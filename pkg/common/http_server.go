@@ -73,7 +73,7 @@ func AddCORSHeaders(allowOrigin string, response http.ResponseWriter) {
 func AuthzOnlyAdminsMiddleware(conf *config.Config) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
-			if !conf.IsBasicAuthnEnabled() {
+			if !conf.IsBasicAuthnEnabled() && !conf.IsBearerAuthEnabled() {
 				next.ServeHTTP(response, request)
 
 				return
@@ -87,8 +87,8 @@ func AuthzOnlyAdminsMiddleware(conf *config.Config) mux.MiddlewareFunc {
 				return
 			}
 
-			// reject non-admin access if authentication is enabled
-			if userAc != nil && !userAc.IsAdmin() {
+			// reject non-admin access if authentication is enabled, the bearer tokens without a subject included
+			if userAc != nil && (userAc.IsAnonymous() || !userAc.IsAdmin()) {
 				AuthzFail(response, request, userAc.GetUsername(), conf.HTTP.Realm, conf.HTTP.Auth.FailDelay)
 
 				return
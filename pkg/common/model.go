@@ -33,42 +33,44 @@ type PaginatedImagesResult struct {
 }
 
 type ImageSummary struct {
-	RepoName        string                    `json:"repoName"`
-	Tag             string                    `json:"tag"`
-	Digest          string                    `json:"digest"`
-	MediaType       string                    `json:"mediaType"`
-	Manifests       []ManifestSummary         `json:"manifests"`
-	Size            string                    `json:"size"`
-	DownloadCount   int                       `json:"downloadCount"`
-	LastUpdated     time.Time                 `json:"lastUpdated"`
-	Description     string                    `json:"description"`
-	IsSigned        bool                      `json:"isSigned"`
-	Licenses        string                    `json:"licenses"`
-	Labels          string                    `json:"labels"`
-	Title           string                    `json:"title"`
-	Source          string                    `json:"source"`
-	Documentation   string                    `json:"documentation"`
-	Authors         string                    `json:"authors"`
-	Vendor          string                    `json:"vendor"`
-	Vulnerabilities ImageVulnerabilitySummary `json:"vulnerabilities"`
-	Referrers       []Referrer                `json:"referrers"`
-	SignatureInfo   []SignatureSummary        `json:"signatureInfo"`
+	RepoName          string                    `json:"repoName"`
+	Tag               string                    `json:"tag"`
+	Digest            string                    `json:"digest"`
+	MediaType         string                    `json:"mediaType"`
+	Manifests         []ManifestSummary         `json:"manifests"`
+	Size              string                    `json:"size"`
+	DownloadCount     int                       `json:"downloadCount"`
+	LastPullTimestamp *time.Time                `json:"lastPullTimestamp,omitempty" yaml:"lastpulltimestamp,omitempty"`
+	LastUpdated       time.Time                 `json:"lastUpdated"`
+	Description       string                    `json:"description"`
+	IsSigned          bool                      `json:"isSigned"`
+	Licenses          string                    `json:"licenses"`
+	Labels            string                    `json:"labels"`
+	Title             string                    `json:"title"`
+	Source            string                    `json:"source"`
+	Documentation     string                    `json:"documentation"`
+	Authors           string                    `json:"authors"`
+	Vendor            string                    `json:"vendor"`
+	Vulnerabilities   ImageVulnerabilitySummary `json:"vulnerabilities"`
+	Referrers         []Referrer                `json:"referrers"`
+	SignatureInfo     []SignatureSummary        `json:"signatureInfo"`
 }
 
 type ManifestSummary struct {
-	Digest          string                    `json:"digest"`
-	ConfigDigest    string                    `json:"configDigest"`
-	LastUpdated     time.Time                 `json:"lastUpdated"`
-	Size            string                    `json:"size"`
-	Platform        Platform                  `json:"platform"`
-	IsSigned        bool                      `json:"isSigned"`
-	DownloadCount   int                       `json:"downloadCount"`
-	Layers          []LayerSummary            `json:"layers"`
-	History         []LayerHistory            `json:"history"`
-	Vulnerabilities ImageVulnerabilitySummary `json:"vulnerabilities"`
-	Referrers       []Referrer                `json:"referrers"`
-	ArtifactType    string                    `json:"artifactType"`
-	SignatureInfo   []SignatureSummary        `json:"signatureInfo"`
+	Digest            string                    `json:"digest"`
+	ConfigDigest      string                    `json:"configDigest"`
+	LastUpdated       time.Time                 `json:"lastUpdated"`
+	Size              string                    `json:"size"`
+	Platform          Platform                  `json:"platform"`
+	IsSigned          bool                      `json:"isSigned"`
+	DownloadCount     int                       `json:"downloadCount"`
+	LastPullTimestamp *time.Time                `json:"lastPullTimestamp,omitempty" yaml:"lastpulltimestamp,omitempty"`
+	Layers            []LayerSummary            `json:"layers"`
+	History           []LayerHistory            `json:"history"`
+	Vulnerabilities   ImageVulnerabilitySummary `json:"vulnerabilities"`
+	Referrers         []Referrer                `json:"referrers"`
+	ArtifactType      string                    `json:"artifactType"`
+	SignatureInfo     []SignatureSummary        `json:"signatureInfo"`
 }
 
 type SignatureSummary struct {
@@ -99,6 +101,13 @@ type LayerSummary struct {
 	Score  int    `json:"score"`
 }
 
+type LayerUsage struct {
+	Digest          string `json:"digest"`
+	MediaType       string `json:"mediaType"`
+	Size            string `json:"size"`
+	SharedRepoCount int    `json:"sharedRepoCount"`
+}
+
 type LayerHistory struct {
 	Layer              LayerSummary       `json:"layer"`
 	HistoryDescription HistoryDescription `json:"historyDescription"`
@@ -120,6 +129,16 @@ type Referrer struct {
 	Annotations  []Annotation `json:"annotations"`
 }
 
+type RepoEvent struct {
+	Type           string    `json:"type"`
+	Reference      string    `json:"reference"`
+	Digest         string    `json:"digest"`
+	MediaType      string    `json:"mediatype"`
+	PreviousDigest string    `json:"previousdigest"`
+	Actor          string    `json:"actor"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
 type Annotation struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
@@ -197,6 +216,25 @@ type ReferrersResp struct {
 type ReferrersResult struct {
 	Referrers []Referrer `json:"referrers"`
 }
+
+type RepoActivityResp struct {
+	RepoActivityResult `json:"data"`
+	Errors             []ErrorGQL `json:"errors"`
+}
+
+type RepoActivityResult struct {
+	RepoActivity []RepoEvent `json:"repoActivity"`
+}
+
+type ImageLayersResp struct {
+	ImageLayersResult `json:"data"`
+	Errors            []ErrorGQL `json:"errors"`
+}
+
+type ImageLayersResult struct {
+	ImageLayers []LayerUsage `json:"imageLayers"`
+}
+
 type GlobalSearchResultResp struct {
 	GlobalSearchResult `json:"data"`
 	Errors             []ErrorGQL `json:"errors"`
@@ -263,4 +301,16 @@ type BookmarkedReposResponse struct {
 type ImageTags struct {
 	Name string   `json:"name"`
 	Tags []string `json:"tags"`
+	// zot specific, only returned if the details are requested
+	Details []TagDetails `json:"details,omitempty"`
+	Total   int          `json:"total,omitempty"`
+}
+
+// TagDetails is what a client needs to know about a tag to fetch its manifest directly.
+type TagDetails struct {
+	Tag          string    `json:"tag"`
+	Digest       string    `json:"digest"`
+	MediaType    string    `json:"mediaType"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
 }
@@ -479,6 +479,37 @@ func TestNewExporter(t *testing.T) {
 
 					So(isChannelDrained(chMetric), ShouldEqual, true)
 				})
+				Convey("Collecting data: Test that the uploads in flight gauge goes up and down", func() {
+					monitoring.IncUploadsInFlight(serverController.Metrics)
+					monitoring.IncUploadsInFlight(serverController.Metrics)
+					monitoring.DecUploadsInFlight(serverController.Metrics)
+					time.Sleep(SleepTime)
+
+					go func() {
+						// this blocks
+						collector.Collect(chMetric)
+					}()
+
+					// the gauges are collected before the counters
+					var metric dto.Metric
+
+					for _, name := range []string{"zot_up", "zot_scheduler_workers_total", "zot_info"} {
+						pmMetric := <-chMetric
+						So(pmMetric.Desc().String(), ShouldEqual, collector.MetricsDesc[name].String())
+					}
+
+					pmMetric := <-chMetric
+					So(pmMetric.Desc().String(), ShouldEqual, collector.MetricsDesc["zot_http_uploads_in_flight"].String())
+
+					err := pmMetric.Write(&metric)
+					So(err, ShouldBeNil)
+					So(*metric.Gauge.Value, ShouldEqual, 1)
+
+					pmMetric = <-chMetric
+					So(pmMetric.Desc().String(), ShouldEqual, collector.MetricsDesc["zot_scheduler_generators_total"].String())
+
+					So(isChannelDrained(chMetric), ShouldEqual, true)
+				})
 				Convey("Negative testing: Send unknown metric type to MetricServer", func() {
 					serverController.Metrics.SendMetric(getRandomLatency())
 				})
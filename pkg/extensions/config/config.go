@@ -21,12 +21,26 @@ type ExtensionConfig struct {
 	Mgmt    *MgmtConfig
 	APIKey  *APIKeyConfig
 	Trust   *ImageTrustConfig
+	Tracing *TracingConfig
+	Events  *EventsConfig
 }
 
 type ImageTrustConfig struct {
 	BaseConfig `mapstructure:",squash"`
 	Cosign     bool
 	Notation   bool
+	// the first policy matching a repository applies to it
+	Policies []TrustPolicy
+}
+
+// TrustPolicy requires the images of the repositories matching its patterns to be signed by a trusted key,
+// signatures and the other artifacts referring to an image are not concerned.
+type TrustPolicy struct {
+	Repositories []string
+	// the images without a trusted signature are not served
+	EnforceOnPull bool
+	// the tags can only be moved to images with a trusted signature, new tags are still allowed
+	EnforceOnOverwrite bool
 }
 
 type APIKeyConfig struct {
@@ -46,6 +60,13 @@ type SearchConfig struct {
 	BaseConfig `mapstructure:",squash"`
 	// CVE search
 	CVE *CVEConfig
+	// per repository log of the push, pull and delete events
+	Activity *ActivityConfig
+}
+
+type ActivityConfig struct {
+	MaxEvents int           // events kept per repository, default is 100
+	MaxAge    time.Duration // events older than this are dropped, 0 meaning no limit
 }
 
 type CVEConfig struct {
@@ -67,6 +88,35 @@ type PrometheusConfig struct {
 	Path string // default is "/metrics"
 }
 
+type TracingConfig struct {
+	BaseConfig `mapstructure:",squash"`
+	// OTLP/HTTP endpoint the spans are sent to, e.g. "localhost:4318", if not specified
+	// the OTEL_EXPORTER_OTLP_ENDPOINT environment variable or "localhost:4318" is used
+	Endpoint string
+	Insecure bool // send the spans over plain HTTP
+}
+
+type EventsConfig struct {
+	BaseConfig `mapstructure:",squash"`
+	Webhooks   []WebhookConfig
+}
+
+type WebhookConfig struct {
+	URL string
+	// if set, the payloads are signed with HMAC-SHA256 and the signature is sent in the
+	// X-Zot-Signature-256 header as "sha256=<hex digest>"
+	Secret string
+	// events sent to the webhook, among push, delete and tag, all of them if empty
+	Events []string
+	// glob patterns of the repositories whose events are sent, all of them if empty
+	Repositories []string
+	Headers      map[string]string
+	Timeout      time.Duration // default is 10s
+	MaxRetries   *int          // default is 3
+	// delay before the first retry, doubled at each retry, default is 1s
+	RetryDelay *time.Duration
+}
+
 type ScrubConfig struct {
 	BaseConfig `mapstructure:",squash"`
 	Interval   time.Duration
@@ -28,10 +28,13 @@ type RegistryConfig struct {
 	Content      []Content
 	TLSVerify    *bool
 	OnDemand     bool
-	CertDir      string
-	MaxRetries   *int
-	RetryDelay   *time.Duration
-	OnlySigned   *bool
+	/* OnDemandTTL is how long a tag synced on demand is served from the local storage
+	before the registry is asked again if it changed, 0 asks it on every request. */
+	OnDemandTTL time.Duration
+	CertDir     string
+	MaxRetries  *int
+	RetryDelay  *time.Duration
+	OnlySigned  *bool
 }
 
 type Content struct {
@@ -44,4 +47,6 @@ type Content struct {
 type Tags struct {
 	Regex  *string
 	Semver *bool
+	// SemverConstraint keeps the semver tags matching the constraint, like ">= 1.2.0, < 2.0.0".
+	SemverConstraint *string
 }
@@ -0,0 +1,52 @@
+package events
+
+import (
+	"slices"
+	"time"
+)
+
+// the types of the events.
+const (
+	// a manifest was pushed, by tag or by digest.
+	PushEvent = "push"
+	// a manifest was deleted.
+	DeleteEvent = "delete"
+	// a tag which already existed was moved to another manifest.
+	TagEvent = "tag"
+	// a manifest was pulled, only recorded in the activity log of the repository, not sent to the webhooks.
+	PullEvent = "pull"
+)
+
+// Event is the JSON payload sent to the webhooks.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	// the user who made the request, empty for anonymous requests
+	Actor      string `json:"actor,omitempty"`
+	Repository string `json:"repository"`
+	// the tag or the digest given in the request
+	Reference string `json:"reference"`
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType,omitempty"`
+	// the manifest the tag pointed to before a tag event
+	PreviousDigest string `json:"previousDigest,omitempty"`
+}
+
+// Notifier sends the events of the registry to the configured webhooks.
+type Notifier interface {
+	// Notify queues the event, it doesn't wait for it to be sent.
+	Notify(event Event)
+	// Stop sends the events still queued, without retrying the failed ones.
+	Stop()
+}
+
+func IsValidEventType(eventType string) bool {
+	return slices.Contains([]string{PushEvent, DeleteEvent, TagEvent}, eventType)
+}
+
+type noopNotifier struct{}
+
+func (noopNotifier) Notify(Event) {}
+
+func (noopNotifier) Stop() {}
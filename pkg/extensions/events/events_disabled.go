@@ -0,0 +1,23 @@
+//go:build !events
+// +build !events
+
+package events
+
+import (
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/log"
+)
+
+// NewNotifier ...
+func NewNotifier(conf *config.Config, log log.Logger) Notifier {
+	if conf.IsEventsEnabled() {
+		log.Warn().Msg("skipping enabling events extension because given zot binary doesn't include this feature," +
+			"please build a binary that does so")
+	}
+
+	return noopNotifier{}
+}
+
+func IsBuiltWithEventsExtension() bool {
+	return false
+}
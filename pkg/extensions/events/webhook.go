@@ -0,0 +1,281 @@
+//go:build events
+// +build events
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/config"
+	zcommon "zotregistry.dev/zot/pkg/common"
+	extconf "zotregistry.dev/zot/pkg/extensions/config"
+	"zotregistry.dev/zot/pkg/log"
+)
+
+const (
+	// the HMAC-SHA256 signature of the payload, if the webhook has a secret.
+	SignatureHeader = "X-Zot-Signature-256"
+	EventHeader     = "X-Zot-Event"
+	// the id of the event, the same for all the attempts to send it.
+	DeliveryHeader = "X-Zot-Delivery"
+
+	defaultTimeout    = 10 * time.Second
+	defaultMaxRetries = 3
+	defaultRetryDelay = time.Second
+	// the events of a webhook which can't keep up are dropped once its queue is full.
+	queueSize = 1000
+)
+
+type webhookNotifier struct {
+	webhooks []*webhook
+	stopped  bool
+	lock     sync.RWMutex
+	// stops the retries
+	stop chan struct{}
+	wg   sync.WaitGroup
+	log  log.Logger
+}
+
+// NewNotifier starts sending the events to the webhooks of the config, each webhook has its own queue
+// so a slow one doesn't delay the others.
+func NewNotifier(conf *config.Config, log log.Logger) Notifier {
+	if !conf.IsEventsEnabled() {
+		log.Info().Msg("events config not provided, skipping events")
+
+		return noopNotifier{}
+	}
+
+	notifier := &webhookNotifier{
+		stop: make(chan struct{}),
+		log:  log,
+	}
+
+	for _, webhookConfig := range conf.Extensions.Events.Webhooks {
+		webhook := newWebhook(webhookConfig, log)
+		notifier.webhooks = append(notifier.webhooks, webhook)
+
+		notifier.wg.Add(1)
+
+		go func() {
+			defer notifier.wg.Done()
+
+			webhook.run(notifier.stop)
+		}()
+	}
+
+	log.Info().Int("webhooks", len(notifier.webhooks)).Msg("events enabled")
+
+	return notifier
+}
+
+func (notifier *webhookNotifier) Notify(event Event) {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	notifier.lock.RLock()
+	defer notifier.lock.RUnlock()
+
+	if notifier.stopped {
+		return
+	}
+
+	for _, webhook := range notifier.webhooks {
+		if !webhook.accepts(event) {
+			continue
+		}
+
+		select {
+		case webhook.queue <- event:
+		default:
+			notifier.log.Error().Err(zerr.ErrWebhookFailed).Str("url", webhook.config.URL).Str("event", event.Type).
+				Str("repository", event.Repository).Msg("webhook queue is full, dropping the event")
+		}
+	}
+}
+
+func (notifier *webhookNotifier) Stop() {
+	notifier.lock.Lock()
+
+	if notifier.stopped {
+		notifier.lock.Unlock()
+
+		return
+	}
+
+	notifier.stopped = true
+
+	close(notifier.stop)
+
+	for _, webhook := range notifier.webhooks {
+		close(webhook.queue)
+	}
+
+	notifier.lock.Unlock()
+
+	notifier.wg.Wait()
+}
+
+type webhook struct {
+	config     extconf.WebhookConfig
+	client     *http.Client
+	queue      chan Event
+	maxRetries int
+	retryDelay time.Duration
+	log        log.Logger
+}
+
+func newWebhook(config extconf.WebhookConfig, log log.Logger) *webhook {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	maxRetries := defaultMaxRetries
+	if config.MaxRetries != nil {
+		maxRetries = *config.MaxRetries
+	}
+
+	retryDelay := defaultRetryDelay
+	if config.RetryDelay != nil {
+		retryDelay = *config.RetryDelay
+	}
+
+	return &webhook{
+		config:     config,
+		client:     &http.Client{Timeout: timeout},
+		queue:      make(chan Event, queueSize),
+		maxRetries: maxRetries,
+		retryDelay: retryDelay,
+		log:        log,
+	}
+}
+
+// accepts tells if the event passes the filters of the webhook.
+func (webhook *webhook) accepts(event Event) bool {
+	if len(webhook.config.Events) > 0 && !slices.Contains(webhook.config.Events, event.Type) {
+		return false
+	}
+
+	return len(webhook.config.Repositories) == 0 ||
+		zcommon.MatchesAnyGlob(webhook.config.Repositories, event.Repository)
+}
+
+func (webhook *webhook) run(stop <-chan struct{}) {
+	for event := range webhook.queue {
+		webhook.deliver(event, stop)
+	}
+}
+
+// deliver sends the event, retrying with an exponential backoff if the webhook can't be reached
+// or answers with a server error.
+func (webhook *webhook) deliver(event Event, stop <-chan struct{}) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		webhook.log.Error().Err(err).Str("event", event.Type).Msg("failed to marshal event")
+
+		return
+	}
+
+	delay := webhook.retryDelay
+
+	for attempt := 0; ; attempt++ {
+		retry, err := webhook.send(event, body)
+		if err == nil {
+			webhook.log.Debug().Str("url", webhook.config.URL).Str("event", event.Type).Str("id", event.ID).
+				Str("repository", event.Repository).Msg("event sent to webhook")
+
+			return
+		}
+
+		if !retry || attempt >= webhook.maxRetries {
+			webhook.log.Error().Err(err).Str("url", webhook.config.URL).Str("event", event.Type).Str("id", event.ID).
+				Str("repository", event.Repository).Int("attempts", attempt+1).Msg("failed to send event to webhook")
+
+			return
+		}
+
+		webhook.log.Warn().Err(err).Str("url", webhook.config.URL).Str("event", event.Type).Str("id", event.ID).
+			Dur("retryIn", delay).Msg("failed to send event to webhook, retrying")
+
+		select {
+		case <-stop:
+			webhook.log.Error().Err(err).Str("url", webhook.config.URL).Str("event", event.Type).Str("id", event.ID).
+				Msg("failed to send event to webhook, not retrying because zot is stopping")
+
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+}
+
+// send posts the event once, it returns whether sending it again may succeed if it failed.
+func (webhook *webhook) send(event Event, body []byte) (bool, error) {
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodPost, webhook.config.URL,
+		bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	for name, value := range webhook.config.Headers {
+		request.Header.Set(name, value)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, event.Type)
+	request.Header.Set(DeliveryHeader, event.ID)
+
+	if webhook.config.Secret != "" {
+		request.Header.Set(SignatureHeader, Sign(webhook.config.Secret, body))
+	}
+
+	response, err := webhook.client.Do(request)
+	if err != nil {
+		return true, err
+	}
+
+	_, _ = io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+
+	if response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusMultipleChoices {
+		return false, nil
+	}
+
+	err = fmt.Errorf("%w: %s", zerr.ErrWebhookFailed, response.Status)
+
+	return response.StatusCode >= http.StatusInternalServerError || response.StatusCode == http.StatusTooManyRequests,
+		err
+}
+
+// Sign returns the signature of the payload sent in the X-Zot-Signature-256 header,
+// receivers compute it with their copy of the secret to check the payload comes from zot.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func IsBuiltWithEventsExtension() bool {
+	return true
+}
@@ -0,0 +1,233 @@
+//go:build events
+// +build events
+
+package events_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/resty.v1"
+
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	extconf "zotregistry.dev/zot/pkg/extensions/config"
+	"zotregistry.dev/zot/pkg/extensions/events"
+	"zotregistry.dev/zot/pkg/log"
+	test "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+// webhookReceiver keeps the events it's sent, after failing the given number of requests.
+type webhookReceiver struct {
+	lock     sync.Mutex
+	failures int
+	attempts int
+	events   []events.Event
+	headers  []http.Header
+	bodies   [][]byte
+}
+
+func (receiver *webhookReceiver) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+
+	receiver.attempts++
+
+	if receiver.failures > 0 {
+		receiver.failures--
+		response.WriteHeader(http.StatusServiceUnavailable)
+
+		return
+	}
+
+	body, _ := io.ReadAll(request.Body)
+
+	event := events.Event{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	receiver.events = append(receiver.events, event)
+	receiver.headers = append(receiver.headers, request.Header)
+	receiver.bodies = append(receiver.bodies, body)
+}
+
+func (receiver *webhookReceiver) received() []events.Event {
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+
+	return append([]events.Event{}, receiver.events...)
+}
+
+func newEventsConfig(webhooks ...extconf.WebhookConfig) *config.Config {
+	enable := true
+
+	conf := config.New()
+	conf.Extensions = &extconf.ExtensionConfig{
+		Events: &extconf.EventsConfig{BaseConfig: extconf.BaseConfig{Enable: &enable}, Webhooks: webhooks},
+	}
+
+	return conf
+}
+
+func TestWebhooks(t *testing.T) {
+	Convey("Events are sent to the webhooks matching them", t, func() {
+		ciReceiver := &webhookReceiver{}
+		ciServer := httptest.NewServer(ciReceiver)
+		defer ciServer.Close()
+
+		auditReceiver := &webhookReceiver{}
+		auditServer := httptest.NewServer(auditReceiver)
+		defer auditServer.Close()
+
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := newEventsConfig(
+			extconf.WebhookConfig{
+				URL: ciServer.URL, Secret: "secret", Events: []string{events.PushEvent, events.TagEvent},
+				Repositories: []string{"apps/**"}, Headers: map[string]string{"Authorization": "Bearer token"},
+			},
+			extconf.WebhookConfig{URL: auditServer.URL},
+		)
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+
+		ctlr := api.NewController(conf)
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+
+		image := CreateRandomImage()
+		err := UploadImage(image, baseURL, "apps/web", "latest")
+		So(err, ShouldBeNil)
+
+		updatedImage := CreateRandomImage()
+		err = UploadImage(updatedImage, baseURL, "apps/web", "latest")
+		So(err, ShouldBeNil)
+
+		err = UploadImage(CreateRandomImage(), baseURL, "tools/cli", "1.0")
+		So(err, ShouldBeNil)
+
+		resp, err := resty.R().Delete(baseURL + "/v2/apps/web/manifests/latest")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		// the events still queued are sent when the server stops
+		cm.StopServer()
+
+		ciEvents := ciReceiver.received()
+		So(len(ciEvents), ShouldEqual, 3)
+
+		So(ciEvents[0].Type, ShouldEqual, events.PushEvent)
+		So(ciEvents[0].Repository, ShouldEqual, "apps/web")
+		So(ciEvents[0].Reference, ShouldEqual, "latest")
+		So(ciEvents[0].Digest, ShouldEqual, image.DigestStr())
+		So(ciEvents[0].ID, ShouldNotBeEmpty)
+		So(ciEvents[0].Timestamp.IsZero(), ShouldBeFalse)
+
+		So(ciEvents[1].Type, ShouldEqual, events.PushEvent)
+		So(ciEvents[1].Digest, ShouldEqual, updatedImage.DigestStr())
+
+		So(ciEvents[2].Type, ShouldEqual, events.TagEvent)
+		So(ciEvents[2].Digest, ShouldEqual, updatedImage.DigestStr())
+		So(ciEvents[2].PreviousDigest, ShouldEqual, image.DigestStr())
+
+		for id, header := range ciReceiver.headers {
+			So(header.Get(events.SignatureHeader), ShouldEqual, events.Sign("secret", ciReceiver.bodies[id]))
+			So(header.Get(events.EventHeader), ShouldEqual, ciEvents[id].Type)
+			So(header.Get(events.DeliveryHeader), ShouldEqual, ciEvents[id].ID)
+			So(header.Get("Authorization"), ShouldEqual, "Bearer token")
+		}
+
+		// no filters, no signature
+		auditEvents := auditReceiver.received()
+		So(len(auditEvents), ShouldEqual, 5)
+		So(auditEvents[3].Type, ShouldEqual, events.PushEvent)
+		So(auditEvents[3].Repository, ShouldEqual, "tools/cli")
+		So(auditEvents[4].Type, ShouldEqual, events.DeleteEvent)
+		So(auditEvents[4].Reference, ShouldEqual, "latest")
+		So(auditEvents[4].Digest, ShouldEqual, updatedImage.DigestStr())
+		So(auditReceiver.headers[0].Get(events.SignatureHeader), ShouldBeEmpty)
+	})
+
+	Convey("Failed deliveries are retried", t, func() {
+		receiver := &webhookReceiver{failures: 2}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+
+		retryDelay := 10 * time.Millisecond
+		maxRetries := 2
+
+		notifier := events.NewNotifier(newEventsConfig(extconf.WebhookConfig{
+			URL: server.URL, RetryDelay: &retryDelay, MaxRetries: &maxRetries,
+		}), log.NewLogger("debug", ""))
+
+		notifier.Notify(events.Event{Type: events.PushEvent, Repository: "repo", Reference: "tag"})
+
+		// the retries are given up once the notifier is stopped
+		for i := 0; i < 100 && len(receiver.received()) == 0; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		So(len(receiver.received()), ShouldEqual, 1)
+
+		receiver.lock.Lock()
+		So(receiver.attempts, ShouldEqual, 3)
+		receiver.lock.Unlock()
+
+		notifier.Stop()
+
+		// the events are dropped once the notifier is stopped
+		notifier.Notify(events.Event{Type: events.PushEvent, Repository: "repo", Reference: "tag"})
+		So(len(receiver.received()), ShouldEqual, 1)
+	})
+
+	Convey("Events rejected by the webhook are not retried", t, func() {
+		var attempts atomic.Int32
+
+		server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			attempts.Add(1)
+
+			response.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		retryDelay := time.Millisecond
+
+		notifier := events.NewNotifier(newEventsConfig(extconf.WebhookConfig{
+			URL: server.URL, RetryDelay: &retryDelay,
+		}), log.NewLogger("debug", ""))
+
+		notifier.Notify(events.Event{Type: events.DeleteEvent, Repository: "repo", Reference: "tag"})
+
+		for i := 0; i < 100 && attempts.Load() == 0; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		// there would have been retries by now
+		time.Sleep(100 * time.Millisecond)
+
+		notifier.Stop()
+
+		So(attempts.Load(), ShouldEqual, 1)
+	})
+
+	Convey("Events are not sent if the extension is disabled", t, func() {
+		conf := newEventsConfig()
+		conf.Extensions.Events = nil
+
+		notifier := events.NewNotifier(conf, log.NewLogger("debug", ""))
+		notifier.Notify(events.Event{Type: events.PushEvent})
+		notifier.Stop()
+	})
+}
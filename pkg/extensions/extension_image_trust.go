@@ -4,17 +4,26 @@
 package extensions
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	oras "github.com/oras-project/artifacts-spec/specs-go/v1"
 
 	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api/config"
 	"zotregistry.dev/zot/pkg/api/constants"
 	zcommon "zotregistry.dev/zot/pkg/common"
+	extconf "zotregistry.dev/zot/pkg/extensions/config"
 	"zotregistry.dev/zot/pkg/extensions/imagetrust"
 	"zotregistry.dev/zot/pkg/log"
 	mTypes "zotregistry.dev/zot/pkg/meta/types"
@@ -191,3 +200,122 @@ func SetupImageTrustExtension(conf *config.Config, metaDB mTypes.MetaDB, log log
 
 	return nil
 }
+
+// CheckPullTrustPolicy returns zerr.ErrImageNotTrusted if the trust policy of the repository
+// doesn't allow serving the manifest.
+func CheckPullTrustPolicy(ctx context.Context, conf *config.Config, metaDB mTypes.MetaDB, repo, reference string,
+	digest godigest.Digest, mediaType string, content []byte,
+) error {
+	policy, ok := getTrustPolicy(conf, repo)
+	if !ok || !policy.EnforceOnPull || isTrustPolicyExempt(reference, mediaType, content) {
+		return nil
+	}
+
+	return checkImageTrusted(ctx, metaDB, repo, digest)
+}
+
+// CheckOverwriteTrustPolicy returns zerr.ErrImageNotTrusted if the trust policy of the repository
+// doesn't allow moving the tag to the manifest.
+func CheckOverwriteTrustPolicy(ctx context.Context, conf *config.Config, metaDB mTypes.MetaDB, repo, tag string,
+	digest godigest.Digest, mediaType string, content []byte,
+) error {
+	policy, ok := getTrustPolicy(conf, repo)
+	if !ok || !policy.EnforceOnOverwrite || isTrustPolicyExempt(tag, mediaType, content) {
+		return nil
+	}
+
+	return checkImageTrusted(ctx, metaDB, repo, digest)
+}
+
+// CheckImageTrusted returns zerr.ErrImageNotTrusted if the image has no signature verified with a trusted key,
+// whatever the trust policies.
+func CheckImageTrusted(ctx context.Context, metaDB mTypes.MetaDB, repo string, digest godigest.Digest) error {
+	return checkImageTrusted(ctx, metaDB, repo, digest)
+}
+
+func getTrustPolicy(conf *config.Config, repo string) (extconf.TrustPolicy, bool) {
+	if !conf.IsTrustPolicyEnabled() {
+		return extconf.TrustPolicy{}, false
+	}
+
+	for _, policy := range conf.Extensions.Trust.Policies {
+		if zcommon.MatchesAnyGlob(policy.Repositories, repo) {
+			return policy, true
+		}
+	}
+
+	return extconf.TrustPolicy{}, false
+}
+
+// isTrustPolicyExempt tells if the manifest is a signature or another artifact referring to an image,
+// those can't be signed themselves.
+func isTrustPolicyExempt(reference, mediaType string, content []byte) bool {
+	// the tags of the cosign signatures, attestations and sboms, and the referrers tag schema
+	if strings.HasPrefix(reference, "sha256-") || mediaType == oras.MediaTypeArtifactManifest {
+		return true
+	}
+
+	var manifest struct {
+		Subject *ispec.Descriptor `json:"subject,omitempty"`
+	}
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return false
+	}
+
+	return manifest.Subject != nil
+}
+
+// checkImageTrusted returns zerr.ErrImageNotTrusted if the image has no trusted signature, the manifests
+// of a multiarch image are trusted if one of the tagged indexes listing them is.
+func checkImageTrusted(ctx context.Context, metaDB mTypes.MetaDB, repo string, digest godigest.Digest) error {
+	notTrustedErr := fmt.Errorf("%w: %s@%s", zerr.ErrImageNotTrusted, repo, digest)
+
+	if metaDB == nil {
+		return notTrustedErr
+	}
+
+	repoMeta, err := metaDB.GetRepoMeta(ctx, repo)
+	if err != nil {
+		return notTrustedErr
+	}
+
+	if hasTrustedSignature(repoMeta.Signatures[digest.String()]) {
+		return nil
+	}
+
+	for _, descriptor := range repoMeta.Tags {
+		if descriptor.MediaType != ispec.MediaTypeImageIndex ||
+			!hasTrustedSignature(repoMeta.Signatures[descriptor.Digest]) {
+			continue
+		}
+
+		imageMeta, err := metaDB.GetImageMeta(godigest.Digest(descriptor.Digest))
+		if err != nil || imageMeta.Index == nil {
+			continue
+		}
+
+		if slices.ContainsFunc(imageMeta.Index.Manifests, func(manifest ispec.Descriptor) bool {
+			return manifest.Digest == digest
+		}) {
+			return nil
+		}
+	}
+
+	return notTrustedErr
+}
+
+// hasTrustedSignature tells if one of the signatures was verified with a trusted key which hasn't expired.
+func hasTrustedSignature(signatures mTypes.ManifestSignatures) bool {
+	for _, signaturesOfType := range signatures {
+		for _, signature := range signaturesOfType {
+			for _, layer := range signature.LayersInfo {
+				if layer.Signer != "" && (layer.Date.IsZero() || time.Now().Before(layer.Date)) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
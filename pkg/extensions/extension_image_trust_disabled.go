@@ -4,8 +4,13 @@
 package extensions
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/gorilla/mux"
+	godigest "github.com/opencontainers/go-digest"
 
+	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api/config"
 	"zotregistry.dev/zot/pkg/log"
 	mTypes "zotregistry.dev/zot/pkg/meta/types"
@@ -34,3 +39,20 @@ func SetupImageTrustExtension(conf *config.Config, metaDB mTypes.MetaDB, log log
 
 	return nil
 }
+
+func CheckPullTrustPolicy(ctx context.Context, conf *config.Config, metaDB mTypes.MetaDB, repo, reference string,
+	digest godigest.Digest, mediaType string, content []byte,
+) error {
+	return nil
+}
+
+func CheckOverwriteTrustPolicy(ctx context.Context, conf *config.Config, metaDB mTypes.MetaDB, repo, tag string,
+	digest godigest.Digest, mediaType string, content []byte,
+) error {
+	return nil
+}
+
+// CheckImageTrusted can't verify the signatures without the image trust extension, no image is trusted.
+func CheckImageTrusted(ctx context.Context, metaDB mTypes.MetaDB, repo string, digest godigest.Digest) error {
+	return fmt.Errorf("%w: %s@%s", zerr.ErrImageNotTrusted, repo, digest)
+}
@@ -6,6 +6,7 @@ package extensions_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,12 +18,15 @@ import (
 	"time"
 
 	guuid "github.com/gofrs/uuid"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sigstore/cosign/v2/cmd/cosign/cli/generate"
 	"github.com/sigstore/cosign/v2/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/v2/cmd/cosign/cli/sign"
 	. "github.com/smartystreets/goconvey/convey"
 	"gopkg.in/resty.v1"
 
+	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api"
 	"zotregistry.dev/zot/pkg/api/config"
 	"zotregistry.dev/zot/pkg/api/constants"
@@ -31,10 +35,12 @@ import (
 	extconf "zotregistry.dev/zot/pkg/extensions/config"
 	"zotregistry.dev/zot/pkg/extensions/monitoring"
 	"zotregistry.dev/zot/pkg/log"
+	mTypes "zotregistry.dev/zot/pkg/meta/types"
 	"zotregistry.dev/zot/pkg/storage"
 	"zotregistry.dev/zot/pkg/storage/local"
 	test "zotregistry.dev/zot/pkg/test/common"
 	. "zotregistry.dev/zot/pkg/test/image-utils"
+	"zotregistry.dev/zot/pkg/test/mocks"
 	"zotregistry.dev/zot/pkg/test/signature"
 	tskip "zotregistry.dev/zot/pkg/test/skip"
 )
@@ -1001,3 +1007,124 @@ func RunSignatureUploadAndVerificationTests(t *testing.T, cacheDriverParams map[
 		So(resp.StatusCode(), ShouldEqual, http.StatusInternalServerError)
 	})
 }
+
+func TestTrustPolicies(t *testing.T) {
+	defaultValue := true
+
+	newConfig := func() *config.Config {
+		conf := config.New()
+		conf.Extensions = &extconf.ExtensionConfig{
+			Trust: &extconf.ImageTrustConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultValue},
+				Cosign:     true,
+				Policies: []extconf.TrustPolicy{
+					{Repositories: []string{"prod/**"}, EnforceOnPull: true, EnforceOnOverwrite: true},
+				},
+			},
+		}
+
+		return conf
+	}
+
+	Convey("Trust policies", t, func() {
+		conf := newConfig()
+		ctx := context.Background()
+
+		image := CreateRandomImage()
+		imageBlob, err := json.Marshal(image.Manifest)
+		So(err, ShouldBeNil)
+
+		multiarch := CreateMultiarchWith().Images([]Image{image}).Build()
+
+		trustedSignature := mTypes.ManifestSignatures{zcommon.CosignSignature: []mTypes.SignatureInfo{
+			{LayersInfo: []mTypes.LayerInfo{{Signer: "key"}}},
+		}}
+		expiredSignature := mTypes.ManifestSignatures{zcommon.CosignSignature: []mTypes.SignatureInfo{
+			{LayersInfo: []mTypes.LayerInfo{{Signer: "key", Date: time.Now().Add(-time.Hour)}}},
+		}}
+
+		repoMeta := mTypes.RepoMeta{Name: "prod/app", Signatures: map[string]mTypes.ManifestSignatures{}}
+		metaDB := mocks.MetaDBMock{
+			GetRepoMetaFn: func(ctx context.Context, repo string) (mTypes.RepoMeta, error) {
+				return repoMeta, nil
+			},
+			GetImageMetaFn: func(digest godigest.Digest) (mTypes.ImageMeta, error) {
+				return mTypes.ImageMeta{Index: &multiarch.Index}, nil
+			},
+		}
+
+		checkPull := func(repo, reference string, content []byte) error {
+			return extensions.CheckPullTrustPolicy(ctx, conf, metaDB, repo, reference, image.Digest(),
+				ispec.MediaTypeImageManifest, content)
+		}
+
+		So(errors.Is(checkPull("prod/app", "1.0", imageBlob), zerr.ErrImageNotTrusted), ShouldBeTrue)
+		So(errors.Is(extensions.CheckOverwriteTrustPolicy(ctx, conf, metaDB, "prod/app", "1.0", image.Digest(),
+			ispec.MediaTypeImageManifest, imageBlob), zerr.ErrImageNotTrusted), ShouldBeTrue)
+
+		// the repositories without a policy
+		So(checkPull("dev/app", "1.0", imageBlob), ShouldBeNil)
+
+		// the signatures and the other artifacts referring to an image
+		So(checkPull("prod/app", "sha256-"+image.Digest().Encoded()+".sig", imageBlob), ShouldBeNil)
+
+		referrer := CreateMockNotationSignature(image.DescriptorRef())
+		referrerBlob, err := json.Marshal(referrer.Manifest)
+		So(err, ShouldBeNil)
+		So(checkPull("prod/app", referrer.DigestStr(), referrerBlob), ShouldBeNil)
+
+		repoMeta.Signatures[image.DigestStr()] = expiredSignature
+		So(errors.Is(checkPull("prod/app", "1.0", imageBlob), zerr.ErrImageNotTrusted), ShouldBeTrue)
+
+		repoMeta.Signatures[image.DigestStr()] = trustedSignature
+		So(checkPull("prod/app", "1.0", imageBlob), ShouldBeNil)
+
+		// the manifests of a signed multiarch image
+		delete(repoMeta.Signatures, image.DigestStr())
+		repoMeta.Tags = map[string]mTypes.Descriptor{
+			"multiarch": {Digest: multiarch.DigestStr(), MediaType: ispec.MediaTypeImageIndex},
+		}
+		So(errors.Is(checkPull("prod/app", image.DigestStr(), imageBlob), zerr.ErrImageNotTrusted), ShouldBeTrue)
+
+		repoMeta.Signatures[multiarch.DigestStr()] = trustedSignature
+		So(checkPull("prod/app", image.DigestStr(), imageBlob), ShouldBeNil)
+
+		// without enforcement
+		conf.Extensions.Trust.Policies[0].EnforceOnPull = false
+		repoMeta.Signatures = map[string]mTypes.ManifestSignatures{}
+		So(checkPull("prod/app", "1.0", imageBlob), ShouldBeNil)
+	})
+
+	Convey("Trust policies on the manifests served and pushed", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := newConfig()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+
+		ctlr := api.NewController(conf)
+		ctlrManager := test.NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		image := CreateRandomImage()
+		So(UploadImage(image, baseURL, "prod/app", "1.0"), ShouldBeNil)
+		So(UploadImage(image, baseURL, "dev/app", "1.0"), ShouldBeNil)
+
+		resp, err := resty.R().Get(baseURL + "/v2/prod/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().Get(baseURL + "/v2/dev/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		// new tags are allowed, moving the existing ones to an image without a trusted signature isn't
+		So(UploadImage(CreateRandomImage(), baseURL, "prod/app", "2.0"), ShouldBeNil)
+		So(UploadImage(image, baseURL, "prod/app", "latest"), ShouldBeNil)
+		So(UploadImage(image, baseURL, "prod/app", "latest"), ShouldBeNil)
+		So(UploadImage(CreateRandomImage(), baseURL, "prod/app", "latest"), ShouldNotBeNil)
+		So(UploadImage(CreateRandomImage(), baseURL, "dev/app", "1.0"), ShouldBeNil)
+	})
+}
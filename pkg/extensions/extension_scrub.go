@@ -4,9 +4,16 @@
 package extensions
 
 import (
+	"encoding/json"
+	"net/http"
 	"time"
 
+	"github.com/gorilla/mux"
+
 	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/api/constants"
+	zcommon "zotregistry.dev/zot/pkg/common"
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
 	"zotregistry.dev/zot/pkg/extensions/scrub"
 	"zotregistry.dev/zot/pkg/log"
 	"zotregistry.dev/zot/pkg/scheduler"
@@ -14,9 +21,9 @@ import (
 	storageTypes "zotregistry.dev/zot/pkg/storage/types"
 )
 
-// EnableScrubExtension enables scrub extension.
+// EnableScrubExtension enables scrub extension, the results are kept in the report and counted in the metrics.
 func EnableScrubExtension(config *config.Config, log log.Logger, storeController storage.StoreController,
-	sch *scheduler.Scheduler,
+	sch *scheduler.Scheduler, report *storage.ScrubReport, metrics monitoring.MetricServer,
 ) {
 	if config.Extensions.Scrub != nil &&
 		*config.Extensions.Scrub.Enable {
@@ -28,28 +35,55 @@ func EnableScrubExtension(config *config.Config, log log.Logger, storeController
 			log.Warn().Msg("scrub interval set to too-short interval < 2h, changing scrub duration to 2 hours and continuing.") //nolint:lll // gofumpt conflicts with lll
 		}
 
-		generator := &taskGenerator{
-			imgStore: storeController.DefaultStore,
-			log:      log,
-		}
-		sch.SubmitGenerator(generator, config.Extensions.Scrub.Interval, scheduler.LowPriority)
-
-		if config.Storage.SubPaths != nil {
-			for route := range config.Storage.SubPaths {
-				generator := &taskGenerator{
-					imgStore: storeController.SubStore[route],
-					log:      log,
-				}
-				sch.SubmitGenerator(generator, config.Extensions.Scrub.Interval, scheduler.LowPriority)
-			}
-		}
+		submitScrubGenerators(config, log, storeController, sch, report, metrics, config.Extensions.Scrub.Interval)
 	} else {
 		log.Info().Msg("scrub config not provided, skipping scrub")
 	}
 }
 
+// RunScrub starts a scrub of every store, regardless of the scrub interval.
+// It returns false if scrub isn't enabled.
+func RunScrub(config *config.Config, log log.Logger, storeController storage.StoreController,
+	sch *scheduler.Scheduler, report *storage.ScrubReport, metrics monitoring.MetricServer,
+) bool {
+	if config.Extensions == nil || config.Extensions.Scrub == nil || !*config.Extensions.Scrub.Enable {
+		return false
+	}
+
+	submitScrubGenerators(config, log, storeController, sch, report, metrics, time.Duration(0))
+
+	return true
+}
+
+// submitScrubGenerators submits the generators scrubbing the stores every interval, or once if it's 0.
+func submitScrubGenerators(config *config.Config, log log.Logger, storeController storage.StoreController,
+	sch *scheduler.Scheduler, report *storage.ScrubReport, metrics monitoring.MetricServer, interval time.Duration,
+) {
+	generator := &taskGenerator{
+		imgStore: storeController.DefaultStore,
+		report:   report,
+		metrics:  metrics,
+		log:      log,
+	}
+	sch.SubmitGenerator(generator, interval, scheduler.LowPriority)
+
+	if config.Storage.SubPaths != nil {
+		for route := range config.Storage.SubPaths {
+			generator := &taskGenerator{
+				imgStore: storeController.SubStore[route],
+				report:   report,
+				metrics:  metrics,
+				log:      log,
+			}
+			sch.SubmitGenerator(generator, interval, scheduler.LowPriority)
+		}
+	}
+}
+
 type taskGenerator struct {
 	imgStore storageTypes.ImageStore
+	report   *storage.ScrubReport
+	metrics  monitoring.MetricServer
 	log      log.Logger
 	lastRepo string
 	done     bool
@@ -73,7 +107,7 @@ func (gen *taskGenerator) Next() (scheduler.Task, error) {
 
 	gen.lastRepo = repo
 
-	return scrub.NewTask(gen.imgStore, repo, gen.log), nil
+	return scrub.NewTask(gen.imgStore, repo, gen.report, gen.metrics, gen.log), nil
 }
 
 func (gen *taskGenerator) IsDone() bool {
@@ -88,3 +122,48 @@ func (gen *taskGenerator) Reset() {
 	gen.lastRepo = ""
 	gen.done = false
 }
+
+func SetupScrubRoutes(config *config.Config, router *mux.Router, report *storage.ScrubReport, log log.Logger) {
+	if config.Extensions == nil || config.Extensions.Scrub == nil || !*config.Extensions.Scrub.Enable {
+		log.Info().Msg("skip enabling the scrub route as the config prerequisites are not met")
+
+		return
+	}
+
+	log.Info().Msg("setting up scrub routes")
+
+	scrubStatus := ScrubStatus{Report: report, Log: log}
+
+	// the results name every repository and their broken blobs, only admins can read them
+	scrubRouter := router.PathPrefix(constants.ExtScrub).Subrouter()
+	scrubRouter.Use(zcommon.AddExtensionSecurityHeaders())
+	scrubRouter.Use(zcommon.AuthzOnlyAdminsMiddleware(config))
+	scrubRouter.Methods(http.MethodGet).HandlerFunc(scrubStatus.HandleGetStatus)
+
+	log.Info().Msg("finished setting up scrub routes")
+}
+
+type ScrubStatus struct {
+	Report *storage.ScrubReport
+	Log    log.Logger
+}
+
+// scrubHandler godoc
+// @Summary Get the results of the periodic scrub
+// @Description Get the result of the last scrub of each repository, only admins can read them
+// @Router  /v2/_zot/ext/scrub [get]
+// @Produce json
+// @Success 200 {object}   storage.ScrubStatus
+// @Failure 500 {string}   string   "internal server error".
+func (scrubStatus *ScrubStatus) HandleGetStatus(w http.ResponseWriter, r *http.Request) {
+	buf, err := json.Marshal(scrubStatus.Report.Status())
+	if err != nil {
+		scrubStatus.Log.Error().Err(err).Str("component", "scrub").Msg("failed to marshal scrub status response")
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", constants.DefaultMediaType)
+	_, _ = w.Write(buf)
+}
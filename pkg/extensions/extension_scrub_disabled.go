@@ -4,7 +4,10 @@
 package extensions
 
 import (
+	"github.com/gorilla/mux"
+
 	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
 	"zotregistry.dev/zot/pkg/log"
 	"zotregistry.dev/zot/pkg/scheduler"
 	"zotregistry.dev/zot/pkg/storage"
@@ -12,8 +15,20 @@ import (
 
 // EnableScrubExtension ...
 func EnableScrubExtension(config *config.Config, log log.Logger, storeController storage.StoreController,
-	sch *scheduler.Scheduler,
+	sch *scheduler.Scheduler, report *storage.ScrubReport, metrics monitoring.MetricServer,
 ) {
 	log.Warn().Msg("skipping enabling scrub extension because given zot binary doesn't include this feature," +
 		"please build a binary that does so")
 }
+
+// RunScrub ...
+func RunScrub(config *config.Config, log log.Logger, storeController storage.StoreController,
+	sch *scheduler.Scheduler, report *storage.ScrubReport, metrics monitoring.MetricServer,
+) bool {
+	return false
+}
+
+func SetupScrubRoutes(config *config.Config, router *mux.Router, report *storage.ScrubReport, log log.Logger) {
+	log.Warn().Msg("skipping setting up scrub routes because given zot binary doesn't include this feature," +
+		"please build a binary that does so")
+}
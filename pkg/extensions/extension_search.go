@@ -4,17 +4,20 @@
 package extensions
 
 import (
+	"context"
 	"net/http"
 	"time"
 
 	gqlHandler "github.com/99designs/gqlgen/graphql/handler"
 	"github.com/gorilla/mux"
 
+	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api/config"
 	"zotregistry.dev/zot/pkg/api/constants"
 	zcommon "zotregistry.dev/zot/pkg/common"
 	"zotregistry.dev/zot/pkg/extensions/search"
 	cveinfo "zotregistry.dev/zot/pkg/extensions/search/cve"
+	cvemodel "zotregistry.dev/zot/pkg/extensions/search/cve/model"
 	"zotregistry.dev/zot/pkg/extensions/search/gql_generated"
 	"zotregistry.dev/zot/pkg/log"
 	mTypes "zotregistry.dev/zot/pkg/meta/types"
@@ -72,6 +75,41 @@ func startScanner(interval time.Duration, metaDB mTypes.MetaDB, sch *scheduler.S
 	sch.SubmitGenerator(generator, interval, scheduler.MediumPriority)
 }
 
+// ScanPushedImage schedules the cve scan of a pushed image, the periodic scan only looks for
+// the images not scanned yet every 15 minutes.
+func ScanPushedImage(conf *config.Config, taskScheduler *scheduler.Scheduler, cveScanner CveScanner,
+	repo, digest string, log log.Logger,
+) {
+	if !conf.IsCveScanningEnabled() || taskScheduler == nil || cveScanner == nil {
+		return
+	}
+
+	taskScheduler.SubmitTask(cveinfo.NewScanTask(cveScanner, repo, digest, log), scheduler.MediumPriority)
+}
+
+// GetImageMaxSeverity returns the highest severity of the CVEs of the image, NONE if it has none.
+// The image is scanned if it wasn't already.
+func GetImageMaxSeverity(ctx context.Context, cveScanner CveScanner, repo, digest string) (string, error) {
+	if cveScanner == nil {
+		return "", zerr.ErrCVESearchDisabled
+	}
+
+	cveMap, err := cveScanner.ScanImage(ctx, zcommon.GetFullImageName(repo, digest))
+	if err != nil {
+		return "", err
+	}
+
+	maxSeverity := cvemodel.SeverityNone
+
+	for _, cve := range cveMap {
+		if cvemodel.CompareSeverities(maxSeverity, cve.Severity) > 0 {
+			maxSeverity = cve.Severity
+		}
+	}
+
+	return maxSeverity, nil
+}
+
 func SetupSearchRoutes(conf *config.Config, router *mux.Router, storeController storage.StoreController,
 	metaDB mTypes.MetaDB, cveScanner CveScanner, log log.Logger,
 ) {
@@ -4,8 +4,11 @@
 package extensions
 
 import (
+	"context"
+
 	"github.com/gorilla/mux"
 
+	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api/config"
 	"zotregistry.dev/zot/pkg/log"
 	mTypes "zotregistry.dev/zot/pkg/meta/types"
@@ -33,6 +36,12 @@ func EnableSearchExtension(config *config.Config, storeController storage.StoreC
 		"please build a binary that does so")
 }
 
+// ScanPushedImage ...
+func ScanPushedImage(config *config.Config, taskScheduler *scheduler.Scheduler, cveScanner CveScanner,
+	repo, digest string, log log.Logger,
+) {
+}
+
 // SetupSearchRoutes ...
 func SetupSearchRoutes(config *config.Config, router *mux.Router, storeController storage.StoreController,
 	metaDB mTypes.MetaDB, cveScanner CveScanner, log log.Logger,
@@ -40,3 +49,8 @@ func SetupSearchRoutes(config *config.Config, router *mux.Router, storeControlle
 	log.Warn().Msg("skipping setting up search routes because given zot binary doesn't include this feature," +
 		"please build a binary that does so")
 }
+
+// GetImageMaxSeverity can't scan the image without the search extension.
+func GetImageMaxSeverity(ctx context.Context, cveScanner CveScanner, repo, digest string) (string, error) {
+	return "", zerr.ErrCVESearchDisabled
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/url"
 	"strings"
+	"time"
 
 	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api/config"
@@ -26,16 +27,8 @@ func EnableSyncExtension(config *config.Config, metaDB mTypes.MetaDB,
 
 		for _, registryConfig := range config.Extensions.Sync.Registries {
 			registryConfig := registryConfig
-			if len(registryConfig.URLs) > 1 {
-				if err := removeSelfURLs(config, &registryConfig, log); err != nil {
-					return nil, err
-				}
-			}
-
-			if len(registryConfig.URLs) == 0 {
-				log.Error().Err(zerr.ErrSyncNoURLsLeft).Msg("failed to start sync extension")
-
-				return nil, zerr.ErrSyncNoURLsLeft
+			if err := selectSyncURLs(config, &registryConfig, log); err != nil {
+				return nil, err
 			}
 
 			isPeriodical := len(registryConfig.Content) != 0 && registryConfig.PollInterval != 0
@@ -45,13 +38,8 @@ func EnableSyncExtension(config *config.Config, metaDB mTypes.MetaDB,
 				continue
 			}
 
-			tmpDir := config.Extensions.Sync.DownloadDir
-			credsPath := config.Extensions.Sync.CredentialsFile
-
-			service, err := sync.New(registryConfig, credsPath, tmpDir, storeController, metaDB, log)
+			service, err := newSyncService(config, registryConfig, metaDB, storeController, log)
 			if err != nil {
-				log.Error().Err(err).Msg("failed to initialize sync extension")
-
 				return nil, err
 			}
 
@@ -75,6 +63,73 @@ func EnableSyncExtension(config *config.Config, metaDB mTypes.MetaDB,
 	return nil, nil //nolint: nilnil
 }
 
+// RunSync starts a sync of the content of every registry which has some, regardless of their poll interval.
+// It returns false if sync isn't enabled or if there's no content to sync.
+func RunSync(config *config.Config, metaDB mTypes.MetaDB, storeController storage.StoreController,
+	sch *scheduler.Scheduler, log log.Logger,
+) (bool, error) {
+	if config.Extensions == nil || config.Extensions.Sync == nil || !*config.Extensions.Sync.Enable {
+		return false, nil
+	}
+
+	started := false
+
+	for _, registryConfig := range config.Extensions.Sync.Registries {
+		if len(registryConfig.Content) == 0 {
+			continue
+		}
+
+		registryConfig := registryConfig
+		if err := selectSyncURLs(config, &registryConfig, log); err != nil {
+			return started, err
+		}
+
+		service, err := newSyncService(config, registryConfig, metaDB, storeController, log)
+		if err != nil {
+			return started, err
+		}
+
+		sch.SubmitGenerator(sync.NewTaskGenerator(service, log), time.Duration(0), scheduler.MediumPriority)
+
+		started = true
+	}
+
+	return started, nil
+}
+
+// selectSyncURLs removes the urls of this zot from the ones of the registry, it fails if none is left.
+func selectSyncURLs(config *config.Config, registryConfig *syncconf.RegistryConfig, log log.Logger) error {
+	if len(registryConfig.URLs) > 1 {
+		if err := removeSelfURLs(config, registryConfig, log); err != nil {
+			return err
+		}
+	}
+
+	if len(registryConfig.URLs) == 0 {
+		log.Error().Err(zerr.ErrSyncNoURLsLeft).Msg("failed to start sync extension")
+
+		return zerr.ErrSyncNoURLsLeft
+	}
+
+	return nil
+}
+
+func newSyncService(config *config.Config, registryConfig syncconf.RegistryConfig, metaDB mTypes.MetaDB,
+	storeController storage.StoreController, log log.Logger,
+) (sync.Service, error) {
+	tmpDir := config.Extensions.Sync.DownloadDir
+	credsPath := config.Extensions.Sync.CredentialsFile
+
+	service, err := sync.New(registryConfig, credsPath, tmpDir, storeController, metaDB, log)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to initialize sync extension")
+
+		return nil, err
+	}
+
+	return service, nil
+}
+
 func getLocalIPs() ([]string, error) {
 	var localIPs []string
 
@@ -21,3 +21,10 @@ func EnableSyncExtension(config *config.Config, metaDB mTypes.MetaDB,
 
 	return nil, nil //nolint: nilnil
 }
+
+// RunSync ...
+func RunSync(config *config.Config, metaDB mTypes.MetaDB, storeController storage.StoreController,
+	sch *scheduler.Scheduler, log log.Logger,
+) (bool, error) {
+	return false, nil
+}
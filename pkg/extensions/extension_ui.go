@@ -28,6 +28,8 @@ type uiHandler struct {
 func (uih uiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	buf, _ := content.ReadFile("build/index.html")
 
+	w.Header().Set("Cache-Control", "no-cache")
+
 	_, err := w.Write(buf)
 	if err != nil {
 		uih.log.Error().Err(err).Msg("failed to serve index.html")
@@ -57,6 +59,22 @@ func addUISecurityHeaders(h http.Handler) http.HandlerFunc { //nolint:varnamelen
 	}
 }
 
+// addUICacheHeaders lets the browsers keep the files under static/ as their names change with their content,
+// while index.html is checked again on each visit so a new version of the UI is loaded after zot is upgraded.
+func addUICacheHeaders(fsys fs.FS, h http.Handler) http.HandlerFunc { //nolint:varnamelen
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/")
+
+		if _, err := fs.Stat(fsys, path); err == nil && strings.HasPrefix(path, "static/") {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+
+		h.ServeHTTP(w, r)
+	}
+}
+
 func SetupUIRoutes(conf *config.Config, router *mux.Router,
 	log log.Logger,
 ) {
@@ -90,7 +108,7 @@ func SetupUIRoutes(conf *config.Config, router *mux.Router,
 	router.PathPrefix("/user").Methods(allowedMethods...).
 		Handler(addUISecurityHeaders(uih))
 	router.PathPrefix("/").Methods(allowedMethods...).
-		Handler(addUISecurityHeaders(http.FileServer(http.FS(fsub))))
+		Handler(addUISecurityHeaders(addUICacheHeaders(fsub, http.FileServer(http.FS(fsub)))))
 
 	log.Info().Msg("finished setting up ui routes")
 }
@@ -71,6 +71,18 @@ func TestUIExtension(t *testing.T) {
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
 		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Cache-Control"), ShouldEqual, "no-cache")
+
+		resp, err = resty.R().Get(baseURL + "/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(resp.Header().Get("Cache-Control"), ShouldEqual, "no-cache")
+
+		// the missing files aren't cached
+		resp, err = resty.R().Get(baseURL + "/static/js/missing.js")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		So(resp.Header().Get("Cache-Control"), ShouldEqual, "no-cache")
 
 		resp, err = resty.R().Get(baseURL + "/image/")
 		So(err, ShouldBeNil)
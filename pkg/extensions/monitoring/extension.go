@@ -130,6 +130,61 @@ var (
 		},
 		[]string{"name"},
 	)
+	gcRemovedManifests = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "gc_removed_manifests_total",
+			Help:      "Total number of manifests removed by garbage collection",
+		},
+		[]string{"repo"},
+	)
+	gcRemovedBlobsBytes = promauto.NewSummaryVec( //nolint: gochecknoglobals
+		prometheus.SummaryOpts{
+			Namespace: metricsNamespace,
+			Name:      "gc_removed_blobs_bytes",
+			Help:      "Size of the blobs removed by garbage collection",
+		},
+		[]string{"repo"},
+	)
+	httpUploadsInFlight = promauto.NewGauge( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_uploads_in_flight",
+			Help:      "Number of blob upload requests being served",
+		},
+	)
+	scrubRepos = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "scrub_repos_total",
+			Help:      "Total number of repositories checked by scrub, by result",
+		},
+		[]string{"status"},
+	)
+	scrubAffectedImages = promauto.NewGaugeVec( //nolint: gochecknoglobals
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "scrub_affected_images",
+			Help:      "Number of images with missing or corrupted blobs found by the last scrub of the repository",
+		},
+		[]string{"repo"},
+	)
+	storageTierReads = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "storage_tier_reads_total",
+			Help:      "Total number of blobs read from the storage, by the tier they were found in",
+		},
+		[]string{"tier"},
+	)
+	storageTierMovedBlobs = promauto.NewCounterVec( //nolint: gochecknoglobals
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "storage_tier_moved_blobs_total",
+			Help:      "Total number of blobs moved between the storage tiers, by the tier they were moved to",
+		},
+		[]string{"tier"},
+	)
 )
 
 type metricServer struct {
@@ -289,3 +344,48 @@ func ObserveWorkersTasksDuration(ms MetricServer, taskName string, duration time
 		workersTasksDuration.WithLabelValues(taskName).Observe(duration.Seconds())
 	})
 }
+
+func IncGCRemovedManifests(ms MetricServer, repo string) {
+	ms.SendMetric(func() {
+		gcRemovedManifests.WithLabelValues(repo).Inc()
+	})
+}
+
+func ObserveGCRemovedBlob(ms MetricServer, repo string, size int64) {
+	ms.SendMetric(func() {
+		gcRemovedBlobsBytes.WithLabelValues(repo).Observe(float64(size))
+	})
+}
+
+// IncUploadsInFlight and DecUploadsInFlight are always sent, so the gauge stays balanced
+// when the metrics are enabled while uploads are in progress.
+func IncUploadsInFlight(ms MetricServer) {
+	ms.ForceSendMetric(func() {
+		httpUploadsInFlight.Inc()
+	})
+}
+
+func DecUploadsInFlight(ms MetricServer) {
+	ms.ForceSendMetric(func() {
+		httpUploadsInFlight.Dec()
+	})
+}
+
+func SetScrubRepoResults(ms MetricServer, repo, status string, affectedImages int) {
+	ms.SendMetric(func() {
+		scrubRepos.WithLabelValues(status).Inc()
+		scrubAffectedImages.WithLabelValues(repo).Set(float64(affectedImages))
+	})
+}
+
+func IncStorageTierReads(ms MetricServer, tier string) {
+	ms.SendMetric(func() {
+		storageTierReads.WithLabelValues(tier).Inc()
+	})
+}
+
+func IncStorageTierMovedBlobs(ms MetricServer, tier string) {
+	ms.SendMetric(func() {
+		storageTierMovedBlobs.WithLabelValues(tier).Inc()
+	})
+}
@@ -18,10 +18,14 @@ import (
 const (
 	metricsNamespace = "zot"
 	// Counters.
-	httpConnRequests    = metricsNamespace + ".http.requests"
-	repoDownloads       = metricsNamespace + ".repo.downloads"
-	repoUploads         = metricsNamespace + ".repo.uploads"
-	schedulerGenerators = metricsNamespace + ".scheduler.generators"
+	httpConnRequests      = metricsNamespace + ".http.requests"
+	repoDownloads         = metricsNamespace + ".repo.downloads"
+	repoUploads           = metricsNamespace + ".repo.uploads"
+	schedulerGenerators   = metricsNamespace + ".scheduler.generators"
+	gcRemovedManifests    = metricsNamespace + ".gc.removed.manifests"
+	scrubRepos            = metricsNamespace + ".scrub.repos"
+	storageTierReads      = metricsNamespace + ".storage.tier.reads"
+	storageTierMovedBlobs = metricsNamespace + ".storage.tier.moved.blobs"
 	// Gauge.
 	repoStorageBytes          = metricsNamespace + ".repo.storage.bytes"
 	serverInfo                = metricsNamespace + ".info"
@@ -29,8 +33,11 @@ const (
 	schedulerWorkers          = metricsNamespace + ".scheduler.workers"
 	schedulerGeneratorsStatus = metricsNamespace + ".scheduler.generators.status"
 	schedulerTasksQueue       = metricsNamespace + ".scheduler.tasksqueue.length"
+	httpUploadsInFlight       = metricsNamespace + ".http.uploads.in.flight"
+	scrubAffectedImages       = metricsNamespace + ".scrub.affected.images"
 	// Summary.
 	httpRepoLatencySeconds = metricsNamespace + ".http.repo.latency.seconds"
+	gcRemovedBlobsBytes    = metricsNamespace + ".gc.removed.blobs.bytes"
 	// Histogram.
 	httpMethodLatencySeconds  = metricsNamespace + ".http.method.latency.seconds"
 	storageLockLatencySeconds = metricsNamespace + ".storage.lock.latency.seconds"
@@ -49,6 +56,9 @@ type metricServer struct {
 	bucketsF2S map[float64]string // float64 to string conversion of buckets label
 	log        log.Logger
 	lock       *sync.RWMutex
+	// the number of blob uploads in flight, the gauge is set with its new value
+	uploadsInFlight int
+	uploadsLock     *sync.Mutex
 }
 
 type MetricsInfo struct {
@@ -232,13 +242,14 @@ func NewMetricsServer(enabled bool, log log.Logger) MetricServer {
 	}
 
 	ms := &metricServer{
-		enabled:    enabled,
-		reqChan:    make(chan interface{}),
-		cacheChan:  make(chan MetricsCopy),
-		cache:      mi,
-		bucketsF2S: bucketsFloat2String,
-		log:        log,
-		lock:       &sync.RWMutex{},
+		enabled:     enabled,
+		reqChan:     make(chan interface{}),
+		cacheChan:   make(chan MetricsCopy),
+		cache:       mi,
+		bucketsF2S:  bucketsFloat2String,
+		log:         log,
+		lock:        &sync.RWMutex{},
+		uploadsLock: &sync.Mutex{},
 	}
 
 	go ms.Run()
@@ -249,10 +260,14 @@ func NewMetricsServer(enabled bool, log log.Logger) MetricServer {
 // contains a map with key=CounterName and value=CounterLabels.
 func GetCounters() map[string][]string {
 	return map[string][]string{
-		httpConnRequests:    {"method", "code"},
-		repoDownloads:       {"repo"},
-		repoUploads:         {"repo"},
-		schedulerGenerators: {},
+		httpConnRequests:      {"method", "code"},
+		repoDownloads:         {"repo"},
+		repoUploads:           {"repo"},
+		schedulerGenerators:   {},
+		gcRemovedManifests:    {"repo"},
+		scrubRepos:            {"status"},
+		storageTierReads:      {"tier"},
+		storageTierMovedBlobs: {"tier"},
 	}
 }
 
@@ -264,12 +279,15 @@ func GetGauges() map[string][]string {
 		schedulerGeneratorsStatus: {"priority", "state"},
 		schedulerTasksQueue:       {"priority"},
 		schedulerWorkers:          {"state"},
+		httpUploadsInFlight:       {},
+		scrubAffectedImages:       {"repo"},
 	}
 }
 
 func GetSummaries() map[string][]string {
 	return map[string][]string{
 		httpRepoLatencySeconds: {"repo"},
+		gcRemovedBlobsBytes:    {"repo"},
 	}
 }
 
@@ -636,3 +654,82 @@ func SetSchedulerWorkers(ms MetricServer, w map[string]int) {
 		ms.SendMetric(workers)
 	}
 }
+
+func IncGCRemovedManifests(ms MetricServer, repo string) {
+	counter := CounterValue{
+		Name:        gcRemovedManifests,
+		LabelNames:  []string{"repo"},
+		LabelValues: []string{repo},
+	}
+	ms.SendMetric(counter)
+}
+
+func ObserveGCRemovedBlob(ms MetricServer, repo string, size int64) {
+	sv := SummaryValue{
+		Name:        gcRemovedBlobsBytes,
+		Sum:         float64(size),
+		LabelNames:  []string{"repo"},
+		LabelValues: []string{repo},
+	}
+	ms.SendMetric(sv)
+}
+
+func IncUploadsInFlight(ms MetricServer) {
+	addUploadsInFlight(ms, 1)
+}
+
+func DecUploadsInFlight(ms MetricServer) {
+	addUploadsInFlight(ms, -1)
+}
+
+// addUploadsInFlight sends the gauge while holding the lock, so the values are set in order.
+// The gauge is always sent, so it stays balanced when the metrics are enabled while uploads are in progress.
+func addUploadsInFlight(ms MetricServer, delta int) {
+	server := ms.(*metricServer)
+
+	server.uploadsLock.Lock()
+	defer server.uploadsLock.Unlock()
+
+	server.uploadsInFlight += delta
+
+	gauge := GaugeValue{
+		Name:  httpUploadsInFlight,
+		Value: float64(server.uploadsInFlight),
+	}
+	ms.ForceSendMetric(gauge)
+}
+
+func SetScrubRepoResults(ms MetricServer, repo, status string, affectedImages int) {
+	counter := CounterValue{
+		Name:        scrubRepos,
+		LabelNames:  []string{"status"},
+		LabelValues: []string{status},
+	}
+	ms.SendMetric(counter)
+
+	gauge := GaugeValue{
+		Name:        scrubAffectedImages,
+		Value:       float64(affectedImages),
+		LabelNames:  []string{"repo"},
+		LabelValues: []string{repo},
+	}
+	ms.SendMetric(gauge)
+}
+
+func IncStorageTierReads(ms MetricServer, tier string) {
+	counter := CounterValue{
+		Name:        storageTierReads,
+		LabelNames:  []string{"tier"},
+		LabelValues: []string{tier},
+	}
+	ms.SendMetric(counter)
+}
+
+func IncStorageTierMovedBlobs(ms MetricServer, tier string) {
+	counter := CounterValue{
+		Name:        storageTierMovedBlobs,
+		LabelNames:  []string{"tier"},
+		LabelValues: []string{tier},
+	}
+	ms.SendMetric(counter)
+}
@@ -71,6 +71,12 @@ func TestExtensionMetrics(t *testing.T) {
 
 		monitoring.ObserveStorageLockLatency(ctlr.Metrics, time.Millisecond, rootDir, "RWLock")
 
+		monitoring.IncUploadsInFlight(ctlr.Metrics)
+		monitoring.IncUploadsInFlight(ctlr.Metrics)
+		monitoring.DecUploadsInFlight(ctlr.Metrics)
+
+		monitoring.SetScrubRepoResults(ctlr.Metrics, "alpine", "affected", 2)
+
 		resp, err := resty.R().Get(baseURL + "/metrics")
 		So(err, ShouldBeNil)
 		So(resp, ShouldNotBeNil)
@@ -80,6 +86,9 @@ func TestExtensionMetrics(t *testing.T) {
 		So(respStr, ShouldContainSubstring, "zot_repo_downloads_total{repo=\"alpine\"} 1")
 		So(respStr, ShouldContainSubstring, "zot_repo_uploads_total{repo=\"alpine\"} 1")
 		So(respStr, ShouldContainSubstring, "zot_repo_storage_bytes{repo=\"alpine\"}")
+		So(respStr, ShouldContainSubstring, "zot_http_uploads_in_flight 1")
+		So(respStr, ShouldContainSubstring, "zot_scrub_repos_total{status=\"affected\"} 1")
+		So(respStr, ShouldContainSubstring, "zot_scrub_affected_images{repo=\"alpine\"} 2")
 		So(respStr, ShouldContainSubstring, "zot_storage_lock_latency_seconds_bucket")
 		So(respStr, ShouldContainSubstring, "zot_storage_lock_latency_seconds_sum")
 		So(respStr, ShouldContainSubstring, "zot_storage_lock_latency_seconds_bucket")
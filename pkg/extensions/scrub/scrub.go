@@ -8,17 +8,28 @@ import (
 	"fmt"
 	"path"
 
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
 	"zotregistry.dev/zot/pkg/log"
 	"zotregistry.dev/zot/pkg/storage"
 	storageTypes "zotregistry.dev/zot/pkg/storage/types"
 )
 
 // Scrub Extension for repo...
-func RunScrubRepo(ctx context.Context, imgStore storageTypes.ImageStore, repo string, log log.Logger) error {
+// The results are logged and kept in the report, to be queried with the scrub route, and counted in the metrics.
+func RunScrubRepo(ctx context.Context, imgStore storageTypes.ImageStore, repo string, report *storage.ScrubReport,
+	metrics monitoring.MetricServer, log log.Logger,
+) error {
 	execMsg := fmt.Sprintf("executing scrub to check manifest/blob integrity for %s", path.Join(imgStore.RootDir(), repo))
 	log.Info().Msg(execMsg)
 
 	results, err := storage.CheckRepo(ctx, repo, imgStore)
+
+	// a cancelled scrub didn't check the repo, the previous result is kept
+	if ctx.Err() == nil {
+		report.SetRepoResults(repo, results, err)
+		setScrubMetrics(metrics, repo, results, err)
+	}
+
 	if err != nil {
 		errMessage := fmt.Sprintf("failed to run scrub for %s", path.Join(imgStore.RootDir(), repo))
 		log.Error().Err(err).Msg(errMessage)
@@ -52,18 +63,46 @@ func RunScrubRepo(ctx context.Context, imgStore storageTypes.ImageStore, repo st
 	return nil
 }
 
+// setScrubMetrics counts the repo as failed if it couldn't be checked, affected if any of its images
+// has missing or corrupted blobs and ok otherwise.
+func setScrubMetrics(metrics monitoring.MetricServer, repo string, results []storage.ScrubImageResult, err error) {
+	affectedImages := 0
+
+	for _, result := range results {
+		if result.Status != "ok" {
+			affectedImages++
+		}
+	}
+
+	status := "ok"
+
+	switch {
+	case err != nil:
+		status = "failed"
+	case affectedImages > 0:
+		status = "affected"
+	}
+
+	monitoring.SetScrubRepoResults(metrics, repo, status, affectedImages)
+}
+
 type Task struct {
 	imgStore storageTypes.ImageStore
 	repo     string
+	report   *storage.ScrubReport
+	metrics  monitoring.MetricServer
 	log      log.Logger
 }
 
-func NewTask(imgStore storageTypes.ImageStore, repo string, log log.Logger) *Task {
-	return &Task{imgStore, repo, log}
+func NewTask(imgStore storageTypes.ImageStore, repo string, report *storage.ScrubReport,
+	metrics monitoring.MetricServer, log log.Logger,
+) *Task {
+	return &Task{imgStore, repo, report, metrics, log}
 }
 
 func (scrubT *Task) DoWork(ctx context.Context) error {
-	return RunScrubRepo(ctx, scrubT.imgStore, scrubT.repo, scrubT.log) //nolint: contextcheck
+	//nolint: contextcheck
+	return RunScrubRepo(ctx, scrubT.imgStore, scrubT.repo, scrubT.report, scrubT.metrics, scrubT.log)
 }
 
 func (scrubT *Task) String() string {
@@ -5,16 +5,20 @@ package scrub_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"path"
 	"testing"
 	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/resty.v1"
 
 	"zotregistry.dev/zot/pkg/api"
 	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/api/constants"
 	extconf "zotregistry.dev/zot/pkg/extensions/config"
 	"zotregistry.dev/zot/pkg/extensions/monitoring"
 	"zotregistry.dev/zot/pkg/extensions/scrub"
@@ -125,6 +129,72 @@ func TestScrubExtension(t *testing.T) {
 		found, err := test.ReadLogFileAndSearchString(logFile.Name(), "blobs/manifest affected", 60*time.Second)
 		So(found, ShouldBeTrue)
 		So(err, ShouldBeNil)
+
+		resp, err := resty.R().Get(test.GetBaseURL(port) + constants.FullScrub)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		var status storage.ScrubStatus
+
+		err = json.Unmarshal(resp.Body(), &status)
+		So(err, ShouldBeNil)
+		So(status.Repositories, ShouldHaveLength, 1)
+		So(status.Repositories[0].Name, ShouldEqual, repoName)
+		So(status.Repositories[0].ScrubResults, ShouldHaveLength, 1)
+		So(status.Repositories[0].ScrubResults[0].Status, ShouldEqual, "affected")
+		So(status.Repositories[0].ScrubResults[0].AffectedBlob, ShouldEqual, layerDigest.Encoded())
+	})
+
+	Convey("Scrub results are only for admins", t, func(c C) {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+
+		htpasswdPath := test.MakeHtpasswdFileFromString(test.GetCredString("admin", "admin") +
+			test.GetCredString("user", "user"))
+		defer os.Remove(htpasswdPath)
+
+		conf.HTTP.Auth = &config.AuthConfig{
+			HTPasswd: config.AuthHTPasswd{
+				Path: htpasswdPath,
+			},
+		}
+		conf.HTTP.AccessControl = &config.AccessControlConfig{
+			AdminPolicy: config.Policy{
+				Users:   []string{"admin"},
+				Actions: []string{},
+			},
+		}
+
+		trueValue := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Scrub: &extconf.ScrubConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &trueValue},
+				Interval:   2,
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		resp, err := resty.R().Get(baseURL + constants.FullScrub)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+		resp, err = resty.R().SetBasicAuth("user", "user").Get(baseURL + constants.FullScrub)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusForbidden)
+
+		resp, err = resty.R().SetBasicAuth("admin", "admin").Get(baseURL + constants.FullScrub)
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		So(string(resp.Body()), ShouldEqual, `{"repositories":[]}`)
 	})
 
 	Convey("Generator error - not enough permissions to access root directory", t, func(c C) {
@@ -204,7 +274,7 @@ func TestRunScrubRepo(t *testing.T) {
 		err = WriteImageToFileSystem(image, repoName, "0.0.1", srcStorageCtlr)
 		So(err, ShouldBeNil)
 
-		err = scrub.RunScrubRepo(context.Background(), imgStore, repoName, log)
+		err = scrub.RunScrubRepo(context.Background(), imgStore, repoName, storage.NewScrubReport(), metrics, log)
 		So(err, ShouldBeNil)
 
 		data, err := os.ReadFile(logFile.Name())
@@ -247,12 +317,22 @@ func TestRunScrubRepo(t *testing.T) {
 			panic(err)
 		}
 
-		err = scrub.RunScrubRepo(context.Background(), imgStore, repoName, log)
+		report := storage.NewScrubReport()
+
+		err = scrub.RunScrubRepo(context.Background(), imgStore, repoName, report, metrics, log)
 		So(err, ShouldBeNil)
 
 		data, err := os.ReadFile(logFile.Name())
 		So(err, ShouldBeNil)
 		So(string(data), ShouldContainSubstring, "blobs/manifest affected")
+
+		status := report.Status()
+		So(status.Repositories, ShouldHaveLength, 1)
+		So(status.Repositories[0].Name, ShouldEqual, repoName)
+		So(status.Repositories[0].Error, ShouldBeEmpty)
+		So(status.Repositories[0].ScrubResults, ShouldHaveLength, 1)
+		So(status.Repositories[0].ScrubResults[0].Status, ShouldEqual, "affected")
+		So(status.Repositories[0].ScrubResults[0].AffectedBlob, ShouldEqual, layerDigest.Encoded())
 	})
 
 	Convey("CheckRepo error - not enough permissions to access root directory", t, func(c C) {
@@ -283,7 +363,7 @@ func TestRunScrubRepo(t *testing.T) {
 
 		So(os.Chmod(path.Join(dir, repoName), 0o000), ShouldBeNil)
 
-		err = scrub.RunScrubRepo(context.Background(), imgStore, repoName, log)
+		err = scrub.RunScrubRepo(context.Background(), imgStore, repoName, storage.NewScrubReport(), metrics, log)
 		So(err, ShouldNotBeNil)
 
 		data, err := os.ReadFile(logFile.Name())
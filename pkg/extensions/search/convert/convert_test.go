@@ -796,6 +796,19 @@ func TestIndexAnnotations(t *testing.T) {
 		So(*imageSummary.Documentation, ShouldResemble, "IndexDocumentation")
 		So(*imageSummary.Source, ShouldResemble, "IndexSource")
 
+		So(annotationsToMap(imageSummary.Annotations), ShouldResemble, map[string]string{
+			ispec.AnnotationCreated:       indexCreatedTime.Format(time.RFC3339),
+			ispec.AnnotationTitle:         "IndexTitle",
+			ispec.AnnotationDocumentation: "IndexDocumentation",
+			ispec.AnnotationSource:        "IndexSource",
+		})
+		So(annotationsToMap(imageSummary.Manifests[0].Annotations), ShouldResemble, map[string]string{
+			ispec.AnnotationDescription: "ConfigDescription",
+			ispec.AnnotationLicenses:    "ConfigLicenses",
+			ispec.AnnotationVendor:      "ManifestVendor",
+			ispec.AnnotationAuthors:     "ManifestAuthors",
+		})
+
 		err = metaDB.ResetDB()
 		So(err, ShouldBeNil)
 		//--------------------------------------------------------
@@ -838,6 +851,16 @@ func TestIndexAnnotations(t *testing.T) {
 	})
 }
 
+func annotationsToMap(annotations []*gql_generated.Annotation) map[string]string {
+	result := map[string]string{}
+
+	for _, annotation := range annotations {
+		result[*annotation.Key] = *annotation.Value
+	}
+
+	return result
+}
+
 func TestConvertErrors(t *testing.T) {
 	ctx := context.Background()
 	log := log.NewLogger("debug", "")
@@ -211,6 +211,22 @@ func StringMap2Annotations(strMap map[string]string) []*gql_generated.Annotation
 	return annotations
 }
 
+// getManifestAnnotations merges the config labels with the manifest annotations,
+// the annotations taking precedence in case of duplicate keys.
+func getManifestAnnotations(manifestAnnotations, configLabels map[string]string) []*gql_generated.Annotation {
+	merged := make(map[string]string, len(manifestAnnotations)+len(configLabels))
+
+	for key, value := range configLabels {
+		merged[key] = value
+	}
+
+	for key, value := range manifestAnnotations {
+		merged[key] = value
+	}
+
+	return StringMap2Annotations(merged)
+}
+
 func GetPreloads(ctx context.Context) map[string]bool {
 	if !graphql.HasOperationContext(ctx) {
 		return map[string]bool{}
@@ -469,25 +485,27 @@ func ImageIndex2ImageSummary(ctx context.Context, fullImageMeta mTypes.FullImage
 	}
 
 	indexSummary := gql_generated.ImageSummary{
-		RepoName:      &repo,
-		Tag:           &tag,
-		Digest:        &indexDigestStr,
-		MediaType:     &indexMediaType,
-		Manifests:     manifestSummaries,
-		LastUpdated:   imageLastUpdated,
-		IsSigned:      &isSigned,
-		SignatureInfo: signaturesInfo,
-		Size:          ref(strconv.FormatInt(indexSize, 10)),
-		DownloadCount: ref(fullImageMeta.Statistics.DownloadCount),
-		Description:   &annotations.Description,
-		Title:         &annotations.Title,
-		Documentation: &annotations.Documentation,
-		Licenses:      &annotations.Licenses,
-		Labels:        &annotations.Labels,
-		Source:        &annotations.Source,
-		Vendor:        &annotations.Vendor,
-		Authors:       &annotations.Authors,
-		Referrers:     getReferrers(fullImageMeta.Referrers),
+		RepoName:          &repo,
+		Tag:               &tag,
+		Digest:            &indexDigestStr,
+		MediaType:         &indexMediaType,
+		Manifests:         manifestSummaries,
+		LastUpdated:       imageLastUpdated,
+		IsSigned:          &isSigned,
+		SignatureInfo:     signaturesInfo,
+		Size:              ref(strconv.FormatInt(indexSize, 10)),
+		DownloadCount:     ref(fullImageMeta.Statistics.DownloadCount),
+		LastPullTimestamp: getLastPullTimestamp(fullImageMeta.Statistics),
+		Description:       &annotations.Description,
+		Title:             &annotations.Title,
+		Documentation:     &annotations.Documentation,
+		Licenses:          &annotations.Licenses,
+		Labels:            &annotations.Labels,
+		Source:            &annotations.Source,
+		Vendor:            &annotations.Vendor,
+		Authors:           &annotations.Authors,
+		Referrers:         getReferrers(fullImageMeta.Referrers),
+		Annotations:       StringMap2Annotations(fullImageMeta.Index.Annotations),
 	}
 
 	return &indexSummary, indexBlobs, nil
@@ -508,6 +526,7 @@ func ImageManifest2ImageSummary(ctx context.Context, fullImageMeta mTypes.FullIm
 		artifactType   = zcommon.GetManifestArtifactType(fullImageMeta.Manifests[0].Manifest)
 		platform       = getPlatform(manifest.Config.Platform)
 		downloadCount  = fullImageMeta.Statistics.DownloadCount
+		lastPull       = getLastPullTimestamp(fullImageMeta.Statistics)
 		isSigned       = isImageSigned(fullImageMeta.Signatures)
 	)
 
@@ -534,42 +553,47 @@ func ImageManifest2ImageSummary(ctx context.Context, fullImageMeta mTypes.FullIm
 	}
 
 	signaturesInfo := GetSignaturesInfo(isSigned, fullImageMeta.Signatures)
+	manifestAnnotations := getManifestAnnotations(manifest.Manifest.Annotations, manifest.Config.Config.Labels)
 
 	manifestSummary := gql_generated.ManifestSummary{
-		Digest:        &manifestDigest,
-		ConfigDigest:  &configDigest,
-		LastUpdated:   imageLastUpdated,
-		Size:          &imageSizeStr,
-		IsSigned:      &isSigned,
-		SignatureInfo: signaturesInfo,
-		Platform:      &platform,
-		DownloadCount: &downloadCount,
-		Layers:        getLayersSummaries(manifest.Manifest),
-		History:       historyEntries,
-		Referrers:     getReferrers(fullImageMeta.Referrers),
-		ArtifactType:  &artifactType,
+		Digest:            &manifestDigest,
+		ConfigDigest:      &configDigest,
+		LastUpdated:       imageLastUpdated,
+		Size:              &imageSizeStr,
+		IsSigned:          &isSigned,
+		SignatureInfo:     signaturesInfo,
+		Platform:          &platform,
+		DownloadCount:     &downloadCount,
+		LastPullTimestamp: lastPull,
+		Layers:            getLayersSummaries(manifest.Manifest),
+		History:           historyEntries,
+		Referrers:         getReferrers(fullImageMeta.Referrers),
+		ArtifactType:      &artifactType,
+		Annotations:       manifestAnnotations,
 	}
 
 	imageSummary := gql_generated.ImageSummary{
-		RepoName:      &repoName,
-		Tag:           &tag,
-		Digest:        &manifestDigest,
-		MediaType:     &mediaType,
-		Manifests:     []*gql_generated.ManifestSummary{&manifestSummary},
-		LastUpdated:   imageLastUpdated,
-		IsSigned:      &isSigned,
-		SignatureInfo: signaturesInfo,
-		Size:          &imageSizeStr,
-		DownloadCount: &downloadCount,
-		Description:   &annotations.Description,
-		Title:         &annotations.Title,
-		Documentation: &annotations.Documentation,
-		Licenses:      &annotations.Licenses,
-		Labels:        &annotations.Labels,
-		Source:        &annotations.Source,
-		Vendor:        &annotations.Vendor,
-		Authors:       &authors,
-		Referrers:     manifestSummary.Referrers,
+		RepoName:          &repoName,
+		Tag:               &tag,
+		Digest:            &manifestDigest,
+		MediaType:         &mediaType,
+		Manifests:         []*gql_generated.ManifestSummary{&manifestSummary},
+		LastUpdated:       imageLastUpdated,
+		IsSigned:          &isSigned,
+		SignatureInfo:     signaturesInfo,
+		Size:              &imageSizeStr,
+		DownloadCount:     &downloadCount,
+		LastPullTimestamp: lastPull,
+		Description:       &annotations.Description,
+		Title:             &annotations.Title,
+		Documentation:     &annotations.Documentation,
+		Licenses:          &annotations.Licenses,
+		Labels:            &annotations.Labels,
+		Source:            &annotations.Source,
+		Vendor:            &annotations.Vendor,
+		Authors:           &authors,
+		Referrers:         manifestSummary.Referrers,
+		Annotations:       manifestAnnotations,
 	}
 
 	return &imageSummary, imageBlobsMap, nil
@@ -600,6 +624,16 @@ func getArch(arch string, variant string) string {
 	return arch
 }
 
+// getLastPullTimestamp returns nil if the manifest was never pulled, the statistics of new manifests
+// are stored with an empty timestamp, read back as the unix epoch.
+func getLastPullTimestamp(statistics mTypes.DescriptorStatistics) *time.Time {
+	if statistics.LastPullTimestamp.IsZero() || statistics.LastPullTimestamp.Unix() == 0 {
+		return nil
+	}
+
+	return &statistics.LastPullTimestamp
+}
+
 func ref[T any](val T) *T {
 	ref := val
 
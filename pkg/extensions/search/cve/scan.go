@@ -16,7 +16,7 @@ func NewScanTaskGenerator(
 	scanner Scanner,
 	logC log.Logger,
 ) scheduler.TaskGenerator {
-	sublogger := logC.With().Str("component", "cve").Logger()
+	sublogger := logC.Module("cve").With().Str("component", "cve").Logger()
 
 	return &scanTaskGenerator{
 		log:        log.Logger{Logger: sublogger},
@@ -152,7 +152,7 @@ func (gen *scanTaskGenerator) Next() (scheduler.Task, error) {
 	// Mark the digest as scheduled so it is skipped on next generator run
 	gen.setScheduled(digest, true)
 
-	return newScanTask(gen, imageMeta[0].Repo, digest), nil
+	return &scanTask{generator: gen, scanner: gen.scanner, log: gen.log, repo: imageMeta[0].Repo, digest: digest}, nil
 }
 
 func (gen *scanTaskGenerator) IsDone() bool {
@@ -173,31 +173,54 @@ func (gen *scanTaskGenerator) Reset() {
 }
 
 type scanTask struct {
+	// nil for the scans of the pushed images
 	generator *scanTaskGenerator
+	scanner   Scanner
+	log       log.Logger
 	repo      string
 	digest    string
 }
 
-func newScanTask(generator *scanTaskGenerator, repo string, digest string) *scanTask {
-	return &scanTask{generator, repo, digest}
+// NewScanTask returns a task scanning a pushed image, so its vulnerabilities are known without
+// waiting for the next run of the scan generator.
+func NewScanTask(scanner Scanner, repo, digest string, logC log.Logger) scheduler.Task {
+	sublogger := logC.Module("cve").With().Str("component", "cve").Logger()
+
+	return &scanTask{scanner: scanner, log: log.Logger{Logger: sublogger}, repo: repo, digest: digest}
 }
 
 func (st *scanTask) DoWork(ctx context.Context) error {
-	// When work finished clean this entry from the generator
-	defer st.generator.setScheduled(st.digest, false)
-
 	image := st.repo + "@" + st.digest
 
+	if st.generator != nil {
+		// When work finished clean this entry from the generator
+		defer st.generator.setScheduled(st.digest, false)
+	} else {
+		// the pushed image may not be scannable or may have been scanned by the generator meanwhile
+		if st.scanner.IsResultCached(st.digest) {
+			return nil
+		}
+
+		if ok, err := st.scanner.IsImageFormatScannable(st.repo, st.digest); !ok || err != nil {
+			st.log.Debug().Err(err).Str("image", image).Msg("skipping cve scan of pushed image which can't be scanned")
+
+			return nil
+		}
+	}
+
 	// We cache the results internally in the scanner
 	// so we can discard the actual results for now
-	if _, err := st.generator.scanner.ScanImage(ctx, image); err != nil {
-		st.generator.log.Error().Err(err).Str("image", image).Msg("failed to perform scheduled cve scan for image")
-		st.generator.addError(st.digest, err)
+	if _, err := st.scanner.ScanImage(ctx, image); err != nil {
+		st.log.Error().Err(err).Str("image", image).Msg("failed to perform scheduled cve scan for image")
+
+		if st.generator != nil {
+			st.generator.addError(st.digest, err)
+		}
 
 		return err
 	}
 
-	st.generator.log.Debug().Str("image", image).Msg("scheduled cve scan completed successfully for image")
+	st.log.Debug().Str("image", image).Msg("scheduled cve scan completed successfully for image")
 
 	return nil
 }
@@ -573,3 +573,45 @@ func TestScanGeneratorWithRealData(t *testing.T) {
 		So([]string{"MEDIUM", "HIGH", "CRITICAL"}, ShouldContain, cveSummary.MaxSeverity)
 	})
 }
+
+func TestScanTaskOfPushedImage(t *testing.T) {
+	Convey("Test the CVE scan task of a pushed image", t, func() {
+		scanned := []string{}
+
+		scanner := mocks.CveScannerMock{
+			IsImageFormatScannableFn: func(repo string, reference string) (bool, error) {
+				return reference != "sha256:signature", nil
+			},
+			IsResultCachedFn: func(digest string) bool {
+				return digest == "sha256:cached"
+			},
+			ScanImageFn: func(ctx context.Context, image string) (map[string]cvemodel.CVE, error) {
+				scanned = append(scanned, image)
+
+				if image == "repo@sha256:failing" {
+					return nil, ErrFailedScan
+				}
+
+				return map[string]cvemodel.CVE{}, nil
+			},
+		}
+
+		logger := log.NewLogger("debug", "")
+
+		err := cveinfo.NewScanTask(scanner, "repo", "sha256:image", logger).DoWork(context.Background())
+		So(err, ShouldBeNil)
+
+		// already scanned by the generator
+		err = cveinfo.NewScanTask(scanner, "repo", "sha256:cached", logger).DoWork(context.Background())
+		So(err, ShouldBeNil)
+
+		// not an image
+		err = cveinfo.NewScanTask(scanner, "repo", "sha256:signature", logger).DoWork(context.Background())
+		So(err, ShouldBeNil)
+
+		err = cveinfo.NewScanTask(scanner, "repo", "sha256:failing", logger).DoWork(context.Background())
+		So(err, ShouldWrap, ErrFailedScan)
+
+		So(scanned, ShouldResemble, []string{"repo@sha256:image", "repo@sha256:failing"})
+	})
+}
@@ -127,8 +127,8 @@ func NewScanner(storeController storage.StoreController,
 }
 
 func (scanner Scanner) getTrivyOptions(image string) flag.Options {
-	// Split image to get route prefix
-	prefixName := storage.GetRoutePrefix(image)
+	// Get the route of the substore storing the image
+	prefixName, _ := scanner.storeController.GetSubStoreRoute(image)
 
 	var opts flag.Options
 
@@ -244,7 +244,8 @@ func (scanner Scanner) isManifestScanable(digestStr string) (bool, error) {
 
 	for _, imageLayer := range manifestData.Manifests[0].Manifest.Layers {
 		switch imageLayer.MediaType {
-		case ispec.MediaTypeImageLayerGzip, ispec.MediaTypeImageLayer, string(regTypes.DockerLayer):
+		case ispec.MediaTypeImageLayerGzip, ispec.MediaTypeImageLayerZstd, ispec.MediaTypeImageLayer,
+			string(regTypes.DockerLayer):
 			continue
 		default:
 			return false, zerr.ErrScanNotSupported
@@ -261,7 +262,8 @@ func (scanner Scanner) isManifestDataScannable(manifestData mTypes.ManifestMeta)
 
 	for _, imageLayer := range manifestData.Manifest.Layers {
 		switch imageLayer.MediaType {
-		case ispec.MediaTypeImageLayerGzip, ispec.MediaTypeImageLayer, string(regTypes.DockerLayer):
+		case ispec.MediaTypeImageLayerGzip, ispec.MediaTypeImageLayerZstd, ispec.MediaTypeImageLayer,
+			string(regTypes.DockerLayer):
 			continue
 		default:
 			return false, zerr.ErrScanNotSupported
@@ -82,27 +82,29 @@ type ComplexityRoot struct {
 	}
 
 	ImageSummary struct {
-		Authors         func(childComplexity int) int
-		Description     func(childComplexity int) int
-		Digest          func(childComplexity int) int
-		Documentation   func(childComplexity int) int
-		DownloadCount   func(childComplexity int) int
-		IsDeletable     func(childComplexity int) int
-		IsSigned        func(childComplexity int) int
-		Labels          func(childComplexity int) int
-		LastUpdated     func(childComplexity int) int
-		Licenses        func(childComplexity int) int
-		Manifests       func(childComplexity int) int
-		MediaType       func(childComplexity int) int
-		Referrers       func(childComplexity int) int
-		RepoName        func(childComplexity int) int
-		SignatureInfo   func(childComplexity int) int
-		Size            func(childComplexity int) int
-		Source          func(childComplexity int) int
-		Tag             func(childComplexity int) int
-		Title           func(childComplexity int) int
-		Vendor          func(childComplexity int) int
-		Vulnerabilities func(childComplexity int) int
+		Annotations       func(childComplexity int) int
+		Authors           func(childComplexity int) int
+		Description       func(childComplexity int) int
+		Digest            func(childComplexity int) int
+		Documentation     func(childComplexity int) int
+		DownloadCount     func(childComplexity int) int
+		IsDeletable       func(childComplexity int) int
+		IsSigned          func(childComplexity int) int
+		Labels            func(childComplexity int) int
+		LastPullTimestamp func(childComplexity int) int
+		LastUpdated       func(childComplexity int) int
+		Licenses          func(childComplexity int) int
+		Manifests         func(childComplexity int) int
+		MediaType         func(childComplexity int) int
+		Referrers         func(childComplexity int) int
+		RepoName          func(childComplexity int) int
+		SignatureInfo     func(childComplexity int) int
+		Size              func(childComplexity int) int
+		Source            func(childComplexity int) int
+		Tag               func(childComplexity int) int
+		Title             func(childComplexity int) int
+		Vendor            func(childComplexity int) int
+		Vulnerabilities   func(childComplexity int) int
 	}
 
 	ImageVulnerabilitySummary struct {
@@ -125,20 +127,29 @@ type ComplexityRoot struct {
 		Size   func(childComplexity int) int
 	}
 
-	ManifestSummary struct {
-		ArtifactType    func(childComplexity int) int
-		ConfigDigest    func(childComplexity int) int
+	LayerUsage struct {
 		Digest          func(childComplexity int) int
-		DownloadCount   func(childComplexity int) int
-		History         func(childComplexity int) int
-		IsSigned        func(childComplexity int) int
-		LastUpdated     func(childComplexity int) int
-		Layers          func(childComplexity int) int
-		Platform        func(childComplexity int) int
-		Referrers       func(childComplexity int) int
-		SignatureInfo   func(childComplexity int) int
+		MediaType       func(childComplexity int) int
+		SharedRepoCount func(childComplexity int) int
 		Size            func(childComplexity int) int
-		Vulnerabilities func(childComplexity int) int
+	}
+
+	ManifestSummary struct {
+		Annotations       func(childComplexity int) int
+		ArtifactType      func(childComplexity int) int
+		ConfigDigest      func(childComplexity int) int
+		Digest            func(childComplexity int) int
+		DownloadCount     func(childComplexity int) int
+		History           func(childComplexity int) int
+		IsSigned          func(childComplexity int) int
+		LastPullTimestamp func(childComplexity int) int
+		LastUpdated       func(childComplexity int) int
+		Layers            func(childComplexity int) int
+		Platform          func(childComplexity int) int
+		Referrers         func(childComplexity int) int
+		SignatureInfo     func(childComplexity int) int
+		Size              func(childComplexity int) int
+		Vulnerabilities   func(childComplexity int) int
 	}
 
 	PackageInfo struct {
@@ -176,11 +187,13 @@ type ComplexityRoot struct {
 		ExpandedRepoInfo        func(childComplexity int, repo string) int
 		GlobalSearch            func(childComplexity int, query string, filter *Filter, requestedPage *PageInput) int
 		Image                   func(childComplexity int, image string) int
+		ImageLayers             func(childComplexity int, image string) int
 		ImageList               func(childComplexity int, repo string, requestedPage *PageInput) int
 		ImageListForCve         func(childComplexity int, id string, filter *Filter, requestedPage *PageInput) int
 		ImageListForDigest      func(childComplexity int, id string, requestedPage *PageInput) int
 		ImageListWithCVEFixed   func(childComplexity int, id string, image string, filter *Filter, requestedPage *PageInput) int
 		Referrers               func(childComplexity int, repo string, digest string, typeArg []string) int
+		RepoActivity            func(childComplexity int, repo string, reference *string) int
 		RepoListWithNewestImage func(childComplexity int, requestedPage *PageInput) int
 		StarredRepos            func(childComplexity int, requestedPage *PageInput) int
 	}
@@ -193,6 +206,16 @@ type ComplexityRoot struct {
 		Size         func(childComplexity int) int
 	}
 
+	RepoEvent struct {
+		Actor          func(childComplexity int) int
+		Digest         func(childComplexity int) int
+		MediaType      func(childComplexity int) int
+		PreviousDigest func(childComplexity int) int
+		Reference      func(childComplexity int) int
+		Timestamp      func(childComplexity int) int
+		Type           func(childComplexity int) int
+	}
+
 	RepoInfo struct {
 		Images  func(childComplexity int) int
 		Summary func(childComplexity int) int
@@ -231,7 +254,9 @@ type QueryResolver interface {
 	DerivedImageList(ctx context.Context, image string, digest *string, requestedPage *PageInput) (*PaginatedImagesResult, error)
 	BaseImageList(ctx context.Context, image string, digest *string, requestedPage *PageInput) (*PaginatedImagesResult, error)
 	Image(ctx context.Context, image string) (*ImageSummary, error)
+	ImageLayers(ctx context.Context, image string) ([]*LayerUsage, error)
 	Referrers(ctx context.Context, repo string, digest string, typeArg []string) ([]*Referrer, error)
+	RepoActivity(ctx context.Context, repo string, reference *string) ([]*RepoEvent, error)
 	StarredRepos(ctx context.Context, requestedPage *PageInput) (*PaginatedReposResult, error)
 	BookmarkedRepos(ctx context.Context, requestedPage *PageInput) (*PaginatedReposResult, error)
 }
@@ -402,6 +427,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.HistoryDescription.EmptyLayer(childComplexity), true
 
+	case "ImageSummary.Annotations":
+		if e.complexity.ImageSummary.Annotations == nil {
+			break
+		}
+
+		return e.complexity.ImageSummary.Annotations(childComplexity), true
+
 	case "ImageSummary.Authors":
 		if e.complexity.ImageSummary.Authors == nil {
 			break
@@ -458,6 +490,11 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ImageSummary.Labels(childComplexity), true
 
+	case "ImageSummary.LastPullTimestamp":
+		if e.complexity.ImageSummary.LastPullTimestamp == nil {
+			break
+		}
+
 	case "ImageSummary.LastUpdated":
 		if e.complexity.ImageSummary.LastUpdated == nil {
 			break
@@ -626,6 +663,13 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.LayerSummary.Size(childComplexity), true
 
+	case "ManifestSummary.Annotations":
+		if e.complexity.ManifestSummary.Annotations == nil {
+			break
+		}
+
+		return e.complexity.ManifestSummary.Annotations(childComplexity), true
+
 	case "ManifestSummary.ArtifactType":
 		if e.complexity.ManifestSummary.ArtifactType == nil {
 			break
@@ -640,6 +684,34 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ManifestSummary.ConfigDigest(childComplexity), true
 
+	case "LayerUsage.Digest":
+		if e.complexity.LayerUsage.Digest == nil {
+			break
+		}
+
+		return e.complexity.LayerUsage.Digest(childComplexity), true
+
+	case "LayerUsage.MediaType":
+		if e.complexity.LayerUsage.MediaType == nil {
+			break
+		}
+
+		return e.complexity.LayerUsage.MediaType(childComplexity), true
+
+	case "LayerUsage.SharedRepoCount":
+		if e.complexity.LayerUsage.SharedRepoCount == nil {
+			break
+		}
+
+		return e.complexity.LayerUsage.SharedRepoCount(childComplexity), true
+
+	case "LayerUsage.Size":
+		if e.complexity.LayerUsage.Size == nil {
+			break
+		}
+
+		return e.complexity.LayerUsage.Size(childComplexity), true
+
 	case "ManifestSummary.Digest":
 		if e.complexity.ManifestSummary.Digest == nil {
 			break
@@ -668,6 +740,11 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.ManifestSummary.IsSigned(childComplexity), true
 
+	case "ManifestSummary.LastPullTimestamp":
+		if e.complexity.ManifestSummary.LastPullTimestamp == nil {
+			break
+		}
+
 	case "ManifestSummary.LastUpdated":
 		if e.complexity.ManifestSummary.LastUpdated == nil {
 			break
@@ -885,6 +962,18 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.Query.Image(childComplexity, args["image"].(string)), true
 
+	case "Query.ImageLayers":
+		if e.complexity.Query.ImageLayers == nil {
+			break
+		}
+
+		args, err := ec.field_Query_ImageLayers_args(context.TODO(), rawArgs)
+		if err != nil {
+			return 0, false
+		}
+
+		return e.complexity.Query.ImageLayers(childComplexity, args["image"].(string)), true
+
 	case "Query.ImageList":
 		if e.complexity.Query.ImageList == nil {
 			break
@@ -945,6 +1034,18 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.Query.Referrers(childComplexity, args["repo"].(string), args["digest"].(string), args["type"].([]string)), true
 
+	case "Query.RepoActivity":
+		if e.complexity.Query.RepoActivity == nil {
+			break
+		}
+
+		args, err := ec.field_Query_RepoActivity_args(context.TODO(), rawArgs)
+		if err != nil {
+			return 0, false
+		}
+
+		return e.complexity.Query.RepoActivity(childComplexity, args["repo"].(string), args["reference"].(*string)), true
+
 	case "Query.RepoListWithNewestImage":
 		if e.complexity.Query.RepoListWithNewestImage == nil {
 			break
@@ -1004,6 +1105,55 @@ func (e *executableSchema) Complexity(typeName, field string, childComplexity in
 
 		return e.complexity.Referrer.Size(childComplexity), true
 
+	case "RepoEvent.Actor":
+		if e.complexity.RepoEvent.Actor == nil {
+			break
+		}
+
+		return e.complexity.RepoEvent.Actor(childComplexity), true
+
+	case "RepoEvent.Digest":
+		if e.complexity.RepoEvent.Digest == nil {
+			break
+		}
+
+		return e.complexity.RepoEvent.Digest(childComplexity), true
+
+	case "RepoEvent.MediaType":
+		if e.complexity.RepoEvent.MediaType == nil {
+			break
+		}
+
+		return e.complexity.RepoEvent.MediaType(childComplexity), true
+
+	case "RepoEvent.PreviousDigest":
+		if e.complexity.RepoEvent.PreviousDigest == nil {
+			break
+		}
+
+		return e.complexity.RepoEvent.PreviousDigest(childComplexity), true
+
+	case "RepoEvent.Reference":
+		if e.complexity.RepoEvent.Reference == nil {
+			break
+		}
+
+		return e.complexity.RepoEvent.Reference(childComplexity), true
+
+	case "RepoEvent.Timestamp":
+		if e.complexity.RepoEvent.Timestamp == nil {
+			break
+		}
+
+		return e.complexity.RepoEvent.Timestamp(childComplexity), true
+
+	case "RepoEvent.Type":
+		if e.complexity.RepoEvent.Type == nil {
+			break
+		}
+
+		return e.complexity.RepoEvent.Type(childComplexity), true
+
 	case "RepoInfo.Images":
 		if e.complexity.RepoInfo.Images == nil {
 			break
@@ -1367,6 +1517,10 @@ type ImageSummary {
     """
     DownloadCount: Int
     """
+    Timestamp of the last download of the manifest of this image
+    """
+    LastPullTimestamp: Time
+    """
     Timestamp of the last modification done to the image (from config or the last updated layer)
     """
     LastUpdated: Time
@@ -1423,6 +1577,10 @@ type ImageSummary {
     True if current user has delete permission on this tag.
     """
     IsDeletable: Boolean
+    """
+    Annotations of the image index or manifest, for manifests these include the labels of the image config
+    """
+    Annotations: [Annotation]
 }
 """
 Details about a specific version of an image for a certain operating system and architecture.
@@ -1461,6 +1619,10 @@ type ManifestSummary {
     """
     DownloadCount: Int
     """
+    Timestamp of the last download of this image manifest
+    """
+    LastPullTimestamp: Time
+    """
     List of layers matching the search criteria
     NOTE: the actual search logic for layers is not implemented at the moment
     """
@@ -1481,6 +1643,10 @@ type ManifestSummary {
     Value of the artifactType field if present else the value of the config media type
     """
     ArtifactType: String
+    """
+    Annotations of the manifest, including the labels of the image config
+    """
+    Annotations: [Annotation]
 }
 
 """
@@ -1582,6 +1748,28 @@ type LayerSummary {
     Digest: String
 }
 
+"""
+A layer of an image, with the number of other repositories sharing it
+"""
+type LayerUsage {
+    """
+    Digest of the layer content
+    """
+    Digest: String
+    """
+    Media type of the layer
+    """
+    MediaType: String
+    """
+    The compressed size of the layer in bytes
+    """
+    Size: String  # Int64 is not supported.
+    """
+    Number of the other repositories having an image with this layer, which is stored only once
+    """
+    SharedRepoCount: Int
+}
+
 """
 Information on how a layer was created
 """
@@ -1665,6 +1853,40 @@ type Referrer {
     Annotations:  [Annotation]!
 }
 
+"""
+An event of the activity log of a repository
+"""
+type RepoEvent {
+    """
+    Type of the event: push, pull, delete, or tag when an existing tag was moved to another manifest
+    """
+    Type: String
+    """
+    The tag or the digest given in the request
+    """
+    Reference: String
+    """
+    Digest of the manifest
+    """
+    Digest: String
+    """
+    Media type of the manifest
+    """
+    MediaType: String
+    """
+    Digest of the manifest the tag pointed to before a tag event
+    """
+    PreviousDigest: String
+    """
+    The user who made the request, empty for anonymous requests
+    """
+    Actor: String
+    """
+    Time of the event
+    """
+    Timestamp: Time
+}
+
 """
 Contains details about the OS and architecture of the image
 """
@@ -1965,6 +2187,14 @@ type Query {
         image: String!
     ): ImageSummary!
 
+    """
+    Returns the layers of an image, or of all the images of an index, with the number of other repositories sharing each of them
+    """
+    ImageLayers(
+        "Image name in the format ` + "`" + `repository:tag` + "`" + ` or ` + "`" + `repository@digest` + "`" + `"
+        image: String!
+    ): [LayerUsage]!
+
     """
     Returns a list of descriptors of an image or artifact manifest that are found in a <repo> and have a subject field of <digest>
     Can be filtered based on a specific artifact type <type>
@@ -1978,6 +2208,16 @@ type Query {
         type: [String!]
     ): [Referrer]!
 
+    """
+    Returns the activity log of a repository, the most recent event first
+    """
+    RepoActivity(
+        "Repository name"
+        repo: String!,
+        "Only return the events of this tag or digest"
+        reference: String
+    ): [RepoEvent]!
+
     """
     Receive RepoSummaries of repos starred by current user
     """
@@ -2182,6 +2422,21 @@ func (ec *executionContext) field_Query_GlobalSearch_args(ctx context.Context, r
 	return args, nil
 }
 
+func (ec *executionContext) field_Query_ImageLayers_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 string
+	if tmp, ok := rawArgs["image"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("image"))
+		arg0, err = ec.unmarshalNString2string(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["image"] = arg0
+	return args, nil
+}
+
 func (ec *executionContext) field_Query_ImageListForCVE_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
 	var err error
 	args := map[string]interface{}{}
@@ -2353,6 +2608,30 @@ func (ec *executionContext) field_Query_Referrers_args(ctx context.Context, rawA
 	return args, nil
 }
 
+func (ec *executionContext) field_Query_RepoActivity_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
+	var err error
+	args := map[string]interface{}{}
+	var arg0 string
+	if tmp, ok := rawArgs["repo"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("repo"))
+		arg0, err = ec.unmarshalNString2string(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["repo"] = arg0
+	var arg1 *string
+	if tmp, ok := rawArgs["reference"]; ok {
+		ctx := graphql.WithPathContext(ctx, graphql.NewPathWithField("reference"))
+		arg1, err = ec.unmarshalOString2ᚖstring(ctx, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	args["reference"] = arg1
+	return args, nil
+}
+
 func (ec *executionContext) field_Query_RepoListWithNewestImage_args(ctx context.Context, rawArgs map[string]interface{}) (map[string]interface{}, error) {
 	var err error
 	args := map[string]interface{}{}
@@ -3071,6 +3350,8 @@ func (ec *executionContext) fieldContext_GlobalSearchResult_Images(ctx context.C
 				return ec.fieldContext_ImageSummary_Size(ctx, field)
 			case "DownloadCount":
 				return ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
+			case "LastPullTimestamp":
+				return ec.fieldContext_ImageSummary_LastPullTimestamp(ctx, field)
 			case "LastUpdated":
 				return ec.fieldContext_ImageSummary_LastUpdated(ctx, field)
 			case "Description":
@@ -3099,6 +3380,8 @@ func (ec *executionContext) fieldContext_GlobalSearchResult_Images(ctx context.C
 				return ec.fieldContext_ImageSummary_Referrers(ctx, field)
 			case "IsDeletable":
 				return ec.fieldContext_ImageSummary_IsDeletable(ctx, field)
+			case "Annotations":
+				return ec.fieldContext_ImageSummary_Annotations(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type ImageSummary", field.Name)
 		},
@@ -3639,6 +3922,8 @@ func (ec *executionContext) fieldContext_ImageSummary_Manifests(ctx context.Cont
 				return ec.fieldContext_ManifestSummary_Platform(ctx, field)
 			case "DownloadCount":
 				return ec.fieldContext_ManifestSummary_DownloadCount(ctx, field)
+			case "LastPullTimestamp":
+				return ec.fieldContext_ManifestSummary_LastPullTimestamp(ctx, field)
 			case "Layers":
 				return ec.fieldContext_ManifestSummary_Layers(ctx, field)
 			case "History":
@@ -3649,6 +3934,8 @@ func (ec *executionContext) fieldContext_ImageSummary_Manifests(ctx context.Cont
 				return ec.fieldContext_ManifestSummary_Referrers(ctx, field)
 			case "ArtifactType":
 				return ec.fieldContext_ManifestSummary_ArtifactType(ctx, field)
+			case "Annotations":
+				return ec.fieldContext_ManifestSummary_Annotations(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type ManifestSummary", field.Name)
 		},
@@ -3738,6 +4025,47 @@ func (ec *executionContext) fieldContext_ImageSummary_DownloadCount(ctx context.
 	return fc, nil
 }
 
+func (ec *executionContext) _ImageSummary_LastPullTimestamp(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_LastPullTimestamp(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.LastPullTimestamp, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*time.Time)
+	fc.Result = res
+	return ec.marshalOTime2ᚖtimeᚐTime(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ImageSummary_LastPullTimestamp(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ImageSummary",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Time does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _ImageSummary_LastUpdated(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_ImageSummary_LastUpdated(ctx, field)
 	if err != nil {
@@ -4348,6 +4676,53 @@ func (ec *executionContext) fieldContext_ImageSummary_IsDeletable(ctx context.Co
 	return fc, nil
 }
 
+func (ec *executionContext) _ImageSummary_Annotations(ctx context.Context, field graphql.CollectedField, obj *ImageSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ImageSummary_Annotations(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Annotations, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.([]*Annotation)
+	fc.Result = res
+	return ec.marshalOAnnotation2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ImageSummary_Annotations(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ImageSummary",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Key":
+				return ec.fieldContext_Annotation_Key(ctx, field)
+			case "Value":
+				return ec.fieldContext_Annotation_Value(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type Annotation", field.Name)
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _ImageVulnerabilitySummary_MaxSeverity(ctx context.Context, field graphql.CollectedField, obj *ImageVulnerabilitySummary) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_ImageVulnerabilitySummary_MaxSeverity(ctx, field)
 	if err != nil {
@@ -4817,8 +5192,172 @@ func (ec *executionContext) fieldContext_LayerSummary_Digest(ctx context.Context
 	return fc, nil
 }
 
-func (ec *executionContext) _ManifestSummary_Digest(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_ManifestSummary_Digest(ctx, field)
+func (ec *executionContext) _LayerUsage_Digest(ctx context.Context, field graphql.CollectedField, obj *LayerUsage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_LayerUsage_Digest(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Digest, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_LayerUsage_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "LayerUsage",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _LayerUsage_MediaType(ctx context.Context, field graphql.CollectedField, obj *LayerUsage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_LayerUsage_MediaType(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.MediaType, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_LayerUsage_MediaType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "LayerUsage",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _LayerUsage_Size(ctx context.Context, field graphql.CollectedField, obj *LayerUsage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_LayerUsage_Size(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Size, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_LayerUsage_Size(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "LayerUsage",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _LayerUsage_SharedRepoCount(ctx context.Context, field graphql.CollectedField, obj *LayerUsage) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_LayerUsage_SharedRepoCount(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.SharedRepoCount, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*int)
+	fc.Result = res
+	return ec.marshalOInt2ᚖint(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_LayerUsage_SharedRepoCount(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "LayerUsage",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Int does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _ManifestSummary_Digest(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_Digest(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -5159,6 +5698,47 @@ func (ec *executionContext) fieldContext_ManifestSummary_DownloadCount(ctx conte
 	return fc, nil
 }
 
+func (ec *executionContext) _ManifestSummary_LastPullTimestamp(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_LastPullTimestamp(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.LastPullTimestamp, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*time.Time)
+	fc.Result = res
+	return ec.marshalOTime2ᚖtimeᚐTime(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ManifestSummary_LastPullTimestamp(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ManifestSummary",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type Time does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _ManifestSummary_Layers(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_ManifestSummary_Layers(ctx, field)
 	if err != nil {
@@ -5404,6 +5984,53 @@ func (ec *executionContext) fieldContext_ManifestSummary_ArtifactType(ctx contex
 	return fc, nil
 }
 
+func (ec *executionContext) _ManifestSummary_Annotations(ctx context.Context, field graphql.CollectedField, obj *ManifestSummary) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_ManifestSummary_Annotations(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Annotations, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.([]*Annotation)
+	fc.Result = res
+	return ec.marshalOAnnotation2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_ManifestSummary_Annotations(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "ManifestSummary",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Key":
+				return ec.fieldContext_Annotation_Key(ctx, field)
+			case "Value":
+				return ec.fieldContext_Annotation_Value(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type Annotation", field.Name)
+		},
+	}
+	return fc, nil
+}
+
 func (ec *executionContext) _PackageInfo_Name(ctx context.Context, field graphql.CollectedField, obj *PackageInfo) (ret graphql.Marshaler) {
 	fc, err := ec.fieldContext_PackageInfo_Name(ctx, field)
 	if err != nil {
@@ -5756,6 +6383,8 @@ func (ec *executionContext) fieldContext_PaginatedImagesResult_Results(ctx conte
 				return ec.fieldContext_ImageSummary_Size(ctx, field)
 			case "DownloadCount":
 				return ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
+			case "LastPullTimestamp":
+				return ec.fieldContext_ImageSummary_LastPullTimestamp(ctx, field)
 			case "LastUpdated":
 				return ec.fieldContext_ImageSummary_LastUpdated(ctx, field)
 			case "Description":
@@ -5784,6 +6413,8 @@ func (ec *executionContext) fieldContext_PaginatedImagesResult_Results(ctx conte
 				return ec.fieldContext_ImageSummary_Referrers(ctx, field)
 			case "IsDeletable":
 				return ec.fieldContext_ImageSummary_IsDeletable(ctx, field)
+			case "Annotations":
+				return ec.fieldContext_ImageSummary_Annotations(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type ImageSummary", field.Name)
 		},
@@ -6659,6 +7290,8 @@ func (ec *executionContext) fieldContext_Query_Image(ctx context.Context, field
 				return ec.fieldContext_ImageSummary_Size(ctx, field)
 			case "DownloadCount":
 				return ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
+			case "LastPullTimestamp":
+				return ec.fieldContext_ImageSummary_LastPullTimestamp(ctx, field)
 			case "LastUpdated":
 				return ec.fieldContext_ImageSummary_LastUpdated(ctx, field)
 			case "Description":
@@ -6687,6 +7320,8 @@ func (ec *executionContext) fieldContext_Query_Image(ctx context.Context, field
 				return ec.fieldContext_ImageSummary_Referrers(ctx, field)
 			case "IsDeletable":
 				return ec.fieldContext_ImageSummary_IsDeletable(ctx, field)
+			case "Annotations":
+				return ec.fieldContext_ImageSummary_Annotations(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type ImageSummary", field.Name)
 		},
@@ -6705,8 +7340,8 @@ func (ec *executionContext) fieldContext_Query_Image(ctx context.Context, field
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_Referrers(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_Referrers(ctx, field)
+func (ec *executionContext) _Query_ImageLayers(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_ImageLayers(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -6719,7 +7354,7 @@ func (ec *executionContext) _Query_Referrers(ctx context.Context, field graphql.
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().Referrers(rctx, fc.Args["repo"].(string), fc.Args["digest"].(string), fc.Args["type"].([]string))
+		return ec.resolvers.Query().ImageLayers(rctx, fc.Args["image"].(string))
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -6731,12 +7366,12 @@ func (ec *executionContext) _Query_Referrers(ctx context.Context, field graphql.
 		}
 		return graphql.Null
 	}
-	res := resTmp.([]*Referrer)
+	res := resTmp.([]*LayerUsage)
 	fc.Result = res
-	return ec.marshalNReferrer2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐReferrer(ctx, field.Selections, res)
+	return ec.marshalNLayerUsage2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐLayerUsage(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Query_Referrers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_Query_ImageLayers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
 		Object:     "Query",
 		Field:      field,
@@ -6744,18 +7379,16 @@ func (ec *executionContext) fieldContext_Query_Referrers(ctx context.Context, fi
 		IsResolver: true,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
 			switch field.Name {
+			case "Digest":
+				return ec.fieldContext_LayerUsage_Digest(ctx, field)
 			case "MediaType":
-				return ec.fieldContext_Referrer_MediaType(ctx, field)
-			case "ArtifactType":
-				return ec.fieldContext_Referrer_ArtifactType(ctx, field)
+				return ec.fieldContext_LayerUsage_MediaType(ctx, field)
 			case "Size":
-				return ec.fieldContext_Referrer_Size(ctx, field)
-			case "Digest":
-				return ec.fieldContext_Referrer_Digest(ctx, field)
-			case "Annotations":
-				return ec.fieldContext_Referrer_Annotations(ctx, field)
+				return ec.fieldContext_LayerUsage_Size(ctx, field)
+			case "SharedRepoCount":
+				return ec.fieldContext_LayerUsage_SharedRepoCount(ctx, field)
 			}
-			return nil, fmt.Errorf("no field named %q was found under type Referrer", field.Name)
+			return nil, fmt.Errorf("no field named %q was found under type LayerUsage", field.Name)
 		},
 	}
 	defer func() {
@@ -6765,15 +7398,15 @@ func (ec *executionContext) fieldContext_Query_Referrers(ctx context.Context, fi
 		}
 	}()
 	ctx = graphql.WithFieldContext(ctx, fc)
-	if fc.Args, err = ec.field_Query_Referrers_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+	if fc.Args, err = ec.field_Query_ImageLayers_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
 		ec.Error(ctx, err)
 		return fc, err
 	}
 	return fc, nil
 }
 
-func (ec *executionContext) _Query_StarredRepos(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Query_StarredRepos(ctx, field)
+func (ec *executionContext) _Query_Referrers(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_Referrers(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -6786,7 +7419,7 @@ func (ec *executionContext) _Query_StarredRepos(ctx context.Context, field graph
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Query().StarredRepos(rctx, fc.Args["requestedPage"].(*PageInput))
+		return ec.resolvers.Query().Referrers(rctx, fc.Args["repo"].(string), fc.Args["digest"].(string), fc.Args["type"].([]string))
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -6798,7 +7431,145 @@ func (ec *executionContext) _Query_StarredRepos(ctx context.Context, field graph
 		}
 		return graphql.Null
 	}
-	res := resTmp.(*PaginatedReposResult)
+	res := resTmp.([]*Referrer)
+	fc.Result = res
+	return ec.marshalNReferrer2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐReferrer(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_Referrers(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "MediaType":
+				return ec.fieldContext_Referrer_MediaType(ctx, field)
+			case "ArtifactType":
+				return ec.fieldContext_Referrer_ArtifactType(ctx, field)
+			case "Size":
+				return ec.fieldContext_Referrer_Size(ctx, field)
+			case "Digest":
+				return ec.fieldContext_Referrer_Digest(ctx, field)
+			case "Annotations":
+				return ec.fieldContext_Referrer_Annotations(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type Referrer", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_Referrers_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return fc, err
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_RepoActivity(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_RepoActivity(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().RepoActivity(rctx, fc.Args["repo"].(string), fc.Args["reference"].(*string))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.([]*RepoEvent)
+	fc.Result = res
+	return ec.marshalNRepoEvent2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoEvent(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Query_RepoActivity(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Query",
+		Field:      field,
+		IsMethod:   true,
+		IsResolver: true,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Type":
+				return ec.fieldContext_RepoEvent_Type(ctx, field)
+			case "Reference":
+				return ec.fieldContext_RepoEvent_Reference(ctx, field)
+			case "Digest":
+				return ec.fieldContext_RepoEvent_Digest(ctx, field)
+			case "MediaType":
+				return ec.fieldContext_RepoEvent_MediaType(ctx, field)
+			case "PreviousDigest":
+				return ec.fieldContext_RepoEvent_PreviousDigest(ctx, field)
+			case "Actor":
+				return ec.fieldContext_RepoEvent_Actor(ctx, field)
+			case "Timestamp":
+				return ec.fieldContext_RepoEvent_Timestamp(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type RepoEvent", field.Name)
+		},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = ec.Recover(ctx, r)
+			ec.Error(ctx, err)
+		}
+	}()
+	ctx = graphql.WithFieldContext(ctx, fc)
+	if fc.Args, err = ec.field_Query_RepoActivity_args(ctx, field.ArgumentMap(ec.Variables)); err != nil {
+		ec.Error(ctx, err)
+		return fc, err
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Query_StarredRepos(ctx context.Context, field graphql.CollectedField) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Query_StarredRepos(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return ec.resolvers.Query().StarredRepos(rctx, fc.Args["requestedPage"].(*PageInput))
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.(*PaginatedReposResult)
 	fc.Result = res
 	return ec.marshalNPaginatedReposResult2ᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedReposResult(ctx, field.Selections, res)
 }
@@ -7160,7 +7931,303 @@ func (ec *executionContext) _Referrer_Digest(ctx context.Context, field graphql.
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Digest, nil
+		return obj.Digest, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Referrer_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Referrer",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _Referrer_Annotations(ctx context.Context, field graphql.CollectedField, obj *Referrer) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_Referrer_Annotations(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Annotations, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		if !graphql.HasFieldError(ctx, fc) {
+			ec.Errorf(ctx, "must not be null")
+		}
+		return graphql.Null
+	}
+	res := resTmp.([]*Annotation)
+	fc.Result = res
+	return ec.marshalNAnnotation2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_Referrer_Annotations(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "Referrer",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			switch field.Name {
+			case "Key":
+				return ec.fieldContext_Annotation_Key(ctx, field)
+			case "Value":
+				return ec.fieldContext_Annotation_Value(ctx, field)
+			}
+			return nil, fmt.Errorf("no field named %q was found under type Annotation", field.Name)
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _RepoEvent_Type(ctx context.Context, field graphql.CollectedField, obj *RepoEvent) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_RepoEvent_Type(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Type, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_RepoEvent_Type(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "RepoEvent",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _RepoEvent_Reference(ctx context.Context, field graphql.CollectedField, obj *RepoEvent) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_RepoEvent_Reference(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Reference, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_RepoEvent_Reference(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "RepoEvent",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _RepoEvent_Digest(ctx context.Context, field graphql.CollectedField, obj *RepoEvent) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_RepoEvent_Digest(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Digest, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_RepoEvent_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "RepoEvent",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _RepoEvent_MediaType(ctx context.Context, field graphql.CollectedField, obj *RepoEvent) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_RepoEvent_MediaType(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.MediaType, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_RepoEvent_MediaType(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "RepoEvent",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _RepoEvent_PreviousDigest(ctx context.Context, field graphql.CollectedField, obj *RepoEvent) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_RepoEvent_PreviousDigest(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.PreviousDigest, nil
+	})
+	if err != nil {
+		ec.Error(ctx, err)
+		return graphql.Null
+	}
+	if resTmp == nil {
+		return graphql.Null
+	}
+	res := resTmp.(*string)
+	fc.Result = res
+	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
+}
+
+func (ec *executionContext) fieldContext_RepoEvent_PreviousDigest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+	fc = &graphql.FieldContext{
+		Object:     "RepoEvent",
+		Field:      field,
+		IsMethod:   false,
+		IsResolver: false,
+		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
+			return nil, errors.New("field of type String does not have child fields")
+		},
+	}
+	return fc, nil
+}
+
+func (ec *executionContext) _RepoEvent_Actor(ctx context.Context, field graphql.CollectedField, obj *RepoEvent) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_RepoEvent_Actor(ctx, field)
+	if err != nil {
+		return graphql.Null
+	}
+	ctx = graphql.WithFieldContext(ctx, fc)
+	defer func() {
+		if r := recover(); r != nil {
+			ec.Error(ctx, ec.Recover(ctx, r))
+			ret = graphql.Null
+		}
+	}()
+	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
+		ctx = rctx // use context from middleware stack in children
+		return obj.Actor, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -7174,9 +8241,9 @@ func (ec *executionContext) _Referrer_Digest(ctx context.Context, field graphql.
 	return ec.marshalOString2ᚖstring(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Referrer_Digest(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_RepoEvent_Actor(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Referrer",
+		Object:     "RepoEvent",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
@@ -7187,8 +8254,8 @@ func (ec *executionContext) fieldContext_Referrer_Digest(ctx context.Context, fi
 	return fc, nil
 }
 
-func (ec *executionContext) _Referrer_Annotations(ctx context.Context, field graphql.CollectedField, obj *Referrer) (ret graphql.Marshaler) {
-	fc, err := ec.fieldContext_Referrer_Annotations(ctx, field)
+func (ec *executionContext) _RepoEvent_Timestamp(ctx context.Context, field graphql.CollectedField, obj *RepoEvent) (ret graphql.Marshaler) {
+	fc, err := ec.fieldContext_RepoEvent_Timestamp(ctx, field)
 	if err != nil {
 		return graphql.Null
 	}
@@ -7201,37 +8268,28 @@ func (ec *executionContext) _Referrer_Annotations(ctx context.Context, field gra
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (interface{}, error) {
 		ctx = rctx // use context from middleware stack in children
-		return obj.Annotations, nil
+		return obj.Timestamp, nil
 	})
 	if err != nil {
 		ec.Error(ctx, err)
 		return graphql.Null
 	}
 	if resTmp == nil {
-		if !graphql.HasFieldError(ctx, fc) {
-			ec.Errorf(ctx, "must not be null")
-		}
 		return graphql.Null
 	}
-	res := resTmp.([]*Annotation)
+	res := resTmp.(*time.Time)
 	fc.Result = res
-	return ec.marshalNAnnotation2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx, field.Selections, res)
+	return ec.marshalOTime2ᚖtimeᚐTime(ctx, field.Selections, res)
 }
 
-func (ec *executionContext) fieldContext_Referrer_Annotations(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
+func (ec *executionContext) fieldContext_RepoEvent_Timestamp(ctx context.Context, field graphql.CollectedField) (fc *graphql.FieldContext, err error) {
 	fc = &graphql.FieldContext{
-		Object:     "Referrer",
+		Object:     "RepoEvent",
 		Field:      field,
 		IsMethod:   false,
 		IsResolver: false,
 		Child: func(ctx context.Context, field graphql.CollectedField) (*graphql.FieldContext, error) {
-			switch field.Name {
-			case "Key":
-				return ec.fieldContext_Annotation_Key(ctx, field)
-			case "Value":
-				return ec.fieldContext_Annotation_Value(ctx, field)
-			}
-			return nil, fmt.Errorf("no field named %q was found under type Annotation", field.Name)
+			return nil, errors.New("field of type Time does not have child fields")
 		},
 	}
 	return fc, nil
@@ -7287,6 +8345,8 @@ func (ec *executionContext) fieldContext_RepoInfo_Images(ctx context.Context, fi
 				return ec.fieldContext_ImageSummary_Size(ctx, field)
 			case "DownloadCount":
 				return ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
+			case "LastPullTimestamp":
+				return ec.fieldContext_ImageSummary_LastPullTimestamp(ctx, field)
 			case "LastUpdated":
 				return ec.fieldContext_ImageSummary_LastUpdated(ctx, field)
 			case "Description":
@@ -7315,6 +8375,8 @@ func (ec *executionContext) fieldContext_RepoInfo_Images(ctx context.Context, fi
 				return ec.fieldContext_ImageSummary_Referrers(ctx, field)
 			case "IsDeletable":
 				return ec.fieldContext_ImageSummary_IsDeletable(ctx, field)
+			case "Annotations":
+				return ec.fieldContext_ImageSummary_Annotations(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type ImageSummary", field.Name)
 		},
@@ -7648,6 +8710,8 @@ func (ec *executionContext) fieldContext_RepoSummary_NewestImage(ctx context.Con
 				return ec.fieldContext_ImageSummary_Size(ctx, field)
 			case "DownloadCount":
 				return ec.fieldContext_ImageSummary_DownloadCount(ctx, field)
+			case "LastPullTimestamp":
+				return ec.fieldContext_ImageSummary_LastPullTimestamp(ctx, field)
 			case "LastUpdated":
 				return ec.fieldContext_ImageSummary_LastUpdated(ctx, field)
 			case "Description":
@@ -7676,6 +8740,8 @@ func (ec *executionContext) fieldContext_RepoSummary_NewestImage(ctx context.Con
 				return ec.fieldContext_ImageSummary_Referrers(ctx, field)
 			case "IsDeletable":
 				return ec.fieldContext_ImageSummary_IsDeletable(ctx, field)
+			case "Annotations":
+				return ec.fieldContext_ImageSummary_Annotations(ctx, field)
 			}
 			return nil, fmt.Errorf("no field named %q was found under type ImageSummary", field.Name)
 		},
@@ -10125,6 +11191,8 @@ func (ec *executionContext) _ImageSummary(ctx context.Context, sel ast.Selection
 			out.Values[i] = ec._ImageSummary_Size(ctx, field, obj)
 		case "DownloadCount":
 			out.Values[i] = ec._ImageSummary_DownloadCount(ctx, field, obj)
+		case "LastPullTimestamp":
+			out.Values[i] = ec._ImageSummary_LastPullTimestamp(ctx, field, obj)
 		case "LastUpdated":
 			out.Values[i] = ec._ImageSummary_LastUpdated(ctx, field, obj)
 		case "Description":
@@ -10153,6 +11221,8 @@ func (ec *executionContext) _ImageSummary(ctx context.Context, sel ast.Selection
 			out.Values[i] = ec._ImageSummary_Referrers(ctx, field, obj)
 		case "IsDeletable":
 			out.Values[i] = ec._ImageSummary_IsDeletable(ctx, field, obj)
+		case "Annotations":
+			out.Values[i] = ec._ImageSummary_Annotations(ctx, field, obj)
 		default:
 			panic("unknown field " + strconv.Quote(field.Name))
 		}
@@ -10300,6 +11370,48 @@ func (ec *executionContext) _LayerSummary(ctx context.Context, sel ast.Selection
 	return out
 }
 
+var layerUsageImplementors = []string{"LayerUsage"}
+
+func (ec *executionContext) _LayerUsage(ctx context.Context, sel ast.SelectionSet, obj *LayerUsage) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, layerUsageImplementors)
+
+	out := graphql.NewFieldSet(fields)
+	deferred := make(map[string]*graphql.FieldSet)
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("LayerUsage")
+		case "Digest":
+			out.Values[i] = ec._LayerUsage_Digest(ctx, field, obj)
+		case "MediaType":
+			out.Values[i] = ec._LayerUsage_MediaType(ctx, field, obj)
+		case "Size":
+			out.Values[i] = ec._LayerUsage_Size(ctx, field, obj)
+		case "SharedRepoCount":
+			out.Values[i] = ec._LayerUsage_SharedRepoCount(ctx, field, obj)
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch(ctx)
+	if out.Invalids > 0 {
+		return graphql.Null
+	}
+
+	atomic.AddInt32(&ec.deferred, int32(len(deferred)))
+
+	for label, dfs := range deferred {
+		ec.processDeferredGroup(graphql.DeferredGroup{
+			Label:    label,
+			Path:     graphql.GetPath(ctx),
+			FieldSet: dfs,
+			Context:  ctx,
+		})
+	}
+
+	return out
+}
+
 var manifestSummaryImplementors = []string{"ManifestSummary"}
 
 func (ec *executionContext) _ManifestSummary(ctx context.Context, sel ast.SelectionSet, obj *ManifestSummary) graphql.Marshaler {
@@ -10327,6 +11439,8 @@ func (ec *executionContext) _ManifestSummary(ctx context.Context, sel ast.Select
 			out.Values[i] = ec._ManifestSummary_Platform(ctx, field, obj)
 		case "DownloadCount":
 			out.Values[i] = ec._ManifestSummary_DownloadCount(ctx, field, obj)
+		case "LastPullTimestamp":
+			out.Values[i] = ec._ManifestSummary_LastPullTimestamp(ctx, field, obj)
 		case "Layers":
 			out.Values[i] = ec._ManifestSummary_Layers(ctx, field, obj)
 		case "History":
@@ -10337,6 +11451,8 @@ func (ec *executionContext) _ManifestSummary(ctx context.Context, sel ast.Select
 			out.Values[i] = ec._ManifestSummary_Referrers(ctx, field, obj)
 		case "ArtifactType":
 			out.Values[i] = ec._ManifestSummary_ArtifactType(ctx, field, obj)
+		case "Annotations":
+			out.Values[i] = ec._ManifestSummary_Annotations(ctx, field, obj)
 		default:
 			panic("unknown field " + strconv.Quote(field.Name))
 		}
@@ -10826,6 +11942,28 @@ func (ec *executionContext) _Query(ctx context.Context, sel ast.SelectionSet) gr
 					func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
 			}
 
+			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return rrm(innerCtx) })
+		case "ImageLayers":
+			field := field
+
+			innerFunc := func(ctx context.Context, fs *graphql.FieldSet) (res graphql.Marshaler) {
+				defer func() {
+					if r := recover(); r != nil {
+						ec.Error(ctx, ec.Recover(ctx, r))
+					}
+				}()
+				res = ec._Query_ImageLayers(ctx, field)
+				if res == graphql.Null {
+					atomic.AddUint32(&fs.Invalids, 1)
+				}
+				return res
+			}
+
+			rrm := func(ctx context.Context) graphql.Marshaler {
+				return ec.OperationContext.RootResolverMiddleware(ctx,
+					func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
+			}
+
 			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return rrm(innerCtx) })
 		case "Referrers":
 			field := field
@@ -10848,6 +11986,28 @@ func (ec *executionContext) _Query(ctx context.Context, sel ast.SelectionSet) gr
 					func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
 			}
 
+			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return rrm(innerCtx) })
+		case "RepoActivity":
+			field := field
+
+			innerFunc := func(ctx context.Context, fs *graphql.FieldSet) (res graphql.Marshaler) {
+				defer func() {
+					if r := recover(); r != nil {
+						ec.Error(ctx, ec.Recover(ctx, r))
+					}
+				}()
+				res = ec._Query_RepoActivity(ctx, field)
+				if res == graphql.Null {
+					atomic.AddUint32(&fs.Invalids, 1)
+				}
+				return res
+			}
+
+			rrm := func(ctx context.Context) graphql.Marshaler {
+				return ec.OperationContext.RootResolverMiddleware(ctx,
+					func(ctx context.Context) graphql.Marshaler { return innerFunc(ctx, out) })
+			}
+
 			out.Concurrently(i, func(ctx context.Context) graphql.Marshaler { return rrm(innerCtx) })
 		case "StarredRepos":
 			field := field
@@ -10971,6 +12131,54 @@ func (ec *executionContext) _Referrer(ctx context.Context, sel ast.SelectionSet,
 	return out
 }
 
+var repoEventImplementors = []string{"RepoEvent"}
+
+func (ec *executionContext) _RepoEvent(ctx context.Context, sel ast.SelectionSet, obj *RepoEvent) graphql.Marshaler {
+	fields := graphql.CollectFields(ec.OperationContext, sel, repoEventImplementors)
+
+	out := graphql.NewFieldSet(fields)
+	deferred := make(map[string]*graphql.FieldSet)
+	for i, field := range fields {
+		switch field.Name {
+		case "__typename":
+			out.Values[i] = graphql.MarshalString("RepoEvent")
+		case "Type":
+			out.Values[i] = ec._RepoEvent_Type(ctx, field, obj)
+		case "Reference":
+			out.Values[i] = ec._RepoEvent_Reference(ctx, field, obj)
+		case "Digest":
+			out.Values[i] = ec._RepoEvent_Digest(ctx, field, obj)
+		case "MediaType":
+			out.Values[i] = ec._RepoEvent_MediaType(ctx, field, obj)
+		case "PreviousDigest":
+			out.Values[i] = ec._RepoEvent_PreviousDigest(ctx, field, obj)
+		case "Actor":
+			out.Values[i] = ec._RepoEvent_Actor(ctx, field, obj)
+		case "Timestamp":
+			out.Values[i] = ec._RepoEvent_Timestamp(ctx, field, obj)
+		default:
+			panic("unknown field " + strconv.Quote(field.Name))
+		}
+	}
+	out.Dispatch(ctx)
+	if out.Invalids > 0 {
+		return graphql.Null
+	}
+
+	atomic.AddInt32(&ec.deferred, int32(len(deferred)))
+
+	for label, dfs := range deferred {
+		ec.processDeferredGroup(graphql.DeferredGroup{
+			Label:    label,
+			Path:     graphql.GetPath(ctx),
+			FieldSet: dfs,
+			Context:  ctx,
+		})
+	}
+
+	return out
+}
+
 var repoInfoImplementors = []string{"RepoInfo"}
 
 func (ec *executionContext) _RepoInfo(ctx context.Context, sel ast.SelectionSet, obj *RepoInfo) graphql.Marshaler {
@@ -11585,6 +12793,44 @@ func (ec *executionContext) marshalNInt2int(ctx context.Context, sel ast.Selecti
 	return res
 }
 
+func (ec *executionContext) marshalNLayerUsage2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐLayerUsage(ctx context.Context, sel ast.SelectionSet, v []*LayerUsage) graphql.Marshaler {
+	ret := make(graphql.Array, len(v))
+	var wg sync.WaitGroup
+	isLen1 := len(v) == 1
+	if !isLen1 {
+		wg.Add(len(v))
+	}
+	for i := range v {
+		i := i
+		fc := &graphql.FieldContext{
+			Index:  &i,
+			Result: &v[i],
+		}
+		ctx := graphql.WithFieldContext(ctx, fc)
+		f := func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					ec.Error(ctx, ec.Recover(ctx, r))
+					ret = nil
+				}
+			}()
+			if !isLen1 {
+				defer wg.Done()
+			}
+			ret[i] = ec.marshalOLayerUsage2ᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐLayerUsage(ctx, sel, v[i])
+		}
+		if isLen1 {
+			f(i)
+		} else {
+			go f(i)
+		}
+
+	}
+	wg.Wait()
+
+	return ret
+}
+
 func (ec *executionContext) marshalNPaginatedImagesResult2zotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐPaginatedImagesResult(ctx context.Context, sel ast.SelectionSet, v PaginatedImagesResult) graphql.Marshaler {
 	return ec._PaginatedImagesResult(ctx, sel, &v)
 }
@@ -11651,6 +12897,44 @@ func (ec *executionContext) marshalNReferrer2ᚕᚖzotregistryᚗdevᚋzotᚋpkg
 	return ret
 }
 
+func (ec *executionContext) marshalNRepoEvent2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoEvent(ctx context.Context, sel ast.SelectionSet, v []*RepoEvent) graphql.Marshaler {
+	ret := make(graphql.Array, len(v))
+	var wg sync.WaitGroup
+	isLen1 := len(v) == 1
+	if !isLen1 {
+		wg.Add(len(v))
+	}
+	for i := range v {
+		i := i
+		fc := &graphql.FieldContext{
+			Index:  &i,
+			Result: &v[i],
+		}
+		ctx := graphql.WithFieldContext(ctx, fc)
+		f := func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					ec.Error(ctx, ec.Recover(ctx, r))
+					ret = nil
+				}
+			}()
+			if !isLen1 {
+				defer wg.Done()
+			}
+			ret[i] = ec.marshalORepoEvent2ᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoEvent(ctx, sel, v[i])
+		}
+		if isLen1 {
+			f(i)
+		} else {
+			go f(i)
+		}
+
+	}
+	wg.Wait()
+
+	return ret
+}
+
 func (ec *executionContext) marshalNRepoInfo2zotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoInfo(ctx context.Context, sel ast.SelectionSet, v RepoInfo) graphql.Marshaler {
 	return ec._RepoInfo(ctx, sel, &v)
 }
@@ -11987,6 +13271,47 @@ func (ec *executionContext) marshalN__TypeKind2string(ctx context.Context, sel a
 	return res
 }
 
+func (ec *executionContext) marshalOAnnotation2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx context.Context, sel ast.SelectionSet, v []*Annotation) graphql.Marshaler {
+	if v == nil {
+		return graphql.Null
+	}
+	ret := make(graphql.Array, len(v))
+	var wg sync.WaitGroup
+	isLen1 := len(v) == 1
+	if !isLen1 {
+		wg.Add(len(v))
+	}
+	for i := range v {
+		i := i
+		fc := &graphql.FieldContext{
+			Index:  &i,
+			Result: &v[i],
+		}
+		ctx := graphql.WithFieldContext(ctx, fc)
+		f := func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					ec.Error(ctx, ec.Recover(ctx, r))
+					ret = nil
+				}
+			}()
+			if !isLen1 {
+				defer wg.Done()
+			}
+			ret[i] = ec.marshalOAnnotation2ᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx, sel, v[i])
+		}
+		if isLen1 {
+			f(i)
+		} else {
+			go f(i)
+		}
+
+	}
+	wg.Wait()
+
+	return ret
+}
+
 func (ec *executionContext) marshalOAnnotation2ᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐAnnotation(ctx context.Context, sel ast.SelectionSet, v *Annotation) graphql.Marshaler {
 	if v == nil {
 		return graphql.Null
@@ -12250,6 +13575,13 @@ func (ec *executionContext) marshalOLayerSummary2ᚖzotregistryᚗdevᚋzotᚋpk
 	return ec._LayerSummary(ctx, sel, v)
 }
 
+func (ec *executionContext) marshalOLayerUsage2ᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐLayerUsage(ctx context.Context, sel ast.SelectionSet, v *LayerUsage) graphql.Marshaler {
+	if v == nil {
+		return graphql.Null
+	}
+	return ec._LayerUsage(ctx, sel, v)
+}
+
 func (ec *executionContext) marshalOManifestSummary2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐManifestSummary(ctx context.Context, sel ast.SelectionSet, v []*ManifestSummary) graphql.Marshaler {
 	if v == nil {
 		return graphql.Null
@@ -12457,6 +13789,13 @@ func (ec *executionContext) marshalOReferrer2ᚖzotregistryᚗdevᚋzotᚋpkgᚋ
 	return ec._Referrer(ctx, sel, v)
 }
 
+func (ec *executionContext) marshalORepoEvent2ᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoEvent(ctx context.Context, sel ast.SelectionSet, v *RepoEvent) graphql.Marshaler {
+	if v == nil {
+		return graphql.Null
+	}
+	return ec._RepoEvent(ctx, sel, v)
+}
+
 func (ec *executionContext) marshalORepoSummary2ᚕᚖzotregistryᚗdevᚋzotᚋpkgᚋextensionsᚋsearchᚋgql_generatedᚐRepoSummary(ctx context.Context, sel ast.SelectionSet, v []*RepoSummary) graphql.Marshaler {
 	if v == nil {
 		return graphql.Null
@@ -109,6 +109,8 @@ type ImageSummary struct {
 	Size *string `json:"Size,omitempty"`
 	// Number of downloads of the manifest of this image
 	DownloadCount *int `json:"DownloadCount,omitempty"`
+	// Timestamp of the last download of the manifest of this image
+	LastPullTimestamp *time.Time `json:"LastPullTimestamp,omitempty"`
 	// Timestamp of the last modification done to the image (from config or the last updated layer)
 	LastUpdated *time.Time `json:"LastUpdated,omitempty"`
 	// Human-readable description of the software packaged in the image
@@ -138,6 +140,8 @@ type ImageSummary struct {
 	Referrers []*Referrer `json:"Referrers,omitempty"`
 	// True if current user has delete permission on this tag.
 	IsDeletable *bool `json:"IsDeletable,omitempty"`
+	// Annotations of the image index or manifest, for manifests these include the labels of the image config
+	Annotations []*Annotation `json:"Annotations,omitempty"`
 }
 
 // Contains summary of vulnerabilities found in a specific image
@@ -174,6 +178,18 @@ type LayerSummary struct {
 	Digest *string `json:"Digest,omitempty"`
 }
 
+// A layer of an image, with the number of other repositories sharing it
+type LayerUsage struct {
+	// Digest of the layer content
+	Digest *string `json:"Digest,omitempty"`
+	// Media type of the layer
+	MediaType *string `json:"MediaType,omitempty"`
+	// The compressed size of the layer in bytes
+	Size *string `json:"Size,omitempty"`
+	// Number of the other repositories having an image with this layer, which is stored only once
+	SharedRepoCount *int `json:"SharedRepoCount,omitempty"`
+}
+
 // Details about a specific version of an image for a certain operating system and architecture.
 type ManifestSummary struct {
 	// Digest of the manifest file associated with this image
@@ -192,6 +208,8 @@ type ManifestSummary struct {
 	Platform *Platform `json:"Platform,omitempty"`
 	// Total number of image manifest downloads from this repository
 	DownloadCount *int `json:"DownloadCount,omitempty"`
+	// Timestamp of the last download of this image manifest
+	LastPullTimestamp *time.Time `json:"LastPullTimestamp,omitempty"`
 	// List of layers matching the search criteria
 	// NOTE: the actual search logic for layers is not implemented at the moment
 	Layers []*LayerSummary `json:"Layers,omitempty"`
@@ -203,6 +221,8 @@ type ManifestSummary struct {
 	Referrers []*Referrer `json:"Referrers,omitempty"`
 	// Value of the artifactType field if present else the value of the config media type
 	ArtifactType *string `json:"ArtifactType,omitempty"`
+	// Annotations of the manifest, including the labels of the image config
+	Annotations []*Annotation `json:"Annotations,omitempty"`
 }
 
 // Contains the name of the package, the current installed version and the version where the CVE was fixed
@@ -280,6 +300,24 @@ type Referrer struct {
 	Annotations []*Annotation `json:"Annotations"`
 }
 
+// An event of the activity log of a repository
+type RepoEvent struct {
+	// Type of the event: push, pull, delete, or tag when an existing tag was moved to another manifest
+	Type *string `json:"Type,omitempty"`
+	// The tag or the digest given in the request
+	Reference *string `json:"Reference,omitempty"`
+	// Digest of the manifest
+	Digest *string `json:"Digest,omitempty"`
+	// Media type of the manifest
+	MediaType *string `json:"MediaType,omitempty"`
+	// Digest of the manifest the tag pointed to before a tag event
+	PreviousDigest *string `json:"PreviousDigest,omitempty"`
+	// The user who made the request, empty for anonymous requests
+	Actor *string `json:"Actor,omitempty"`
+	// Time of the event
+	Timestamp *time.Time `json:"Timestamp,omitempty"`
+}
+
 // Contains details about the repo: both general information on the repo, and the list of images
 type RepoInfo struct {
 	// List of images in the repo
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	godigest "github.com/opencontainers/go-digest"
@@ -1242,6 +1243,144 @@ func getImageList(ctx context.Context, repo string, metaDB mTypes.MetaDB, cveInf
 	}, nil
 }
 
+// getRepoActivity returns the events of the activity log of the repo, the most recent first,
+// optionally only the ones of a tag or digest.
+func getRepoActivity(ctx context.Context, metaDB mTypes.MetaDB, repo string, reference *string, log log.Logger,
+) ([]*gql_generated.RepoEvent, error) {
+	if ok, err := reqCtx.RepoIsUserAvailable(ctx, repo); !ok || err != nil {
+		log.Info().Err(err).Str("repository", repo).Bool("availability", ok).Str("component", "graphql").
+			Msg("repo user availability")
+
+		return []*gql_generated.RepoEvent{}, nil //nolint:nilerr // don't give details to a potential attacker
+	}
+
+	repoEvents, err := metaDB.GetRepoEvents(ctx, repo)
+	if err != nil {
+		log.Error().Err(err).Str("repository", repo).Str("component", "graphql").
+			Msg("failed to get the activity log of the repository")
+
+		return []*gql_generated.RepoEvent{}, err
+	}
+
+	results := make([]*gql_generated.RepoEvent, 0, len(repoEvents))
+
+	for i := len(repoEvents) - 1; i >= 0; i-- {
+		event := repoEvents[i]
+
+		if reference != nil && *reference != "" && event.Reference != *reference && event.Digest != *reference {
+			continue
+		}
+
+		results = append(results, &gql_generated.RepoEvent{
+			Type:           &event.Type,
+			Reference:      &event.Reference,
+			Digest:         &event.Digest,
+			MediaType:      &event.MediaType,
+			PreviousDigest: &event.PreviousDigest,
+			Actor:          &event.Actor,
+			Timestamp:      &event.Timestamp,
+		})
+	}
+
+	return results, nil
+}
+
+// getImageLayers returns the layers of the image, or of all the images of an index, each once, with the
+// number of the other repositories the user can access having a tagged image with the same layer.
+func getImageLayers(ctx context.Context, image string, metaDB mTypes.MetaDB, log log.Logger,
+) ([]*gql_generated.LayerUsage, error) {
+	repo, reference, isTag, err := zcommon.GetRepoReference(image)
+	if err != nil {
+		return []*gql_generated.LayerUsage{}, gqlerror.Errorf("no reference provided")
+	}
+
+	if ok, err := reqCtx.RepoIsUserAvailable(ctx, repo); !ok || err != nil {
+		log.Info().Err(err).Str("repository", repo).Bool("availability", ok).Str("component", "graphql").
+			Msg("repo user availability")
+
+		return []*gql_generated.LayerUsage{}, nil //nolint:nilerr // don't give details to a potential attacker
+	}
+
+	repoMeta, err := metaDB.GetRepoMeta(ctx, repo)
+	if err != nil {
+		if errors.Is(err, zerr.ErrRepoMetaNotFound) {
+			return []*gql_generated.LayerUsage{}, gqlerror.Errorf("repository not found")
+		}
+
+		return []*gql_generated.LayerUsage{}, err
+	}
+
+	imageDigest := reference
+
+	if isTag {
+		descriptor, ok := repoMeta.Tags[reference]
+		if !ok {
+			return []*gql_generated.LayerUsage{}, gqlerror.Errorf("can't find image: %s:%s", repo, reference)
+		}
+
+		imageDigest = descriptor.Digest
+	}
+
+	imageMeta, err := metaDB.GetImageMeta(godigest.Digest(imageDigest))
+	if err != nil {
+		return []*gql_generated.LayerUsage{}, err
+	}
+
+	layers := []ispec.Descriptor{}
+	sharingRepos := map[godigest.Digest]map[string]bool{}
+
+	for _, manifest := range imageMeta.Manifests {
+		for _, layer := range manifest.Manifest.Layers {
+			if _, found := sharingRepos[layer.Digest]; !found {
+				sharingRepos[layer.Digest] = map[string]bool{}
+				layers = append(layers, layer)
+			}
+		}
+	}
+
+	// the images are only looked at, none of them is returned
+	_, err = metaDB.FilterTags(ctx, mTypes.AcceptAllRepoTag,
+		func(otherRepoMeta mTypes.RepoMeta, otherImageMeta mTypes.ImageMeta) bool {
+			if otherRepoMeta.Name == repo {
+				return false
+			}
+
+			for _, manifest := range otherImageMeta.Manifests {
+				for _, layer := range manifest.Manifest.Layers {
+					if repos, found := sharingRepos[layer.Digest]; found {
+						repos[otherRepoMeta.Name] = true
+					}
+				}
+			}
+
+			return false
+		})
+	if err != nil {
+		log.Error().Err(err).Str("image", image).Str("component", "graphql").
+			Msg("failed to find the repositories sharing the layers of the image")
+
+		return []*gql_generated.LayerUsage{}, err
+	}
+
+	results := make([]*gql_generated.LayerUsage, 0, len(layers))
+
+	for _, layer := range layers {
+		digest := layer.Digest.String()
+		mediaType := layer.MediaType
+		size := strconv.FormatInt(layer.Size, 10)
+		sharedRepoCount := len(sharingRepos[layer.Digest])
+
+		results = append(results, &gql_generated.LayerUsage{
+			Digest:          &digest,
+			MediaType:       &mediaType,
+			Size:            &size,
+			SharedRepoCount: &sharedRepoCount,
+		})
+	}
+
+	return results, nil
+}
+
 func getReferrers(metaDB mTypes.MetaDB, repo string, referredDigest string, artifactTypes []string,
 	log log.Logger,
 ) ([]*gql_generated.Referrer, error) {
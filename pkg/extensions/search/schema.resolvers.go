@@ -137,6 +137,11 @@ func (r *queryResolver) Image(ctx context.Context, image string) (*gql_generated
 	return getImageSummary(ctx, repo, tag, nil, skip, r.metaDB, r.cveInfo, r.log)
 }
 
+// ImageLayers is the resolver for the ImageLayers field.
+func (r *queryResolver) ImageLayers(ctx context.Context, image string) ([]*gql_generated.LayerUsage, error) {
+	return getImageLayers(ctx, image, r.metaDB, r.log)
+}
+
 // Referrers is the resolver for the Referrers field.
 func (r *queryResolver) Referrers(ctx context.Context, repo string, digest string, typeArg []string) ([]*gql_generated.Referrer, error) {
 	referrers, err := getReferrers(r.metaDB, repo, digest, typeArg, r.log)
@@ -149,6 +154,11 @@ func (r *queryResolver) Referrers(ctx context.Context, repo string, digest strin
 	return referrers, nil
 }
 
+// RepoActivity is the resolver for the RepoActivity field.
+func (r *queryResolver) RepoActivity(ctx context.Context, repo string, reference *string) ([]*gql_generated.RepoEvent, error) {
+	return getRepoActivity(ctx, r.metaDB, repo, reference, r.log)
+}
+
 // StarredRepos is the resolver for the StarredRepos field.
 func (r *queryResolver) StarredRepos(ctx context.Context, requestedPage *gql_generated.PageInput) (*gql_generated.PaginatedReposResult, error) {
 	return getStarredRepos(ctx, r.cveInfo, r.log, requestedPage, r.metaDB)
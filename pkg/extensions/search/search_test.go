@@ -704,7 +704,7 @@ func TestRepoListWithNewestImage(t *testing.T) {
 		defer ctlr.Shutdown()
 
 		substring := "{\"Search\":{\"Enable\":true,\"CVE\":{\"UpdateInterval\":3600000000000," +
-			"\"Trivy\":{\"DBRepository\":\"ghcr.io/project-zot/trivy-db\",\"JavaDBRepository\":\"\"}}}"
+			"\"Trivy\":{\"DBRepository\":\"ghcr.io/project-zot/trivy-db\",\"JavaDBRepository\":\"\"}},\"Activity\":null}"
 		found, err := readFileAndSearchString(logPath, substring, 2*time.Minute)
 		So(found, ShouldBeTrue)
 		So(err, ShouldBeNil)
@@ -3374,7 +3374,7 @@ func TestGlobalSearch(t *testing.T) {
 
 		// Wait for trivy db to download
 		substring := "{\"Search\":{\"Enable\":true,\"CVE\":{\"UpdateInterval\":3600000000000," +
-			"\"Trivy\":{\"DBRepository\":\"ghcr.io/project-zot/trivy-db\",\"JavaDBRepository\":\"\"}}}"
+			"\"Trivy\":{\"DBRepository\":\"ghcr.io/project-zot/trivy-db\",\"JavaDBRepository\":\"\"}},\"Activity\":null}"
 		found, err := readFileAndSearchString(logPath, substring, 2*time.Minute)
 		So(found, ShouldBeTrue)
 		So(err, ShouldBeNil)
@@ -5241,6 +5241,8 @@ func TestMetaDBWhenReadingImages(t *testing.T) {
 		So(err, ShouldBeNil)
 
 		Convey("Download 3 times", func() {
+			beforePull := time.Now().Add(-time.Second)
+
 			resp, err := resty.R().Get(baseURL + "/v2/" + "repo1" + "/manifests/" + "1.0.1")
 			So(err, ShouldBeNil)
 			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
@@ -5257,7 +5259,8 @@ func TestMetaDBWhenReadingImages(t *testing.T) {
 			{
 				GlobalSearch(query:"repo1:1.0"){
 					Images {
-						RepoName Tag DownloadCount
+						RepoName Tag DownloadCount LastPullTimestamp
+						Manifests { DownloadCount LastPullTimestamp }
 					}
 				}
 			}`
@@ -5273,6 +5276,26 @@ func TestMetaDBWhenReadingImages(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(responseStruct.Images, ShouldNotBeEmpty)
 			So(responseStruct.Images[0].DownloadCount, ShouldEqual, 3)
+			So(responseStruct.Images[0].LastPullTimestamp, ShouldNotBeNil)
+			So(*responseStruct.Images[0].LastPullTimestamp, ShouldHappenAfter, beforePull)
+			So(responseStruct.Images[0].Manifests[0].DownloadCount, ShouldEqual, 3)
+			So(responseStruct.Images[0].Manifests[0].LastPullTimestamp, ShouldNotBeNil)
+			So(*responseStruct.Images[0].Manifests[0].LastPullTimestamp, ShouldEqual,
+				*responseStruct.Images[0].LastPullTimestamp)
+		})
+
+		Convey("Never downloaded", func() {
+			query := `
+			{
+				ImageList(repo:"repo1"){
+					Results { RepoName Tag DownloadCount LastPullTimestamp }
+				}
+			}`
+
+			resp, err := resty.R().Get(baseURL + graphqlQueryPrefix + "?query=" + url.QueryEscape(query))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+			So(string(resp.Body()), ShouldContainSubstring, `"DownloadCount":0,"LastPullTimestamp":null`)
 		})
 
 		Convey("Error when incrementing", func() {
@@ -5289,6 +5312,199 @@ func TestMetaDBWhenReadingImages(t *testing.T) {
 	})
 }
 
+func TestRepoActivity(t *testing.T) {
+	Convey("Push, pull and delete images with the activity log enabled", t, func() {
+		dir := t.TempDir()
+
+		port := GetFreePort()
+		baseURL := GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = dir
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{
+				BaseConfig: extconf.BaseConfig{Enable: &defaultVal},
+				Activity:   &extconf.ActivityConfig{MaxEvents: 4},
+			},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		image1 := CreateRandomImage()
+		image2 := CreateRandomImage()
+
+		err := UploadImage(image1, baseURL, "repo1", "1.0")
+		So(err, ShouldBeNil)
+
+		err = UploadImage(image2, baseURL, "repo1", "1.0")
+		So(err, ShouldBeNil)
+
+		resp, err := resty.R().Get(baseURL + "/v2/repo1/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		getActivity := func(args string) []zcommon.RepoEvent {
+			query := fmt.Sprintf(`
+			{
+				RepoActivity(%s){
+					Type Reference Digest MediaType PreviousDigest Actor Timestamp
+				}
+			}`, args)
+
+			resp, err := resty.R().Get(baseURL + graphqlQueryPrefix + "?query=" + url.QueryEscape(query))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			responseStruct := &zcommon.RepoActivityResp{}
+
+			err = json.Unmarshal(resp.Body(), responseStruct)
+			So(err, ShouldBeNil)
+			So(responseStruct.Errors, ShouldBeEmpty)
+
+			return responseStruct.RepoActivity
+		}
+
+		// the events are recorded in the background
+		waitForActivity := func(args string, recorded func([]zcommon.RepoEvent) bool) []zcommon.RepoEvent {
+			events := getActivity(args)
+
+			for i := 0; i < 100 && !recorded(events); i++ {
+				time.Sleep(50 * time.Millisecond)
+
+				events = getActivity(args)
+			}
+
+			return events
+		}
+
+		// push, push and tag moved, pull: the first push is over the limit
+		events := waitForActivity(`repo:"repo1"`, func(events []zcommon.RepoEvent) bool {
+			return len(events) == 4 && events[0].Type == "pull"
+		})
+		So(len(events), ShouldEqual, 4)
+		So(events[0].Type, ShouldEqual, "pull")
+		So(events[0].Digest, ShouldEqual, image2.DigestStr())
+		So(events[1].Type, ShouldEqual, "tag")
+		So(events[1].Reference, ShouldEqual, "1.0")
+		So(events[1].PreviousDigest, ShouldEqual, image1.DigestStr())
+		So(events[2].Type, ShouldEqual, "push")
+		So(events[2].Digest, ShouldEqual, image2.DigestStr())
+		So(events[2].MediaType, ShouldEqual, ispec.MediaTypeImageManifest)
+		So(events[3].Type, ShouldEqual, "push")
+		So(events[3].Digest, ShouldEqual, image1.DigestStr())
+		So(events[0].Timestamp, ShouldHappenOnOrAfter, events[3].Timestamp)
+
+		statusCode, err := DeleteImage("repo1", image2.DigestStr(), baseURL)
+		So(err, ShouldBeNil)
+		So(statusCode, ShouldEqual, http.StatusAccepted)
+
+		// the push of image1 was dropped to keep 4 events
+		events = waitForActivity(fmt.Sprintf(`repo:"repo1", reference:"%s"`, image2.DigestStr()),
+			func(events []zcommon.RepoEvent) bool {
+				return len(events) > 0 && events[0].Type == "delete"
+			})
+		So(len(events), ShouldEqual, 4)
+		So(events[0].Type, ShouldEqual, "delete")
+
+		events = getActivity(`repo:"repo1", reference:"1.0"`)
+		So(len(events), ShouldEqual, 3)
+		So(events[0].Type, ShouldEqual, "pull")
+
+		events = getActivity(`repo:"repo2"`)
+		So(events, ShouldBeEmpty)
+	})
+}
+
+func TestImageLayers(t *testing.T) {
+	Convey("Find the repositories sharing the layers of an image", t, func() {
+		port := GetFreePort()
+		baseURL := GetBaseURL(port)
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		defaultVal := true
+		conf.Extensions = &extconf.ExtensionConfig{
+			Search: &extconf.SearchConfig{BaseConfig: extconf.BaseConfig{Enable: &defaultVal}},
+		}
+
+		ctlr := api.NewController(conf)
+
+		ctlrManager := NewControllerManager(ctlr)
+		ctlrManager.StartAndWait(port)
+		defer ctlrManager.StopServer()
+
+		baseLayer, appLayer, otherLayer := []byte("base layer"), []byte("app layer"), []byte("other layer")
+		appImage := CreateImageWith().LayerBlobs([][]byte{baseLayer, appLayer}).DefaultConfig().Build()
+		otherImage := CreateImageWith().LayerBlobs([][]byte{baseLayer, otherLayer}).DefaultConfig().Build()
+
+		for _, upload := range []struct {
+			image Image
+			repo  string
+		}{{appImage, "app"}, {appImage, "mirror"}, {otherImage, "other"}} {
+			err := UploadImage(upload.image, baseURL, upload.repo, "1.0")
+			So(err, ShouldBeNil)
+		}
+
+		// another tag of the same repo isn't counted
+		err := UploadImage(otherImage, baseURL, "app", "2.0")
+		So(err, ShouldBeNil)
+
+		getLayers := func(image string) ([]zcommon.LayerUsage, []zcommon.ErrorGQL) {
+			query := fmt.Sprintf(`{ImageLayers(image:"%s"){Digest MediaType Size SharedRepoCount}}`, image)
+
+			resp, err := resty.R().Get(baseURL + graphqlQueryPrefix + "?query=" + url.QueryEscape(query))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+			responseStruct := &zcommon.ImageLayersResp{}
+
+			err = json.Unmarshal(resp.Body(), responseStruct)
+			So(err, ShouldBeNil)
+
+			return responseStruct.ImageLayers, responseStruct.Errors
+		}
+
+		layers, errs := getLayers("app:1.0")
+		So(errs, ShouldBeEmpty)
+		So(layers, ShouldResemble, []zcommon.LayerUsage{
+			{
+				Digest:          godigest.FromBytes(baseLayer).String(),
+				MediaType:       ispec.MediaTypeImageLayerGzip,
+				Size:            strconv.Itoa(len(baseLayer)),
+				SharedRepoCount: 2,
+			},
+			{
+				Digest:          godigest.FromBytes(appLayer).String(),
+				MediaType:       ispec.MediaTypeImageLayerGzip,
+				Size:            strconv.Itoa(len(appLayer)),
+				SharedRepoCount: 1,
+			},
+		})
+
+		layers, errs = getLayers("other@" + otherImage.DigestStr())
+		So(errs, ShouldBeEmpty)
+		So(len(layers), ShouldEqual, 2)
+		So(layers[0].SharedRepoCount, ShouldEqual, 2)
+		So(layers[1].Digest, ShouldEqual, godigest.FromBytes(otherLayer).String())
+		// app:2.0
+		So(layers[1].SharedRepoCount, ShouldEqual, 1)
+
+		_, errs = getLayers("app")
+		So(errs, ShouldNotBeEmpty)
+
+		_, errs = getLayers("app:3.0")
+		So(errs, ShouldNotBeEmpty)
+
+		_, errs = getLayers("missing:1.0")
+		So(errs, ShouldNotBeEmpty)
+	})
+}
+
 func TestMetaDBWhenDeletingImages(t *testing.T) {
 	Convey("Setting up zot repo with test images", t, func() {
 		dir := t.TempDir()
@@ -49,7 +49,7 @@ func (cm ContentManager) MatchesContent(repo string) bool {
 	return content != nil
 }
 
-// FilterTags filters a repo tags based on content config rules (semver, regex).
+// FilterTags filters a repo tags based on content config rules (semver, semver constraint, regex).
 func (cm ContentManager) FilterTags(repo string, tags []string) ([]string, error) {
 	content := cm.getContentByLocalRepo(repo)
 
@@ -66,6 +66,13 @@ func (cm ContentManager) FilterTags(repo string, tags []string) ([]string, error
 		if content.Tags.Semver != nil && *content.Tags.Semver {
 			tags = filterTagsBySemver(tags, cm.log)
 		}
+
+		if content.Tags.SemverConstraint != nil {
+			tags, err = filterTagsBySemverConstraint(tags, *content.Tags.SemverConstraint, cm.log)
+			if err != nil {
+				return []string{}, err
+			}
+		}
 	}
 
 	return tags, nil
@@ -255,3 +262,26 @@ func filterTagsBySemver(tags []string, log log.Logger) []string {
 
 	return filteredTags
 }
+
+// filterTagsBySemverConstraint filters tags by checking if they are semver versions matching the constraint.
+func filterTagsBySemverConstraint(tags []string, constraint string, log log.Logger) ([]string, error) {
+	filteredTags := []string{}
+
+	log.Info().Str("constraint", constraint).Msg("filtering tags using semver constraint")
+
+	semverConstraint, err := semver.NewConstraint(constraint)
+	if err != nil {
+		log.Error().Err(err).Str("constraint", constraint).Msg("failed to parse semver constraint")
+
+		return filteredTags, err
+	}
+
+	for _, tag := range tags {
+		version, err := semver.NewVersion(tag)
+		if err == nil && semverConstraint.Check(version) {
+			filteredTags = append(filteredTags, tag)
+		}
+	}
+
+	return filteredTags, nil
+}
@@ -170,6 +170,8 @@ func TestFilterTags(t *testing.T) {
 	badRegex := "[*"
 	semverFalse := false
 	semverTrue := true
+	semverConstraint := ">= 1.2.0, < 2.0.0"
+	badSemverConstraint := "~> one"
 	testCases := []struct {
 		tags         []string
 		repo         string
@@ -213,6 +215,24 @@ func TestFilterTags(t *testing.T) {
 			filteredTags: []string{"v1.0.1"},
 			err:          false,
 		},
+		{
+			repo: "infra/busybox",
+			content: []syncconf.Content{
+				{Prefix: "infra/*", Tags: &syncconf.Tags{SemverConstraint: &semverConstraint}},
+			},
+			tags:         []string{"latest", "v1.0.1", "1.2.0", "v1.36.1", "2.0.0", "1.3.0-rc1"},
+			filteredTags: []string{"1.2.0", "v1.36.1"},
+			err:          false,
+		},
+		{
+			repo: "infra/busybox",
+			content: []syncconf.Content{
+				{Prefix: "infra/*", Tags: &syncconf.Tags{SemverConstraint: &badSemverConstraint}},
+			},
+			tags:         []string{"latest", "v1.0.1"},
+			filteredTags: []string{},
+			err:          true,
+		},
 		{
 			repo: "repo",
 			content: []syncconf.Content{
@@ -7,6 +7,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/containers/common/pkg/retry"
 	"github.com/containers/image/v5/copy"
@@ -34,7 +36,10 @@ type BaseService struct {
 	repositories    []string
 	references      references.References
 	client          *client.Client
-	log             log.Logger
+	// when each repo:tag was last synced on demand, to serve it locally until onDemandTTL expires
+	syncedTags     map[string]time.Time
+	syncedTagsLock sync.Mutex
+	log            log.Logger
 }
 
 func New(
@@ -45,7 +50,9 @@ func New(
 	metadb mTypes.MetaDB,
 	log log.Logger,
 ) (*BaseService, error) {
-	service := &BaseService{}
+	service := &BaseService{syncedTags: map[string]time.Time{}}
+
+	log = log.Module("sync")
 
 	service.config = opts
 	service.log = log
@@ -279,6 +286,13 @@ func (service *BaseService) SyncImage(ctx context.Context, repo, reference strin
 
 	remoteRepo = service.remote.GetDockerRemoteRepo(remoteRepo)
 
+	if service.isSyncedRecently(repo, reference) {
+		service.log.Debug().Str("remote", remoteURL).Str("repository", repo).Str("reference", reference).
+			Msg("image synced recently, serving it from the local storage")
+
+		return nil
+	}
+
 	service.log.Info().Str("remote", remoteURL).Str("repository", repo).Str("reference", reference).
 		Msg("syncing image")
 
@@ -292,9 +306,49 @@ func (service *BaseService) SyncImage(ctx context.Context, repo, reference strin
 		return err
 	}
 
+	service.setSynced(repo, reference)
+
 	return nil
 }
 
+// isSyncedRecently tells if the tag was synced less than onDemandTTL ago and is still in the local storage,
+// in which case the registry isn't asked again. Digests never change, so they're not tracked.
+func (service *BaseService) isSyncedRecently(repo, reference string) bool {
+	if service.config.OnDemandTTL <= 0 {
+		return false
+	}
+
+	service.syncedTagsLock.Lock()
+	syncedAt, ok := service.syncedTags[repo+":"+reference]
+	service.syncedTagsLock.Unlock()
+
+	if !ok || time.Since(syncedAt) >= service.config.OnDemandTTL {
+		return false
+	}
+
+	// the image may have been deleted or garbage collected since
+	imageStore := service.storeController.GetImageStore(repo)
+
+	_, _, _, err := imageStore.GetImageManifest(repo, reference)
+
+	return err == nil
+}
+
+func (service *BaseService) setSynced(repo, reference string) {
+	if service.config.OnDemandTTL <= 0 {
+		return
+	}
+
+	if _, err := digest.Parse(reference); err == nil {
+		return
+	}
+
+	service.syncedTagsLock.Lock()
+	defer service.syncedTagsLock.Unlock()
+
+	service.syncedTags[repo+":"+reference] = time.Now()
+}
+
 // sync repo periodically.
 func (service *BaseService) SyncRepo(ctx context.Context, repo string) error {
 	service.log.Info().Str("repository", repo).Str("registry", service.client.GetConfig().URL).
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	dockerManifest "github.com/containers/image/v5/manifest"
 	"github.com/containers/image/v5/oci/layout"
@@ -170,6 +171,70 @@ func TestService(t *testing.T) {
 	})
 }
 
+func TestOnDemandTTL(t *testing.T) {
+	Convey("tags synced on demand are served locally until the ttl expires", t, func() {
+		conf := syncconf.RegistryConfig{
+			URLs:        []string{"http://localhost"},
+			OnDemandTTL: time.Hour,
+		}
+
+		imageStoreErr := error(nil)
+
+		storeController := storage.StoreController{
+			DefaultStore: mocks.MockedImageStore{
+				GetImageManifestFn: func(repo, reference string) ([]byte, godigest.Digest, string, error) {
+					return []byte{}, "", "", imageStoreErr
+				},
+			},
+		}
+
+		service, err := New(conf, "", os.TempDir(), storeController, mocks.MetaDBMock{}, log.Logger{})
+		So(err, ShouldBeNil)
+
+		remoteCalls := 0
+
+		service.remote = mocks.SyncRemote{
+			GetImageReferenceFn: func(repo, tag string) (types.ImageReference, error) {
+				remoteCalls++
+
+				return nil, ErrTestError
+			},
+		}
+
+		err = service.SyncImage(context.Background(), "repo", "tag")
+		So(err, ShouldEqual, ErrTestError)
+		So(remoteCalls, ShouldEqual, 1)
+
+		service.setSynced("repo", "tag")
+		service.setSynced("repo", godigest.FromString("manifest").String())
+
+		err = service.SyncImage(context.Background(), "repo", "tag")
+		So(err, ShouldBeNil)
+		So(remoteCalls, ShouldEqual, 1)
+
+		// digests are never tracked
+		err = service.SyncImage(context.Background(), "repo", godigest.FromString("manifest").String())
+		So(err, ShouldEqual, ErrTestError)
+		So(remoteCalls, ShouldEqual, 2)
+
+		Convey("the tag was deleted from the local storage", func() {
+			imageStoreErr = zerr.ErrManifestNotFound
+
+			err = service.SyncImage(context.Background(), "repo", "tag")
+			So(err, ShouldEqual, ErrTestError)
+			So(remoteCalls, ShouldEqual, 3)
+		})
+
+		Convey("the ttl expired", func() {
+			service.syncedTags["repo:tag"] = time.Now().Add(-time.Hour)
+
+			err = service.SyncImage(context.Background(), "repo", "tag")
+			So(err, ShouldEqual, ErrTestError)
+			So(remoteCalls, ShouldEqual, 3)
+		})
+	})
+}
+
 func TestSyncRepo(t *testing.T) {
 	Convey("trigger context error", t, func() {
 		conf := syncconf.RegistryConfig{
@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// the spans started with the global tracer are dropped unless tracing is enabled.
+const tracerName = "zotregistry.dev/zot"
+
+func noShutdown(context.Context) error {
+	return nil
+}
+
+// StartSpan starts a child span of the span of the context.
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// TracedMiddleware records the time spent in the middleware, e.g. the authentication of the request,
+// as a span which ends when the middleware calls the next handler.
+func TracedMiddleware(name string, middleware mux.MiddlewareFunc) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			parentSpan := trace.SpanFromContext(request.Context())
+
+			ctx, span := StartSpan(request.Context(), name)
+			defer span.End()
+
+			middleware(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+				span.End()
+
+				// the values the middleware added to the context are kept, the next spans aren't its children
+				next.ServeHTTP(response, request.WithContext(trace.ContextWithSpan(request.Context(), parentSpan)))
+			})).ServeHTTP(response, request.WithContext(ctx))
+		})
+	}
+}
+
+// RouteSpanNameMiddleware names the span of the request after its method and route,
+// e.g. "GET /v2/{name}/manifests/{reference}".
+func RouteSpanNameMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if route := mux.CurrentRoute(request); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				trace.SpanFromContext(request.Context()).SetName(request.Method + " " + stripRouteRegexps(template))
+			}
+		}
+
+		next.ServeHTTP(response, request)
+	})
+}
+
+// stripRouteRegexps removes the patterns of the route variables, "{name:[a-z]+}" becomes "{name}".
+func stripRouteRegexps(template string) string {
+	var builder strings.Builder
+
+	depth := 0
+	inPattern := false
+
+	for _, char := range template {
+		switch {
+		case char == '{':
+			depth++
+
+			if depth == 1 {
+				builder.WriteRune(char)
+			}
+
+			continue
+		case char == '}':
+			depth--
+
+			if depth == 0 {
+				inPattern = false
+
+				builder.WriteRune(char)
+			}
+
+			continue
+		case char == ':' && depth == 1:
+			inPattern = true
+		}
+
+		if !inPattern {
+			builder.WriteRune(char)
+		}
+	}
+
+	return builder.String()
+}
+
+// StartStorageSpan starts a span for a call to the storage of the repo, the storage API doesn't take a context
+// so the span is ended by the caller once the call returns.
+func StartStorageSpan(ctx context.Context, operation, repo string) trace.Span {
+	_, span := StartSpan(ctx, "storage."+operation, attribute.String("repository", repo))
+
+	return span
+}
@@ -0,0 +1,107 @@
+//go:build tracing
+// +build tracing
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/log"
+)
+
+// EnableTracing sends the spans of the server to the OTLP endpoint of the config.
+// It returns the function sending the spans left, to be called when the server stops.
+func EnableTracing(conf *config.Config, log log.Logger) func(context.Context) error {
+	if !conf.IsTracingEnabled() {
+		log.Info().Msg("tracing config not provided, skipping tracing")
+
+		return noShutdown
+	}
+
+	options := []otlptracehttp.Option{}
+
+	if conf.Extensions.Tracing.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpoint(conf.Extensions.Tracing.Endpoint))
+	}
+
+	if conf.Extensions.Tracing.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	shutdown, err := setupTracerProvider(context.Background(), "zot", conf.ReleaseTag, options...)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to enable tracing")
+
+		return noShutdown
+	}
+
+	log.Info().Str("endpoint", conf.Extensions.Tracing.Endpoint).Msg("tracing enabled")
+
+	return shutdown
+}
+
+// EnableCLITracing sends the spans of zli to the OTLP endpoint of the standard OTEL_EXPORTER_OTLP_ENDPOINT
+// or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT environment variables, if one of them is set.
+func EnableCLITracing() (func(context.Context) error, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return noShutdown, nil
+	}
+
+	shutdown, err := setupTracerProvider(context.Background(), "zli", config.ReleaseTag)
+	if err != nil {
+		return noShutdown, err
+	}
+
+	return shutdown, nil
+}
+
+func setupTracerProvider(ctx context.Context, serviceName, version string, options ...otlptracehttp.Option,
+) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(serviceName),
+			semconv.ServiceVersion(version),
+		)),
+	)
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	return tracerProvider.Shutdown, nil
+}
+
+// Handler starts a span for each request, continuing the trace of the client if the request carries one.
+// The span is named after the route once the router matched it, see RouteSpanNameMiddleware.
+func Handler(handler http.Handler) http.Handler {
+	return otelhttp.NewHandler(handler, "http", otelhttp.WithSpanNameFormatter(
+		func(_ string, request *http.Request) string {
+			return request.Method
+		}))
+}
+
+// Transport starts a span for each request sent with it and passes the trace to the server.
+func Transport(transport http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(transport)
+}
+
+func IsBuiltWithTracingExtension() bool {
+	return true
+}
@@ -0,0 +1,41 @@
+//go:build !tracing
+// +build !tracing
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/log"
+)
+
+// EnableTracing ...
+func EnableTracing(conf *config.Config, log log.Logger) func(context.Context) error {
+	if conf.IsTracingEnabled() {
+		log.Warn().Msg("skipping enabling tracing extension because given zot binary doesn't include this feature," +
+			"please build a binary that does so")
+	}
+
+	return noShutdown
+}
+
+// EnableCLITracing ...
+func EnableCLITracing() (func(context.Context) error, error) {
+	return noShutdown, nil
+}
+
+// Handler ...
+func Handler(handler http.Handler) http.Handler {
+	return handler
+}
+
+// Transport ...
+func Transport(transport http.RoundTripper) http.RoundTripper {
+	return transport
+}
+
+func IsBuiltWithTracingExtension() bool {
+	return false
+}
@@ -0,0 +1,134 @@
+//go:build tracing
+// +build tracing
+
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/resty.v1"
+
+	"zotregistry.dev/zot/pkg/api"
+	"zotregistry.dev/zot/pkg/api/config"
+	extconf "zotregistry.dev/zot/pkg/extensions/config"
+	"zotregistry.dev/zot/pkg/extensions/tracing"
+	test "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+// otlpReceiver keeps the spans sent by the OTLP exporter, they're encoded as protobuf
+// so the tests only look for the names in them.
+type otlpReceiver struct {
+	lock     sync.Mutex
+	payloads [][]byte
+}
+
+func (receiver *otlpReceiver) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost || request.URL.Path != "/v1/traces" {
+		response.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	body, _ := io.ReadAll(request.Body)
+
+	receiver.lock.Lock()
+	receiver.payloads = append(receiver.payloads, body)
+	receiver.lock.Unlock()
+
+	response.WriteHeader(http.StatusOK)
+}
+
+func (receiver *otlpReceiver) received() []byte {
+	receiver.lock.Lock()
+	defer receiver.lock.Unlock()
+
+	return bytes.Join(receiver.payloads, nil)
+}
+
+func TestTracing(t *testing.T) {
+	Convey("Spans of the requests are sent to the OTLP endpoint", t, func() {
+		receiver := &otlpReceiver{}
+		otlpServer := httptest.NewServer(receiver)
+		defer otlpServer.Close()
+
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Storage.RootDirectory = t.TempDir()
+		conf.Extensions = &extconf.ExtensionConfig{}
+		enable := true
+		conf.Extensions.Tracing = &extconf.TracingConfig{
+			BaseConfig: extconf.BaseConfig{Enable: &enable},
+			Endpoint:   strings.TrimPrefix(otlpServer.URL, "http://"),
+			Insecure:   true,
+		}
+
+		ctlr := api.NewController(conf)
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+
+		err := UploadImage(CreateRandomImage(), baseURL, "repo", "latest")
+		So(err, ShouldBeNil)
+
+		resp, err := resty.R().Get(baseURL + "/v2/repo/tags/list")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+
+		// the spans left are sent when the server stops
+		cm.StopServer()
+
+		received := string(receiver.received())
+		So(received, ShouldContainSubstring, "zot")
+		So(received, ShouldContainSubstring, "GET /v2/{name}/tags/list")
+		So(received, ShouldContainSubstring, "PUT /v2/{name}/manifests/{reference}")
+		So(received, ShouldContainSubstring, "authn")
+		So(received, ShouldContainSubstring, "storage.PutImageManifest")
+		So(received, ShouldContainSubstring, "storage.FinishBlobUpload")
+		So(received, ShouldContainSubstring, "repository")
+	})
+
+	Convey("zli only sends spans if the OTLP endpoint is set in the environment", t, func() {
+		shutdown, err := tracing.EnableCLITracing()
+		So(err, ShouldBeNil)
+		So(shutdown(context.Background()), ShouldBeNil)
+
+		receiver := &otlpReceiver{}
+		otlpServer := httptest.NewServer(receiver)
+		defer otlpServer.Close()
+
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", otlpServer.URL)
+
+		shutdown, err = tracing.EnableCLITracing()
+		So(err, ShouldBeNil)
+
+		traceParent := make(chan string, 1)
+
+		server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			traceParent <- request.Header.Get("Traceparent")
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: tracing.Transport(http.DefaultTransport)}
+
+		resp, err := client.Get(server.URL)
+		So(err, ShouldBeNil)
+		resp.Body.Close()
+
+		// the trace is passed to the server
+		So(<-traceParent, ShouldNotBeEmpty)
+
+		So(shutdown(context.Background()), ShouldBeNil)
+		So(string(receiver.received()), ShouldContainSubstring, "zli")
+	})
+}
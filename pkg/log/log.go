@@ -1,20 +1,41 @@
 package log
 
 import (
+	"context"
+	"io"
 	"os"
 	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
+
+	zerr "zotregistry.dev/zot/errors"
 )
 
-const defaultPerms = 0o0600
+const (
+	defaultPerms = 0o0600
+
+	JSONFormat    = "json"
+	ConsoleFormat = "console"
+)
+
+type moduleCtxKey struct{}
 
 //nolint:gochecknoglobals
-var loggerSetTimeFormat sync.Once
+var (
+	loggerSetTimeFormat sync.Once
+
+	// level of the loggers, the global level of zerolog is the lowest of it and of the module levels
+	baseLevel    atomic.Int32
+	moduleLevels atomic.Pointer[map[string]zerolog.Level]
+
+	// IDs of the requests served by the goroutines, added to their logs
+	requestIDs sync.Map
+)
 
 // Logger extends zerolog's Logger.
 type Logger struct {
@@ -26,6 +47,11 @@ func (l Logger) Println(v ...interface{}) {
 }
 
 func NewLogger(level, output string) Logger {
+	return NewLoggerWithFormat(level, output, JSONFormat)
+}
+
+// NewLoggerWithFormat returns a logger writing JSON records, or human-friendly lines with the console format.
+func NewLoggerWithFormat(level, output, format string) Logger {
 	loggerSetTimeFormat.Do(func() {
 		zerolog.TimeFieldFormat = time.RFC3339Nano
 	})
@@ -35,24 +61,103 @@ func NewLogger(level, output string) Logger {
 		panic(err)
 	}
 
-	zerolog.SetGlobalLevel(lvl)
+	SetLevel(lvl)
 
-	var log zerolog.Logger
+	var writer io.Writer = os.Stdout
 
-	if output == "" {
-		log = zerolog.New(os.Stdout)
-	} else {
+	if output != "" {
 		file, err := os.OpenFile(output, os.O_APPEND|os.O_WRONLY|os.O_CREATE, defaultPerms)
 		if err != nil {
 			panic(err)
 		}
-		log = zerolog.New(file)
+
+		writer = file
+	}
+
+	if format == ConsoleFormat {
+		// no colors in files
+		writer = zerolog.ConsoleWriter{Out: writer, TimeFormat: time.RFC3339, NoColor: output != ""}
+	}
+
+	log := zerolog.New(writer).Hook(levelHook{}).Hook(goroutineHook{})
+
+	return Logger{Logger: log.With().Caller().Timestamp().Logger()}
+}
+
+// SetLevel changes the level of all the loggers, the modules with their own level aside.
+func SetLevel(level zerolog.Level) {
+	baseLevel.Store(int32(level))
+
+	lowest := level
+
+	if levels := moduleLevels.Load(); levels != nil {
+		for _, moduleLevel := range *levels {
+			if moduleLevel < lowest {
+				lowest = moduleLevel
+			}
+		}
 	}
 
-	return Logger{Logger: log.Hook(goroutineHook{}).With().Caller().Timestamp().Logger()}
+	zerolog.SetGlobalLevel(lowest)
+}
+
+// SetModuleLevels sets the levels of the modules, e.g. {"sync": "debug"}, overriding the level of the loggers.
+func SetModuleLevels(levels map[string]string) error {
+	parsedLevels := make(map[string]zerolog.Level, len(levels))
+
+	for module, level := range levels {
+		lvl, err := zerolog.ParseLevel(level)
+		if err != nil || lvl == zerolog.NoLevel {
+			return zerr.ErrBadConfig
+		}
+
+		parsedLevels[module] = lvl
+	}
+
+	moduleLevels.Store(&parsedLevels)
+	SetLevel(zerolog.Level(baseLevel.Load()))
+
+	return nil
+}
+
+// Module returns the logger of the module, using the level of the module if it has one.
+func (l Logger) Module(name string) Logger {
+	ctx := context.WithValue(context.Background(), moduleCtxKey{}, name)
+
+	return Logger{Logger: l.With().Ctx(ctx).Logger()}
+}
+
+// SetRequestID adds the request ID to the logs of the calling goroutine, until the returned function is called.
+func SetRequestID(requestID string) func() {
+	goroutineID := GoroutineID()
+	requestIDs.Store(goroutineID, requestID)
+
+	return func() {
+		requestIDs.Delete(goroutineID)
+	}
 }
 
 func NewAuditLogger(level, output string) *Logger {
+	// don't create the file if the level is wrong
+	if _, err := zerolog.ParseLevel(level); err != nil {
+		panic(err)
+	}
+
+	if output == "" {
+		return NewAuditLoggerWithWriter(level, os.Stdout)
+	}
+
+	auditFile, err := os.OpenFile(output, os.O_APPEND|os.O_WRONLY|os.O_CREATE, defaultPerms)
+	if err != nil {
+		panic(err)
+	}
+
+	return NewAuditLoggerWithWriter(level, auditFile)
+}
+
+// NewAuditLoggerWithWriter returns an audit logger writing its records to writer,
+// e.g. a RotatingFile or a syslog writer.
+func NewAuditLoggerWithWriter(level string, writer io.Writer) *Logger {
 	loggerSetTimeFormat.Do(func() {
 		zerolog.TimeFieldFormat = time.RFC3339Nano
 	})
@@ -62,20 +167,9 @@ func NewAuditLogger(level, output string) *Logger {
 		panic(err)
 	}
 
-	zerolog.SetGlobalLevel(lvl)
+	SetLevel(lvl)
 
-	var auditLog zerolog.Logger
-
-	if output == "" {
-		auditLog = zerolog.New(os.Stdout)
-	} else {
-		auditFile, err := os.OpenFile(output, os.O_APPEND|os.O_WRONLY|os.O_CREATE, defaultPerms)
-		if err != nil {
-			panic(err)
-		}
-
-		auditLog = zerolog.New(auditFile)
-	}
+	auditLog := zerolog.New(writer)
 
 	return &Logger{Logger: auditLog.With().Timestamp().Logger()}
 }
@@ -97,7 +191,36 @@ func GoroutineID() int {
 type goroutineHook struct{}
 
 func (h goroutineHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
-	if level != zerolog.NoLevel {
-		e.Int("goroutine", GoroutineID())
+	if level != zerolog.NoLevel && level != zerolog.Disabled {
+		goroutineID := GoroutineID()
+		e.Int("goroutine", goroutineID)
+
+		if requestID, ok := requestIDs.Load(goroutineID); ok {
+			e.Str("requestID", requestID.(string)) //nolint:forcetypeassert
+		}
+	}
+}
+
+// levelHook drops the logs under the level of their logger, the global level being lower when a module
+// has a lower level.
+type levelHook struct{}
+
+func (h levelHook) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	if level == zerolog.NoLevel {
+		return
+	}
+
+	minLevel := zerolog.Level(baseLevel.Load())
+
+	if levels := moduleLevels.Load(); levels != nil {
+		if module, ok := e.GetCtx().Value(moduleCtxKey{}).(string); ok {
+			if moduleLevel, ok := (*levels)[module]; ok {
+				minLevel = moduleLevel
+			}
+		}
+	}
+
+	if level < minLevel {
+		e.Discard()
 	}
 }
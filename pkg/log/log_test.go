@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -25,17 +26,22 @@ import (
 	"zotregistry.dev/zot/pkg/api/constants"
 	"zotregistry.dev/zot/pkg/log"
 	test "zotregistry.dev/zot/pkg/test/common"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
 )
 
 type AuditLog struct {
-	Level    string `json:"level"`
-	ClientIP string `json:"clientIP"` //nolint:tagliatelle // keep IP
-	Subject  string `json:"subject"`
-	Action   string `json:"action"`
-	Object   string `json:"object"`
-	Status   int    `json:"status"`
-	Time     string `json:"time"`
-	Message  string `json:"message"`
+	Level      string `json:"level"`
+	ClientIP   string `json:"clientIP"` //nolint:tagliatelle // keep IP
+	Subject    string `json:"subject"`
+	Action     string `json:"action"`
+	Object     string `json:"object"`
+	Repository string `json:"repository"`
+	Reference  string `json:"reference"`
+	Digest     string `json:"digest"`
+	Result     string `json:"result"`
+	Status     int    `json:"status"`
+	Time       string `json:"time"`
+	Message    string `json:"message"`
 }
 
 func TestAuditLogMessages(t *testing.T) {
@@ -282,6 +288,60 @@ func TestAuditLogMessages(t *testing.T) {
 				patchPath := location
 				So(auditLog.Object, ShouldEqual, patchPath)
 			})
+
+			Convey("Test manifest and denied requests", func() {
+				image := CreateRandomImage()
+
+				err := UploadImageWithBasicAuth(image, baseURL, "repo", "1.0", username, password)
+				So(err, ShouldBeNil)
+
+				auditLogs := readAuditLogs(auditFile)
+				So(auditLogs, ShouldNotBeEmpty)
+
+				manifestLog := auditLogs[len(auditLogs)-1]
+				So(manifestLog.Subject, ShouldEqual, username)
+				So(manifestLog.Action, ShouldEqual, http.MethodPut)
+				So(manifestLog.Repository, ShouldEqual, "repo")
+				So(manifestLog.Reference, ShouldEqual, "1.0")
+				So(manifestLog.Digest, ShouldEqual, image.DigestStr())
+				So(manifestLog.Result, ShouldEqual, "success")
+				So(manifestLog.Status, ShouldEqual, http.StatusCreated)
+
+				// failed mutating requests are logged too
+				resp, err := resty.R().SetBasicAuth(username, password).Delete(baseURL + "/v2/repo/manifests/2.0")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+
+				auditLogs = readAuditLogs(auditFile)
+				So(len(auditLogs), ShouldEqual, 1)
+				So(auditLogs[0].Action, ShouldEqual, http.MethodDelete)
+				So(auditLogs[0].Reference, ShouldEqual, "2.0")
+				So(auditLogs[0].Result, ShouldEqual, "failure")
+				So(auditLogs[0].Status, ShouldEqual, http.StatusNotFound)
+
+				// so are the failed logins, whatever the request
+				resp, err = resty.R().SetBasicAuth(username, "wrong").Get(baseURL + "/v2/repo/tags/list")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+				auditLogs = readAuditLogs(auditFile)
+				So(len(auditLogs), ShouldEqual, 1)
+				So(auditLogs[0].Subject, ShouldEqual, username)
+				So(auditLogs[0].Action, ShouldEqual, http.MethodGet)
+				So(auditLogs[0].Repository, ShouldEqual, "repo")
+				So(auditLogs[0].Result, ShouldEqual, "failure")
+				So(auditLogs[0].Status, ShouldEqual, http.StatusUnauthorized)
+
+				// but not the anonymous requests asking how to authenticate
+				resp, err = resty.R().Get(baseURL + "/v2/")
+				So(err, ShouldBeNil)
+				So(resp.StatusCode(), ShouldEqual, http.StatusUnauthorized)
+
+				time.Sleep(100 * time.Millisecond)
+
+				byteValue, _ := io.ReadAll(auditFile)
+				So(len(byteValue), ShouldEqual, 0)
+			})
 		})
 	})
 }
@@ -317,3 +377,218 @@ func TestNewAuditLogger(t *testing.T) {
 		}, ShouldPanic)
 	})
 }
+
+// readAuditLogs waits for the audit records of the last requests and parses them.
+func readAuditLogs(auditFile *os.File) []AuditLog {
+	byteValue, _ := io.ReadAll(auditFile)
+	for len(byteValue) == 0 {
+		time.Sleep(100 * time.Millisecond)
+		byteValue, _ = io.ReadAll(auditFile)
+	}
+
+	auditLogs := []AuditLog{}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(byteValue)), "\n") {
+		var auditLog AuditLog
+		if err := json.Unmarshal([]byte(line), &auditLog); err != nil {
+			panic(err)
+		}
+
+		auditLogs = append(auditLogs, auditLog)
+	}
+
+	return auditLogs
+}
+
+func TestRotatingFile(t *testing.T) {
+	Convey("Log files are rotated once they're too big", t, func() {
+		dir := t.TempDir()
+		logPath := path.Join(dir, "zot-audit.log")
+
+		rotatingFile, err := log.NewRotatingFile(logPath, 100, 2, 0)
+		So(err, ShouldBeNil)
+
+		defer rotatingFile.Close()
+
+		audit := log.NewAuditLoggerWithWriter("debug", rotatingFile)
+
+		for i := 0; i < 10; i++ {
+			audit.Info().Int("id", i).Msg("HTTP API Audit")
+		}
+
+		rotatedPaths, err := filepath.Glob(logPath + ".*")
+		So(err, ShouldBeNil)
+		So(len(rotatedPaths), ShouldEqual, 2)
+
+		for _, logFile := range append(rotatedPaths, logPath) {
+			info, err := os.Stat(logFile)
+			So(err, ShouldBeNil)
+			So(info.Size(), ShouldBeLessThanOrEqualTo, 100)
+		}
+
+		// the records are only written to the current file
+		content, err := os.ReadFile(logPath)
+		So(err, ShouldBeNil)
+		So(string(content), ShouldContainSubstring, `"id":9`)
+
+		Convey("Rotated files older than maxAge are removed", func() {
+			rotatingFile, err := log.NewRotatingFile(logPath, 100, 0, time.Nanosecond)
+			So(err, ShouldBeNil)
+
+			defer rotatingFile.Close()
+
+			audit := log.NewAuditLoggerWithWriter("debug", rotatingFile)
+			for i := 0; i < 3; i++ {
+				audit.Info().Int("id", i).Msg("HTTP API Audit")
+			}
+
+			rotatedPaths, err := filepath.Glob(logPath + ".*")
+			So(err, ShouldBeNil)
+			So(rotatedPaths, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Get error when opening the rotating file", t, func() {
+		_, err := log.NewRotatingFile(path.Join(t.TempDir(), "missing", "zot-audit.log"), 100, 0, 0)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestModuleLevels(t *testing.T) {
+	Convey("Modules log with their own levels", t, func() {
+		logPath := path.Join(t.TempDir(), "zot.log")
+		logger := log.NewLogger("info", logPath)
+
+		So(log.SetModuleLevels(map[string]string{"sync": "debug", "http": "error"}), ShouldBeNil)
+		defer func() { So(log.SetModuleLevels(nil), ShouldBeNil) }()
+
+		syncLogger := logger.Module("sync")
+		httpLogger := logger.Module("http")
+		gcLogger := logger.Module("gc")
+
+		logger.Debug().Msg("base debug")
+		logger.Info().Msg("base info")
+		syncLogger.Debug().Msg("sync debug")
+		syncLogger.Trace().Msg("sync trace")
+		httpLogger.Warn().Msg("http warn")
+		httpLogger.Error().Msg("http error")
+		gcLogger.Debug().Msg("gc debug")
+
+		content, err := os.ReadFile(logPath)
+		So(err, ShouldBeNil)
+		So(string(content), ShouldNotContainSubstring, "base debug")
+		So(string(content), ShouldContainSubstring, "base info")
+		So(string(content), ShouldContainSubstring, "sync debug")
+		So(string(content), ShouldNotContainSubstring, "sync trace")
+		So(string(content), ShouldNotContainSubstring, "http warn")
+		So(string(content), ShouldContainSubstring, "http error")
+		So(string(content), ShouldNotContainSubstring, "gc debug")
+
+		So(log.SetModuleLevels(map[string]string{"sync": "verbose"}), ShouldNotBeNil)
+	})
+
+	Convey("Logs are written as console lines", t, func() {
+		logPath := path.Join(t.TempDir(), "zot.log")
+		logger := log.NewLoggerWithFormat("info", logPath, log.ConsoleFormat)
+
+		logger.Info().Str("repository", "app").Msg("console line")
+
+		content, err := os.ReadFile(logPath)
+		So(err, ShouldBeNil)
+		So(string(content), ShouldContainSubstring, "INF")
+		So(string(content), ShouldContainSubstring, "console line")
+		So(string(content), ShouldContainSubstring, "repository=app")
+		So(json.Valid(bytes.TrimSpace(content)), ShouldBeFalse)
+	})
+
+	Convey("Logs carry the ID of the request being served", t, func() {
+		logPath := path.Join(t.TempDir(), "zot.log")
+		logger := log.NewLogger("info", logPath)
+
+		done := log.SetRequestID("request-1")
+		logger.Info().Msg("during request")
+		done()
+		logger.Info().Msg("after request")
+
+		content, err := os.ReadFile(logPath)
+		So(err, ShouldBeNil)
+
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		So(lines, ShouldHaveLength, 2)
+		So(lines[0], ShouldContainSubstring, `"requestID":"request-1"`)
+		So(lines[1], ShouldNotContainSubstring, "requestID")
+	})
+}
+
+func TestAccessLogs(t *testing.T) {
+	Convey("Access logs are correlated and sampled", t, func() {
+		port := test.GetFreePort()
+		baseURL := test.GetBaseURL(port)
+		logPath := path.Join(t.TempDir(), "zot.log")
+
+		conf := config.New()
+		conf.HTTP.Port = port
+		conf.Log = &config.LogConfig{
+			Level:    "info",
+			Output:   logPath,
+			Sampling: &config.LogSamplingConfig{Pulls: 100},
+		}
+
+		ctlr := api.NewController(conf)
+		ctlr.Config.Storage.RootDirectory = t.TempDir()
+
+		cm := test.NewControllerManager(ctlr)
+		cm.StartAndWait(port)
+		defer cm.StopServer()
+
+		for i := 0; i < 5; i++ {
+			resp, err := resty.R().Get(baseURL + "/v2/")
+			So(err, ShouldBeNil)
+			So(resp.StatusCode(), ShouldEqual, http.StatusOK)
+		}
+
+		// the requests failing and the pushes are always logged
+		resp, err := resty.R().SetHeader(constants.RequestIDHeader, "my-request").
+			Get(baseURL + "/v2/app/manifests/1.0")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusNotFound)
+		So(resp.Header().Get(constants.RequestIDHeader), ShouldEqual, "my-request")
+
+		resp, err = resty.R().SetHeader(constants.RequestIDHeader, "bad request id").
+			Post(baseURL + "/v2/app/blobs/uploads/")
+		So(err, ShouldBeNil)
+		So(resp.StatusCode(), ShouldEqual, http.StatusAccepted)
+
+		generatedID := resp.Header().Get(constants.RequestIDHeader)
+		So(generatedID, ShouldNotEqual, "bad request id")
+		So(generatedID, ShouldNotBeEmpty)
+
+		content, err := os.ReadFile(logPath)
+		So(err, ShouldBeNil)
+
+		var pulls, notFound, pushes int
+
+		for _, line := range strings.Split(string(content), "\n") {
+			if !strings.Contains(line, `"message":"HTTP API"`) {
+				continue
+			}
+
+			switch {
+			case strings.Contains(line, `"path":"/v2/"`):
+				pulls++
+			case strings.Contains(line, `"statusCode":404`):
+				So(line, ShouldContainSubstring, `"requestID":"my-request"`)
+
+				notFound++
+			case strings.Contains(line, `"method":"POST"`):
+				So(line, ShouldContainSubstring, fmt.Sprintf(`"requestID":"%s"`, generatedID))
+
+				pushes++
+			}
+		}
+
+		So(pulls, ShouldEqual, 1)
+		So(notFound, ShouldEqual, 1)
+		So(pushes, ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,157 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	rotatedTimeFormat = "20060102T150405.000000000"
+	defaultSyslogTag  = "zot"
+)
+
+// RotatingFile is a log file renamed with the time of its rotation once it gets bigger than maxBytes,
+// the oldest rotated files are removed once there are more than maxBackups of them or once they're older than maxAge.
+type RotatingFile struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+	maxAge     time.Duration
+	file       *os.File
+	size       int64
+	lock       sync.Mutex
+}
+
+func NewRotatingFile(path string, maxBytes int64, maxBackups int, maxAge time.Duration) (*RotatingFile, error) {
+	rotatingFile := &RotatingFile{
+		path:       path,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+		maxAge:     maxAge,
+	}
+
+	if err := rotatingFile.open(); err != nil {
+		return nil, err
+	}
+
+	return rotatingFile, nil
+}
+
+func (rotatingFile *RotatingFile) Write(buf []byte) (int, error) {
+	rotatingFile.lock.Lock()
+	defer rotatingFile.lock.Unlock()
+
+	if rotatingFile.maxBytes > 0 && rotatingFile.size > 0 &&
+		rotatingFile.size+int64(len(buf)) > rotatingFile.maxBytes {
+		if err := rotatingFile.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rotatingFile.file.Write(buf)
+	rotatingFile.size += int64(n)
+
+	return n, err
+}
+
+func (rotatingFile *RotatingFile) Close() error {
+	rotatingFile.lock.Lock()
+	defer rotatingFile.lock.Unlock()
+
+	return rotatingFile.file.Close()
+}
+
+func (rotatingFile *RotatingFile) open() error {
+	file, err := os.OpenFile(rotatingFile.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, defaultPerms)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return err
+	}
+
+	rotatingFile.file = file
+	rotatingFile.size = info.Size()
+
+	return nil
+}
+
+func (rotatingFile *RotatingFile) rotate() error {
+	if err := rotatingFile.file.Close(); err != nil {
+		return err
+	}
+
+	rotatedPath := fmt.Sprintf("%s.%s", rotatingFile.path, time.Now().UTC().Format(rotatedTimeFormat))
+
+	if err := os.Rename(rotatingFile.path, rotatedPath); err != nil {
+		return err
+	}
+
+	if err := rotatingFile.open(); err != nil {
+		return err
+	}
+
+	rotatingFile.removeOldFiles()
+
+	return nil
+}
+
+// removeOldFiles removes the rotated files beyond maxBackups and the ones older than maxAge,
+// failing to remove them doesn't prevent logging.
+func (rotatingFile *RotatingFile) removeOldFiles() {
+	if rotatingFile.maxBackups == 0 && rotatingFile.maxAge == 0 {
+		return
+	}
+
+	rotatedPaths, err := filepath.Glob(rotatingFile.path + ".*")
+	if err != nil {
+		return
+	}
+
+	backups := 0
+
+	// the time in their names sorts them from the newest to the oldest
+	sort.Sort(sort.Reverse(sort.StringSlice(rotatedPaths)))
+
+	for _, rotatedPath := range rotatedPaths {
+		rotatedTime, err := time.Parse(rotatedTimeFormat, strings.TrimPrefix(rotatedPath, rotatingFile.path+"."))
+		if err != nil {
+			continue
+		}
+
+		backups++
+
+		if (rotatingFile.maxBackups > 0 && backups > rotatingFile.maxBackups) ||
+			(rotatingFile.maxAge > 0 && time.Since(rotatedTime) > rotatingFile.maxAge) {
+			_ = os.Remove(rotatedPath)
+		}
+	}
+}
+
+// NewSyslogWriter returns a writer sending the records to syslog with the priority matching their level,
+// to the local syslog daemon if network is empty.
+func NewSyslogWriter(network, address, tag string) (io.Writer, error) {
+	if tag == "" {
+		tag = defaultSyslogTag
+	}
+
+	syslogWriter, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return zerolog.SyslogLevelWriter(syslogWriter), nil
+}
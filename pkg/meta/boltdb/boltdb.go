@@ -75,6 +75,11 @@ func New(boltDB *bbolt.DB, log log.Logger) (*BoltDB, error) {
 			return err
 		}
 
+		_, err = repoBlobsBuck.CreateBucketIfNotExists([]byte(RepoEventsBuck))
+		if err != nil {
+			return err
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -136,6 +141,46 @@ func (bdw *BoltDB) GetRepoLastUpdated(repo string) time.Time {
 	return lastUpdated
 }
 
+func (bdw *BoltDB) AddRepoEvent(repo string, event mTypes.RepoEvent, maxEvents int, maxAge time.Duration) error {
+	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
+		repoEventsBuck := tx.Bucket([]byte(RepoBlobsBuck)).Bucket([]byte(RepoEventsBuck))
+
+		events := []mTypes.RepoEvent{}
+
+		if eventsBlob := repoEventsBuck.Get([]byte(repo)); len(eventsBlob) > 0 {
+			if err := json.Unmarshal(eventsBlob, &events); err != nil {
+				return err
+			}
+		}
+
+		eventsBlob, err := json.Marshal(common.AppendRepoEvent(events, event, maxEvents, maxAge))
+		if err != nil {
+			return err
+		}
+
+		return repoEventsBuck.Put([]byte(repo), eventsBlob)
+	})
+
+	return err
+}
+
+func (bdw *BoltDB) GetRepoEvents(ctx context.Context, repo string) ([]mTypes.RepoEvent, error) {
+	events := []mTypes.RepoEvent{}
+
+	err := bdw.DB.View(func(tx *bbolt.Tx) error {
+		repoEventsBuck := tx.Bucket([]byte(RepoBlobsBuck)).Bucket([]byte(RepoEventsBuck))
+
+		eventsBlob := repoEventsBuck.Get([]byte(repo))
+		if len(eventsBlob) == 0 {
+			return nil
+		}
+
+		return json.Unmarshal(eventsBlob, &events)
+	})
+
+	return events, err
+}
+
 func (bdw *BoltDB) SetImageMeta(digest godigest.Digest, imageMeta mTypes.ImageMeta) error {
 	err := bdw.DB.Update(func(tx *bbolt.Tx) error {
 		buck := tx.Bucket([]byte(ImageMetaBuck))
@@ -1107,6 +1152,7 @@ func (bdw *BoltDB) DeleteRepoMeta(repo string) error {
 		repoBuck := tx.Bucket([]byte(RepoMetaBuck))
 		repoBlobsBuck := tx.Bucket([]byte(RepoBlobsBuck))
 		repoLastUpdatedBuck := repoBlobsBuck.Bucket([]byte(RepoLastUpdatedBuck))
+		repoEventsBuck := repoBlobsBuck.Bucket([]byte(RepoEventsBuck))
 
 		err := repoBuck.Delete([]byte(repo))
 		if err != nil {
@@ -1118,6 +1164,11 @@ func (bdw *BoltDB) DeleteRepoMeta(repo string) error {
 			return err
 		}
 
+		err = repoEventsBuck.Delete([]byte(repo))
+		if err != nil {
+			return err
+		}
+
 		return repoLastUpdatedBuck.Delete([]byte(repo))
 	})
 
@@ -1550,6 +1601,16 @@ func (bdw *BoltDB) GetBookmarkedRepos(ctx context.Context) ([]string, error) {
 	return userData.BookmarkedRepos, err
 }
 
+func (bdw *BoltDB) Ping(ctx context.Context) error {
+	return bdw.DB.View(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte(VersionBucket)) == nil {
+			return zerr.ErrBucketDoesNotExist
+		}
+
+		return nil
+	})
+}
+
 func (bdw *BoltDB) PatchDB() error {
 	var DBVersion string
 
@@ -422,6 +422,37 @@ func getPartialManifestList(descriptors []ispec.Descriptor, manifestDigest strin
 	return result
 }
 
+// MaxRepoEvents is the most events kept in the activity log of a repo, whatever the configured limit.
+const MaxRepoEvents = 10000
+
+// AppendRepoEvent adds the event to the activity log of a repo and trims it, keeping at most maxEvents events
+// not older than maxAge. A 0 limit is ignored, the log is still capped to MaxRepoEvents.
+func AppendRepoEvent(events []mTypes.RepoEvent, event mTypes.RepoEvent, maxEvents int, maxAge time.Duration,
+) []mTypes.RepoEvent {
+	events = append(events, event)
+
+	if maxAge > 0 {
+		oldest := event.Timestamp.Add(-maxAge)
+
+		first := 0
+		for first < len(events) && events[first].Timestamp.Before(oldest) {
+			first++
+		}
+
+		events = events[first:]
+	}
+
+	if maxEvents <= 0 || maxEvents > MaxRepoEvents {
+		maxEvents = MaxRepoEvents
+	}
+
+	if len(events) > maxEvents {
+		events = events[len(events)-maxEvents:]
+	}
+
+	return events
+}
+
 func deref[T any](pointer *T, defaultVal T) T {
 	if pointer != nil {
 		return *pointer
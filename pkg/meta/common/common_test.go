@@ -126,4 +126,35 @@ func TestUtils(t *testing.T) {
 
 		So(res, ShouldEqual, false)
 	})
+
+	Convey("AppendRepoEvent", t, func() {
+		now := time.Now()
+
+		events := []mTypes.RepoEvent{
+			{Reference: "old", Timestamp: now.Add(-2 * time.Hour)},
+			{Reference: "recent", Timestamp: now.Add(-time.Minute)},
+		}
+
+		res := common.AppendRepoEvent(events, mTypes.RepoEvent{Reference: "new", Timestamp: now}, 0, 0)
+		So(len(res), ShouldEqual, 3)
+
+		res = common.AppendRepoEvent(events, mTypes.RepoEvent{Reference: "new", Timestamp: now}, 0, time.Hour)
+		So(len(res), ShouldEqual, 2)
+		So(res[0].Reference, ShouldEqual, "recent")
+		So(res[1].Reference, ShouldEqual, "new")
+
+		res = common.AppendRepoEvent(events, mTypes.RepoEvent{Reference: "new", Timestamp: now}, 1, 0)
+		So(len(res), ShouldEqual, 1)
+		So(res[0].Reference, ShouldEqual, "new")
+
+		events = make([]mTypes.RepoEvent, common.MaxRepoEvents)
+
+		res = common.AppendRepoEvent(events, mTypes.RepoEvent{Reference: "new", Timestamp: now}, 0, 0)
+		So(len(res), ShouldEqual, common.MaxRepoEvents)
+		So(res[len(res)-1].Reference, ShouldEqual, "new")
+
+		res = common.AppendRepoEvent(events, mTypes.RepoEvent{Reference: "new", Timestamp: now},
+			2*common.MaxRepoEvents, 0)
+		So(len(res), ShouldEqual, common.MaxRepoEvents)
+	})
 }
@@ -2,6 +2,7 @@ package dynamodb
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -150,6 +151,119 @@ func (dwr *DynamoDB) GetRepoLastUpdated(repo string) time.Time {
 	return lastUpdated
 }
 
+// maxRepoEventsUpdates is the number of times the activity log of a repo is read and updated again when it was
+// changed in between.
+const maxRepoEventsUpdates = 10
+
+// AddRepoEvent updates the activity log of the repo only if it wasn't changed since it was read, so the events
+// added concurrently, e.g. by several zot instances sharing the table, aren't lost.
+func (dwr *DynamoDB) AddRepoEvent(repo string, event mTypes.RepoEvent, maxEvents int, maxAge time.Duration) error {
+	var err error
+
+	for i := 0; i < maxRepoEventsUpdates; i++ {
+		err = dwr.addRepoEvent(repo, event, maxEvents, maxAge)
+		if temp := new(types.ConditionalCheckFailedException); !errors.As(err, &temp) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (dwr *DynamoDB) addRepoEvent(repo string, event mTypes.RepoEvent, maxEvents int, maxAge time.Duration) error {
+	previousBlob, err := dwr.getRepoEventsBlob(context.Background(), repo)
+	if err != nil {
+		return err
+	}
+
+	events := []mTypes.RepoEvent{}
+
+	if len(previousBlob) > 0 {
+		if err := json.Unmarshal(previousBlob, &events); err != nil {
+			return err
+		}
+	}
+
+	eventsBlob, err := json.Marshal(common.AppendRepoEvent(events, event, maxEvents, maxAge))
+	if err != nil {
+		return err
+	}
+
+	mdAttributeValue, err := attributevalue.Marshal(eventsBlob)
+	if err != nil {
+		return err
+	}
+
+	attributeValues := map[string]types.AttributeValue{
+		":RepoEvents": mdAttributeValue,
+	}
+	condition := "attribute_not_exists(#RE)"
+
+	if previousBlob != nil {
+		previousAttributeValue, err := attributevalue.Marshal(previousBlob)
+		if err != nil {
+			return err
+		}
+
+		attributeValues[":PreviousRepoEvents"] = previousAttributeValue
+		condition = "#RE = :PreviousRepoEvents"
+	}
+
+	_, err = dwr.Client.UpdateItem(context.Background(), &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]string{
+			"#RE": "RepoEvents",
+		},
+		ExpressionAttributeValues: attributeValues,
+		Key: map[string]types.AttributeValue{
+			"TableKey": &types.AttributeValueMemberS{
+				Value: repo,
+			},
+		},
+		TableName:           aws.String(dwr.RepoBlobsTablename),
+		ConditionExpression: aws.String(condition),
+		UpdateExpression:    aws.String("SET #RE = :RepoEvents"),
+	})
+
+	return err
+}
+
+func (dwr *DynamoDB) GetRepoEvents(ctx context.Context, repo string) ([]mTypes.RepoEvent, error) {
+	events := []mTypes.RepoEvent{}
+
+	eventsBlob, err := dwr.getRepoEventsBlob(ctx, repo)
+	if err != nil || len(eventsBlob) == 0 {
+		return events, err
+	}
+
+	err = json.Unmarshal(eventsBlob, &events)
+
+	return events, err
+}
+
+// getRepoEventsBlob returns the activity log of the repo as stored, nil if there's none.
+func (dwr *DynamoDB) getRepoEventsBlob(ctx context.Context, repo string) ([]byte, error) {
+	resp, err := dwr.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(dwr.RepoBlobsTablename),
+		Key: map[string]types.AttributeValue{
+			"TableKey": &types.AttributeValueMemberS{Value: repo},
+		},
+		ProjectionExpression: aws.String("RepoEvents"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Item == nil || resp.Item["RepoEvents"] == nil {
+		return nil, nil
+	}
+
+	var eventsBlob []byte
+
+	err = attributevalue.Unmarshal(resp.Item["RepoEvents"], &eventsBlob)
+
+	return eventsBlob, err
+}
+
 func (dwr *DynamoDB) ImageTrustStore() mTypes.ImageTrustStore {
 	return dwr.imgTrustStore
 }
@@ -2004,6 +2118,14 @@ func getBatchImageKeys(digests []string) []map[string]types.AttributeValue {
 	return result
 }
 
+func (dwr *DynamoDB) Ping(ctx context.Context) error {
+	_, err := dwr.Client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(dwr.VersionTablename),
+	})
+
+	return err
+}
+
 func (dwr *DynamoDB) PatchDB() error {
 	DBVersion, err := dwr.getDBVersion()
 	if err != nil {
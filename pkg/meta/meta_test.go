@@ -1345,6 +1345,52 @@ func RunMetaDBTests(t *testing.T, metaDB mTypes.MetaDB, preparationFuncs ...func
 			So(time.Now(), ShouldHappenAfter, repoMeta.Statistics[image1.Digest.String()].LastPullTimestamp)
 		})
 
+		Convey("Test AddRepoEvent", func() {
+			repo1 := "repo1"
+			now := time.Now()
+
+			events, err := metaDB.GetRepoEvents(ctx, repo1)
+			So(err, ShouldBeNil)
+			So(events, ShouldBeEmpty)
+
+			for i := 0; i < 3; i++ {
+				err = metaDB.AddRepoEvent(repo1, mTypes.RepoEvent{
+					Type:      "push",
+					Reference: fmt.Sprintf("tag%d", i),
+					Digest:    godigest.FromString(fmt.Sprint(i)).String(),
+					Actor:     "user",
+					Timestamp: now.Add(time.Duration(i) * time.Minute),
+				}, 2, 0)
+				So(err, ShouldBeNil)
+			}
+
+			events, err = metaDB.GetRepoEvents(ctx, repo1)
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 2)
+			So(events[0].Reference, ShouldEqual, "tag1")
+			So(events[1].Reference, ShouldEqual, "tag2")
+			So(events[1].Actor, ShouldEqual, "user")
+
+			err = metaDB.AddRepoEvent(repo1, mTypes.RepoEvent{
+				Type:      "delete",
+				Reference: "tag2",
+				Timestamp: now.Add(time.Hour),
+			}, 10, 30*time.Minute)
+			So(err, ShouldBeNil)
+
+			events, err = metaDB.GetRepoEvents(ctx, repo1)
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 1)
+			So(events[0].Type, ShouldEqual, "delete")
+
+			err = metaDB.DeleteRepoMeta(repo1)
+			So(err, ShouldBeNil)
+
+			events, err = metaDB.GetRepoEvents(ctx, repo1)
+			So(err, ShouldBeNil)
+			So(events, ShouldBeEmpty)
+		})
+
 		Convey("Test AddImageSignature", func() {
 			var (
 				repo1  = "repo1"
@@ -137,6 +137,13 @@ type MetaDB interface { //nolint:interfacebloat
 
 	GetRepoLastUpdated(repo string) time.Time
 
+	// AddRepoEvent appends an event to the activity log of the repo, dropping the oldest events past maxEvents
+	// and the ones older than maxAge, a 0 value meaning no limit
+	AddRepoEvent(repo string, event RepoEvent, maxEvents int, maxAge time.Duration) error
+
+	// GetRepoEvents returns the activity log of the repo, oldest event first
+	GetRepoEvents(ctx context.Context, repo string) ([]RepoEvent, error)
+
 	GetAllRepoNames() ([]string, error)
 
 	// ResetDB will delete all data in the DB
@@ -144,6 +151,9 @@ type MetaDB interface { //nolint:interfacebloat
 
 	PatchDB() error
 
+	// Ping checks the DB can be queried
+	Ping(ctx context.Context) error
+
 	ImageTrustStore() ImageTrustStore
 
 	SetImageTrustStore(imgTrustStore ImageTrustStore)
@@ -294,6 +304,18 @@ type DescriptorStatistics struct {
 	PushedBy          string
 }
 
+// RepoEvent is an entry of the activity log of a repo: a push, pull or delete of a reference, or a tag moved
+// to another manifest.
+type RepoEvent struct {
+	Type           string    `json:"type"`
+	Reference      string    `json:"reference"`
+	Digest         string    `json:"digest"`
+	MediaType      string    `json:"mediaType"`
+	PreviousDigest string    `json:"previousDigest,omitempty"`
+	Actor          string    `json:"actor,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
 type (
 	SignatureType = string
 )
@@ -0,0 +1,28 @@
+package uac
+
+import (
+	"context"
+)
+
+// request-local context key.
+var auditCtxKey = Key(2) //nolint: gochecknoglobals
+
+// AuditRecord is shared by the audit logger with the handlers of a request, it's filled with
+// what's only known once the request is authenticated.
+type AuditRecord struct {
+	Username string
+}
+
+// WithAuditRecord returns a context carrying a new AuditRecord for the request.
+func WithAuditRecord(ctx context.Context) (context.Context, *AuditRecord) {
+	record := &AuditRecord{}
+
+	return context.WithValue(ctx, &auditCtxKey, record), record
+}
+
+// AuditRecordFromContext returns the AuditRecord of the request, nil if audit logging is disabled.
+func AuditRecordFromContext(ctx context.Context) *AuditRecord {
+	record, _ := ctx.Value(&auditCtxKey).(*AuditRecord)
+
+	return record
+}
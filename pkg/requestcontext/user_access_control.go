@@ -226,6 +226,10 @@ func (uac *UserAccessControl) matchesRepo(globPatterns map[string]bool, reposito
 Later UserAcFromContext(request.Context()) can be used to obtain UserAccessControl that was saved on it.
 */
 func (uac *UserAccessControl) SaveOnRequest(request *http.Request) {
+	if record := AuditRecordFromContext(request.Context()); record != nil {
+		record.Username = uac.GetUsername()
+	}
+
 	uacContext := context.WithValue(request.Context(), GetContextKey(), *uac)
 
 	*request = *request.WithContext(uacContext)
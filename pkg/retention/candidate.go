@@ -18,6 +18,7 @@ func GetCandidates(repoMeta mTypes.RepoMeta) []*types.Candidate {
 					Tag:           tag,
 					PushTimestamp: stats.PushTimestamp,
 					PullTimestamp: stats.LastPullTimestamp,
+					Signed:        isSigned(repoMeta.Signatures[digestStr]),
 				}
 
 				candidates = append(candidates, candidate)
@@ -27,3 +28,13 @@ func GetCandidates(repoMeta mTypes.RepoMeta) []*types.Candidate {
 
 	return candidates
 }
+
+func isSigned(signatures mTypes.ManifestSignatures) bool {
+	for _, signaturesOfType := range signatures {
+		if len(signaturesOfType) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
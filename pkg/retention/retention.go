@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	glob "github.com/bmatcuk/doublestar/v4"
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 
 	zerr "zotregistry.dev/zot/errors"
@@ -40,7 +39,7 @@ func NewPolicyManager(config config.ImageRetention, log zlog.Logger, auditLog *z
 	return policyManager{
 		config:   config,
 		regex:    NewRegexMatcher(),
-		log:      log,
+		log:      log.Module("retention"),
 		auditLog: auditLog,
 	}
 }
@@ -174,6 +173,20 @@ func (p policyManager) GetRetainedTags(ctx context.Context, repoMeta mTypes.Repo
 		}
 	}
 
+	// signed tags are kept whether a tag policy retained them or not
+	if policy, err := p.getRepoPolicy(repo); err == nil && policy.KeepSigned {
+		for _, candidate := range candidates {
+			if candidate.Signed && !zcommon.Contains(retainTags, candidate.Tag) {
+				candidate.RetainedBy = keepSignedName
+
+				logAction(repo, "keep", fmt.Sprintf(retainedStrFormat, candidate.RetainedBy), candidate,
+					p.config.DryRun, &p.log)
+
+				retainTags = append(retainTags, candidate.Tag)
+			}
+		}
+	}
+
 	// log tags which will be removed
 	for _, candidateInfo := range candidates {
 		if !zcommon.Contains(retainTags, candidateInfo.Tag) {
@@ -197,11 +210,8 @@ func (p policyManager) GetRetainedTags(ctx context.Context, repoMeta mTypes.Repo
 
 func (p policyManager) getRepoPolicy(repo string) (config.RetentionPolicy, error) {
 	for _, policy := range p.config.Policies {
-		for _, pattern := range policy.Repositories {
-			matched, err := glob.Match(pattern, repo)
-			if err == nil && matched {
-				return policy, nil
-			}
+		if zcommon.MatchesAnyGlob(policy.Repositories, repo) {
+			return policy, nil
 		}
 	}
 
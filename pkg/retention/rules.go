@@ -14,6 +14,7 @@ const (
 	daysPushName   = "pushedWithin"
 	latestPullName = "mostRecentlyPulledCount"
 	latestPushName = "mostRecentlyPushedCount"
+	keepSignedName = "keepSigned"
 )
 
 // rules implementatio
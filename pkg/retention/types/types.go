@@ -15,6 +15,7 @@ type Candidate struct {
 	Tag           string
 	PushTimestamp time.Time
 	PullTimestamp time.Time
+	Signed        bool
 	RetainedBy    string
 }
 
@@ -93,7 +93,7 @@ func NewScheduler(cfg *config.Config, ms monitoring.MetricServer, logC log.Logge
 	chHigh := make(chan Task, rateLimiterScheduler)
 	generatorPQ := make(generatorsPriorityQueue, 0)
 	numWorkers := getNumWorkers(cfg)
-	sublogger := logC.With().Str("component", "scheduler").Logger()
+	sublogger := logC.Module("scheduler").With().Str("component", "scheduler").Logger()
 
 	heap.Init(&generatorPQ)
 	// force pushing this metric (for zot minimal metrics are enabled on first scraping)
@@ -407,7 +407,7 @@ func (scheduler *Scheduler) SubmitTask(task Task, priority Priority) {
 	}
 
 	select {
-	case tasksQ <- task:
+	case tasksQ <- submittedTask{task}:
 		scheduler.log.Info().Msg("adding a new task")
 	default:
 		if scheduler.inShutdown() {
@@ -416,6 +416,31 @@ func (scheduler *Scheduler) SubmitTask(task Task, priority Priority) {
 	}
 }
 
+// submittedTask is a task submitted with SubmitTask rather than generated, handed over by SubmitPendingTasks.
+type submittedTask struct {
+	Task
+}
+
+// SubmitPendingTasks submits to another scheduler the tasks submitted to this one, e.g. the CVE scans of the pushed
+// images, which didn't start before it was shut down. The tasks of its generators are left out, the other scheduler
+// having its own generators.
+func (scheduler *Scheduler) SubmitPendingTasks(other *Scheduler) {
+	for _, priority := range []Priority{HighPriority, MediumPriority, LowPriority} {
+		tasksQ := scheduler.getTasksChannelByPriority(priority)
+
+		for drained := false; !drained; {
+			select {
+			case task := <-tasksQ:
+				if submitted, ok := task.(submittedTask); ok {
+					other.SubmitTask(submitted.Task, priority)
+				}
+			default:
+				drained = true
+			}
+		}
+	}
+}
+
 type Priority int
 
 const (
@@ -380,6 +380,29 @@ func TestScheduler(t *testing.T) {
 		So(string(data), ShouldNotContainSubstring, "adding a new task")
 	})
 
+	Convey("Test handing over the pending tasks to another scheduler", t, func() {
+		logFile, err := os.CreateTemp("", "zot-log*.txt")
+		So(err, ShouldBeNil)
+
+		defer os.Remove(logFile.Name()) // clean up
+
+		logger := log.NewLogger("debug", logFile.Name())
+		metrics := monitoring.NewMetricsServer(true, logger)
+		sch := scheduler.NewScheduler(config.New(), metrics, logger)
+		otherSch := scheduler.NewScheduler(config.New(), metrics, logger)
+
+		sch.SubmitTask(&task{log: logger, msg: "executing pending task", err: false}, scheduler.HighPriority)
+		sch.SubmitPendingTasks(otherSch)
+
+		otherSch.RunScheduler()
+		time.Sleep(500 * time.Millisecond)
+		otherSch.Shutdown()
+
+		data, err := os.ReadFile(logFile.Name())
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, "executing pending task")
+	})
+
 	Convey("Test stopping scheduler by calling Shutdown()", t, func() {
 		logFile, err := os.CreateTemp("", "zot-log*.txt")
 		So(err, ShouldBeNil)
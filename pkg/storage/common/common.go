@@ -8,9 +8,13 @@ import (
 	"fmt"
 	"math/rand"
 	"path"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/registry/storage/driver"
 	godigest "github.com/opencontainers/go-digest"
 	"github.com/opencontainers/image-spec/schema"
@@ -66,7 +70,7 @@ func ValidateManifest(imgStore storageTypes.ImageStore, repo, reference, mediaTy
 	log zlog.Logger,
 ) (godigest.Digest, error) {
 	// validate the manifest
-	if !IsSupportedMediaType(mediaType) {
+	if !IsSupportedMediaType(mediaType) && !IsDockerMediaType(mediaType) {
 		log.Debug().Interface("actual", mediaType).
 			Msg("bad manifest media type")
 
@@ -80,7 +84,7 @@ func ValidateManifest(imgStore storageTypes.ImageStore, repo, reference, mediaTy
 	}
 
 	switch mediaType {
-	case ispec.MediaTypeImageManifest:
+	case ispec.MediaTypeImageManifest, schema2.MediaTypeManifest:
 		var manifest ispec.Manifest
 
 		// validate manifest
@@ -98,7 +102,8 @@ func ValidateManifest(imgStore storageTypes.ImageStore, repo, reference, mediaTy
 
 		// validate blobs only for known media types
 		if manifest.Config.MediaType == ispec.MediaTypeImageConfig ||
-			manifest.Config.MediaType == ispec.MediaTypeEmptyJSON {
+			manifest.Config.MediaType == ispec.MediaTypeEmptyJSON ||
+			manifest.Config.MediaType == schema2.MediaTypeImageConfig {
 			// validate config blob - a lightweight check if the blob is present
 			ok, _, _, err := imgStore.StatBlob(repo, manifest.Config.Digest)
 			if !ok || err != nil {
@@ -127,13 +132,43 @@ func ValidateManifest(imgStore storageTypes.ImageStore, repo, reference, mediaTy
 			}
 		}
 	case oras.MediaTypeArtifactManifest:
-		var m oras.Descriptor
-		if err := json.Unmarshal(body, &m); err != nil {
+		var artifactManifest oras.Manifest
+		if err := json.Unmarshal(body, &artifactManifest); err != nil {
 			log.Error().Err(err).Msg("failed to unmarshal JSON")
 
 			return "", zerr.ErrBadManifest
 		}
-	case ispec.MediaTypeImageIndex:
+
+		if artifactManifest.MediaType != "" && artifactManifest.MediaType != oras.MediaTypeArtifactManifest {
+			log.Error().Str("mediaType", artifactManifest.MediaType).Msg("failed to validate ORAS artifact manifest media type")
+
+			return "", zerr.ErrBadManifest
+		}
+
+		if artifactManifest.ArtifactType == "" {
+			log.Error().Msg("failed to validate ORAS artifact manifest due to missing artifact type")
+
+			return "", zerr.ErrBadManifest
+		}
+
+		if artifactManifest.Subject != nil {
+			if err := artifactManifest.Subject.Digest.Validate(); err != nil {
+				log.Error().Err(err).Msg("failed to validate ORAS artifact manifest subject")
+
+				return "", zerr.ErrBadManifest
+			}
+		}
+
+		// validate blobs - a lightweight check if the blob is present
+		for _, blob := range artifactManifest.Blobs {
+			if ok, _, _, err := imgStore.StatBlob(repo, blob.Digest); !ok || err != nil {
+				log.Error().Err(err).Str("digest", blob.Digest.String()).
+					Msg("failed to stat blob due to missing ORAS artifact blob")
+
+				return "", zerr.ErrBadManifest
+			}
+		}
+	case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
 		// validate manifest
 		if err := ValidateImageIndexSchema(body); err != nil {
 			log.Error().Err(err).Msg("failed to validate OCIv1 image index manifest schema")
@@ -536,7 +571,7 @@ func IsBlobReferencedInImageIndex(imgStore storageTypes.ImageStore, repo string,
 		var found bool
 
 		switch desc.MediaType {
-		case ispec.MediaTypeImageIndex:
+		case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
 			indexImage, err := GetImageIndex(imgStore, repo, desc.Digest, log)
 			if err != nil {
 				log.Error().Err(err).Str("repository", repo).Str("digest", desc.Digest.String()).
@@ -546,7 +581,7 @@ func IsBlobReferencedInImageIndex(imgStore storageTypes.ImageStore, repo string,
 			}
 
 			found, _ = IsBlobReferencedInImageIndex(imgStore, repo, digest, indexImage, log)
-		case ispec.MediaTypeImageManifest:
+		case ispec.MediaTypeImageManifest, schema2.MediaTypeManifest:
 			found, _ = isBlobReferencedInImageManifest(imgStore, repo, digest, desc.Digest, log)
 		case oras.MediaTypeArtifactManifest:
 			found, _ = isBlobReferencedInORASManifest(imgStore, repo, digest, desc.Digest, log)
@@ -632,6 +667,11 @@ func IsSignature(descriptor ispec.Descriptor) bool {
 	return false
 }
 
+// IsBlobPath tells if the path is the one of a blob, <repo>/blobs/<algorithm>/<encoded digest>.
+func IsBlobPath(blobPath string) bool {
+	return filepath.Base(filepath.Dir(filepath.Dir(blobPath))) == "blobs"
+}
+
 func GetOrasReferrers(imgStore storageTypes.ImageStore, repo string, gdigest godigest.Digest, artifactType string,
 	log zlog.Logger,
 ) ([]oras.Descriptor, error) {
@@ -784,6 +824,8 @@ func GetReferrers(imgStore storageTypes.ImageStore, repo string, gdigest godiges
 		}
 	}
 
+	result = appendReferrersTagDescriptors(imgStore, repo, index, gdigest, artifactTypes, result, log)
+
 	index = ispec.Index{
 		Versioned:   imeta.Versioned{SchemaVersion: storageConstants.SchemaVersion},
 		MediaType:   ispec.MediaTypeImageIndex,
@@ -794,6 +836,49 @@ func GetReferrers(imgStore storageTypes.ImageStore, repo string, gdigest godiges
 	return index, nil
 }
 
+// appendReferrersTagDescriptors adds the referrers listed in the index tagged following the referrers tag schema
+// (https://github.com/opencontainers/distribution-spec/blob/main/spec.md#referrers-tag-schema), clients
+// maintain it for registries without the referrers API so it's found in the images copied from them.
+func appendReferrersTagDescriptors(imgStore storageTypes.ImageStore, repo string, index ispec.Index,
+	gdigest godigest.Digest, artifactTypes []string, result []ispec.Descriptor, log zlog.Logger,
+) []ispec.Descriptor {
+	referrersTag := fmt.Sprintf("%s-%s", gdigest.Algorithm(), gdigest.Encoded())
+
+	for _, descriptor := range index.Manifests {
+		if descriptor.MediaType != ispec.MediaTypeImageIndex ||
+			descriptor.Annotations[ispec.AnnotationRefName] != referrersTag {
+			continue
+		}
+
+		referrersIndex, err := GetImageIndex(imgStore, repo, descriptor.Digest, log)
+		if err != nil {
+			log.Warn().Err(err).Str("repository", repo).Str("tag", referrersTag).
+				Msg("failed to read index with referrers tag, skipping it")
+
+			continue
+		}
+
+		for _, referrer := range referrersIndex.Manifests {
+			if slices.ContainsFunc(result, func(desc ispec.Descriptor) bool { return desc.Digest == referrer.Digest }) {
+				continue
+			}
+
+			if len(artifactTypes) > 0 && !zcommon.Contains(artifactTypes, referrer.ArtifactType) {
+				continue
+			}
+
+			// the index may still list the referrers deleted since it was pushed
+			if ok, _, _, err := imgStore.StatBlob(repo, referrer.Digest); err != nil || !ok {
+				continue
+			}
+
+			result = append(result, referrer)
+		}
+	}
+
+	return result
+}
+
 func GetOrasManifestByDigest(imgStore storageTypes.ImageStore, repo string, digest godigest.Digest, log zlog.Logger,
 ) (oras.Manifest, error) {
 	var artManifest oras.Manifest
@@ -841,11 +926,11 @@ func GetBlobDescriptorFromIndex(imgStore storageTypes.ImageStore, index ispec.In
 		}
 
 		switch desc.MediaType {
-		case ispec.MediaTypeImageManifest:
+		case ispec.MediaTypeImageManifest, schema2.MediaTypeManifest:
 			if foundDescriptor, err := getBlobDescriptorFromManifest(imgStore, repo, blobDigest, desc, log); err == nil {
 				return foundDescriptor, nil
 			}
-		case ispec.MediaTypeImageIndex:
+		case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
 			indexImage, err := GetImageIndex(imgStore, repo, desc.Digest, log)
 			if err != nil {
 				return ispec.Descriptor{}, err
@@ -914,10 +999,19 @@ func IsSupportedMediaType(mediaType string) bool {
 		mediaType == oras.MediaTypeArtifactManifest
 }
 
+// IsDockerMediaType tells if the media type is the one of a Docker schema2 manifest or manifest list, they're
+// accepted along with the OCI ones by the registries configured for compatibility with older clients.
+func IsDockerMediaType(mediaType string) bool {
+	return mediaType == schema2.MediaTypeManifest || mediaType == manifestlist.MediaTypeManifestList
+}
+
+// IsNonDistributable tells if the layer is not stored in the registry, the foreign layers of the Docker
+// manifests are the ancestors of the OCI non-distributable ones.
 func IsNonDistributable(mediaType string) bool {
 	return mediaType == ispec.MediaTypeImageLayerNonDistributable || //nolint:staticcheck
 		mediaType == ispec.MediaTypeImageLayerNonDistributableGzip || //nolint:staticcheck
-		mediaType == ispec.MediaTypeImageLayerNonDistributableZstd //nolint:staticcheck
+		mediaType == ispec.MediaTypeImageLayerNonDistributableZstd || //nolint:staticcheck
+		mediaType == schema2.MediaTypeForeignLayer
 }
 
 func ValidateManifestSchema(buf []byte) error {
@@ -1162,3 +1256,76 @@ func (smt *smTask) String() string {
 func (smt *smTask) Name() string {
 	return "StorageMetricsTask"
 }
+
+// BlobUploadsCleanupGenerator generates a task for each repository, removing its uploads which timed out.
+type BlobUploadsCleanupGenerator struct {
+	ImgStore storageTypes.ImageStore
+	Timeout  time.Duration
+	Log      zlog.Logger
+	lastRepo string
+	done     bool
+}
+
+func (gen *BlobUploadsCleanupGenerator) Name() string {
+	return "BlobUploadsCleanupGenerator"
+}
+
+func (gen *BlobUploadsCleanupGenerator) Next() (scheduler.Task, error) {
+	repo, err := gen.ImgStore.GetNextRepository(gen.lastRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	if repo == "" {
+		gen.done = true
+
+		return nil, nil
+	}
+
+	gen.lastRepo = repo
+
+	return &blobUploadsCleanupTask{imgStore: gen.ImgStore, repo: repo, timeout: gen.Timeout, log: gen.Log}, nil
+}
+
+func (gen *BlobUploadsCleanupGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *BlobUploadsCleanupGenerator) IsReady() bool {
+	return true
+}
+
+func (gen *BlobUploadsCleanupGenerator) Reset() {
+	gen.lastRepo = ""
+	gen.done = false
+}
+
+type blobUploadsCleanupTask struct {
+	imgStore storageTypes.ImageStore
+	repo     string
+	timeout  time.Duration
+	log      zlog.Logger
+}
+
+func (task *blobUploadsCleanupTask) DoWork(ctx context.Context) error {
+	count, err := task.imgStore.CleanupBlobUploads(task.repo, task.timeout)
+	if err != nil {
+		task.log.Error().Err(err).Str("repository", task.repo).Msg("failed to remove stale blob uploads")
+
+		return err
+	}
+
+	if count > 0 {
+		task.log.Info().Str("repository", task.repo).Int("count", count).Msg("removed stale blob uploads")
+	}
+
+	return nil
+}
+
+func (task *blobUploadsCleanupTask) String() string {
+	return fmt.Sprintf("{Name: \"%s\", repo: \"%s\"}", task.Name(), task.repo)
+}
+
+func (task *blobUploadsCleanupTask) Name() string {
+	return "BlobUploadsCleanupTask"
+}
@@ -139,6 +139,145 @@ func TestValidateManifest(t *testing.T) {
 			_, _, err = imgStore.PutImageManifest("test", "1.0", ispec.MediaTypeImageManifest, body)
 			So(err, ShouldBeNil)
 		})
+
+		Convey("ORAS artifact manifest", func() {
+			subject := ispec.Manifest{
+				Config: ispec.Descriptor{
+					MediaType: ispec.MediaTypeImageConfig,
+					Digest:    cdigest,
+					Size:      int64(len(cblob)),
+				},
+				Layers: []ispec.Descriptor{
+					{
+						MediaType: ispec.MediaTypeImageLayer,
+						Digest:    digest,
+						Size:      int64(len(content)),
+					},
+				},
+			}
+			subject.SchemaVersion = 2
+
+			subjectBody, err := json.Marshal(subject)
+			So(err, ShouldBeNil)
+
+			subjectDigest, _, err := imgStore.PutImageManifest("test", "1.0", ispec.MediaTypeImageManifest, subjectBody)
+			So(err, ShouldBeNil)
+
+			artifactManifest := artifactspec.Manifest{
+				MediaType:    artifactspec.MediaTypeArtifactManifest,
+				ArtifactType: "signature-example",
+				Blobs: []artifactspec.Descriptor{
+					{MediaType: "application/octet-stream", Digest: digest, Size: int64(len(content))},
+				},
+				Subject: &artifactspec.Descriptor{
+					MediaType: ispec.MediaTypeImageManifest,
+					Digest:    subjectDigest,
+					Size:      int64(len(subjectBody)),
+				},
+			}
+
+			body, err := json.Marshal(artifactManifest)
+			So(err, ShouldBeNil)
+
+			manifestDigest, pushedSubjectDigest, err := imgStore.PutImageManifest("test",
+				godigest.FromBytes(body).String(), artifactspec.MediaTypeArtifactManifest, body)
+			So(err, ShouldBeNil)
+			So(manifestDigest, ShouldEqual, godigest.FromBytes(body))
+			So(pushedSubjectDigest, ShouldEqual, subjectDigest)
+
+			Convey("without artifact type", func() {
+				artifactManifest.ArtifactType = ""
+
+				body, err := json.Marshal(artifactManifest)
+				So(err, ShouldBeNil)
+
+				_, _, err = imgStore.PutImageManifest("test", godigest.FromBytes(body).String(),
+					artifactspec.MediaTypeArtifactManifest, body)
+				So(errors.Is(err, zerr.ErrBadManifest), ShouldBeTrue)
+			})
+
+			Convey("with a missing blob", func() {
+				artifactManifest.Blobs[0].Digest = godigest.FromString("missing blob")
+
+				body, err := json.Marshal(artifactManifest)
+				So(err, ShouldBeNil)
+
+				_, _, err = imgStore.PutImageManifest("test", godigest.FromBytes(body).String(),
+					artifactspec.MediaTypeArtifactManifest, body)
+				So(errors.Is(err, zerr.ErrBadManifest), ShouldBeTrue)
+			})
+
+			Convey("with another media type", func() {
+				artifactManifest.MediaType = ispec.MediaTypeImageManifest
+
+				body, err := json.Marshal(artifactManifest)
+				So(err, ShouldBeNil)
+
+				_, _, err = imgStore.PutImageManifest("test", godigest.FromBytes(body).String(),
+					artifactspec.MediaTypeArtifactManifest, body)
+				So(errors.Is(err, zerr.ErrBadManifest), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestGetReferrersTag(t *testing.T) {
+	Convey("Referrers listed in the index with the referrers tag", t, func() {
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+		storeController := storage.StoreController{
+			DefaultStore: local.NewImageStore(t.TempDir(), false, false, log, metrics, nil, nil),
+		}
+		imgStore := storeController.DefaultStore
+
+		image := CreateRandomImage()
+		So(WriteImageToFileSystem(image, "repo", "1.0", storeController), ShouldBeNil)
+
+		// the manifests pushed by clients following the referrers tag schema may lack the subject
+		signature := CreateRandomImage()
+		So(WriteImageToFileSystem(signature, "repo", signature.DigestStr(), storeController), ShouldBeNil)
+
+		sbom := CreateImageWith().RandomLayers(1, 10).DefaultConfig().Subject(image.DescriptorRef()).
+			ArtifactType("application/vnd.example.sbom").Build()
+		So(WriteImageToFileSystem(sbom, "repo", sbom.DigestStr(), storeController), ShouldBeNil)
+
+		signatureDesc := signature.Descriptor()
+		signatureDesc.ArtifactType = "application/vnd.example.signature"
+		sbomDesc := sbom.Descriptor()
+		sbomDesc.ArtifactType = "application/vnd.example.sbom"
+
+		referrersIndex := ispec.Index{
+			MediaType: ispec.MediaTypeImageIndex,
+			Manifests: []ispec.Descriptor{signatureDesc, sbomDesc},
+		}
+		referrersIndex.SchemaVersion = 2
+
+		indexBlob, err := json.Marshal(referrersIndex)
+		So(err, ShouldBeNil)
+
+		referrersTag := "sha256-" + image.Digest().Encoded()
+		_, _, err = imgStore.PutImageManifest("repo", referrersTag, ispec.MediaTypeImageIndex, indexBlob)
+		So(err, ShouldBeNil)
+
+		referrers, err := common.GetReferrers(imgStore, "repo", image.Digest(), nil, log)
+		So(err, ShouldBeNil)
+		So(len(referrers.Manifests), ShouldEqual, 2)
+
+		digests := []godigest.Digest{referrers.Manifests[0].Digest, referrers.Manifests[1].Digest}
+		So(digests, ShouldContain, signature.Digest())
+		So(digests, ShouldContain, sbom.Digest())
+
+		referrers, err = common.GetReferrers(imgStore, "repo", image.Digest(),
+			[]string{"application/vnd.example.signature"}, log)
+		So(err, ShouldBeNil)
+		So(len(referrers.Manifests), ShouldEqual, 1)
+		So(referrers.Manifests[0].Digest, ShouldEqual, signature.Digest())
+		So(referrers.Manifests[0].ArtifactType, ShouldEqual, "application/vnd.example.signature")
+
+		// the referrers tag of another subject doesn't apply
+		referrers, err = common.GetReferrers(imgStore, "repo", sbom.Digest(), nil, log)
+		So(err, ShouldBeNil)
+		So(referrers.Manifests, ShouldBeEmpty)
 	})
 }
 
@@ -0,0 +1,313 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	storagedriver "github.com/docker/distribution/registry/storage/driver"
+
+	zcommon "zotregistry.dev/zot/pkg/common"
+	zlog "zotregistry.dev/zot/pkg/log"
+	common "zotregistry.dev/zot/pkg/storage/common"
+	storageTypes "zotregistry.dev/zot/pkg/storage/types"
+)
+
+// Driver encrypts the blobs, manifests included, of the repositories matching its patterns when they're written
+// and decrypts them when they're read, whatever their repository, so the blobs written before the encryption was
+// enabled or with another key are still read. The other files, e.g. index.json, are in plaintext.
+type Driver struct {
+	storageTypes.Driver
+	rootDir string
+	keyring *Keyring
+	// glob patterns of the repositories encrypted, all of them if empty
+	repositories []string
+	log          zlog.Logger
+}
+
+func NewDriver(driver storageTypes.Driver, rootDir string, keyring *Keyring, repositories []string,
+	log zlog.Logger,
+) *Driver {
+	return &Driver{
+		Driver:       driver,
+		rootDir:      filepath.Clean(rootDir),
+		keyring:      keyring,
+		repositories: repositories,
+		log:          log,
+	}
+}
+
+func (driver *Driver) Reader(path string, offset int64) (io.ReadCloser, error) {
+	if !common.IsBlobPath(path) {
+		return driver.Driver.Reader(path, offset)
+	}
+
+	header, encrypted, size, err := driver.readHeader(path)
+	if err != nil || !encrypted {
+		return driver.Driver.Reader(path, offset)
+	}
+
+	reader, err := driver.Driver.Reader(path, segmentOffset(header, offset))
+	if err != nil {
+		return nil, err
+	}
+
+	decryptReader, err := newDecryptReader(reader, header, size, offset, driver.keyring)
+	if err != nil {
+		reader.Close()
+
+		return nil, err
+	}
+
+	return decryptReader, nil
+}
+
+func (driver *Driver) ReadFile(path string) ([]byte, error) {
+	content, err := driver.Driver.ReadFile(path)
+	if err != nil || !common.IsBlobPath(path) {
+		return content, err
+	}
+
+	if _, encrypted, err := driver.readBlobHeader(path, bytes.NewReader(content)); err != nil || !encrypted {
+		return content, err
+	}
+
+	return decrypt(content, driver.keyring)
+}
+
+// Stat returns the size of the content of the encrypted blobs.
+func (driver *Driver) Stat(path string) (storagedriver.FileInfo, error) {
+	fileInfo, err := driver.Driver.Stat(path)
+	if err != nil || fileInfo.IsDir() || !common.IsBlobPath(path) {
+		return fileInfo, err
+	}
+
+	header, encrypted, size, err := driver.readHeader(path)
+	if err != nil || !encrypted {
+		return fileInfo, err
+	}
+
+	return storagedriver.FileInfoInternal{FileInfoFields: storagedriver.FileInfoFields{
+		Path:    fileInfo.Path(),
+		Size:    plaintextSize(header, size),
+		ModTime: fileInfo.ModTime(),
+		IsDir:   false,
+	}}, nil
+}
+
+// WriteFile encrypts the manifests written to the repositories encrypted.
+func (driver *Driver) WriteFile(path string, content []byte) (int, error) {
+	if !driver.isEncrypted(path) {
+		return driver.Driver.WriteFile(path, content)
+	}
+
+	encrypted, err := encrypt(content, driver.keyring)
+	if err != nil {
+		return -1, err
+	}
+
+	if _, err := driver.Driver.WriteFile(path, encrypted); err != nil {
+		return -1, err
+	}
+
+	return len(content), nil
+}
+
+// Move encrypts the uploads moved to the blobs of the repositories encrypted, the uploads themselves are
+// in plaintext until then. The blobs moved between repositories, e.g. by dedupe, are encrypted or decrypted if
+// only one of them is encrypted.
+func (driver *Driver) Move(sourcePath string, destPath string) error {
+	encrypted := driver.isEncrypted(destPath)
+
+	// whatever their content, the uploads are in plaintext
+	if driver.isEncrypted(sourcePath) == encrypted {
+		return driver.Driver.Move(sourcePath, destPath)
+	}
+
+	if err := driver.copyBlob(sourcePath, destPath, encrypted); err != nil {
+		return err
+	}
+
+	return driver.Driver.Delete(sourcePath)
+}
+
+// Link links the blobs of repositories both encrypted or both in plaintext, the blob is copied, encrypted or
+// decrypted, otherwise.
+func (driver *Driver) Link(src, dest string) error {
+	encrypted := driver.isEncrypted(dest)
+
+	if driver.isEncrypted(src) == encrypted {
+		return driver.Driver.Link(src, dest)
+	}
+
+	return driver.copyBlob(src, dest, encrypted)
+}
+
+// EncryptRepo encrypts with the current key the blobs of the repository which are in plaintext or encrypted with
+// another key, to encrypt the repositories pushed before the encryption was enabled or after the key was rotated.
+// It returns the number of blobs encrypted, the registry must not be running.
+func (driver *Driver) EncryptRepo(repo string) (int, error) {
+	encrypted := 0
+
+	if !driver.isEncryptedRepo(repo) {
+		return encrypted, nil
+	}
+
+	blobsDir := path.Join(driver.rootDir, repo, "blobs")
+
+	err := driver.Driver.Walk(blobsDir, func(fileInfo storagedriver.FileInfo) error {
+		if fileInfo.IsDir() || !common.IsBlobPath(fileInfo.Path()) {
+			return nil
+		}
+
+		blobPath := fileInfo.Path()
+
+		header, ok, _, err := driver.readHeader(blobPath)
+		if err != nil {
+			return err
+		}
+
+		if ok && header.keyID == driver.keyring.current {
+			return nil
+		}
+
+		if err := driver.copyBlob(blobPath, blobPath, true); err != nil {
+			return err
+		}
+
+		encrypted++
+
+		return nil
+	})
+	if err != nil && !errors.As(err, &storagedriver.PathNotFoundError{}) {
+		driver.log.Error().Err(err).Str("repository", repo).Msg("failed to encrypt blobs")
+
+		return encrypted, err
+	}
+
+	driver.log.Info().Str("repository", repo).Int("blobs", encrypted).Str("key", driver.keyring.current).
+		Msg("encrypted blobs")
+
+	return encrypted, nil
+}
+
+// copyBlob writes the content of the file, decrypted first if it's encrypted, to the blob, encrypted with the
+// current key or not. The content is written next to the blobs directory of the repository and moved once
+// complete, so the file can be the blob itself.
+func (driver *Driver) copyBlob(sourcePath, blobPath string, encrypted bool) error {
+	tmpPath := path.Join(path.Dir(path.Dir(path.Dir(blobPath))), ".encrypted-"+path.Base(blobPath))
+
+	if err := driver.copyFile(sourcePath, tmpPath, encrypted); err != nil {
+		_ = driver.Driver.Delete(tmpPath)
+
+		return err
+	}
+
+	if err := driver.Driver.Move(tmpPath, blobPath); err != nil {
+		_ = driver.Driver.Delete(tmpPath)
+
+		return err
+	}
+
+	return nil
+}
+
+func (driver *Driver) copyFile(sourcePath, destPath string, encrypted bool) error {
+	reader, err := driver.Reader(sourcePath, 0)
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	fileWriter, err := driver.Driver.Writer(destPath, false)
+	if err != nil {
+		return err
+	}
+
+	var writer io.WriteCloser = fileWriter
+
+	if encrypted {
+		writer, err = newEncryptWriter(fileWriter, driver.keyring)
+	}
+
+	if err == nil {
+		if _, err = io.Copy(writer, reader); err == nil && encrypted {
+			err = writer.Close()
+		}
+	}
+
+	if err != nil {
+		_ = fileWriter.Cancel()
+		fileWriter.Close()
+
+		return err
+	}
+
+	if err := fileWriter.Commit(); err != nil {
+		fileWriter.Close()
+
+		return err
+	}
+
+	return fileWriter.Close()
+}
+
+// readHeader returns the header and the size of the blob if it's encrypted.
+func (driver *Driver) readHeader(path string) (header, bool, int64, error) {
+	fileInfo, err := driver.Driver.Stat(path)
+	if err != nil {
+		return header{}, false, 0, err
+	}
+
+	reader, err := driver.Driver.Reader(path, 0)
+	if err != nil {
+		return header{}, false, 0, err
+	}
+
+	defer reader.Close()
+
+	blobHeader, encrypted, err := driver.readBlobHeader(path, reader)
+
+	return blobHeader, encrypted, fileInfo.Size(), err
+}
+
+// readBlobHeader returns the header of the blob if it's encrypted. The blobs of the repositories in plaintext
+// are encrypted only if their key is in the keyring, as their content can start like an encrypted one.
+func (driver *Driver) readBlobHeader(path string, reader io.Reader) (header, bool, error) {
+	blobHeader, encrypted, err := readHeader(reader)
+	if err != nil || !encrypted || driver.isEncrypted(path) {
+		return blobHeader, encrypted, err
+	}
+
+	if _, err := driver.keyring.get(blobHeader.keyID); err != nil {
+		return header{}, false, nil
+	}
+
+	return blobHeader, true, nil
+}
+
+// isEncrypted tells if the path is the one of a blob of a repository encrypted.
+func (driver *Driver) isEncrypted(blobPath string) bool {
+	if !common.IsBlobPath(blobPath) {
+		return false
+	}
+
+	repo, err := filepath.Rel(driver.rootDir, filepath.Dir(filepath.Dir(filepath.Dir(filepath.Clean(blobPath)))))
+	if err != nil || repo == "." || strings.HasPrefix(repo, "..") {
+		return false
+	}
+
+	return driver.isEncryptedRepo(filepath.ToSlash(repo))
+}
+
+func (driver *Driver) isEncryptedRepo(repo string) bool {
+	if len(driver.repositories) == 0 {
+		return true
+	}
+
+	return zcommon.MatchesAnyGlob(driver.repositories, repo)
+}
@@ -0,0 +1,310 @@
+package encryption_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	"zotregistry.dev/zot/pkg/log"
+	"zotregistry.dev/zot/pkg/storage"
+	"zotregistry.dev/zot/pkg/storage/cache"
+	"zotregistry.dev/zot/pkg/storage/encryption"
+	"zotregistry.dev/zot/pkg/storage/imagestore"
+	"zotregistry.dev/zot/pkg/storage/local"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+func newKey() []byte {
+	key := make([]byte, 32)
+
+	_, err := rand.Read(key)
+	So(err, ShouldBeNil)
+
+	return key
+}
+
+func readBlobFile(rootDir, repo string, digest godigest.Digest) []byte {
+	content, err := os.ReadFile(path.Join(rootDir, repo, "blobs", digest.Algorithm().String(), digest.Encoded()))
+	So(err, ShouldBeNil)
+
+	return content
+}
+
+type kmsMock struct {
+	kmsiface.KMSAPI
+	key []byte
+}
+
+func (mock kmsMock) DecryptWithContext(ctx aws.Context, input *kms.DecryptInput, opts ...request.Option,
+) (*kms.DecryptOutput, error) {
+	if !bytes.Equal(input.CiphertextBlob, []byte("wrapped")) {
+		return nil, zerr.ErrBadBlob
+	}
+
+	return &kms.DecryptOutput{Plaintext: mock.key}, nil
+}
+
+func TestEncryption(t *testing.T) {
+	Convey("Blobs are encrypted at rest", t, func() {
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		rootDir := t.TempDir()
+		oldKey := newKey()
+
+		keyring, err := encryption.NewKeyring("old", map[string][]byte{"old": oldKey})
+		So(err, ShouldBeNil)
+
+		newStore := func(keyring *encryption.Keyring) (*encryption.Driver, storage.StoreController) {
+			driver := encryption.NewDriver(local.New(true), rootDir, keyring, []string{"secret/**"}, log)
+			imgStore := imagestore.NewImageStore(rootDir, rootDir, true, false, log, metrics, nil, driver, nil)
+
+			return driver, storage.StoreController{DefaultStore: imgStore}
+		}
+
+		driver, storeController := newStore(keyring)
+		imgStore := storeController.DefaultStore
+
+		// the layer spans several segments
+		layer := make([]byte, 200*1024+10)
+		_, err = rand.Read(layer)
+		So(err, ShouldBeNil)
+
+		image := CreateImageWith().LayerBlobs([][]byte{layer}).DefaultConfig().Build()
+		layerDigest := image.Manifest.Layers[0].Digest
+
+		So(WriteImageToFileSystem(image, "secret/app", "1.0", storeController), ShouldBeNil)
+		So(WriteImageToFileSystem(image, "public/app", "1.0", storeController), ShouldBeNil)
+
+		// the blobs of the repositories not matching the patterns stay in plaintext
+		So(readBlobFile(rootDir, "public/app", layerDigest), ShouldResemble, layer)
+
+		encrypted := readBlobFile(rootDir, "secret/app", layerDigest)
+		So(bytes.HasPrefix(encrypted, []byte("zotenc01")), ShouldBeTrue)
+		So(bytes.Contains(encrypted, layer[:1024]), ShouldBeFalse)
+
+		manifestFile := readBlobFile(rootDir, "secret/app", image.Digest())
+		So(bytes.Contains(manifestFile, []byte(layerDigest.String())), ShouldBeFalse)
+
+		// the reads are transparent
+		content, err := imgStore.GetBlobContent("secret/app", layerDigest)
+		So(err, ShouldBeNil)
+		So(content, ShouldResemble, layer)
+
+		ok, size, err := imgStore.CheckBlob("secret/app", layerDigest)
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		So(size, ShouldEqual, len(layer))
+
+		manifest, digest, _, err := imgStore.GetImageManifest("secret/app", "1.0")
+		So(err, ShouldBeNil)
+		So(digest, ShouldEqual, image.Digest())
+		So(godigest.FromBytes(manifest), ShouldEqual, image.Digest())
+
+		for _, byteRange := range [][2]int64{{0, 9}, {65530, 65545}, {100000, 200000}, {204790, 204809}} {
+			reader, _, _, err := imgStore.GetBlobPartial("secret/app", layerDigest, "", byteRange[0], byteRange[1])
+			So(err, ShouldBeNil)
+
+			content, err := io.ReadAll(reader)
+			So(err, ShouldBeNil)
+			So(reader.Close(), ShouldBeNil)
+			So(content, ShouldResemble, layer[byteRange[0]:byteRange[1]+1])
+		}
+
+		Convey("Keys are rotated", func() {
+			rotatedKey := newKey()
+
+			keyring, err := encryption.NewKeyring("new", map[string][]byte{"old": oldKey, "new": rotatedKey})
+			So(err, ShouldBeNil)
+
+			driver, storeController := newStore(keyring)
+
+			// the blobs encrypted with the old key are still read
+			content, err := storeController.DefaultStore.GetBlobContent("secret/app", layerDigest)
+			So(err, ShouldBeNil)
+			So(content, ShouldResemble, layer)
+
+			encrypted, err := driver.EncryptRepo("secret/app")
+			So(err, ShouldBeNil)
+			So(encrypted, ShouldEqual, 3)
+
+			So(bytes.HasPrefix(readBlobFile(rootDir, "secret/app", layerDigest), []byte("zotenc01\x03new")), ShouldBeTrue)
+
+			// nothing left to encrypt
+			encrypted, err = driver.EncryptRepo("secret/app")
+			So(err, ShouldBeNil)
+			So(encrypted, ShouldEqual, 0)
+
+			// the old key can be dropped once the blobs are encrypted again
+			keyring, err = encryption.NewKeyring("new", map[string][]byte{"new": rotatedKey})
+			So(err, ShouldBeNil)
+
+			_, storeController = newStore(keyring)
+
+			content, err = storeController.DefaultStore.GetBlobContent("secret/app", layerDigest)
+			So(err, ShouldBeNil)
+			So(content, ShouldResemble, layer)
+		})
+
+		Convey("Plaintext blobs are encrypted", func() {
+			driver := encryption.NewDriver(local.New(true), rootDir, keyring, nil, log)
+
+			encrypted, err := driver.EncryptRepo("public/app")
+			So(err, ShouldBeNil)
+			So(encrypted, ShouldEqual, 3)
+
+			So(bytes.HasPrefix(readBlobFile(rootDir, "public/app", layerDigest), []byte("zotenc01")), ShouldBeTrue)
+
+			content, err := imgStore.GetBlobContent("public/app", layerDigest)
+			So(err, ShouldBeNil)
+			So(content, ShouldResemble, layer)
+		})
+
+		Convey("Blobs encrypted with an unknown key", func() {
+			keyring, err := encryption.NewKeyring("other", map[string][]byte{"other": newKey()})
+			So(err, ShouldBeNil)
+
+			_, storeController := newStore(keyring)
+
+			_, err = storeController.DefaultStore.GetBlobContent("secret/app", layerDigest)
+			So(errors.Is(err, zerr.ErrEncryptionKeyNotFound), ShouldBeTrue)
+		})
+
+		Convey("Tampered blobs", func() {
+			blobPath := path.Join(rootDir, "secret/app", "blobs", "sha256", layerDigest.Encoded())
+			encrypted[len(encrypted)-1] ^= 1
+			So(os.WriteFile(blobPath, encrypted, 0o600), ShouldBeNil)
+
+			reader, _, err := imgStore.GetBlob("secret/app", layerDigest, "")
+			So(err, ShouldBeNil)
+
+			_, err = io.ReadAll(reader)
+			So(err, ShouldNotBeNil)
+			So(reader.Close(), ShouldBeNil)
+
+			// truncated after the first segment, the header is 24 bytes long
+			So(os.WriteFile(blobPath, encrypted[:24+64*1024+16], 0o600), ShouldBeNil)
+
+			_, err = driver.ReadFile(blobPath)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Blobs deduped between encrypted and plaintext repositories", t, func() {
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		rootDir := t.TempDir()
+
+		keyring, err := encryption.NewKeyring("key", map[string][]byte{"key": newKey()})
+		So(err, ShouldBeNil)
+
+		cacheDriver, err := storage.Create("boltdb", cache.BoltDBDriverParameters{
+			RootDir:     rootDir,
+			Name:        "cache",
+			UseRelPaths: true,
+		}, log)
+		So(err, ShouldBeNil)
+
+		driver := encryption.NewDriver(local.New(true), rootDir, keyring, []string{"secret/**"}, log)
+		imgStore := imagestore.NewImageStore(rootDir, rootDir, true, false, log, metrics, nil, driver, cacheDriver)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		// a plaintext blob which looks like an encrypted one is encrypted all the same
+		layer := append([]byte("zotenc01"), make([]byte, 1024)...)
+		_, err = rand.Read(layer[8:])
+		So(err, ShouldBeNil)
+
+		image := CreateImageWith().LayerBlobs([][]byte{layer}).DefaultConfig().Build()
+		layerDigest := image.Manifest.Layers[0].Digest
+
+		checkBlobs := func() {
+			So(readBlobFile(rootDir, "public/app", layerDigest), ShouldResemble, layer)
+
+			encrypted := readBlobFile(rootDir, "secret/app", layerDigest)
+			So(bytes.HasPrefix(encrypted, []byte("zotenc01\x03key")), ShouldBeTrue)
+			So(bytes.Contains(encrypted, layer[8:]), ShouldBeFalse)
+
+			for _, repo := range []string{"public/app", "secret/app"} {
+				content, err := imgStore.GetBlobContent(repo, layerDigest)
+				So(err, ShouldBeNil)
+				So(content, ShouldResemble, layer)
+			}
+		}
+
+		Convey("Pushed to the plaintext repository first", func() {
+			So(WriteImageToFileSystem(image, "public/app", "1.0", storeController), ShouldBeNil)
+			So(WriteImageToFileSystem(image, "secret/app", "1.0", storeController), ShouldBeNil)
+
+			checkBlobs()
+		})
+
+		Convey("Pushed to the encrypted repository first", func() {
+			So(WriteImageToFileSystem(image, "secret/app", "1.0", storeController), ShouldBeNil)
+			So(WriteImageToFileSystem(image, "public/app", "1.0", storeController), ShouldBeNil)
+
+			checkBlobs()
+		})
+	})
+
+	Convey("Keyrings", t, func() {
+		keyringPath := path.Join(t.TempDir(), "keyring.json")
+		key := newKey()
+
+		writeKeyring := func(content string) {
+			So(os.WriteFile(keyringPath, []byte(content), 0o600), ShouldBeNil)
+		}
+
+		writeKeyring(`{"currentKey":"k1","keys":{"k1":"` + base64.StdEncoding.EncodeToString(key) + `"}}`)
+
+		keyring, err := encryption.LoadKeyring(context.Background(), keyringPath, nil)
+		So(err, ShouldBeNil)
+		So(keyring.CurrentKey(), ShouldEqual, "k1")
+
+		Convey("Keys decrypted with a KMS", func() {
+			writeKeyring(`{"currentKey":"k1","keys":{"k1":"` + base64.StdEncoding.EncodeToString([]byte("wrapped")) + `"}}`)
+
+			keyring, err := encryption.LoadKeyring(context.Background(), keyringPath,
+				encryption.NewKMS(kmsMock{key: key}))
+			So(err, ShouldBeNil)
+			So(keyring.CurrentKey(), ShouldEqual, "k1")
+
+			writeKeyring(`{"currentKey":"k1","keys":{"k1":"` + base64.StdEncoding.EncodeToString(key) + `"}}`)
+
+			_, err = encryption.LoadKeyring(context.Background(), keyringPath, encryption.NewKMS(kmsMock{key: key}))
+			So(errors.Is(err, zerr.ErrBadBlob), ShouldBeTrue)
+		})
+
+		Convey("Invalid keyrings", func() {
+			_, err := encryption.LoadKeyring(context.Background(), path.Join(t.TempDir(), "missing.json"), nil)
+			So(err, ShouldNotBeNil)
+
+			for _, content := range []string{
+				`{"currentKey":`,
+				`{"currentKey":"k2","keys":{"k1":"` + base64.StdEncoding.EncodeToString(key) + `"}}`,
+				`{"currentKey":"k1","keys":{"k1":"not base64"}}`,
+				`{"currentKey":"k1","keys":{"k1":"` + base64.StdEncoding.EncodeToString(key[:16]) + `"}}`,
+			} {
+				writeKeyring(content)
+
+				_, err := encryption.LoadKeyring(context.Background(), keyringPath, nil)
+				So(errors.Is(err, zerr.ErrInvalidKeyring), ShouldBeTrue)
+			}
+		})
+	})
+}
@@ -0,0 +1,159 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+
+	zerr "zotregistry.dev/zot/errors"
+)
+
+const (
+	// AES-256
+	keySize      = 32
+	maxKeyIDSize = 255
+)
+
+// KMS decrypts the keys of a keyring, they're data keys encrypted with a master key which never leaves the KMS.
+type KMS interface {
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+type awsKMS struct {
+	client kmsiface.KMSAPI
+}
+
+// NewAWSKMS returns the KMS decrypting the data keys generated with `aws kms generate-data-key`, the credentials
+// are the ones of the environment, like for the s3 storage driver.
+func NewAWSKMS(region, endpoint string) (KMS, error) {
+	cfg := aws.NewConfig().WithRegion(region)
+	if endpoint != "" {
+		cfg = cfg.WithEndpoint(endpoint)
+	}
+
+	awsSession, err := session.NewSession(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKMS(kms.New(awsSession)), nil
+}
+
+// NewKMS returns the KMS using the client.
+func NewKMS(client kmsiface.KMSAPI) KMS {
+	return awsKMS{client: client}
+}
+
+func (awsKMS awsKMS) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	output, err := awsKMS.client.DecryptWithContext(ctx, &kms.DecryptInput{CiphertextBlob: ciphertext})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Plaintext, nil
+}
+
+// keyringFile is the JSON file of a keyring, the keys are base64 encoded.
+type keyringFile struct {
+	CurrentKey string            `json:"currentKey"`
+	Keys       map[string]string `json:"keys"`
+}
+
+// Keyring holds the keys the blobs are encrypted with, by their id. The new blobs are encrypted with the current
+// key, the other ones are kept to read the blobs encrypted before the keys were rotated.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// LoadKeyring reads the keyring file, its keys are decrypted with the KMS if there's one.
+func LoadKeyring(ctx context.Context, path string, kms KMS) (*Keyring, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyringFile
+
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", zerr.ErrInvalidKeyring, path, err)
+	}
+
+	keys := make(map[string][]byte, len(file.Keys))
+
+	for keyID, encodedKey := range file.Keys {
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %s: %w", zerr.ErrInvalidKeyring, keyID, err)
+		}
+
+		if kms != nil {
+			if key, err = kms.Decrypt(ctx, key); err != nil {
+				return nil, fmt.Errorf("failed to decrypt key %s with the KMS: %w", keyID, err)
+			}
+		}
+
+		keys[keyID] = key
+	}
+
+	return NewKeyring(file.CurrentKey, keys)
+}
+
+// NewKeyring returns the keyring of the 32 bytes keys, new blobs are encrypted with the current one.
+func NewKeyring(current string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("%w: current key %q not in the keys", zerr.ErrInvalidKeyring, current)
+	}
+
+	keyring := &Keyring{
+		current: current,
+		keys:    make(map[string]cipher.AEAD, len(keys)),
+	}
+
+	for keyID, key := range keys {
+		if keyID == "" || len(keyID) > maxKeyIDSize {
+			return nil, fmt.Errorf("%w: key ids must have 1 to %d bytes", zerr.ErrInvalidKeyring, maxKeyIDSize)
+		}
+
+		if len(key) != keySize {
+			return nil, fmt.Errorf("%w: key %s must have %d bytes", zerr.ErrInvalidKeyring, keyID, keySize)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		keyring.keys[keyID] = aead
+	}
+
+	return keyring, nil
+}
+
+// CurrentKey returns the id of the key the new blobs are encrypted with.
+func (keyring *Keyring) CurrentKey() string {
+	return keyring.current
+}
+
+func (keyring *Keyring) get(keyID string) (cipher.AEAD, error) {
+	aead, ok := keyring.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", zerr.ErrEncryptionKeyNotFound, keyID)
+	}
+
+	return aead, nil
+}
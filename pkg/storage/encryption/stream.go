@@ -0,0 +1,300 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+/*
+An encrypted blob starts with a header: the magic, the length of the key id, the key id and the base of the nonces.
+The content follows, split in segments of segmentSize bytes sealed with AES-GCM one by one, so a part of the blob
+can be read without decrypting it all. The nonce of a segment is the base with its index xored in the last bytes,
+and the last segment is marked in the additional data, so segments can't be reordered or truncated.
+*/
+
+const (
+	magic       = "zotenc01"
+	segmentSize = 64 * 1024
+	nonceSize   = 12
+	tagSize     = 16
+)
+
+type header struct {
+	keyID string
+	nonce []byte
+}
+
+func (header header) size() int64 {
+	return int64(len(magic) + 1 + len(header.keyID) + nonceSize)
+}
+
+// readHeader returns the header of the encrypted blob, or false if the blob is in plaintext.
+func readHeader(reader io.Reader) (header, bool, error) {
+	prefix := make([]byte, len(magic)+1)
+
+	if _, err := io.ReadFull(reader, prefix); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return header{}, false, nil
+		}
+
+		return header{}, false, err
+	}
+
+	if string(prefix[:len(magic)]) != magic {
+		return header{}, false, nil
+	}
+
+	rest := make([]byte, int(prefix[len(magic)])+nonceSize)
+
+	if _, err := io.ReadFull(reader, rest); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return header{}, false, nil
+		}
+
+		return header{}, false, err
+	}
+
+	keyIDSize := len(rest) - nonceSize
+
+	return header{keyID: string(rest[:keyIDSize]), nonce: rest[keyIDSize:]}, true, nil
+}
+
+// plaintextSize returns the size of the content of the encrypted blob of the size.
+func plaintextSize(header header, size int64) int64 {
+	segments := countSegments(header, size)
+
+	return size - header.size() - segments*tagSize
+}
+
+func countSegments(header header, size int64) int64 {
+	body := size - header.size()
+	if body <= 0 {
+		return 0
+	}
+
+	return (body + segmentSize + tagSize - 1) / (segmentSize + tagSize)
+}
+
+func segmentNonce(base []byte, index int64) []byte {
+	nonce := make([]byte, nonceSize)
+	copy(nonce, base)
+
+	counter := binary.BigEndian.Uint64(nonce[nonceSize-8:]) ^ uint64(index)
+	binary.BigEndian.PutUint64(nonce[nonceSize-8:], counter)
+
+	return nonce
+}
+
+func additionalData(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+
+	return []byte{0}
+}
+
+// encryptWriter seals the content written to it, Close seals the last segment.
+type encryptWriter struct {
+	writer io.Writer
+	aead   cipher.AEAD
+	nonce  []byte
+	index  int64
+	buf    []byte
+}
+
+func newEncryptWriter(writer io.Writer, keyring *Keyring) (*encryptWriter, error) {
+	aead, err := keyring.get(keyring.current)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	headerBytes := append([]byte(magic), byte(len(keyring.current)))
+	headerBytes = append(headerBytes, keyring.current...)
+	headerBytes = append(headerBytes, nonce...)
+
+	if _, err := writer.Write(headerBytes); err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{
+		writer: writer,
+		aead:   aead,
+		nonce:  nonce,
+		buf:    make([]byte, 0, segmentSize),
+	}, nil
+}
+
+func (writer *encryptWriter) Write(content []byte) (int, error) {
+	written := len(content)
+
+	for len(content) > 0 {
+		// a full segment is sealed once more content comes, Close seals the last one as final
+		if len(writer.buf) == segmentSize {
+			if err := writer.seal(false); err != nil {
+				return 0, err
+			}
+		}
+
+		size := min(segmentSize-len(writer.buf), len(content))
+		writer.buf = append(writer.buf, content[:size]...)
+		content = content[size:]
+	}
+
+	return written, nil
+}
+
+func (writer *encryptWriter) Close() error {
+	return writer.seal(true)
+}
+
+func (writer *encryptWriter) seal(final bool) error {
+	sealed := writer.aead.Seal(nil, segmentNonce(writer.nonce, writer.index), writer.buf, additionalData(final))
+
+	writer.index++
+	writer.buf = writer.buf[:0]
+
+	_, err := writer.writer.Write(sealed)
+
+	return err
+}
+
+// decryptReader opens the segments of an encrypted blob from the one its reader is positioned at.
+type decryptReader struct {
+	reader   io.ReadCloser
+	aead     cipher.AEAD
+	nonce    []byte
+	index    int64
+	segments int64
+	// size of the encrypted blob without its header
+	body int64
+	// bytes of the first segment before the offset which was read
+	skip int64
+	buf  []byte
+}
+
+func (reader *decryptReader) Read(content []byte) (int, error) {
+	for len(reader.buf) == 0 {
+		if reader.index >= reader.segments {
+			return 0, io.EOF
+		}
+
+		if err := reader.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	read := copy(content, reader.buf)
+	reader.buf = reader.buf[read:]
+
+	return read, nil
+}
+
+func (reader *decryptReader) open() error {
+	final := reader.index == reader.segments-1
+
+	size := int64(segmentSize + tagSize)
+	if final {
+		size = reader.body - reader.index*(segmentSize+tagSize)
+	}
+
+	sealed := make([]byte, size)
+
+	if _, err := io.ReadFull(reader.reader, sealed); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+
+		return err
+	}
+
+	segment, err := reader.aead.Open(sealed[:0], segmentNonce(reader.nonce, reader.index), sealed,
+		additionalData(final))
+	if err != nil {
+		return err
+	}
+
+	reader.index++
+
+	if reader.skip > 0 {
+		segment = segment[min(reader.skip, int64(len(segment))):]
+		reader.skip = 0
+	}
+
+	reader.buf = segment
+
+	return nil
+}
+
+func (reader *decryptReader) Close() error {
+	return reader.reader.Close()
+}
+
+// encrypt returns the content encrypted with the current key of the keyring.
+func encrypt(content []byte, keyring *Keyring) ([]byte, error) {
+	buf := bytes.Buffer{}
+
+	writer, err := newEncryptWriter(&buf, keyring)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(content); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decrypt returns the content of the encrypted blob, or the blob itself if it's in plaintext.
+func decrypt(content []byte, keyring *Keyring) ([]byte, error) {
+	header, ok, err := readHeader(bytes.NewReader(content))
+	if err != nil || !ok {
+		return content, err
+	}
+
+	reader, err := newDecryptReader(io.NopCloser(bytes.NewReader(content[header.size():])), header,
+		int64(len(content)), 0, keyring)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(reader)
+}
+
+// newDecryptReader returns the reader of the content from the offset, the reader of the encrypted blob must be
+// positioned at the segment of the offset.
+func newDecryptReader(reader io.ReadCloser, header header, size, offset int64, keyring *Keyring,
+) (*decryptReader, error) {
+	aead, err := keyring.get(header.keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		reader:   reader,
+		aead:     aead,
+		nonce:    header.nonce,
+		index:    offset / segmentSize,
+		segments: countSegments(header, size),
+		body:     size - header.size(),
+		skip:     offset % segmentSize,
+	}, nil
+}
+
+// segmentOffset returns the offset in the encrypted blob of the segment with the offset of the content.
+func segmentOffset(header header, offset int64) int64 {
+	return header.size() + (offset/segmentSize)*(segmentSize+tagSize)
+}
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/registry/storage/driver"
 	godigest "github.com/opencontainers/go-digest"
 	ispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -17,6 +19,7 @@ import (
 	zerr "zotregistry.dev/zot/errors"
 	"zotregistry.dev/zot/pkg/api/config"
 	zcommon "zotregistry.dev/zot/pkg/common"
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
 	zlog "zotregistry.dev/zot/pkg/log"
 	mTypes "zotregistry.dev/zot/pkg/meta/types"
 	"zotregistry.dev/zot/pkg/retention"
@@ -37,6 +40,9 @@ type Options struct {
 	Delay time.Duration
 
 	ImageRetention config.ImageRetention
+
+	// if set, the removed manifests and blobs are counted in metrics
+	Metrics monitoring.MetricServer
 }
 
 type GarbageCollect struct {
@@ -46,6 +52,8 @@ type GarbageCollect struct {
 	policyMgr rTypes.PolicyManager
 	auditLog  *zlog.Logger
 	log       zlog.Logger
+	// set by the dry-run reports, collects the manifests removed from the index
+	report *Report
 }
 
 func NewGarbageCollect(imgStore types.ImageStore, metaDB mTypes.MetaDB, opts Options,
@@ -57,7 +65,7 @@ func NewGarbageCollect(imgStore types.ImageStore, metaDB mTypes.MetaDB, opts Opt
 		opts:      opts,
 		policyMgr: retention.NewPolicyManager(opts.ImageRetention, log, auditLog),
 		auditLog:  auditLog,
-		log:       log,
+		log:       log.Module("gc"),
 	}
 }
 
@@ -74,6 +82,19 @@ func (gc GarbageCollect) CleanImageStorePeriodically(interval time.Duration, sch
 	sch.SubmitGenerator(generator, interval, scheduler.MediumPriority)
 }
 
+/*
+CleanImageStore runs a garbage collect on the ImageStore provided in constructor once, as soon as the
+Scheduler can, regardless of the periodic ones.
+*/
+func (gc GarbageCollect) CleanImageStore(sch *scheduler.Scheduler) {
+	generator := &GCTaskGenerator{
+		imgStore: gc.imgStore,
+		gc:       gc,
+	}
+
+	sch.SubmitGenerator(generator, time.Duration(0), scheduler.HighPriority)
+}
+
 /*
 CleanRepo executes a garbage collection of any blob found in storage which is not referenced
 in any manifests referenced in repo's index.json
@@ -215,7 +236,7 @@ func (gc GarbageCollect) removeIndexReferrers(repo string, rootIndex *ispec.Inde
 
 	for _, desc := range index.Manifests {
 		switch desc.MediaType {
-		case ispec.MediaTypeImageIndex:
+		case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
 			indexImage, err := common.GetImageIndex(gc.imgStore, repo, desc.Digest, gc.log)
 			if err != nil {
 				gc.log.Error().Err(err).Str("module", "gc").Str("repository", repo).Str("digest", desc.Digest.String()).
@@ -244,7 +265,7 @@ func (gc GarbageCollect) removeIndexReferrers(repo string, rootIndex *ispec.Inde
 			if gced {
 				count++
 			}
-		case ispec.MediaTypeImageManifest, oras.MediaTypeArtifactManifest:
+		case ispec.MediaTypeImageManifest, schema2.MediaTypeManifest, oras.MediaTypeArtifactManifest:
 			image, err := common.GetImageManifest(gc.imgStore, repo, desc.Digest, gc.log)
 			if err != nil {
 				gc.log.Error().Err(err).Str("module", "gc").Str("repo", repo).Str("digest", desc.Digest.String()).
@@ -289,7 +310,8 @@ func (gc GarbageCollect) removeReferrer(repo string, index *ispec.Index, manifes
 		}
 
 		if !referenced {
-			gced, err = gc.gcManifest(repo, index, manifestDesc, signatureType, subject.Digest, gc.opts.ImageRetention.Delay)
+			gced, err = gc.gcManifest(repo, index, manifestDesc, ReasonDeleteReferrers, signatureType, subject.Digest,
+				gc.opts.ImageRetention.Delay)
 			if err != nil {
 				return false, err
 			}
@@ -322,7 +344,8 @@ func (gc GarbageCollect) removeReferrer(repo string, index *ispec.Index, manifes
 			referenced := isManifestReferencedInIndex(index, subjectDigest)
 
 			if !referenced {
-				gced, err = gc.gcManifest(repo, index, manifestDesc, storage.CosignType, subjectDigest, gc.opts.Delay)
+				gced, err = gc.gcManifest(repo, index, manifestDesc, ReasonDeleteReferrers, storage.CosignType,
+					subjectDigest, gc.opts.Delay)
 				if err != nil {
 					return false, err
 				}
@@ -378,7 +401,7 @@ func (gc GarbageCollect) removeTagsPerRetentionPolicy(ctx context.Context, repo
 		tag, ok := getDescriptorTag(desc)
 		if ok && !zcommon.Contains(retainTags, tag) {
 			// remove tags which should not be retained
-			_, err := gc.removeManifest(repo, index, desc, tag, "", "")
+			_, err := gc.removeManifest(repo, index, desc, tag, ReasonKeepTags, "", "")
 			if err != nil && !errors.Is(err, zerr.ErrManifestNotFound) {
 				return err
 			}
@@ -389,7 +412,7 @@ func (gc GarbageCollect) removeTagsPerRetentionPolicy(ctx context.Context, repo
 }
 
 // gcManifest removes a manifest entry from an index and syncs metaDB accordingly if the blob is older than gc.Delay.
-func (gc GarbageCollect) gcManifest(repo string, index *ispec.Index, desc ispec.Descriptor,
+func (gc GarbageCollect) gcManifest(repo string, index *ispec.Index, desc ispec.Descriptor, reason string,
 	signatureType string, subjectDigest godigest.Digest, delay time.Duration,
 ) (bool, error) {
 	var gced bool
@@ -403,7 +426,8 @@ func (gc GarbageCollect) gcManifest(repo string, index *ispec.Index, desc ispec.
 	}
 
 	if canGC {
-		if gced, err = gc.removeManifest(repo, index, desc, desc.Digest.String(), signatureType, subjectDigest); err != nil {
+		if gced, err = gc.removeManifest(repo, index, desc, desc.Digest.String(), reason, signatureType,
+			subjectDigest); err != nil {
 			return false, err
 		}
 	}
@@ -412,8 +436,8 @@ func (gc GarbageCollect) gcManifest(repo string, index *ispec.Index, desc ispec.
 }
 
 // removeManifest removes a manifest entry from an index and syncs metaDB accordingly.
-func (gc GarbageCollect) removeManifest(repo string, index *ispec.Index,
-	desc ispec.Descriptor, reference string, signatureType string, subjectDigest godigest.Digest,
+func (gc GarbageCollect) removeManifest(repo string, index *ispec.Index, desc ispec.Descriptor,
+	reference, reason string, signatureType string, subjectDigest godigest.Digest,
 ) (bool, error) {
 	_, err := common.RemoveManifestDescByReference(index, reference, true)
 	if err != nil {
@@ -424,10 +448,25 @@ func (gc GarbageCollect) removeManifest(repo string, index *ispec.Index,
 		return false, err
 	}
 
+	if gc.report != nil {
+		gc.report.Manifests = append(gc.report.Manifests, RemovedManifest{
+			Repo:      repo,
+			Reference: reference,
+			Digest:    desc.Digest.String(),
+			MediaType: desc.MediaType,
+			Size:      desc.Size,
+			Reason:    reason,
+		})
+	}
+
 	if gc.opts.ImageRetention.DryRun {
 		return true, nil
 	}
 
+	if gc.opts.Metrics != nil {
+		monitoring.IncGCRemovedManifests(gc.opts.Metrics, repo)
+	}
+
 	// sync metaDB
 	if gc.metaDB != nil {
 		if signatureType != "" {
@@ -471,10 +510,11 @@ func (gc GarbageCollect) removeUntaggedManifests(repo string, index *ispec.Index
 		}
 
 		// remove untagged images
-		if desc.MediaType == ispec.MediaTypeImageManifest || desc.MediaType == ispec.MediaTypeImageIndex {
+		if desc.MediaType == ispec.MediaTypeImageManifest || desc.MediaType == ispec.MediaTypeImageIndex ||
+			common.IsDockerMediaType(desc.MediaType) {
 			_, ok := getDescriptorTag(desc)
 			if !ok {
-				gced, err = gc.gcManifest(repo, index, desc, "", "", gc.opts.ImageRetention.Delay)
+				gced, err = gc.gcManifest(repo, index, desc, ReasonDeleteUntagged, "", "", gc.opts.ImageRetention.Delay)
 				if err != nil {
 					return false, err
 				}
@@ -509,7 +549,7 @@ func (gc GarbageCollect) identifyManifestsReferencedInIndex(index ispec.Index, r
 ) error {
 	for _, desc := range index.Manifests {
 		switch desc.MediaType {
-		case ispec.MediaTypeImageIndex:
+		case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
 			indexImage, err := common.GetImageIndex(gc.imgStore, repo, desc.Digest, gc.log)
 			if err != nil {
 				gc.log.Error().Err(err).Str("module", "gc").Str("repository", repo).
@@ -529,7 +569,7 @@ func (gc GarbageCollect) identifyManifestsReferencedInIndex(index ispec.Index, r
 			if err := gc.identifyManifestsReferencedInIndex(indexImage, repo, referenced); err != nil {
 				return err
 			}
-		case ispec.MediaTypeImageManifest, oras.MediaTypeArtifactManifest:
+		case ispec.MediaTypeImageManifest, schema2.MediaTypeManifest, oras.MediaTypeArtifactManifest:
 			image, err := common.GetImageManifest(gc.imgStore, repo, desc.Digest, gc.log)
 			if err != nil {
 				gc.log.Error().Err(err).Str("module", "gc").Str("repo", repo).
@@ -552,8 +592,6 @@ func (gc GarbageCollect) removeUnreferencedBlobs(repo string, delay time.Duratio
 ) error {
 	gc.log.Debug().Str("module", "gc").Str("repository", repo).Msg("cleaning orphan blobs")
 
-	refBlobs := map[string]bool{}
-
 	index, err := common.GetIndex(gc.imgStore, repo, gc.log)
 	if err != nil {
 		log.Error().Err(err).Str("module", "gc").Str("repository", repo).Msg("failed to read index.json in repo")
@@ -561,23 +599,64 @@ func (gc GarbageCollect) removeUnreferencedBlobs(repo string, delay time.Duratio
 		return err
 	}
 
-	err = gc.addIndexBlobsToReferences(repo, index, refBlobs)
+	gcBlobs, blobCount, err := gc.getUnreferencedBlobs(repo, index, delay, log)
 	if err != nil {
-		log.Error().Err(err).Str("module", "gc").Str("repository", repo).Msg("failed to get referenced blobs in repo")
+		// /blobs/sha256/ may be empty in the case of s3, no need to return err, we want to skip
+		if errors.As(err, &driver.PathNotFoundError{}) {
+			return nil
+		}
 
 		return err
 	}
 
-	allBlobs, err := gc.imgStore.GetAllBlobs(repo)
+	// if we removed all blobs from repo
+	removeRepo := len(gcBlobs) > 0 && len(gcBlobs) == blobCount
+
+	blobSizes := gc.getBlobSizes(repo, gcBlobs)
+
+	reaped, err := gc.imgStore.CleanupRepo(repo, gcBlobs, removeRepo)
+
+	gc.observeRemovedBlobs(repo, blobSizes)
+
 	if err != nil {
-		// /blobs/sha256/ may be empty in the case of s3, no need to return err, we want to skip
-		if errors.As(err, &driver.PathNotFoundError{}) {
-			return nil
+		return err
+	}
+
+	// the repository itself was removed, along with its metadata
+	if removeRepo && gc.metaDB != nil && !gc.imgStore.DirExists(path.Join(gc.imgStore.RootDir(), repo)) {
+		if err := gc.metaDB.DeleteRepoMeta(repo); err != nil {
+			log.Error().Err(err).Str("module", "gc").Str("repository", repo).Msg("failed to delete repo meta")
+
+			return err
 		}
+	}
 
-		log.Error().Err(err).Str("module", "gc").Str("repository", repo).Msg("failed to get all blobs")
+	log.Info().Str("module", "gc").Str("repository", repo).Int("count", reaped).
+		Msg("garbage collected blobs")
 
-		return err
+	return nil
+}
+
+// getUnreferencedBlobs returns the blobs older than delay which aren't referenced by the manifests of the index,
+// with the count of all the blobs of the repo.
+func (gc GarbageCollect) getUnreferencedBlobs(repo string, index ispec.Index, delay time.Duration, log zlog.Logger,
+) ([]godigest.Digest, int, error) {
+	refBlobs := map[string]bool{}
+
+	err := gc.addIndexBlobsToReferences(repo, index, refBlobs)
+	if err != nil {
+		log.Error().Err(err).Str("module", "gc").Str("repository", repo).Msg("failed to get referenced blobs in repo")
+
+		return nil, 0, err
+	}
+
+	allBlobs, err := gc.imgStore.GetAllBlobs(repo)
+	if err != nil {
+		if !errors.As(err, &driver.PathNotFoundError{}) {
+			log.Error().Err(err).Str("module", "gc").Str("repository", repo).Msg("failed to get all blobs")
+		}
+
+		return nil, 0, err
 	}
 
 	gcBlobs := make([]godigest.Digest, 0)
@@ -588,7 +667,7 @@ func (gc GarbageCollect) removeUnreferencedBlobs(repo string, delay time.Duratio
 			log.Error().Err(err).Str("module", "gc").Str("repository", repo).Str("digest", blob).
 				Msg("failed to parse digest")
 
-			return err
+			return nil, 0, err
 		}
 
 		if _, ok := refBlobs[digest.String()]; !ok {
@@ -597,7 +676,7 @@ func (gc GarbageCollect) removeUnreferencedBlobs(repo string, delay time.Duratio
 				log.Error().Err(err).Str("module", "gc").Str("repository", repo).Str("digest", blob).
 					Msg("failed to determine GC delay")
 
-				return err
+				return nil, 0, err
 			}
 
 			if canGC {
@@ -606,18 +685,33 @@ func (gc GarbageCollect) removeUnreferencedBlobs(repo string, delay time.Duratio
 		}
 	}
 
-	// if we removed all blobs from repo
-	removeRepo := len(gcBlobs) > 0 && len(gcBlobs) == len(allBlobs)
+	return gcBlobs, len(allBlobs), nil
+}
 
-	reaped, err := gc.imgStore.CleanupRepo(repo, gcBlobs, removeRepo)
-	if err != nil {
-		return err
+// getBlobSizes returns the size of the blobs about to be removed, to be counted in metrics once they are.
+func (gc GarbageCollect) getBlobSizes(repo string, blobs []godigest.Digest) map[godigest.Digest]int64 {
+	blobSizes := map[godigest.Digest]int64{}
+
+	if gc.opts.Metrics == nil {
+		return blobSizes
 	}
 
-	log.Info().Str("module", "gc").Str("repository", repo).Int("count", reaped).
-		Msg("garbage collected blobs")
+	for _, digest := range blobs {
+		if _, size, _, err := gc.imgStore.StatBlob(repo, digest); err == nil {
+			blobSizes[digest] = size
+		}
+	}
 
-	return nil
+	return blobSizes
+}
+
+// observeRemovedBlobs counts in metrics the blobs which are gone, some may have been kept because they are in use.
+func (gc GarbageCollect) observeRemovedBlobs(repo string, blobSizes map[godigest.Digest]int64) {
+	for digest, size := range blobSizes {
+		if found, _, _, err := gc.imgStore.StatBlob(repo, digest); err != nil || !found {
+			monitoring.ObserveGCRemovedBlob(gc.opts.Metrics, repo, size)
+		}
+	}
 }
 
 // used by removeUnreferencedBlobs()
@@ -626,14 +720,14 @@ func (gc GarbageCollect) addIndexBlobsToReferences(repo string, index ispec.Inde
 ) error {
 	for _, desc := range index.Manifests {
 		switch desc.MediaType {
-		case ispec.MediaTypeImageIndex:
+		case ispec.MediaTypeImageIndex, manifestlist.MediaTypeManifestList:
 			if err := gc.addImageIndexBlobsToReferences(repo, desc.Digest, refBlobs); err != nil {
 				gc.log.Error().Err(err).Str("module", "gc").Str("repository", repo).
 					Str("digest", desc.Digest.String()).Msg("failed to read blobs in multiarch(index) image")
 
 				return err
 			}
-		case ispec.MediaTypeImageManifest:
+		case ispec.MediaTypeImageManifest, schema2.MediaTypeManifest:
 			if err := gc.addImageManifestBlobsToReferences(repo, desc.Digest, refBlobs); err != nil {
 				gc.log.Error().Err(err).Str("module", "gc").Str("repository", repo).
 					Str("digest", desc.Digest.String()).Msg("failed to read blobs in image manifest")
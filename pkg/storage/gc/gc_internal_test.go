@@ -340,7 +340,7 @@ func TestGarbageCollectWithMockedImageStore(t *testing.T) {
 				},
 			}, gcOptions, audit, log)
 
-			_, err := gc.removeManifest("", &ispec.Index{}, ispec.DescriptorEmptyJSON, "tag", "", "")
+			_, err := gc.removeManifest("", &ispec.Index{}, ispec.DescriptorEmptyJSON, "tag", ReasonKeepTags, "", "")
 			So(err, ShouldNotBeNil)
 		})
 
@@ -640,7 +640,7 @@ func TestGarbageCollectWithMockedImageStore(t *testing.T) {
 			index := &ispec.Index{
 				Manifests: []ispec.Descriptor{desc},
 			}
-			_, err = gc.removeManifest(repoName, index, desc, desc.Digest.String(), storage.NotationType,
+			_, err = gc.removeManifest(repoName, index, desc, desc.Digest.String(), ReasonDeleteReferrers, storage.NotationType,
 				godigest.FromBytes([]byte("digest2")))
 
 			So(err, ShouldNotBeNil)
@@ -0,0 +1,80 @@
+//go:build !metrics
+// +build !metrics
+
+package gc_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	zlog "zotregistry.dev/zot/pkg/log"
+	"zotregistry.dev/zot/pkg/storage"
+	"zotregistry.dev/zot/pkg/storage/gc"
+	"zotregistry.dev/zot/pkg/storage/local"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+	"zotregistry.dev/zot/pkg/test/mocks"
+)
+
+// the metrics of the minimal server can be read back, the prometheus ones are only scraped.
+func TestGarbageCollectMetrics(t *testing.T) {
+	Convey("Removed manifests and blobs are counted in metrics", t, func() {
+		log := zlog.NewLogger("debug", "/dev/null")
+		metrics := monitoring.NewMetricsServer(true, log)
+
+		imgStore := local.NewImageStore(t.TempDir(), false, false, log, metrics, nil, nil)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		err := WriteImageToFileSystem(CreateRandomImage(), "gc-metrics", "0.0.1", storeController)
+		So(err, ShouldBeNil)
+
+		untagged := CreateRandomImage()
+		err = WriteImageToFileSystem(untagged, "gc-metrics", untagged.DigestStr(), storeController)
+		So(err, ShouldBeNil)
+
+		trueVal := true
+
+		garbageCollect := gc.NewGarbageCollect(imgStore, mocks.MetaDBMock{}, gc.Options{
+			ImageRetention: config.ImageRetention{
+				Policies: []config.RetentionPolicy{
+					{
+						Repositories:   []string{"**"},
+						DeleteUntagged: &trueVal,
+					},
+				},
+			},
+			Metrics: metrics,
+		}, nil, log)
+
+		err = garbageCollect.CleanRepo(context.Background(), "gc-metrics")
+		So(err, ShouldBeNil)
+
+		metricsCopy, ok := metrics.ReceiveMetrics().(monitoring.MetricsCopy)
+		So(ok, ShouldBeTrue)
+
+		removedManifests := 0
+
+		for _, counter := range metricsCopy.Counters {
+			if counter.Name == "zot.gc.removed.manifests" {
+				removedManifests = counter.Count
+			}
+		}
+
+		So(removedManifests, ShouldEqual, 1)
+
+		var removedBlobs monitoring.SummaryValue
+
+		for _, summary := range metricsCopy.Summaries {
+			if summary.Name == "zot.gc.removed.blobs.bytes" {
+				removedBlobs = summary
+			}
+		}
+
+		// the manifest, its config and its layers
+		So(removedBlobs.Count, ShouldEqual, 2+len(untagged.Layers))
+		So(removedBlobs.Sum, ShouldEqual, float64(untagged.Size()))
+	})
+}
@@ -1,16 +1,22 @@
 package gc_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
 	"testing"
 	"time"
 
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/registry/storage/driver/factory"
 	_ "github.com/docker/distribution/registry/storage/driver/s3-aws"
 	guuid "github.com/gofrs/uuid"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
 	. "github.com/smartystreets/goconvey/convey"
 	"gopkg.in/resty.v1"
 
@@ -28,6 +34,7 @@ import (
 	"zotregistry.dev/zot/pkg/storage/s3"
 	storageTypes "zotregistry.dev/zot/pkg/storage/types"
 	. "zotregistry.dev/zot/pkg/test/image-utils"
+	"zotregistry.dev/zot/pkg/test/mocks"
 	tskip "zotregistry.dev/zot/pkg/test/skip"
 )
 
@@ -660,6 +667,49 @@ func TestGarbageCollectAndRetention(t *testing.T) {
 					So(tags, ShouldNotContain, "0.0.3")
 				})
 
+				Convey("retain 3 most recently pushed images and the signed ones", func() {
+					err = metaDB.AddManifestSignature("retention", gcOld1.Digest(), mTypes.SignatureMetadata{
+						SignatureType:   "cosign",
+						SignatureDigest: godigest.FromString("signature").String(),
+					})
+					So(err, ShouldBeNil)
+
+					gc := gc.NewGarbageCollect(imgStore, metaDB, gc.Options{
+						Delay: storageConstants.DefaultGCDelay,
+						ImageRetention: config.ImageRetention{
+							Delay: storageConstants.DefaultRetentionDelay,
+							Policies: []config.RetentionPolicy{
+								{
+									Repositories:    []string{"**"},
+									DeleteReferrers: true,
+									DeleteUntagged:  &trueVal,
+									KeepSigned:      true,
+									KeepTags: []config.KeepTagsPolicy{
+										{
+											Patterns:                []string{".*"},
+											MostRecentlyPushedCount: 3,
+										},
+									},
+								},
+							},
+						},
+					}, audit, log)
+
+					err = gc.CleanRepo(ctx, "retention")
+					So(err, ShouldBeNil)
+
+					tags, err := imgStore.GetImageTags("retention")
+					So(err, ShouldBeNil)
+
+					So(tags, ShouldContain, "0.0.1")
+					So(tags, ShouldContain, "0.0.4")
+					So(tags, ShouldContain, "0.0.5")
+					So(tags, ShouldContain, "0.0.6")
+
+					So(tags, ShouldNotContain, "0.0.2")
+					So(tags, ShouldNotContain, "0.0.3")
+				})
+
 				Convey("retain 3 most recently pulled images", func() {
 					gc := gc.NewGarbageCollect(imgStore, metaDB, gc.Options{
 						Delay: storageConstants.DefaultGCDelay,
@@ -890,3 +940,217 @@ func TestGarbageCollectAndRetention(t *testing.T) {
 		})
 	}
 }
+
+func TestGarbageCollectRemovedRepos(t *testing.T) {
+	Convey("The metadata of the repositories removed by GC is deleted", t, func() {
+		log := zlog.NewLogger("debug", "/dev/null")
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		rootDir := t.TempDir()
+		imgStore := local.NewImageStore(rootDir, false, false, log, metrics, nil, nil)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		err := WriteImageToFileSystem(CreateRandomImage(), "tagged", "0.0.1", storeController)
+		So(err, ShouldBeNil)
+
+		untagged := CreateRandomImage()
+		err = WriteImageToFileSystem(untagged, "untagged", untagged.DigestStr(), storeController)
+		So(err, ShouldBeNil)
+
+		deletedRepos := []string{}
+		trueVal := true
+
+		garbageCollect := gc.NewGarbageCollect(imgStore, mocks.MetaDBMock{
+			DeleteRepoMetaFn: func(repo string) error {
+				deletedRepos = append(deletedRepos, repo)
+
+				return nil
+			},
+		}, gc.Options{
+			ImageRetention: config.ImageRetention{
+				Policies: []config.RetentionPolicy{
+					{
+						Repositories:   []string{"**"},
+						DeleteUntagged: &trueVal,
+					},
+				},
+			},
+		}, nil, log)
+
+		err = garbageCollect.CleanRepo(context.Background(), "tagged")
+		So(err, ShouldBeNil)
+
+		err = garbageCollect.CleanRepo(context.Background(), "untagged")
+		So(err, ShouldBeNil)
+
+		_, err = os.Stat(path.Join(rootDir, "untagged"))
+		So(os.IsNotExist(err), ShouldBeTrue)
+
+		So(deletedRepos, ShouldResemble, []string{"untagged"})
+	})
+}
+
+func TestGarbageCollectDryRun(t *testing.T) {
+	Convey("The dry-run reports what GC would remove without removing it", t, func() {
+		log := zlog.NewLogger("debug", "/dev/null")
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		rootDir := t.TempDir()
+		imgStore := local.NewImageStore(rootDir, false, false, log, metrics, nil, nil)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		tagged := CreateRandomImage()
+		err := WriteImageToFileSystem(tagged, "tagged", "0.0.1", storeController)
+		So(err, ShouldBeNil)
+
+		orphan := []byte("orphan blob")
+		_, _, err = imgStore.FullBlobUpload("tagged", bytes.NewReader(orphan), godigest.FromBytes(orphan))
+		So(err, ShouldBeNil)
+
+		untagged := CreateRandomImage()
+		err = WriteImageToFileSystem(untagged, "untagged", untagged.DigestStr(), storeController)
+		So(err, ShouldBeNil)
+
+		trueVal := true
+
+		garbageCollect := gc.NewGarbageCollect(imgStore, mocks.MetaDBMock{}, gc.Options{
+			ImageRetention: config.ImageRetention{
+				Policies: []config.RetentionPolicy{
+					{
+						Repositories:   []string{"**"},
+						DeleteUntagged: &trueVal,
+					},
+				},
+			},
+		}, nil, log)
+
+		untaggedBlobs := map[string]int64{
+			untagged.ManifestDescriptor.Digest.String(): untagged.ManifestDescriptor.Size,
+			untagged.ConfigDescriptor.Digest.String():   untagged.ConfigDescriptor.Size,
+		}
+
+		for _, layer := range untagged.Manifest.Layers {
+			untaggedBlobs[layer.Digest.String()] = layer.Size
+		}
+
+		Convey("GC", func() {
+			report, err := garbageCollect.DryRun(context.Background(), false)
+			So(err, ShouldBeNil)
+
+			So(report.Manifests, ShouldResemble, []gc.RemovedManifest{
+				{
+					Repo:      "untagged",
+					Reference: untagged.DigestStr(),
+					Digest:    untagged.DigestStr(),
+					MediaType: ispec.MediaTypeImageManifest,
+					Size:      untagged.ManifestDescriptor.Size,
+					Reason:    gc.ReasonDeleteUntagged,
+				},
+			})
+
+			blobs := map[string]int64{}
+			size := int64(0)
+
+			for _, blob := range report.Blobs {
+				blobs[blob.Repo+"@"+blob.Digest] = blob.Size
+				size += blob.Size
+			}
+
+			expectedBlobs := map[string]int64{"tagged@" + godigest.FromBytes(orphan).String(): int64(len(orphan))}
+			for digest, blobSize := range untaggedBlobs {
+				expectedBlobs["untagged@"+digest] = blobSize
+			}
+
+			So(blobs, ShouldResemble, expectedBlobs)
+			So(report.Size, ShouldEqual, size)
+		})
+
+		Convey("Retention", func() {
+			report, err := garbageCollect.DryRun(context.Background(), true)
+			So(err, ShouldBeNil)
+			So(report.Manifests, ShouldHaveLength, 1)
+
+			// the orphan blob isn't removed by the retention policies
+			blobs := map[string]int64{}
+
+			for _, blob := range report.Blobs {
+				So(blob.Repo, ShouldEqual, "untagged")
+				blobs[blob.Digest] = blob.Size
+			}
+
+			So(blobs, ShouldResemble, untaggedBlobs)
+		})
+
+		// nothing was removed
+		found, _, _, err := imgStore.StatBlob("tagged", godigest.FromBytes(orphan))
+		So(err, ShouldBeNil)
+		So(found, ShouldBeTrue)
+
+		_, _, _, err = imgStore.GetImageManifest("untagged", untagged.DigestStr())
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestGarbageCollectDockerManifests(t *testing.T) {
+	Convey("The blobs of the Docker manifests are kept", t, func() {
+		log := zlog.NewLogger("debug", "/dev/null")
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		imgStore := local.NewImageStore(t.TempDir(), false, false, log, metrics, nil, nil)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		// the blobs are written along with an untagged OCI manifest
+		image := CreateRandomImage()
+		err := WriteImageToFileSystem(image, "docker", image.DigestStr(), storeController)
+		So(err, ShouldBeNil)
+
+		dockerManifest := image.Manifest
+		dockerManifest.MediaType = schema2.MediaTypeManifest
+		dockerManifest.Config.MediaType = schema2.MediaTypeImageConfig
+
+		manifestBlob, err := json.Marshal(dockerManifest)
+		So(err, ShouldBeNil)
+
+		manifestDigest, _, err := imgStore.PutImageManifest("docker", godigest.FromBytes(manifestBlob).String(),
+			schema2.MediaTypeManifest, manifestBlob)
+		So(err, ShouldBeNil)
+
+		listBlob, err := json.Marshal(ispec.Index{
+			Versioned: image.Manifest.Versioned,
+			MediaType: manifestlist.MediaTypeManifestList,
+			Manifests: []ispec.Descriptor{
+				{MediaType: schema2.MediaTypeManifest, Digest: manifestDigest, Size: int64(len(manifestBlob))},
+			},
+		})
+		So(err, ShouldBeNil)
+
+		_, _, err = imgStore.PutImageManifest("docker", "1.0", manifestlist.MediaTypeManifestList, listBlob)
+		So(err, ShouldBeNil)
+
+		trueVal := true
+
+		garbageCollect := gc.NewGarbageCollect(imgStore, mocks.MetaDBMock{}, gc.Options{
+			ImageRetention: config.ImageRetention{
+				Policies: []config.RetentionPolicy{
+					{
+						Repositories:   []string{"**"},
+						DeleteUntagged: &trueVal,
+					},
+				},
+			},
+		}, nil, log)
+
+		err = garbageCollect.CleanRepo(context.Background(), "docker")
+		So(err, ShouldBeNil)
+
+		_, _, _, err = imgStore.GetImageManifest("docker", image.DigestStr())
+		So(err, ShouldNotBeNil)
+
+		for _, digest := range []godigest.Digest{manifestDigest, image.Manifest.Config.Digest,
+			image.Manifest.Layers[0].Digest} {
+			ok, _, err := imgStore.CheckBlob("docker", digest)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package gc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/docker/distribution/registry/storage/driver"
+	godigest "github.com/opencontainers/go-digest"
+
+	zerr "zotregistry.dev/zot/errors"
+	zcommon "zotregistry.dev/zot/pkg/common"
+	"zotregistry.dev/zot/pkg/retention"
+	common "zotregistry.dev/zot/pkg/storage/common"
+)
+
+// reasons for removing a manifest, named after the retention settings.
+const (
+	ReasonKeepTags        = "keepTags"
+	ReasonDeleteReferrers = "deleteReferrers"
+	ReasonDeleteUntagged  = "deleteUntagged"
+)
+
+// Report lists what a garbage collection would remove, without removing anything.
+type Report struct {
+	Manifests []RemovedManifest `json:"manifests"`
+	Blobs     []RemovedBlob     `json:"blobs"`
+	// bytes freed by removing the blobs, the ones deduped with other repositories included
+	Size int64 `json:"size"`
+}
+
+// RemovedManifest is a manifest entry removed from the index of a repository, by tag or by digest.
+type RemovedManifest struct {
+	Repo      string `json:"repo"`
+	Reference string `json:"reference"`
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType"`
+	Size      int64  `json:"size"`
+	Reason    string `json:"reason"`
+}
+
+type RemovedBlob struct {
+	Repo   string `json:"repo"`
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+}
+
+/*
+DryRun reports what a garbage collection of every repository of the ImageStore would remove now, applying the
+retention policies as if dryRun was set. With retentionOnly, only the manifests removed by the retention policies
+are reported, with the blobs they leave unreferenced, not the blobs which already are.
+*/
+func (gc GarbageCollect) DryRun(ctx context.Context, retentionOnly bool) (Report, error) {
+	report := Report{Manifests: []RemovedManifest{}, Blobs: []RemovedBlob{}}
+
+	repos, err := gc.imgStore.GetRepositories()
+	if err != nil {
+		return report, err
+	}
+
+	opts := gc.opts
+	opts.ImageRetention.DryRun = true
+	opts.Metrics = nil
+
+	dryRunGC := GarbageCollect{
+		imgStore:  gc.imgStore,
+		metaDB:    gc.metaDB,
+		opts:      opts,
+		policyMgr: retention.NewPolicyManager(opts.ImageRetention, gc.log, nil),
+		log:       gc.log,
+		report:    &report,
+	}
+
+	for _, repo := range repos {
+		if zcommon.IsContextDone(ctx) {
+			return report, ctx.Err()
+		}
+
+		if err := dryRunGC.dryRunRepo(ctx, repo, retentionOnly); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func (gc GarbageCollect) dryRunRepo(ctx context.Context, repo string, retentionOnly bool) error {
+	var lockLatency time.Time
+
+	gc.imgStore.RLock(&lockLatency)
+	defer gc.imgStore.RUnlock(&lockLatency)
+
+	index, err := common.GetIndex(gc.imgStore, repo, gc.log)
+	if err != nil {
+		// the repository was removed in the meantime
+		if errors.Is(err, zerr.ErrRepoNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	// the manifests are only removed from this copy of the index
+	prunedIndex := index
+	prunedIndex.Manifests = append(prunedIndex.Manifests[:0:0], index.Manifests...)
+
+	if err := gc.removeTagsPerRetentionPolicy(ctx, repo, &prunedIndex); err != nil {
+		return err
+	}
+
+	if err := gc.removeManifestsPerRepoPolicy(ctx, repo, &prunedIndex); err != nil {
+		return err
+	}
+
+	gcBlobs, _, err := gc.getUnreferencedBlobs(repo, prunedIndex, gc.opts.Delay, gc.log)
+	if err != nil {
+		if errors.As(err, &driver.PathNotFoundError{}) {
+			return nil
+		}
+
+		return err
+	}
+
+	// the blobs which are unreferenced already are left to the garbage collection
+	orphanBlobs := map[godigest.Digest]bool{}
+
+	if retentionOnly {
+		blobs, _, err := gc.getUnreferencedBlobs(repo, index, gc.opts.Delay, gc.log)
+		if err != nil {
+			return err
+		}
+
+		for _, digest := range blobs {
+			orphanBlobs[digest] = true
+		}
+	}
+
+	for _, digest := range gcBlobs {
+		if orphanBlobs[digest] {
+			continue
+		}
+
+		_, size, _, err := gc.imgStore.StatBlob(repo, digest)
+		if err != nil {
+			return err
+		}
+
+		gc.report.Blobs = append(gc.report.Blobs, RemovedBlob{Repo: repo, Digest: digest.String(), Size: size})
+		gc.report.Size += size
+	}
+
+	return nil
+}
@@ -14,6 +14,8 @@ import (
 	"time"
 	"unicode/utf8"
 
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/registry/storage/driver"
 	guuid "github.com/gofrs/uuid"
 	godigest "github.com/opencontainers/go-digest"
@@ -564,6 +566,19 @@ func (is *ImageStore) PutImageManifest(repo, reference, mediaType string, //noli
 		}
 
 		artifactType = zcommon.GetIndexArtifactType(index)
+	} else if mediaType == artifactspec.MediaTypeArtifactManifest {
+		var artifactManifest artifactspec.Manifest
+
+		err := json.Unmarshal(body, &artifactManifest)
+		if err != nil {
+			return "", "", err
+		}
+
+		if artifactManifest.Subject != nil {
+			subjectDigest = artifactManifest.Subject.Digest
+		}
+
+		artifactType = artifactManifest.ArtifactType
 	}
 
 	updateIndex, oldDgst, err := common.CheckIfIndexNeedsUpdate(&index, &desc, is.log)
@@ -651,9 +666,9 @@ func (is *ImageStore) deleteImageManifest(repo, reference string, detectCollisio
 
 	/* check if manifest is referenced in image indexes, do not allow index images manipulations
 	(ie. remove manifest being part of an image index)	*/
-	if manifestDesc.MediaType == ispec.MediaTypeImageManifest {
+	if manifestDesc.MediaType == ispec.MediaTypeImageManifest || manifestDesc.MediaType == schema2.MediaTypeManifest {
 		for _, mDesc := range index.Manifests {
-			if mDesc.MediaType == ispec.MediaTypeImageIndex {
+			if mDesc.MediaType == ispec.MediaTypeImageIndex || mDesc.MediaType == manifestlist.MediaTypeManifestList {
 				if ok, _ := common.IsBlobReferencedInImageIndex(is, repo, manifestDesc.Digest, ispec.Index{
 					Manifests: []ispec.Descriptor{mDesc},
 				}, is.log); ok {
@@ -1065,10 +1080,25 @@ retry:
 		// prevent overwrite original blob
 		if !is.storeDriver.SameFile(dst, dstRecord) {
 			if err := is.storeDriver.Link(dstRecord, dst); err != nil {
-				is.log.Error().Err(err).Str("blobPath", dstRecord).Str("component", "dedupe").
-					Msg("failed to link blobs")
+				if !errors.Is(err, zerr.ErrTooManyLinks) {
+					is.log.Error().Err(err).Str("blobPath", dstRecord).Str("component", "dedupe").
+						Msg("failed to link blobs")
 
-				return err
+					return err
+				}
+
+				// the original blob can't be linked anymore, the uploaded blob is kept as a copy
+				is.log.Warn().Err(err).Str("blobPath", dstRecord).Str("dst", dst).Str("component", "dedupe").
+					Msg("failed to link blobs, storing a copy")
+
+				if err := is.storeDriver.Move(src, dst); err != nil {
+					is.log.Error().Err(err).Str("src", src).Str("dst", dst).Str("component", "dedupe").
+						Msg("failed to rename blob")
+
+					return err
+				}
+
+				return is.cache.PutBlob(dstDigest, dst)
 			}
 
 			if err := is.cache.PutBlob(dstDigest, dst); err != nil {
@@ -1134,6 +1164,168 @@ func (is *ImageStore) DeleteBlobUpload(repo, uuid string) error {
 	return nil
 }
 
+// CleanupBlobUploads removes the uploads of the repository which didn't receive data for longer than the timeout,
+// it returns how many were removed.
+func (is *ImageStore) CleanupBlobUploads(repo string, timeout time.Duration) (int, error) {
+	blobUploads, err := is.storeDriver.List(path.Join(is.rootDir, repo, storageConstants.BlobUploadDir))
+	if err != nil {
+		if errors.As(err, &driver.PathNotFoundError{}) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	count := 0
+
+	for _, blobUpload := range blobUploads {
+		fileInfo, err := is.storeDriver.Stat(blobUpload)
+		if err != nil || fileInfo.IsDir() || time.Since(fileInfo.ModTime()) < timeout {
+			continue
+		}
+
+		uuid := path.Base(blobUpload)
+
+		if err := is.DeleteBlobUpload(repo, uuid); err != nil && !errors.Is(err, zerr.ErrUploadNotFound) {
+			return count, err
+		}
+
+		is.log.Debug().Str("repository", repo).Str("session_id", uuid).Time("lastModified", fileInfo.ModTime()).
+			Msg("removed stale blob upload")
+
+		count++
+	}
+
+	return count, nil
+}
+
+// RunBlobUploadsCleanup periodically removes the uploads which timed out from all the repositories.
+func (is *ImageStore) RunBlobUploadsCleanup(timeout time.Duration, sch *scheduler.Scheduler) {
+	generator := &common.BlobUploadsCleanupGenerator{
+		ImgStore: is,
+		Timeout:  timeout,
+		Log:      is.log,
+	}
+
+	sch.SubmitGenerator(generator, timeout, scheduler.LowPriority)
+}
+
+// ListBlobUploads returns the upload sessions of the repository which weren't finished yet.
+func (is *ImageStore) ListBlobUploads(repo string) ([]storageTypes.BlobUpload, error) {
+	blobUploads, err := is.storeDriver.List(path.Join(is.rootDir, repo, storageConstants.BlobUploadDir))
+	if err != nil {
+		if errors.As(err, &driver.PathNotFoundError{}) {
+			return []storageTypes.BlobUpload{}, nil
+		}
+
+		return nil, err
+	}
+
+	uploads := make([]storageTypes.BlobUpload, 0, len(blobUploads))
+
+	for _, blobUpload := range blobUploads {
+		// the uploads finished meanwhile are skipped
+		fileInfo, err := is.storeDriver.Stat(blobUpload)
+		if err != nil || fileInfo.IsDir() {
+			continue
+		}
+
+		uploads = append(uploads, storageTypes.BlobUpload{
+			SessionID:    path.Base(blobUpload),
+			Size:         fileInfo.Size(),
+			LastModified: fileInfo.ModTime(),
+		})
+	}
+
+	return uploads, nil
+}
+
+// CheckWritable writes then removes a probe file in the root directory, to make sure the storage accepts writes.
+func (is *ImageStore) CheckWritable() error {
+	uuid, err := guuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	probePath := path.Join(is.rootDir, ".probe-"+uuid.String())
+
+	if _, err := is.storeDriver.WriteFile(probePath, []byte(uuid.String())); err != nil {
+		return err
+	}
+
+	return is.storeDriver.Delete(probePath)
+}
+
+// DeleteRepository removes the repository with its images and its uploads, the repositories nested in it are kept.
+// The blobs are removed one by one so the content of the ones deduped in other repositories is moved to them.
+func (is *ImageStore) DeleteRepository(repo string) error {
+	var lockLatency time.Time
+
+	is.Lock(&lockLatency)
+	defer is.Unlock(&lockLatency)
+
+	// e.g. the directory of nested repositories
+	if ok, err := is.ValidateRepo(repo); !ok || err != nil {
+		if errors.Is(err, zerr.ErrInvalidRepositoryName) {
+			return err
+		}
+
+		return zerr.ErrRepoNotFound
+	}
+
+	dir := path.Join(is.rootDir, repo)
+
+	// none of the blobs is referenced once the index is empty
+	index := ispec.Index{Manifests: []ispec.Descriptor{}}
+	index.SchemaVersion = 2
+
+	if err := is.PutIndexContent(repo, index); err != nil {
+		return err
+	}
+
+	blobs, err := is.GetAllBlobs(repo)
+	if err != nil {
+		return err
+	}
+
+	for _, blob := range blobs {
+		digest := godigest.NewDigestFromEncoded(godigest.SHA256, blob)
+
+		if err := is.deleteBlob(repo, digest); err != nil && !errors.Is(err, zerr.ErrBlobNotFound) {
+			is.log.Error().Err(err).Str("repository", repo).Str("digest", digest.String()).
+				Msg("failed to delete blob")
+
+			return err
+		}
+	}
+
+	for _, file := range []string{"blobs", storageConstants.BlobUploadDir, "index.json", ispec.ImageLayoutFile} {
+		if err := is.storeDriver.Delete(path.Join(dir, file)); err != nil &&
+			!errors.As(err, &driver.PathNotFoundError{}) {
+			is.log.Error().Err(err).Str("repository", repo).Str("file", file).Msg("failed to delete repository file")
+
+			return err
+		}
+	}
+
+	// the directories left empty, up to the root directory
+	rootDir := path.Clean(is.rootDir)
+
+	for ; dir != rootDir && strings.HasPrefix(dir, rootDir) && dir != path.Dir(dir); dir = path.Dir(dir) {
+		if files, err := is.storeDriver.List(dir); err != nil || len(files) > 0 {
+			break
+		}
+
+		if err := is.storeDriver.Delete(dir); err != nil && !errors.As(err, &driver.PathNotFoundError{}) {
+			return err
+		}
+	}
+
+	is.log.Info().Str("repository", repo).Int("blobs", len(blobs)).Msg("deleted repository")
+
+	return nil
+}
+
 // BlobPath returns the repository path of a blob.
 func (is *ImageStore) BlobPath(repo string, digest godigest.Digest) string {
 	return path.Join(is.rootDir, repo, "blobs", digest.Algorithm().String(), digest.Encoded())
@@ -1860,11 +2052,18 @@ func (is *ImageStore) dedupeBlobs(ctx context.Context, digest godigest.Digest, d
 		} else {
 			// if we have an original blob cached then we can safely dedupe the rest of them
 			if originalBlob != "" {
-				if err := is.storeDriver.Link(originalBlob, blobPath); err != nil {
+				err := is.storeDriver.Link(originalBlob, blobPath)
+				if err != nil && !errors.Is(err, zerr.ErrTooManyLinks) {
 					is.log.Error().Err(err).Str("path", blobPath).Str("component", "dedupe").Msg("failed to dedupe blob")
 
 					return err
 				}
+
+				// the blob keeps its own content, the next duplicates are linked to it instead
+				if err != nil {
+					is.log.Warn().Err(err).Str("path", blobPath).Str("originalBlob", originalBlob).
+						Str("component", "dedupe").Msg("failed to dedupe blob, keeping it as a copy")
+				}
 			}
 
 			// cache it
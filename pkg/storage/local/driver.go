@@ -288,6 +288,11 @@ func (driver *Driver) Link(src, dest string) error {
 	}
 
 	if err := os.Link(src, dest); err != nil {
+		// the filesystems limit the number of hard links to a file, ext4 to 65000
+		if errors.Is(err, syscall.EMLINK) {
+			return zerr.ErrTooManyLinks
+		}
+
 		return driver.formatErr(err)
 	}
 
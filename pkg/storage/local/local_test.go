@@ -35,6 +35,7 @@ import (
 	"zotregistry.dev/zot/pkg/storage/cache"
 	storageConstants "zotregistry.dev/zot/pkg/storage/constants"
 	"zotregistry.dev/zot/pkg/storage/gc"
+	"zotregistry.dev/zot/pkg/storage/imagestore"
 	"zotregistry.dev/zot/pkg/storage/local"
 	storageTypes "zotregistry.dev/zot/pkg/storage/types"
 	. "zotregistry.dev/zot/pkg/test/image-utils"
@@ -1987,6 +1988,65 @@ func TestHardLink(t *testing.T) {
 	})
 }
 
+// tooManyLinksDriver is a local driver on a filesystem which doesn't allow more hard links to the blobs.
+type tooManyLinksDriver struct {
+	*local.Driver
+}
+
+func (driver tooManyLinksDriver) Link(src, dest string) error {
+	return zerr.ErrTooManyLinks
+}
+
+func TestDedupeTooManyLinks(t *testing.T) {
+	log := zlog.Logger{Logger: zerolog.New(os.Stdout)}
+	metrics := monitoring.NewMetricsServer(false, log)
+
+	Convey("Blobs are copied when they can't be linked anymore", t, func() {
+		dir := t.TempDir()
+
+		cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+			RootDir:     dir,
+			Name:        "cache",
+			UseRelPaths: false,
+		}, log)
+
+		imgStore := imagestore.NewImageStore(dir, dir, true, true, log, metrics, nil,
+			tooManyLinksDriver{local.New(true)}, cacheDriver)
+
+		blob, digest := GetRandomImageConfig()
+
+		for _, repo := range []string{"dedupe1", "dedupe2"} {
+			_, _, err := imgStore.FullBlobUpload(repo, bytes.NewReader(blob), digest)
+			So(err, ShouldBeNil)
+		}
+
+		blobPath1 := imgStore.BlobPath("dedupe1", digest)
+		blobPath2 := imgStore.BlobPath("dedupe2", digest)
+
+		content, err := os.ReadFile(blobPath2)
+		So(err, ShouldBeNil)
+		So(content, ShouldResemble, blob)
+
+		fileInfo1, err := os.Stat(blobPath1)
+		So(err, ShouldBeNil)
+		fileInfo2, err := os.Stat(blobPath2)
+		So(err, ShouldBeNil)
+		So(os.SameFile(fileInfo1, fileInfo2), ShouldBeFalse)
+
+		So(cacheDriver.HasBlob(digest, blobPath1), ShouldBeTrue)
+		So(cacheDriver.HasBlob(digest, blobPath2), ShouldBeTrue)
+
+		Convey("and are kept when rebuilding dedupe", func() {
+			err := imgStore.RunDedupeForDigest(context.Background(), digest, true, []string{blobPath1, blobPath2})
+			So(err, ShouldBeNil)
+
+			content, err := os.ReadFile(blobPath2)
+			So(err, ShouldBeNil)
+			So(content, ShouldResemble, blob)
+		})
+	})
+}
+
 func TestInjectWriteFile(t *testing.T) {
 	Convey("writeFile without commit", t, func() {
 		dir := t.TempDir()
@@ -2958,6 +3018,113 @@ func TestPutBlobChunkStreamed(t *testing.T) {
 	})
 }
 
+func TestCleanupBlobUploads(t *testing.T) {
+	Convey("Stale blob uploads are removed", t, func() {
+		dir := t.TempDir()
+
+		log := zlog.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		imgStore := local.NewImageStore(dir, true, true, log, metrics, nil, nil)
+
+		// no uploads yet
+		count, err := imgStore.CleanupBlobUploads("test", time.Hour)
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 0)
+
+		staleUpload, err := imgStore.NewBlobUpload("test")
+		So(err, ShouldBeNil)
+
+		_, err = imgStore.PutBlobChunkStreamed("test", staleUpload, strings.NewReader("stale"))
+		So(err, ShouldBeNil)
+
+		stalePath := imgStore.BlobUploadPath("test", staleUpload)
+		So(os.Chtimes(stalePath, time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour)), ShouldBeNil)
+
+		activeUpload, err := imgStore.NewBlobUpload("test")
+		So(err, ShouldBeNil)
+
+		count, err = imgStore.CleanupBlobUploads("test", time.Hour)
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 1)
+
+		_, err = imgStore.GetBlobUpload("test", staleUpload)
+		So(errors.Is(err, zerr.ErrUploadNotFound), ShouldBeTrue)
+
+		_, err = imgStore.GetBlobUpload("test", activeUpload)
+		So(err, ShouldBeNil)
+	})
+}
+
+func TestDeleteRepository(t *testing.T) {
+	Convey("Repositories are deleted with their blobs and uploads", t, func() {
+		dir := t.TempDir()
+
+		log := zlog.Logger{Logger: zerolog.New(os.Stdout)}
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		cacheDriver, _ := storage.Create("boltdb", cache.BoltDBDriverParameters{
+			RootDir:     dir,
+			Name:        "cache",
+			UseRelPaths: true,
+		}, log)
+
+		imgStore := local.NewImageStore(dir, true, true, log, metrics, nil, cacheDriver)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		// the blobs of the other repositories are deduped against the ones of the deleted one
+		image := CreateRandomImage()
+		So(WriteImageToFileSystem(image, "app", "1.0", storeController), ShouldBeNil)
+		So(WriteImageToFileSystem(image, "app/nested", "1.0", storeController), ShouldBeNil)
+		So(WriteImageToFileSystem(image, "other", "1.0", storeController), ShouldBeNil)
+
+		upload, err := imgStore.NewBlobUpload("app")
+		So(err, ShouldBeNil)
+
+		_, err = imgStore.PutBlobChunkStreamed("app", upload, strings.NewReader("upload"))
+		So(err, ShouldBeNil)
+
+		uploads, err := imgStore.ListBlobUploads("app")
+		So(err, ShouldBeNil)
+		So(len(uploads), ShouldEqual, 1)
+		So(uploads[0].SessionID, ShouldEqual, upload)
+		So(uploads[0].Size, ShouldEqual, len("upload"))
+
+		uploads, err = imgStore.ListBlobUploads("missing")
+		So(err, ShouldBeNil)
+		So(uploads, ShouldBeEmpty)
+
+		So(imgStore.DeleteRepository("app"), ShouldBeNil)
+
+		repos, err := imgStore.GetRepositories()
+		So(err, ShouldBeNil)
+		So(repos, ShouldResemble, []string{"app/nested", "other"})
+
+		// the nested repository is kept
+		_, err = os.Stat(path.Join(dir, "app", "index.json"))
+		So(os.IsNotExist(err), ShouldBeTrue)
+
+		for _, repo := range []string{"app/nested", "other"} {
+			_, _, _, err := imgStore.GetImageManifest(repo, "1.0")
+			So(err, ShouldBeNil)
+
+			for _, layer := range image.Manifest.Layers {
+				content, err := imgStore.GetBlobContent(repo, layer.Digest)
+				So(err, ShouldBeNil)
+				So(godigest.FromBytes(content), ShouldEqual, layer.Digest)
+			}
+		}
+
+		So(errors.Is(imgStore.DeleteRepository("app"), zerr.ErrRepoNotFound), ShouldBeTrue)
+		So(errors.Is(imgStore.DeleteRepository("../app"), zerr.ErrInvalidRepositoryName), ShouldBeTrue)
+
+		So(imgStore.DeleteRepository("app/nested"), ShouldBeNil)
+
+		_, err = os.Stat(path.Join(dir, "app"))
+		So(os.IsNotExist(err), ShouldBeTrue)
+	})
+}
+
 func TestPullRange(t *testing.T) {
 	Convey("Repo layout", t, func(c C) {
 		dir := t.TempDir()
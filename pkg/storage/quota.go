@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/config"
+	zcommon "zotregistry.dev/zot/pkg/common"
+)
+
+type RepoQuotaUsage struct {
+	Name  string `json:"name"`
+	Usage int64  `json:"usage"`
+	// the lowest of the per repository limits of the policies matching the repository
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+}
+
+type PolicyQuotaUsage struct {
+	Repositories []string `json:"repositories"`
+	// bytes stored in all the repositories matching the policy
+	Usage              int64 `json:"usage"`
+	MaxBytes           int64 `json:"maxBytes,omitempty"`
+	MaxRepositoryBytes int64 `json:"maxRepositoryBytes,omitempty"`
+}
+
+type QuotaUsage struct {
+	// bytes stored in all the repositories
+	Usage        int64              `json:"usage"`
+	MaxBytes     int64              `json:"maxBytes,omitempty"`
+	Repositories []RepoQuotaUsage   `json:"repositories"`
+	Policies     []PolicyQuotaUsage `json:"policies,omitempty"`
+}
+
+// Quotas checks the bytes stored in the repositories against the configured limits.
+// The usage of a repository is the size of its blobs, so the blobs shared by several repositories
+// count in each of them. The usages are cached by the store controllers returned by New(),
+// which share them with all their quotas, the other ones walk the storage when they're needed.
+type Quotas struct {
+	config config.QuotaConfig
+	usages *repoUsages
+}
+
+func NewQuotas(quotaConfig *config.QuotaConfig, storeController StoreController) *Quotas {
+	quotas := &Quotas{usages: storeController.usages}
+
+	if quotas.usages == nil {
+		quotas.usages = newRepoUsages(storeController, false)
+	}
+
+	if quotaConfig != nil {
+		quotas.config = *quotaConfig
+	}
+
+	return quotas
+}
+
+// Check returns zerr.ErrQuotaExceeded if storing size more bytes in the repository would exceed one of its limits.
+func (quotas *Quotas) Check(repo string, size int64) error {
+	policies := quotas.matchingPolicies(repo)
+
+	// the usage of all the repositories is only needed for the limits on several repositories
+	needsAll := quotas.config.MaxBytes > 0
+
+	for _, policy := range policies {
+		if policy.MaxBytes > 0 {
+			needsAll = true
+		}
+	}
+
+	var usages map[string]int64
+
+	if needsAll {
+		var err error
+
+		usages, err = quotas.usages.all()
+		if err != nil {
+			return err
+		}
+	} else {
+		usage, err := quotas.usages.get(repo)
+		if err != nil {
+			return err
+		}
+
+		usages = map[string]int64{repo: usage}
+	}
+
+	if quotas.config.MaxBytes > 0 {
+		if total := sumUsages(usages, nil); total+size > quotas.config.MaxBytes {
+			return fmt.Errorf("%w: %d bytes stored in the registry, the limit is %d bytes",
+				zerr.ErrQuotaExceeded, total, quotas.config.MaxBytes)
+		}
+	}
+
+	for _, policy := range policies {
+		if policy.MaxRepositoryBytes > 0 && usages[repo]+size > policy.MaxRepositoryBytes {
+			return fmt.Errorf("%w: %d bytes stored in repository %s, the limit is %d bytes",
+				zerr.ErrQuotaExceeded, usages[repo], repo, policy.MaxRepositoryBytes)
+		}
+
+		if policy.MaxBytes > 0 {
+			if total := sumUsages(usages, policy.Repositories); total+size > policy.MaxBytes {
+				return fmt.Errorf("%w: %d bytes stored in repositories %v, the limit is %d bytes",
+					zerr.ErrQuotaExceeded, total, policy.Repositories, policy.MaxBytes)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Usage returns the bytes stored in each repository along with the limits applying to them.
+func (quotas *Quotas) Usage() (QuotaUsage, error) {
+	usages, err := quotas.usages.all()
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+
+	quotaUsage := QuotaUsage{
+		Usage:        sumUsages(usages, nil),
+		MaxBytes:     quotas.config.MaxBytes,
+		Repositories: []RepoQuotaUsage{},
+	}
+
+	for repo, usage := range usages {
+		repoQuotaUsage := RepoQuotaUsage{Name: repo, Usage: usage}
+
+		for _, policy := range quotas.matchingPolicies(repo) {
+			if policy.MaxRepositoryBytes > 0 &&
+				(repoQuotaUsage.MaxBytes == 0 || policy.MaxRepositoryBytes < repoQuotaUsage.MaxBytes) {
+				repoQuotaUsage.MaxBytes = policy.MaxRepositoryBytes
+			}
+		}
+
+		quotaUsage.Repositories = append(quotaUsage.Repositories, repoQuotaUsage)
+	}
+
+	sort.Slice(quotaUsage.Repositories, func(i, j int) bool {
+		return quotaUsage.Repositories[i].Name < quotaUsage.Repositories[j].Name
+	})
+
+	for _, policy := range quotas.config.Policies {
+		quotaUsage.Policies = append(quotaUsage.Policies, PolicyQuotaUsage{
+			Repositories:       policy.Repositories,
+			Usage:              sumUsages(usages, policy.Repositories),
+			MaxBytes:           policy.MaxBytes,
+			MaxRepositoryBytes: policy.MaxRepositoryBytes,
+		})
+	}
+
+	return quotaUsage, nil
+}
+
+func (quotas *Quotas) matchingPolicies(repo string) []config.QuotaPolicy {
+	policies := []config.QuotaPolicy{}
+
+	for _, policy := range quotas.config.Policies {
+		if zcommon.MatchesAnyGlob(policy.Repositories, repo) {
+			policies = append(policies, policy)
+		}
+	}
+
+	return policies
+}
+
+// sumUsages adds up the usages of the repositories matching the patterns, or all of them if there are none.
+func sumUsages(usages map[string]int64, patterns []string) int64 {
+	var total int64
+
+	for repo, usage := range usages {
+		if patterns == nil || zcommon.MatchesAnyGlob(patterns, repo) {
+			total += usage
+		}
+	}
+
+	return total
+}
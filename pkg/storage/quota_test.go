@@ -0,0 +1,181 @@
+package storage_test
+
+import (
+	"errors"
+	"testing"
+
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/api/config"
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	"zotregistry.dev/zot/pkg/log"
+	"zotregistry.dev/zot/pkg/storage"
+	"zotregistry.dev/zot/pkg/storage/local"
+	storageTypes "zotregistry.dev/zot/pkg/storage/types"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+// imageSize is the size of the blobs of the image, config and manifest included.
+func imageSize(image Image) int64 {
+	size := image.ManifestDescriptor.Size + image.Manifest.Config.Size
+
+	for _, layer := range image.Manifest.Layers {
+		size += layer.Size
+	}
+
+	return size
+}
+
+func TestQuotas(t *testing.T) {
+	Convey("Storage quotas", t, func() {
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		storeController := storage.StoreController{
+			DefaultStore: local.NewImageStore(t.TempDir(), false, false, log, metrics, nil, nil),
+			SubStore: map[string]storageTypes.ImageStore{
+				"/team": local.NewImageStore(t.TempDir(), false, false, log, metrics, nil, nil),
+			},
+		}
+
+		appImage := CreateRandomImage()
+		So(WriteImageToFileSystem(appImage, "app", "1.0", storeController), ShouldBeNil)
+
+		teamImage := CreateRandomImage()
+		So(WriteImageToFileSystem(teamImage, "team/web", "1.0", storeController), ShouldBeNil)
+
+		appSize := imageSize(appImage)
+		teamSize := imageSize(teamImage)
+
+		Convey("No limits", func() {
+			quotas := storage.NewQuotas(nil, storeController)
+			So(quotas.Check("app", 1<<40), ShouldBeNil)
+
+			usage, err := quotas.Usage()
+			So(err, ShouldBeNil)
+			So(usage.Usage, ShouldEqual, appSize+teamSize)
+			So(usage.MaxBytes, ShouldEqual, 0)
+			So(usage.Repositories, ShouldResemble, []storage.RepoQuotaUsage{
+				{Name: "app", Usage: appSize},
+				{Name: "team/web", Usage: teamSize},
+			})
+			So(usage.Policies, ShouldBeEmpty)
+		})
+
+		Convey("Global limit", func() {
+			quotas := storage.NewQuotas(&config.QuotaConfig{MaxBytes: appSize + teamSize + 10}, storeController)
+			So(quotas.Check("app", 10), ShouldBeNil)
+			So(quotas.Check("new", 10), ShouldBeNil)
+
+			err := quotas.Check("new", 11)
+			So(errors.Is(err, zerr.ErrQuotaExceeded), ShouldBeTrue)
+		})
+
+		Convey("Repository and namespace limits", func() {
+			quotas := storage.NewQuotas(&config.QuotaConfig{
+				Policies: []config.QuotaPolicy{
+					{Repositories: []string{"**"}, MaxRepositoryBytes: appSize + 10},
+					{Repositories: []string{"team/**"}, MaxBytes: teamSize + 5},
+				},
+			}, storeController)
+
+			So(quotas.Check("app", 10), ShouldBeNil)
+			So(errors.Is(quotas.Check("app", 11), zerr.ErrQuotaExceeded), ShouldBeTrue)
+
+			// the namespace limit applies to all of its repositories together
+			So(quotas.Check("team/api", 5), ShouldBeNil)
+			So(errors.Is(quotas.Check("team/api", 6), zerr.ErrQuotaExceeded), ShouldBeTrue)
+
+			usage, err := quotas.Usage()
+			So(err, ShouldBeNil)
+			So(usage.Repositories, ShouldResemble, []storage.RepoQuotaUsage{
+				{Name: "app", Usage: appSize, MaxBytes: appSize + 10},
+				{Name: "team/web", Usage: teamSize, MaxBytes: appSize + 10},
+			})
+			So(usage.Policies, ShouldResemble, []storage.PolicyQuotaUsage{
+				{Repositories: []string{"**"}, Usage: appSize + teamSize, MaxRepositoryBytes: appSize + 10},
+				{Repositories: []string{"team/**"}, Usage: teamSize, MaxBytes: teamSize + 5},
+			})
+		})
+	})
+}
+
+func TestQuotasUsageCache(t *testing.T) {
+	Convey("The usages are cached by the store controllers", t, func() {
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		conf := config.New()
+		conf.Storage.RootDirectory = t.TempDir()
+		conf.Storage.Dedupe = false
+
+		storeController, err := storage.New(conf, nil, metrics, log)
+		So(err, ShouldBeNil)
+
+		image := CreateRandomImage()
+		So(WriteImageToFileSystem(image, "app", "1.0", storeController), ShouldBeNil)
+
+		size := imageSize(image)
+		quotas := storage.NewQuotas(&config.QuotaConfig{MaxBytes: 1 << 40}, storeController)
+
+		usage, err := quotas.Usage()
+		So(err, ShouldBeNil)
+		So(usage.Usage, ShouldEqual, size)
+
+		// the blobs stored by the image stores are counted without walking the storage
+		otherImage := CreateRandomImage()
+		So(WriteImageToFileSystem(otherImage, "app", "2.0", storeController), ShouldBeNil)
+		So(WriteImageToFileSystem(otherImage, "other", "1.0", storeController), ShouldBeNil)
+
+		otherSize := imageSize(otherImage)
+
+		// the quotas built after a config reload share the cache
+		quotas = storage.NewQuotas(&config.QuotaConfig{MaxBytes: size + 2*otherSize}, storeController)
+		So(quotas.Check("app", 0), ShouldBeNil)
+		So(errors.Is(quotas.Check("app", 1), zerr.ErrQuotaExceeded), ShouldBeTrue)
+
+		usage, err = quotas.Usage()
+		So(err, ShouldBeNil)
+		So(usage.Repositories, ShouldResemble, []storage.RepoQuotaUsage{
+			{Name: "app", Usage: size + otherSize},
+			{Name: "other", Usage: otherSize},
+		})
+
+		// the repositories are walked again after their manifests are deleted
+		imgStore := storeController.GetImageStore("other")
+		So(imgStore.DeleteImageManifest("other", "1.0", false), ShouldBeNil)
+
+		remaining := storedSize(imgStore, "other")
+
+		usage, err = quotas.Usage()
+		So(err, ShouldBeNil)
+		So(usage.Repositories[1], ShouldResemble, storage.RepoQuotaUsage{Name: "other", Usage: remaining})
+
+		layer := otherImage.Manifest.Layers[0]
+		So(imgStore.DeleteBlob("other", layer.Digest), ShouldBeNil)
+
+		usage, err = quotas.Usage()
+		So(err, ShouldBeNil)
+		So(usage.Repositories[1].Usage, ShouldEqual, remaining-layer.Size)
+		So(usage.Repositories[1].Usage, ShouldEqual, storedSize(imgStore, "other"))
+	})
+}
+
+// storedSize returns the size of the blobs found in the storage for the repository.
+func storedSize(imgStore storageTypes.ImageStore, repo string) int64 {
+	blobs, err := imgStore.GetAllBlobs(repo)
+	So(err, ShouldBeNil)
+
+	var size int64
+
+	for _, blob := range blobs {
+		_, blobSize, _, err := imgStore.StatBlob(repo, godigest.NewDigestFromEncoded(godigest.SHA256, blob))
+		So(err, ShouldBeNil)
+
+		size += blobSize
+	}
+
+	return size
+}
@@ -0,0 +1,275 @@
+package recompression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/containers/storage/pkg/chunked/compressor"
+	"github.com/klauspost/compress/zstd"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.dev/zot/errors"
+	zcommon "zotregistry.dev/zot/pkg/common"
+	zlog "zotregistry.dev/zot/pkg/log"
+	"zotregistry.dev/zot/pkg/meta"
+	mTypes "zotregistry.dev/zot/pkg/meta/types"
+	"zotregistry.dev/zot/pkg/scheduler"
+	"zotregistry.dev/zot/pkg/storage"
+	storageTypes "zotregistry.dev/zot/pkg/storage/types"
+)
+
+const (
+	FormatZstd        = "zstd"
+	FormatZstdChunked = "zstd:chunked"
+	DefaultTagSuffix  = "-zstd"
+	DefaultLevel      = 3
+)
+
+type Options struct {
+	// FormatZstdChunked or FormatZstd
+	Format string
+	// appended to the tag of the pushed image to get the one of its copy
+	TagSuffix string
+	Level     int
+}
+
+// Recompressor pushes a copy of the images with their gzip layers recompressed to zstd. zstd:chunked layers
+// keep the offsets of their files in annotations, so the clients supporting it (podman, cri-o) pull only the files
+// they don't have already with range requests.
+type Recompressor struct {
+	storeController storage.StoreController
+	metaDB          mTypes.MetaDB
+	opts            Options
+	log             zlog.Logger
+}
+
+func NewRecompressor(storeController storage.StoreController, metaDB mTypes.MetaDB, opts Options,
+	log zlog.Logger,
+) Recompressor {
+	if opts.Format == "" {
+		opts.Format = FormatZstdChunked
+	}
+
+	if opts.TagSuffix == "" {
+		opts.TagSuffix = DefaultTagSuffix
+	}
+
+	if opts.Level == 0 {
+		opts.Level = DefaultLevel
+	}
+
+	return Recompressor{
+		storeController: storeController,
+		metaDB:          metaDB,
+		opts:            opts,
+		log:             log,
+	}
+}
+
+// IsRecompressed tells if the images pushed with the reference get a recompressed copy, the copies themselves,
+// the images pushed by digest and the signatures don't.
+func (recompressor Recompressor) IsRecompressed(reference string) bool {
+	if _, err := godigest.Parse(reference); err == nil {
+		return false
+	}
+
+	return !strings.HasSuffix(reference, recompressor.opts.TagSuffix) &&
+		!zcommon.IsCosignTag(reference) && !zcommon.IsReferrersTag(reference)
+}
+
+// NewTask returns the task recompressing the image with the tag.
+func (recompressor Recompressor) NewTask(repo, tag string) scheduler.Task {
+	return &recompressTask{recompressor: recompressor, repo: repo, tag: tag}
+}
+
+// RecompressImage pushes the copy of the image with the tag, it returns its digest, or an empty one if the image
+// has no gzip layer. Image indexes and artifacts referring to other images are left as they are.
+func (recompressor Recompressor) RecompressImage(ctx context.Context, repo, tag string) (godigest.Digest, error) {
+	imgStore := recompressor.storeController.GetImageStore(repo)
+
+	content, _, mediaType, err := imgStore.GetImageManifest(repo, tag)
+	if err != nil {
+		return "", err
+	}
+
+	if mediaType != ispec.MediaTypeImageManifest {
+		return "", nil
+	}
+
+	var manifest ispec.Manifest
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return "", err
+	}
+
+	if manifest.Subject != nil {
+		return "", nil
+	}
+
+	recompressed := false
+
+	for idx, layer := range manifest.Layers {
+		if layer.MediaType != ispec.MediaTypeImageLayerGzip {
+			continue
+		}
+
+		if zcommon.IsContextDone(ctx) {
+			return "", ctx.Err()
+		}
+
+		manifest.Layers[idx], err = recompressor.recompressLayer(imgStore, repo, layer)
+		if err != nil {
+			recompressor.log.Error().Err(err).Str("repository", repo).Str("layer", layer.Digest.String()).
+				Msg("failed to recompress layer")
+
+			return "", err
+		}
+
+		recompressed = true
+	}
+
+	if !recompressed {
+		return "", nil
+	}
+
+	// the config is left as it is, the diff ids are the digests of the uncompressed layers
+	content, err = json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+
+	reference := tag + recompressor.opts.TagSuffix
+
+	digest, _, err := imgStore.PutImageManifest(repo, reference, ispec.MediaTypeImageManifest, content)
+	if err != nil {
+		return "", err
+	}
+
+	if recompressor.metaDB != nil {
+		if err := meta.OnUpdateManifest(ctx, repo, reference, ispec.MediaTypeImageManifest, digest, content,
+			recompressor.storeController, recompressor.metaDB, recompressor.log); err != nil {
+			return "", err
+		}
+	}
+
+	recompressor.log.Info().Str("repository", repo).Str("tag", reference).Str("format", recompressor.opts.Format).
+		Msg("pushed recompressed image")
+
+	return digest, nil
+}
+
+// recompressLayer uploads the layer recompressed, its digest is computed while it's written.
+func (recompressor Recompressor) recompressLayer(imgStore storageTypes.ImageStore, repo string,
+	layer ispec.Descriptor,
+) (ispec.Descriptor, error) {
+	blob, _, err := imgStore.GetBlob(repo, layer.Digest, layer.MediaType)
+	if err != nil {
+		return layer, err
+	}
+
+	defer blob.Close()
+
+	tarReader, err := gzip.NewReader(blob)
+	if err != nil {
+		return layer, err
+	}
+
+	uuid, err := imgStore.NewBlobUpload(repo)
+	if err != nil {
+		return layer, err
+	}
+
+	// zstd:chunked writes the offsets of the files to the annotations once the layer is complete
+	annotations := map[string]string{}
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		writer, err := recompressor.newWriter(pipeWriter, annotations)
+		if err == nil {
+			_, err = io.Copy(writer, tarReader)
+
+			if closeErr := writer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+
+		pipeWriter.CloseWithError(err)
+	}()
+
+	digester := godigest.SHA256.Digester()
+
+	size, err := imgStore.PutBlobChunkStreamed(repo, uuid, io.TeeReader(pipeReader, digester.Hash()))
+	if err != nil {
+		pipeReader.CloseWithError(err)
+		_ = imgStore.DeleteBlobUpload(repo, uuid)
+
+		return layer, err
+	}
+
+	digest := digester.Digest()
+
+	if err := imgStore.FinishBlobUpload(repo, uuid, bytes.NewReader(nil), digest); err != nil {
+		_ = imgStore.DeleteBlobUpload(repo, uuid)
+
+		return layer, err
+	}
+
+	recompressedLayer := layer
+	recompressedLayer.MediaType = ispec.MediaTypeImageLayerZstd
+	recompressedLayer.Digest = digest
+	recompressedLayer.Size = size
+
+	if len(annotations) > 0 || len(layer.Annotations) > 0 {
+		recompressedLayer.Annotations = map[string]string{}
+
+		for key, value := range layer.Annotations {
+			recompressedLayer.Annotations[key] = value
+		}
+
+		for key, value := range annotations {
+			recompressedLayer.Annotations[key] = value
+		}
+	}
+
+	return recompressedLayer, nil
+}
+
+func (recompressor Recompressor) newWriter(output io.Writer, annotations map[string]string,
+) (io.WriteCloser, error) {
+	switch recompressor.opts.Format {
+	case FormatZstdChunked:
+		level := recompressor.opts.Level
+
+		return compressor.ZstdCompressor(output, annotations, &level)
+	case FormatZstd:
+		return zstd.NewWriter(output, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(recompressor.opts.Level)))
+	default:
+		return nil, fmt.Errorf("%w: %s", zerr.ErrCompressionNotSupported, recompressor.opts.Format)
+	}
+}
+
+type recompressTask struct {
+	recompressor Recompressor
+	repo         string
+	tag          string
+}
+
+func (task *recompressTask) DoWork(ctx context.Context) error {
+	_, err := task.recompressor.RecompressImage(ctx, task.repo, task.tag)
+
+	return err
+}
+
+func (task *recompressTask) String() string {
+	return fmt.Sprintf("{Name: %s, repo: %s, tag: %s}", task.Name(), task.repo, task.tag)
+}
+
+func (task *recompressTask) Name() string {
+	return "RecompressTask"
+}
@@ -0,0 +1,190 @@
+package recompression_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	"zotregistry.dev/zot/pkg/log"
+	"zotregistry.dev/zot/pkg/storage"
+	"zotregistry.dev/zot/pkg/storage/local"
+	"zotregistry.dev/zot/pkg/storage/recompression"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+)
+
+// zstdChunkedChecksumAnnotation is set by the zstd:chunked compressor on the layers.
+const zstdChunkedChecksumAnnotation = "io.github.containers.zstd-chunked.manifest-checksum"
+
+func createTarLayer(files map[string]string) ([]byte, []byte) {
+	tarBuf := bytes.Buffer{}
+	tarWriter := tar.NewWriter(&tarBuf)
+
+	for name, content := range files {
+		So(tarWriter.WriteHeader(&tar.Header{
+			Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg,
+		}), ShouldBeNil)
+
+		_, err := tarWriter.Write([]byte(content))
+		So(err, ShouldBeNil)
+	}
+
+	So(tarWriter.Close(), ShouldBeNil)
+
+	gzipBuf := bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(&gzipBuf)
+
+	_, err := gzipWriter.Write(tarBuf.Bytes())
+	So(err, ShouldBeNil)
+	So(gzipWriter.Close(), ShouldBeNil)
+
+	return tarBuf.Bytes(), gzipBuf.Bytes()
+}
+
+func TestRecompression(t *testing.T) {
+	Convey("Recompress the gzip layers of an image", t, func() {
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		imgStore := local.NewImageStore(t.TempDir(), false, false, log, metrics, nil, nil)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		tarLayer, gzipLayer := createTarLayer(map[string]string{
+			"etc/hostname": "zot",
+			"usr/bin/app":  string(bytes.Repeat([]byte("binary"), 1000)),
+		})
+		uncompressedLayer := []byte("uncompressed layer")
+
+		image := CreateImageWith().Layers([]Layer{
+			{Blob: gzipLayer, MediaType: ispec.MediaTypeImageLayerGzip, Digest: godigest.FromBytes(gzipLayer)},
+			{
+				Blob: uncompressedLayer, MediaType: ispec.MediaTypeImageLayer,
+				Digest: godigest.FromBytes(uncompressedLayer),
+			},
+		}).DefaultConfig().Build()
+		So(WriteImageToFileSystem(image, "app", "1.0", storeController), ShouldBeNil)
+
+		getManifest := func(reference string) ispec.Manifest {
+			content, _, _, err := imgStore.GetImageManifest("app", reference)
+			So(err, ShouldBeNil)
+
+			var manifest ispec.Manifest
+			So(json.Unmarshal(content, &manifest), ShouldBeNil)
+
+			return manifest
+		}
+
+		getLayer := func(digest godigest.Digest) []byte {
+			content, err := imgStore.GetBlobContent("app", digest)
+			So(err, ShouldBeNil)
+
+			return content
+		}
+
+		decompress := func(content []byte) []byte {
+			reader, err := zstd.NewReader(bytes.NewReader(content))
+			So(err, ShouldBeNil)
+
+			defer reader.Close()
+
+			decompressed, err := io.ReadAll(reader)
+			So(err, ShouldBeNil)
+
+			return decompressed
+		}
+
+		Convey("zstd:chunked", func() {
+			recompressor := recompression.NewRecompressor(storeController, nil, recompression.Options{}, log)
+
+			digest, err := recompressor.RecompressImage(context.Background(), "app", "1.0")
+			So(err, ShouldBeNil)
+			So(digest, ShouldNotBeEmpty)
+
+			manifest := getManifest("1.0-zstd")
+			So(manifest.Config, ShouldResemble, image.Manifest.Config)
+			So(manifest.Layers, ShouldHaveLength, 2)
+			So(manifest.Layers[0].MediaType, ShouldEqual, ispec.MediaTypeImageLayerZstd)
+			So(manifest.Layers[0].Annotations, ShouldContainKey, zstdChunkedChecksumAnnotation)
+			So(manifest.Layers[1], ShouldResemble, image.Manifest.Layers[1])
+
+			layer := getLayer(manifest.Layers[0].Digest)
+			So(int64(len(layer)), ShouldEqual, manifest.Layers[0].Size)
+			So(godigest.FromBytes(layer), ShouldEqual, manifest.Layers[0].Digest)
+
+			// the files can be read as a regular tar
+			tarReader := tar.NewReader(bytes.NewReader(decompress(layer)))
+			names := []string{}
+
+			for {
+				header, err := tarReader.Next()
+				if err != nil {
+					So(err, ShouldEqual, io.EOF)
+
+					break
+				}
+
+				names = append(names, header.Name)
+			}
+
+			So(names, ShouldContain, "etc/hostname")
+			So(names, ShouldContain, "usr/bin/app")
+		})
+
+		Convey("zstd", func() {
+			recompressor := recompression.NewRecompressor(storeController, nil, recompression.Options{
+				Format:    recompression.FormatZstd,
+				TagSuffix: "-z",
+				Level:     10,
+			}, log)
+
+			_, err := recompressor.RecompressImage(context.Background(), "app", "1.0")
+			So(err, ShouldBeNil)
+
+			manifest := getManifest("1.0-z")
+			So(manifest.Layers[0].MediaType, ShouldEqual, ispec.MediaTypeImageLayerZstd)
+			So(manifest.Layers[0].Annotations, ShouldBeEmpty)
+			So(decompress(getLayer(manifest.Layers[0].Digest)), ShouldResemble, tarLayer)
+		})
+
+		Convey("Images without gzip layers", func() {
+			recompressor := recompression.NewRecompressor(storeController, nil, recompression.Options{}, log)
+
+			_, err := recompressor.RecompressImage(context.Background(), "app", "1.0")
+			So(err, ShouldBeNil)
+
+			digest, err := recompressor.RecompressImage(context.Background(), "app", "1.0-zstd")
+			So(err, ShouldBeNil)
+			So(digest, ShouldBeEmpty)
+		})
+
+		Convey("Invalid gzip layer", func() {
+			So(WriteImageToFileSystem(CreateRandomImage(), "app", "2.0", storeController), ShouldBeNil)
+
+			recompressor := recompression.NewRecompressor(storeController, nil, recompression.Options{}, log)
+
+			_, err := recompressor.RecompressImage(context.Background(), "app", "2.0")
+			So(err, ShouldNotBeNil)
+
+			_, _, _, err = imgStore.GetImageManifest("app", "2.0-zstd")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Recompressed references", func() {
+			recompressor := recompression.NewRecompressor(storeController, nil, recompression.Options{}, log)
+
+			So(recompressor.IsRecompressed("1.0"), ShouldBeTrue)
+			So(recompressor.IsRecompressed("1.0-zstd"), ShouldBeFalse)
+			So(recompressor.IsRecompressed(image.DigestStr()), ShouldBeFalse)
+			So(recompressor.IsRecompressed("sha256-"+image.Digest().Encoded()+".sig"), ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	storageTypes "zotregistry.dev/zot/pkg/storage/types"
+)
+
+type RepoStats struct {
+	Name      string `json:"name"`
+	Tags      int    `json:"tags"`
+	Manifests int    `json:"manifests"`
+	Blobs     int    `json:"blobs"`
+	// bytes stored in the blobs of the repository, the ones shared with other repositories included
+	Size    int64 `json:"size"`
+	Uploads int   `json:"uploads"`
+	// the last time the index of the repository changed
+	LastUpdated time.Time `json:"lastUpdated"`
+}
+
+type RepoUploads struct {
+	Name    string                    `json:"name"`
+	Uploads []storageTypes.BlobUpload `json:"uploads"`
+}
+
+// GetRepoStats returns the figures of every repository of the stores, sorted by name.
+// They're computed from the storage, which is walked for the size of the blobs.
+func GetRepoStats(storeController StoreController) ([]RepoStats, error) {
+	stats := []RepoStats{}
+
+	for _, imgStore := range storeController.imageStores() {
+		repos, err := imgStore.GetRepositories()
+		if err != nil {
+			return stats, err
+		}
+
+		for _, repo := range repos {
+			repoStats, err := getRepoStats(imgStore, repo)
+			if err != nil {
+				return stats, err
+			}
+
+			stats = append(stats, repoStats)
+		}
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+
+	return stats, nil
+}
+
+func getRepoStats(imgStore storageTypes.ImageStore, repo string) (RepoStats, error) {
+	repoStats := RepoStats{Name: repo}
+
+	indexContent, err := imgStore.GetIndexContent(repo)
+	if err != nil {
+		return repoStats, err
+	}
+
+	var index ispec.Index
+
+	if err := json.Unmarshal(indexContent, &index); err != nil {
+		return repoStats, err
+	}
+
+	repoStats.Manifests = len(index.Manifests)
+
+	for _, desc := range index.Manifests {
+		if _, ok := desc.Annotations[ispec.AnnotationRefName]; ok {
+			repoStats.Tags++
+		}
+	}
+
+	blobs, err := imgStore.GetAllBlobs(repo)
+	if err != nil {
+		return repoStats, err
+	}
+
+	repoStats.Blobs = len(blobs)
+
+	sizes, err := repoBlobs(imgStore, repo)
+	if err != nil {
+		return repoStats, err
+	}
+
+	repoStats.Size = sumBlobs(sizes)
+
+	uploads, err := imgStore.ListBlobUploads(repo)
+	if err != nil {
+		return repoStats, err
+	}
+
+	repoStats.Uploads = len(uploads)
+
+	if _, _, modTime, err := imgStore.StatIndex(repo); err == nil {
+		repoStats.LastUpdated = modTime
+	}
+
+	return repoStats, nil
+}
+
+// GetRepoUploads returns the upload sessions in progress of every repository of the stores which has some.
+func GetRepoUploads(storeController StoreController) ([]RepoUploads, error) {
+	repoUploads := []RepoUploads{}
+
+	for _, imgStore := range storeController.imageStores() {
+		repos, err := imgStore.GetRepositories()
+		if err != nil {
+			return repoUploads, err
+		}
+
+		for _, repo := range repos {
+			uploads, err := imgStore.ListBlobUploads(repo)
+			if err != nil {
+				return repoUploads, err
+			}
+
+			if len(uploads) > 0 {
+				repoUploads = append(repoUploads, RepoUploads{Name: repo, Uploads: uploads})
+			}
+		}
+	}
+
+	sort.Slice(repoUploads, func(i, j int) bool {
+		return repoUploads[i].Name < repoUploads[j].Name
+	})
+
+	return repoUploads, nil
+}
@@ -0,0 +1,314 @@
+package storage
+
+import (
+	"io"
+	"path"
+	"sync"
+
+	godigest "github.com/opencontainers/go-digest"
+
+	storageTypes "zotregistry.dev/zot/pkg/storage/types"
+)
+
+// repoUsages caches the bytes stored in each repository, the blobs shared by several repositories count in
+// each of them. The image stores are walked the first time the usages are needed, then the image stores
+// returned by New() keep the cache up to date when they store or remove blobs, see usageTrackingStore.
+type repoUsages struct {
+	storeController StoreController
+	// false if the image stores don't update the cache, the usages are computed each time they're needed then
+	tracked bool
+	loaded  bool
+	// the size of each blob of each repository
+	blobs  map[string]map[godigest.Digest]int64
+	totals map[string]int64
+	// the repositories walked again before their usage is used, e.g. after some of their manifests were removed
+	stale map[string]bool
+	lock  sync.Mutex
+}
+
+func newRepoUsages(storeController StoreController, tracked bool) *repoUsages {
+	return &repoUsages{storeController: storeController, tracked: tracked}
+}
+
+// all returns the bytes stored in each repository of all the image stores.
+func (usages *repoUsages) all() (map[string]int64, error) {
+	usages.lock.Lock()
+	defer usages.lock.Unlock()
+
+	if err := usages.load(); err != nil {
+		return map[string]int64{}, err
+	}
+
+	totals := make(map[string]int64, len(usages.totals))
+	for repo, total := range usages.totals {
+		totals[repo] = total
+	}
+
+	return totals, nil
+}
+
+// get returns the bytes stored in the repository.
+func (usages *repoUsages) get(repo string) (int64, error) {
+	if !usages.tracked {
+		blobs, err := repoBlobs(usages.storeController.GetImageStore(repo), repo)
+
+		return sumBlobs(blobs), err
+	}
+
+	usages.lock.Lock()
+	defer usages.lock.Unlock()
+
+	if err := usages.load(); err != nil {
+		return 0, err
+	}
+
+	return usages.totals[repo], nil
+}
+
+// load walks all the image stores the first time, or each time if the usages aren't tracked,
+// then only the repositories which became stale. The caller must hold the lock.
+func (usages *repoUsages) load() error {
+	if usages.loaded && usages.tracked {
+		for repo := range usages.stale {
+			if err := usages.walk(usages.storeController.GetImageStore(repo), repo); err != nil {
+				return err
+			}
+
+			delete(usages.stale, repo)
+		}
+
+		return nil
+	}
+
+	usages.blobs = map[string]map[godigest.Digest]int64{}
+	usages.totals = map[string]int64{}
+	usages.stale = map[string]bool{}
+
+	for _, imgStore := range usages.storeController.imageStores() {
+		repos, err := imgStore.GetRepositories()
+		if err != nil {
+			return err
+		}
+
+		for _, repo := range repos {
+			if err := usages.walk(imgStore, repo); err != nil {
+				return err
+			}
+		}
+	}
+
+	usages.loaded = true
+
+	return nil
+}
+
+// walk computes the usage of the repository from the storage, the caller must hold the lock.
+func (usages *repoUsages) walk(imgStore storageTypes.ImageStore, repo string) error {
+	// e.g. the repositories removed by the garbage collection once they had no blobs left
+	if !imgStore.DirExists(path.Join(imgStore.RootDir(), repo)) {
+		delete(usages.blobs, repo)
+		delete(usages.totals, repo)
+
+		return nil
+	}
+
+	blobs, err := repoBlobs(imgStore, repo)
+	if err != nil {
+		return err
+	}
+
+	usages.blobs[repo] = blobs
+	usages.totals[repo] = sumBlobs(blobs)
+
+	return nil
+}
+
+// addBlob counts the blob in the usage of the repository, it's counted once however many times it's stored.
+func (usages *repoUsages) addBlob(repo string, digest godigest.Digest, size int64) {
+	usages.lock.Lock()
+	defer usages.lock.Unlock()
+
+	// the repository is walked anyway before its usage is used
+	if !usages.loaded || usages.stale[repo] {
+		return
+	}
+
+	blobs, ok := usages.blobs[repo]
+	if !ok {
+		blobs = map[godigest.Digest]int64{}
+		usages.blobs[repo] = blobs
+	}
+
+	usages.totals[repo] += size - blobs[digest]
+	blobs[digest] = size
+}
+
+func (usages *repoUsages) removeBlob(repo string, digest godigest.Digest) {
+	usages.lock.Lock()
+	defer usages.lock.Unlock()
+
+	if !usages.loaded || usages.stale[repo] {
+		return
+	}
+
+	if blobs, ok := usages.blobs[repo]; ok {
+		usages.totals[repo] -= blobs[digest]
+		delete(blobs, digest)
+	}
+}
+
+// invalidate walks the repository again the next time its usage is needed, for the changes
+// of its blobs which can't be followed one by one, e.g. the ones made by the garbage collection.
+func (usages *repoUsages) invalidate(repo string) {
+	usages.lock.Lock()
+	defer usages.lock.Unlock()
+
+	if usages.loaded {
+		usages.stale[repo] = true
+	}
+}
+
+// repoBlobs returns the size of each blob of the repository.
+func repoBlobs(imgStore storageTypes.ImageStore, repo string) (map[godigest.Digest]int64, error) {
+	blobs := map[godigest.Digest]int64{}
+
+	digests, err := imgStore.GetAllBlobs(repo)
+	if err != nil {
+		return blobs, err
+	}
+
+	for _, encoded := range digests {
+		digest := godigest.NewDigestFromEncoded(godigest.SHA256, encoded)
+
+		// the blobs removed meanwhile don't count
+		_, size, _, err := imgStore.StatBlob(repo, digest)
+		if err != nil {
+			continue
+		}
+
+		blobs[digest] = size
+	}
+
+	return blobs, nil
+}
+
+func sumBlobs(blobs map[godigest.Digest]int64) int64 {
+	var total int64
+
+	for _, size := range blobs {
+		total += size
+	}
+
+	return total
+}
+
+// usageTrackingStore updates the usages of the repositories when the blobs are stored or removed.
+type usageTrackingStore struct {
+	storageTypes.ImageStore
+	usages *repoUsages
+}
+
+func (store usageTrackingStore) PutImageManifest(repo, reference, mediaType string, body []byte,
+) (godigest.Digest, godigest.Digest, error) {
+	digest, subjectDigest, err := store.ImageStore.PutImageManifest(repo, reference, mediaType, body)
+	if err == nil {
+		store.usages.addBlob(repo, digest, int64(len(body)))
+	}
+
+	return digest, subjectDigest, err
+}
+
+func (store usageTrackingStore) DeleteImageManifest(repo, reference string, detectCollision bool) error {
+	err := store.ImageStore.DeleteImageManifest(repo, reference, detectCollision)
+
+	// the manifest blob is only removed if no other reference points to it
+	store.usages.invalidate(repo)
+
+	return err
+}
+
+func (store usageTrackingStore) FinishBlobUpload(repo, uuid string, body io.Reader, digest godigest.Digest) error {
+	if err := store.ImageStore.FinishBlobUpload(repo, uuid, body, digest); err != nil {
+		return err
+	}
+
+	if _, size, _, err := store.ImageStore.StatBlob(repo, digest); err == nil {
+		store.usages.addBlob(repo, digest, size)
+	} else {
+		store.usages.invalidate(repo)
+	}
+
+	return nil
+}
+
+func (store usageTrackingStore) FullBlobUpload(repo string, body io.Reader, digest godigest.Digest,
+) (string, int64, error) {
+	sessionID, size, err := store.ImageStore.FullBlobUpload(repo, body, digest)
+	if err == nil {
+		store.usages.addBlob(repo, digest, size)
+	}
+
+	return sessionID, size, err
+}
+
+// CheckBlob counts the blob since it's copied from another repository if it's only found in the dedupe cache.
+func (store usageTrackingStore) CheckBlob(repo string, digest godigest.Digest) (bool, int64, error) {
+	ok, size, err := store.ImageStore.CheckBlob(repo, digest)
+	if err == nil && ok {
+		store.usages.addBlob(repo, digest, size)
+	}
+
+	return ok, size, err
+}
+
+func (store usageTrackingStore) DeleteBlob(repo string, digest godigest.Digest) error {
+	err := store.ImageStore.DeleteBlob(repo, digest)
+	if err == nil {
+		store.usages.removeBlob(repo, digest)
+	}
+
+	return err
+}
+
+func (store usageTrackingStore) CleanupRepo(repo string, blobs []godigest.Digest, removeRepo bool) (int, error) {
+	count, err := store.ImageStore.CleanupRepo(repo, blobs, removeRepo)
+
+	// some of the blobs may be manifests still referenced, and the repository may be removed
+	store.usages.invalidate(repo)
+
+	return count, err
+}
+
+func (store usageTrackingStore) DeleteRepository(repo string) error {
+	err := store.ImageStore.DeleteRepository(repo)
+
+	store.usages.invalidate(repo)
+
+	return err
+}
+
+// trackUsage wraps the image stores of the controller so they keep the usages of its repositories up to date.
+func trackUsage(storeController StoreController) StoreController {
+	usages := newRepoUsages(StoreController{}, true)
+
+	tracked := StoreController{
+		SubStoreRepositories: storeController.SubStoreRepositories,
+		usages:               usages,
+	}
+
+	if storeController.DefaultStore != nil {
+		tracked.DefaultStore = usageTrackingStore{ImageStore: storeController.DefaultStore, usages: usages}
+	}
+
+	if storeController.SubStore != nil {
+		tracked.SubStore = make(map[string]storageTypes.ImageStore, len(storeController.SubStore))
+
+		for route, imgStore := range storeController.SubStore {
+			tracked.SubStore[route] = usageTrackingStore{ImageStore: imgStore, usages: usages}
+		}
+	}
+
+	usages.storeController = tracked
+
+	return tracked
+}
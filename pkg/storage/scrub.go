@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
@@ -44,6 +46,88 @@ type ScrubResults struct {
 	ScrubResults []ScrubImageResult `json:"scrubResults"`
 }
 
+// RepoScrubStatus is the result of the last scrub of a repository.
+type RepoScrubStatus struct {
+	Name      string    `json:"name"`
+	CheckedAt time.Time `json:"checkedAt"`
+	// Error is set if the repository couldn't be checked at all
+	Error        string             `json:"error,omitempty"`
+	ScrubResults []ScrubImageResult `json:"scrubResults"`
+}
+
+// ScrubStatus is the result of the last scrub of each repository, sorted by repository name.
+type ScrubStatus struct {
+	Repositories []RepoScrubStatus `json:"repositories"`
+}
+
+// ScrubReport keeps the result of the last scrub of each repository, as the periodic scrub
+// checks them one after the other, so they can be queried while the server is running.
+type ScrubReport struct {
+	lock  *sync.RWMutex
+	repos map[string]RepoScrubStatus
+}
+
+func NewScrubReport() *ScrubReport {
+	return &ScrubReport{
+		lock:  &sync.RWMutex{},
+		repos: map[string]RepoScrubStatus{},
+	}
+}
+
+// SetRepoResults replaces the last result of the repository, err is the error it couldn't be checked with.
+func (report *ScrubReport) SetRepoResults(repo string, results []ScrubImageResult, err error) {
+	status := RepoScrubStatus{
+		Name:         repo,
+		CheckedAt:    time.Now(),
+		ScrubResults: results,
+	}
+
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	report.lock.Lock()
+	defer report.lock.Unlock()
+
+	report.repos[repo] = status
+}
+
+func (report *ScrubReport) Status() ScrubStatus {
+	report.lock.RLock()
+	defer report.lock.RUnlock()
+
+	status := ScrubStatus{Repositories: make([]RepoScrubStatus, 0, len(report.repos))}
+
+	for _, repoStatus := range report.repos {
+		status.Repositories = append(status.Repositories, repoStatus)
+	}
+
+	sort.Slice(status.Repositories, func(i, j int) bool {
+		return status.Repositories[i].Name < status.Repositories[j].Name
+	})
+
+	return status
+}
+
+// PrintScrubStatus prints the results of the repositories as a table, followed by the repositories
+// which couldn't be checked.
+func (status ScrubStatus) PrintScrubStatus(resultWriter io.Writer) {
+	results := ScrubResults{ScrubResults: []ScrubImageResult{}}
+
+	for _, repoStatus := range status.Repositories {
+		results.ScrubResults = append(results.ScrubResults, repoStatus.ScrubResults...)
+	}
+
+	results.PrintScrubResults(resultWriter)
+
+	for _, repoStatus := range status.Repositories {
+		if repoStatus.Error != "" {
+			fmt.Fprintf(resultWriter, "\nfailed to scrub %s at %s: %s\n", repoStatus.Name,
+				repoStatus.CheckedAt.Format(time.RFC3339), repoStatus.Error)
+		}
+	}
+}
+
 func (sc StoreController) CheckAllBlobsIntegrity(ctx context.Context) (ScrubResults, error) {
 	results := ScrubResults{}
 
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -14,10 +15,14 @@ import (
 	zcommon "zotregistry.dev/zot/pkg/common"
 	"zotregistry.dev/zot/pkg/extensions/monitoring"
 	"zotregistry.dev/zot/pkg/log"
+	"zotregistry.dev/zot/pkg/storage/cache"
 	common "zotregistry.dev/zot/pkg/storage/common"
 	"zotregistry.dev/zot/pkg/storage/constants"
+	"zotregistry.dev/zot/pkg/storage/encryption"
+	"zotregistry.dev/zot/pkg/storage/imagestore"
 	"zotregistry.dev/zot/pkg/storage/local"
 	"zotregistry.dev/zot/pkg/storage/s3"
+	"zotregistry.dev/zot/pkg/storage/tiering"
 	storageTypes "zotregistry.dev/zot/pkg/storage/types"
 )
 
@@ -55,10 +60,10 @@ func New(config *config.Config, linter common.Lint, metrics monitoring.MetricSer
 
 		// false positive lint - linter does not implement Lint method
 		//nolint:typecheck,contextcheck
-		rootDir := config.Storage.RootDirectory
-		defaultStore = local.NewImageStore(rootDir,
-			config.Storage.Dedupe, config.Storage.Commit, log, metrics, linter, cacheDriver,
-		)
+		defaultStore, err = newLocalImageStore(config.Storage.StorageConfig, linter, metrics, log, cacheDriver)
+		if err != nil {
+			return storeController, err
+		}
 	} else {
 		storeName := fmt.Sprintf("%v", config.Storage.StorageDriver["name"])
 		if storeName != constants.S3StorageDriverName {
@@ -108,10 +113,75 @@ func New(config *config.Config, linter common.Lint, metrics monitoring.MetricSer
 			}
 
 			storeController.SubStore = subImageStore
+
+			for route, storageConfig := range subPaths {
+				if len(storageConfig.Repositories) == 0 {
+					continue
+				}
+
+				if storeController.SubStoreRepositories == nil {
+					storeController.SubStoreRepositories = make(map[string][]string)
+				}
+
+				storeController.SubStoreRepositories[route] = storageConfig.Repositories
+			}
+		}
+	}
+
+	return trackUsage(storeController), nil
+}
+
+// newLocalImageStore returns an image store on the local storage.
+func newLocalImageStore(storageConfig config.StorageConfig, linter common.Lint, metrics monitoring.MetricServer,
+	log log.Logger, cacheDriver cache.Cache,
+) (storageTypes.ImageStore, error) {
+	rootDir := storageConfig.RootDirectory
+
+	driver, err := NewLocalDriver(storageConfig, metrics, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return imagestore.NewImageStore(rootDir, rootDir, storageConfig.Dedupe, storageConfig.Commit, log, metrics,
+		linter, driver, cacheDriver), nil
+}
+
+// NewLocalDriver returns the driver of a local image store, with a cold tier if tiering is enabled, or encrypting
+// the blobs if encryption is.
+func NewLocalDriver(storageConfig config.StorageConfig, metrics monitoring.MetricServer, log log.Logger,
+) (storageTypes.Driver, error) {
+	rootDir := storageConfig.RootDirectory
+	driver := local.New(storageConfig.Commit)
+
+	if storageConfig.Tiering != nil {
+		return tiering.NewDriver(driver, rootDir, storageConfig.Tiering.RootDirectory, metrics, log), nil
+	}
+
+	if storageConfig.Encryption != nil {
+		var kms encryption.KMS
+
+		if storageConfig.Encryption.KMS != nil {
+			var err error
+
+			kms, err = encryption.NewAWSKMS(storageConfig.Encryption.KMS.Region, storageConfig.Encryption.KMS.Endpoint)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to create KMS client")
+
+				return nil, err
+			}
 		}
+
+		keyring, err := encryption.LoadKeyring(context.Background(), storageConfig.Encryption.Keyring, kms)
+		if err != nil {
+			log.Error().Err(err).Str("keyring", storageConfig.Encryption.Keyring).Msg("failed to load keyring")
+
+			return nil, err
+		}
+
+		return encryption.NewDriver(driver, rootDir, keyring, storageConfig.Encryption.Repositories, log), nil
 	}
 
-	return storeController, nil
+	return driver, nil
 }
 
 func getSubStore(cfg *config.Config, subPaths map[string]config.StorageConfig,
@@ -166,10 +236,12 @@ func getSubStore(cfg *config.Config, subPaths map[string]config.StorageConfig,
 					return nil, err
 				}
 
-				rootDir := storageConfig.RootDirectory
-				imgStoreMap[storageConfig.RootDirectory] = local.NewImageStore(rootDir,
-					storageConfig.Dedupe, storageConfig.Commit, log, metrics, linter, cacheDriver,
-				)
+				imgStore, err := newLocalImageStore(storageConfig, linter, metrics, log, cacheDriver)
+				if err != nil {
+					return nil, err
+				}
+
+				imgStoreMap[storageConfig.RootDirectory] = imgStore
 
 				subImageStore[route] = imgStoreMap[storageConfig.RootDirectory]
 			}
@@ -2,8 +2,10 @@ package storage
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	zcommon "zotregistry.dev/zot/pkg/common"
 	storageTypes "zotregistry.dev/zot/pkg/storage/types"
 )
 
@@ -15,6 +17,10 @@ const (
 type StoreController struct {
 	DefaultStore storageTypes.ImageStore
 	SubStore     map[string]storageTypes.ImageStore
+	// glob patterns of the repositories stored in the substore of each route, besides the ones under the route
+	SubStoreRepositories map[string][]string
+	// the bytes stored in each repository, kept up to date by the image stores of the controllers returned by New()
+	usages *repoUsages
 }
 
 func GetRoutePrefix(name string) string {
@@ -31,19 +37,48 @@ func GetRoutePrefix(name string) string {
 }
 
 func (sc StoreController) GetImageStore(name string) storageTypes.ImageStore {
-	if sc.SubStore != nil {
-		// SubStore is being provided, now we need to find equivalent image store and this will be found by splitting name
-		prefixName := GetRoutePrefix(name)
+	if route, ok := sc.GetSubStoreRoute(name); ok {
+		return sc.SubStore[route]
+	}
+
+	return sc.DefaultStore
+}
+
+// GetSubStoreRoute returns the route of the substore the repository is stored in, or false if it's stored in
+// the default store. The route matching the first component of the name is picked first, then the first one,
+// in alphabetical order, having a repository pattern matching the name.
+func (sc StoreController) GetSubStoreRoute(name string) (string, bool) {
+	if sc.SubStore == nil {
+		return "", false
+	}
+
+	// SubStore is being provided, now we need to find equivalent image store and this will be found by splitting name
+	prefixName := GetRoutePrefix(name)
 
-		imgStore, ok := sc.SubStore[prefixName]
-		if !ok {
-			imgStore = sc.DefaultStore
+	if _, ok := sc.SubStore[prefixName]; ok {
+		return prefixName, true
+	}
+
+	routes := make([]string, 0, len(sc.SubStoreRepositories))
+	for route := range sc.SubStoreRepositories {
+		routes = append(routes, route)
+	}
+
+	sort.Strings(routes)
+
+	repo := strings.SplitN(name, ":", 2)[0] //nolint:gomnd
+
+	for _, route := range routes {
+		if _, ok := sc.SubStore[route]; !ok {
+			continue
 		}
 
-		return imgStore
+		if zcommon.MatchesAnyGlob(sc.SubStoreRepositories[route], repo) {
+			return route, true
+		}
 	}
 
-	return sc.DefaultStore
+	return "", false
 }
 
 func (sc StoreController) GetDefaultImageStore() storageTypes.ImageStore {
@@ -53,3 +88,13 @@ func (sc StoreController) GetDefaultImageStore() storageTypes.ImageStore {
 func (sc StoreController) GetImageSubStores() map[string]storageTypes.ImageStore {
 	return sc.SubStore
 }
+
+// imageStores returns the default store followed by the substores.
+func (sc StoreController) imageStores() []storageTypes.ImageStore {
+	imgStores := []storageTypes.ImageStore{sc.DefaultStore}
+	for _, imgStore := range sc.SubStore {
+		imgStores = append(imgStores, imgStore)
+	}
+
+	return imgStores
+}
@@ -1545,6 +1545,31 @@ func TestStorageHandler(t *testing.T) {
 
 				imgStore = storeController.GetImageStore("c/zot-c-test")
 				So(imgStore.RootDir(), ShouldEqual, firstRootDir)
+
+				// the repositories matching the patterns of a substore are stored in it
+				storeController.SubStoreRepositories = map[string][]string{
+					"/a": {"infra/**"},
+					"/b": {"infra/db/*", "*-cache"},
+				}
+
+				imgStore = storeController.GetImageStore("infra/db/postgres")
+				So(imgStore.RootDir(), ShouldEqual, secondRootDir)
+
+				imgStore = storeController.GetImageStore("build-cache:latest")
+				So(imgStore.RootDir(), ShouldEqual, thirdRootDir)
+
+				imgStore = storeController.GetImageStore("b/infra")
+				So(imgStore.RootDir(), ShouldEqual, thirdRootDir)
+
+				imgStore = storeController.GetImageStore("c/zot-c-test")
+				So(imgStore.RootDir(), ShouldEqual, firstRootDir)
+
+				route, ok := storeController.GetSubStoreRoute("infra/app")
+				So(ok, ShouldBeTrue)
+				So(route, ShouldEqual, "/a")
+
+				_, ok = storeController.GetSubStoreRoute("zot-x-test")
+				So(ok, ShouldBeFalse)
 			})
 		})
 	}
@@ -0,0 +1,279 @@
+package tiering
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	storagedriver "github.com/docker/distribution/registry/storage/driver"
+
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	zlog "zotregistry.dev/zot/pkg/log"
+	common "zotregistry.dev/zot/pkg/storage/common"
+	storageConstants "zotregistry.dev/zot/pkg/storage/constants"
+	storageTypes "zotregistry.dev/zot/pkg/storage/types"
+)
+
+const (
+	HotTier  = "hot"
+	ColdTier = "cold"
+)
+
+// Driver stores the blobs of a local image store in two tiers: its root directory and a cold directory,
+// where the tiering tasks move the layers of the images which are not pulled anymore. The cold directory
+// has the same layout as the root directory, the blobs are moved back to the root directory when they're read.
+type Driver struct {
+	storageTypes.Driver
+	rootDir string
+	coldDir string
+	// the blobs read concurrently are moved back once
+	lock    sync.Mutex
+	metrics monitoring.MetricServer
+	log     zlog.Logger
+}
+
+func NewDriver(driver storageTypes.Driver, rootDir, coldDir string, metrics monitoring.MetricServer,
+	log zlog.Logger,
+) *Driver {
+	return &Driver{
+		Driver:  driver,
+		rootDir: filepath.Clean(rootDir),
+		coldDir: filepath.Clean(coldDir),
+		metrics: metrics,
+		log:     log,
+	}
+}
+
+func (driver *Driver) Reader(path string, offset int64) (io.ReadCloser, error) {
+	if err := driver.restore(path); err != nil {
+		return nil, err
+	}
+
+	return driver.Driver.Reader(path, offset)
+}
+
+func (driver *Driver) ReadFile(path string) ([]byte, error) {
+	if err := driver.restore(path); err != nil {
+		return nil, err
+	}
+
+	return driver.Driver.ReadFile(path)
+}
+
+// Stat also returns the info of the blobs in the cold tier, without moving them back, so checking
+// a blob exists doesn't count as reading it.
+func (driver *Driver) Stat(path string) (storagedriver.FileInfo, error) {
+	fileInfo, err := driver.Driver.Stat(path)
+	if !errors.As(err, &storagedriver.PathNotFoundError{}) || !common.IsBlobPath(path) {
+		return fileInfo, err
+	}
+
+	coldPath, ok := driver.coldPath(path)
+	if !ok {
+		return fileInfo, err
+	}
+
+	coldInfo, coldErr := os.Stat(coldPath)
+	if coldErr != nil {
+		return fileInfo, err
+	}
+
+	return storagedriver.FileInfoInternal{FileInfoFields: storagedriver.FileInfoFields{
+		Path:    path,
+		Size:    coldInfo.Size(),
+		ModTime: coldInfo.ModTime(),
+		IsDir:   coldInfo.IsDir(),
+	}}, nil
+}
+
+// List also returns the blobs of a repository which are in the cold tier, so they're garbage collected
+// along with the other ones.
+func (driver *Driver) List(fullpath string) ([]string, error) {
+	keys, err := driver.Driver.List(fullpath)
+	if err != nil && !errors.As(err, &storagedriver.PathNotFoundError{}) {
+		return keys, err
+	}
+
+	coldPath, ok := driver.coldPath(fullpath)
+	if !ok || path.Base(path.Dir(fullpath)) != "blobs" {
+		return keys, err
+	}
+
+	coldFiles, coldErr := os.ReadDir(coldPath)
+	if coldErr != nil {
+		return keys, err
+	}
+
+	listed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		listed[key] = true
+	}
+
+	for _, coldFile := range coldFiles {
+		if key := path.Join(fullpath, coldFile.Name()); !listed[key] {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// Delete also removes the copy in the cold tier, of a blob or of a whole repository.
+func (driver *Driver) Delete(path string) error {
+	err := driver.Driver.Delete(path)
+	if err != nil && !errors.As(err, &storagedriver.PathNotFoundError{}) {
+		return err
+	}
+
+	coldPath, ok := driver.coldPath(path)
+	if !ok {
+		return err
+	}
+
+	if _, statErr := os.Stat(coldPath); statErr != nil {
+		return err
+	}
+
+	return os.RemoveAll(coldPath)
+}
+
+// Move drops the copy in the cold tier of the blob pushed again.
+func (driver *Driver) Move(sourcePath string, destPath string) error {
+	if err := driver.Driver.Move(sourcePath, destPath); err != nil {
+		return err
+	}
+
+	if coldPath, ok := driver.coldPath(destPath); ok && common.IsBlobPath(destPath) {
+		if err := os.Remove(coldPath); err != nil && !os.IsNotExist(err) {
+			driver.log.Warn().Err(err).Str("blob", coldPath).Msg("failed to remove blob from the cold tier")
+		}
+	}
+
+	return nil
+}
+
+// Link moves the original blob back first, the deduped copies are hard links to it.
+func (driver *Driver) Link(src, dest string) error {
+	if err := driver.restore(src); err != nil {
+		return err
+	}
+
+	return driver.Driver.Link(src, dest)
+}
+
+// restore moves the blob back from the cold tier if it's only there.
+func (driver *Driver) restore(path string) error {
+	if !common.IsBlobPath(path) {
+		return nil
+	}
+
+	coldPath, ok := driver.coldPath(path)
+	if !ok {
+		return nil
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		monitoring.IncStorageTierReads(driver.metrics, HotTier)
+
+		return nil
+	}
+
+	driver.lock.Lock()
+	defer driver.lock.Unlock()
+
+	// moved back meanwhile
+	if _, err := os.Stat(path); err == nil {
+		monitoring.IncStorageTierReads(driver.metrics, HotTier)
+
+		return nil
+	}
+
+	// not in the cold tier either, the driver returns it's not found
+	if _, err := os.Stat(coldPath); err != nil {
+		return nil //nolint:nilerr
+	}
+
+	if err := moveFile(coldPath, path); err != nil {
+		driver.log.Error().Err(err).Str("blob", path).Msg("failed to move blob back from the cold tier")
+
+		return err
+	}
+
+	monitoring.IncStorageTierReads(driver.metrics, ColdTier)
+	monitoring.IncStorageTierMovedBlobs(driver.metrics, HotTier)
+
+	driver.log.Debug().Str("blob", path).Msg("moved blob back from the cold tier")
+
+	return nil
+}
+
+// coldPath returns the path in the cold tier of a path in the root directory.
+func (driver *Driver) coldPath(path string) (string, bool) {
+	rel, err := filepath.Rel(driver.rootDir, filepath.Clean(path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+
+	return filepath.Join(driver.coldDir, rel), true
+}
+
+// moveFile copies the file to the other tier then removes it. The copy is written next to the blobs directory
+// and renamed once complete, so a partial copy is never read, and it keeps the modification time the GC delay
+// applies to.
+func moveFile(src, dest string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), storageConstants.DefaultDirPerms); err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(filepath.Dir(dest)), ".tiering-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	srcFile, err := os.Open(src)
+	if err != nil {
+		tmpFile.Close()
+
+		return err
+	}
+
+	_, err = io.Copy(tmpFile, srcFile)
+	srcFile.Close()
+
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmpFile.Name(), storageConstants.DefaultFilePerms); err != nil {
+		return err
+	}
+
+	if err := os.Chtimes(tmpFile.Name(), info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpFile.Name(), dest); err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
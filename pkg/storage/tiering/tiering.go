@@ -0,0 +1,240 @@
+package tiering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	zlog "zotregistry.dev/zot/pkg/log"
+	mTypes "zotregistry.dev/zot/pkg/meta/types"
+	"zotregistry.dev/zot/pkg/scheduler"
+	common "zotregistry.dev/zot/pkg/storage/common"
+	storageTypes "zotregistry.dev/zot/pkg/storage/types"
+)
+
+const DefaultInterval = 24 * time.Hour
+
+type Options struct {
+	// the cold tier of the image store, its driver must be a Driver with the same directory
+	ColdDir string
+	// the layers of the images not pulled, or pushed, for longer are moved to the cold tier
+	ColdAfter time.Duration
+
+	Metrics monitoring.MetricServer
+}
+
+// Tiering moves the layers of the images of an image store which were not used for a while to its cold tier.
+// The last pulls and pushes of the images are read from metaDB, the layers shared with images still in use,
+// the configs and the manifests stay in the image store.
+type Tiering struct {
+	imgStore storageTypes.ImageStore
+	metaDB   mTypes.MetaDB
+	opts     Options
+	log      zlog.Logger
+}
+
+func NewTiering(imgStore storageTypes.ImageStore, metaDB mTypes.MetaDB, opts Options, log zlog.Logger) Tiering {
+	return Tiering{
+		imgStore: imgStore,
+		metaDB:   metaDB,
+		opts:     opts,
+		log:      log,
+	}
+}
+
+// MoveColdBlobsPeriodically checks the repositories of the image store at each interval.
+func (tiering Tiering) MoveColdBlobsPeriodically(interval time.Duration, sch *scheduler.Scheduler) {
+	generator := &taskGenerator{
+		imgStore: tiering.imgStore,
+		tiering:  tiering,
+	}
+
+	sch.SubmitGenerator(generator, interval, scheduler.LowPriority)
+}
+
+// MoveColdBlobs moves the layers of the images of the repository which weren't used for ColdAfter.
+func (tiering Tiering) MoveColdBlobs(ctx context.Context, repo string) error {
+	repoMeta, err := tiering.metaDB.GetRepoMeta(ctx, repo)
+	if err != nil {
+		if errors.Is(err, zerr.ErrRepoMetaNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	var lockLatency time.Time
+
+	tiering.imgStore.Lock(&lockLatency)
+	defer tiering.imgStore.Unlock(&lockLatency)
+
+	index, err := common.GetIndex(tiering.imgStore, repo, tiering.log)
+	if err != nil {
+		return err
+	}
+
+	usedLayers := map[godigest.Digest]bool{}
+	unusedLayers := map[godigest.Digest]bool{}
+
+	for _, desc := range index.Manifests {
+		layers, err := tiering.getLayers(repo, desc)
+		if err != nil {
+			return err
+		}
+
+		blobs := usedLayers
+		if tiering.isUnused(repoMeta, desc.Digest) {
+			blobs = unusedLayers
+		}
+
+		for _, layer := range layers {
+			blobs[layer] = true
+		}
+	}
+
+	moved := 0
+
+	for digest := range unusedLayers {
+		if usedLayers[digest] {
+			continue
+		}
+
+		blobPath := path.Join(tiering.imgStore.RootDir(), repo, "blobs", digest.Algorithm().String(), digest.Encoded())
+
+		// already in the cold tier or missing
+		if _, err := os.Stat(blobPath); err != nil {
+			continue
+		}
+
+		coldPath := path.Join(tiering.opts.ColdDir, repo, "blobs", digest.Algorithm().String(), digest.Encoded())
+
+		if err := moveFile(blobPath, coldPath); err != nil {
+			tiering.log.Error().Err(err).Str("blob", blobPath).Msg("failed to move blob to the cold tier")
+
+			return err
+		}
+
+		monitoring.IncStorageTierMovedBlobs(tiering.opts.Metrics, ColdTier)
+
+		moved++
+	}
+
+	tiering.log.Info().Str("repository", repo).Int("blobs", moved).Msg("moved unused blobs to the cold tier")
+
+	return nil
+}
+
+// isUnused tells if the image wasn't pulled or pushed for ColdAfter, the images unknown to metaDB are used.
+func (tiering Tiering) isUnused(repoMeta mTypes.RepoMeta, digest godigest.Digest) bool {
+	statistics, ok := repoMeta.Statistics[digest.String()]
+	if !ok {
+		return false
+	}
+
+	lastUse := statistics.PushTimestamp
+	if statistics.LastPullTimestamp.After(lastUse) {
+		lastUse = statistics.LastPullTimestamp
+	}
+
+	return !lastUse.IsZero() && time.Since(lastUse) > tiering.opts.ColdAfter
+}
+
+// getLayers returns the layers of the image, or of all the images of an image index.
+func (tiering Tiering) getLayers(repo string, desc ispec.Descriptor) ([]godigest.Digest, error) {
+	layers := []godigest.Digest{}
+
+	switch desc.MediaType {
+	case ispec.MediaTypeImageManifest:
+		manifest, err := common.GetImageManifest(tiering.imgStore, repo, desc.Digest, tiering.log)
+		if err != nil {
+			return layers, err
+		}
+
+		for _, layer := range manifest.Layers {
+			layers = append(layers, layer.Digest)
+		}
+	case ispec.MediaTypeImageIndex:
+		index, err := common.GetImageIndex(tiering.imgStore, repo, desc.Digest, tiering.log)
+		if err != nil {
+			return layers, err
+		}
+
+		for _, manifest := range index.Manifests {
+			manifestLayers, err := tiering.getLayers(repo, manifest)
+			if err != nil {
+				return layers, err
+			}
+
+			layers = append(layers, manifestLayers...)
+		}
+	}
+
+	return layers, nil
+}
+
+// taskGenerator takes the repositories of the image store one after the other.
+type taskGenerator struct {
+	imgStore storageTypes.ImageStore
+	tiering  Tiering
+	lastRepo string
+	done     bool
+}
+
+func (gen *taskGenerator) Name() string {
+	return "TieringTaskGenerator"
+}
+
+func (gen *taskGenerator) Next() (scheduler.Task, error) {
+	repo, err := gen.imgStore.GetNextRepository(gen.lastRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	if repo == "" {
+		gen.done = true
+
+		return nil, nil
+	}
+
+	gen.lastRepo = repo
+
+	return &tieringTask{tiering: gen.tiering, repo: repo}, nil
+}
+
+func (gen *taskGenerator) IsDone() bool {
+	return gen.done
+}
+
+func (gen *taskGenerator) IsReady() bool {
+	return true
+}
+
+func (gen *taskGenerator) Reset() {
+	gen.lastRepo = ""
+	gen.done = false
+}
+
+type tieringTask struct {
+	tiering Tiering
+	repo    string
+}
+
+func (task *tieringTask) DoWork(ctx context.Context) error {
+	return task.tiering.MoveColdBlobs(ctx, task.repo)
+}
+
+func (task *tieringTask) String() string {
+	return fmt.Sprintf("{Name: %s, repo: %s}", task.Name(), task.repo)
+}
+
+func (task *tieringTask) Name() string {
+	return "TieringTask"
+}
@@ -0,0 +1,155 @@
+package tiering_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	zerr "zotregistry.dev/zot/errors"
+	"zotregistry.dev/zot/pkg/extensions/monitoring"
+	"zotregistry.dev/zot/pkg/log"
+	mTypes "zotregistry.dev/zot/pkg/meta/types"
+	"zotregistry.dev/zot/pkg/storage"
+	"zotregistry.dev/zot/pkg/storage/imagestore"
+	"zotregistry.dev/zot/pkg/storage/local"
+	"zotregistry.dev/zot/pkg/storage/tiering"
+	. "zotregistry.dev/zot/pkg/test/image-utils"
+	"zotregistry.dev/zot/pkg/test/mocks"
+)
+
+func blobPath(rootDir, repo string, digest godigest.Digest) string {
+	return path.Join(rootDir, repo, "blobs", digest.Algorithm().String(), digest.Encoded())
+}
+
+func TestTiering(t *testing.T) {
+	Convey("Unused layers are moved to the cold tier and back when they're read", t, func() {
+		log := log.NewLogger("debug", "")
+		metrics := monitoring.NewMetricsServer(false, log)
+
+		rootDir := t.TempDir()
+		coldDir := t.TempDir()
+
+		driver := tiering.NewDriver(local.New(true), rootDir, coldDir, metrics, log)
+		imgStore := imagestore.NewImageStore(rootDir, rootDir, false, false, log, metrics, nil, driver, nil)
+		storeController := storage.StoreController{DefaultStore: imgStore}
+
+		oldImage := CreateImageWith().RandomLayers(2, 100).DefaultConfig().Build()
+		So(WriteImageToFileSystem(oldImage, "app", "1.0", storeController), ShouldBeNil)
+
+		newImage := CreateRandomImage()
+		So(WriteImageToFileSystem(newImage, "app", "2.0", storeController), ShouldBeNil)
+
+		// a layer of the old image is also in the new one
+		sharedImage := CreateImageWith().LayerBlobs([][]byte{oldImage.Layers[0]}).DefaultConfig().Build()
+		So(WriteImageToFileSystem(sharedImage, "app", "3.0", storeController), ShouldBeNil)
+
+		metaDB := mocks.MetaDBMock{
+			GetRepoMetaFn: func(ctx context.Context, repo string) (mTypes.RepoMeta, error) {
+				if repo != "app" {
+					return mTypes.RepoMeta{}, zerr.ErrRepoMetaNotFound
+				}
+
+				return mTypes.RepoMeta{
+					Name: repo,
+					Statistics: map[mTypes.ImageDigest]mTypes.DescriptorStatistics{
+						oldImage.DigestStr(): {
+							PushTimestamp:     time.Now().Add(-72 * time.Hour),
+							LastPullTimestamp: time.Now().Add(-48 * time.Hour),
+						},
+						newImage.DigestStr(): {
+							PushTimestamp:     time.Now().Add(-72 * time.Hour),
+							LastPullTimestamp: time.Now().Add(-time.Hour),
+						},
+						sharedImage.DigestStr(): {PushTimestamp: time.Now()},
+					},
+				}, nil
+			},
+		}
+
+		tier := tiering.NewTiering(imgStore, metaDB, tiering.Options{
+			ColdDir:   coldDir,
+			ColdAfter: 24 * time.Hour,
+			Metrics:   metrics,
+		}, log)
+
+		err := tier.MoveColdBlobs(context.Background(), "app")
+		So(err, ShouldBeNil)
+
+		sharedLayer := oldImage.Manifest.Layers[0].Digest
+		coldLayers := []godigest.Digest{}
+
+		for _, layer := range oldImage.Manifest.Layers[1:] {
+			coldLayers = append(coldLayers, layer.Digest)
+		}
+
+		So(coldLayers, ShouldNotBeEmpty)
+
+		for _, layer := range coldLayers {
+			_, err = os.Stat(blobPath(rootDir, "app", layer))
+			So(os.IsNotExist(err), ShouldBeTrue)
+
+			_, err = os.Stat(blobPath(coldDir, "app", layer))
+			So(err, ShouldBeNil)
+		}
+
+		// the layers still used, the manifests and the configs stay in the image store
+		for _, digest := range []godigest.Digest{
+			sharedLayer, oldImage.ManifestDescriptor.Digest, oldImage.Manifest.Config.Digest,
+			newImage.Manifest.Layers[0].Digest,
+		} {
+			_, err = os.Stat(blobPath(rootDir, "app", digest))
+			So(err, ShouldBeNil)
+		}
+
+		// the cold blobs are still in the repository
+		blobs, err := imgStore.GetAllBlobs("app")
+		So(err, ShouldBeNil)
+		So(blobs, ShouldContain, coldLayers[0].Encoded())
+
+		ok, size, err := imgStore.CheckBlob("app", coldLayers[0])
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		So(size, ShouldEqual, oldImage.Manifest.Layers[1].Size)
+
+		// checking a blob doesn't move it back
+		_, err = os.Stat(blobPath(coldDir, "app", coldLayers[0]))
+		So(err, ShouldBeNil)
+
+		reader, _, err := imgStore.GetBlob("app", coldLayers[0], "")
+		So(err, ShouldBeNil)
+
+		content, err := io.ReadAll(reader)
+		So(err, ShouldBeNil)
+		So(reader.Close(), ShouldBeNil)
+		So(godigest.FromBytes(content), ShouldEqual, coldLayers[0])
+
+		_, err = os.Stat(blobPath(rootDir, "app", coldLayers[0]))
+		So(err, ShouldBeNil)
+
+		_, err = os.Stat(blobPath(coldDir, "app", coldLayers[0]))
+		So(os.IsNotExist(err), ShouldBeTrue)
+
+		// the repositories unknown to metaDB are skipped
+		So(tier.MoveColdBlobs(context.Background(), "other"), ShouldBeNil)
+
+		Convey("Deleting a repository removes its cold blobs", func() {
+			err := tier.MoveColdBlobs(context.Background(), "app")
+			So(err, ShouldBeNil)
+
+			err = imgStore.DeleteImageManifest("app", "1.0", false)
+			So(err, ShouldBeNil)
+
+			err = driver.Delete(path.Join(rootDir, "app"))
+			So(err, ShouldBeNil)
+
+			_, err = os.Stat(path.Join(coldDir, "app"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+	})
+}
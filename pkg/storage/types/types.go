@@ -65,6 +65,18 @@ type ImageStore interface { //nolint:interfacebloat
 	GetAllBlobs(repo string) ([]string, error)
 	PopulateStorageMetrics(interval time.Duration, sch *scheduler.Scheduler)
 	VerifyBlobDigestValue(repo string, digest godigest.Digest) error
+	CleanupBlobUploads(repo string, timeout time.Duration) (int, error)
+	RunBlobUploadsCleanup(timeout time.Duration, sch *scheduler.Scheduler)
+	ListBlobUploads(repo string) ([]BlobUpload, error)
+	DeleteRepository(repo string) error
+	CheckWritable() error
+}
+
+// BlobUpload is an upload session of a repository which wasn't finished yet.
+type BlobUpload struct {
+	SessionID    string    `json:"sessionID"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
 }
 
 type Driver interface { //nolint:interfacebloat
@@ -10,6 +10,7 @@ import (
 	artifactspec "github.com/oras-project/artifacts-spec/specs-go/v1"
 
 	"zotregistry.dev/zot/pkg/scheduler"
+	storageTypes "zotregistry.dev/zot/pkg/storage/types"
 )
 
 type MockedImageStore struct {
@@ -60,6 +61,11 @@ type MockedImageStore struct {
 	PopulateStorageMetricsFn     func(interval time.Duration, sch *scheduler.Scheduler)
 	StatIndexFn                  func(repo string) (bool, int64, time.Time, error)
 	VerifyBlobDigestValueFn      func(repo string, digest godigest.Digest) error
+	CleanupBlobUploadsFn         func(repo string, timeout time.Duration) (int, error)
+	RunBlobUploadsCleanupFn      func(timeout time.Duration, sch *scheduler.Scheduler)
+	ListBlobUploadsFn            func(repo string) ([]storageTypes.BlobUpload, error)
+	DeleteRepositoryFn           func(repo string) error
+	CheckWritableFn              func() error
 }
 
 func (is MockedImageStore) StatIndex(repo string) (bool, int64, time.Time, error) {
@@ -434,3 +440,41 @@ func (is MockedImageStore) VerifyBlobDigestValue(repo string, digest godigest.Di
 
 	return nil
 }
+
+func (is MockedImageStore) CleanupBlobUploads(repo string, timeout time.Duration) (int, error) {
+	if is.CleanupBlobUploadsFn != nil {
+		return is.CleanupBlobUploadsFn(repo, timeout)
+	}
+
+	return 0, nil
+}
+
+func (is MockedImageStore) RunBlobUploadsCleanup(timeout time.Duration, sch *scheduler.Scheduler) {
+	if is.RunBlobUploadsCleanupFn != nil {
+		is.RunBlobUploadsCleanupFn(timeout, sch)
+	}
+}
+
+func (is MockedImageStore) ListBlobUploads(repo string) ([]storageTypes.BlobUpload, error) {
+	if is.ListBlobUploadsFn != nil {
+		return is.ListBlobUploadsFn(repo)
+	}
+
+	return []storageTypes.BlobUpload{}, nil
+}
+
+func (is MockedImageStore) DeleteRepository(repo string) error {
+	if is.DeleteRepositoryFn != nil {
+		return is.DeleteRepositoryFn(repo)
+	}
+
+	return nil
+}
+
+func (is MockedImageStore) CheckWritable() error {
+	if is.CheckWritableFn != nil {
+		return is.CheckWritableFn()
+	}
+
+	return nil
+}
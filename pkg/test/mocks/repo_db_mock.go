@@ -14,6 +14,10 @@ type MetaDBMock struct {
 
 	GetRepoLastUpdatedFn func(repo string) time.Time
 
+	AddRepoEventFn func(repo string, event mTypes.RepoEvent, maxEvents int, maxAge time.Duration) error
+
+	GetRepoEventsFn func(ctx context.Context, repo string) ([]mTypes.RepoEvent, error)
+
 	GetStarredReposFn func(ctx context.Context) ([]string, error)
 
 	GetBookmarkedReposFn func(ctx context.Context) ([]string, error)
@@ -46,6 +50,8 @@ type MetaDBMock struct {
 
 	PatchDBFn func() error
 
+	PingFn func(ctx context.Context) error
+
 	ImageTrustStoreFn func() mTypes.ImageTrustStore
 
 	SetImageTrustStoreFn func(mTypes.ImageTrustStore)
@@ -129,6 +135,23 @@ func (sdm MetaDBMock) GetRepoLastUpdated(repo string) time.Time {
 	return time.Time{}
 }
 
+func (sdm MetaDBMock) AddRepoEvent(repo string, event mTypes.RepoEvent, maxEvents int, maxAge time.Duration,
+) error {
+	if sdm.AddRepoEventFn != nil {
+		return sdm.AddRepoEventFn(repo, event, maxEvents, maxAge)
+	}
+
+	return nil
+}
+
+func (sdm MetaDBMock) GetRepoEvents(ctx context.Context, repo string) ([]mTypes.RepoEvent, error) {
+	if sdm.GetRepoEventsFn != nil {
+		return sdm.GetRepoEventsFn(ctx, repo)
+	}
+
+	return []mTypes.RepoEvent{}, nil
+}
+
 func (sdm MetaDBMock) ResetDB() error {
 	if sdm.ResetDBFn != nil {
 		return sdm.ResetDBFn()
@@ -137,6 +160,14 @@ func (sdm MetaDBMock) ResetDB() error {
 	return nil
 }
 
+func (sdm MetaDBMock) Ping(ctx context.Context) error {
+	if sdm.PingFn != nil {
+		return sdm.PingFn(ctx)
+	}
+
+	return nil
+}
+
 func (sdm MetaDBMock) ImageTrustStore() mTypes.ImageTrustStore {
 	if sdm.ImageTrustStoreFn != nil {
 		return sdm.ImageTrustStoreFn()